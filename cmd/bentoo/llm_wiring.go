@@ -1,8 +1,11 @@
 package main
 
 import (
+	"time"
+
 	"github.com/obentoo/bentoolkit/internal/autoupdate"
 	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
 )
 
 // llmConfigToAutoupdate converts the CLI-facing LLM config (config.LLMConfig)
@@ -12,10 +15,11 @@ import (
 // which keeps the config and autoupdate packages free of a mutual import
 // dependency.
 //
-// Every CLI-reachable field is carried across. BaseURL is intentionally NOT
-// mapped: it exists only on autoupdate.LLMConfig and is populated internally
-// for HTTP providers (e.g. the Claude endpoint), with no config-side source.
-// A field-parity test guards against future config drift (R-config-drift).
+// Every CLI-reachable field is carried across, BaseURL included: it points the
+// openai provider at any OpenAI-compatible server (and ollama or gemini at a
+// non-default host); the claude provider ignores it in favour of
+// CLAUDE_API_ENDPOINT. A field-parity test guards against future config drift
+// (R-config-drift).
 func llmConfigToAutoupdate(c config.LLMConfig) autoupdate.LLMConfig {
 	return autoupdate.LLMConfig{
 		Provider:     c.Provider,
@@ -23,6 +27,7 @@ func llmConfigToAutoupdate(c config.LLMConfig) autoupdate.LLMConfig {
 		Model:        c.Model,
 		Bare:         c.Bare,
 		MaxBudgetUSD: c.MaxBudgetUSD,
+		BaseURL:      c.BaseURL,
 	}
 }
 
@@ -44,6 +49,43 @@ func newConfiguredLLMProvider(c config.LLMConfig) (autoupdate.LLMProvider, error
 	return autoupdate.NewLLMProvider(llmConfigToAutoupdate(c))
 }
 
+// newConfiguredAnalyzerLLM builds the analyzer's LLM provider from the CLI
+// config: the llm provider alone, or, with autoupdate.llm_fallbacks set, an
+// autoupdate.MultiLLMClient trying llm and then each fallback in order, so a
+// rate-limited or unavailable primary does not send analysis back to the
+// heuristics. It keeps newConfiguredLLMProvider's contract: (nil, nil) when no
+// provider is configured, and an error when any provider of the chain cannot
+// be built.
+func newConfiguredAnalyzerLLM(c config.AutoupdateConfig) (autoupdate.LLMProvider, error) {
+	if c.LLM.Provider == "" || len(c.LLMFallbacks) == 0 {
+		return newConfiguredLLMProvider(c.LLM)
+	}
+	cfgs := make([]autoupdate.LLMConfig, 0, 1+len(c.LLMFallbacks))
+	cfgs = append(cfgs, llmConfigToAutoupdate(c.LLM))
+	for _, fallback := range c.LLMFallbacks {
+		cfgs = append(cfgs, llmConfigToAutoupdate(fallback))
+	}
+	return autoupdate.NewMultiLLMClient(cfgs)
+}
+
+// newConfiguredLLMCache opens the LLM answer cache in configDir, with the TTL
+// from autoupdate.llm_cache_ttl, for a run with an LLM provider configured.
+// It returns nil, which disables the cache, when no provider is configured or
+// the cache cannot be opened; the latter is a Warn, since the run still works
+// and only pays for every call.
+func newConfiguredLLMCache(configDir string, c config.AutoupdateConfig) *autoupdate.LLMCache {
+	if c.LLM.Provider == "" {
+		return nil
+	}
+	ttl := time.Duration(c.GetLLMCacheTTL()) * time.Second
+	cache, err := autoupdate.NewLLMCache(configDir, autoupdate.WithLLMCacheTTL(ttl))
+	if err != nil {
+		logger.Warn("LLM answer cache unavailable; every LLM call will reach the provider: %v", err)
+		return nil
+	}
+	return cache
+}
+
 // newConfiguredManifestFixer builds an LLM manifest fixer from the CLI config for
 // the --apply path. The agentic fixer edits ebuild files and runs pkgdev, which
 // only the local claude-code CLI agent can do — so it is wired ONLY for
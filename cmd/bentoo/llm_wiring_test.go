@@ -10,8 +10,7 @@ import (
 )
 
 // TestLLMConfigToAutoupdate_CarriesAllFields verifies the mapper copies every
-// CLI-reachable field from config.LLMConfig onto autoupdate.LLMConfig, and that
-// BaseURL (which has no config-side source) is left empty.
+// CLI-reachable field from config.LLMConfig onto autoupdate.LLMConfig.
 // _Requirements: R8.2_
 func TestLLMConfigToAutoupdate_CarriesAllFields(t *testing.T) {
 	src := config.LLMConfig{
@@ -20,6 +19,7 @@ func TestLLMConfigToAutoupdate_CarriesAllFields(t *testing.T) {
 		Model:        "claude-3-haiku-20240307",
 		Bare:         "true",
 		MaxBudgetUSD: 12.5,
+		BaseURL:      "http://localhost:8080/v1",
 	}
 
 	got := llmConfigToAutoupdate(src)
@@ -39,26 +39,22 @@ func TestLLMConfigToAutoupdate_CarriesAllFields(t *testing.T) {
 	if got.MaxBudgetUSD != src.MaxBudgetUSD {
 		t.Errorf("MaxBudgetUSD = %v, want %v", got.MaxBudgetUSD, src.MaxBudgetUSD)
 	}
-
-	// BaseURL has no config-side source and must remain empty (intentionally unmapped).
-	if got.BaseURL != "" {
-		t.Errorf("BaseURL = %q, want empty (intentionally unmapped)", got.BaseURL)
+	if got.BaseURL != src.BaseURL {
+		t.Errorf("BaseURL = %q, want %q", got.BaseURL, src.BaseURL)
 	}
 }
 
 // TestLLMConfigToAutoupdate_FieldParity guards against config drift (R-config-drift).
 // It asserts that EVERY field on autoupdate.LLMConfig is either carried by the
 // mapper (non-zero after mapping a fully-populated source) or is on a documented
-// allow-list of intentionally-unmapped fields (currently only BaseURL).
+// allow-list of intentionally-unmapped fields (currently none).
 //
 // If someone adds a new field to autoupdate.LLMConfig that has a config-side
 // counterpart but forgets to wire it through the mapper, this test fails — the
 // new field stays zero and is not on the allow-list.
 func TestLLMConfigToAutoupdate_FieldParity(t *testing.T) {
 	// Fields on autoupdate.LLMConfig that intentionally have NO config source.
-	intentionallyUnmapped := map[string]bool{
-		"BaseURL": true, // set internally for HTTP providers; no config field
-	}
+	intentionallyUnmapped := map[string]bool{}
 
 	// Build a source with every config-side field set to a distinctive non-zero value.
 	src := config.LLMConfig{
@@ -67,6 +63,7 @@ func TestLLMConfigToAutoupdate_FieldParity(t *testing.T) {
 		Model:        "claude-3-haiku-20240307",
 		Bare:         "true",
 		MaxBudgetUSD: 12.5,
+		BaseURL:      "http://localhost:8080/v1",
 	}
 
 	got := llmConfigToAutoupdate(src)
@@ -182,3 +179,42 @@ func TestNewConfiguredLLMProvider(t *testing.T) {
 		})
 	}
 }
+
+// TestNewConfiguredAnalyzerLLM verifies autoupdate.llm_fallbacks turns the
+// analyzer's provider into a chain trying llm first, and that a fallback which
+// cannot be built fails the chain like a broken primary does.
+func TestNewConfiguredAnalyzerLLM(t *testing.T) {
+	primary := config.LLMConfig{Provider: "ollama", Model: "llama3"}
+
+	p, err := newConfiguredAnalyzerLLM(config.AutoupdateConfig{LLM: primary})
+	if err != nil {
+		t.Fatalf("newConfiguredAnalyzerLLM(no fallbacks): %v", err)
+	}
+	if _, ok := p.(*autoupdate.MultiLLMClient); ok || p == nil {
+		t.Errorf("newConfiguredAnalyzerLLM(no fallbacks) = %T, want the single provider", p)
+	}
+
+	p, err = newConfiguredAnalyzerLLM(config.AutoupdateConfig{
+		LLM:          primary,
+		LLMFallbacks: []config.LLMConfig{{Provider: "ollama", Model: "qwen2"}},
+	})
+	if err != nil {
+		t.Fatalf("newConfiguredAnalyzerLLM(fallbacks): %v", err)
+	}
+	if chain, ok := p.(*autoupdate.MultiLLMClient); !ok || chain.GetModel() != "llama3,qwen2" {
+		t.Errorf("newConfiguredAnalyzerLLM(fallbacks) = %#v, want the llama3,qwen2 chain", p)
+	}
+
+	_, err = newConfiguredAnalyzerLLM(config.AutoupdateConfig{
+		LLM:          primary,
+		LLMFallbacks: []config.LLMConfig{{Provider: "bogus"}},
+	})
+	if !errors.Is(err, autoupdate.ErrLLMUnsupportedProvider) {
+		t.Errorf("newConfiguredAnalyzerLLM(bogus fallback) error = %v, want %v", err, autoupdate.ErrLLMUnsupportedProvider)
+	}
+
+	p, err = newConfiguredAnalyzerLLM(config.AutoupdateConfig{LLMFallbacks: []config.LLMConfig{primary}})
+	if err != nil || p != nil {
+		t.Errorf("newConfiguredAnalyzerLLM(fallbacks, no llm) = %v, %v; want nil, nil", p, err)
+	}
+}
@@ -27,6 +27,16 @@ var (
 	analyzeForce bool
 	// analyzeDryRun shows schema without saving
 	analyzeDryRun bool
+	// analyzeInteractive reviews the suggested schema: accept, edit or reject
+	analyzeInteractive bool
+	// analyzeEstimate reports how many packages would need the LLM without
+	// analyzing anything (requires --all)
+	analyzeEstimate bool
+	// analyzeConcurrency bounds the packages --all analyzes at once
+	analyzeConcurrency int
+	// analyzeTokenFile names a file holding the GitHub token; it takes
+	// precedence over autoupdate.github_token_file
+	analyzeTokenFile string
 )
 
 var analyzeCmd = &cobra.Command{
@@ -43,9 +53,12 @@ Examples:
   bentoo overlay analyze net-misc/foo --url URL Override URL for analysis
   bentoo overlay analyze net-misc/foo --hint "version is in header"
   bentoo overlay analyze --all                  Analyze all packages without schema
+  bentoo overlay analyze --all --estimate       Estimate LLM usage without analyzing
+  bentoo overlay analyze --all --concurrency 8  Analyze up to 8 packages at once
   bentoo overlay analyze net-misc/foo --no-cache  Bypass caches
   bentoo overlay analyze net-misc/foo --force   Overwrite existing schema
-  bentoo overlay analyze net-misc/foo --dry-run Show schema without saving`,
+  bentoo overlay analyze net-misc/foo --dry-run Show schema without saving
+  bentoo overlay analyze net-misc/foo --interactive  Accept, edit or reject the suggestion`,
 	Run: runAnalyze,
 }
 
@@ -55,7 +68,12 @@ func init() {
 	analyzeCmd.Flags().BoolVar(&analyzeAll, "all", false, "Analyze all packages without schema")
 	analyzeCmd.Flags().BoolVar(&analyzeNoCache, "no-cache", false, "Bypass all caches")
 	analyzeCmd.Flags().BoolVar(&analyzeForce, "force", false, "Overwrite existing schema")
-	analyzeCmd.Flags().BoolVar(&analyzeDryRun, "dry-run", false, "Show schema without saving")
+	analyzeCmd.Flags().BoolVar(&analyzeDryRun, "dry-run", false, "Show schema without saving it or caching the analysis")
+	analyzeCmd.Flags().BoolVarP(&analyzeInteractive, "interactive", "i", false, "Review the suggested schema with a sample of the source, then accept, edit or reject it")
+	analyzeCmd.Flags().BoolVar(&analyzeEstimate, "estimate", false, "With --all, estimate LLM usage from discovery only")
+	analyzeCmd.Flags().IntVar(&analyzeConcurrency, "concurrency", autoupdate.DefaultAnalyzerConcurrency, "With --all, max packages analyzed at once (values below 1 mean 1)")
+
+	analyzeCmd.Flags().StringVar(&analyzeTokenFile, "token-file", "", "Read the GitHub API token from this file (default: autoupdate.github_token_file, then GITHUB_TOKEN/GH_TOKEN)")
 
 	overlayCmd.AddCommand(analyzeCmd)
 }
@@ -87,13 +105,25 @@ func runAnalyze(cmd *cobra.Command, args []string) {
 	// provider is configured but cannot be constructed (e.g. the `claude` CLI is
 	// absent or not authenticated), we log a Warn and fall back to the heuristic
 	// analyzer rather than failing — analysis still proceeds (R4.2, R6.1, R6.2).
-	analyzerOpts := []autoupdate.AnalyzerOption{autoupdate.WithAnalyzerConfigDir(configDir)}
+	// --dry-run is read-only throughout: neither packages.toml nor the
+	// analysis cache is written.
+	analyzerOpts := []autoupdate.AnalyzerOption{
+		autoupdate.WithAnalyzerConfigDir(configDir),
+		autoupdate.WithAnalyzerReadOnly(analyzeDryRun),
+		autoupdate.WithMaxConcurrency(analyzeConcurrency),
+		autoupdate.WithAnalyzerGitHubTokenFile(githubTokenFile(analyzeTokenFile, ctx.Config)),
+		autoupdate.WithAnalyzerGiteaHosts(ctx.Config.Autoupdate.GiteaHosts),
+	}
 	llmCfg := ctx.Config.Autoupdate.LLM
-	if p, err := newConfiguredLLMProvider(llmCfg); err != nil {
+	if p, err := newConfiguredAnalyzerLLM(ctx.Config.Autoupdate); err != nil {
 		logger.Warn("LLM provider %q unavailable; falling back to heuristic analysis: %v", llmCfg.Provider, err)
 	} else if p != nil {
 		analyzerOpts = append(analyzerOpts, autoupdate.WithAnalyzerLLMClient(p))
 	}
+	// Per-package llm overrides in packages.toml apply on top of the global config.
+	analyzerOpts = append(analyzerOpts, autoupdate.WithAnalyzerLLMConfig(llmConfigToAutoupdate(llmCfg)))
+	// Answer analyses of unchanged pages from the previous runs.
+	analyzerOpts = append(analyzerOpts, autoupdate.WithAnalyzerLLMCache(newConfiguredLLMCache(configDir, ctx.Config.Autoupdate)))
 
 	// Create analyzer
 	analyzer, err := autoupdate.NewAnalyzer(overlayPath, analyzerOpts...)
@@ -110,7 +140,22 @@ func runAnalyze(cmd *cobra.Command, args []string) {
 		DryRun:  analyzeDryRun,
 	}
 
+	if analyzeInteractive && (analyzeAll || analyzeDryRun) {
+		logger.Error("--interactive works on a single package and cannot be combined with --all or --dry-run")
+		osExit(1)
+		return
+	}
+
 	// Handle different modes
+	if analyzeEstimate {
+		if !analyzeAll {
+			logger.Error("--estimate requires --all")
+			osExit(1)
+			return
+		}
+		runAnalyzeEstimate(analyzer, opts)
+		return
+	}
 	if analyzeAll {
 		runAnalyzeAll(analyzer, opts)
 	} else {
@@ -129,12 +174,21 @@ func runAnalyzeSingle(analyzer *autoupdate.Analyzer, pkg string, opts autoupdate
 	}
 
 	displayAnalyzeResult(result)
+	printLLMUsage(analyzer.LLMUsage())
 
 	// If dry-run, don't save
 	if opts.DryRun {
 		return
 	}
 
+	if analyzeInteractive {
+		if _, err := reviewAnalyzeResult(analyzer, result, os.Stdin, editSchemaInEditor); err != nil {
+			logger.Error("failed to record review: %v", err)
+			osExit(1)
+		}
+		return
+	}
+
 	// If schema was generated, ask for confirmation and save
 	if result.SuggestedSchema != nil {
 		if !result.Validated {
@@ -156,6 +210,46 @@ func runAnalyzeSingle(analyzer *autoupdate.Analyzer, pkg string, opts autoupdate
 	}
 }
 
+// runAnalyzeEstimate reports, from data source discovery alone, how many
+// packages without a schema would fall through to the LLM during --all and the
+// approximate token volume that represents. Nothing is fetched or analyzed.
+func runAnalyzeEstimate(analyzer *autoupdate.Analyzer, opts autoupdate.AnalyzeOptions) {
+	est, err := analyzer.EstimateAnalysis(opts)
+	if err != nil {
+		logger.Error("%v", err)
+		osExit(1)
+		return
+	}
+
+	output.Header.Println("Analysis Estimate")
+	fmt.Printf("  Packages without schema: %d\n", est.Total())
+	output.Success.Printf("  Structured source (no LLM): %d\n", len(est.Structured))
+	if len(est.Cached) > 0 {
+		output.Success.Printf("  Cached analysis (no LLM):   %d\n", len(est.Cached))
+	}
+	output.Warning.Printf("  Needs LLM:                  %d\n", len(est.LLM))
+	if len(est.NoSource) > 0 {
+		output.Error.Printf("  No data source:             %d\n", len(est.NoSource))
+	}
+	if len(est.Failures) > 0 {
+		output.Error.Printf("  Unreadable metadata:        %d\n", len(est.Failures))
+	}
+	for _, pkg := range est.LLM {
+		output.Dim.Printf("    %s\n", pkg)
+	}
+	fmt.Printf("  Estimated LLM tokens: ~%d input, ~%d output\n", est.EstimatedInputTokens, est.EstimatedOutputTokens)
+}
+
+// printLLMUsage reports the tokens the LLM providers used, next to the
+// --estimate figures, so the cost of a run can be checked. Nothing is printed
+// when no LLM was called or its provider does not report usage.
+func printLLMUsage(usage autoupdate.TokenUsage) {
+	if usage.Total() == 0 {
+		return
+	}
+	fmt.Printf("\nLLM tokens used: %d input, %d output\n", usage.InputTokens, usage.OutputTokens)
+}
+
 // runAnalyzeAll handles batch analysis of all packages
 func runAnalyzeAll(analyzer *autoupdate.Analyzer, opts autoupdate.AnalyzeOptions) {
 	output.Info.Println("Analyzing all packages without schema...")
@@ -178,6 +272,7 @@ func runAnalyzeAll(analyzer *autoupdate.Analyzer, opts autoupdate.AnalyzeOptions
 	}
 
 	displayBatchResults(result.Items)
+	printLLMUsage(analyzer.LLMUsage())
 
 	// If dry-run, don't save; still report the batch outcome.
 	if opts.DryRun {
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+// samplePreviewLines caps how much of the fetched sample --interactive shows.
+const samplePreviewLines = 15
+
+// schemaEditor edits a schema and returns the result. The default opens the
+// schema as TOML in $EDITOR; tests substitute a function.
+type schemaEditor func(schema *autoupdate.PackageConfig) (*autoupdate.PackageConfig, error)
+
+// reviewAnalyzeResult is the --interactive loop for a single analysis. It
+// shows a preview of the fetched sample, then asks to accept the schema,
+// edit it (re-validated against the sample after every edit) or reject it.
+// It returns true when the schema was saved.
+func reviewAnalyzeResult(analyzer *autoupdate.Analyzer, result *autoupdate.AnalyzeResult, in io.Reader, edit schemaEditor) (bool, error) {
+	if result.SuggestedSchema == nil {
+		return false, nil
+	}
+	displaySamplePreview(result.SampleContent)
+
+	reader := bufio.NewReader(in)
+	schema := result.SuggestedSchema
+	for {
+		fmt.Print("\n[a]ccept, [e]dit, [r]eject? ")
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			// EOF without an answer: treat as reject, never save unasked.
+			return false, analyzer.RejectSchema(result.Package)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "a", "accept":
+			if err := analyzer.AcceptSchema(result.Package, schema); err != nil {
+				return false, err
+			}
+			output.Success.Println("✓ Schema saved to packages.toml")
+			return true, nil
+		case "r", "reject":
+			output.Info.Println("Schema rejected; packages.toml unchanged")
+			return false, analyzer.RejectSchema(result.Package)
+		case "e", "edit":
+			edited, err := edit(schema)
+			if err != nil {
+				output.Error.Printf("Edit failed: %v\n", err)
+				continue
+			}
+			schema = edited
+			displaySchema(schema)
+			preview, err := analyzer.PreviewSchema(result, schema)
+			switch {
+			case err != nil:
+				output.Error.Printf("  ✗ %v\n", err)
+			case preview.Valid:
+				output.Success.Printf("  ✓ Validated: extracted version %s matches ebuild\n", preview.ExtractedVersion)
+			case preview.ExtractedVersion != "":
+				output.Warning.Printf("  ⚠ Version mismatch: extracted %s, ebuild %s\n",
+					preview.ExtractedVersion, preview.EbuildVersion)
+			default:
+				output.Warning.Printf("  ⚠ No version extracted: %v\n", preview.Error)
+			}
+		}
+	}
+}
+
+// displaySamplePreview prints the first lines of the content the suggested
+// schema was validated against.
+func displaySamplePreview(sample []byte) {
+	if len(sample) == 0 {
+		return
+	}
+	fmt.Println()
+	output.Header.Println("Sample Content")
+	fmt.Println()
+	lines := strings.Split(strings.TrimSpace(string(sample)), "\n")
+	for i, line := range lines {
+		if i == samplePreviewLines {
+			output.Dim.Printf("  ... (%d more lines)\n", len(lines)-i)
+			break
+		}
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// editSchemaInEditor writes schema to a temporary TOML file, opens it in
+// $EDITOR (vi when unset) and decodes the saved result.
+func editSchemaInEditor(schema *autoupdate.PackageConfig) (*autoupdate.PackageConfig, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(schema); err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	f, err := os.CreateTemp("", "bentoo-schema-*.toml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close() //nolint:errcheck
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, f.Name()) //nolint:gosec // the user's own $EDITOR
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", editor, err)
+	}
+
+	var edited autoupdate.PackageConfig
+	if _, err := toml.DecodeFile(f.Name(), &edited); err != nil {
+		return nil, fmt.Errorf("failed to parse edited schema: %w", err)
+	}
+	return &edited, nil
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
 )
 
 // TestAnalyzeCmd_HasRunFunction verifies that the analyze command has a Run or RunE function set.
@@ -24,6 +28,8 @@ func TestAnalyzeCmd_RequiredFlags(t *testing.T) {
 		{"no-cache", "bool"},
 		{"force", "bool"},
 		{"dry-run", "bool"},
+		{"estimate", "bool"},
+		{"interactive", "bool"},
 	}
 
 	for _, rf := range requiredFlags {
@@ -48,7 +54,7 @@ func TestAnalyzeCmd_CommandUse(t *testing.T) {
 
 // TestAnalyzeCmd_BoolFlagDefaults verifies that boolean flags default to false.
 func TestAnalyzeCmd_BoolFlagDefaults(t *testing.T) {
-	boolFlags := []string{"all", "no-cache", "force", "dry-run"}
+	boolFlags := []string{"all", "no-cache", "force", "dry-run", "estimate", "interactive"}
 	for _, name := range boolFlags {
 		t.Run(name, func(t *testing.T) {
 			flag := analyzeCmd.Flags().Lookup(name)
@@ -135,3 +141,55 @@ func TestRunAnalyze_OverlayPathBoundsCheck(t *testing.T) {
 		})
 	}
 }
+
+// TestReviewAnalyzeResult drives the --interactive loop: an edit followed by
+// accept saves the edited schema, a reject saves nothing.
+func TestReviewAnalyzeResult(t *testing.T) {
+	newResult := func() *autoupdate.AnalyzeResult {
+		return &autoupdate.AnalyzeResult{
+			Package:         "app-misc/test",
+			SuggestedSchema: &autoupdate.PackageConfig{URL: "https://example.com/api", Parser: "json", Path: "latest"},
+			SampleContent:   []byte(`{"latest":"2.0.0-beta","stable":"1.0.0"}`),
+			EbuildVersion:   "1.0.0",
+		}
+	}
+	edit := func(schema *autoupdate.PackageConfig) (*autoupdate.PackageConfig, error) {
+		edited := *schema
+		edited.Path = "stable"
+		return &edited, nil
+	}
+
+	t.Run("edit then accept", func(t *testing.T) {
+		overlay := t.TempDir()
+		analyzer, err := autoupdate.NewAnalyzer(overlay, autoupdate.WithAnalyzerConfigDir(t.TempDir()))
+		if err != nil {
+			t.Fatalf("NewAnalyzer: %v", err)
+		}
+		saved, err := reviewAnalyzeResult(analyzer, newResult(), strings.NewReader("e\na\n"), edit)
+		if err != nil || !saved {
+			t.Fatalf("reviewAnalyzeResult = %v, %v; want saved", saved, err)
+		}
+		cfg, err := autoupdate.LoadPackagesConfig(overlay)
+		if err != nil {
+			t.Fatalf("LoadPackagesConfig: %v", err)
+		}
+		if got := cfg.Packages["app-misc/test"].Path; got != "stable" {
+			t.Errorf("saved path = %q, want the edited %q", got, "stable")
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		overlay := t.TempDir()
+		analyzer, err := autoupdate.NewAnalyzer(overlay, autoupdate.WithAnalyzerConfigDir(t.TempDir()))
+		if err != nil {
+			t.Fatalf("NewAnalyzer: %v", err)
+		}
+		saved, err := reviewAnalyzeResult(analyzer, newResult(), strings.NewReader("r\n"), edit)
+		if err != nil || saved {
+			t.Fatalf("reviewAnalyzeResult = %v, %v; want not saved", saved, err)
+		}
+		if _, err := os.Stat(filepath.Join(overlay, ".autoupdate", "packages.toml")); !os.IsNotExist(err) {
+			t.Errorf("packages.toml written on reject: %v", err)
+		}
+	})
+}
@@ -41,6 +41,9 @@ var (
 	// autoupdateClean removes the old ebuild after a successful apply, keeping
 	// only the newly created version
 	autoupdateClean bool
+	// autoupdateQA runs pkgcheck on each applied package and reverts the apply
+	// when it reports errors
+	autoupdateQA bool
 	// autoupdateConcurrency bounds parallel version checks and the --apply all
 	// worker pool (range [1,100])
 	autoupdateConcurrency int
@@ -63,6 +66,78 @@ var (
 	// rate-limited output instead. It is one of the gate's opt-outs (alongside
 	// NO_COLOR and BENTOO_NO_TUI); see tuiEnabledForApply (R2.1, R2.2).
 	autoupdateNoTUI bool
+	// autoupdateRevert undoes the last committed update of a "category/pkg",
+	// restoring its previous ebuilds and marking the update pending again
+	autoupdateRevert string
+	// autoupdateValidate runs pkgcheck on a pending update of a
+	// "category/pkg" and marks it validated or failed
+	autoupdateValidate string
+	// autoupdateMine restricts --check to packages whose metadata.xml lists
+	// this maintainer email
+	autoupdateMine string
+	// autoupdateStale makes --check list the outdated packages ranked by how
+	// far behind upstream they are instead of the usual result table
+	autoupdateStale bool
+	// autoupdateFormat is a text/template rendered per --check result in place
+	// of the result table
+	autoupdateFormat string
+	// autoupdateProbeSrc makes --check HEAD each package's current SRC_URI and
+	// warn when its host is unreachable
+	autoupdateProbeSrc bool
+	// autoupdateInstalled makes --check read each package's installed version
+	// from the Portage vdb and warn when it is newer than the overlay's
+	autoupdateInstalled bool
+	// autoupdateQuarantineAfter is the number of consecutive failed --check
+	// runs after which a package is quarantined (0 disables)
+	autoupdateQuarantineAfter int
+	// autoupdateClearQuarantine returns a quarantined "category/pkg", or "all",
+	// to --check
+	autoupdateClearQuarantine string
+	// autoupdateReport makes --check emit the consolidated CI report in this
+	// format ("json" or "markdown") instead of the result table
+	autoupdateReport string
+	// autoupdateJSON makes --check print the results as a JSON array
+	// (autoupdate.WriteResultsJSON) instead of the table
+	autoupdateJSON bool
+	// autoupdateHistory makes --check append each package's outcome to
+	// history.jsonl in the config directory
+	autoupdateHistory bool
+	// autoupdateParserHelp prints the packages.toml parser reference
+	autoupdateParserHelp bool
+	// autoupdateDryRun makes --check read-only: sources are fetched and
+	// updates reported, but no cache, pending, history or packages.toml write
+	// is made. With --apply it previews each update instead of applying it.
+	autoupdateDryRun bool
+	// autoupdateStatus restricts --apply all to the pending entries with
+	// these comma-separated statuses (default: pending,validated), and
+	// --export likewise (default: every entry)
+	autoupdateStatus string
+	// autoupdatePrefetch fetches every package's source into the content
+	// cache without checking, to warm it ahead of a scheduled --check
+	autoupdatePrefetch bool
+	// autoupdateCacheStats prints the version cache statistics; with --check
+	// they are printed after the run, hits and misses included
+	autoupdateCacheStats bool
+	// autoupdateCachePrune drops the version cache entries past their TTL
+	autoupdateCachePrune bool
+	// autoupdateOverlay restricts the run to one configured overlay, by path
+	// or directory name; unset, --check covers every overlay and the other
+	// modes act on the primary one
+	autoupdateOverlay string
+	// autoupdateSort orders the --list and --export output: "package"
+	// (default) or "detected"
+	autoupdateSort string
+	// autoupdateSince limits the --list and --export output to the entries
+	// detected within this age ("7d", "36h")
+	autoupdateSince string
+	// autoupdateExport writes the pending list as a report in this format
+	// ("csv" or "json") instead of listing it
+	autoupdateExport string
+	// autoupdateExportFile is where --export writes; empty or "-" is stdout
+	autoupdateExportFile string
+	// autoupdateTokenFile names a file holding the GitHub token; it takes
+	// precedence over autoupdate.github_token_file
+	autoupdateTokenFile string
 )
 
 var autoupdateCmd = &cobra.Command{
@@ -74,13 +149,32 @@ Examples:
   bentoo overlay autoupdate --check              Check all packages for updates
   bentoo overlay autoupdate --check net-misc/foo Check specific package
   bentoo overlay autoupdate --check --force      Check ignoring cache
+  bentoo overlay autoupdate --check --overlay extra Check only the configured overlay named extra
+  bentoo overlay autoupdate --check --dry-run    Check without writing cache, pending or packages.toml
   bentoo overlay autoupdate --check --only source Check only source packages
   bentoo overlay autoupdate --check --only bin    Check only binary packages
+  bentoo overlay autoupdate --check --mine me@example.com Check only packages I maintain
+  bentoo overlay autoupdate --check --stale      List outdated packages, most behind first
+  bentoo overlay autoupdate --check --format '{{.Package}} {{.UpstreamVersion}}' Script-friendly output
+  bentoo overlay autoupdate --check --report markdown CI summary: behind, coverage, health
+  bentoo overlay autoupdate --check --json       Results as JSON (jq 'any(.has_update)')
+  bentoo overlay autoupdate --prefetch           Fetch every source ahead of a scheduled --check
+  bentoo overlay autoupdate --cache-stats        Show the version cache's size and age
+  bentoo overlay autoupdate --check --cache-stats Check, then show cache hits and misses
+  bentoo overlay autoupdate --cache-prune        Drop expired version cache entries
   bentoo overlay autoupdate --list               List pending updates
+  bentoo overlay autoupdate --export csv --export-file pending.csv Export pending updates as CSV
+  bentoo overlay autoupdate --parser-help        Reference of packages.toml parsers and their fields
   bentoo overlay autoupdate --apply net-misc/foo Apply update for package
   bentoo overlay autoupdate --apply all          Apply all pending updates
+  bentoo overlay autoupdate --apply all --dry-run Show what --apply all would do
+  bentoo overlay autoupdate --apply all --status failed Retry the updates that failed
   bentoo overlay autoupdate --apply net-misc/foo --compile  Apply and compile test
   bentoo overlay autoupdate --apply net-misc/foo --clean    Apply and remove the old ebuild
+  bentoo overlay autoupdate --apply net-misc/foo --qa       Apply, reverting on pkgcheck errors
+  bentoo overlay autoupdate --validate net-misc/foo Run pkgcheck on a pending update before applying it
+  bentoo overlay autoupdate --revert net-misc/foo Undo the last committed update of a package
+  bentoo overlay autoupdate --clear-quarantine net-misc/foo Check a quarantined package again
   bentoo overlay autoupdate --revive-list         List orphaned packages with a newer upstream
   bentoo overlay autoupdate --check --revivable   Check active packages AND report revivable orphans
   bentoo overlay autoupdate --revive net-misc/foo Revive an orphan: seed from ::gentoo and bump
@@ -95,12 +189,37 @@ func init() {
 	autoupdateCmd.Flags().BoolVar(&autoupdateForce, "force", false, "Ignore cache when checking")
 	autoupdateCmd.Flags().BoolVar(&autoupdateCompile, "compile", false, "Run compile test after apply")
 	autoupdateCmd.Flags().BoolVarP(&autoupdateClean, "clean", "c", false, "Remove the old ebuild after a successful apply, keeping only the new version")
+	autoupdateCmd.Flags().BoolVar(&autoupdateQA, "qa", false, "Run pkgcheck after apply and revert the update when it reports errors (skipped when pkgcheck is not installed)")
 	autoupdateCmd.Flags().IntVar(&autoupdateConcurrency, "concurrency", autoupdate.DefaultConcurrency, "max parallel checks/applies (1-100)")
 	autoupdateCmd.Flags().IntVar(&autoupdateTimeout, "timeout", 0, "per-request HTTP timeout in seconds for --check (0 = use config autoupdate.http_timeout, default 30)")
 	autoupdateCmd.Flags().StringVar(&autoupdateOnly, "only", "", "Restrict --check to packages of this type: \"bin\" or \"source\"")
+	autoupdateCmd.Flags().StringVar(&autoupdateMine, "mine", "", "Restrict --check to packages whose metadata.xml lists this maintainer email")
+	autoupdateCmd.Flags().BoolVar(&autoupdateStale, "stale", false, "With --check, list outdated packages ranked by how far behind upstream they are")
+	autoupdateCmd.Flags().BoolVar(&autoupdateProbeSrc, "probe-src", false, "With --check, also HEAD each package's current SRC_URI and warn when its host is unreachable")
+	autoupdateCmd.Flags().BoolVar(&autoupdateInstalled, "installed", false, "With --check, also report the version installed on this system (from "+autoupdate.DefaultVDBPath+") and warn when the overlay is behind it")
+	autoupdateCmd.Flags().IntVar(&autoupdateQuarantineAfter, "quarantine-after", autoupdate.DefaultQuarantineThreshold, "Quarantine (skip in --check) a package after this many consecutive failed checks (0 = never)")
+	autoupdateCmd.Flags().StringVar(&autoupdateClearQuarantine, "clear-quarantine", "", "Return a quarantined package, or \"all\", to --check")
+	autoupdateCmd.Flags().BoolVar(&autoupdateHistory, "history", false, "With --check, append each package's outcome to history.jsonl in the autoupdate config directory")
+	autoupdateCmd.Flags().BoolVarP(&autoupdateDryRun, "dry-run", "n", false, "With --check, fetch and report updates without writing the cache, the pending list, the history log or packages.toml; with --apply, show the version each update would bump from and to without applying it")
+	autoupdateCmd.Flags().StringVar(&autoupdateStatus, "status", "", "With --apply all, apply only the pending entries with these comma-separated statuses (pending, validated, failed; default pending,validated); with --export, export only them (default all)")
+	autoupdateCmd.Flags().BoolVar(&autoupdatePrefetch, "prefetch", false, "Fetch every package's source into the content cache without checking, so a later --check makes conditional requests")
+	autoupdateCmd.Flags().BoolVar(&autoupdateCacheStats, "cache-stats", false, "Print the version cache's entries, expired entries and their age span; with --check, print them after the run with the cache hits and misses")
+	autoupdateCmd.Flags().BoolVar(&autoupdateCachePrune, "cache-prune", false, "Remove the version cache entries older than their TTL and report how many were dropped")
+	autoupdateCmd.Flags().BoolVar(&autoupdateParserHelp, "parser-help", false, "Print every packages.toml parser with the fields it reads and an example")
+	autoupdateCmd.Flags().StringVar(&autoupdateReport, "report", "", "With --check, print the consolidated CI report (behind, coverage, unhealthy, orphaned, quarantined) as \"json\" or \"markdown\"")
+	autoupdateCmd.Flags().BoolVar(&autoupdateJSON, "json", false, "With --check, print the results, failures included, as a JSON array for scripts and CI")
+	autoupdateCmd.Flags().StringVar(&autoupdateFormat, "format", "", "With --check, print each result through this Go text/template (fields of autoupdate.CheckResult) instead of the table")
 	autoupdateCmd.Flags().BoolVar(&autoupdateReviveList, "revive-list", false, "List disabled (orphaned) packages whose upstream is newer than ::gentoo")
 	autoupdateCmd.Flags().StringVar(&autoupdateRevive, "revive", "", "Revive an orphaned package by seeding from ::gentoo and bumping it, or \"all\" for every revivable orphan")
 	autoupdateCmd.Flags().BoolVar(&autoupdateRevivable, "revivable", false, "With --check, also report revivable orphans (disabled+absent, upstream newer than ::gentoo) in the same pass")
+	autoupdateCmd.Flags().StringVar(&autoupdateValidate, "validate", "", "Run pkgcheck on the pending update of the specified package and mark it validated or failed")
+	autoupdateCmd.Flags().StringVar(&autoupdateRevert, "revert", "", "Undo the last committed update of the specified package (staged, not committed)")
+	autoupdateCmd.Flags().StringVar(&autoupdateOverlay, "overlay", "", "Act on this configured overlay (path or directory name) only; by default --check covers every overlay in overlay.path/overlay.paths and the other modes the primary one")
+	autoupdateCmd.Flags().StringVar(&autoupdateTokenFile, "token-file", "", "Read the GitHub API token from this file (default: autoupdate.github_token_file, then GITHUB_TOKEN/GH_TOKEN)")
+	autoupdateCmd.Flags().StringVar(&autoupdateSort, "sort", "", "With --list or --export, order the pending updates by \"package\" name (default) or by when they were \"detected\"")
+	autoupdateCmd.Flags().StringVar(&autoupdateSince, "since", "", "With --list or --export, show only the pending updates detected within this age, in days (\"7d\") or a duration (\"36h\")")
+	autoupdateCmd.Flags().StringVar(&autoupdateExport, "export", "", "Write the pending updates as a \"csv\" or \"json\" report (package, current, new, status, detected_at, error)")
+	autoupdateCmd.Flags().StringVar(&autoupdateExportFile, "export-file", "", "With --export, write the report to this file instead of stdout")
 	autoupdateCmd.Flags().BoolVar(&autoupdateNoTUI, "no-tui", false, "Disable the live TUI; stream plain output (also honors NO_COLOR and BENTOO_NO_TUI)")
 
 	overlayCmd.AddCommand(autoupdateCmd)
@@ -194,6 +313,36 @@ func runAutoupdate(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if autoupdateQuarantineAfter < 0 {
+		logger.Error("--quarantine-after must be >= 0, got %d", autoupdateQuarantineAfter)
+		osExit(1)
+		return
+	}
+
+	// --json replaces the table, so it cannot be combined with the flags that
+	// replace it too, or with the ones writing more to stdout around it.
+	if autoupdateJSON {
+		for flag, set := range map[string]bool{
+			"--format": autoupdateFormat != "", "--report": autoupdateReport != "", "--stale": autoupdateStale,
+			"--revivable": autoupdateRevivable, "--cache-stats": autoupdateCacheStats,
+		} {
+			if set {
+				logger.Error("--json cannot be combined with %s", flag)
+				osExit(1)
+				return
+			}
+		}
+	}
+
+	switch autoupdateReport {
+	case "", "json", "markdown":
+		// valid
+	default:
+		logger.Error("--report must be \"json\" or \"markdown\", got %q", autoupdateReport)
+		osExit(1)
+		return
+	}
+
 	// Validate --only up front so a typo fails fast rather than silently
 	// checking everything. Only "bin"/"source" (or unset) are accepted.
 	switch autoupdateOnly {
@@ -205,6 +354,71 @@ func runAutoupdate(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// --dry-run only makes sense for the read side and for a preview of an
+	// apply; a revert or revive is a write by definition.
+	if autoupdateDryRun && !autoupdateCheck && autoupdateApply == "" {
+		logger.Error("--dry-run can only be used with --check or --apply")
+		osExit(1)
+		return
+	}
+	if autoupdateStatus != "" && autoupdateApply != "all" && autoupdateExport == "" {
+		logger.Error("--status can only be used with --apply all or --export")
+		osExit(1)
+		return
+	}
+	applyStatuses, err := autoupdate.ParseUpdateStatuses(autoupdateStatus)
+	if err != nil {
+		logger.Error("--status: %v", err)
+		osExit(1)
+		return
+	}
+
+	if autoupdateSort != "" && !autoupdateList && autoupdateExport == "" {
+		logger.Error("--sort can only be used with --list or --export")
+		osExit(1)
+		return
+	}
+	listSort, err := autoupdate.ParsePendingSort(autoupdateSort)
+	if err != nil {
+		logger.Error("--sort: %v", err)
+		osExit(1)
+		return
+	}
+
+	if autoupdateSince != "" && !autoupdateList && autoupdateExport == "" {
+		logger.Error("--since can only be used with --list or --export")
+		osExit(1)
+		return
+	}
+	var listSince time.Duration
+	if autoupdateSince != "" {
+		if listSince, err = autoupdate.ParseAge(autoupdateSince); err != nil {
+			logger.Error("--since: %v", err)
+			osExit(1)
+			return
+		}
+	}
+
+	if autoupdateExportFile != "" && autoupdateExport == "" {
+		logger.Error("--export-file can only be used with --export")
+		osExit(1)
+		return
+	}
+	var exportFormat autoupdate.ExportFormat
+	if autoupdateExport != "" {
+		if exportFormat, err = autoupdate.ParseExportFormat(autoupdateExport); err != nil {
+			logger.Error("--export: %v", err)
+			osExit(1)
+			return
+		}
+	}
+
+	// The parser reference needs neither config nor overlay.
+	if autoupdateParserHelp {
+		printParserHelp(os.Stdout)
+		return
+	}
+
 	appCtx, err := loadAppContextNoValidation()
 	if err != nil {
 		logger.Error("loading config: %v", err)
@@ -212,16 +426,52 @@ func runAutoupdate(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	overlayPath := appCtx.OverlayPath
+	// Every configured overlay, the primary first. --check covers them all;
+	// the other modes act on one, the primary unless --overlay picks another
+	// or the package they name lives in another.
+	overlays, err := appCtx.Config.GetOverlayPathsNoValidation()
+	if err != nil {
+		logger.Error("loading config: %v", err)
+		osExit(1)
+		return
+	}
+	if autoupdateOverlay != "" {
+		selected, err := selectAutoupdateOverlay(overlays, autoupdateOverlay)
+		if err != nil {
+			logger.Error("%v", err)
+			osExit(1)
+			return
+		}
+		overlays = []string{selected}
+	}
+	overlayPath := overlays[0]
+	switch {
+	case autoupdateCheck && len(args) > 0:
+		overlays = []string{overlayHoldingPackage(overlays, args[0])}
+	case autoupdateApply != "" && autoupdateApply != "all":
+		overlayPath = overlayHoldingPackage(overlays, autoupdateApply)
+	case autoupdateRevert != "":
+		overlayPath = overlayHoldingPackage(overlays, autoupdateRevert)
+	case autoupdateValidate != "":
+		overlayPath = overlayHoldingPackage(overlays, autoupdateValidate)
+	}
+	// One JSON document or report per run: several overlays would print
+	// several.
+	if autoupdateCheck && len(overlays) > 1 && (autoupdateJSON || autoupdateReport != "") {
+		logger.Error("--json and --report cover one overlay; pick one with --overlay")
+		osExit(1)
+		return
+	}
 
 	// Determine config directory for autoupdate
-	home, err := os.UserHomeDir()
+	configDir, err := autoupdateConfigDir()
 	if err != nil {
 		logger.Error("failed to get home directory: %v", err)
 		osExit(1)
 		return
 	}
-	configDir := filepath.Join(home, ".config", "bentoo", "autoupdate")
+	// The state of the overlay the non-check modes act on.
+	stateDir := autoupdateStateDir(configDir, overlayPath, appCtx.Config)
 
 	// Wire SIGINT/SIGTERM into a context so an in-flight check cancels cleanly.
 	// The Checker threads this context through every outbound HTTP/LLM call, so
@@ -239,13 +489,34 @@ func runAutoupdate(cmd *cobra.Command, args []string) {
 	// Handle different modes
 	switch {
 	case autoupdateCheck:
-		runCheck(runCtx, overlayPath, configDir, args, cacheTTL, appCtx.Config, appCtx.Config.Autoupdate.LLM)
+		osExit(runCheckOverlays(runCtx, overlays, configDir, args, cacheTTL, appCtx.Config))
+	case autoupdatePrefetch:
+		runPrefetch(runCtx, overlayPath, configDir, appCtx.Config)
+	case autoupdateCachePrune:
+		runCachePrune(overlayPath, stateDir, cacheTTL)
+	case autoupdateCacheStats:
+		runCacheStats(overlayPath, stateDir, cacheTTL)
+	case autoupdateExport != "":
+		runExport(stateDir, exportFormat, autoupdateExportFile, applyStatuses, listSort, listSince)
 	case autoupdateList:
-		runList(configDir)
+		runList(stateDir, listSort, listSince)
+	case autoupdateApply != "" && autoupdateDryRun:
+		runApplyPreview(overlayPath, stateDir, autoupdateApply, applyStatuses)
 	case autoupdateApply == "all":
-		runApplyAll(runCtx, overlayPath, configDir, appCtx.Config.Autoupdate.LLM)
+		runApplyAll(runCtx, overlayPath, stateDir, applyStatuses, appCtx.Config.Autoupdate)
 	case autoupdateApply != "":
-		runApply(runCtx, overlayPath, configDir, autoupdateApply, appCtx.Config.Autoupdate.LLM)
+		runApply(runCtx, overlayPath, stateDir, autoupdateApply, appCtx.Config.Autoupdate)
+	case autoupdateValidate != "":
+		runValidate(runCtx, overlayPath, stateDir, autoupdateValidate, appCtx.Config.Autoupdate.Pkgcheck)
+	case autoupdateRevert != "":
+		runRevert(overlayPath, stateDir, autoupdateRevert)
+	case autoupdateClearQuarantine != "":
+		runClearQuarantine(stateDir, autoupdateClearQuarantine)
+	case (autoupdateReviveList || autoupdateRevive != "") && stateDir != configDir:
+		// The revive flow shares one directory between its checker's
+		// settings and its state.
+		logger.Error("--revive and --revive-list act on the primary overlay only")
+		osExit(1)
 	case autoupdateReviveList:
 		runReviveList(runCtx, overlayPath, configDir, cacheTTL, appCtx.Config, appCtx.Config.Autoupdate.LLM)
 	case autoupdateRevive != "":
@@ -256,6 +527,15 @@ func runAutoupdate(cmd *cobra.Command, args []string) {
 	}
 }
 
+// installedVDBPath maps --installed to the vdb WithVDB reads: the system
+// vdb when set, empty (lookup disabled) otherwise.
+func installedVDBPath(enabled bool) string {
+	if enabled {
+		return autoupdate.DefaultVDBPath
+	}
+	return ""
+}
+
 // resolveHTTPTimeout resolves the per-request HTTP timeout for --check and the
 // revive flows: the --timeout flag when positive, otherwise
 // autoupdate.http_timeout from config (which itself falls back to a 30s default).
@@ -268,14 +548,63 @@ func resolveHTTPTimeout(cfg *config.Config) time.Duration {
 	return time.Duration(secs) * time.Second
 }
 
+// resolveTokenFile resolves the GitHub token file for --check, --prefetch and
+// the revive flows: the --token-file flag, otherwise
+// autoupdate.github_token_file from config. Empty means none; the checker
+// then falls back to GITHUB_TOKEN/GH_TOKEN.
+func resolveTokenFile(cfg *config.Config) string {
+	return githubTokenFile(autoupdateTokenFile, cfg)
+}
+
+// githubTokenFile returns flag when set, otherwise the token file configured
+// in autoupdate.github_token_file.
+func githubTokenFile(flag string, cfg *config.Config) string {
+	if flag != "" || cfg == nil {
+		return flag
+	}
+	return cfg.Autoupdate.GitHubTokenFile
+}
+
 // runCheck handles the --check flag. cacheTTL must be a positive duration —
 // the caller resolves it from AutoupdateConfig.GetCacheTTL, which guarantees a
 // positive value (R2.1, R2.2). A non-positive cacheTTL is treated as "use the
 // Checker default" and the WithCacheTTL option is skipped, since WithCacheTTL
 // rejects non-positive values at construction time.
-func runCheck(ctx context.Context, overlayPath, configDir string, args []string, cacheTTL time.Duration, cfg *config.Config, llmCfg config.LLMConfig) {
+//
+// runCheck returns the exit code instead of exiting, so runAutoupdate can
+// check every configured overlay and exit once. The overlay's state lives in
+// autoupdateStateDir(configDir, overlayPath, cfg).
+func runCheck(ctx context.Context, overlayPath, configDir string, args []string, cacheTTL time.Duration, cfg *config.Config, llmCfg config.LLMConfig) int {
+	// Parse --format before any network work so a typo fails immediately.
+	display := displayCheckResults
+	if autoupdateFormat != "" {
+		formatter, err := autoupdate.NewResultFormatter(autoupdateFormat)
+		if err != nil {
+			logger.Error("invalid --format: %v", err)
+			return 1
+		}
+		display = func(results []autoupdate.CheckResult) {
+			if err := formatter.Format(os.Stdout, results); err != nil {
+				logger.Error("--format: %v", err)
+				osExit(1)
+			}
+		}
+	}
+	if autoupdateJSON {
+		display = func(results []autoupdate.CheckResult) {
+			writeCheckResultsJSON(autoupdate.BatchResult[autoupdate.CheckResult]{Items: results})
+		}
+	}
+	// The progress counter shares stdout with the results; keep it out of
+	// JSON.
+	showProgress := !quiet && !autoupdateJSON
+	stateDir := autoupdateStateDir(configDir, overlayPath, cfg)
+
 	opts := []autoupdate.CheckerOption{
 		autoupdate.WithConfigDir(configDir),
+		autoupdate.WithStateDir(stateDir),
+		// --token-file > autoupdate.github_token_file > GITHUB_TOKEN/GH_TOKEN.
+		autoupdate.WithGitHubTokenFile(resolveTokenFile(cfg)),
 		autoupdate.WithContext(ctx),
 		autoupdate.WithConcurrency(autoupdateConcurrency),
 		// Per-request HTTP timeout (flag > config > 30s default). The Checker
@@ -284,6 +613,18 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 		// Restrict the batch to a package type when --only is set; empty is a
 		// no-op (checks every package). Ignored on the single-package path.
 		autoupdate.WithTypeFilter(autoupdateOnly),
+		// --probe-src: flag packages whose current distfile host is dead.
+		autoupdate.WithSourceProbe(autoupdateProbeSrc),
+		// --installed: compare against the version installed on this system.
+		autoupdate.WithVDB(installedVDBPath(autoupdateInstalled)),
+		// --quarantine-after: stop checking packages that keep failing.
+		autoupdate.WithQuarantineThreshold(autoupdateQuarantineAfter),
+		// --history: keep a JSON Lines record of every check.
+		autoupdate.WithHistoryLog(autoupdateHistory),
+		// --dry-run: report what would change, write nothing.
+		autoupdate.WithReadOnly(autoupdateDryRun),
+		// Once --prefetch has run, keep making conditional requests.
+		autoupdate.WithContentCache(autoupdate.HasContentCache(stateDir)),
 		// NewChecker authenticates api.github.com itself: it resolves the token
 		// from GITHUB_TOKEN/GH_TOKEN via the secrets chain (github.ResolveToken).
 		// Tune per-host HTTP rate limits: GitHub ~10/s and GitLab ~3/s (the two
@@ -295,6 +636,15 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 	if cacheTTL > 0 {
 		opts = append(opts, autoupdate.WithCacheTTL(cacheTTL))
 	}
+	if cfg != nil && cfg.Autoupdate.CacheCompact {
+		opts = append(opts, autoupdate.WithCacheCompact(true))
+	}
+	if cfg != nil && cfg.Autoupdate.CachePolicy != "" {
+		opts = append(opts, autoupdate.WithCachePolicy(autoupdate.CachePolicy(cfg.Autoupdate.CachePolicy)))
+	}
+	if cfg != nil && cfg.Autoupdate.NegativeCacheTTL > 0 {
+		opts = append(opts, autoupdate.WithNegativeCacheTTL(time.Duration(cfg.Autoupdate.NegativeCacheTTL)*time.Second))
+	}
 
 	// Wire an LLM provider into the check path (R5.2). newConfiguredLLMProvider
 	// returns (nil, nil) when no provider is configured, (provider, nil) on
@@ -312,6 +662,12 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 		opts = append(opts, autoupdate.WithLLMClient(p))
 	}
 	opts = append(opts, autoupdate.WithLLMProviderConfigured(llmCfg.Provider != ""))
+	// Per-package llm overrides in packages.toml apply on top of the global config.
+	opts = append(opts, autoupdate.WithLLMConfig(llmConfigToAutoupdate(llmCfg)))
+	// Answer LLM extraction for unchanged content from the previous runs.
+	if cfg != nil {
+		opts = append(opts, autoupdate.WithLLMCache(newConfiguredLLMCache(configDir, cfg.Autoupdate)))
+	}
 
 	// Progress feedback: CheckAll fans out concurrently and otherwise prints
 	// nothing until the final table, so show a live [pct%] done/total counter on
@@ -319,7 +675,7 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 	// driven by CheckAll's atomic counter, so the count is monotonic even though
 	// it fires from many goroutines. Suppressed under --quiet; harmless on the
 	// single-package path (CheckPackage never fires it).
-	if !quiet {
+	if showProgress {
 		opts = append(opts, autoupdate.WithProgressCallback(func(done, total uint64) {
 			percent := uint64(0)
 			if total > 0 {
@@ -340,8 +696,11 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 	checker, err := newChecker()
 	if err != nil {
 		logger.Error("failed to initialize checker: %v", err)
-		osExit(1)
-		return
+		return 1
+	}
+	if autoupdateCacheStats {
+		// Deferred so the hits and misses of this run are in.
+		defer func() { displayCacheStats(checker.Cache().Stats()) }()
 	}
 
 	if len(args) > 0 {
@@ -350,7 +709,9 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 		// ctx is threaded into the Checker via WithContext above, so every
 		// outbound request observes it; CheckPackage takes no ctx parameter.
 		result, err := checker.CheckPackage(pkg, autoupdateForce) //nolint:contextcheck // ctx is injected via autoupdate.WithContext
-		if err != nil {
+		// A not-comparable upstream is displayed as a warning below.
+		var cmpErr *autoupdate.ComparisonError
+		if err != nil && !errors.As(err, &cmpErr) {
 			// A removed ebuild is not a hard error: auto-disable the orphaned
 			// entry and report it as info so repeated runs stay quiet.
 			if errors.Is(err, autoupdate.ErrNoEbuildFound) {
@@ -358,36 +719,88 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 					logger.Warn("failed to disable orphaned package %s: %v", pkg, derr)
 				}
 				logger.Info("%s has no ebuild in the overlay — disabled in packages.toml", pkg)
-				return
+				return 0
 			}
 			logger.Error("failed to check package %s: %v", pkg, err)
-			osExit(1)
-			return
+			return 1
 		}
-		displayCheckResults([]autoupdate.CheckResult{*result})
-		return
+		display([]autoupdate.CheckResult{*result})
+		return 0
+	}
+
+	// --report: one artifact for CI instead of the table. It runs CheckAll
+	// itself; per-package failures are reported inside it, not via exit code.
+	if autoupdateReport != "" {
+		report, err := checker.GenerateReport() //nolint:contextcheck // ctx is injected via autoupdate.WithContext
+		if showProgress {
+			fmt.Print("\r                                        \r")
+		}
+		if err != nil {
+			logger.Error("failed to generate report: %v", err)
+			return 1
+		}
+		format := report.FormatJSON
+		if autoupdateReport == "markdown" {
+			format = report.FormatMarkdown
+		}
+		if err := format(os.Stdout); err != nil {
+			logger.Error("failed to write report: %v", err)
+			return 1
+		}
+		return 0
 	}
 
-	// Check all packages. CheckAll never returns a fatal error: every
-	// per-package failure is captured in the BatchResult. ctx is threaded
-	// into the Checker via WithContext above; CheckAll takes no ctx parameter.
-	result := checker.CheckAll(autoupdateForce) //nolint:contextcheck // ctx is injected via autoupdate.WithContext
+	// Check all packages (or, with --mine, the maintainer's own). CheckAll never
+	// returns a fatal error: every per-package failure is captured in the
+	// BatchResult. ctx is threaded into the Checker via WithContext above;
+	// CheckAll takes no ctx parameter.
+	var result autoupdate.BatchResult[autoupdate.CheckResult]
+	if autoupdateMine != "" {
+		result = checker.CheckMine(autoupdateMine, autoupdateForce) //nolint:contextcheck // ctx is injected via autoupdate.WithContext
+	} else {
+		result = checker.CheckAll(autoupdateForce) //nolint:contextcheck // ctx is injected via autoupdate.WithContext
+	}
 
 	// Clear the progress line before rendering results so the counter does not
 	// bleed into the table. Mirrors `overlay compare`'s clear step.
-	if !quiet {
+	if showProgress {
 		fmt.Print("\r                                        \r")
 	}
 
-	// Display the successfully checked packages.
-	displayCheckResults(result.Items)
+	// Display the successfully checked packages, or with --stale only the
+	// outdated ones, most behind first.
+	switch {
+	case autoupdateJSON:
+		// The failures are entries of the JSON array.
+		writeCheckResultsJSON(result)
+	case autoupdateStale:
+		autoupdate.FormatStaleness(os.Stdout, autoupdate.RankByStaleness(result.Items))
+	default:
+		display(result.Items)
+	}
 
 	// Emit one stderr line per per-package failure. FormatFailures is called
 	// only after CheckAll has fully completed, so the output is deterministic.
-	if result.HasFailures() {
+	if result.HasFailures() && !autoupdateJSON {
 		result.FormatFailures(os.Stderr)
 	}
 
+	// Quarantined packages were skipped silently by CheckAll; say so, so a
+	// rotted entry does not simply vanish from the report.
+	if quarantined := checker.Quarantined(); len(quarantined) > 0 {
+		names := make([]string, len(quarantined))
+		for i, q := range quarantined {
+			names[i] = q.Package
+		}
+		if autoupdateJSON {
+			logger.Warn("%d quarantined package(s) not checked: %s (see --clear-quarantine)",
+				len(names), strings.Join(names, ", "))
+		} else {
+			output.Warning.Printf("%d quarantined package(s) not checked: %s (see --clear-quarantine)\n",
+				len(names), strings.Join(names, ", "))
+		}
+	}
+
 	// Offer an interactive LLM registry repair for the packages that failed
 	// upstream-version extraction (story 014). Gated to a usable claude-code fixer
 	// AND an interactive stdin. newConfiguredRegistryFixer returns a TRUE nil
@@ -400,7 +813,7 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 		logger.Warn("LLM registry fixer unavailable; --check will not offer registry repair: %v", ferr)
 		fixer = nil
 	}
-	if fixer != nil && stdinIsTerminal() {
+	if fixer != nil && stdinIsTerminal() && !autoupdateJSON {
 		if perr := promptRegistryFixes(ctx, overlayPath, fixer, result.Failures, os.Stdin, newChecker); perr != nil {
 			logger.Warn("registry-fix prompt ended with an error: %v", perr)
 		}
@@ -415,8 +828,16 @@ func runCheck(ctx context.Context, overlayPath, configDir string, args []string,
 		reportRevivableOrphans(checker, cfg)
 	}
 
-	// Exit with the contract-defined code: 0 all-ok, 1 partial, 2 total fail.
-	osExit(result.ExitCode())
+	// The contract-defined exit code: 0 all-ok, 1 partial, 2 total fail.
+	return result.ExitCode()
+}
+
+// writeCheckResultsJSON prints a --check --json batch to stdout.
+func writeCheckResultsJSON(result autoupdate.BatchResult[autoupdate.CheckResult]) {
+	if err := autoupdate.WriteResultsJSON(os.Stdout, result); err != nil {
+		logger.Error("failed to write JSON results: %v", err)
+		osExit(1)
+	}
 }
 
 // stdinIsTerminal reports whether standard input is an interactive terminal (a
@@ -503,9 +924,24 @@ func displayCheckResults(results []autoupdate.CheckResult) {
 			}
 			output.Success.Printf("  %s%s: %s → %s%s\n",
 				tag, r.Package, r.CurrentVersion, r.UpstreamVersion, cacheIndicator)
+			if r.ChangelogURL != "" {
+				output.Dim.Printf("    changes: %s\n", r.ChangelogURL)
+			}
 		} else {
 			output.Dim.Printf("  %s%s: %s (up to date)\n", tag, r.Package, r.CurrentVersion)
 		}
+		if r.NeedsReview {
+			output.Warning.Printf("    needs review: %s\n", r.ReviewNote)
+		}
+		if r.SourceUnreachable {
+			output.Warning.Printf("    source unreachable: %s\n", r.SourceNote)
+		}
+		if r.InstalledAhead {
+			output.Warning.Printf("    installed %s is newer than the overlay\n", r.InstalledVersion)
+		}
+		if r.Snapshot != "" {
+			output.Dim.Printf("    snapshot %s is %d commit(s) past %s\n", r.Snapshot, r.SnapshotCommits, r.UpstreamVersion)
+		}
 	}
 
 	fmt.Println()
@@ -546,17 +982,64 @@ func typeTag(t string) string {
 }
 
 // runList handles the --list flag
-func runList(configDir string) {
+// autoupdateConfigDir returns the directory holding the autoupdate pending
+// list and caches, ~/.config/bentoo/autoupdate.
+func autoupdateConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "bentoo", "autoupdate"), nil
+}
+
+func runList(configDir string, order autoupdate.PendingSort, since time.Duration) {
 	pending, err := autoupdate.NewPendingList(configDir)
 	if err != nil {
 		logger.Error("failed to load pending list: %v", err)
 		osExit(1)
 	}
 
-	updates := pending.List()
+	updates := pending.List(autoupdate.WithSort(order), autoupdate.WithDetectedWithin(since))
 	displayPendingUpdates(updates)
 }
 
+// runExport handles the --export flag: it writes the pending updates with
+// one of statuses (every entry when none), detected within since (any time
+// when zero), to file, or stdout when file is empty or "-".
+func runExport(configDir string, format autoupdate.ExportFormat, file string, statuses []autoupdate.UpdateStatus, order autoupdate.PendingSort, since time.Duration) {
+	pending, err := autoupdate.NewPendingList(configDir)
+	if err != nil {
+		logger.Error("failed to load pending list: %v", err)
+		osExit(1)
+		return
+	}
+
+	updates := pending.List(autoupdate.WithSort(order), autoupdate.WithDetectedWithin(since))
+	if len(statuses) > 0 {
+		updates = autoupdate.SelectPending(updates, statuses)
+	}
+
+	if file == "" || file == "-" {
+		if err := autoupdate.ExportPending(os.Stdout, updates, format); err != nil {
+			logger.Error("failed to export pending updates: %v", err)
+			osExit(1)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	if err := autoupdate.ExportPending(&buf, updates, format); err != nil {
+		logger.Error("failed to export pending updates: %v", err)
+		osExit(1)
+		return
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		logger.Error("failed to write %s: %v", file, err)
+		osExit(1)
+		return
+	}
+	logger.Info("Exported %d pending update(s) to %s", len(updates), file)
+}
+
 // displayPendingUpdates formats and displays pending updates
 func displayPendingUpdates(updates []autoupdate.PendingUpdate) {
 	if len(updates) == 0 {
@@ -575,6 +1058,12 @@ func displayPendingUpdates(updates []autoupdate.PendingUpdate) {
 		output.Package.Printf("  %s\n", u.Package)
 		fmt.Printf("    Version: %s → %s\n", u.CurrentVersion, u.NewVersion)
 		fmt.Printf("    Status:  %s\n", statusStr)
+		if len(u.GroupMembers) > 0 {
+			fmt.Printf("    Group:   %s (%s)\n", u.Group, strings.Join(u.GroupMembers, ", "))
+		}
+		if u.ChangelogURL != "" {
+			fmt.Printf("    Changes: %s\n", u.ChangelogURL)
+		}
 		if u.Error != "" {
 			output.Error.Printf("    Error:   %s\n", u.Error)
 		}
@@ -640,7 +1129,7 @@ func applierFixerOption(llmCfg config.LLMConfig) autoupdate.ApplierOption {
 // WithApplierContext so a SIGINT/SIGTERM cancels the in-flight `pkgdev manifest`
 // or compile child process within ~2 s (R1.1, R1.2). The existing orphan
 // rollback path then removes the half-applied .ebuild (R1.3).
-func runApply(ctx context.Context, overlayPath, configDir, pkg string, llmCfg config.LLMConfig) {
+func runApply(ctx context.Context, overlayPath, configDir, pkg string, auCfg config.AutoupdateConfig) {
 	// Derive a cancelable apply context from the signal-aware ctx so the TUI's
 	// Ctrl-C (which invokes cancel) cancels the in-flight child via
 	// WithApplierContext and triggers the existing orphan rollback (R5.1/R5.2).
@@ -654,8 +1143,11 @@ func runApply(ctx context.Context, overlayPath, configDir, pkg string, llmCfg co
 	opts := []autoupdate.ApplierOption{
 		autoupdate.WithApplierContext(applyCtx),
 		autoupdate.WithApplierClean(autoupdateClean),
+		autoupdate.WithApplierRunQA(autoupdateQA),
+		autoupdate.WithApplierQATool(auCfg.Pkgcheck),
 		autoupdate.WithApplierPackagesConfig(loadPackagesConfigForApply(overlayPath)),
-		applierFixerOption(llmCfg),
+		autoupdate.WithApplierSkipMissingManifest(true),
+		applierFixerOption(auCfg.LLM),
 	}
 	opts = append(opts, extra...)
 
@@ -689,6 +1181,242 @@ func runApply(ctx context.Context, overlayPath, configDir, pkg string, llmCfg co
 	displayApplyResult(result)
 }
 
+// runApplyPreview handles `--apply <pkg|all> --dry-run`: it reports the
+// version each selected update would bump from and to, or why it is obsolete,
+// without touching the overlay or the pending list. It needs neither the
+// reporter nor the LLM fixer, since nothing runs.
+func runApplyPreview(overlayPath, configDir, target string, statuses []autoupdate.UpdateStatus) {
+	applier, err := autoupdate.NewApplier(overlayPath, configDir)
+	if err != nil {
+		logger.Error("failed to initialize applier: %v", err)
+		osExit(1)
+		return
+	}
+
+	pkgs := []string{target}
+	if target == "all" {
+		pkgs = nil
+		for _, u := range autoupdate.SelectPending(applier.Pending().List(), statuses) {
+			pkgs = append(pkgs, u.Package)
+		}
+		if len(pkgs) == 0 {
+			logger.Info("No pending updates to apply")
+			return
+		}
+	}
+
+	failures := 0
+	for _, pkg := range pkgs {
+		result, err := applier.Preview(pkg)
+		if err != nil {
+			failures++
+		}
+		displayApplyResult(result)
+	}
+	if failures > 0 {
+		osExit(1)
+	}
+}
+
+// runRevert handles `--revert <pkg>`: it restores the package directory to its
+// state before the last commit touching it and resets the update to pending.
+// The rollback is staged, not committed, so the user decides how to record it.
+func runRevert(overlayPath, configDir, pkg string) {
+	applier, err := autoupdate.NewApplier(overlayPath, configDir)
+	if err != nil {
+		logger.Error("failed to initialize applier: %v", err)
+		osExit(1)
+		return
+	}
+
+	result, err := applier.RevertApplied(pkg)
+	if err != nil {
+		logger.Error("failed to revert %s: %v", pkg, err)
+		osExit(1)
+		return
+	}
+
+	if result.RestoredVersion != "" {
+		output.Success.Printf("Reverted %s %s -> %s (commit %.12s)\n", pkg, result.RevertedVersion, result.RestoredVersion, result.Commit)
+	} else {
+		output.Success.Printf("Reverted %s %s (commit %.12s)\n", pkg, result.RevertedVersion, result.Commit)
+	}
+	output.Info.Println("The rollback is staged; commit it, or amend the update commit before pushing.")
+}
+
+// runValidate handles `--validate <pkg>`: pkgcheck scans the pending update
+// of pkg and the entry is marked validated or failed. It exits non-zero when
+// the scan reports errors or cannot run.
+func runValidate(ctx context.Context, overlayPath, configDir, pkg, qaTool string) {
+	applier, err := autoupdate.NewApplier(overlayPath, configDir,
+		autoupdate.WithApplierContext(ctx),
+		autoupdate.WithApplierQATool(qaTool),
+		autoupdate.WithApplierPackagesConfig(loadPackagesConfigForApply(overlayPath)),
+	)
+	if err != nil {
+		logger.Error("failed to initialize applier: %v", err)
+		osExit(1)
+		return
+	}
+
+	result, err := applier.Validate(pkg)
+	if result.Output != "" {
+		fmt.Println(result.Output)
+	}
+	if err != nil {
+		logger.Error("failed to validate %s: %v", pkg, err)
+		osExit(1)
+		return
+	}
+	output.Success.Printf("Validated %s %s -> %s\n", pkg, result.OldVersion, result.NewVersion)
+}
+
+// printParserHelp writes the parser reference from autoupdate.ParserSpecs.
+func printParserHelp(w io.Writer) {
+	fmt.Fprintln(w, "Every package needs url and parser. \"a|b\" means at least one of a and b.")
+	for _, spec := range autoupdate.ParserSpecs() {
+		fmt.Fprintf(w, "\n%s\n  %s\n", spec.Name, spec.Description)
+		if len(spec.Required) > 0 {
+			fmt.Fprintf(w, "  required: %s\n", strings.Join(spec.Required, ", "))
+		}
+		if len(spec.Optional) > 0 {
+			fmt.Fprintf(w, "  optional: %s\n", strings.Join(spec.Optional, ", "))
+		}
+		fmt.Fprintln(w, "  example:")
+		for _, line := range strings.Split(spec.Example, "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// runPrefetch handles the --prefetch flag: it fetches every package --check
+// would, stores the bodies with their ETag and Last-Modified validators, and
+// reports how many were fetched. A --check run afterwards finds the content
+// cache and makes its requests conditional.
+func runPrefetch(ctx context.Context, overlayPath, configDir string, cfg *config.Config) {
+	checker, err := autoupdate.NewChecker(overlayPath,
+		autoupdate.WithConfigDir(configDir),
+		autoupdate.WithStateDir(autoupdateStateDir(configDir, overlayPath, cfg)),
+		autoupdate.WithGitHubTokenFile(resolveTokenFile(cfg)),
+		autoupdate.WithContext(ctx),
+		autoupdate.WithConcurrency(autoupdateConcurrency),
+		autoupdate.WithHTTPRequestTimeout(resolveHTTPTimeout(cfg)),
+		autoupdate.WithTypeFilter(autoupdateOnly),
+		autoupdate.WithContentCache(true),
+		autoupdate.WithRateLimiter(autoupdate.NewRateLimiter(autoupdate.WithTunedHostPolicies())),
+	)
+	if err != nil {
+		logger.Error("failed to initialize checker: %v", err)
+		osExit(1)
+		return
+	}
+
+	batch, err := checker.Prefetch()
+	if err != nil {
+		logger.Error("prefetch: %v", err)
+		osExit(1)
+		return
+	}
+	output.Success.Printf("Prefetched %d package source(s)\n", len(batch.Items))
+	if batch.HasFailures() {
+		batch.FormatFailures(os.Stderr)
+		osExit(batch.ExitCode())
+	}
+}
+
+// openVersionCache opens the version cache --check uses, through a Checker
+// so the per-package cache_ttl overrides of packages.toml apply: pruning
+// with the global TTL alone would drop entries a package keeps longer.
+func openVersionCache(overlayPath, configDir string, cacheTTL time.Duration) (*autoupdate.Cache, error) {
+	opts := []autoupdate.CheckerOption{autoupdate.WithConfigDir(configDir)}
+	if cacheTTL > 0 {
+		opts = append(opts, autoupdate.WithCacheTTL(cacheTTL))
+	}
+	checker, err := autoupdate.NewChecker(overlayPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return checker.Cache(), nil
+}
+
+// runCacheStats handles --cache-stats on its own: the cache's size and age.
+// Hits and misses are counted per process, so they are shown after --check.
+func runCacheStats(overlayPath, configDir string, cacheTTL time.Duration) {
+	cache, err := openVersionCache(overlayPath, configDir, cacheTTL)
+	if err != nil {
+		logger.Error("failed to open cache: %v", err)
+		osExit(1)
+		return
+	}
+	displayCacheStats(cache.Stats())
+}
+
+// runCachePrune handles --cache-prune: it drops the expired version cache
+// entries and reports how many.
+func runCachePrune(overlayPath, configDir string, cacheTTL time.Duration) {
+	cache, err := openVersionCache(overlayPath, configDir, cacheTTL)
+	if err != nil {
+		logger.Error("failed to open cache: %v", err)
+		osExit(1)
+		return
+	}
+	dropped, err := cache.Prune()
+	if err != nil {
+		logger.Error("failed to prune cache: %v", err)
+		osExit(1)
+		return
+	}
+	output.Success.Printf("Pruned %d expired cache entry(ies), %d left\n", dropped, cache.Len())
+}
+
+// displayCacheStats prints a CacheStats. Hits and misses are left out when
+// no lookup was made, as in a --cache-stats run without --check.
+func displayCacheStats(stats autoupdate.CacheStats) {
+	fmt.Println()
+	output.Header.Println("Version Cache")
+	fmt.Printf("  Entries: %d (%d expired)\n", stats.Entries, stats.Expired)
+	if stats.Entries > 0 {
+		fmt.Printf("  Oldest:  %s\n", stats.Oldest.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("  Newest:  %s\n", stats.Newest.Local().Format("2006-01-02 15:04:05"))
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		fmt.Printf("  Hits:    %d of %d lookups (%.0f%%)\n", stats.Hits, lookups, float64(stats.Hits)*100/float64(lookups))
+		fmt.Printf("  Misses:  %d\n", stats.Misses)
+	}
+}
+
+// runClearQuarantine handles --clear-quarantine: it returns one package, or
+// with "all" every quarantined package, to --check with a reset failure count.
+func runClearQuarantine(configDir, target string) {
+	pending, err := autoupdate.NewPendingList(configDir)
+	if err != nil {
+		logger.Error("failed to load pending list: %v", err)
+		osExit(1)
+		return
+	}
+
+	pkgs := []string{target}
+	if target == "all" {
+		pkgs = pkgs[:0]
+		for _, q := range pending.Quarantined() {
+			pkgs = append(pkgs, q.Package)
+		}
+		if len(pkgs) == 0 {
+			logger.Info("No quarantined packages")
+			return
+		}
+	}
+
+	for _, pkg := range pkgs {
+		if err := pending.ClearQuarantine(pkg); err != nil {
+			logger.Error("failed to clear quarantine: %v", err)
+			osExit(1)
+			return
+		}
+		output.Success.Printf("Cleared quarantine of %s\n", pkg)
+	}
+}
+
 // runApplyAll handles `--apply all`: it applies every pending update, reusing a
 // single Applier so the pending list and logs directory are loaded once. ctx is
 // threaded into the Applier via WithApplierContext so a SIGINT/SIGTERM cancels
@@ -705,7 +1433,7 @@ func runApply(ctx context.Context, overlayPath, configDir, pkg string, llmCfg co
 // package overlaps instead of running one at a time. With --compile they stay
 // serial so the elevated compile step's confirmation prompt and sudo invocation
 // are not interleaved. Both paths live in applyAllPackages.
-func runApplyAll(ctx context.Context, overlayPath, configDir string, llmCfg config.LLMConfig) {
+func runApplyAll(ctx context.Context, overlayPath, configDir string, statuses []autoupdate.UpdateStatus, auCfg config.AutoupdateConfig) {
 	// Read the pending list up front so the reporter's batch denominator (and the
 	// "nothing to do" short-circuit) are known before the TUI program starts. The
 	// applier built below loads the same pending.json, and Apply mutates it as it
@@ -717,7 +1445,9 @@ func runApplyAll(ctx context.Context, overlayPath, configDir string, llmCfg conf
 		osExit(1)
 		return
 	}
-	updates := pending.List()
+	// --status picks the entries; by default a failed entry waits for an
+	// explicit --status failed rather than failing every batch again.
+	updates := autoupdate.SelectPending(pending.List(), statuses)
 	if len(updates) == 0 {
 		logger.Info("No pending updates to apply")
 		return
@@ -734,11 +1464,14 @@ func runApplyAll(ctx context.Context, overlayPath, configDir string, llmCfg conf
 	opts := []autoupdate.ApplierOption{
 		autoupdate.WithApplierContext(applyCtx),
 		autoupdate.WithApplierClean(autoupdateClean),
+		autoupdate.WithApplierRunQA(autoupdateQA),
+		autoupdate.WithApplierQATool(auCfg.Pkgcheck),
 		autoupdate.WithApplierPackagesConfig(loadPackagesConfigForApply(overlayPath)),
 		// Reuse the pending list already loaded so the applier and this snapshot
 		// share one in-memory source of truth.
 		autoupdate.WithApplierPendingList(pending),
-		applierFixerOption(llmCfg),
+		autoupdate.WithApplierSkipMissingManifest(true),
+		applierFixerOption(auCfg.LLM),
 	}
 	opts = append(opts, extra...)
 
@@ -844,7 +1577,7 @@ func displayApplyAllResults(results []*autoupdate.ApplyResult, failures int) {
 		displayApplyResult(result)
 	}
 
-	applied, obsolete := 0, 0
+	applied, obsolete, skipped := 0, 0, 0
 	for _, r := range results {
 		switch {
 		case r == nil:
@@ -852,6 +1585,9 @@ func displayApplyAllResults(results []*autoupdate.ApplyResult, failures int) {
 			obsolete++
 		case r.Success:
 			applied++
+			if r.ManifestSkipped {
+				skipped++
+			}
 		}
 	}
 
@@ -864,6 +1600,10 @@ func displayApplyAllResults(results []*autoupdate.ApplyResult, failures int) {
 	if failures > 0 {
 		output.Error.Printf("  Failed:   %d\n", failures)
 	}
+	if skipped > 0 {
+		output.Warning.Printf("  Manifest skipped for %d package(s): pkgdev not found\n", skipped)
+		output.Info.Println("Run 'bentoo overlay manifest' manually before committing")
+	}
 	if applied > 0 {
 		output.Info.Println("Don't forget to commit the changes with 'bentoo overlay commit'")
 	}
@@ -885,7 +1625,17 @@ func displayApplyResult(result *autoupdate.ApplyResult) {
 
 	output.Package.Printf("  %s\n", result.Package)
 	fmt.Printf("    Version: %s → %s\n", result.OldVersion, result.NewVersion)
+	if len(result.GroupMembers) > 0 {
+		fmt.Printf("    Group:   %s\n", strings.Join(result.GroupMembers, ", "))
+	}
 
+	if result.Obsolete && result.DryRun {
+		output.Warning.Println("    Status:  Obsolete (would be pruned from pending)")
+		if result.ObsoleteReason != "" {
+			output.Info.Printf("    Reason:  %s\n", result.ObsoleteReason)
+		}
+		return
+	}
 	if result.Obsolete {
 		output.Warning.Println("    Status:  Obsolete (pruned from pending)")
 		if result.ObsoleteReason != "" {
@@ -894,13 +1644,21 @@ func displayApplyResult(result *autoupdate.ApplyResult) {
 		return
 	}
 
+	if result.DryRun && result.Error == nil {
+		output.Info.Println("    Status:  Would apply (dry run, nothing written)")
+		return
+	}
+
 	if result.Success {
 		output.Success.Println("    Status:  Success")
+		if result.ManifestSkipped {
+			output.Warning.Println("    Manifest: skipped (pkgdev not found)")
+		}
 		if result.Fixed {
 			output.Warning.Printf("    Fixed:   manifest repaired by LLM — %s\n", result.FixSummary)
 		}
 		if result.QASummary != "" {
-			output.Warning.Printf("    QA:      pkgcheck findings after the fix — review before committing:\n%s\n", result.QASummary)
+			output.Warning.Printf("    QA:      pkgcheck findings — review before committing:\n%s\n", result.QASummary)
 		}
 		if result.CleanedOldVersion != "" {
 			fmt.Printf("    Removed: %s-%s.ebuild (old version)\n", filepath.Base(result.Package), result.CleanedOldVersion)
@@ -915,6 +1673,9 @@ func displayApplyResult(result *autoupdate.ApplyResult) {
 		if result.Error != nil {
 			output.Error.Printf("    Error:   %v\n", result.Error)
 		}
+		if result.QASummary != "" {
+			output.Error.Printf("    QA:      pkgcheck output:\n%s\n", result.QASummary)
+		}
 		if result.LogPath != "" {
 			output.Info.Printf("    Log:     %s\n", result.LogPath)
 		}
@@ -925,13 +1686,13 @@ func displayApplyResult(result *autoupdate.ApplyResult) {
 // It mirrors runCheck's option set exactly — config dir, context, concurrency,
 // type filter, tuned rate limiter, cache TTL, and the same LLM wiring (with the
 // err-first nil guard) — so a revived package's upstream check behaves
-// identically to a normal --check. The GitHub token is not an option: NewChecker
-// resolves it itself from GITHUB_TOKEN/GH_TOKEN via the secrets chain. The
+// identically to a normal --check, including its GitHub token file. The
 // progress callback is omitted: the revive paths drive single-package
 // CheckPackage calls, which never fire it.
-func reviveCheckerOptions(ctx context.Context, configDir string, cacheTTL, httpTimeout time.Duration, llmCfg config.LLMConfig) []autoupdate.CheckerOption {
+func reviveCheckerOptions(ctx context.Context, configDir string, cacheTTL, httpTimeout time.Duration, tokenFile string, llmCfg config.LLMConfig) []autoupdate.CheckerOption {
 	opts := []autoupdate.CheckerOption{
 		autoupdate.WithConfigDir(configDir),
+		autoupdate.WithGitHubTokenFile(tokenFile),
 		autoupdate.WithContext(ctx),
 		autoupdate.WithConcurrency(autoupdateConcurrency),
 		autoupdate.WithTypeFilter(autoupdateOnly),
@@ -953,6 +1714,8 @@ func reviveCheckerOptions(ctx context.Context, configDir string, cacheTTL, httpT
 		opts = append(opts, autoupdate.WithLLMClient(p))
 	}
 	opts = append(opts, autoupdate.WithLLMProviderConfigured(llmCfg.Provider != ""))
+	// Per-package llm overrides in packages.toml apply on top of the global config.
+	opts = append(opts, autoupdate.WithLLMConfig(llmConfigToAutoupdate(llmCfg)))
 
 	return opts
 }
@@ -1012,7 +1775,7 @@ func resolveGentooProvider(cfg *config.Config) (provider.Provider, error) {
 // (the same option set as --check) and the ::gentoo provider, then prints the
 // candidates FindRevivableOrphans returns as a PACKAGE | GENTOO | UPSTREAM table.
 func runReviveList(ctx context.Context, overlayPath, configDir string, cacheTTL time.Duration, cfg *config.Config, llmCfg config.LLMConfig) {
-	checker, err := autoupdate.NewChecker(overlayPath, reviveCheckerOptions(ctx, configDir, cacheTTL, resolveHTTPTimeout(cfg), llmCfg)...)
+	checker, err := autoupdate.NewChecker(overlayPath, reviveCheckerOptions(ctx, configDir, cacheTTL, resolveHTTPTimeout(cfg), resolveTokenFile(cfg), llmCfg)...)
 	if err != nil {
 		logger.Error("failed to initialize checker: %v", err)
 		osExit(1)
@@ -1107,7 +1870,7 @@ func runRevive(ctx context.Context, overlayPath, configDir, target string, cache
 	}
 
 	// Build the initial Checker (shared option set) to resolve the target list.
-	checker, err := autoupdate.NewChecker(overlayPath, reviveCheckerOptions(ctx, configDir, cacheTTL, resolveHTTPTimeout(cfg), llmCfg)...)
+	checker, err := autoupdate.NewChecker(overlayPath, reviveCheckerOptions(ctx, configDir, cacheTTL, resolveHTTPTimeout(cfg), resolveTokenFile(cfg), llmCfg)...)
 	if err != nil {
 		logger.Error("failed to initialize checker: %v", err)
 		osExit(1)
@@ -1163,7 +1926,7 @@ func runRevive(ctx context.Context, overlayPath, configDir, target string, cache
 	httpTimeout := resolveHTTPTimeout(cfg)
 	outcomes := make([]reviveOutcome, 0, len(targets))
 	for _, pkg := range targets {
-		outcomes = append(outcomes, reviveOne(ctx, pkg, overlayPath, configDir, cacheTTL, httpTimeout, llmCfg, prov, pdp, applier, pending))
+		outcomes = append(outcomes, reviveOne(ctx, pkg, overlayPath, configDir, cacheTTL, httpTimeout, resolveTokenFile(cfg), llmCfg, prov, pdp, applier, pending))
 	}
 
 	failures := displayReviveSummary(outcomes)
@@ -1180,7 +1943,7 @@ func runRevive(ctx context.Context, overlayPath, configDir, target string, cache
 // version, seed it into the overlay, re-enable the entry in packages.toml BEFORE
 // checking (so the checker won't skip it), CheckPackage(force=true) to populate
 // pending with the upstream version, then Apply (honouring --compile / --clean).
-func reviveOne(ctx context.Context, pkg, overlayPath, configDir string, cacheTTL, httpTimeout time.Duration, llmCfg config.LLMConfig, prov provider.Provider, pdp provider.PackageDirProvider, applier *autoupdate.Applier, pending *autoupdate.PendingList) reviveOutcome {
+func reviveOne(ctx context.Context, pkg, overlayPath, configDir string, cacheTTL, httpTimeout time.Duration, tokenFile string, llmCfg config.LLMConfig, prov provider.Provider, pdp provider.PackageDirProvider, applier *autoupdate.Applier, pending *autoupdate.PendingList) reviveOutcome {
 	output.Info.Printf("Reviving %s...\n", pkg)
 
 	category, pkgName, ok := splitPackage(pkg)
@@ -1222,7 +1985,7 @@ func reviveOne(ctx context.Context, pkg, overlayPath, configDir string, cacheTTL
 	// It shares the applier's pending list so the entry CheckPackage writes is
 	// visible to Apply below (same in-memory map, same process).
 	checker, err := autoupdate.NewChecker(overlayPath,
-		append(reviveCheckerOptions(ctx, configDir, cacheTTL, httpTimeout, llmCfg), autoupdate.WithPendingList(pending))...)
+		append(reviveCheckerOptions(ctx, configDir, cacheTTL, httpTimeout, tokenFile, llmCfg), autoupdate.WithPendingList(pending))...)
 	if err != nil {
 		return reviveOutcome{pkg: pkg, status: "failed", detail: fmt.Sprintf("checker init failed: %v", err)}
 	}
@@ -44,6 +44,11 @@ func setupApplyAllTest(t *testing.T, n int, factory func(context.Context, string
 	tmp := t.TempDir()
 	overlayDir := filepath.Join(tmp, "overlay")
 	configDir := filepath.Join(tmp, "config")
+	for _, dir := range []string{"profiles", "metadata"} {
+		if err := os.MkdirAll(filepath.Join(overlayDir, dir), 0o755); err != nil {
+			t.Fatalf("MkdirAll %s: %v", dir, err)
+		}
+	}
 
 	pending, err := autoupdate.NewPendingList(configDir)
 	if err != nil {
@@ -168,6 +168,12 @@ loop:
 			continue
 		}
 		checkRes, checkErr := c.CheckPackage(pkg, true) //nolint:contextcheck // ctx is injected via autoupdate.WithContext in newChecker's opts
+		// A not-comparable version was still extracted; it passes with the
+		// warning below.
+		var cmpErr *autoupdate.ComparisonError
+		if errors.As(checkErr, &cmpErr) {
+			checkErr = nil
+		}
 
 		// Pass = no error AND a version was extracted. A benign cache/pending
 		// warning leaves checkErr nil with UpstreamVersion set (checker.go success
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+// autoupdateStateDir returns where the autoupdate state (caches, pending list,
+// history) of overlayPath lives: configDir itself for the primary overlay, so
+// a single-overlay setup keeps its files where they always were, and a
+// per-overlay subdirectory (autoupdate.OverlayStateDir) for the others. With
+// no overlay configured, overlayPath is taken as the primary.
+func autoupdateStateDir(configDir, overlayPath string, cfg *config.Config) string {
+	if cfg == nil {
+		return configDir
+	}
+	primary, err := cfg.GetOverlayPathNoValidation()
+	if err != nil || filepath.Clean(primary) == filepath.Clean(overlayPath) {
+		return configDir
+	}
+	return autoupdate.OverlayStateDir(configDir, overlayPath)
+}
+
+// selectAutoupdateOverlay resolves --overlay against the configured overlays:
+// name matches an overlay's path, or its directory name when that is
+// unambiguous.
+func selectAutoupdateOverlay(overlays []string, name string) (string, error) {
+	for _, path := range overlays {
+		if filepath.Clean(path) == filepath.Clean(name) {
+			return path, nil
+		}
+	}
+	var matches []string
+	for _, path := range overlays {
+		if filepath.Base(path) == name {
+			matches = append(matches, path)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return "", fmt.Errorf("--overlay %q is not a configured overlay (%s)", name, strings.Join(overlays, ", "))
+	default:
+		return "", fmt.Errorf("--overlay %q matches several overlays (%s); give its path", name, strings.Join(matches, ", "))
+	}
+}
+
+// overlayHoldingPackage returns the first overlay with a directory for pkg
+// (category/package), or the first overlay, the primary, when none has one.
+func overlayHoldingPackage(overlays []string, pkg string) string {
+	for _, path := range overlays {
+		if info, err := os.Stat(filepath.Join(path, pkg)); err == nil && info.IsDir() {
+			return path
+		}
+	}
+	return overlays[0]
+}
+
+// runCheckOverlays runs --check on each overlay in turn, under a header
+// naming it when there are several (see showOverlayHeaders), and returns the
+// worst exit code: an overlay whose every package failed outweighs one with a
+// partial failure.
+func runCheckOverlays(ctx context.Context, overlays []string, configDir string, args []string, cacheTTL time.Duration, cfg *config.Config) int {
+	code := 0
+	headers := showOverlayHeaders(len(overlays))
+	for i, overlayPath := range overlays {
+		if headers {
+			if i > 0 {
+				fmt.Println()
+			}
+			output.Header.Printf("Overlay %s\n", overlayPath)
+		}
+		code = max(code, runCheck(ctx, overlayPath, configDir, args, cacheTTL, cfg, cfg.Autoupdate.LLM))
+	}
+	return code
+}
+
+// showOverlayHeaders reports whether runCheckOverlays prints a header per
+// overlay: only for several overlays in the default table output. A --format
+// template or --json is read by scripts, which the headers would break.
+func showOverlayHeaders(count int) bool {
+	return count > 1 && !quiet && !autoupdateJSON && autoupdateFormat == ""
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// TestSelectAutoupdateOverlay verifies --overlay matches a configured path or
+// an unambiguous directory name, and rejects anything else.
+func TestSelectAutoupdateOverlay(t *testing.T) {
+	overlays := []string{"/srv/main", "/srv/extra", "/home/me/extra"}
+
+	if got, err := selectAutoupdateOverlay(overlays, "/srv/extra/"); err != nil || got != "/srv/extra" {
+		t.Errorf("select(path) = %q, %v; want /srv/extra", got, err)
+	}
+	if got, err := selectAutoupdateOverlay(overlays, "main"); err != nil || got != "/srv/main" {
+		t.Errorf("select(name) = %q, %v; want /srv/main", got, err)
+	}
+	if _, err := selectAutoupdateOverlay(overlays, "extra"); err == nil {
+		t.Error("select(ambiguous name) succeeded, want an error")
+	}
+	if _, err := selectAutoupdateOverlay(overlays, "other"); err == nil {
+		t.Error("select(unknown) succeeded, want an error")
+	}
+}
+
+// TestAutoupdateStateDir verifies the primary overlay keeps the config
+// directory for its state and any other overlay gets its own.
+func TestAutoupdateStateDir(t *testing.T) {
+	configDir := "/home/me/.config/bentoo/autoupdate"
+	primary, extra := t.TempDir(), t.TempDir()
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: primary, Paths: []string{extra}}}
+
+	if got := autoupdateStateDir(configDir, primary, cfg); got != configDir {
+		t.Errorf("autoupdateStateDir(primary) = %q, want %q", got, configDir)
+	}
+	if got, want := autoupdateStateDir(configDir, extra, cfg), autoupdate.OverlayStateDir(configDir, extra); got != want {
+		t.Errorf("autoupdateStateDir(extra) = %q, want %q", got, want)
+	}
+	if got := autoupdateStateDir(configDir, "/srv/anything", &config.Config{}); got != configDir {
+		t.Errorf("autoupdateStateDir(no overlay configured) = %q, want %q", got, configDir)
+	}
+}
+
+// TestShowOverlayHeaders verifies the per-overlay headers only go with the
+// default table output for several overlays.
+func TestShowOverlayHeaders(t *testing.T) {
+	origQuiet, origJSON, origFormat := quiet, autoupdateJSON, autoupdateFormat
+	defer func() { quiet, autoupdateJSON, autoupdateFormat = origQuiet, origJSON, origFormat }()
+
+	tests := []struct {
+		name   string
+		count  int
+		quiet  bool
+		json   bool
+		format string
+		want   bool
+	}{
+		{name: "table, several overlays", count: 2, want: true},
+		{name: "table, one overlay", count: 1, want: false},
+		{name: "quiet", count: 2, quiet: true, want: false},
+		{name: "json", count: 2, json: true, want: false},
+		{name: "template", count: 2, format: "{{.Package}}", want: false},
+	}
+	for _, tt := range tests {
+		quiet, autoupdateJSON, autoupdateFormat = tt.quiet, tt.json, tt.format
+		if got := showOverlayHeaders(tt.count); got != tt.want {
+			t.Errorf("%s: showOverlayHeaders(%d) = %v, want %v", tt.name, tt.count, got, tt.want)
+		}
+	}
+}
+
+// TestRunCheckOverlays verifies --check covers every overlay, keeps each
+// overlay's pending list apart, and exits with the worst overlay's code.
+func TestRunCheckOverlays(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"version": "1.0.0"})
+	}))
+	defer server.Close()
+
+	primary, extra := t.TempDir(), t.TempDir()
+	configDir := t.TempDir()
+	writeExitTestPackagesConfig(t, primary, server.URL, []string{"cat-a/one"})
+	writeExitTestEbuild(t, primary, "cat-a/one", "0.9.0")
+	writeExitTestPackagesConfig(t, extra, server.URL, []string{"cat-b/two"})
+	writeExitTestEbuild(t, extra, "cat-b/two", "0.9.0")
+	// An entry without an ebuild leaves the extra overlay partially failed.
+	cfgPath := filepath.Join(extra, ".autoupdate", "packages.toml")
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("read packages.toml: %v", err)
+	}
+	data = append(data, "[\"cat-b/bad\"]\nurl = \""+server.URL+"\"\nparser = \"json\"\npath = \"nonexistent\"\n"...)
+	writeExitTestEbuild(t, extra, "cat-b/bad", "0.9.0")
+	if err := os.WriteFile(cfgPath, data, 0o644); err != nil {
+		t.Fatalf("write packages.toml: %v", err)
+	}
+
+	origForce, origConc := autoupdateForce, autoupdateConcurrency
+	autoupdateForce, autoupdateConcurrency = true, autoupdate.DefaultConcurrency
+	defer func() { autoupdateForce, autoupdateConcurrency = origForce, origConc }()
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: primary, Paths: []string{extra}}}
+	var code int
+	captureStdout(t, func() {
+		code = runCheckOverlays(context.Background(), []string{primary, extra}, configDir, nil, 0, cfg)
+	})
+	if code != 1 {
+		t.Errorf("runCheckOverlays() = %d, want 1 (the extra overlay failed partially)", code)
+	}
+
+	for dir, want := range map[string]string{
+		configDir: "cat-a/one",
+		autoupdate.OverlayStateDir(configDir, extra): "cat-b/two",
+	} {
+		pending, err := autoupdate.NewPendingList(dir)
+		if err != nil {
+			t.Fatalf("NewPendingList(%s) error = %v", dir, err)
+		}
+		updates := pending.List()
+		if len(updates) != 1 || updates[0].Package != want {
+			t.Errorf("pending list in %s = %+v, want only %s", dir, updates, want)
+		}
+	}
+}
@@ -123,8 +123,8 @@ func TestRunCheck_Revivable(t *testing.T) {
 	withFakeGentoo(t, fake)
 
 	code := withExitIntercept(func() {
-		runCheck(context.Background(), overlay, configDir, nil, 0,
-			&config.Config{}, config.LLMConfig{})
+		osExit(runCheck(context.Background(), overlay, configDir, nil, 0,
+			&config.Config{}, config.LLMConfig{}))
 	})
 	if code != 0 {
 		t.Fatalf("runCheck --revivable exit code = %d, want 0 (no active packages, report is read-only)", code)
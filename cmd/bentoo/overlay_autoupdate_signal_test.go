@@ -84,7 +84,7 @@ func TestRunAutoupdate_SignalCancels(t *testing.T) {
 	}()
 
 	// Run the command in a goroutine. withExitIntercept absorbs the osExit call
-	// that runCheck makes on completion, so the goroutine returns normally.
+	// that runAutoupdate makes on completion, so the goroutine returns normally.
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
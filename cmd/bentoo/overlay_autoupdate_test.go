@@ -145,7 +145,7 @@ func TestCLI_ExitCodes(t *testing.T) {
 				// exercise cache freshness; force=true bypasses the cache.
 				// Zero config.LLMConfig{} (Provider == "") → no LLM provider is
 				// wired and the exit-code contract is unaffected.
-				runCheck(context.Background(), overlayDir, configDir, nil, 0, &config.Config{}, config.LLMConfig{})
+				osExit(runCheck(context.Background(), overlayDir, configDir, nil, 0, &config.Config{}, config.LLMConfig{}))
 			})
 			if code != tt.wantExit {
 				t.Errorf("runCheck exit code = %d, want %d", code, tt.wantExit)
@@ -154,6 +154,63 @@ func TestCLI_ExitCodes(t *testing.T) {
 	}
 }
 
+// TestRunCheck_JSON verifies --check --json prints one array holding the
+// checked packages and the failures, with the error as a string, and nothing
+// else on stdout.
+func TestRunCheck_JSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"version": "1.0.0"})
+	}))
+	defer server.Close()
+
+	overlayDir := t.TempDir()
+	configDir := t.TempDir()
+	cfgDir := filepath.Join(overlayDir, ".autoupdate")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", cfgDir, err)
+	}
+	toml := "[\"cat-a/good\"]\nurl = \"" + server.URL + "\"\nparser = \"json\"\npath = \"version\"\n\n" +
+		"[\"cat-b/bad\"]\nurl = \"" + server.URL + "\"\nparser = \"json\"\npath = \"nonexistent\"\n"
+	if err := os.WriteFile(filepath.Join(cfgDir, "packages.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatalf("write packages.toml: %v", err)
+	}
+	writeExitTestEbuild(t, overlayDir, "cat-a/good", "0.9.0")
+	writeExitTestEbuild(t, overlayDir, "cat-b/bad", "0.9.0")
+
+	origForce, origConc, origJSON := autoupdateForce, autoupdateConcurrency, autoupdateJSON
+	autoupdateForce, autoupdateConcurrency, autoupdateJSON = true, autoupdate.DefaultConcurrency, true
+	defer func() { autoupdateForce, autoupdateConcurrency, autoupdateJSON = origForce, origConc, origJSON }()
+
+	var code int
+	out := captureStdout(t, func() {
+		code = withExitIntercept(func() {
+			osExit(runCheck(context.Background(), overlayDir, configDir, nil, 0, &config.Config{}, config.LLMConfig{}))
+		})
+	})
+	if code != 1 {
+		t.Errorf("runCheck exit code = %d, want 1 (partial failure)", code)
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("stdout is not a JSON array: %v\n%s", err, out)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %s", len(entries), out)
+	}
+	good, bad := entries[0], entries[1]
+	if good["package"] != "cat-a/good" || good["current"] != "0.9.0" || good["upstream"] != "1.0.0" || good["has_update"] != true {
+		t.Errorf("checked entry = %v, want cat-a/good 0.9.0 -> 1.0.0 with has_update", good)
+	}
+	if _, ok := good["error"]; ok {
+		t.Errorf("checked entry has an error: %v", good)
+	}
+	if msg, _ := bad["error"].(string); bad["package"] != "cat-b/bad" || msg == "" {
+		t.Errorf("failed entry = %v, want cat-b/bad with an error string", bad)
+	}
+}
+
 // TestRunAutoupdate_CacheTTLFromConfig verifies R2.1 end-to-end: a user
 // `autoupdate.cache_ttl: 60` in ~/.config/bentoo/config.yaml reaches the Cache
 // that runCheck constructs, so the written cache entry is fresh under the
@@ -398,3 +455,123 @@ func TestRunAutoupdate_OverlayPathBoundsCheck(t *testing.T) {
 		})
 	}
 }
+
+// TestPrintParserHelp verifies --parser-help lists every registered parser.
+func TestPrintParserHelp(t *testing.T) {
+	var b strings.Builder
+	printParserHelp(&b)
+	out := b.String()
+	for _, spec := range autoupdate.ParserSpecs() {
+		if !strings.Contains(out, "\n"+spec.Name+"\n") {
+			t.Errorf("parser help does not list %q", spec.Name)
+		}
+	}
+	if !strings.Contains(out, "required: feed.date, feed.version") {
+		t.Errorf("parser help does not show the json-feed required fields:\n%s", out)
+	}
+}
+
+// TestRunCachePrune verifies --cache-prune drops the entries past the global
+// TTL but keeps one a package's cache_ttl keeps longer.
+func TestRunCachePrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "autoupdate")
+	if err := os.MkdirAll(filepath.Join(overlayDir, ".autoupdate"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	packagesTOML := `["cat-a/slow"]
+url = "https://example.com/slow.json"
+parser = "json"
+path = "version"
+cache_ttl = 86400
+
+["cat-a/fast"]
+url = "https://example.com/fast.json"
+parser = "json"
+path = "version"
+`
+	if err := os.WriteFile(filepath.Join(overlayDir, ".autoupdate", "packages.toml"), []byte(packagesTOML), 0o644); err != nil {
+		t.Fatalf("write packages.toml: %v", err)
+	}
+
+	threeHoursAgo := time.Now().Add(-3 * time.Hour)
+	seed, err := autoupdate.NewCache(configDir, autoupdate.WithNowFunc(func() time.Time { return threeHoursAgo }))
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	for _, pkg := range []string{"cat-a/slow", "cat-a/fast"} {
+		if err := seed.Set(pkg, "1.0", "https://example.com"); err != nil {
+			t.Fatalf("Set(%s): %v", pkg, err)
+		}
+	}
+
+	if code := withExitIntercept(func() { runCachePrune(overlayDir, configDir, time.Hour) }); code != -1 {
+		t.Fatalf("runCachePrune exited with %d", code)
+	}
+
+	cache, err := autoupdate.NewCache(configDir)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if _, ok := cache.GetEntry("cat-a/fast"); ok {
+		t.Error("entry past the global TTL was kept")
+	}
+	if _, ok := cache.GetEntry("cat-a/slow"); !ok {
+		t.Error("entry within its package cache_ttl was pruned")
+	}
+}
+
+// TestRunExport verifies --export writes the entries with the selected
+// statuses to the export file, in the requested order.
+func TestRunExport(t *testing.T) {
+	configDir := t.TempDir()
+	pending, err := autoupdate.NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList: %v", err)
+	}
+	detected := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, u := range []autoupdate.PendingUpdate{
+		{Package: "cat-a/zeta", CurrentVersion: "1.0", NewVersion: "1.1", Status: autoupdate.StatusFailed, Error: "fetch failed"},
+		{Package: "cat-a/alpha", CurrentVersion: "2.0", NewVersion: "2.1", Status: autoupdate.StatusPending},
+		{Package: "cat-a/beta", CurrentVersion: "3.0", NewVersion: "3.1", Status: autoupdate.StatusValidated},
+	} {
+		u.DetectedAt = detected.Add(time.Duration(i) * time.Hour)
+		if err := pending.Add(u); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "pending.csv")
+	statuses := []autoupdate.UpdateStatus{autoupdate.StatusFailed, autoupdate.StatusPending}
+	if code := withExitIntercept(func() {
+		runExport(configDir, autoupdate.ExportCSV, file, statuses, autoupdate.SortByDetectedAt, 0)
+	}); code != -1 {
+		t.Fatalf("runExport exited with %d", code)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("export file not written: %v", err)
+	}
+	want := "package,current,new,status,detected_at,error\n" +
+		"cat-a/zeta,1.0,1.1,failed,2026-03-01T00:00:00Z,fetch failed\n" +
+		"cat-a/alpha,2.0,2.1,pending,2026-03-01T01:00:00Z,\n"
+	if string(data) != want {
+		t.Errorf("export =\n%s\nwant\n%s", data, want)
+	}
+}
+
+// TestGitHubTokenFile verifies the --token-file flag wins over
+// autoupdate.github_token_file.
+func TestGitHubTokenFile(t *testing.T) {
+	cfg := &config.Config{Autoupdate: config.AutoupdateConfig{GitHubTokenFile: "/etc/bentoo/gh-token"}}
+	if got := githubTokenFile("", cfg); got != "/etc/bentoo/gh-token" {
+		t.Errorf("githubTokenFile(no flag) = %q, want the configured file", got)
+	}
+	if got := githubTokenFile("/tmp/flag-token", cfg); got != "/tmp/flag-token" {
+		t.Errorf("githubTokenFile(flag) = %q, want the flag", got)
+	}
+	if got := githubTokenFile("", nil); got != "" {
+		t.Errorf("githubTokenFile(no config) = %q, want empty", got)
+	}
+}
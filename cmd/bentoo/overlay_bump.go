@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+	"github.com/spf13/cobra"
+)
+
+// BumpFlags holds command-line flags for the bump operation
+type BumpFlags struct {
+	From           string // --from: version to copy instead of the highest one
+	UpdateLiterals bool   // --update-literals: replace the old version spelled out in the ebuild
+	NoManifest     bool   // --no-manifest: skip the Manifest update
+	NoStage        bool   // --no-stage: leave the new files unstaged
+	DryRun         bool   // --dry-run: show what would be done
+}
+
+var bumpFlags BumpFlags
+
+var bumpCmd = &cobra.Command{
+	Use:   "bump <category>/<package> <new-version>",
+	Short: "Create a new version of a package from an existing ebuild",
+	Long: `Copy an ebuild of a package to a new version, regenerate the package's
+Manifest and stage the new ebuild with it.
+
+Unlike rename, which moves the ebuild, the old version is kept. The highest
+non-live ebuild is copied unless --from names another version (revision
+included, e.g. 1.2.3-r1). An existing ebuild for the new version is never
+overwritten.
+
+Ebuilds derive their versions from ${PV}, so the copy is verbatim by default;
+--update-literals also replaces the old version written out in the ebuild
+(MY_PV="1_2_3", a hardcoded SRC_URI) with the new one.
+
+Examples:
+  # Add app-misc/hello-2.0.0 from the highest hello ebuild
+  bentoo overlay bump app-misc/hello 2.0.0
+
+  # Copy a specific version and update its hardcoded version strings
+  bentoo overlay bump --from 1.9.0-r2 --update-literals app-misc/hello 2.0.0`,
+	Args: cobra.ExactArgs(2),
+	Run:  runBump,
+}
+
+func init() {
+	bumpCmd.Flags().StringVar(&bumpFlags.From, "from", "", "Version of the ebuild to copy (default: the highest non-live one)")
+	bumpCmd.Flags().BoolVar(&bumpFlags.UpdateLiterals, "update-literals", false, "Replace the old version written out in the ebuild with the new one")
+	bumpCmd.Flags().BoolVar(&bumpFlags.NoManifest, "no-manifest", false, "Skip the Manifest update")
+	bumpCmd.Flags().BoolVar(&bumpFlags.NoStage, "no-stage", false, "Do not stage the new ebuild and Manifest")
+	bumpCmd.Flags().BoolVarP(&bumpFlags.DryRun, "dry-run", "n", false, "Show what would be done without making changes")
+	overlayCmd.AddCommand(bumpCmd)
+}
+
+func runBump(cmd *cobra.Command, args []string) {
+	ctx, err := loadAppContext()
+	if err != nil {
+		logger.Error("loading config: %v", err)
+		osExit(1)
+	}
+
+	opts := &overlay.BumpOptions{
+		OldVersion:     bumpFlags.From,
+		UpdateLiterals: bumpFlags.UpdateLiterals,
+		NoManifest:     bumpFlags.NoManifest,
+		NoStage:        bumpFlags.NoStage,
+		DryRun:         bumpFlags.DryRun,
+	}
+	result, err := overlay.Bump(ctx.Config, args[0], args[1], opts)
+	// A staging failure comes after the ebuild was written, which is
+	// reported before the error; a conflict wrote nothing.
+	if result != nil && !errors.Is(err, overlay.ErrBumpTargetExists) {
+		logger.Info("%s", overlay.FormatBumpResult(result, opts.DryRun))
+	}
+	if err != nil {
+		logger.Error("%v", err)
+		osExit(1)
+	}
+}
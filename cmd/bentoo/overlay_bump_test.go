@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRunBump verifies the command copies the ebuild and exits non-zero on a
+// conflict.
+func TestRunBump(t *testing.T) {
+	overlayDir, cleanup := setupTestHomeWithGitRepo(t)
+	defer cleanup()
+
+	pkgDir := filepath.Join(overlayDir, "app-misc", "hello")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "hello-1.0.ebuild"), []byte("EAPI=8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := bumpFlags
+	defer func() { bumpFlags = orig }()
+	bumpFlags = BumpFlags{NoManifest: true}
+
+	if code := withExitIntercept(func() { runBump(bumpCmd, []string{"app-misc/hello", "1.1"}) }); code != -1 {
+		t.Fatalf("runBump() exited with %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, "hello-1.1.ebuild")); err != nil {
+		t.Errorf("new ebuild not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, "hello-1.0.ebuild")); err != nil {
+		t.Errorf("old ebuild removed: %v", err)
+	}
+
+	if code := withExitIntercept(func() { runBump(bumpCmd, []string{"app-misc/hello", "1.1"}) }); code != 1 {
+		t.Errorf("runBump(existing version) exit = %d, want 1", code)
+	}
+}
@@ -25,6 +25,8 @@ var commitCmd = &cobra.Command{
 	Long: `Commit staged changes to the overlay repository.
 If no message is provided with -m, an automatic commit message is generated
 based on the ebuild changes and a confirmation prompt is shown.
+Set git.commit_format to "conventional" in the config for Conventional
+Commits style messages ("feat(app-misc/test): add 1.0").
 Use -y to skip the confirmation prompt and commit automatically.`,
 	Run: runCommit,
 }
@@ -92,7 +94,12 @@ func runCommit(cmd *cobra.Command, args []string) {
 	// non-ebuild files such as eclasses, profiles, licenses, and metadata).
 	changes := overlay.AnalyzeChanges(stagedEntries)
 	fileChanges := overlay.AnalyzeRepoFileChanges(stagedEntries)
-	generatedMessage := overlay.GenerateCommitMessage(changes, fileChanges)
+	format, err := overlay.ParseMessageFormat(ctx.Config.Git.CommitFormat)
+	if err != nil {
+		logger.Error("git.commit_format: %v", err)
+		osExit(1)
+	}
+	generatedMessage := overlay.GenerateFormattedMessage(changes, fileChanges, format)
 
 	// Dry-run mode: just show what would be committed
 	if commitDryRun {
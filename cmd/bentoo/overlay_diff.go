@@ -1,27 +1,36 @@
 package main
 
 import (
-	"os"
-	"os/exec"
+	"fmt"
 
+	"github.com/fatih/color"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/overlay"
 	"github.com/spf13/cobra"
 )
 
 var (
-	diffStaged bool
+	diffStaged   bool
+	diffVersions []string
 )
 
 var diffCmd = &cobra.Command{
-	Use:   "diff [path]",
+	Use:   "diff [category/package|path...]",
 	Short: "Show diff of changes",
-	Long: `Show the diff of changes in the overlay repository.
-By default shows unstaged changes. Use --staged to show staged changes.`,
+	Long: `Show the diff of changes in the overlay repository, optionally limited to
+packages (category/package) or other paths.
+By default shows unstaged changes. Use --staged to show staged changes.
+
+With --versions OLD,NEW and a single category/package, diff two of the
+package's ebuilds instead, e.g. after a version bump:
+
+  bentoo overlay diff app-misc/foo --versions 1.0,1.1`,
 	Run: runDiff,
 }
 
 func init() {
 	diffCmd.Flags().BoolVarP(&diffStaged, "staged", "s", false, "Show staged changes")
+	diffCmd.Flags().StringSliceVar(&diffVersions, "versions", nil, "Diff the ebuilds of two versions of one package (OLD,NEW)")
 	overlayCmd.AddCommand(diffCmd)
 }
 
@@ -32,30 +41,32 @@ func runDiff(cmd *cobra.Command, args []string) {
 		osExit(1)
 	}
 
-	overlayPath := ctx.OverlayPath
-
-	// Build git diff command
-	gitArgs := []string{"diff", "--color=always"}
-	if diffStaged {
-		gitArgs = append(gitArgs, "--staged")
-	}
 	if err := validateGitPathArgs(args); err != nil {
 		logger.Error("%v", err)
 		osExit(1)
 	}
-	gitArgs = append(gitArgs, args...)
 
-	gitCmd := exec.Command("git", gitArgs...)
-	gitCmd.Dir = overlayPath
-	gitCmd.Stdout = os.Stdout
-	gitCmd.Stderr = os.Stderr
+	// Colors follow the terminal (and --no-color / NO_COLOR) like the rest
+	// of the output, since the diff is captured before it is printed.
+	opts := &overlay.DiffOptions{Staged: diffStaged, Color: !color.NoColor}
 
-	if err := gitCmd.Run(); err != nil {
-		// git diff returns exit code 1 if there are differences, which is not an error
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return
+	var out string
+	if len(diffVersions) > 0 {
+		if len(diffVersions) != 2 || len(args) != 1 {
+			logger.Error("--versions takes two versions (OLD,NEW) and exactly one category/package")
+			osExit(1)
 		}
+		if diffStaged {
+			logger.Error("--staged cannot be combined with --versions")
+			osExit(1)
+		}
+		out, err = overlay.DiffVersions(ctx.Config, args[0], diffVersions[0], diffVersions[1], opts)
+	} else {
+		out, err = overlay.Diff(ctx.Config, opts, args...)
+	}
+	if err != nil {
 		logger.Error("running git diff: %v", err)
 		osExit(1)
 	}
+	fmt.Print(out)
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -74,6 +76,61 @@ func TestDiffRejectsGitFlags(t *testing.T) {
 	}
 }
 
+// TestDiffVersionsFlag tests that --versions diffs two ebuilds of a package
+// and rejects incomplete or conflicting invocations.
+func TestDiffVersionsFlag(t *testing.T) {
+	overlayDir, cleanup := setupTestHomeWithGitRepo(t)
+	defer cleanup()
+
+	pkgDir := filepath.Join(overlayDir, "app-misc", "foo")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	for name, content := range map[string]string{
+		"foo-1.0.ebuild": "EAPI=8\nSLOT=\"0\"\n",
+		"foo-1.1.ebuild": "EAPI=8\nSLOT=\"1\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(pkgDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	origStaged, origVersions := diffStaged, diffVersions
+	defer func() { diffStaged, diffVersions = origStaged, origVersions }()
+
+	diffStaged, diffVersions = false, []string{"1.0", "1.1"}
+	var code int
+	out := captureStdout(t, func() {
+		code = withExitIntercept(func() { runDiff(diffCmd, []string{"app-misc/foo"}) })
+	})
+	if code != -1 {
+		t.Fatalf("runDiff --versions exited with %d", code)
+	}
+	if !strings.Contains(out, `-SLOT="0"`) || !strings.Contains(out, `+SLOT="1"`) {
+		t.Errorf("runDiff --versions output = %q, want the SLOT change", out)
+	}
+
+	tests := []struct {
+		name     string
+		staged   bool
+		versions []string
+		args     []string
+	}{
+		{"one version", false, []string{"1.0"}, []string{"app-misc/foo"}},
+		{"no package", false, []string{"1.0", "1.1"}, nil},
+		{"with staged", true, []string{"1.0", "1.1"}, []string{"app-misc/foo"}},
+		{"unknown version", false, []string{"1.0", "2.0"}, []string{"app-misc/foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffStaged, diffVersions = tt.staged, tt.versions
+			if code := withExitIntercept(func() { runDiff(diffCmd, tt.args) }); code != 1 {
+				t.Errorf("runDiff should exit(1), got exit(%d)", code)
+			}
+		})
+	}
+}
+
 // ---- log tests ----
 
 // TestLogDefaultCount tests that log command defaults to 10 commits.
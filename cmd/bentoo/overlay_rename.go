@@ -18,12 +18,18 @@ type RenameFlags struct {
 	Yes        bool // -y, --yes: skip confirmation prompts
 	NoManifest bool // --no-manifest: skip Manifest updates
 	Force      bool // --force: proceed despite warnings
+	// BumpRevision (--bump-revision) increments the revision of the matched
+	// version instead of renaming it
+	BumpRevision bool
+	// RenameFiles (--rename-files) renames the files/ entries named after the
+	// old version along with the ebuild
+	RenameFiles bool
 }
 
 var renameFlags RenameFlags
 
 var renameCmd = &cobra.Command{
-	Use:   "rename <category>:<package-pattern>:<old-version> => <new-version>",
+	Use:   "rename <category>:<package-pattern>:<old-version> [=> <new-version>]",
 	Short: "Bulk rename ebuilds from old version to new version",
 	Long: `Rename multiple ebuild files matching a pattern from an old version to a new version.
 
@@ -36,6 +42,12 @@ Where:
   - old-version: exact version to match (without revision suffix)
   - new-version: target version to rename to
 
+With --bump-revision the version is kept and "=> <new-version>" is left out:
+each matching package's highest revision of old-version goes up by one
+(1.0.0 → 1.0.0-r1, 1.0.0-r1 → 1.0.0-r2), and files/ entries named after
+that revision are renamed along. An old-version carrying a revision bumps
+exactly that one.
+
 Examples:
   # Rename all gst-* packages in media-plugins from 1.24.11 to 1.26.10
   bentoo overlay rename media-plugins:gst-*:1.24.11 => 1.26.10
@@ -49,9 +61,15 @@ Examples:
   # Skip confirmation prompt
   bentoo overlay rename -y media-plugins:gst-*:1.24.11 => 1.26.10
 
+  # Rename files/hello-1.0.0-fix.patch to hello-2.0.0-fix.patch as well
+  bentoo overlay rename --rename-files app-misc:hello:1.0.0 => 2.0.0
+
   # Force rename even if version-specific files exist
-  bentoo overlay rename --force media-plugins:gst-*:1.24.11 => 1.26.10`,
-	Args: cobra.ExactArgs(3),
+  bentoo overlay rename --force media-plugins:gst-*:1.24.11 => 1.26.10
+
+  # Bump app-misc/hello-1.0.0 to its next revision
+  bentoo overlay rename --bump-revision app-misc:hello:1.0.0`,
+	Args: cobra.RangeArgs(1, 3),
 	Run:  runRename,
 }
 
@@ -60,12 +78,18 @@ func init() {
 	renameCmd.Flags().BoolVarP(&renameFlags.Yes, "yes", "y", false, "Skip confirmation prompts (except for global search without --force)")
 	renameCmd.Flags().BoolVar(&renameFlags.NoManifest, "no-manifest", false, "Skip Manifest updates after renaming")
 	renameCmd.Flags().BoolVar(&renameFlags.Force, "force", false, "Proceed despite version-specific files or conflicts")
+	renameCmd.Flags().BoolVar(&renameFlags.RenameFiles, "rename-files", false, "Rename files/ entries named after <old-version> to <new-version> too")
+	renameCmd.Flags().BoolVar(&renameFlags.BumpRevision, "bump-revision", false, "Increment the -rN revision of <old-version> instead of renaming it (no \"=> <new-version>\")")
 	overlayCmd.AddCommand(renameCmd)
 }
 
 func runRename(cmd *cobra.Command, args []string) {
 	// Parse command arguments
-	spec, err := ParseRenameArgs(args)
+	parse := ParseRenameArgs
+	if renameFlags.BumpRevision {
+		parse = ParseRevisionBumpArgs
+	}
+	spec, err := parse(args)
 	if err != nil {
 		logger.Error("%v", err)
 		osExit(1)
@@ -80,14 +104,15 @@ func runRename(cmd *cobra.Command, args []string) {
 
 	// Convert flags to options
 	opts := &overlay.RenameOptions{
-		DryRun:     renameFlags.DryRun,
-		SkipPrompt: renameFlags.Yes,
-		NoManifest: renameFlags.NoManifest,
-		Force:      renameFlags.Force,
+		DryRun:      renameFlags.DryRun,
+		SkipPrompt:  renameFlags.Yes,
+		NoManifest:  renameFlags.NoManifest,
+		Force:       renameFlags.Force,
+		RenameFiles: renameFlags.RenameFiles,
 	}
 
 	// Preview mode: find matches first without executing
-	previewResult, err := overlay.RenamePreview(ctx.Config, spec)
+	previewResult, err := overlay.RenamePreviewWithOptions(ctx.Config, spec, opts)
 	if err != nil {
 		logger.Error("%v", err)
 		osExit(1)
@@ -164,6 +189,7 @@ func promptConfirmation() bool {
 // Errors for command parsing
 var (
 	ErrInvalidArgCount     = errors.New("invalid argument count: expected 3 arguments")
+	ErrBumpArgCount        = errors.New("invalid argument count: --bump-revision expects 1 argument")
 	ErrMissingSeparator    = errors.New("second argument must be '=>'")
 	ErrInvalidSpecFormat   = errors.New("first argument must be in format <category>:<package-pattern>:<old-version>")
 	ErrEmptyCategory       = errors.New("category cannot be empty")
@@ -185,15 +211,29 @@ func ParseRenameArgs(args []string) (*overlay.RenameSpec, error) {
 	}
 
 	// Parse first argument: category:package-pattern:old-version
-	parts := strings.SplitN(args[0], ":", 3)
+	spec, err := parseRenameSource(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	spec.NewVersion = strings.TrimSpace(args[2])
+	if spec.NewVersion == "" {
+		return nil, ErrEmptyNewVersion
+	}
+	return spec, nil
+}
+
+// parseRenameSource parses "<category>:<package-pattern>:<old-version>" into
+// a RenameSpec without a new version.
+func parseRenameSource(arg string) (*overlay.RenameSpec, error) {
+	parts := strings.SplitN(arg, ":", 3)
 	if len(parts) != 3 {
-		return nil, fmt.Errorf("%w: got '%s'", ErrInvalidSpecFormat, args[0])
+		return nil, fmt.Errorf("%w: got '%s'", ErrInvalidSpecFormat, arg)
 	}
 
 	category := strings.TrimSpace(parts[0])
 	packagePattern := strings.TrimSpace(parts[1])
 	oldVersion := strings.TrimSpace(parts[2])
-	newVersion := strings.TrimSpace(args[2])
 
 	// Validate components
 	if category == "" {
@@ -205,14 +245,25 @@ func ParseRenameArgs(args []string) (*overlay.RenameSpec, error) {
 	if oldVersion == "" {
 		return nil, ErrEmptyOldVersion
 	}
-	if newVersion == "" {
-		return nil, ErrEmptyNewVersion
-	}
 
 	return &overlay.RenameSpec{
 		Category:       category,
 		PackagePattern: packagePattern,
 		OldVersion:     oldVersion,
-		NewVersion:     newVersion,
 	}, nil
 }
+
+// ParseRevisionBumpArgs parses the arguments of a --bump-revision rename into
+// a RenameSpec with BumpRevision set.
+// Expected format: ["<category>:<package-pattern>:<old-version>"]
+func ParseRevisionBumpArgs(args []string) (*overlay.RenameSpec, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: got %d", ErrBumpArgCount, len(args))
+	}
+	spec, err := parseRenameSource(args[0])
+	if err != nil {
+		return nil, err
+	}
+	spec.BumpRevision = true
+	return spec, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/leanovate/gopter"
@@ -313,3 +314,22 @@ func genWrongSeparator() gopter.Gen {
 		gen.Const(""),
 	)
 }
+
+// TestParseRevisionBumpArgs tests --bump-revision takes the source alone.
+func TestParseRevisionBumpArgs(t *testing.T) {
+	spec, err := ParseRevisionBumpArgs([]string{"app-misc:hello:1.0.0-r1"})
+	if err != nil {
+		t.Fatalf("ParseRevisionBumpArgs() error = %v", err)
+	}
+	if spec.Category != "app-misc" || spec.PackagePattern != "hello" || spec.OldVersion != "1.0.0-r1" ||
+		spec.NewVersion != "" || !spec.BumpRevision {
+		t.Errorf("ParseRevisionBumpArgs() = %+v, want a bump of app-misc:hello:1.0.0-r1", spec)
+	}
+
+	if _, err := ParseRevisionBumpArgs([]string{"app-misc:hello:1.0.0", "=>", "2.0.0"}); !errors.Is(err, ErrBumpArgCount) {
+		t.Errorf("ParseRevisionBumpArgs(3 args) error = %v, want %v", err, ErrBumpArgCount)
+	}
+	if _, err := ParseRevisionBumpArgs([]string{"app-misc:hello:"}); !errors.Is(err, ErrEmptyOldVersion) {
+		t.Errorf("ParseRevisionBumpArgs(no version) error = %v, want %v", err, ErrEmptyOldVersion)
+	}
+}
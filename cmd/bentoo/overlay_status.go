@@ -1,19 +1,32 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
 	"github.com/obentoo/bentoolkit/internal/overlay"
 	"github.com/spf13/cobra"
 )
 
+// statusJSON prints the summary as JSON instead of text (--json)
+var statusJSON bool
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show the status of changes in the overlay",
-	Long:  `Display the current status of changes in the overlay repository, grouped by category/package.`,
-	Run:   runStatus,
+	Long: `Display a snapshot of the overlay: the number of packages, packages with
+pending autoupdate entries, packages missing a metadata.xml or a Manifest
+(when an ebuild fetches distfiles), and the uncommitted changes in the
+overlay repository, grouped by category/package.
+
+Use --json for machine-readable output.`,
+	Run: runStatus,
 }
 
 func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the status as JSON")
 	overlayCmd.AddCommand(statusCmd)
 }
 
@@ -24,11 +37,41 @@ func runStatus(cmd *cobra.Command, args []string) {
 		osExit(1)
 	}
 
-	statuses, err := overlay.Status(ctx.Config)
+	summary, err := overlay.Summarize(ctx.Config, pendingPackages())
 	if err != nil {
 		logger.Error("%v", err)
 		osExit(1)
 	}
 
-	logger.Info("%s", overlay.FormatStatus(statuses))
+	if statusJSON {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			logger.Error("encoding status: %v", err)
+			osExit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	logger.Info("%s", overlay.FormatOverlaySummary(summary))
+}
+
+// pendingPackages returns the packages with an autoupdate entry still to be
+// applied. A missing or unreadable pending list only costs the status that
+// line, so it is a warning rather than an error.
+func pendingPackages() []string {
+	configDir, err := autoupdateConfigDir()
+	if err != nil {
+		logger.Warn("autoupdate pending list unavailable: %v", err)
+		return nil
+	}
+	pending, err := autoupdate.NewPendingList(configDir)
+	if err != nil {
+		logger.Warn("autoupdate pending list unavailable: %v", err)
+		return nil
+	}
+	var packages []string
+	for _, u := range autoupdate.SelectPending(pending.List(), nil) {
+		packages = append(packages, u.Package)
+	}
+	return packages
 }
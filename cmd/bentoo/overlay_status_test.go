@@ -1,8 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
+	"github.com/obentoo/bentoolkit/internal/overlay"
 )
 
 // TestStatusCmd_HasRunFunction verifies that the status command has a Run or RunE function set.
@@ -42,3 +49,46 @@ func TestStatusCmd_IsRegisteredUnderOverlay(t *testing.T) {
 		t.Error("status command should be registered under overlay command")
 	}
 }
+
+// TestRunStatusJSON verifies --json prints the summary as JSON, counting the
+// overlay's packages and the ones with a pending autoupdate entry.
+func TestRunStatusJSON(t *testing.T) {
+	overlayDir, cleanup := setupTestHomeWithGitRepo(t)
+	defer cleanup()
+	pkgDir := filepath.Join(overlayDir, "app-misc", "hello")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "hello-1.0.ebuild"), []byte("# ebuild\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configDir, err := autoupdateConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := autoupdate.NewPendingList(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pending.Add(autoupdate.PendingUpdate{Package: "app-misc/hello", CurrentVersion: "1.0", NewVersion: "2.0", Status: autoupdate.StatusPending}); err != nil {
+		t.Fatal(err)
+	}
+
+	statusJSON = true
+	defer func() { statusJSON = false }()
+	out := captureStdout(t, func() {
+		if code := withExitIntercept(func() { runStatus(statusCmd, nil) }); code != -1 {
+			t.Errorf("runStatus exited with %d", code)
+		}
+	})
+
+	var summary overlay.OverlaySummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if summary.Packages != 1 || !slices.Equal(summary.Pending, []string{"app-misc/hello"}) ||
+		!slices.Equal(summary.MissingMetadata, []string{"app-misc/hello"}) {
+		t.Errorf("summary = %+v, want app-misc/hello counted, pending and missing metadata.xml", summary)
+	}
+}
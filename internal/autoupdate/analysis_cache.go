@@ -55,6 +55,9 @@ type AnalysisCache struct {
 	mu sync.RWMutex
 	// nowFunc allows injecting time for testing
 	nowFunc func() time.Time
+	// readOnly keeps every change in memory and skips the save. Set by
+	// NewAnalyzer under WithAnalyzerReadOnly.
+	readOnly bool
 }
 
 // AnalysisCacheOption is a functional option for configuring AnalysisCache
@@ -210,6 +213,9 @@ func (c *AnalysisCache) Save() error {
 // saveUnsafe persists the analysis cache to disk without locking.
 // Caller must hold the write lock.
 func (c *AnalysisCache) saveUnsafe() error {
+	if c.readOnly {
+		return nil
+	}
 	cf := analysisCacheFile{
 		Entries: c.Entries,
 	}
@@ -0,0 +1,128 @@
+// Package autoupdate provides a discovery-only cost estimate for batch analysis.
+package autoupdate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Token estimates for a single LLM schema analysis. buildSchemaAnalysisPrompt
+// truncates the fetched content to 4000 characters and adds roughly 600
+// characters of instructions and package metadata; at ~4 characters per token
+// that is about 1200 input tokens. The response is a short JSON object well
+// under the 1000-token cap requested from the provider.
+const (
+	// EstimatedInputTokensPerAnalysis is the approximate prompt size, in tokens,
+	// of one LLM schema analysis.
+	EstimatedInputTokensPerAnalysis = 1200
+	// EstimatedOutputTokensPerAnalysis is the approximate response size, in
+	// tokens, of one LLM schema analysis.
+	EstimatedOutputTokensPerAnalysis = 300
+)
+
+// AnalysisEstimate summarizes what AnalyzeAll would do without running it.
+// Every package lacking a schema lands in exactly one of the package lists.
+type AnalysisEstimate struct {
+	// Structured lists packages with a structured (JSON API) data source, e.g.
+	// GitHub releases, PyPI, npm or crates.io, whose schema can be derived
+	// from the known response shape without the LLM.
+	Structured []string
+	// LLM lists packages whose only candidate sources are opaque pages (e.g.
+	// an HTML homepage), so analysis falls through to the LLM.
+	LLM []string
+	// Cached lists packages with a fresh analysis cache entry; they are served
+	// from the cache and cost nothing.
+	Cached []string
+	// NoSource lists packages for which discovery found no candidate source;
+	// AnalyzeAll would fail them with ErrNoDataSources.
+	NoSource []string
+	// Failures records packages whose ebuild metadata could not be read, keyed
+	// by package name.
+	Failures map[string]error
+	// EstimatedInputTokens is the approximate total prompt size, in tokens,
+	// across every package in LLM.
+	EstimatedInputTokens int
+	// EstimatedOutputTokens is the approximate total response size, in tokens,
+	// across every package in LLM.
+	EstimatedOutputTokens int
+}
+
+// Total returns the number of packages the estimate covers.
+func (e *AnalysisEstimate) Total() int {
+	return len(e.Structured) + len(e.LLM) + len(e.Cached) + len(e.NoSource) + len(e.Failures)
+}
+
+// EstimatedCost returns the approximate spend for the LLM-bound packages given
+// provider prices in currency units per million input and output tokens.
+// Prices vary by provider and model, so they are supplied by the caller rather
+// than hardcoded here.
+func (e *AnalysisEstimate) EstimatedCost(inputPerMTok, outputPerMTok float64) float64 {
+	return float64(e.EstimatedInputTokens)/1e6*inputPerMTok +
+		float64(e.EstimatedOutputTokens)/1e6*outputPerMTok
+}
+
+// EstimateAnalysis runs data source discovery for every package AnalyzeAll
+// would process and classifies each one by whether a structured source is
+// available, without fetching anything or calling the LLM. It lets a user gauge
+// how many packages (and roughly how many tokens) a batch analysis of a large
+// overlay will send to the LLM before paying for it.
+//
+// opts.URL and opts.NoCache are honoured exactly as Analyze honours them: a
+// provided URL joins every package's candidate list, and NoCache disables the
+// cache short-circuit. A package whose metadata cannot be read is recorded in
+// Failures; only a failure to enumerate the overlay is returned as an error.
+func (a *Analyzer) EstimateAnalysis(opts AnalyzeOptions) (*AnalysisEstimate, error) {
+	estimate := &AnalysisEstimate{
+		Failures: make(map[string]error),
+	}
+
+	packages, err := a.findPackagesWithoutSchemas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find packages: %w", err)
+	}
+	sort.Strings(packages)
+
+	for _, pkg := range packages {
+		if !opts.NoCache && a.cache != nil {
+			if _, ok := a.cache.Get(pkg); ok {
+				estimate.Cached = append(estimate.Cached, pkg)
+				continue
+			}
+		}
+
+		meta, err := ExtractEbuildMetadata(a.overlayPath, pkg)
+		if err != nil {
+			estimate.Failures[pkg] = err
+			continue
+		}
+
+		sources := DiscoverDataSources(meta, opts.URL, WithGiteaHosts(a.giteaHosts...))
+		switch {
+		case len(sources) == 0:
+			estimate.NoSource = append(estimate.NoSource, pkg)
+		case hasStructuredSource(sources):
+			estimate.Structured = append(estimate.Structured, pkg)
+		default:
+			estimate.LLM = append(estimate.LLM, pkg)
+		}
+	}
+
+	estimate.EstimatedInputTokens = len(estimate.LLM) * EstimatedInputTokensPerAnalysis
+	estimate.EstimatedOutputTokens = len(estimate.LLM) * EstimatedOutputTokensPerAnalysis
+
+	return estimate, nil
+}
+
+// hasStructuredSource reports whether any candidate source returns JSON. The
+// registry sources (GitHub, PyPI, npm, crates.io, RubyGems, Hex.pm) all do, as does a provided
+// URL that detectContentType recognizes as an API endpoint; a homepage is HTML.
+// A GNU release listing is HTML and a SourceForge RSS is XML, but both are
+// analyzed without the LLM, so they count too.
+func hasStructuredSource(sources []DataSource) bool {
+	for _, s := range sources {
+		if s.ContentType == ContentTypeJSON || s.Type == "gnu" || s.Type == "sourceforge" {
+			return true
+		}
+	}
+	return false
+}
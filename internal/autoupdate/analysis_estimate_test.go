@@ -0,0 +1,126 @@
+package autoupdate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeEstimateEbuild writes a minimal ebuild with the given HOMEPAGE and
+// SRC_URI into overlay/<pkg>.
+func writeEstimateEbuild(t *testing.T, overlay, pkg, homepage, srcURI string) {
+	t.Helper()
+	dir := filepath.Join(overlay, pkg)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", dir, err)
+	}
+	content := "EAPI=8\nHOMEPAGE=\"" + homepage + "\"\nSRC_URI=\"" + srcURI + "\"\n"
+	if err := os.WriteFile(filepath.Join(dir, filepath.Base(pkg)+"-1.0.ebuild"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write ebuild: %v", err)
+	}
+}
+
+// TestEstimateAnalysisMixedOverlay classifies a mixed overlay: GitHub-hosted
+// packages need no LLM, homepage-only packages do, and packages that already
+// have a schema are not counted at all.
+func TestEstimateAnalysisMixedOverlay(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlay := filepath.Join(tmpDir, "overlay")
+
+	writeEstimateEbuild(t, overlay, "app-misc/gh-one", "https://github.com/owner/gh-one", "")
+	writeEstimateEbuild(t, overlay, "app-misc/gh-two", "https://example.org/two",
+		"https://github.com/owner/gh-two/archive/v1.0.tar.gz")
+	writeEstimateEbuild(t, overlay, "dev-python/pypkg", "https://pypi.org/project/pypkg/", "")
+	writeEstimateEbuild(t, overlay, "net-misc/opaque-a", "https://opaque-a.example.com/", "")
+	writeEstimateEbuild(t, overlay, "net-misc/opaque-b", "https://opaque-b.example.com/download", "")
+	writeEstimateEbuild(t, overlay, "net-misc/nowhere", "", "")
+	writeEstimateEbuild(t, overlay, "net-misc/has-schema", "https://has-schema.example.com/", "")
+
+	cfg := &PackagesConfig{Packages: map[string]PackageConfig{
+		"net-misc/has-schema": {URL: "https://has-schema.example.com/", Parser: "regex", Pattern: `(\d+)`},
+	}}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerConfigDir(filepath.Join(tmpDir, "config")),
+		WithAnalyzerPackagesConfig(cfg),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	est, err := analyzer.EstimateAnalysis(AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("EstimateAnalysis: %v", err)
+	}
+
+	if want := []string{"app-misc/gh-one", "app-misc/gh-two", "dev-python/pypkg"}; !reflect.DeepEqual(est.Structured, want) {
+		t.Errorf("Structured = %v, want %v", est.Structured, want)
+	}
+	if want := []string{"net-misc/opaque-a", "net-misc/opaque-b"}; !reflect.DeepEqual(est.LLM, want) {
+		t.Errorf("LLM = %v, want %v", est.LLM, want)
+	}
+	if want := []string{"net-misc/nowhere"}; !reflect.DeepEqual(est.NoSource, want) {
+		t.Errorf("NoSource = %v, want %v", est.NoSource, want)
+	}
+	if est.Total() != 6 {
+		t.Errorf("Total() = %d, want 6 (schema'd package excluded)", est.Total())
+	}
+	if est.EstimatedInputTokens != 2*EstimatedInputTokensPerAnalysis {
+		t.Errorf("EstimatedInputTokens = %d, want %d", est.EstimatedInputTokens, 2*EstimatedInputTokensPerAnalysis)
+	}
+	if est.EstimatedOutputTokens != 2*EstimatedOutputTokensPerAnalysis {
+		t.Errorf("EstimatedOutputTokens = %d, want %d", est.EstimatedOutputTokens, 2*EstimatedOutputTokensPerAnalysis)
+	}
+}
+
+// TestEstimateAnalysisCachedAndProvidedURL checks that cached packages are free
+// and that a provided JSON API URL makes every package structured.
+func TestEstimateAnalysisCachedAndProvidedURL(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlay := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	writeEstimateEbuild(t, overlay, "net-misc/cached", "https://cached.example.com/", "")
+	writeEstimateEbuild(t, overlay, "net-misc/opaque", "https://opaque.example.com/", "")
+
+	cache, err := NewAnalysisCache(configDir)
+	if err != nil {
+		t.Fatalf("NewAnalysisCache: %v", err)
+	}
+	if err := cache.Set("net-misc/cached", &PackageConfig{URL: "https://cached.example.com/", Parser: "regex", Pattern: `(\d+)`}, "https://cached.example.com/"); err != nil {
+		t.Fatalf("cache.Set: %v", err)
+	}
+
+	analyzer, err := NewAnalyzer(overlay, WithAnalyzerConfigDir(configDir), WithAnalyzerCache(cache))
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	est, err := analyzer.EstimateAnalysis(AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("EstimateAnalysis: %v", err)
+	}
+	if want := []string{"net-misc/cached"}; !reflect.DeepEqual(est.Cached, want) {
+		t.Errorf("Cached = %v, want %v", est.Cached, want)
+	}
+	if want := []string{"net-misc/opaque"}; !reflect.DeepEqual(est.LLM, want) {
+		t.Errorf("LLM = %v, want %v", est.LLM, want)
+	}
+
+	// NoCache bypasses the cache and a provided API URL is structured.
+	est, err = analyzer.EstimateAnalysis(AnalyzeOptions{NoCache: true, URL: "https://api.example.com/releases.json"})
+	if err != nil {
+		t.Fatalf("EstimateAnalysis: %v", err)
+	}
+	if len(est.Cached) != 0 || len(est.LLM) != 0 || len(est.Structured) != 2 {
+		t.Errorf("got Cached=%v LLM=%v Structured=%v, want 2 structured only", est.Cached, est.LLM, est.Structured)
+	}
+}
+
+// TestAnalysisEstimateCost checks the per-million-token cost arithmetic.
+func TestAnalysisEstimateCost(t *testing.T) {
+	est := &AnalysisEstimate{EstimatedInputTokens: 2_000_000, EstimatedOutputTokens: 500_000}
+	if got := est.EstimatedCost(0.25, 1.25); got != 0.5+0.625 {
+		t.Errorf("EstimatedCost() = %v, want %v", got, 0.5+0.625)
+	}
+}
@@ -0,0 +1,70 @@
+// Package autoupdate provides the accept/reject step that follows Analyze.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// ErrNoSchema is returned by AcceptSchema when there is no schema to save.
+var ErrNoSchema = errors.New("no schema to accept")
+
+// Analyze only suggests: it never writes packages.toml. A caller reviewing
+// the suggestion (the `analyze --interactive` loop) inspects
+// AnalyzeResult.SuggestedSchema together with SampleContent and
+// ExtractedVersion, optionally edits the schema and re-checks it with
+// PreviewSchema, then records the decision with AcceptSchema or RejectSchema.
+
+// PreviewSchema validates schema, typically an edited copy of
+// result.SuggestedSchema, against result's ebuild version. The sample fetched
+// during analysis is reused while the URL is unchanged, so tweaking a path or
+// pattern costs no request; a new URL is fetched.
+func (a *Analyzer) PreviewSchema(result *AnalyzeResult, schema *PackageConfig) (*ValidationResult, error) {
+	content := result.SampleContent
+	if content == nil || result.SuggestedSchema == nil || schema.URL != result.SuggestedSchema.URL {
+		fetched, err := a.fetchContentFromURL(schema.URL, schema.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch content for validation: %w", err)
+		}
+		content = fetched
+	}
+	return ValidateSchema(content, schema, result.EbuildVersion), nil
+}
+
+// AcceptSchema validates schema and saves it as pkg's entry in
+// packages.toml, merged with the entries currently on disk. The analysis
+// cache is updated too, so a later analyze of pkg replays the accepted
+// schema rather than the original suggestion.
+func (a *Analyzer) AcceptSchema(pkg string, schema *PackageConfig) error {
+	if schema == nil {
+		return fmt.Errorf("%w for %s", ErrNoSchema, pkg)
+	}
+	if err := ValidatePackageConfig(pkg, schema); err != nil {
+		return err
+	}
+	if err := a.LoadAndMergeSchema(pkg, schema); err != nil {
+		return err
+	}
+	if a.cache != nil {
+		if err := a.cache.Set(pkg, schema, schema.URL); err != nil {
+			logger.Debug("cache write failed for %s: %v", pkg, err)
+		}
+	}
+	return nil
+}
+
+// RejectSchema discards the suggestion for pkg. packages.toml is left as it
+// is, including any schema pkg already had; only the cached analysis is
+// dropped, so the next analyze of pkg asks again instead of replaying the
+// rejected suggestion.
+func (a *Analyzer) RejectSchema(pkg string) error {
+	if a.cache == nil {
+		return nil
+	}
+	if err := a.cache.Delete(pkg); err != nil {
+		return fmt.Errorf("failed to drop cached analysis for %s: %w", pkg, err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// analyzeForReview runs Analyze on app-misc/test 1.0.0 against a JSON source
+// reporting that version, with packages.toml already holding one other entry.
+// Without an LLM the heuristic suggestion need not validate; the tests edit it.
+func analyzeForReview(t *testing.T) (*Analyzer, *AnalyzeResult, string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"version": "1.0.0", "stable": "1.0.0"})
+	}))
+	t.Cleanup(server.Close)
+
+	overlay, configPath := writePackagesTOML(t, `["app-misc/other"]
+url = "https://example.com/other"
+parser = "json"
+path = "version"
+`)
+	pkgDir := filepath.Join(overlay, "app-misc", "test")
+	if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "test-1.0.0.ebuild"), []byte("EAPI=8\nHOMEPAGE=\"https://example.com\"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	rateLimiter := createFastRateLimiter()
+	setFastHTTPLimit(rateLimiter, server.URL)
+	cfg, err := LoadPackagesConfig(overlay)
+	if err != nil {
+		t.Fatalf("LoadPackagesConfig: %v", err)
+	}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerPackagesConfig(cfg),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	before, _ := os.ReadFile(configPath)
+	result, err := analyzer.Analyze("app-misc/test", AnalyzeOptions{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if result.SuggestedSchema == nil || len(result.SampleContent) == 0 {
+		t.Fatalf("Analyze result = schema %+v, %d sample bytes; want a suggestion with its sample",
+			result.SuggestedSchema, len(result.SampleContent))
+	}
+	if after, _ := os.ReadFile(configPath); string(after) != string(before) {
+		t.Fatalf("Analyze wrote packages.toml:\n%s", after)
+	}
+	return analyzer, result, overlay
+}
+
+// TestAcceptSchema_SavesEditedSchema verifies AcceptSchema persists an edited
+// suggestion next to the existing entries.
+func TestAcceptSchema_SavesEditedSchema(t *testing.T) {
+	analyzer, result, overlay := analyzeForReview(t)
+
+	edited := *result.SuggestedSchema
+	edited.Parser = "json"
+	edited.Path = "stable"
+	edited.Selector = ""
+	edited.FallbackParser = ""
+	preview, err := analyzer.PreviewSchema(result, &edited)
+	if err != nil || !preview.Valid || preview.ExtractedVersion != "1.0.0" {
+		t.Fatalf("PreviewSchema = %+v, %v; want valid 1.0.0", preview, err)
+	}
+
+	if err := analyzer.AcceptSchema("app-misc/test", &edited); err != nil {
+		t.Fatalf("AcceptSchema: %v", err)
+	}
+	cfg, err := LoadPackagesConfig(overlay)
+	if err != nil {
+		t.Fatalf("LoadPackagesConfig: %v", err)
+	}
+	if got := cfg.Packages["app-misc/test"]; got.Path != "stable" || got.URL != edited.URL {
+		t.Errorf("saved schema = %+v, want the edited one", got)
+	}
+	if _, ok := cfg.Packages["app-misc/other"]; !ok {
+		t.Error("existing entry lost")
+	}
+	if cached, ok := analyzer.Cache().Get("app-misc/test"); !ok || cached.Path != "stable" {
+		t.Errorf("analysis cache = %+v, want the accepted schema", cached)
+	}
+}
+
+// TestAcceptSchema_RejectsInvalid verifies an invalid or missing schema is
+// not saved.
+func TestAcceptSchema_RejectsInvalid(t *testing.T) {
+	analyzer, result, overlay := analyzeForReview(t)
+
+	if err := analyzer.AcceptSchema("app-misc/test", nil); !errors.Is(err, ErrNoSchema) {
+		t.Errorf("AcceptSchema(nil) = %v, want ErrNoSchema", err)
+	}
+	broken := *result.SuggestedSchema
+	broken.URL = ""
+	if err := analyzer.AcceptSchema("app-misc/test", &broken); err == nil {
+		t.Error("AcceptSchema accepted a schema without url")
+	}
+	cfg, _ := LoadPackagesConfig(overlay)
+	if _, ok := cfg.Packages["app-misc/test"]; ok {
+		t.Error("invalid schema was saved")
+	}
+}
+
+// TestRejectSchema_LeavesConfigUntouched verifies RejectSchema only drops the
+// cached suggestion.
+func TestRejectSchema_LeavesConfigUntouched(t *testing.T) {
+	analyzer, _, overlay := analyzeForReview(t)
+	configPath := filepath.Join(overlay, ".autoupdate", "packages.toml")
+	before, _ := os.ReadFile(configPath)
+
+	if _, ok := analyzer.Cache().Get("app-misc/test"); !ok {
+		t.Fatal("suggestion not cached by Analyze")
+	}
+	if err := analyzer.RejectSchema("app-misc/test"); err != nil {
+		t.Fatalf("RejectSchema: %v", err)
+	}
+	if after, _ := os.ReadFile(configPath); string(after) != string(before) {
+		t.Errorf("RejectSchema changed packages.toml:\n%s", after)
+	}
+	if _, ok := analyzer.Cache().Get("app-misc/test"); ok {
+		t.Error("rejected suggestion still cached")
+	}
+}
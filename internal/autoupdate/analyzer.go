@@ -6,10 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -100,11 +102,16 @@ type AnalyzeOptions struct {
 	URL string
 	// Hint provides user guidance to the LLM
 	Hint string
-	// NoCache bypasses all caches
+	// NoCache bypasses all caches: the analysis cache and the LLM cache are
+	// neither read nor filled
 	NoCache bool
-	// Force overwrites existing schema
+	// Force analyzes a package even when it already has a schema
 	Force bool
-	// DryRun shows schema without saving
+	// DryRun does all the fetching and LLM work and returns the suggested
+	// schema, but writes nothing: the analysis and LLM caches are read, not
+	// filled. Analyze and AnalyzeAll never save to packages.toml themselves;
+	// the caller decides whether to call SaveSchema. WithAnalyzerReadOnly
+	// makes SaveSchema a no-op too.
 	DryRun bool
 }
 
@@ -126,12 +133,22 @@ type AnalyzeResult struct {
 	DataSource *DataSource
 	// FromCache indicates if the result was from cache
 	FromCache bool
+	// SampleContent is the body fetched from SuggestedSchema.URL to validate
+	// it, kept so a reviewer can see what the schema reads and PreviewSchema
+	// can re-check an edit without another request
+	SampleContent []byte
 }
 
 // DefaultLLMTimeout is the default per-operation timeout applied to a single
 // LLM analysis call when no explicit timeout is configured on the Analyzer.
 const DefaultLLMTimeout = 60 * time.Second
 
+// DefaultAnalyzerConcurrency is the number of packages AnalyzeAll analyzes at
+// once unless WithMaxConcurrency says otherwise. It is kept low because most
+// analyses end in an LLM call, whose provider limits are far tighter than any
+// forge's.
+const DefaultAnalyzerConcurrency = 3
+
 // Analyzer handles package analysis and schema generation.
 // It coordinates between ebuild metadata extraction, data source discovery,
 // LLM analysis, and schema validation.
@@ -144,8 +161,19 @@ type Analyzer struct {
 	llmClient LLMProvider
 	// httpClient handles HTTP requests with retry logic
 	httpClient *RetryableHTTPClient
+	// githubTokenFromFile is the token read by WithAnalyzerGitHubTokenFile
+	githubTokenFromFile string
+	// giteaHosts are the self-hosted Gitea instances discovery recognizes
+	// besides DefaultGiteaHosts. Set via WithAnalyzerGiteaHosts.
+	giteaHosts []string
 	// cache manages LLM analysis caching
 	cache *AnalysisCache
+	// llmCache answers AnalyzeContent for content already analyzed. Set via
+	// WithAnalyzerLLMCache; nil disables it.
+	llmCache *LLMCache
+	// readOnly suppresses every write the Analyzer would make. Set via
+	// WithAnalyzerReadOnly.
+	readOnly bool
 	// rateLimiter manages request rate limiting
 	rateLimiter *RateLimiter
 	// configDir is the directory for storing cache files
@@ -161,6 +189,16 @@ type Analyzer struct {
 	// llmTimeout bounds a single LLM analysis operation. Defaults to
 	// DefaultLLMTimeout.
 	llmTimeout time.Duration
+	// llmProviders builds the providers for packages whose existing schema
+	// carries an llm override, applied on top of the global configuration set
+	// via WithAnalyzerLLMConfig.
+	llmProviders *llmProviderPool
+	// maxConcurrency bounds the packages AnalyzeAll analyzes at once.
+	// Defaults to DefaultAnalyzerConcurrency.
+	maxConcurrency int
+	// usageBase is llmClient's token usage when the Analyzer was built, which
+	// LLMUsage leaves out.
+	usageBase TokenUsage
 }
 
 // AnalyzerOption is a functional option for configuring Analyzer.
@@ -190,6 +228,19 @@ func WithAnalyzerCache(cache *AnalysisCache) AnalyzerOption {
 	}
 }
 
+// WithAnalyzerLLMCache answers schema analysis from cache when the same
+// provider and model already analyzed the same content with the same prompt.
+// A hit skips the LLM call and its rate limit wait entirely. Unlike
+// WithAnalyzerCache, which holds the schema per package for a day, it is
+// keyed by content, so it also spares AnalyzeAll and --force re-runs over
+// unchanged pages. A nil cache disables it.
+func WithAnalyzerLLMCache(cache *LLMCache) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.llmCache = cache
+		return nil
+	}
+}
+
 // WithAnalyzerRateLimiter sets a custom rate limiter for the analyzer.
 func WithAnalyzerRateLimiter(limiter *RateLimiter) AnalyzerOption {
 	return func(a *Analyzer) error {
@@ -228,6 +279,16 @@ func WithAnalyzerContext(ctx context.Context) AnalyzerOption {
 	}
 }
 
+// WithAnalyzerLLMConfig sets the global LLM configuration that per-package llm
+// overrides are applied on top of. It does not wire a provider for packages
+// without an override; use WithAnalyzerLLMClient for that.
+func WithAnalyzerLLMConfig(cfg LLMConfig) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.llmProviders = newLLMProviderPool(cfg)
+		return nil
+	}
+}
+
 // WithAnalyzerOpTimeout sets the per-operation timeout used to derive a child
 // context for each outbound HTTP fetch. A non-positive duration is rejected.
 func WithAnalyzerOpTimeout(d time.Duration) AnalyzerOption {
@@ -252,17 +313,29 @@ func WithAnalyzerLLMTimeout(d time.Duration) AnalyzerOption {
 	}
 }
 
+// WithMaxConcurrency sets how many packages AnalyzeAll analyzes at once,
+// DefaultAnalyzerConcurrency by default. Raise it where the rate limits
+// allow, lower it on a slow machine; n below 1 is clamped to 1, analyzing
+// one package at a time.
+func WithMaxConcurrency(n int) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.maxConcurrency = max(n, 1)
+		return nil
+	}
+}
+
 // NewAnalyzer creates a new analyzer instance for the given overlay.
 func NewAnalyzer(overlayPath string, opts ...AnalyzerOption) (*Analyzer, error) {
 	// Determine config directory
 	configDir := filepath.Join(os.Getenv("HOME"), ".config", "bentoo", "autoupdate")
 
 	analyzer := &Analyzer{
-		overlayPath: overlayPath,
-		configDir:   configDir,
-		ctx:         context.Background(), // SAFE: default parent; replaced by WithAnalyzerContext when cmd/ wires signal.NotifyContext
-		opTimeout:   DefaultOpTimeout,
-		llmTimeout:  DefaultLLMTimeout,
+		overlayPath:    overlayPath,
+		configDir:      configDir,
+		ctx:            context.Background(), // SAFE: default parent; replaced by WithAnalyzerContext when cmd/ wires signal.NotifyContext
+		opTimeout:      DefaultOpTimeout,
+		llmTimeout:     DefaultLLMTimeout,
+		maxConcurrency: DefaultAnalyzerConcurrency,
 	}
 
 	// Apply options first to allow overriding configDir
@@ -297,20 +370,50 @@ func NewAnalyzer(overlayPath string, opts ...AnalyzerOption) (*Analyzer, error)
 		}
 		analyzer.cache = cache
 	}
+	if analyzer.readOnly {
+		analyzer.cache.readOnly = true
+		if analyzer.llmCache != nil {
+			analyzer.llmCache.readOnly = true
+		}
+	}
 
 	// Initialize rate limiter if not provided
 	if analyzer.rateLimiter == nil {
 		analyzer.rateLimiter = NewRateLimiter()
 	}
+	if err := loadRateLimits(analyzer.configDir, analyzer.rateLimiter); err != nil {
+		return nil, fmt.Errorf("failed to load rate limits: %w", err)
+	}
 
 	// Initialize HTTP client if not provided
 	if analyzer.httpClient == nil {
 		analyzer.httpClient = NewRetryableHTTPClient()
 	}
+	// Discovery and analysis read GitHub's API too.
+	applyGitHubToken(analyzer.httpClient, analyzer.githubTokenFromFile)
+
+	if analyzer.llmProviders == nil {
+		analyzer.llmProviders = newLLMProviderPool(LLMConfig{})
+	}
+	analyzer.usageBase = LLMUsage(analyzer.llmClient)
 
 	return analyzer, nil
 }
 
+// LLMUsage returns the tokens the Analyzer's LLM providers used since it was
+// built, per-package overrides included, as their APIs reported them: after
+// AnalyzeAll, the cost of the run. Answers from the LLM cache cost nothing. A
+// provider that does not report usage (the claude-code CLI) counts as zero.
+// The global client's calls are counted even when another component, such as
+// a Checker sharing it, made them.
+func (a *Analyzer) LLMUsage() TokenUsage {
+	usage := LLMUsage(a.llmClient).Sub(a.usageBase)
+	for _, provider := range a.llmProviders.built() {
+		usage = usage.Add(LLMUsage(provider))
+	}
+	return usage
+}
+
 // Analyze analyzes a single package and suggests a schema.
 func (a *Analyzer) Analyze(pkg string, opts AnalyzeOptions) (*AnalyzeResult, error) {
 	result := &AnalyzeResult{
@@ -344,12 +447,20 @@ func (a *Analyzer) Analyze(pkg string, opts AnalyzeOptions) (*AnalyzeResult, err
 	result.EbuildVersion = meta.Version
 
 	// Discover data sources
-	sources := DiscoverDataSources(meta, opts.URL)
+	sources := DiscoverDataSources(meta, opts.URL, WithGiteaHosts(a.giteaHosts...))
 	if len(sources) == 0 {
 		result.Error = fmt.Errorf("%w: %s", ErrNoDataSources, pkg)
 		return result, result.Error
 	}
 
+	// A re-analysis (Force) of a package whose schema sets an llm override
+	// uses that provider instead of the global one.
+	var override *PackageLLMConfig
+	if existing, ok := a.config.Packages[pkg]; ok {
+		override = existing.LLM
+	}
+	llm := a.llmProviders.providerFor(pkg, override, a.llmClient)
+
 	// Try each data source until one succeeds
 	var lastErr error
 	for _, source := range sources {
@@ -361,7 +472,7 @@ func (a *Analyzer) Analyze(pkg string, opts AnalyzeOptions) (*AnalyzeResult, err
 		}
 
 		// Analyze content with LLM (if available)
-		schema, err := a.analyzeContent(content, meta, opts.Hint, &source)
+		schema, err := a.analyzeContentWith(llm, content, meta, &source, opts)
 		if err != nil {
 			lastErr = err
 			continue
@@ -371,7 +482,7 @@ func (a *Analyzer) Analyze(pkg string, opts AnalyzeOptions) (*AnalyzeResult, err
 		result.DataSource = &source
 
 		// Cache the analysis result
-		if !opts.NoCache && a.cache != nil {
+		if !opts.NoCache && !opts.DryRun && a.cache != nil {
 			if cacheErr := a.cache.Set(pkg, schema, source.URL); cacheErr != nil {
 				logger.Debug("cache write failed for %s: %v", pkg, cacheErr)
 			}
@@ -407,12 +518,14 @@ func (a *Analyzer) validateResult(result *AnalyzeResult, opts AnalyzeOptions) (*
 	}
 
 	// Fetch content for validation
-	content, err := a.fetchContentFromURL(result.SuggestedSchema.URL)
+	content, err := a.fetchContentFromURL(result.SuggestedSchema.URL, result.SuggestedSchema.Headers)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to fetch content for validation: %w", err)
 		return result, result.Error
 	}
 
+	result.SampleContent = content
+
 	// Validate schema
 	validationResult := ValidateSchema(content, result.SuggestedSchema, result.EbuildVersion)
 	result.ExtractedVersion = validationResult.ExtractedVersion
@@ -440,18 +553,19 @@ func (a *Analyzer) fetchContent(source DataSource) ([]byte, error) {
 		return nil, fmt.Errorf("rate limit error: %w", err)
 	}
 
-	return a.fetchContentFromURL(source.URL)
+	return a.fetchContentFromURL(source.URL, source.Headers)
 }
 
-// fetchContentFromURL fetches content from a URL. The request is bounded by a
+// fetchContentFromURL fetches content from a URL, sending headers (a source's
+// or a schema's) on top of the client's defaults. The request is bounded by a
 // child of the Analyzer's parent context (set via WithAnalyzerContext) with the
 // configured per-operation timeout, so a cancelled parent context or an expired
 // deadline aborts the in-flight HTTP call.
-func (a *Analyzer) fetchContentFromURL(url string) ([]byte, error) {
+func (a *Analyzer) fetchContentFromURL(url string, headers map[string]string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(a.ctx, a.opTimeout)
 	defer cancel()
 
-	resp, err := a.httpClient.GetWithContext(ctx, url)
+	resp, err := a.httpClient.GetWithHeadersContext(ctx, url, headers)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -464,17 +578,49 @@ func (a *Analyzer) fetchContentFromURL(url string) ([]byte, error) {
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
 		// Translate an http.MaxBytesReader overflow into ErrResponseTooLarge
-		// (R11.3); GetWithContext caps the body at httputil.MaxBodyBytes.
+		// (R11.3); the client caps the body at RetryConfig.MaxBodySize, and
+		// Analyze moves on to the next candidate source.
 		return nil, fmt.Errorf("failed to read response body: %w", classifyBodyReadError(err))
 	}
 
 	return content, nil
 }
 
-// analyzeContent analyzes content and generates a schema.
-func (a *Analyzer) analyzeContent(content []byte, meta *EbuildMetadata, hint string, source *DataSource) (*PackageConfig, error) {
+// analyzeContent analyzes content with llm, the package's effective provider,
+// and generates a schema. A nil llm falls back to a content-type heuristic.
+func (a *Analyzer) analyzeContent(llm LLMProvider, content []byte, meta *EbuildMetadata, hint string, source *DataSource) (*PackageConfig, error) {
+	return a.analyzeContentWith(llm, content, meta, source, AnalyzeOptions{Hint: hint})
+}
+
+// analyzeContentWith is analyzeContent under opts: NoCache neither reads nor
+// fills the LLM cache, and DryRun only reads it.
+func (a *Analyzer) analyzeContentWith(llm LLMProvider, content []byte, meta *EbuildMetadata, source *DataSource, opts AnalyzeOptions) (*PackageConfig, error) {
+	hint := opts.Hint
+	// A source with a known layout has nothing for the LLM to work out.
+	if source != nil {
+		if source.Type == "sourceforge" {
+			return sourceForgeSchema(meta, source), nil
+		}
+		if _, ok := fixedSourceSchemas[source.Type]; ok {
+			return a.generateDefaultSchema(content, source)
+		}
+		// Nor has a listing of the package's tarballs.
+		if schema := detectFileIndexSchema(content, meta, source); schema != nil {
+			return schema, nil
+		}
+	}
+
 	// If LLM client is available, use it for analysis
-	if a.llmClient != nil {
+	if llm != nil {
+		llmCache := a.llmCache
+		if opts.NoCache {
+			llmCache = nil
+		}
+		key := llmCache.analysisKey(llm, content, meta, hint)
+		if analysis, ok := llmCache.getAnalysis(key); ok {
+			return a.schemaFromAnalysis(analysis, source)
+		}
+
 		ctx, cancel := context.WithTimeout(a.ctx, a.llmTimeout)
 		defer cancel()
 
@@ -483,10 +629,13 @@ func (a *Analyzer) analyzeContent(content []byte, meta *EbuildMetadata, hint str
 			return nil, fmt.Errorf("LLM rate limit error: %w", err)
 		}
 
-		analysis, err := a.llmClient.AnalyzeContent(content, meta, hint)
+		analysis, err := llm.AnalyzeContent(content, meta, hint)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrAnalysisFailed, err)
 		}
+		if !opts.DryRun {
+			llmCache.setAnalysis(key, analysis)
+		}
 
 		return a.schemaFromAnalysis(analysis, source)
 	}
@@ -542,12 +691,53 @@ func (a *Analyzer) schemaFromAnalysis(analysis *SchemaAnalysis, source *DataSour
 	return schema, nil
 }
 
+// fixedSourceSchemas are the schemas for discovered source types whose layout
+// is known, keyed by DataSource.Type. They are suggested as is, without the
+// LLM and whatever the content.
+var fixedSourceSchemas = map[string]PackageConfig{
+	// GNU listings get their dedicated parser, which takes the tarball name
+	// from the listing URL.
+	"gnu": {Parser: "gnu-ftp"},
+	// package.json and composer.json both declare a top-level "version".
+	"manifest": {Parser: "json", Path: "version"},
+	// A CRAN DESCRIPTION file is read by the dcf parser's default field.
+	"cran": {Parser: "dcf"},
+	// GitLab lists releases newest first.
+	"gitlab": {Parser: "json", Path: gitlabReleasesPath},
+	// So do Gitea and Forgejo, in GitHub's layout.
+	"gitea": {Parser: "json", Path: giteaReleasesPath},
+	// A WordPress readme.txt names the release in its Stable tag header.
+	"wordpress": {Parser: "readme-txt"},
+	// The PyPI JSON API names the latest release in info.version.
+	"pypi": {Parser: "json", Path: pypiVersionPath},
+	// An npm registry document names the release tagged latest in
+	// dist-tags.latest.
+	"npm": {Parser: "json", Path: npmVersionPath},
+	// The RubyGems API names the latest release in version.
+	"rubygems": {Parser: "json", Path: rubygemsVersionPath},
+	// The Hex.pm API names the newest stable release in
+	// latest_stable_version.
+	"hex": {Parser: "json", Path: hexVersionPath},
+	// The crates.io API names the newest stable release in
+	// crate.max_stable_version.
+	"crates": {Parser: "json", Path: cratesVersionPath},
+	// The Go module proxy's @latest names the release in Version.
+	"go-module-proxy": {Parser: "json", Path: goProxyVersionPath},
+}
+
 // generateDefaultSchema generates a default schema based on content type.
 func (a *Analyzer) generateDefaultSchema(content []byte, source *DataSource) (*PackageConfig, error) {
 	schema := &PackageConfig{
 		URL: source.URL,
 	}
 
+	if fixed, ok := fixedSourceSchemas[source.Type]; ok {
+		fixed.URL = source.URL
+		// The headers the source was fetched with are needed by every check.
+		fixed.Headers = maps.Clone(source.Headers)
+		return &fixed, nil
+	}
+
 	// Determine parser based on content type
 	switch source.ContentType {
 	case ContentTypeJSON:
@@ -598,7 +788,8 @@ func detectJSONPath(content []byte) string {
 }
 
 // AnalyzeAll analyzes all packages without schemas.
-// It processes packages in parallel with a maximum of 3 concurrent analyses.
+// It processes packages in parallel, at most WithMaxConcurrency (default
+// DefaultAnalyzerConcurrency) at a time.
 //
 // It returns a BatchResult: successfully analyzed packages land in Items, while
 // a per-package failure is recorded in Failures keyed by the package name and
@@ -626,9 +817,8 @@ func (a *Analyzer) AnalyzeAll(opts AnalyzeOptions) BatchResult[AnalyzeResult] {
 		return batch
 	}
 
-	// Process packages in parallel with max 3 concurrent
-	const maxConcurrent = 3
-	sem := make(chan struct{}, maxConcurrent)
+	// Process packages in parallel, bounded by the configured limit
+	sem := make(chan struct{}, a.maxConcurrency)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
@@ -735,21 +925,19 @@ func (a *Analyzer) SaveSchema(pkg string, schema *PackageConfig) error {
 }
 
 // savePackagesConfig saves the packages configuration to disk.
-// It preserves existing entries and formats TOML consistently with sorted keys.
+// It preserves existing entries and writes them through encodePackagesTOML.
 func (a *Analyzer) savePackagesConfig() error {
 	configPath := filepath.Join(a.overlayPath, ".autoupdate", "packages.toml")
+	if a.readOnly {
+		logReadOnly("write %d package(s) to %s", len(a.config.Packages), configPath)
+		return nil
+	}
 
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(configPath), 0o750); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Convert to file format (top-level keys are package names)
-	fileConfig := make(map[string]PackageConfig)
-	for pkg, cfg := range a.config.Packages {
-		fileConfig[pkg] = cfg
-	}
-
 	// Write to temp file first for atomic operation
 	tmpPath := configPath + ".tmp"
 	f, err := os.Create(tmpPath)
@@ -757,9 +945,7 @@ func (a *Analyzer) savePackagesConfig() error {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 
-	// Use TOML encoder with consistent formatting
-	encoder := toml.NewEncoder(f)
-	if err := encoder.Encode(fileConfig); err != nil {
+	if err := encodePackagesTOML(f, a.config.Packages); err != nil {
 		f.Close()          //nolint:errcheck
 		os.Remove(tmpPath) //nolint:errcheck
 		return fmt.Errorf("failed to encode config: %w", err)
@@ -775,6 +961,30 @@ func (a *Analyzer) savePackagesConfig() error {
 	return nil
 }
 
+// encodePackagesTOML writes packages as packages.toml, one top-level table per
+// package in sorted name order, separated by a blank line. The order is fixed
+// here rather than left to the encoder's map handling, so saving after a
+// one-package change yields a diff touching only that package's table.
+func encodePackagesTOML(w io.Writer, packages map[string]PackageConfig) error {
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := toml.NewEncoder(w).Encode(map[string]PackageConfig{name: packages[name]}); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // LoadAndMergeSchema loads existing config, adds/updates a schema, and saves.
 // This ensures existing entries are preserved when adding new schemas.
 func (a *Analyzer) LoadAndMergeSchema(pkg string, schema *PackageConfig) error {
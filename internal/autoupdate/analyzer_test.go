@@ -1,6 +1,7 @@
 package autoupdate
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -9,8 +10,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -756,15 +759,17 @@ func TestSaveSchema(t *testing.T) {
 // **Feature: autoupdate-analyzer, Property 28: Parallel Processing Limit**
 // **Validates: Requirements 11.3**
 //
-// For any batch analysis operation, the analyzer SHALL process at most 3 packages concurrently.
+// For any batch analysis operation, the analyzer SHALL process at most its
+// configured limit (WithMaxConcurrency, default DefaultAnalyzerConcurrency) of
+// packages concurrently.
 func TestParallelProcessingLimit(t *testing.T) {
 	parameters := gopter.DefaultTestParameters()
 	parameters.MinSuccessfulTests = 100
 	properties := gopter.NewProperties(parameters)
 
-	// Property: AnalyzeAll processes at most 3 packages concurrently
-	properties.Property("AnalyzeAll processes at most 3 packages concurrently", prop.ForAll(
-		func(numPackages int) bool {
+	// Property: AnalyzeAll processes at most the configured limit concurrently
+	properties.Property("AnalyzeAll processes at most the configured limit concurrently", prop.ForAll(
+		func(numPackages, limit int) bool {
 			// Clamp to reasonable range
 			if numPackages < 1 {
 				numPackages = 1
@@ -825,6 +830,7 @@ HOMEPAGE="`+server.URL+`"
 			analyzer, err := NewAnalyzer(tmpDir,
 				WithAnalyzerRateLimiter(rateLimiter),
 				WithAnalyzerHTTPClient(httpClient),
+				WithMaxConcurrency(limit),
 			)
 			if err != nil {
 				return false
@@ -836,10 +842,11 @@ HOMEPAGE="`+server.URL+`"
 			}
 			_ = analyzer.AnalyzeAll(opts)
 
-			// Max concurrent should be at most 3
-			return maxConcurrent <= 3
+			// Max concurrent should be at most the configured limit
+			return maxConcurrent <= int32(analyzer.maxConcurrency)
 		},
 		gen.IntRange(1, 10),
+		gen.IntRange(1, 5),
 	))
 
 	// Property: Semaphore limits concurrent goroutines to maxConcurrent
@@ -853,7 +860,7 @@ HOMEPAGE="`+server.URL+`"
 				numGoroutines = 20
 			}
 
-			const maxConcurrent = 3
+			const maxConcurrent = DefaultAnalyzerConcurrency
 			var maxObserved int32
 			var current int32
 			var mu sync.Mutex
@@ -979,13 +986,11 @@ HOMEPAGE="`+server.URL+`"
 		gen.IntRange(1, 8),
 	))
 
-	// Property: Concurrency limit constant is 3
-	properties.Property("Concurrency limit constant is 3", prop.ForAll(
+	// Property: Without WithMaxConcurrency the limit is DefaultAnalyzerConcurrency
+	properties.Property("Default concurrency limit is DefaultAnalyzerConcurrency", prop.ForAll(
 		func(dummy int) bool {
-			// This tests that the maxConcurrent constant in AnalyzeAll is 3
-			// We verify this by checking the implementation behavior
-			const expectedMaxConcurrent = 3
-
+			// This tests that an analyzer built without WithMaxConcurrency
+			// bounds AnalyzeAll by DefaultAnalyzerConcurrency
 			var maxObserved int32
 			var current int32
 			var mu sync.Mutex
@@ -1039,7 +1044,7 @@ HOMEPAGE="`+server.URL+`"
 				WithAnalyzerRateLimiter(rateLimiter),
 				WithAnalyzerHTTPClient(httpClient),
 			)
-			if err != nil {
+			if err != nil || analyzer.maxConcurrency != DefaultAnalyzerConcurrency {
 				return false
 			}
 
@@ -1049,9 +1054,8 @@ HOMEPAGE="`+server.URL+`"
 			}
 			_ = analyzer.AnalyzeAll(opts)
 
-			// Max observed should be exactly 3 (the limit)
-			// With 6 packages and 100ms delay, we should hit the limit
-			return maxObserved <= expectedMaxConcurrent
+			// Max observed should be at most the default limit
+			return maxObserved <= int32(analyzer.maxConcurrency)
 		},
 		gen.IntRange(1, 10),
 	))
@@ -1059,6 +1063,23 @@ HOMEPAGE="`+server.URL+`"
 	properties.TestingRun(t)
 }
 
+// TestWithMaxConcurrency verifies the option sets the limit and clamps a
+// value below 1 to 1.
+func TestWithMaxConcurrency(t *testing.T) {
+	for n, want := range map[int]int{8: 8, 1: 1, 0: 1, -4: 1} {
+		analyzer, err := NewAnalyzer(t.TempDir(),
+			WithAnalyzerConfigDir(t.TempDir()),
+			WithMaxConcurrency(n),
+		)
+		if err != nil {
+			t.Fatalf("NewAnalyzer() error = %v", err)
+		}
+		if analyzer.maxConcurrency != want {
+			t.Errorf("WithMaxConcurrency(%d) limit = %d, want %d", n, analyzer.maxConcurrency, want)
+		}
+	}
+}
+
 // TestAnalyzeAll_ReturnsBatchResult verifies AnalyzeAll returns a BatchResult
 // that separates successfully analyzed packages from per-package failures.
 // Three schema-less packages are created; one points its HOMEPAGE at a server
@@ -2080,3 +2101,506 @@ func TestAnalysisCache_LazyRevalidation(t *testing.T) {
 		t.Errorf("expected Info log %q, got lines: %v", "analysis cache entry for "+pkg+" invalidated: ...", lines)
 	}
 }
+
+// TestSaveSchema_MinimalDiff verifies packages.toml is written in sorted
+// package order, so adding one package only inserts that package's lines.
+func TestSaveSchema_MinimalDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	analyzer, err := NewAnalyzer(tmpDir)
+	if err != nil {
+		t.Fatalf("NewAnalyzer failed: %v", err)
+	}
+
+	for _, name := range []string{"net-misc/zeta", "app-misc/alpha", "dev-util/mid", "app-misc/beta", "www-apps/omega"} {
+		schema := &PackageConfig{
+			URL:     "https://example.com/" + name,
+			Parser:  "json",
+			Path:    "version",
+			Headers: map[string]string{"Accept": "application/json", "X-Api": "v2"},
+		}
+		if err := analyzer.SaveSchema(name, schema); err != nil {
+			t.Fatalf("SaveSchema(%s) failed: %v", name, err)
+		}
+	}
+	configPath := filepath.Join(tmpDir, ".autoupdate", "packages.toml")
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	// Rewriting without changes must be byte-identical.
+	mid := analyzer.config.Packages["dev-util/mid"]
+	if err := analyzer.SaveSchema("dev-util/mid", &mid); err != nil {
+		t.Fatalf("SaveSchema failed: %v", err)
+	}
+	again, _ := os.ReadFile(configPath)
+	if !bytes.Equal(before, again) {
+		t.Fatalf("unchanged config rewritten differently:\n--- before\n%s\n--- after\n%s", before, again)
+	}
+
+	if err := analyzer.SaveSchema("dev-lang/new", &PackageConfig{URL: "https://example.com/new", Parser: "regex", Pattern: `v([0-9.]+)`}); err != nil {
+		t.Fatalf("SaveSchema failed: %v", err)
+	}
+	after, _ := os.ReadFile(configPath)
+
+	oldLines := strings.Split(string(before), "\n")
+	newLines := strings.Split(string(after), "\n")
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	if removed := oldLines[prefix : len(oldLines)-suffix]; len(removed) != 0 {
+		t.Errorf("adding a package changed existing lines: %q", removed)
+	}
+	added := strings.Join(newLines[prefix:len(newLines)-suffix], "\n")
+	for _, other := range []string{"alpha", "beta", "mid", "zeta", "omega"} {
+		if strings.Contains(added, other) {
+			t.Errorf("added block mentions %s:\n%s", other, added)
+		}
+	}
+	if !strings.Contains(added, `["dev-lang/new"]`) {
+		t.Errorf("added block lacks the new package:\n%s", added)
+	}
+
+	// Packages appear in sorted order.
+	var order []string
+	for _, line := range newLines {
+		if strings.HasPrefix(line, `["`) {
+			order = append(order, line)
+		}
+	}
+	if !sort.StringsAreSorted(order) {
+		t.Errorf("package tables not sorted: %v", order)
+	}
+}
+
+// redirectTransport sends every request to target, keeping the path, and
+// records the URLs that were requested.
+type redirectTransport struct {
+	target *url.URL
+	mu     sync.Mutex
+	urls   []string
+}
+
+func (rt *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.urls = append(rt.urls, req.URL.String())
+	rt.mu.Unlock()
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// analyzeCountingLLM is an LLMProvider that counts AnalyzeContent calls.
+type analyzeCountingLLM struct {
+	stubLLMProvider
+	calls atomic.Int32
+}
+
+func (l *analyzeCountingLLM) AnalyzeContent(content []byte, meta *EbuildMetadata, hint string) (*SchemaAnalysis, error) {
+	l.calls.Add(1)
+	return l.stubLLMProvider.AnalyzeContent(content, meta, hint)
+}
+
+// TestAnalyzeAll_PyPIWithoutLLM verifies a dev-python package referencing
+// pythonhosted.org is configured from the PyPI JSON API at info.version,
+// without calling the LLM.
+func TestAnalyzeAll_PyPIWithoutLLM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pypi/foo-bar/json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"info": {"name": "Foo_Bar", "version": "1.2.0"}, "releases": {}}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	transport := &redirectTransport{target: target}
+
+	overlay := t.TempDir()
+	createTestEbuildContent(t, overlay, "dev-python/foo-bar", "1.2.0", `EAPI=8
+HOMEPAGE="https://example.com/foo-bar"
+SRC_URI="https://files.pythonhosted.org/packages/source/f/Foo_Bar/Foo_Bar-1.2.0.tar.gz"
+`)
+
+	rateLimiter := createFastRateLimiter()
+	rateLimiter.SetHTTPLimit("pypi.org", rate.Inf, 1000)
+	httpClient := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})
+	httpClient.SetHTTPClient(&http.Client{Transport: transport})
+	llm := &analyzeCountingLLM{}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(httpClient),
+		WithAnalyzerLLMClient(llm),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	batch := analyzer.AnalyzeAll(AnalyzeOptions{NoCache: true})
+	if batch.HasFailures() || len(batch.Items) != 1 {
+		t.Fatalf("AnalyzeAll = %+v, failures %v", batch.Items, batch.Failures)
+	}
+	result := batch.Items[0]
+	schema := result.SuggestedSchema
+	if schema.URL != "https://pypi.org/pypi/foo-bar/json" || schema.Parser != "json" || schema.Path != "info.version" {
+		t.Errorf("SuggestedSchema = %+v, want the PyPI JSON API at info.version", schema)
+	}
+	if !result.Validated || result.ExtractedVersion != "1.2.0" {
+		t.Errorf("Validated = %v, ExtractedVersion = %q; want a validated 1.2.0", result.Validated, result.ExtractedVersion)
+	}
+	if llm.calls.Load() != 0 {
+		t.Errorf("the LLM analyzed content %d time(s) for a PyPI source", llm.calls.Load())
+	}
+}
+
+// TestAnalyzeAll_NPMScopedWithoutLLM verifies a package whose SRC_URI is a
+// scoped npm tarball is configured from the registry document at
+// dist-tags.latest, requested with the scope's slash encoded, without
+// calling the LLM.
+func TestAnalyzeAll_NPMScopedWithoutLLM(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		uris []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		uris = append(uris, r.RequestURI)
+		mu.Unlock()
+		if r.URL.EscapedPath() != "/@acme%2fcli" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "@acme/cli", "dist-tags": {"latest": "1.2.0", "next": "2.0.0-beta.1"}}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	transport := &redirectTransport{target: target}
+
+	overlay := t.TempDir()
+	createTestEbuildContent(t, overlay, "app-misc/acme-cli", "1.2.0", `EAPI=8
+HOMEPAGE="https://example.com/acme-cli"
+SRC_URI="https://registry.npmjs.org/@acme/cli/-/cli-${PV}.tgz -> ${P}.tgz"
+`)
+
+	rateLimiter := createFastRateLimiter()
+	rateLimiter.SetHTTPLimit("registry.npmjs.org", rate.Inf, 1000)
+	httpClient := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})
+	httpClient.SetHTTPClient(&http.Client{Transport: transport})
+	llm := &analyzeCountingLLM{}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(httpClient),
+		WithAnalyzerLLMClient(llm),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	batch := analyzer.AnalyzeAll(AnalyzeOptions{NoCache: true})
+	if batch.HasFailures() || len(batch.Items) != 1 {
+		t.Fatalf("AnalyzeAll = %+v, failures %v (requests %v)", batch.Items, batch.Failures, uris)
+	}
+	result := batch.Items[0]
+	schema := result.SuggestedSchema
+	if schema.URL != "https://registry.npmjs.org/@acme%2fcli" || schema.Parser != "json" || schema.Path != "dist-tags.latest" {
+		t.Errorf("SuggestedSchema = %+v, want the npm registry document at dist-tags.latest", schema)
+	}
+	if !result.Validated || result.ExtractedVersion != "1.2.0" {
+		t.Errorf("Validated = %v, ExtractedVersion = %q; want a validated 1.2.0", result.Validated, result.ExtractedVersion)
+	}
+	if llm.calls.Load() != 0 {
+		t.Errorf("the LLM analyzed content %d time(s) for an npm source", llm.calls.Load())
+	}
+}
+
+// TestAnalyze_SkipsOversizedSource verifies a candidate whose body exceeds
+// the client's MaxBodySize fails with ErrResponseTooLarge and analysis moves
+// on to the next candidate, the homepage.
+func TestAnalyze_SkipsOversizedSource(t *testing.T) {
+	const limit = 4 * 1024
+	var hugeRequested atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/huge.json" {
+			hugeRequested.Store(true)
+			w.Write(bytes.Repeat([]byte(" "), 64*limit)) //nolint:errcheck // test server
+			return
+		}
+		w.Write([]byte(`<html><body><span class="version">1.0.0</span></body></html>`)) //nolint:errcheck // test server
+	}))
+	defer server.Close()
+
+	overlay := t.TempDir()
+	createTestEbuildContent(t, overlay, "app-misc/test", "1.0.0", "EAPI=8\nHOMEPAGE=\""+server.URL+"/page\"\n")
+
+	rateLimiter := createFastRateLimiter()
+	setFastHTTPLimit(rateLimiter, server.URL)
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second, MaxBodySize: limit})),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	if _, err := analyzer.fetchContentFromURL(server.URL+"/huge.json", nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("fetchContentFromURL(oversized) error = %v, want %v", err, ErrResponseTooLarge)
+	}
+
+	result, err := analyzer.Analyze("app-misc/test", AnalyzeOptions{URL: server.URL + "/huge.json", NoCache: true})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if !hugeRequested.Load() {
+		t.Error("the oversized candidate was never tried")
+	}
+	if result.DataSource == nil || result.DataSource.Type != "homepage" {
+		t.Errorf("DataSource = %+v, want the homepage after the oversized candidate", result.DataSource)
+	}
+}
+
+// TestAnalyzeAll_HexWithoutLLM verifies a package with a Hex.pm homepage is
+// configured from the Hex.pm API at latest_stable_version, skipping the
+// pre-release listed first, and fetched with the registry User-Agent, without
+// calling the LLM.
+func TestAnalyzeAll_HexWithoutLLM(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		agents []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents = append(agents, r.Header.Get("User-Agent"))
+		mu.Unlock()
+		if r.URL.Path != "/api/packages/jason" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "jason", "latest_stable_version": "1.4.4", "releases": [{"version": "2.0.0-rc.1"}, {"version": "1.4.4"}]}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	httpClient := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})
+	httpClient.SetHTTPClient(&http.Client{Transport: &redirectTransport{target: target}})
+
+	overlay := t.TempDir()
+	createTestEbuildContent(t, overlay, "dev-elixir/jason", "1.4.4", `EAPI=8
+HOMEPAGE="https://hex.pm/packages/jason"
+`)
+
+	rateLimiter := createFastRateLimiter()
+	rateLimiter.SetHTTPLimit("hex.pm", rate.Inf, 1000)
+	llm := &analyzeCountingLLM{}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(httpClient),
+		WithAnalyzerLLMClient(llm),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	batch := analyzer.AnalyzeAll(AnalyzeOptions{NoCache: true})
+	if batch.HasFailures() || len(batch.Items) != 1 {
+		t.Fatalf("AnalyzeAll = %+v, failures %v", batch.Items, batch.Failures)
+	}
+	result := batch.Items[0]
+	schema := result.SuggestedSchema
+	if schema.URL != "https://hex.pm/api/packages/jason" || schema.Parser != "json" || schema.Path != "latest_stable_version" {
+		t.Errorf("SuggestedSchema = %+v, want the Hex.pm API at latest_stable_version", schema)
+	}
+	if schema.Headers["User-Agent"] != registryUserAgent {
+		t.Errorf("SuggestedSchema.Headers = %v, want the registry User-Agent", schema.Headers)
+	}
+	if !result.Validated || result.ExtractedVersion != "1.4.4" {
+		t.Errorf("Validated = %v, ExtractedVersion = %q; want a validated 1.4.4", result.Validated, result.ExtractedVersion)
+	}
+	if llm.calls.Load() != 0 {
+		t.Errorf("the LLM analyzed content %d time(s) for a Hex.pm source", llm.calls.Load())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, agent := range agents {
+		if agent != registryUserAgent {
+			t.Errorf("request %d User-Agent = %q, want %q", i+1, agent, registryUserAgent)
+		}
+	}
+}
+
+// TestAnalyzeAll_CratesUserAgent verifies a dev-rust package with a crates.io
+// homepage is configured from the crates.io API at crate.max_stable_version
+// with the User-Agent crates.io requires, and that the analysis fetch, the
+// validation fetch and a later CheckPackage all send it.
+func TestAnalyzeAll_CratesUserAgent(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		agents []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents = append(agents, r.Header.Get("User-Agent"))
+		mu.Unlock()
+		if r.URL.Path != "/api/v1/crates/foo-rs" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"crate": {"name": "foo-rs", "max_version": "1.5.0-rc.1", "max_stable_version": "1.4.0"}}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	transport := &redirectTransport{target: target}
+	httpClient := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})
+	httpClient.SetHTTPClient(&http.Client{Transport: transport})
+
+	overlay := t.TempDir()
+	createTestEbuildContent(t, overlay, "dev-rust/foo-rs", "1.4.0", `EAPI=8
+HOMEPAGE="https://crates.io/crates/foo-rs"
+`)
+
+	rateLimiter := createFastRateLimiter()
+	rateLimiter.SetHTTPLimit("crates.io", rate.Inf, 1000)
+	llm := &analyzeCountingLLM{}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(httpClient),
+		WithAnalyzerLLMClient(llm),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	batch := analyzer.AnalyzeAll(AnalyzeOptions{NoCache: true})
+	if batch.HasFailures() || len(batch.Items) != 1 {
+		t.Fatalf("AnalyzeAll = %+v, failures %v", batch.Items, batch.Failures)
+	}
+	result := batch.Items[0]
+	schema := result.SuggestedSchema
+	if schema.URL != "https://crates.io/api/v1/crates/foo-rs" || schema.Parser != "json" || schema.Path != "crate.max_stable_version" {
+		t.Errorf("SuggestedSchema = %+v, want the crates.io API at crate.max_stable_version", schema)
+	}
+	if schema.Headers["User-Agent"] != registryUserAgent {
+		t.Errorf("SuggestedSchema.Headers = %v, want the crates.io User-Agent", schema.Headers)
+	}
+	if !result.Validated || result.ExtractedVersion != "1.4.0" {
+		t.Errorf("Validated = %v, ExtractedVersion = %q; want a validated 1.4.0", result.Validated, result.ExtractedVersion)
+	}
+	if llm.calls.Load() != 0 {
+		t.Errorf("the LLM analyzed content %d time(s) for a crates.io source", llm.calls.Load())
+	}
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{"dev-rust/foo-rs": *schema}}),
+		WithHTTPClient(httpClient),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	check, err := checker.CheckPackage("dev-rust/foo-rs", true)
+	if err != nil || check.UpstreamVersion != "1.4.0" {
+		t.Fatalf("CheckPackage = %+v, %v; want upstream 1.4.0", check, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(agents) < 3 {
+		t.Fatalf("server saw %d request(s), want the analysis, validation and check fetches", len(agents))
+	}
+	for i, agent := range agents {
+		if agent != registryUserAgent {
+			t.Errorf("request %d User-Agent = %q, want %q", i+1, agent, registryUserAgent)
+		}
+	}
+}
+
+// TestAnalyzeAll_DryRunWritesNothing verifies a dry run suggests and validates
+// schemas like a normal run while packages.toml, the analysis cache and the
+// LLM cache stay untouched, and that DryRun composes with Force and NoCache.
+func TestAnalyzeAll_DryRunWritesNothing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>release 1.2.3</body></html>")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	overlay := t.TempDir()
+	for _, pkg := range []string{"app-misc/foo", "app-misc/bar", "app-misc/kept"} {
+		createTestEbuildContent(t, overlay, pkg, "1.2.3", "EAPI=8\nHOMEPAGE=\""+server.URL+"/"+pkg+"\"\n")
+	}
+	configPath := filepath.Join(overlay, ".autoupdate", "packages.toml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	original := []byte("[\"app-misc/kept\"]\nurl = \"" + server.URL + "/kept\"\nparser = \"regex\"\npattern = 'release ([0-9.]+)'\n")
+	if err := os.WriteFile(configPath, original, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	configDir := t.TempDir()
+	llmCache, err := NewLLMCache(configDir)
+	if err != nil {
+		t.Fatalf("NewLLMCache: %v", err)
+	}
+	rateLimiter := createFastRateLimiter()
+	setFastHTTPLimit(rateLimiter, server.URL)
+	llm := &patternLLMStub{analysis: &SchemaAnalysis{ParserType: "regex", Pattern: `release (\d+\.\d+\.\d+)`}}
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerConfigDir(configDir),
+		WithAnalyzerLLMClient(llm),
+		WithAnalyzerLLMCache(llmCache),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	batch := analyzer.AnalyzeAll(AnalyzeOptions{DryRun: true})
+	if batch.HasFailures() || len(batch.Items) != 2 {
+		t.Fatalf("AnalyzeAll() = %+v, failures %v; want foo and bar", batch.Items, batch.Failures)
+	}
+	for _, r := range batch.Items {
+		if r.SuggestedSchema == nil || !r.Validated || r.ExtractedVersion != "1.2.3" {
+			t.Errorf("%s: schema %+v, validated %v, version %q; want a validated suggestion",
+				r.Package, r.SuggestedSchema, r.Validated, r.ExtractedVersion)
+		}
+	}
+
+	// Force re-analyzes the package that has a schema, NoCache skips the
+	// caches, and still nothing is written.
+	result, err := analyzer.Analyze("app-misc/kept", AnalyzeOptions{DryRun: true, Force: true, NoCache: true})
+	if err != nil || result.SuggestedSchema == nil {
+		t.Fatalf("Analyze(Force, NoCache, DryRun) = %+v, %v", result, err)
+	}
+
+	if got, err := os.ReadFile(configPath); err != nil || !bytes.Equal(got, original) {
+		t.Errorf("packages.toml changed by a dry run:\n%s", got)
+	}
+	if n := analyzer.cache.Len(); n != 0 {
+		t.Errorf("analysis cache has %d entries after a dry run, want 0", n)
+	}
+	if n := llmCache.Len(); n != 0 {
+		t.Errorf("LLM cache has %d entries after a dry run, want 0", n)
+	}
+	entries, _ := os.ReadDir(configDir)
+	for _, e := range entries {
+		if !e.IsDir() && strings.Contains(e.Name(), "cache") {
+			t.Errorf("dry run wrote %s", e.Name())
+		}
+	}
+}
@@ -3,7 +3,6 @@ package autoupdate
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -13,12 +12,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 	"github.com/obentoo/bentoolkit/internal/common/fileutil"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
 	"github.com/obentoo/bentoolkit/internal/common/tui"
+	"github.com/obentoo/bentoolkit/internal/overlay"
 )
 
 // manifestTimeout bounds a single `pkgdev manifest` invocation. The manifest
@@ -32,6 +33,18 @@ const manifestTimeout = 5 * time.Minute
 // scan is best-effort and never blocks the apply.
 const qaCheckTimeout = 2 * time.Minute
 
+// manifestTool is the binary the manifest step shells out to. It is looked up
+// through the lookPath seam so tests can simulate its absence.
+const manifestTool = "pkgdev"
+
+// ManifestToolAvailable reports whether the manifest tool (pkgdev) is on PATH.
+// Callers use it to detect the missing-tool case up front instead of letting
+// every package fail its manifest step with the same exec error.
+func ManifestToolAvailable() bool {
+	_, err := lookPath(manifestTool)
+	return err == nil
+}
+
 // Error variables for applier errors
 var (
 	// ErrEbuildNotFound is returned when the source ebuild file is not found
@@ -96,7 +109,21 @@ type ApplyResult struct {
 	// the authoritative manifest re-run — but surfaces any QA findings the agent's
 	// edit may have introduced so a human can review before committing. Empty when
 	// pkgcheck is absent, reported nothing, or no fix was applied.
+	//
+	// With WithApplierRunQA it also carries the output of the blocking QA
+	// preflight, which fails the apply with ErrQAFailed on pkgcheck errors.
 	QASummary string
+	// ManifestSkipped indicates the manifest step (and any compile test, which
+	// needs a valid Manifest) was skipped because pkgdev is not installed and
+	// the applier runs with WithApplierSkipMissingManifest. The ebuild was still
+	// created; its Manifest must be regenerated manually before committing.
+	ManifestSkipped bool
+	// GroupMembers lists the packages bumped together when the entry was a
+	// group update (see PendingUpdate.GroupMembers). Empty otherwise.
+	GroupMembers []string
+	// DryRun indicates the result is a Preview: it describes what Apply would
+	// do, and nothing was written.
+	DryRun bool
 }
 
 // Applier handles update application for packages.
@@ -155,6 +182,21 @@ type Applier struct {
 	// terminal for the prompt and tee the raw output to the TTY and a capture
 	// buffer. A nil override is normalized back to the CombinedOutput default.
 	runAttached func(cmd *exec.Cmd) ([]byte, error)
+	// skipMissingManifest, when true, turns a missing manifest tool into a
+	// skipped step instead of a per-package failure. Set via
+	// WithApplierSkipMissingManifest.
+	skipMissingManifest bool
+	// manifestToolOnce guards the single pkgdev lookup (and the single warning)
+	// shared by every Apply on this applier, including concurrent ones.
+	manifestToolOnce sync.Once
+	// manifestToolOK caches the result of the lookup guarded by manifestToolOnce.
+	manifestToolOK bool
+	// runQA makes every apply run the blocking pkgcheck preflight. Set via
+	// WithApplierRunQA.
+	runQA bool
+	// qaTool is the pkgcheck binary run by the QA passes and Validate, a
+	// name looked up on PATH or a path. Set via WithApplierQATool.
+	qaTool string
 }
 
 // ApplierOption is a functional option for configuring Applier
@@ -275,6 +317,18 @@ func WithApplierRunAttached(fn func(cmd *exec.Cmd) ([]byte, error)) ApplierOptio
 	}
 }
 
+// WithApplierSkipMissingManifest makes the applier degrade gracefully when
+// pkgdev is not installed: the ebuild is still copied and substituted, but the
+// manifest step (and the compile test, which cannot run without a Manifest) is
+// skipped and the result is flagged ManifestSkipped. A single warning is logged
+// per applier rather than one failure per package. When false (the default) a
+// missing pkgdev fails each apply at the manifest step, as before.
+func WithApplierSkipMissingManifest(skip bool) ApplierOption {
+	return func(a *Applier) {
+		a.skipMissingManifest = skip
+	}
+}
+
 // NewApplier creates a new applier instance for the given overlay.
 // It initializes the pending list and logs directory.
 func NewApplier(overlayPath, configDir string, opts ...ApplierOption) (*Applier, error) {
@@ -285,6 +339,7 @@ func NewApplier(overlayPath, configDir string, opts ...ApplierOption) (*Applier,
 		logsDir:     logsDir,
 		confirmFunc: defaultConfirmFunc,
 		execCommand: exec.CommandContext,
+		qaTool:      defaultQATool,
 		ctx:         context.Background(), // SAFE: default parent; replaced by WithApplierContext when cmd/ wires signal.NotifyContext
 		reporter:    tui.Noop(),           // SAFE: silent default; replaced by WithApplierReporter (R3.3)
 		// SAFE: default == today's behaviour (CombinedOutput), so the compile-log
@@ -347,6 +402,13 @@ func (a *Applier) Apply(pkg string, compile bool) (result *ApplyResult, _ error)
 		a.reporter.TaskDone(pkg, result.Success, applySummary(result), "")
 	}()
 
+	// Nothing is written until the overlay is known to be one, so a wrong
+	// path fails here instead of half-applying into an unrelated tree.
+	if err := overlay.RequireOverlay(a.overlayPath); err != nil {
+		result.Error = err
+		return result, result.Error
+	}
+
 	// Get pending update
 	update, found := a.pending.Get(pkg)
 	if !found {
@@ -372,6 +434,11 @@ func (a *Applier) Apply(pkg string, compile bool) (result *ApplyResult, _ error)
 	}
 	result.NewVersion = newVersion
 
+	// A group entry bumps the whole family at once; see applyGroup.
+	if len(update.GroupMembers) > 0 {
+		return a.applyGroup(pkg, update, newVersion, compile, result)
+	}
+
 	// Re-resolve the current version against the live overlay rather than
 	// trusting update.CurrentVersion. That field is a snapshot from check-time
 	// and drifts: the overlay may have been bumped past it, or the package
@@ -458,6 +525,55 @@ func (a *Applier) Apply(pkg string, compile bool) (result *ApplyResult, _ error)
 		}
 	}()
 
+	// Verify the upstream release signature when the package pins a signing
+	// key. A bad or missing signature fails the apply like a Manifest failure,
+	// so the deferred rollback removes the new ebuild and the entry never
+	// reaches validated. The check downloads the artifact itself and needs no
+	// Manifest, so it runs before the manifest step: a missing pkgdev must not
+	// let an unverified release through.
+	if cfg, ok := a.configs[pkg]; ok && cfg.SigningKey != "" {
+		a.reporter.TaskStage(pkg, "verify")
+		if err := a.verifyReleaseSignature(pkg, newVersion); err != nil {
+			result.Error = err
+			if err := a.pending.SetStatus(pkg, StatusFailed, result.Error.Error()); err != nil {
+				result.Error = fmt.Errorf("%w (also failed to update status: %v)", result.Error, err)
+			}
+			return result, result.Error
+		}
+	}
+
+	// pkgdev is missing and the caller opted into graceful degradation: keep the
+	// file operations, skip every step that needs a Manifest, and leave the
+	// pending entry in place so it still shows up until the Manifest is
+	// regenerated by hand. A requested QA preflight cannot be skipped that way.
+	if a.skipMissingManifest && !a.manifestToolPresent() {
+		if err := a.qaNeedsManifest(pkg); err != nil {
+			result.Error = err
+			if err := a.pending.SetStatus(pkg, StatusFailed, result.Error.Error()); err != nil {
+				result.Error = fmt.Errorf("%w (also failed to update status: %v)", result.Error, err)
+			}
+			return result, result.Error
+		}
+		result.ManifestSkipped = true
+		result.Success = true
+		return result, nil
+	}
+
+	// The QA preflight runs after the manifest step rewrote the Manifest, so
+	// keep the current one to put back if the preflight blocks the apply.
+	var manifestSnap *fileSnapshot
+	if a.runQA {
+		snap, err := snapshotFile(filepath.Join(a.overlayPath, pkg, "Manifest"))
+		if err != nil {
+			result.Error = err
+			if err := a.pending.SetStatus(pkg, StatusFailed, result.Error.Error()); err != nil {
+				result.Error = fmt.Errorf("%w (also failed to update status: %v)", result.Error, err)
+			}
+			return result, result.Error
+		}
+		manifestSnap = snap
+	}
+
 	// Run manifest command. When a fixer is wired, a failure here triggers a
 	// single agentic repair-and-retry before the apply is declared failed; the
 	// outcome (including whether a fix was applied) is recorded on result.
@@ -470,6 +586,26 @@ func (a *Applier) Apply(pkg string, compile bool) (result *ApplyResult, _ error)
 		return result, result.Error
 	}
 
+	// QA preflight (WithApplierRunQA): pkgcheck errors block the apply. The
+	// deferred rollback removes the new ebuild; the Manifest is restored here.
+	if a.runQA {
+		a.reporter.TaskStage(pkg, "qa")
+		findings, err := a.runQAGate(pkg)
+		if findings != "" {
+			result.QASummary = findings
+		}
+		if err != nil {
+			result.Error = err
+			if rerr := manifestSnap.restore(); rerr != nil {
+				warnLogf("qa: failed to restore Manifest of %s: %v", pkg, rerr)
+			}
+			if err := a.pending.SetStatus(pkg, StatusFailed, result.Error.Error()); err != nil {
+				result.Error = fmt.Errorf("%w (also failed to update status: %v)", result.Error, err)
+			}
+			return result, result.Error
+		}
+	}
+
 	// Update status to validated
 	if err := a.pending.SetStatus(pkg, StatusValidated, ""); err != nil {
 		result.Error = fmt.Errorf("failed to update status: %w", err)
@@ -519,6 +655,21 @@ func (a *Applier) Apply(pkg string, compile bool) (result *ApplyResult, _ error)
 	return result, nil
 }
 
+// manifestToolPresent reports whether pkgdev is available, looking it up only
+// once per applier. The first lookup that finds it missing emits the single
+// skip-manifest warning, so an `--apply all` over N packages logs one notice
+// instead of N identical failures.
+func (a *Applier) manifestToolPresent() bool {
+	a.manifestToolOnce.Do(func() {
+		a.manifestToolOK = ManifestToolAvailable()
+		if !a.manifestToolOK {
+			warnLogf("manifest: %s not found (install dev-util/pkgdev); skipping manifest generation. "+
+				"Run 'bentoo overlay manifest' manually before committing", manifestTool)
+		}
+	})
+	return a.manifestToolOK
+}
+
 // applySummary derives the short, one-line summary handed to the reporter's
 // TaskDone for an apply. It is purely cosmetic (the reporter only renders it):
 // on success the new version (noting an LLM fix when one happened), on an
@@ -529,6 +680,9 @@ func applySummary(result *ApplyResult) string {
 	case result == nil:
 		return ""
 	case result.Success:
+		if result.ManifestSkipped {
+			return result.NewVersion + " (manifest skipped)"
+		}
 		if result.Fixed {
 			return result.NewVersion + " (fixed)"
 		}
@@ -827,35 +981,22 @@ func (a *Applier) runManifestWithFix(pkg, version string, result *ApplyResult) e
 // could not run, or reported nothing). It is deliberately non-fatal: pkgcheck
 // exits non-zero whenever it finds issues, so the exit code is ignored.
 //
-// Only stdout is treated as findings: pkgcheck's reporter writes findings to
-// stdout, while diagnostics and crashes (e.g. a GitAddon traceback when the
-// overlay's git history confuses pkgcheck) go to stderr. Surfacing stderr as
-// "findings" once dumped a full Python traceback onto the result, so stderr is
-// captured separately and only logged at debug — a pkgcheck crash yields no QA
-// noise. The scan is bounded by qaCheckTimeout.
+// Surfacing pkgcheck's stderr as "findings" once dumped a full Python traceback
+// onto the result, so a scan that fails without findings (see pkgcheckScan) is
+// only logged at debug — a pkgcheck crash yields no QA noise.
 func (a *Applier) runQACheck(pkgDir, pkg string) string {
-	if _, err := lookPath("pkgcheck"); err != nil {
-		logger.Debug("qa: pkgcheck not on PATH; skipping post-fix QA for %s", pkg)
+	if _, err := lookPath(a.qaTool); err != nil {
+		logger.Debug("qa: %s not found; skipping post-fix QA for %s", a.qaTool, pkg)
 		return ""
 	}
 
-	ctx, cancel := context.WithTimeout(a.ctx, qaCheckTimeout)
-	defer cancel()
-
 	// Scan the single package from its directory so pkgcheck resolves the overlay
 	// repo from cwd. Scope to repo-level checks for the one package via its atom.
-	cmd := a.execCommand(ctx, "pkgcheck", "scan", pkg)
-	cmd.Dir = pkgDir
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	_ = cmd.Run() // non-zero exit == findings; ignore the code, keep stdout.
-
-	if diag := strings.TrimSpace(stderr.String()); diag != "" {
-		logger.Debug("qa: pkgcheck stderr for %s (not surfaced): %s", pkg, diag)
+	findings, err := a.pkgcheckScan(pkgDir, pkg)
+	if errors.Is(err, ErrQAToolFailed) {
+		logger.Debug("qa: post-fix QA not surfaced: %v", err)
 	}
-	return strings.TrimSpace(stdout.String())
+	return findings
 }
 
 // runManifest regenerates the Manifest file with pkgdev. Unlike `ebuild
@@ -901,7 +1042,7 @@ func (a *Applier) runManifest(pkg, version string) error {
 	defer cancel()
 
 	// Run pkgdev manifest from the package directory.
-	cmd := a.execCommand(ctx, "pkgdev", "manifest", "--distdir", distdir)
+	cmd := a.execCommand(ctx, manifestTool, "manifest", "--distdir", distdir)
 	cmd.Dir = pkgDir
 
 	// Stream the long manifest run (distfile download + digest) live as TaskLine
@@ -0,0 +1,146 @@
+package autoupdate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// stubManifestToolMissing makes the lookPath seam report every binary as
+// absent for the duration of the test.
+func stubManifestToolMissing(t *testing.T) {
+	t.Helper()
+	orig := lookPath
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	t.Cleanup(func() { lookPath = orig })
+}
+
+// TestManifestToolAvailable checks both outcomes of the pkgdev lookup.
+func TestManifestToolAvailable(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+
+	lookPath = func(name string) (string, error) {
+		if name != "pkgdev" {
+			t.Errorf("lookPath(%q), want pkgdev", name)
+		}
+		return "/usr/bin/pkgdev", nil
+	}
+	if !ManifestToolAvailable() {
+		t.Error("ManifestToolAvailable() = false with pkgdev on PATH")
+	}
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	if ManifestToolAvailable() {
+		t.Error("ManifestToolAvailable() = true with pkgdev missing")
+	}
+}
+
+// TestApplySkipMissingManifest_SingleNotice applies several packages with
+// pkgdev "missing": every apply must complete its file operations and succeed
+// with ManifestSkipped, pkgdev must never be executed, and exactly one
+// skip-manifest warning must be logged for the whole batch.
+func TestApplySkipMissingManifest_SingleNotice(t *testing.T) {
+	stubManifestToolMissing(t)
+	logs := captureWarnLogs(t)
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	pending, err := NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList: %v", err)
+	}
+	pkgs := []string{"test-cat/one", "test-cat/two", "test-cat/three"}
+	for _, pkg := range pkgs {
+		createTestEbuildFile(t, overlayDir, pkg, "1.0.0")
+		if err := pending.Add(PendingUpdate{
+			Package:        pkg,
+			CurrentVersion: "1.0.0",
+			NewVersion:     "2.0.0",
+			Status:         StatusPending,
+		}); err != nil {
+			t.Fatalf("pending.Add: %v", err)
+		}
+	}
+
+	var execs atomic.Int32
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithApplierSkipMissingManifest(true),
+		WithExecCommand(func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+			execs.Add(1)
+			return exec.CommandContext(ctx, "true")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier: %v", err)
+	}
+
+	for _, pkg := range pkgs {
+		result, err := applier.Apply(pkg, true)
+		if err != nil {
+			t.Fatalf("Apply(%s) error = %v", pkg, err)
+		}
+		if !result.Success || !result.ManifestSkipped {
+			t.Errorf("Apply(%s): Success=%v ManifestSkipped=%v, want both true", pkg, result.Success, result.ManifestSkipped)
+		}
+		name := filepath.Base(pkg)
+		dst := filepath.Join(overlayDir, "test-cat", name, name+"-2.0.0.ebuild")
+		if _, err := os.Stat(dst); err != nil {
+			t.Errorf("Apply(%s): new ebuild missing: %v", pkg, err)
+		}
+	}
+
+	if n := execs.Load(); n != 0 {
+		t.Errorf("external commands executed %d times, want 0 (manifest and compile skipped)", n)
+	}
+
+	var notices int
+	for _, line := range logs.all() {
+		if strings.Contains(line, "skipping manifest generation") {
+			notices++
+		}
+	}
+	if notices != 1 {
+		t.Errorf("got %d skip-manifest notices, want exactly 1; logs: %v", notices, logs.all())
+	}
+}
+
+// TestApplyMissingManifestWithoutSkip keeps the default behaviour: without
+// WithApplierSkipMissingManifest the manifest step still runs (and its result
+// decides the apply), so the lookup result is never consulted.
+func TestApplyMissingManifestWithoutSkip(t *testing.T) {
+	stubManifestToolMissing(t)
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	pkg := "test-cat/test-pkg"
+	createTestEbuildFile(t, overlayDir, pkg, "1.0.0")
+	pending, _ := NewPendingList(configDir)
+	_ = pending.Add(PendingUpdate{Package: pkg, CurrentVersion: "1.0.0", NewVersion: "2.0.0", Status: StatusPending})
+
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(mockExecCommandFailure),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier: %v", err)
+	}
+
+	result, err := applier.Apply(pkg, false)
+	if !errors.Is(err, ErrManifestFailed) {
+		t.Fatalf("Apply() error = %v, want ErrManifestFailed", err)
+	}
+	if result.ManifestSkipped {
+		t.Error("ManifestSkipped = true without WithApplierSkipMissingManifest")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 
+	"github.com/obentoo/bentoolkit/internal/common/config"
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 )
 
@@ -378,6 +379,7 @@ func createTestEbuildFileWithContent(t *testing.T, overlayDir, pkg, version, con
 	if err := os.MkdirAll(pkgDir, 0755); err != nil {
 		t.Fatalf("Failed to create package dir: %v", err)
 	}
+	createOverlaySkeleton(t, overlayDir)
 
 	ebuildPath := filepath.Join(pkgDir, pkgName+"-"+version+".ebuild")
 	if err := os.WriteFile(ebuildPath, []byte(content), 0644); err != nil {
@@ -385,6 +387,17 @@ func createTestEbuildFileWithContent(t *testing.T, overlayDir, pkg, version, con
 	}
 }
 
+// createOverlaySkeleton creates the profiles/ and metadata/ directories that
+// make overlayDir pass config.ValidateOverlay.
+func createOverlaySkeleton(t *testing.T, overlayDir string) {
+	t.Helper()
+	for _, dir := range []string{"profiles", "metadata"} {
+		if err := os.MkdirAll(filepath.Join(overlayDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s dir: %v", dir, err)
+		}
+	}
+}
+
 // mockExecCommandSuccess returns a mock exec.Cmd that always succeeds.
 // It is context-aware so cancellation propagates to the spawned process.
 func mockExecCommandSuccess(ctx context.Context, name string, arg ...string) *exec.Cmd {
@@ -517,6 +530,7 @@ func TestApplyPackageNotInPending(t *testing.T) {
 	tmpDir := t.TempDir()
 	overlayDir := filepath.Join(tmpDir, "overlay")
 	configDir := filepath.Join(tmpDir, "config")
+	createOverlaySkeleton(t, overlayDir)
 
 	applier, err := NewApplier(overlayDir, configDir)
 	if err != nil {
@@ -532,6 +546,49 @@ func TestApplyPackageNotInPending(t *testing.T) {
 	}
 }
 
+// TestApplyRejectsInvalidOverlay tests that Apply refuses an overlay without
+// profiles/ before writing anything
+func TestApplyRejectsInvalidOverlay(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	createTestEbuildFile(t, overlayDir, "test-cat/test-pkg", "1.0.0")
+	if err := os.RemoveAll(filepath.Join(overlayDir, "profiles")); err != nil {
+		t.Fatalf("Failed to remove profiles dir: %v", err)
+	}
+
+	pending, _ := NewPendingList(configDir)
+	pending.Add(PendingUpdate{
+		Package:        "test-cat/test-pkg",
+		CurrentVersion: "1.0.0",
+		NewVersion:     "1.1.0",
+		Status:         StatusPending,
+	})
+
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(mockExecCommandSuccess),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := applier.Apply("test-cat/test-pkg", false)
+	if !errors.Is(err, config.ErrOverlayInvalidStructure) {
+		t.Fatalf("Expected ErrOverlayInvalidStructure, got: %v", err)
+	}
+	if result.Success {
+		t.Error("Expected result.Success to be false")
+	}
+	if _, statErr := os.Stat(applier.EbuildPath("test-cat/test-pkg", "1.1.0")); !os.IsNotExist(statErr) {
+		t.Error("New ebuild written despite invalid overlay")
+	}
+	if update, _ := pending.Get("test-cat/test-pkg"); update == nil || update.Status != StatusPending {
+		t.Errorf("Pending entry changed: %+v", update)
+	}
+}
+
 // TestApplySourceEbuildNotFound tests error when source ebuild doesn't exist
 func TestApplySourceEbuildNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -541,6 +598,7 @@ func TestApplySourceEbuildNotFound(t *testing.T) {
 	// Create package directory but no ebuild
 	pkgDir := filepath.Join(overlayDir, "test-cat", "test-pkg")
 	os.MkdirAll(pkgDir, 0755)
+	createOverlaySkeleton(t, overlayDir)
 
 	pending, _ := NewPendingList(configDir)
 	pending.Add(PendingUpdate{
@@ -0,0 +1,150 @@
+// Package autoupdate provides batch application of the pending update list.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+)
+
+// ErrInvalidUpdateStatus is returned by ParseUpdateStatuses for a name that
+// is not one of ValidStatuses.
+var ErrInvalidUpdateStatus = errors.New("invalid update status")
+
+// DefaultApplyStatuses returns the statuses SelectPending keeps when none are
+// given: entries still waiting to be applied. A failed entry is retried only
+// when asked for explicitly, so one broken package does not fail every batch.
+func DefaultApplyStatuses() []UpdateStatus {
+	return []UpdateStatus{StatusPending, StatusValidated}
+}
+
+// ParseUpdateStatuses parses a comma-separated list of statuses such as
+// "pending,failed". Blank items are skipped, so an empty string yields no
+// statuses (which SelectPending reads as DefaultApplyStatuses).
+func ParseUpdateStatuses(s string) ([]UpdateStatus, error) {
+	var statuses []UpdateStatus
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		status := UpdateStatus(strings.ToLower(item))
+		if !IsValidStatus(status) {
+			return nil, fmt.Errorf("%w: %q (valid: %s)", ErrInvalidUpdateStatus, item, joinStatuses(ValidStatuses()))
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// joinStatuses joins statuses with ", " for messages.
+func joinStatuses(statuses []UpdateStatus) string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// SelectPending returns the updates whose status is one of statuses, in
+// input order. No statuses selects DefaultApplyStatuses.
+func SelectPending(updates []PendingUpdate, statuses []UpdateStatus) []PendingUpdate {
+	if len(statuses) == 0 {
+		statuses = DefaultApplyStatuses()
+	}
+	var selected []PendingUpdate
+	for _, u := range updates {
+		if slices.Contains(statuses, u.Status) {
+			selected = append(selected, u)
+		}
+	}
+	return selected
+}
+
+// ApplyPendingOptions configures ApplyPending.
+type ApplyPendingOptions struct {
+	// Statuses selects the entries to apply; empty selects
+	// DefaultApplyStatuses
+	Statuses []UpdateStatus
+	// DryRun previews each entry with Preview instead of applying it
+	DryRun bool
+	// Compile runs the compile test after each successful apply
+	Compile bool
+}
+
+// ApplyPending applies every pending update whose status opts selects, one
+// at a time, through Apply: the ebuild is copied from the current version to
+// the new one, the Manifest regenerated, and the entry removed on success or
+// marked failed with the error. It returns the results in pending-list order
+// and the number of entries that failed. Callers that want the applies to
+// overlap (the CLI's --apply all) select with SelectPending and run Apply
+// themselves.
+func (a *Applier) ApplyPending(opts ApplyPendingOptions) ([]*ApplyResult, int) {
+	updates := SelectPending(a.pending.List(), opts.Statuses)
+	results := make([]*ApplyResult, len(updates))
+	failures := 0
+	for i, u := range updates {
+		var err error
+		if opts.DryRun {
+			results[i], err = a.Preview(u.Package)
+		} else {
+			results[i], err = a.Apply(u.Package, opts.Compile)
+		}
+		if err != nil {
+			failures++
+		}
+	}
+	return results, failures
+}
+
+// Preview reports what Apply would do for pkg without writing anything: the
+// version it would bump from and to, or why the entry is obsolete. The
+// result has DryRun set; an entry Apply would reject (not pending, or an
+// invalid new version) returns the same error, but its status is left as is.
+func (a *Applier) Preview(pkg string) (*ApplyResult, error) {
+	result := &ApplyResult{Package: pkg, DryRun: true}
+
+	if err := overlay.RequireOverlay(a.overlayPath); err != nil {
+		result.Error = err
+		return result, err
+	}
+
+	update, found := a.pending.Get(pkg)
+	if !found {
+		result.Error = ErrPackageNotInPending
+		return result, result.Error
+	}
+	result.OldVersion = update.CurrentVersion
+
+	newVersion := stripVersionPrefix(strings.TrimSpace(update.NewVersion))
+	if !ebuild.IsValidVersion(newVersion) {
+		result.Error = fmt.Errorf("%w: %q (from %q)", ErrInvalidNewVersion, newVersion, update.NewVersion)
+		return result, result.Error
+	}
+	result.NewVersion = newVersion
+
+	if len(update.GroupMembers) > 0 {
+		result.GroupMembers = append([]string(nil), update.GroupMembers...)
+		return result, nil
+	}
+
+	currentVersion, err := a.resolveCurrentVersion(pkg)
+	if err != nil {
+		result.Obsolete = true
+		result.ObsoleteReason = fmt.Errorf("%w: %s no longer in overlay (%v)", ErrObsoletePending, pkg, err).Error()
+		return result, nil
+	}
+	result.OldVersion = currentVersion
+	if ebuild.CompareVersions(currentVersion, newVersion) >= 0 {
+		result.Obsolete = true
+		result.ObsoleteReason = fmt.Errorf("%w: overlay already at %s (target %s)",
+			ErrObsoletePending, currentVersion, newVersion).Error()
+	}
+	return result, nil
+}
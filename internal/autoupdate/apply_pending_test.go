@@ -0,0 +1,165 @@
+package autoupdate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestParseUpdateStatuses covers the accepted spellings and the rejection of
+// an unknown status.
+func TestParseUpdateStatuses(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []UpdateStatus
+	}{
+		{"", nil},
+		{"failed", []UpdateStatus{StatusFailed}},
+		{" Pending , validated,,pending", []UpdateStatus{StatusPending, StatusValidated}},
+	}
+	for _, tt := range tests {
+		got, err := ParseUpdateStatuses(tt.in)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseUpdateStatuses(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseUpdateStatuses("pending,done"); !errors.Is(err, ErrInvalidUpdateStatus) {
+		t.Errorf("ParseUpdateStatuses(\"pending,done\") error = %v, want %v", err, ErrInvalidUpdateStatus)
+	}
+}
+
+// TestSelectPending verifies the default selection skips failed and applied
+// entries, and explicit statuses select exactly those, in input order.
+func TestSelectPending(t *testing.T) {
+	updates := []PendingUpdate{
+		{Package: "a/pending", Status: StatusPending},
+		{Package: "a/failed", Status: StatusFailed},
+		{Package: "a/validated", Status: StatusValidated},
+		{Package: "a/applied", Status: StatusApplied},
+	}
+	names := func(us []PendingUpdate) []string {
+		var out []string
+		for _, u := range us {
+			out = append(out, u.Package)
+		}
+		return out
+	}
+
+	if got := names(SelectPending(updates, nil)); !slices.Equal(got, []string{"a/pending", "a/validated"}) {
+		t.Errorf("SelectPending(default) = %v, want the pending and validated entries", got)
+	}
+	if got := names(SelectPending(updates, []UpdateStatus{StatusFailed})); !slices.Equal(got, []string{"a/failed"}) {
+		t.Errorf("SelectPending(failed) = %v, want the failed entry", got)
+	}
+}
+
+// newApplyPendingFixture returns an applier over an overlay holding
+// test-cat/pending-1.0, test-cat/validated-1.0 and test-cat/failed-1.0, each
+// with a pending update to 2.0 in the status its name gives.
+func newApplyPendingFixture(t *testing.T) (*Applier, *PendingList, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	pending, err := NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList() error = %v", err)
+	}
+	for _, status := range []UpdateStatus{StatusPending, StatusValidated, StatusFailed} {
+		pkg := "test-cat/" + string(status)
+		createTestEbuildFile(t, overlayDir, pkg, "1.0")
+		if err := pending.Add(PendingUpdate{Package: pkg, CurrentVersion: "1.0", NewVersion: "2.0", Status: status}); err != nil {
+			t.Fatalf("Add(%s) error = %v", pkg, err)
+		}
+	}
+
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(mockExecCommandSuccess),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier() error = %v", err)
+	}
+	return applier, pending, overlayDir
+}
+
+// TestApplyPending verifies the default selection applies the pending and
+// validated entries, removing them from the list, and leaves the failed one
+// alone.
+func TestApplyPending(t *testing.T) {
+	applier, pending, overlayDir := newApplyPendingFixture(t)
+
+	results, failures := applier.ApplyPending(ApplyPendingOptions{})
+	if failures != 0 || len(results) != 2 {
+		t.Fatalf("ApplyPending() = %d results, %d failures; want 2 and 0", len(results), failures)
+	}
+	for _, r := range results {
+		if !r.Success || r.DryRun {
+			t.Errorf("result for %s = %+v, want an applied update", r.Package, r)
+		}
+	}
+
+	for name, wantNew := range map[string]bool{"pending": true, "validated": true, "failed": false} {
+		_, err := os.Stat(filepath.Join(overlayDir, "test-cat", name, name+"-2.0.ebuild"))
+		if (err == nil) != wantNew {
+			t.Errorf("%s-2.0.ebuild exists = %v, want %v", name, err == nil, wantNew)
+		}
+	}
+	if _, ok := pending.Get("test-cat/pending"); ok {
+		t.Error("applied entry still pending")
+	}
+	if u, ok := pending.Get("test-cat/failed"); !ok || u.Status != StatusFailed {
+		t.Errorf("failed entry = %+v, %v; want it untouched", u, ok)
+	}
+}
+
+// TestApplyPending_DryRun verifies a dry run reports each selected update,
+// including an obsolete one, and writes nothing.
+func TestApplyPending_DryRun(t *testing.T) {
+	applier, pending, overlayDir := newApplyPendingFixture(t)
+	createTestEbuildFile(t, overlayDir, "test-cat/failed", "2.0")
+
+	results, failures := applier.ApplyPending(ApplyPendingOptions{
+		Statuses: []UpdateStatus{StatusPending, StatusFailed},
+		DryRun:   true,
+	})
+	if failures != 0 || len(results) != 2 {
+		t.Fatalf("ApplyPending(dry run) = %d results, %d failures; want 2 and 0", len(results), failures)
+	}
+	// test-cat/failed already has a 2.0 ebuild, so its entry is obsolete.
+	want := map[string]struct {
+		old      string
+		obsolete bool
+	}{
+		"test-cat/pending": {"1.0", false},
+		"test-cat/failed":  {"2.0", true},
+	}
+	for _, r := range results {
+		w := want[r.Package]
+		if !r.DryRun || r.Success || r.OldVersion != w.old || r.NewVersion != "2.0" || r.Obsolete != w.obsolete {
+			t.Errorf("result for %s = %+v, want a %s → 2.0 preview (obsolete %v)", r.Package, r, w.old, w.obsolete)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(overlayDir, "test-cat", "pending", "pending-2.0.ebuild")); err == nil {
+		t.Error("dry run created the new ebuild")
+	}
+	for _, pkg := range []string{"test-cat/pending", "test-cat/failed"} {
+		if _, ok := pending.Get(pkg); !ok {
+			t.Errorf("dry run removed %s from the pending list", pkg)
+		}
+	}
+}
+
+// TestPreview_NotPending verifies Preview reports ErrPackageNotInPending like
+// Apply.
+func TestPreview_NotPending(t *testing.T) {
+	applier, _, _ := newApplyPendingFixture(t)
+	if _, err := applier.Preview("test-cat/unknown"); !errors.Is(err, ErrPackageNotInPending) {
+		t.Errorf("Preview() error = %v, want %v", err, ErrPackageNotInPending)
+	}
+}
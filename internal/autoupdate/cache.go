@@ -2,15 +2,18 @@
 package autoupdate
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/obentoo/bentoolkit/internal/common/fileutil"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
 )
 
@@ -36,6 +39,11 @@ var (
 // DefaultCacheTTL is the default time-to-live for cache entries (1 hour)
 const DefaultCacheTTL = time.Hour
 
+// gzipMagic is the two-byte header every gzip stream starts with. load sniffs
+// it so compressed and legacy plain-JSON cache files are both readable
+// regardless of the current WithCompression setting.
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // CacheEntry represents a cached version query result.
 // It stores the version, when it was cached, and the source URL.
 type CacheEntry struct {
@@ -45,11 +53,26 @@ type CacheEntry struct {
 	Timestamp time.Time `json:"timestamp"`
 	// Source is the URL that was queried to get this version
 	Source string `json:"source"`
+	// Body is the fetched content the version was parsed from, kept only
+	// under the raw and raw-small storage policies (see CachePolicy)
+	Body []byte `json:"body,omitempty"`
+	// ReleaseURL is the html_url of the GitHub release the version was read
+	// from, so a cache hit can still link the release notes without a fetch
+	ReleaseURL string `json:"release_url,omitempty"`
+	// NeedsReview and ReviewNote carry CheckResult's review flag, so a cache
+	// hit reports it like the check that fetched the version
+	NeedsReview bool   `json:"needs_review,omitempty"`
+	ReviewNote  string `json:"review_note,omitempty"`
 }
 
 // cacheFile represents the JSON structure stored on disk
 type cacheFile struct {
 	Entries map[string]CacheEntry `json:"entries"`
+	// Sources records which source answered each package last; see
+	// Cache.LastSource
+	Sources map[string]string `json:"sources,omitempty"`
+	// Failures holds the cached failed lookups; see Cache.GetFailure
+	Failures map[string]FailureEntry `json:"failures,omitempty"`
 }
 
 // Cache manages version query caching with TTL-based expiration.
@@ -59,12 +82,59 @@ type Cache struct {
 	Entries map[string]CacheEntry `json:"entries"`
 	// TTL is the time-to-live for cache entries
 	TTL time.Duration
-	// path is the file path where cache is persisted
-	path string
+	// packageTTLs overrides TTL for single packages. Set via SetPackageTTL;
+	// it comes from the packages' configuration and is never persisted.
+	packageTTLs map[string]time.Duration
+	// store persists the cache; a FileStore on the config directory unless
+	// set with WithCacheStore
+	store Store
 	// mu protects concurrent access to Entries
 	mu sync.RWMutex
 	// nowFunc allows injecting time for testing
 	nowFunc func() time.Time
+	// compress gzips the file on save. Set via WithCompression.
+	compress bool
+	// compact drops expired entries and indentation on save. Set via
+	// WithCompaction.
+	compact bool
+	// policy decides whether SetWithBody keeps the body. Set via
+	// WithStoragePolicy; the zero value is CachePolicyVersionOnly.
+	policy CachePolicy
+	// rawSmallLimit is the largest body CachePolicyRawSmall keeps
+	rawSmallLimit int
+	// sources is the last successful source per package. It is kept apart
+	// from Entries so it outlives their TTL and compaction.
+	sources map[string]string
+	// failures is the last failed lookup per package, served for negativeTTL
+	// (see SetFailure). Kept apart from Entries so a failure never shadows a
+	// cached version.
+	failures map[string]FailureEntry
+	// negativeTTL is how long a failure is served; zero disables negative
+	// caching. Set via WithNegativeTTL.
+	negativeTTL time.Duration
+	// readOnly keeps every change in memory and skips the save. Set by
+	// NewChecker under WithReadOnly.
+	readOnly bool
+	// hits and misses count Get outcomes since the cache was created; see
+	// Stats. They are atomic because Get only holds the read lock.
+	hits, misses atomic.Int64
+}
+
+// CacheStats is a snapshot of the cache's contents and of how Get has been
+// answered since it was created.
+type CacheStats struct {
+	// Entries is the number of entries, expired ones included
+	Entries int
+	// Expired is the number of entries past their TTL, which Prune would drop
+	Expired int
+	// Hits is the number of Get calls answered from the cache
+	Hits int64
+	// Misses is the number of Get calls that found no entry or an expired one
+	Misses int64
+	// Oldest and Newest are the earliest and latest entry timestamps, zero
+	// when the cache is empty
+	Oldest time.Time
+	Newest time.Time
 }
 
 // CacheOption is a functional option for configuring Cache
@@ -84,23 +154,45 @@ func WithNowFunc(fn func() time.Time) CacheOption {
 	}
 }
 
+// WithCompression gzips the cache file on every save. Reading is unaffected:
+// a compressed file is recognized by its gzip magic, so turning compression on
+// or off never strands an existing cache.
+func WithCompression(compress bool) CacheOption {
+	return func(c *Cache) {
+		c.compress = compress
+	}
+}
+
+// WithCompaction makes every save a compacting write: expired entries are
+// dropped (from memory as well as the file) and the JSON is written without
+// indentation. For an overlay with thousands of packages this keeps the file
+// from accumulating entries that can never be served again.
+func WithCompaction(compact bool) CacheOption {
+	return func(c *Cache) {
+		c.compact = compact
+	}
+}
+
+// WithCacheStore persists the cache in store instead of cache.json in the
+// config directory.
+func WithCacheStore(store Store) CacheOption {
+	return func(c *Cache) {
+		c.store = store
+	}
+}
+
 // NewCache creates or loads a cache from disk.
 // If the cache file exists, it loads existing entries.
 // If the cache file doesn't exist or is corrupted, it creates a new empty cache.
 // The configDir should be the bentoo config directory (e.g., ~/.config/bentoo/autoupdate).
 func NewCache(configDir string, opts ...CacheOption) (*Cache, error) {
-	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0o750); err != nil {
-		return nil, fmt.Errorf("failed to create cache directory: %w", err)
-	}
-
-	cachePath := filepath.Join(configDir, "cache.json")
-
 	cache := &Cache{
-		Entries: make(map[string]CacheEntry),
-		TTL:     DefaultCacheTTL,
-		path:    cachePath,
-		nowFunc: time.Now,
+		Entries:       make(map[string]CacheEntry),
+		sources:       make(map[string]string),
+		failures:      make(map[string]FailureEntry),
+		TTL:           DefaultCacheTTL,
+		nowFunc:       time.Now,
+		rawSmallLimit: DefaultRawSmallLimit,
 	}
 
 	// Apply options
@@ -108,10 +200,18 @@ func NewCache(configDir string, opts ...CacheOption) (*Cache, error) {
 		opt(cache)
 	}
 
+	if cache.store == nil {
+		// Ensure config directory exists
+		if err := os.MkdirAll(configDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		cache.store = NewFileStore(configDir)
+	}
+
 	// Try to load existing cache
 	if err := cache.load(); err != nil {
 		// If file doesn't exist, that's fine - start with empty cache
-		if !os.IsNotExist(err) {
+		if !errors.Is(err, fs.ErrNotExist) {
 			// Log corruption but continue with empty cache
 			// The corrupted file will be overwritten on next Save
 			cache.Entries = make(map[string]CacheEntry)
@@ -121,13 +221,24 @@ func NewCache(configDir string, opts ...CacheOption) (*Cache, error) {
 	return cache, nil
 }
 
-// load reads the cache from disk
+// load reads the cache from its store
 func (c *Cache) load() error {
-	data, err := os.ReadFile(c.path)
+	data, err := c.store.Load(cacheStoreName)
 	if err != nil {
 		return err
 	}
 
+	if bytes.HasPrefix(data, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCacheCorrupted, err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCacheCorrupted, err)
+		}
+	}
+
 	var cf cacheFile
 	if err := json.Unmarshal(data, &cf); err != nil {
 		return fmt.Errorf("%w: %v", ErrCacheCorrupted, err)
@@ -136,6 +247,12 @@ func (c *Cache) load() error {
 	if cf.Entries != nil {
 		c.Entries = cf.Entries
 	}
+	if cf.Sources != nil {
+		c.sources = cf.Sources
+	}
+	if cf.Failures != nil {
+		c.failures = cf.Failures
+	}
 
 	return nil
 }
@@ -148,14 +265,17 @@ func (c *Cache) Get(pkg string) (string, bool) {
 
 	entry, exists := c.Entries[pkg]
 	if !exists {
+		c.misses.Add(1)
 		return "", false
 	}
 
 	// Check if entry is expired
-	if c.isExpired(entry) {
+	if c.isExpired(pkg, entry) {
+		c.misses.Add(1)
 		return "", false
 	}
 
+	c.hits.Add(1)
 	return entry.Version, true
 }
 
@@ -169,14 +289,42 @@ func (c *Cache) GetWithForce(pkg string, force bool) (string, bool) {
 	return c.Get(pkg)
 }
 
-// isExpired checks if a cache entry has expired based on TTL
-func (c *Cache) isExpired(entry CacheEntry) bool {
+// SetPackageTTL makes pkg's entry expire after ttl instead of the cache's
+// TTL, so a slow-moving package can be served from cache for a week while
+// the rest refresh hourly. A non-positive ttl removes the override.
+func (c *Cache) SetPackageTTL(pkg string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(c.packageTTLs, pkg)
+		return
+	}
+	if c.packageTTLs == nil {
+		c.packageTTLs = make(map[string]time.Duration)
+	}
+	c.packageTTLs[pkg] = ttl
+}
+
+// ttlFor returns the TTL of pkg's entry: its override when set, the cache's
+// TTL otherwise. Caller must hold the lock.
+func (c *Cache) ttlFor(pkg string) time.Duration {
+	if ttl, ok := c.packageTTLs[pkg]; ok {
+		return ttl
+	}
+	return c.TTL
+}
+
+// isExpired checks if pkg's cache entry has expired based on its TTL.
+// Caller must hold the lock.
+func (c *Cache) isExpired(pkg string, entry CacheEntry) bool {
 	now := c.nowFunc()
 	age := now.Sub(entry.Timestamp)
-	return age >= c.TTL
+	return age >= c.ttlFor(pkg)
 }
 
-// Set stores a version in the cache with the current timestamp.
+// Set stores a version in the cache with the current timestamp, clearing
+// any cached failure for the package.
 // It automatically saves the cache to disk after setting.
 func (c *Cache) Set(pkg, version, source string) error {
 	c.mu.Lock()
@@ -187,6 +335,7 @@ func (c *Cache) Set(pkg, version, source string) error {
 		Timestamp: c.nowFunc(),
 		Source:    source,
 	}
+	delete(c.failures, pkg)
 
 	return c.saveUnsafe()
 }
@@ -202,32 +351,44 @@ func (c *Cache) Save() error {
 // saveUnsafe persists the cache to disk without locking.
 // Caller must hold the write lock.
 func (c *Cache) saveUnsafe() error {
+	if c.readOnly {
+		return nil
+	}
+	if c.compact {
+		c.dropExpiredUnsafe()
+	}
+
 	cf := cacheFile{
-		Entries: c.Entries,
+		Entries:  c.Entries,
+		Sources:  c.sources,
+		Failures: c.failures,
 	}
 
-	data, err := json.MarshalIndent(cf, "", "  ")
+	var data []byte
+	var err error
+	if c.compact {
+		data, err = json.Marshal(cf)
+	} else {
+		data, err = json.MarshalIndent(cf, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache: %w", err)
 	}
 
-	// Write to temp file first, then rename for atomicity. Cache files use
-	// 0600 (owner-only) because they may hold sensitive upstream metadata.
-	tmpPath := c.path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, fileutil.CacheFileMode); err != nil {
-		return fmt.Errorf("failed to write cache file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, c.path); err != nil {
-		// Clean up temp file on rename failure
-		os.Remove(tmpPath) //nolint:errcheck
-		return fmt.Errorf("failed to rename cache file: %w", err)
+	if c.compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("failed to compress cache: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress cache: %w", err)
+		}
+		data = buf.Bytes()
 	}
 
-	// os.Rename keeps the temp file's mode, which umask may have widened.
-	// Re-apply the restrictive mode; tolerate filesystems without chmod.
-	if err := fileutil.SafeChmod(c.path, fileutil.CacheFileMode, warnLogger{}); err != nil {
-		return fmt.Errorf("failed to set cache file permissions: %w", err)
+	if err := c.store.Save(cacheStoreName, data); err != nil {
+		return fmt.Errorf("failed to save cache: %w", err)
 	}
 
 	return nil
@@ -240,6 +401,8 @@ func (c *Cache) Delete(pkg string) error {
 	defer c.mu.Unlock()
 
 	delete(c.Entries, pkg)
+	delete(c.sources, pkg)
+	delete(c.failures, pkg)
 	return c.saveUnsafe()
 }
 
@@ -250,6 +413,8 @@ func (c *Cache) Clear() error {
 	defer c.mu.Unlock()
 
 	c.Entries = make(map[string]CacheEntry)
+	c.sources = make(map[string]string)
+	c.failures = make(map[string]FailureEntry)
 	return c.saveUnsafe()
 }
 
@@ -277,11 +442,65 @@ func (c *Cache) Cleanup() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.dropExpiredUnsafe()
+	return c.saveUnsafe()
+}
+
+// Prune removes the entries older than their TTL (the package's override
+// from SetPackageTTL, or the cache's TTL), and the cached failures older than
+// the negative TTL, and returns how many it dropped.
+// The cache is saved only when something was dropped.
+func (c *Cache) Prune() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := c.dropExpiredUnsafe()
+	if dropped == 0 {
+		return 0, nil
+	}
+	return dropped, c.saveUnsafe()
+}
+
+// Stats returns the number of entries, how many have expired, their oldest
+// and newest timestamps, and the Get hits and misses counted so far.
+func (c *Cache) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := CacheStats{
+		Entries: len(c.Entries),
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+	}
 	for pkg, entry := range c.Entries {
-		if c.isExpired(entry) {
-			delete(c.Entries, pkg)
+		if c.isExpired(pkg, entry) {
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || entry.Timestamp.Before(stats.Oldest) {
+			stats.Oldest = entry.Timestamp
+		}
+		if entry.Timestamp.After(stats.Newest) {
+			stats.Newest = entry.Timestamp
 		}
 	}
+	return stats
+}
 
-	return c.saveUnsafe()
+// dropExpiredUnsafe removes expired entries and failures from memory and
+// returns how many it removed. Caller must hold the write lock.
+func (c *Cache) dropExpiredUnsafe() int {
+	dropped := 0
+	for pkg, entry := range c.Entries {
+		if c.isExpired(pkg, entry) {
+			delete(c.Entries, pkg)
+			dropped++
+		}
+	}
+	for pkg, failure := range c.failures {
+		if c.failureExpired(failure) {
+			delete(c.failures, pkg)
+			dropped++
+		}
+	}
+	return dropped
 }
@@ -0,0 +1,138 @@
+// Package autoupdate provides cache storage policies for ebuild autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CachePolicy decides what a cache entry keeps besides the version.
+type CachePolicy string
+
+const (
+	// CachePolicyVersionOnly stores just the extracted version. It is the
+	// default and keeps cache.json small.
+	CachePolicyVersionOnly CachePolicy = "version-only"
+	// CachePolicyRaw also stores the fetched body, so a parser change can be
+	// tried against it with ReparseCached without refetching.
+	CachePolicyRaw CachePolicy = "raw"
+	// CachePolicyRawSmall stores the body only when it is no larger than the
+	// cache's raw-small limit (DefaultRawSmallLimit unless set with
+	// WithRawSmallLimit): JSON APIs are kept, full HTML pages are not.
+	CachePolicyRawSmall CachePolicy = "raw-small"
+)
+
+// DefaultRawSmallLimit is the largest body CachePolicyRawSmall stores.
+const DefaultRawSmallLimit = 64 << 10
+
+// ErrInvalidCachePolicy is returned for a policy other than the CachePolicy
+// constants.
+var ErrInvalidCachePolicy = errors.New("invalid cache policy: must be version-only, raw or raw-small")
+
+// ErrNoCachedBody is returned by ReparseCached when the package's cache entry
+// holds no body, either because the policy did not keep it or because the
+// version came from a fallback source.
+var ErrNoCachedBody = errors.New("no cached body")
+
+// ParseCachePolicy validates s as a CachePolicy. The empty string is
+// CachePolicyVersionOnly.
+func ParseCachePolicy(s string) (CachePolicy, error) {
+	switch p := CachePolicy(s); p {
+	case "":
+		return CachePolicyVersionOnly, nil
+	case CachePolicyVersionOnly, CachePolicyRaw, CachePolicyRawSmall:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w, got %q", ErrInvalidCachePolicy, s)
+	}
+}
+
+// WithStoragePolicy sets what SetWithBody keeps of a fetched body.
+func WithStoragePolicy(policy CachePolicy) CacheOption {
+	return func(c *Cache) {
+		c.policy = policy
+	}
+}
+
+// WithRawSmallLimit sets the largest body, in bytes, CachePolicyRawSmall
+// stores. A non-positive limit keeps DefaultRawSmallLimit.
+func WithRawSmallLimit(n int) CacheOption {
+	return func(c *Cache) {
+		if n > 0 {
+			c.rawSmallLimit = n
+		}
+	}
+}
+
+// keepBody reports whether the storage policy keeps body.
+func (c *Cache) keepBody(body []byte) bool {
+	switch c.policy {
+	case CachePolicyRaw:
+		return len(body) > 0
+	case CachePolicyRawSmall:
+		return len(body) > 0 && len(body) <= c.rawSmallLimit
+	default:
+		return false
+	}
+}
+
+// SetWithBody stores a version like Set, together with the body it was
+// parsed from when the storage policy keeps it.
+func (c *Cache) SetWithBody(pkg, version, source string, body []byte) error {
+	return c.SetEntry(pkg, CacheEntry{Version: version, Source: source, Body: body})
+}
+
+// SetEntry stores entry like SetWithBody, keeping the rest of its fields. The
+// timestamp is set to the current time, and Body dropped unless the storage
+// policy keeps it.
+func (c *Cache) SetEntry(pkg string, entry CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.Timestamp = c.nowFunc()
+	if c.keepBody(entry.Body) {
+		entry.Body = append([]byte(nil), entry.Body...)
+	} else {
+		entry.Body = nil
+	}
+	c.Entries[pkg] = entry
+	delete(c.failures, pkg)
+
+	return c.saveUnsafe()
+}
+
+// WithCachePolicy sets the storage policy of the default Cache constructed by
+// NewChecker; ignored when a Cache is injected via WithCache.
+func WithCachePolicy(policy CachePolicy) CheckerOption {
+	return func(c *Checker) error {
+		p, err := ParseCachePolicy(string(policy))
+		if err != nil {
+			return err
+		}
+		c.cachePolicy = p
+		return nil
+	}
+}
+
+// ReparseCached re-runs pkg's configured parser, select and transform over
+// the body stored in its cache entry, without any network access. It is how a
+// parser change is checked against what upstream last served. The entry is
+// used even when expired; the cache is not updated.
+func (c *Checker) ReparseCached(pkg string) (string, error) {
+	cfg, ok := c.config.Packages[pkg]
+	if !ok {
+		return "", &ConfigError{Package: pkg, Err: fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)}
+	}
+	entry, ok := c.cache.GetEntry(pkg)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrCacheMiss, pkg)
+	}
+	if len(entry.Body) == 0 {
+		return "", fmt.Errorf("%w for %s", ErrNoCachedBody, pkg)
+	}
+	version, err := c.parseContent(pkg, &cfg, entry.Body)
+	if err != nil {
+		return "", err
+	}
+	return applySnapshotPolicy(&cfg, version, nil), nil
+}
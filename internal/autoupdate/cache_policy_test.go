@@ -0,0 +1,118 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestCacheSetWithBody_Policies verifies which bodies each storage policy
+// keeps, and that the body survives a reload.
+func TestCacheSetWithBody_Policies(t *testing.T) {
+	small := []byte(`{"version":"1.0"}`)
+	large := []byte(strings.Repeat("x", DefaultRawSmallLimit+1))
+
+	tests := []struct {
+		policy               CachePolicy
+		keepSmall, keepLarge bool
+	}{
+		{CachePolicyVersionOnly, false, false},
+		{CachePolicyRaw, true, true},
+		{CachePolicyRawSmall, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			dir := t.TempDir()
+			cache, err := NewCache(dir, WithStoragePolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("NewCache() error = %v", err)
+			}
+			if err := cache.SetWithBody("app-misc/small", "1.0", "u", small); err != nil {
+				t.Fatalf("SetWithBody() error = %v", err)
+			}
+			if err := cache.SetWithBody("app-misc/large", "1.0", "u", large); err != nil {
+				t.Fatalf("SetWithBody() error = %v", err)
+			}
+
+			reloaded, err := NewCache(dir)
+			if err != nil {
+				t.Fatalf("NewCache() reload error = %v", err)
+			}
+			for pkg, want := range map[string]bool{"app-misc/small": tt.keepSmall, "app-misc/large": tt.keepLarge} {
+				entry, ok := reloaded.GetEntry(pkg)
+				if !ok || entry.Version != "1.0" {
+					t.Fatalf("%s: entry = %+v, %v; want version 1.0", pkg, entry, ok)
+				}
+				if got := len(entry.Body) > 0; got != want {
+					t.Errorf("%s: body stored = %v, want %v", pkg, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestReparseCached verifies a stored body is re-parsed with the current
+// configuration and without a request, and that a version-only entry reports
+// ErrNoCachedBody.
+func TestReparseCached(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"version":"2.0.0","next":"3.0.0-beta"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-misc/foo"]
+url = "` + srv.URL + `"
+parser = "json"
+path = "version"
+`
+	for _, policy := range []CachePolicy{CachePolicyRaw, CachePolicyVersionOnly} {
+		t.Run(string(policy), func(t *testing.T) {
+			requests.Store(0)
+			overlay, _ := writePackagesTOML(t, content)
+			createTestEbuild(t, overlay, "app-misc/foo", "1.0.0")
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+				WithCachePolicy(policy),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			if _, err := checker.CheckPackage("app-misc/foo", true); err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+
+			cfg := checker.config.Packages["app-misc/foo"]
+			cfg.Path = "next"
+			checker.config.Packages["app-misc/foo"] = cfg
+
+			version, err := checker.ReparseCached("app-misc/foo")
+			if policy == CachePolicyVersionOnly {
+				if !errors.Is(err, ErrNoCachedBody) {
+					t.Errorf("ReparseCached() error = %v, want %v", err, ErrNoCachedBody)
+				}
+				return
+			}
+			if err != nil || version != "3.0.0-beta" {
+				t.Errorf("ReparseCached() = %q, %v; want 3.0.0-beta", version, err)
+			}
+			if n := requests.Load(); n != 1 {
+				t.Errorf("%d requests, want 1 (re-parse must not fetch)", n)
+			}
+		})
+	}
+}
+
+// TestWithCachePolicy_Invalid verifies an unknown policy fails NewChecker.
+func TestWithCachePolicy_Invalid(t *testing.T) {
+	overlay, _ := writePackagesTOML(t, "")
+	_, err := NewChecker(overlay, WithConfigDir(t.TempDir()), WithCachePolicy("everything"))
+	if !errors.Is(err, ErrInvalidCachePolicy) {
+		t.Errorf("NewChecker() error = %v, want %v", err, ErrInvalidCachePolicy)
+	}
+}
@@ -1,9 +1,14 @@
 package autoupdate
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -335,6 +340,48 @@ func TestNewCacheHandlesCorruptedFile(t *testing.T) {
 	}
 }
 
+// TestNewCacheHandlesCorruptedCompressedFile tests that NewCache starts empty
+// from a compressed cache file whose gzip stream or JSON is damaged, and that
+// the next save replaces it with a readable one
+func TestNewCacheHandlesCorruptedCompressedFile(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"truncated gzip": append(append([]byte{}, gzipMagic...), 0x08, 0x00),
+		"gzipped garbage": func() []byte {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write([]byte(`{"entries": [`)) //nolint:errcheck
+			zw.Close()                        //nolint:errcheck
+			return buf.Bytes()
+		}(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, "cache.json"), data, 0600); err != nil {
+				t.Fatalf("Failed to write cache file: %v", err)
+			}
+
+			cache, err := NewCache(tmpDir, WithCompression(true))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cache.Len() != 0 {
+				t.Errorf("Expected empty cache after corruption, got %d entries", cache.Len())
+			}
+			if err := cache.Set("test/pkg", "1.0.0", "https://example.com"); err != nil {
+				t.Fatalf("Failed to set: %v", err)
+			}
+
+			reloaded, err := NewCache(tmpDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version, ok := reloaded.Get("test/pkg"); !ok || version != "1.0.0" {
+				t.Errorf("Get() after rewrite = %q, %v; want 1.0.0", version, ok)
+			}
+		})
+	}
+}
+
 // TestCacheGetMiss tests Get returns false for non-existent entry
 func TestCacheGetMiss(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -632,12 +679,66 @@ func TestCacheAtomicWrite(t *testing.T) {
 	}
 
 	for _, f := range files {
-		if f.Name() == "cache.json.tmp" {
+		if strings.HasPrefix(f.Name(), "cache.json.tmp") {
 			t.Error("Temp file should not remain after successful write")
 		}
 	}
 }
 
+// TestCacheConcurrentSaves tests that two caches saving to the same
+// directory at once, as two Checkers sharing it or two bentoo runs do, never
+// leave a torn cache file: every read sees a whole document
+func TestCacheConcurrentSaves(t *testing.T) {
+	tmpDir := t.TempDir()
+	caches := make([]*Cache, 2)
+	for i := range caches {
+		var err error
+		if caches[i], err = NewCache(tmpDir); err != nil {
+			t.Fatalf("NewCache() error = %v", err)
+		}
+	}
+	// A large document widens the window a shared temp file would tear in.
+	source := "https://example.com/" + strings.Repeat("x", 64<<10)
+
+	var wg sync.WaitGroup
+	for i, cache := range caches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 400; n++ {
+				if err := cache.Set(fmt.Sprintf("cat/pkg%d", i), fmt.Sprintf("1.%d", n), source); err != nil {
+					t.Errorf("Set() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for reads := 0; ; reads++ {
+		select {
+		case <-done:
+			if leftover, _ := filepath.Glob(filepath.Join(tmpDir, "cache.json.tmp*")); len(leftover) > 0 {
+				t.Errorf("temporary files left behind: %v", leftover)
+			}
+			return
+		default:
+		}
+		data, err := os.ReadFile(filepath.Join(tmpDir, "cache.json"))
+		if err != nil {
+			continue
+		}
+		var cf cacheFile
+		if err := json.Unmarshal(data, &cf); err != nil {
+			t.Fatalf("read %d saw a torn cache file (%d bytes): %v", reads, len(data), err)
+		}
+	}
+}
+
 // TestCacheWrite_FinalModeIs0600 verifies that the cache file persisted by
 // Cache.Set ends up with owner-only (0600) permissions end-to-end. The save
 // path writes a temp file then renames it, so the final mode depends on the
@@ -662,3 +763,264 @@ func TestCacheWrite_FinalModeIs0600(t *testing.T) {
 		t.Errorf("cache file mode = %#o, want %#o", got, 0o600)
 	}
 }
+
+// TestCacheCompressedRoundTrip verifies a gzip-compressed cache file is written
+// with the gzip magic and loads back, with or without compression enabled.
+func TestCacheCompressedRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	now := WithNowFunc(func() time.Time { return fixedNow })
+	cache, err := NewCache(tmpDir, now, WithCompression(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cache.Set("net-misc/test-pkg", "1.2.3", "https://example.com/api"); err != nil {
+		t.Fatalf("Failed to set: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "cache.json"))
+	if err != nil {
+		t.Fatalf("Failed to read cache file: %v", err)
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatalf("Expected a gzip file, got %q", data)
+	}
+
+	for _, compress := range []bool{true, false} {
+		loaded, err := NewCache(tmpDir, now, WithCompression(compress))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if version, ok := loaded.Get("net-misc/test-pkg"); !ok || version != "1.2.3" {
+			t.Errorf("compression=%v: Get() = %q, %v; want 1.2.3", compress, version, ok)
+		}
+	}
+}
+
+// TestCacheCompressedLoadsLegacyFile verifies enabling compression still loads
+// an existing uncompressed cache file, and rewrites it compressed on save.
+func TestCacheCompressedLoadsLegacyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	cachePath := filepath.Join(tmpDir, "cache.json")
+
+	cacheData := `{"entries": {"net-misc/test-pkg": {"version": "1.2.3", "timestamp": "2026-01-22T12:00:00Z", "source": "https://example.com/api"}}}`
+	if err := os.WriteFile(cachePath, []byte(cacheData), 0644); err != nil {
+		t.Fatalf("Failed to write cache file: %v", err)
+	}
+
+	cache, err := NewCache(tmpDir, WithCompression(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entry, exists := cache.GetEntry("net-misc/test-pkg")
+	if !exists || entry.Version != "1.2.3" {
+		t.Fatalf("GetEntry() = %+v, %v; want the legacy entry", entry, exists)
+	}
+
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to read cache file: %v", err)
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Error("Expected the legacy file to be rewritten compressed")
+	}
+}
+
+// TestCacheCompactionDropsExpiredOnSave verifies a compacting write removes
+// expired entries from the saved file and from memory, keeping valid ones.
+func TestCacheCompactionDropsExpiredOnSave(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	cache, err := NewCache(tmpDir,
+		WithNowFunc(func() time.Time { return fixedNow }),
+		WithCompaction(true),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cache.Entries["test/expired"] = CacheEntry{
+		Version:   "1.0.0",
+		Timestamp: fixedNow.Add(-2 * time.Hour),
+		Source:    "https://example.com",
+	}
+	if err := cache.Set("test/valid", "2.0.0", "https://example.com"); err != nil {
+		t.Fatalf("Failed to set: %v", err)
+	}
+
+	if _, exists := cache.GetEntry("test/expired"); exists {
+		t.Error("Expected expired entry to be dropped from memory")
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "cache.json"))
+	if err != nil {
+		t.Fatalf("Failed to read cache file: %v", err)
+	}
+	var cf cacheFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		t.Fatalf("Compacted file is not plain JSON: %v", err)
+	}
+	if _, ok := cf.Entries["test/expired"]; ok {
+		t.Error("Expected expired entry to be absent from the saved file")
+	}
+	if _, ok := cf.Entries["test/valid"]; !ok {
+		t.Error("Expected valid entry to remain in the saved file")
+	}
+	if strings.Contains(string(data), "\n") {
+		t.Error("Expected a compacted file without indentation")
+	}
+}
+
+// TestCacheSetPackageTTL tests a per-package TTL overrides the cache's TTL
+// at its exact boundary, in both directions, and that removing it restores
+// the global TTL.
+func TestCacheSetPackageTTL(t *testing.T) {
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	now := fixedNow
+	cache, err := NewCache(t.TempDir(), WithTTL(time.Hour), WithNowFunc(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cache.SetPackageTTL("test/slow", 7*24*time.Hour)
+	cache.SetPackageTTL("test/fast", 15*time.Minute)
+	for _, pkg := range []string{"test/slow", "test/fast", "test/global"} {
+		if err := cache.Set(pkg, "1.0.0", "https://example.com"); err != nil {
+			t.Fatalf("Failed to set %s: %v", pkg, err)
+		}
+	}
+
+	tests := []struct {
+		pkg  string
+		age  time.Duration
+		want bool
+	}{
+		{"test/fast", 15*time.Minute - time.Nanosecond, true},
+		{"test/fast", 15 * time.Minute, false},
+		{"test/global", time.Hour - time.Nanosecond, true},
+		{"test/global", time.Hour, false},
+		{"test/slow", time.Hour, true},
+		{"test/slow", 7*24*time.Hour - time.Nanosecond, true},
+		{"test/slow", 7 * 24 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		now = fixedNow.Add(tt.age)
+		if _, found := cache.Get(tt.pkg); found != tt.want {
+			t.Errorf("Get(%s) after %v found = %v, want %v", tt.pkg, tt.age, found, tt.want)
+		}
+	}
+
+	now = fixedNow.Add(2 * time.Hour)
+	cache.SetPackageTTL("test/slow", 0)
+	if _, found := cache.Get("test/slow"); found {
+		t.Error("Expected the global TTL once the override is removed")
+	}
+}
+
+// TestCacheCompactionKeepsPackageTTL tests compaction drops entries by their
+// own TTL, keeping a long-lived entry past the global TTL.
+func TestCacheCompactionKeepsPackageTTL(t *testing.T) {
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	cache, err := NewCache(t.TempDir(), WithCompaction(true), WithNowFunc(func() time.Time { return fixedNow }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cache.SetPackageTTL("test/slow", 7*24*time.Hour)
+	cache.Entries["test/slow"] = CacheEntry{Version: "1.0.0", Timestamp: fixedNow.Add(-2 * time.Hour)}
+	cache.Entries["test/global"] = CacheEntry{Version: "1.0.0", Timestamp: fixedNow.Add(-2 * time.Hour)}
+
+	if err := cache.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if _, ok := cache.GetEntry("test/slow"); !ok {
+		t.Error("Expected the entry within its package TTL to be kept")
+	}
+	if _, ok := cache.GetEntry("test/global"); ok {
+		t.Error("Expected the entry past the global TTL to be dropped")
+	}
+}
+
+// TestCachePrune tests Prune drops exactly the entries past their own TTL,
+// returns how many, and persists the result.
+func TestCachePrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	cache, err := NewCache(tmpDir, WithTTL(time.Hour), WithNowFunc(func() time.Time { return fixedNow }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cache.SetPackageTTL("test/slow", 24*time.Hour)
+
+	ages := map[string]time.Duration{
+		"test/fresh":   30 * time.Minute,
+		"test/edge":    time.Hour,
+		"test/expired": 3 * time.Hour,
+		"test/slow":    3 * time.Hour,
+	}
+	for pkg, age := range ages {
+		cache.Entries[pkg] = CacheEntry{Version: "1.0.0", Timestamp: fixedNow.Add(-age)}
+	}
+
+	dropped, err := cache.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if dropped != 2 {
+		t.Errorf("Prune() dropped %d entries, want 2", dropped)
+	}
+	for pkg, want := range map[string]bool{"test/fresh": true, "test/edge": false, "test/expired": false, "test/slow": true} {
+		if _, exists := cache.GetEntry(pkg); exists != want {
+			t.Errorf("%s kept = %v, want %v", pkg, exists, want)
+		}
+	}
+
+	reloaded, err := NewCache(tmpDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reloaded.Len() != 2 {
+		t.Errorf("reloaded cache has %d entries, want 2", reloaded.Len())
+	}
+
+	if dropped, err := cache.Prune(); err != nil || dropped != 0 {
+		t.Errorf("second Prune() = %d, %v; want 0, nil", dropped, err)
+	}
+}
+
+// TestCacheStats tests Stats counts entries, expired entries, their time
+// span, and the hits and misses of Get.
+func TestCacheStats(t *testing.T) {
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	cache, err := NewCache(t.TempDir(), WithTTL(time.Hour), WithNowFunc(func() time.Time { return fixedNow }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stats := cache.Stats(); stats != (CacheStats{}) {
+		t.Errorf("Stats() of an empty cache = %+v, want zero", stats)
+	}
+
+	cache.Entries["test/new"] = CacheEntry{Version: "2.0", Timestamp: fixedNow.Add(-time.Minute)}
+	cache.Entries["test/old"] = CacheEntry{Version: "1.0", Timestamp: fixedNow.Add(-2 * time.Hour)}
+
+	cache.Get("test/new")     // hit
+	cache.Get("test/new")     // hit
+	cache.Get("test/old")     // miss: expired
+	cache.Get("test/missing") // miss: absent
+	cache.GetWithForce("test/new", false)
+
+	want := CacheStats{
+		Entries: 2,
+		Expired: 1,
+		Hits:    3,
+		Misses:  2,
+		Oldest:  fixedNow.Add(-2 * time.Hour),
+		Newest:  fixedNow.Add(-time.Minute),
+	}
+	if stats := cache.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
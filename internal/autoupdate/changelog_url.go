@@ -0,0 +1,83 @@
+// Package autoupdate provides best-effort changelog URLs for pending updates.
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// githubRepoURLRe captures owner and repository from a github.com page URL or
+// an api.github.com REST URL.
+var githubRepoURLRe = regexp.MustCompile(`^https?://(?:api\.github\.com/repos|github\.com)/([^/]+)/([^/?#]+)`)
+
+// githubRelease is the subset of a GitHub release object read here.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+}
+
+// resolveChangelogURL returns a URL a reviewer can open to see what changed
+// between current and upstream, or "" when the source offers none. It never
+// fails the check and never fetches: every problem just yields "".
+//
+// releaseURL, the release's html_url found by releaseHTMLURL when the version
+// was fetched (and kept in the cache entry since), wins. Otherwise any GitHub
+// repository URL yields a computed
+// https://github.com/<owner>/<repo>/compare/<old>...<new> link, reusing the
+// upstream tag's "v" prefix for the old tag and dropping the ebuild revision.
+func resolveChangelogURL(cfg *PackageConfig, current, upstream, releaseURL string) string {
+	if releaseURL != "" {
+		return releaseURL
+	}
+	return githubCompareURL(cfg.URL, current, upstream)
+}
+
+// releaseHTMLURL returns the html_url of the release tagged upstream in
+// content, the response of a GitHub releases URL the version was parsed from
+// (the release object, or the matching tag_name of a release list). It
+// returns "" for any other source, and when no release is tagged upstream, so
+// a transformed version or another release never links the wrong notes.
+func releaseHTMLURL(cfg *PackageConfig, content []byte, upstream string) string {
+	if cfg.Parser != "json" || len(content) == 0 {
+		return ""
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || !githubReleasesPathRe.MatchString(u.Path) {
+		return ""
+	}
+
+	var single githubRelease
+	if err := json.Unmarshal(content, &single); err == nil {
+		if single.TagName != upstream {
+			return ""
+		}
+		return single.HTMLURL
+	}
+	var list []githubRelease
+	if err := json.Unmarshal(content, &list); err != nil {
+		return ""
+	}
+	for _, release := range list {
+		if release.TagName == upstream {
+			return release.HTMLURL
+		}
+	}
+	return ""
+}
+
+// githubCompareURL builds a GitHub compare link between the current and
+// upstream tags of the repository behind rawURL, or "" for a non-GitHub URL.
+func githubCompareURL(rawURL, current, upstream string) string {
+	m := githubRepoURLRe.FindStringSubmatch(rawURL)
+	if m == nil {
+		return ""
+	}
+	repo := strings.TrimSuffix(m[2], ".git")
+	oldTag := revisionSuffixRe.ReplaceAllString(current, "")
+	if strings.HasPrefix(upstream, "v") && !strings.HasPrefix(oldTag, "v") {
+		oldTag = "v" + oldTag
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", m[1], repo, oldTag, upstream)
+}
@@ -0,0 +1,183 @@
+package autoupdate
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// checkChangelogPackage checks app-misc/tool at 1.0.0 against path on srv and
+// returns the resulting pending entry.
+func checkChangelogPackage(t *testing.T, srv *httptest.Server, path, jsonPath string) *PendingUpdate {
+	t.Helper()
+	content := `["app-misc/tool"]
+url = "` + srv.URL + path + `"
+parser = "json"
+path = "` + jsonPath + `"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/tool", "1.0.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	if _, err := checker.CheckPackage("app-misc/tool", true); err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	update, found := checker.Pending().Get("app-misc/tool")
+	if !found {
+		t.Fatal("expected a pending update")
+	}
+	return update
+}
+
+// TestChangelogURLFromGitHubRelease verifies a GitHub release source records
+// the release's html_url on the pending entry.
+func TestChangelogURLFromGitHubRelease(t *testing.T) {
+	const htmlURL = "https://github.com/acme/tool/releases/tag/v1.2.0"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/tool/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"tag_name":"v1.2.0","html_url":"` + htmlURL + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	update := checkChangelogPackage(t, srv, "/repos/acme/tool/releases/latest", "tag_name")
+	if update.ChangelogURL != htmlURL {
+		t.Errorf("ChangelogURL = %q, want %q", update.ChangelogURL, htmlURL)
+	}
+}
+
+// TestChangelogURLFromCache verifies the release html_url is read from the
+// body the version was parsed from, with a single request, and that a cache
+// hit links it again without fetching.
+func TestChangelogURLFromCache(t *testing.T) {
+	const htmlURL = "https://github.com/acme/tool/releases/tag/v1.2.0"
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/tool/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		_, _ = w.Write([]byte(`[{"tag_name":"v1.3.0-rc1","html_url":"https://example.com/rc"},{"tag_name":"v1.2.0","html_url":"` + htmlURL + `"}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-misc/tool"]
+url = "` + srv.URL + `/repos/acme/tool/releases"
+parser = "json"
+path = "[1].tag_name"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/tool", "1.0.0")
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	for _, force := range []bool{true, false} {
+		result, err := checker.CheckPackage("app-misc/tool", force)
+		if err != nil {
+			t.Fatalf("CheckPackage(force=%v) error = %v", force, err)
+		}
+		if result.FromCache == force || result.ChangelogURL != htmlURL {
+			t.Errorf("CheckPackage(force=%v): FromCache=%v ChangelogURL=%q, want %q", force, result.FromCache, result.ChangelogURL, htmlURL)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("releases fetched %d times, want 1", n)
+	}
+	if entry, _ := checker.cache.GetEntry("app-misc/tool"); entry.ReleaseURL != htmlURL {
+		t.Errorf("cache entry ReleaseURL = %q, want %q", entry.ReleaseURL, htmlURL)
+	}
+}
+
+// TestReportMarkdownChangelog verifies the report links the changelog of an
+// update that has one and leaves the cell empty otherwise.
+func TestReportMarkdownChangelog(t *testing.T) {
+	report := &Report{Behind: []ReportUpdate{
+		{Package: "app-misc/a", Current: "1.0", Upstream: "1.1", Changelog: "https://github.com/acme/a/compare/1.0...1.1"},
+		{Package: "app-misc/b", Current: "2.0", Upstream: "2.1"},
+	}}
+	var buf bytes.Buffer
+	if err := report.FormatMarkdown(&buf); err != nil {
+		t.Fatalf("FormatMarkdown() error = %v", err)
+	}
+	for _, want := range []string{
+		"| app-misc/a | 1.0 | 1.1 | [changes](https://github.com/acme/a/compare/1.0...1.1) |",
+		"| app-misc/b | 2.0 | 2.1 |  |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+// TestChangelogURLEmptyForGenericSource verifies a non-GitHub source leaves
+// the changelog URL empty.
+func TestChangelogURLEmptyForGenericSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"1.2.0","html_url":"https://example.com/notes"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	update := checkChangelogPackage(t, srv, "/api/version", "version")
+	if update.ChangelogURL != "" {
+		t.Errorf("ChangelogURL = %q, want empty", update.ChangelogURL)
+	}
+}
+
+// TestGitHubCompareURL covers the computed compare link.
+func TestGitHubCompareURL(t *testing.T) {
+	tests := []struct {
+		url, current, upstream, want string
+	}{
+		{"https://api.github.com/repos/acme/tool/tags", "1.0.0-r2", "v1.2.0", "https://github.com/acme/tool/compare/v1.0.0...v1.2.0"},
+		{"https://github.com/acme/tool.git", "1.0.0", "1.2.0", "https://github.com/acme/tool/compare/1.0.0...1.2.0"},
+		{"https://pypi.org/pypi/tool/json", "1.0.0", "1.2.0", ""},
+	}
+	for _, tt := range tests {
+		if got := githubCompareURL(tt.url, tt.current, tt.upstream); got != tt.want {
+			t.Errorf("githubCompareURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+// TestReleaseHTMLURLMatchesTag verifies a release's html_url is only used for
+// the release tagged with the upstream version, for a single release object
+// as for a release list.
+func TestReleaseHTMLURLMatchesTag(t *testing.T) {
+	cfg := &PackageConfig{URL: "https://api.github.com/repos/acme/tool/releases/latest", Parser: "json"}
+	single := []byte(`{"tag_name":"v1.2.0","html_url":"https://github.com/acme/tool/releases/tag/v1.2.0"}`)
+	list := []byte(`[{"tag_name":"v1.2.0","html_url":"https://github.com/acme/tool/releases/tag/v1.2.0"}]`)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		upstream string
+		want     string
+	}{
+		{"single, matching tag", single, "v1.2.0", "https://github.com/acme/tool/releases/tag/v1.2.0"},
+		{"single, other tag", single, "1.2.0", ""},
+		{"list, matching tag", list, "v1.2.0", "https://github.com/acme/tool/releases/tag/v1.2.0"},
+		{"list, other tag", list, "v1.3.0", ""},
+	}
+	for _, tt := range tests {
+		if got := releaseHTMLURL(cfg, tt.content, tt.upstream); got != tt.want {
+			t.Errorf("%s: releaseHTMLURL() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package autoupdate provides typed errors for package check failures.
+package autoupdate
+
+import "fmt"
+
+// CheckStage names the step of a package check at which it failed.
+type CheckStage string
+
+// Check stages, in the order a check runs them.
+const (
+	// StageConfig is resolving the package's configuration and parser.
+	StageConfig CheckStage = "config"
+	// StageFetch is retrieving the upstream document.
+	StageFetch CheckStage = "fetch"
+	// StageParse is extracting a version from the fetched document.
+	StageParse CheckStage = "parse"
+	// StageCompare is ordering the upstream version against the current one.
+	StageCompare CheckStage = "compare"
+)
+
+// The typed check errors below let callers tell failures apart with
+// errors.As — a FetchError is worth retrying, a ParseError or ConfigError
+// needs a packages.toml fix, a ComparisonError needs a transform — without
+// matching on message text. Each wraps its cause, so errors.Is still reaches
+// the underlying sentinel (ErrPackageNotFound, ErrJSONPathNotFound, a context
+// error, ...), and Error returns the cause's message unchanged so log output
+// stays as it was.
+//
+// FetchError and ParseError also match ErrFetchFailed, which has always
+// covered both stages of an upstream version lookup.
+
+// ConfigError reports a package whose configuration cannot be used: it is
+// missing from packages.toml, or its parser cannot be built.
+type ConfigError struct {
+	Package string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying cause.
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// Stage returns StageConfig.
+func (e *ConfigError) Stage() CheckStage { return StageConfig }
+
+// FetchError reports a failure to retrieve the upstream document at URL.
+type FetchError struct {
+	Package string
+	URL     string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *FetchError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying cause.
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrFetchFailed.
+func (e *FetchError) Is(target error) bool { return target == ErrFetchFailed }
+
+// Stage returns StageFetch.
+func (e *FetchError) Stage() CheckStage { return StageFetch }
+
+// ParseError reports a fetched document from which Parser could not extract a
+// version.
+type ParseError struct {
+	Package string
+	Parser  string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying cause.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrFetchFailed.
+func (e *ParseError) Is(target error) bool { return target == ErrFetchFailed }
+
+// Stage returns StageParse.
+func (e *ParseError) Stage() CheckStage { return StageParse }
+
+// ComparisonError reports an upstream version that cannot be ordered against
+// the current one. CheckPackage returns it alongside a complete result with
+// NotComparable set, so a caller may show it as a warning rather than a
+// failure.
+type ComparisonError struct {
+	Package  string
+	Upstream string
+	Current  string
+}
+
+// Error implements the error interface.
+func (e *ComparisonError) Error() string {
+	return fmt.Sprintf("%q not comparable to current %s", e.Upstream, e.Current)
+}
+
+// Stage returns StageCompare.
+func (e *ComparisonError) Stage() CheckStage { return StageCompare }
+
+// comparisonError returns the *ComparisonError for a not-comparable result,
+// or nil.
+func comparisonError(r *CheckResult) error {
+	if !r.NotComparable {
+		return nil
+	}
+	return &ComparisonError{Package: r.Package, Upstream: r.UpstreamVersion, Current: r.CurrentVersion}
+}
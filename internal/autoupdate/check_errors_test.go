@@ -0,0 +1,92 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTypedErrorChecker returns a checker for app-misc/foo at 1.0.0 whose
+// packages.toml entry points at srv with the given JSON path.
+func newTypedErrorChecker(t *testing.T, srv *httptest.Server, path string) *Checker {
+	t.Helper()
+	content := `["app-misc/foo"]
+url = "` + srv.URL + `"
+parser = "json"
+path = "` + path + `"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/foo", "1.0.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0})),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	return checker
+}
+
+// TestCheckPackageTypedErrors verifies a fetch failure yields a FetchError and
+// a bad JSON path a ParseError, each naming the package and still matching
+// ErrFetchFailed.
+func TestCheckPackageTypedErrors(t *testing.T) {
+	t.Run("fetch failure", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := newTypedErrorChecker(t, srv, "version").CheckPackage("app-misc/foo", true)
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) {
+			t.Fatalf("CheckPackage() error = %v, want a *FetchError", err)
+		}
+		if fetchErr.Package != "app-misc/foo" || fetchErr.URL != srv.URL || fetchErr.Stage() != StageFetch {
+			t.Errorf("FetchError = %+v (stage %s), want app-misc/foo at %s", fetchErr, fetchErr.Stage(), srv.URL)
+		}
+		if !errors.Is(err, ErrFetchFailed) {
+			t.Errorf("errors.Is(%v, ErrFetchFailed) = false", err)
+		}
+	})
+
+	t.Run("bad path", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"version":"2.0.0"}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := newTypedErrorChecker(t, srv, "nonexistent").CheckPackage("app-misc/foo", true)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("CheckPackage() error = %v, want a *ParseError", err)
+		}
+		if parseErr.Package != "app-misc/foo" || parseErr.Parser != "json" || parseErr.Stage() != StageParse {
+			t.Errorf("ParseError = %+v (stage %s), want json parser for app-misc/foo", parseErr, parseErr.Stage())
+		}
+		if !errors.Is(err, ErrJSONPathNotFound) || !errors.Is(err, ErrFetchFailed) {
+			t.Errorf("error %v should match both ErrJSONPathNotFound and ErrFetchFailed", err)
+		}
+		var fetchErr *FetchError
+		if errors.As(err, &fetchErr) {
+			t.Errorf("a parse failure must not also be a FetchError: %v", err)
+		}
+	})
+
+	t.Run("unknown package", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(srv.Close)
+
+		_, err := newTypedErrorChecker(t, srv, "version").CheckPackage("app-misc/missing", true)
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) || configErr.Package != "app-misc/missing" {
+			t.Fatalf("CheckPackage() error = %v, want a *ConfigError for app-misc/missing", err)
+		}
+		if !errors.Is(err, ErrPackageNotFound) {
+			t.Errorf("errors.Is(%v, ErrPackageNotFound) = false", err)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -19,7 +20,6 @@ import (
 	"time"
 
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
-	"github.com/obentoo/bentoolkit/internal/common/github"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
 	"github.com/obentoo/bentoolkit/internal/common/provider"
 )
@@ -73,6 +73,52 @@ type CheckResult struct {
 	// an informational result rather than a recurring hard failure. When set,
 	// all other fields except Package are zero-valued.
 	Orphaned bool
+	// NeedsReview is true when the repository behind a GitHub releases URL has
+	// a plain tag newer than its latest release (see PackageConfig.
+	// PreferReleases), when ReReleased is set, when a package.json or
+	// composer.json source declares a placeholder version such as 0.0.0, or
+	// when an LLM-extracted version falls below llm_min_confidence.
+	// UpstreamVersion still holds the release; ReviewNote says what to look at
+	// so a human can decide.
+	NeedsReview bool
+	// ReviewNote explains why NeedsReview is set. Empty otherwise.
+	ReviewNote string
+	// LLMConfidence is the confidence (0 to 1) in an UpstreamVersion that the
+	// LLM extracted, after expect_format and cross_validate; below the
+	// package's llm_min_confidence the result NeedsReview and is not queued.
+	// Zero when another source produced the version.
+	LLMConfidence float64
+	// ReReleased is true when upstream reports the version already in the
+	// overlay (ignoring the ebuild revision) but its artifact checksum no
+	// longer matches the Manifest (see PackageConfig.ChecksumPath). HasUpdate
+	// stays false: a re-release is a nudge to refetch, not a version bump.
+	ReReleased bool
+	// ReleasedAt is when the upstream version was published, when the source
+	// reports it (currently the commit date of commit-tracked packages). Zero
+	// otherwise; RankByStaleness uses it as a tiebreaker.
+	ReleasedAt time.Time
+	// SourceUnreachable is true when the SRC_URI probe (WithSourceProbe) found
+	// the current ebuild's distfile host dead, independent of whether the
+	// version source worked: a detected update may then be unfetchable.
+	SourceUnreachable bool
+	// SourceNote describes the failed probe. Empty otherwise.
+	SourceNote string
+	// InstalledVersion is the highest version of the package installed on
+	// this system according to the vdb (WithVDB). Empty when the lookup is
+	// disabled or the package is not installed.
+	InstalledVersion string
+	// InstalledAhead is true when InstalledVersion is newer than
+	// CurrentVersion, i.e. the overlay is behind what is actually installed.
+	InstalledAhead bool
+	// Snapshot is the `git describe` version upstream reported, such as
+	// "1.2.3-10-gdeadbee", when snapshot_policy = "flag" reduced it to its
+	// base tag for UpstreamVersion. Empty otherwise.
+	Snapshot string
+	// SnapshotCommits is how many commits Snapshot is past its base tag
+	SnapshotCommits int
+	// ChangelogURL is a best-effort link to what changed in the update (see
+	// PendingUpdate.ChangelogURL). Empty unless HasUpdate.
+	ChangelogURL string
 }
 
 // DefaultOpTimeout is the default per-operation timeout applied to a single
@@ -118,6 +164,15 @@ func (c *Checker) operationTimeout(cfg *PackageConfig) time.Duration {
 	return c.opTimeout
 }
 
+// cacheTTL returns the per-package cache TTL (cfg.CacheTTL seconds), or 0
+// when the package uses the cache's global TTL.
+func (cfg *PackageConfig) cacheTTL() time.Duration {
+	if cfg == nil || cfg.CacheTTL <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.CacheTTL) * time.Second
+}
+
 // hostForError extracts the host from a URL for diagnostic messages, falling
 // back to the raw URL when it cannot be parsed. It never returns query strings,
 // so it will not leak a credential carried as a query parameter.
@@ -188,10 +243,19 @@ type Checker struct {
 	// Warn here avoids a confusing double-warn. Set via WithLLMProviderConfigured;
 	// defaults false so existing direct callers are unaffected.
 	llmProviderConfigured bool
+	// llmProviders builds the providers for packages carrying an llm override
+	// (PackageConfig.LLM), applied on top of the global configuration set via
+	// WithLLMConfig.
+	llmProviders *llmProviderPool
 	// httpClient handles HTTP requests with retry logic
 	httpClient *RetryableHTTPClient
+	// githubTokenFromFile is the token read by WithGitHubTokenFile
+	githubTokenFromFile string
 	// configDir is the directory for storing cache and pending files
 	configDir string
+	// stateDir, when set via WithStateDir, replaces configDir for the
+	// per-overlay state: the caches, the pending list and the history.
+	stateDir string
 	// ctx is the parent context for all outbound HTTP/LLM calls. It is set via
 	// WithContext and originates in cmd/ (signal.NotifyContext), so a SIGINT or
 	// deadline cancels every in-flight request. Defaults to context.Background().
@@ -229,6 +293,49 @@ type Checker struct {
 	// default 1-hour TTL. It is ignored when a Cache is injected via WithCache,
 	// since that injected Cache carries its own TTL.
 	cacheTTL time.Duration
+	// negativeTTL, when positive, enables negative caching on the default
+	// Cache. Set via WithNegativeCacheTTL.
+	negativeTTL time.Duration
+	// cacheCompact enables gzip compression and compacting writes on the
+	// default Cache. Set via WithCacheCompact; ignored when a Cache is injected.
+	cacheCompact bool
+	// cachePolicy is the storage policy of the default Cache. Set via
+	// WithCachePolicy; ignored when a Cache is injected.
+	cachePolicy CachePolicy
+	// sourceProbe makes CheckPackage HEAD the current ebuild's SRC_URI. Set
+	// via WithSourceProbe.
+	sourceProbe bool
+	// vdbPath is the Portage vdb CheckPackage reads the installed version
+	// from; empty disables the lookup. Set via WithVDB.
+	vdbPath string
+	// quarantineThreshold is the number of consecutive failed CheckAll runs
+	// that quarantines a package; 0 disables counting. Set via
+	// WithQuarantineThreshold, defaults to DefaultQuarantineThreshold.
+	quarantineThreshold int
+	// historyEnabled turns on the check history log. Set via WithHistoryLog.
+	historyEnabled bool
+	// history is the check history log, or nil when disabled
+	history *historyLog
+	// readOnly suppresses every write the Checker would make. Set via
+	// WithReadOnly.
+	readOnly bool
+	// llmCache answers LLM version extraction for content and prompts already
+	// seen. Set via WithLLMCache; nil disables it.
+	llmCache *LLMCache
+	// contentCacheEnabled makes NewChecker open the content cache. Set via
+	// WithContentCache.
+	contentCacheEnabled bool
+	// contentCache holds fetched bodies and their validators for conditional
+	// requests, or nil when disabled
+	contentCache *ContentCache
+	// store persists the cache, pending list and content cache the Checker
+	// creates, or nil for files in configDir. Set via WithStore.
+	store Store
+	// counters accumulates CheckAll outcomes; see Stats
+	counters checkCounters
+	// gitCommand creates the `git ls-remote` command for parser="git-tags"
+	// packages. Set via WithGitExecCommand; nil uses exec.CommandContext.
+	gitCommand func(ctx context.Context, name string, arg ...string) *exec.Cmd
 }
 
 // CheckerOption is a functional option for configuring Checker
@@ -271,6 +378,16 @@ func WithLLMClient(llm LLMProvider) CheckerOption {
 	}
 }
 
+// WithLLMCache answers LLM version extraction from cache when the same
+// provider and model already extracted a version from the same content and
+// prompt, skipping the call. A nil cache disables it.
+func WithLLMCache(cache *LLMCache) CheckerOption {
+	return func(c *Checker) error {
+		c.llmCache = cache
+		return nil
+	}
+}
+
 // WithLLMProviderConfigured records whether the CLI attempted to configure an
 // LLM provider for this run (true when autoupdate.llm.provider was non-empty),
 // independent of whether the provider was successfully built and wired via
@@ -286,6 +403,16 @@ func WithLLMProviderConfigured(configured bool) CheckerOption {
 	}
 }
 
+// WithLLMConfig sets the global LLM configuration that per-package llm
+// overrides are applied on top of. It does not wire a provider for packages
+// without an override; use WithLLMClient for that.
+func WithLLMConfig(cfg LLMConfig) CheckerOption {
+	return func(c *Checker) error {
+		c.llmProviders = newLLMProviderPool(cfg)
+		return nil
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client for the checker
 func WithHTTPClient(client *RetryableHTTPClient) CheckerOption {
 	return func(c *Checker) error {
@@ -407,6 +534,17 @@ func WithProgressCallback(cb ProgressCallback) CheckerOption {
 	}
 }
 
+// WithCacheCompact makes the default Cache constructed by NewChecker write a
+// gzip-compressed file and drop expired entries on every save (see
+// WithCompression and WithCompaction). It wires `autoupdate.cache_compact`
+// from ~/.config/bentoo/config.yaml.
+func WithCacheCompact(compact bool) CheckerOption {
+	return func(c *Checker) error {
+		c.cacheCompact = compact
+		return nil
+	}
+}
+
 // WithCacheTTL sets the TTL applied to the default Cache constructed by
 // NewChecker when no Cache is injected via WithCache. It enables
 // `autoupdate.cache_ttl` from ~/.config/bentoo/config.yaml to reach Cache.TTL
@@ -430,11 +568,12 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 	configDir := filepath.Join(os.Getenv("HOME"), ".config", "bentoo", "autoupdate")
 
 	checker := &Checker{
-		overlayPath: overlayPath,
-		configDir:   configDir,
-		ctx:         context.Background(), // SAFE: default parent; replaced by WithContext when cmd/ wires signal.NotifyContext
-		opTimeout:   DefaultOpTimeout,
-		concurrency: DefaultConcurrency,
+		overlayPath:         overlayPath,
+		configDir:           configDir,
+		ctx:                 context.Background(), // SAFE: default parent; replaced by WithContext when cmd/ wires signal.NotifyContext
+		opTimeout:           DefaultOpTimeout,
+		concurrency:         DefaultConcurrency,
+		quarantineThreshold: DefaultQuarantineThreshold,
 	}
 
 	// Apply options first to allow overriding configDir
@@ -444,6 +583,10 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 		}
 	}
 
+	if checker.stateDir == "" {
+		checker.stateDir = checker.configDir
+	}
+
 	// Load packages configuration if not provided
 	if checker.config == nil {
 		config, err := LoadPackagesConfig(overlayPath)
@@ -462,27 +605,70 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 		if checker.cacheTTL > 0 {
 			cacheOpts = append(cacheOpts, WithTTL(checker.cacheTTL))
 		}
-		cache, err := NewCache(checker.configDir, cacheOpts...)
+		if checker.cacheCompact {
+			cacheOpts = append(cacheOpts, WithCompression(true), WithCompaction(true))
+		}
+		if checker.cachePolicy != "" {
+			cacheOpts = append(cacheOpts, WithStoragePolicy(checker.cachePolicy))
+		}
+		if checker.negativeTTL > 0 {
+			cacheOpts = append(cacheOpts, WithNegativeTTL(checker.negativeTTL))
+		}
+		if checker.store != nil {
+			cacheOpts = append(cacheOpts, WithCacheStore(checker.store))
+		}
+		cache, err := NewCache(checker.stateDir, cacheOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize cache: %w", err)
 		}
 		checker.cache = cache
 	}
+	// Per-package cache_ttl overrides apply to an injected Cache too: they
+	// belong to the packages, not to how the cache was built.
+	for name, pkgCfg := range checker.config.Packages {
+		if ttl := pkgCfg.cacheTTL(); ttl > 0 {
+			checker.cache.SetPackageTTL(name, ttl)
+		}
+	}
+
+	if checker.historyEnabled && !checker.readOnly {
+		checker.history = newHistoryLog(checker.stateDir)
+	}
 
 	// Initialize pending list if not provided
 	if checker.pending == nil {
-		pending, err := NewPendingList(checker.configDir)
+		pendingOpts := []PendingListOption{}
+		if checker.store != nil {
+			pendingOpts = append(pendingOpts, WithPendingStore(checker.store))
+		}
+		pending, err := NewPendingList(checker.stateDir, pendingOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize pending list: %w", err)
 		}
 		checker.pending = pending
 	}
+	if checker.readOnly {
+		checker.cache.readOnly = true
+		checker.pending.readOnly = true
+		if checker.llmCache != nil {
+			checker.llmCache.readOnly = true
+		}
+	}
 
 	// Initialize HTTP client if not provided
 	if checker.httpClient == nil {
 		checker.httpClient = NewRetryableHTTPClient()
 	}
 
+	if checker.contentCacheEnabled {
+		contentCache, err := NewContentCache(checker.stateDir, checker.contentCacheOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize content cache: %w", err)
+		}
+		contentCache.readOnly = checker.readOnly
+		checker.useContentCache(contentCache)
+	}
+
 	// Apply the configured per-request HTTP timeout to the client and size the
 	// per-operation budget from it. Without this, the default per-request timeout
 	// and the per-operation budget are equal, so the first slow request consumes
@@ -495,27 +681,28 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 		}
 	}
 
-	// Authenticate api.github.com requests. Anonymous GitHub API access is capped
-	// at 60 req/h per IP, which the batch checker exhausts quickly; the server
-	// then answers HTTP 403. The token is resolved from GITHUB_TOKEN/GH_TOKEN via
-	// the secrets chain (github.ResolveToken, the single source of truth); a
-	// resolution error warns and continues with unauthenticated access. An
-	// injected client that already carries a token is left untouched.
-	if checker.httpClient.GetGitHubToken() == "" {
-		token, err := github.ResolveToken()
-		if err != nil {
-			warnLogf("resolving GitHub token: %v; continuing with unauthenticated GitHub API access", err)
-		}
-		if token != "" {
-			checker.httpClient.SetGitHubToken(token)
-		}
-	}
+	// Authenticate api.github.com requests; see applyGitHubToken for the
+	// precedence of the token sources.
+	applyGitHubToken(checker.httpClient, checker.githubTokenFromFile)
 
 	// Initialize the HTTP rate limiter if not injected. A Checker must never
 	// have a nil rateLimiter: fetchContent unconditionally waits on it (R10.3).
 	if checker.rateLimiter == nil {
 		checker.rateLimiter = NewRateLimiter()
 	}
+	// User-tuned limits from ratelimits.toml apply to the concrete limiter,
+	// injected or default; a test fake has no policies to tune.
+	if limiter, ok := checker.rateLimiter.(*RateLimiter); ok {
+		if err := loadRateLimits(checker.configDir, limiter); err != nil {
+			return nil, fmt.Errorf("failed to load rate limits: %w", err)
+		}
+	}
+
+	// Without WithLLMConfig, per-package llm overrides apply on top of an empty
+	// global configuration, so they must name their provider.
+	if checker.llmProviders == nil {
+		checker.llmProviders = newLLMProviderPool(LLMConfig{})
+	}
 
 	// R5.3 / R4.2: a non-empty llm_prompt only drives --check when an LLM
 	// provider is wired (llmClient != nil). Warn for each affected package so
@@ -533,7 +720,8 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 	if checker.llmClient == nil && !checker.llmProviderConfigured && checker.config != nil {
 		names := make([]string, 0, len(checker.config.Packages))
 		for name, pkgCfg := range checker.config.Packages {
-			if pkgCfg.LLMPrompt != "" {
+			// A package naming its own provider is served by the override.
+			if pkgCfg.LLMPrompt != "" && (pkgCfg.LLM == nil || pkgCfg.LLM.Provider == "") {
 				names = append(names, name)
 			}
 		}
@@ -550,7 +738,19 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 
 // CheckPackage checks a single package for updates.
 // If force is true, the cache is bypassed and upstream is queried directly.
+//
+// An upstream version that cannot be ordered against the current one returns
+// the result, with NotComparable set, together with a *ComparisonError. The
+// result is still complete; result.Error is left for failures of the check
+// itself, so callers that show a not-comparable package as a warning can.
 func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
+	defer c.flushContentCache()
+	return c.checkPackage(pkg, force)
+}
+
+// checkPackage is CheckPackage without the content cache flush, which a batch
+// does once at its end.
+func (c *Checker) checkPackage(pkg string, force bool) (*CheckResult, error) {
 	result := &CheckResult{
 		Package: pkg,
 	}
@@ -558,7 +758,7 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 	// Get package configuration
 	pkgConfig, exists := c.config.Packages[pkg]
 	if !exists {
-		result.Error = fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)
+		result.Error = &ConfigError{Package: pkg, Err: fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)}
 		return result, result.Error
 	}
 
@@ -575,13 +775,22 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 	// known and ignores its own errors via resolveType's "source" default.
 	result.Type = c.resolveType(pkg, &pkgConfig)
 
+	// The SRC_URI probe is independent of version detection and reports on
+	// the result whatever the version source does below.
+	if c.sourceProbe {
+		c.probeSource(pkg, result)
+	}
+	if c.vdbPath != "" {
+		c.attachInstalledVersion(pkg, result)
+	}
+
 	// Commit-tracked packages always fetch fresh (no cache): the SHA must be
 	// current so the applier can substitute it in the ebuild, and caching only
 	// the date without the SHA would leave the pending entry unusable.
 	if pkgConfig.Track == "commit" {
 		info, err := c.fetchCommitInfo(&pkgConfig)
 		if err != nil {
-			result.Error = fmt.Errorf("%w: %v", ErrFetchFailed, err)
+			result.Error = &FetchError{Package: pkg, URL: pkgConfig.URL, Err: fmt.Errorf("%w: %w", ErrFetchFailed, err)}
 			return result, result.Error
 		}
 
@@ -594,6 +803,9 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 		}
 		newVersion := base + suffix + info.Date
 		result.UpstreamVersion = newVersion
+		if released, err := time.Parse("20060102", info.Date); err == nil {
+			result.ReleasedAt = released
+		}
 
 		// Write to cache so the UI can display the latest known state,
 		// even though this entry is never read back as a cache hit.
@@ -606,14 +818,14 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 		result.NotComparable = !comparable
 
 		if result.HasUpdate {
-			if err := c.addToPending(pkg, currentVersion, newVersion, info.SHA, ""); err != nil {
+			if err := c.addToPending(pkg, currentVersion, newVersion, info.SHA, "", ""); err != nil {
 				if result.Error == nil {
 					result.Error = fmt.Errorf("failed to add to pending: %w", err)
 				}
 			}
 		}
 
-		return result, nil
+		return result, comparisonError(result)
 	}
 
 	// Check cache first (unless force is true)
@@ -621,7 +833,9 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 		if cachedVersion, ok := c.cache.Get(pkg); ok {
 			result.UpstreamVersion = cachedVersion
 			result.FromCache = true
-			hasUpdate, comparable := c.compareVersions(cachedVersion, currentVersion)
+			entry, _ := c.cache.GetEntry(pkg)
+			result.NeedsReview, result.ReviewNote = entry.NeedsReview, entry.ReviewNote
+			hasUpdate, comparable := c.compareVersions(snapshotComparable(&pkgConfig, cachedVersion), currentVersion)
 			result.HasUpdate = hasUpdate
 			result.NotComparable = !comparable
 
@@ -629,40 +843,105 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 			if result.HasUpdate {
 				sha := c.resolveAuxSHA(&pkgConfig, result)
 				aux := c.resolveAuxValue(&pkgConfig, result)
-				if err := c.addToPending(pkg, currentVersion, cachedVersion, sha, aux); err != nil {
+				result.ChangelogURL = resolveChangelogURL(&pkgConfig, currentVersion, cachedVersion, entry.ReleaseURL)
+				if err := c.addToPending(pkg, currentVersion, cachedVersion, sha, aux, result.ChangelogURL); err != nil {
 					// Log but don't fail the check
 					result.Error = fmt.Errorf("failed to add to pending: %w", err)
 				}
 			}
 
-			return result, nil
+			return result, comparisonError(result)
+		}
+		// A recent failure to reach upstream is served like a version,
+		// sparing a dead endpoint another round of retries.
+		if failure, ok := c.cache.GetFailure(pkg); ok {
+			result.Error = fmt.Errorf("%w: %w", ErrFetchFailed, c.cachedFailureError(pkg, failure))
+			return result, result.Error
 		}
 	}
 
 	// Fetch upstream version
-	upstreamVersion, err := c.fetchUpstreamVersion(pkg, &pkgConfig)
+	fetched, err := c.fetchUpstreamVersion(pkg, &pkgConfig)
 	if err != nil {
-		result.Error = fmt.Errorf("%w: %v", ErrFetchFailed, err)
+		// Only an unreachable upstream is cached: a parse or config error
+		// wants a packages.toml fix, not a pause, and a cancelled run says
+		// nothing about the upstream.
+		var fetchErr *FetchError
+		if errors.As(err, &fetchErr) && c.ctx.Err() == nil {
+			if cacheErr := c.cache.SetFailure(pkg, fetchErr.URL, err); cacheErr != nil {
+				logger.Warn("failed to cache the failed lookup of %s: %v", pkg, cacheErr)
+			}
+		}
+		// err carries the typed FetchError/ParseError/ConfigError of the
+		// primary source, so callers can errors.As on result.Error.
+		result.Error = fmt.Errorf("%w: %w", ErrFetchFailed, err)
 		return result, result.Error
 	}
+	// Remember which source answered, so a reliably failing primary is
+	// skipped next run. Like the cache write below, a failure only logs.
+	if err := c.cache.SetLastSource(pkg, fetched.source); err != nil {
+		logger.Warn("failed to record the last source of %s: %v", pkg, err)
+	}
+	upstreamVersion := fetched.version
+	c.reconcileReleaseTags(&pkgConfig, upstreamVersion, result)
+	upstreamVersion = applySnapshotPolicy(&pkgConfig, upstreamVersion, result)
 	result.UpstreamVersion = upstreamVersion
-
-	// Update cache
-	if err := c.cache.Set(pkg, upstreamVersion, pkgConfig.URL); err != nil {
-		// Log but don't fail the check
-		result.Error = fmt.Errorf("failed to update cache: %w", err)
+	flagManifestPlaceholder(pkgConfig.URL, upstreamVersion, result)
+
+	// An LLM answer is scored instead: missing expect_format or disagreeing
+	// with cross_validate sends it to review rather than failing the check.
+	// Below the threshold it is neither cached (a cache hit would queue it)
+	// nor queued.
+	lowConfidence := false
+	if fetched.source == SourceLLM {
+		confidence, notes := c.assessLLMVersion(pkg, &pkgConfig, upstreamVersion)
+		result.LLMConfidence = confidence
+		if confidence < pkgConfig.MinLLMConfidence() {
+			lowConfidence = true
+			result.NeedsReview = true
+			note := fmt.Sprintf("LLM-extracted version has confidence %.2f (below %.2f): %s",
+				confidence, pkgConfig.MinLLMConfidence(), strings.Join(notes, "; "))
+			if result.ReviewNote != "" {
+				note = result.ReviewNote + "; " + note
+			}
+			result.ReviewNote = note
+		}
+	} else if err := checkExpectFormat(&pkgConfig, upstreamVersion); err != nil {
+		// Reject an oddly-shaped value before it is cached or queued.
+		result.Error = &ParseError{Package: pkg, Parser: pkgConfig.Parser, Err: err}
+		return result, result.Error
 	}
 
-	// Compare versions
-	hasUpdate, comparable := c.compareVersions(upstreamVersion, currentVersion)
+	// Compare versions. A re-release is looked for before the cache write
+	// below, so its review flag is stored and a cache hit keeps it.
+	hasUpdate, comparable := c.compareVersions(snapshotComparable(&pkgConfig, upstreamVersion), currentVersion)
 	result.HasUpdate = hasUpdate
 	result.NotComparable = !comparable
+	if !hasUpdate && comparable {
+		c.detectReRelease(&pkgConfig, fetched.body, upstreamVersion, currentVersion, result)
+	}
+
+	// Update cache; the cache's storage policy decides whether body is kept.
+	// The release link is read from the body now, as the body itself may not
+	// be kept.
+	releaseURL := releaseHTMLURL(&pkgConfig, fetched.body, upstreamVersion)
+	if !lowConfidence {
+		entry := CacheEntry{
+			Version: upstreamVersion, Source: pkgConfig.URL, Body: fetched.body, ReleaseURL: releaseURL,
+			NeedsReview: result.NeedsReview, ReviewNote: result.ReviewNote,
+		}
+		if err := c.cache.SetEntry(pkg, entry); err != nil {
+			// Log but don't fail the check
+			result.Error = fmt.Errorf("failed to update cache: %w", err)
+		}
+	}
 
 	// Add to pending if update available
-	if result.HasUpdate {
+	if result.HasUpdate && !lowConfidence {
 		sha := c.resolveAuxSHA(&pkgConfig, result)
 		aux := c.resolveAuxValue(&pkgConfig, result)
-		if err := c.addToPending(pkg, currentVersion, upstreamVersion, sha, aux); err != nil {
+		result.ChangelogURL = resolveChangelogURL(&pkgConfig, currentVersion, upstreamVersion, releaseURL)
+		if err := c.addToPending(pkg, currentVersion, upstreamVersion, sha, aux, result.ChangelogURL); err != nil {
 			// Log but don't fail the check
 			if result.Error == nil {
 				result.Error = fmt.Errorf("failed to add to pending: %w", err)
@@ -670,7 +949,7 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 		}
 	}
 
-	return result, nil
+	return result, comparisonError(result)
 }
 
 // getCurrentVersion finds the current version of a package in the overlay.
@@ -743,6 +1022,10 @@ func (c *Checker) DisableOrphans(pkgs []string) error {
 	if len(pkgs) == 0 {
 		return nil
 	}
+	if c.readOnly {
+		logReadOnly("disable %s in packages.toml", strings.Join(pkgs, ", "))
+		return nil
+	}
 	if err := DisablePackagesInConfig(c.overlayPath, pkgs); err != nil {
 		return err
 	}
@@ -773,6 +1056,10 @@ func (c *Checker) ReviveDisabled(pkgs []string) error {
 	if len(pkgs) == 0 {
 		return nil
 	}
+	if c.readOnly {
+		logReadOnly("re-enable %s in packages.toml", strings.Join(pkgs, ", "))
+		return nil
+	}
 	if err := EnablePackagesInConfig(c.overlayPath, pkgs); err != nil {
 		return err
 	}
@@ -862,11 +1149,12 @@ func (c *Checker) FindRevivableOrphans(prov provider.Provider) ([]ReviveCandidat
 
 		// Best-effort upstream fetch; a failure just drops this package from the
 		// report (it remains disabled, exactly as before).
-		upstream, err := c.fetchUpstreamVersion(pkg, &cfg)
+		fetched, err := c.fetchUpstreamVersion(pkg, &cfg)
 		if err != nil {
 			notes = append(notes, fmt.Sprintf("%s: upstream fetch failed: %v", pkg, err))
 			continue
 		}
+		upstream := fetched.version
 
 		// Highest version ::gentoo currently carries. A package ::gentoo does not
 		// have is simply not revivable from a gentoo base, so skip it silently.
@@ -1009,17 +1297,20 @@ func (c *Checker) compareVersions(upstream, current string) (hasUpdate, comparab
 // commitHash is non-empty only for track="commit" packages or version-tracked
 // packages with commit_sha_path; auxValue is non-empty only for packages with
 // aux_var/aux_pattern. Both are stored in PendingUpdate so the applier can
-// substitute the corresponding variable in the copied ebuild.
-func (c *Checker) addToPending(pkg, currentVersion, newVersion, commitHash, auxValue string) error {
+// substitute the corresponding variable in the copied ebuild. changelogURL is
+// informational only.
+func (c *Checker) addToPending(pkg, currentVersion, newVersion, commitHash, auxValue, changelogURL string) error {
 	update := PendingUpdate{
 		Package:        pkg,
 		CurrentVersion: currentVersion,
 		NewVersion:     newVersion,
 		CommitHash:     commitHash,
 		AuxValue:       auxValue,
+		ChangelogURL:   changelogURL,
 		Status:         StatusPending,
 		DetectedAt:     time.Now(),
 	}
+	c.groupPendingUpdate(&update)
 	return c.pending.Add(update)
 }
 
@@ -1219,66 +1510,134 @@ func scanCommitsForVersion(content []byte, messageRelPath, versionPattern string
 	return best
 }
 
+// upstreamFetch is what fetchUpstreamVersion found.
+type upstreamFetch struct {
+	// version is the extracted upstream version
+	version string
+	// body is the primary source's content, nil when the fallback or the
+	// LLM answered
+	body []byte
+	// source is which source answered: SourcePrimary, SourceFallback or
+	// SourceLLM (see assessLLMVersion)
+	source string
+}
+
 // fetchUpstreamVersion fetches and parses the upstream version for a package.
 // It tries the primary URL/parser first, then fallback if configured, then LLM if available.
-func (c *Checker) fetchUpstreamVersion(pkg string, cfg *PackageConfig) (string, error) {
+// When the fallback answered last time (see Cache.LastSource) it is tried
+// before the primary instead.
+// An LLM answer that is not IsPlausibleVersion is rejected like a failure.
+func (c *Checker) fetchUpstreamVersion(pkg string, cfg *PackageConfig) (upstreamFetch, error) {
 	// The script parser drives a headless browser itself, so it bypasses
 	// fetchContent/fetchAndParse entirely (and therefore transform/select, which
 	// the script handles in JS — see ValidatePackageConfig). It has no fallback
 	// or LLM stage: the script is the single source of truth.
 	if cfg.Parser == "script" {
-		return c.parseLive(cfg)
+		version, err := c.parseLive(cfg)
+		if err != nil {
+			return upstreamFetch{}, &FetchError{Package: pkg, URL: cfg.URL, Err: err}
+		}
+		return upstreamFetch{version: version, source: SourcePrimary}, nil
+	}
+
+	// A package whose fallback won last run starts there: its primary is
+	// likely still failing, and trying it first costs a request (and its
+	// retries) every run. If the fallback fails now, the primary gets its turn.
+	fallbackCfg := fallbackConfig(cfg)
+	if fallbackCfg != nil && c.cache.LastSource(pkg) == SourceFallback {
+		if version, _, err := c.fetchAndParse(pkg, cfg.FallbackURL, fallbackCfg); err == nil {
+			return upstreamFetch{version: version, source: SourceFallback}, nil
+		}
+		fallbackCfg = nil // already tried
+	}
+
+	// A two-stage source first resolves the real URL from its index. A
+	// broken index fails the primary like any other primary error, so the
+	// fallback and the LLM still get their turn; the LLM then reads the index,
+	// the only upstream page known.
+	primaryURL := cfg.URL
+	var primaryErr error
+	if cfg.PreFetch != nil {
+		resolved, err := c.resolvePreFetch(pkg, cfg)
+		if err != nil {
+			primaryErr = err
+			primaryURL = cfg.PreFetch.URL
+		} else {
+			primaryURL = resolved
+		}
 	}
 
 	// Try primary URL
-	version, err := c.fetchAndParse(cfg.URL, cfg)
-	if err == nil {
-		return version, nil
+	// Only the primary body is returned for the cache: ReparseCached re-runs
+	// the primary parser, which a fallback or LLM body would not suit.
+	if primaryErr == nil {
+		version, body, err := c.fetchAndParse(pkg, primaryURL, cfg)
+		if err == nil {
+			return upstreamFetch{version: version, body: body, source: SourcePrimary}, nil
+		}
+		primaryErr = err
 	}
-	primaryErr := err
 
 	// Try fallback URL if configured
-	if cfg.FallbackURL != "" && cfg.FallbackParser != "" {
-		fallbackPattern := cfg.FallbackPattern
-		if fallbackPattern == "" && cfg.FallbackParser == "json" {
-			fallbackPattern = cfg.Path // Use primary path for JSON fallback
-		}
-
-		// Derive a config for the fallback URL: it swaps in the fallback
-		// parser/pattern but keeps the primary path/selector/xpath and the
-		// transform/select post-processing so the fallback behaves consistently.
-		fallbackCfg := &PackageConfig{
-			Parser:    cfg.FallbackParser,
-			Path:      cfg.Path,
-			Pattern:   fallbackPattern,
-			Selector:  cfg.Selector,
-			XPath:     cfg.XPath,
-			Transform: cfg.Transform,
-			Select:    cfg.Select,
-		}
-		version, err = c.fetchAndParse(cfg.FallbackURL, fallbackCfg)
+	if fallbackCfg != nil {
+		version, _, err := c.fetchAndParse(pkg, cfg.FallbackURL, fallbackCfg)
 		if err == nil {
-			return version, nil
+			return upstreamFetch{version: version, source: SourceFallback}, nil
 		}
 	}
 
 	// Try LLM if configured and available
-	if c.llmClient != nil && cfg.LLMPrompt != "" {
+	if llm := c.llmProviders.providerFor(pkg, cfg.LLM, c.llmClient); llm != nil && cfg.LLMPrompt != "" {
 		// Fetch content from primary URL for LLM
-		content, err := c.fetchContent(cfg.URL, cfg.Headers, c.operationTimeout(cfg))
+		content, err := c.fetchContent(primaryURL, cfg.Headers, c.operationTimeout(cfg))
 		if err == nil {
-			version, err = c.llmClient.ExtractVersion(content, cfg.LLMPrompt)
+			version, err := c.llmCache.ExtractVersion(llm, content, cfg.LLMPrompt)
+			if err == nil && !IsPlausibleVersion(version) {
+				return upstreamFetch{}, fmt.Errorf("all version extraction methods failed: %w (LLM returned %w %q)",
+					primaryErr, ErrImplausibleVersion, version)
+			}
 			if err == nil {
-				return version, nil
+				return upstreamFetch{version: version, source: SourceLLM}, nil
 			}
 		}
 	}
 
 	// All methods failed
-	return "", fmt.Errorf("all version extraction methods failed: %w", primaryErr)
+	return upstreamFetch{}, fmt.Errorf("all version extraction methods failed: %w", primaryErr)
 }
 
-// fetchAndParse fetches content from rawURL and extracts a version from it.
+// fallbackConfig derives the config for cfg's fallback URL, or returns nil
+// when none is configured. It swaps in the fallback parser/pattern but keeps
+// the primary path/selector/xpath, the body preprocessing (strip_ansi,
+// strip_jsonp) and the transform/select post-processing so the fallback
+// behaves consistently.
+func fallbackConfig(cfg *PackageConfig) *PackageConfig {
+	if cfg.FallbackURL == "" || cfg.FallbackParser == "" {
+		return nil
+	}
+	fallbackPattern := cfg.FallbackPattern
+	if fallbackPattern == "" && cfg.FallbackParser == "json" {
+		fallbackPattern = cfg.Path // Use primary path for JSON fallback
+	}
+	return &PackageConfig{
+		Parser:            cfg.FallbackParser,
+		Path:              cfg.Path,
+		Pattern:           fallbackPattern,
+		Selector:          cfg.Selector,
+		XPath:             cfg.XPath,
+		Transform:         cfg.Transform,
+		Select:            cfg.Select,
+		VersionConstraint: cfg.VersionConstraint,
+		StablePattern:     cfg.StablePattern,
+		ExcludePattern:    cfg.ExcludePattern,
+		StripANSI:         cfg.StripANSI,
+		StripJSONP:        cfg.StripJSONP,
+	}
+}
+
+// fetchAndParse fetches content from rawURL and extracts a version from it,
+// returning the fetched body alongside for the cache (see CachePolicy).
+// Failures are returned as a *FetchError, *ParseError or *ConfigError for pkg.
 //
 // It takes the whole *PackageConfig so it can apply the post-extraction stages:
 //   - select: when cfg.Select is "max"/"last", every candidate is extracted
@@ -1291,49 +1650,139 @@ func (c *Checker) fetchUpstreamVersion(pkg string, cfg *PackageConfig) (string,
 // The parser itself is built via NewParserFromConfig so every configured parser
 // type is supported — including "html", whose selector/xpath fields wire the
 // scrape plus optional regex post-processing (carried in Pattern).
-func (c *Checker) fetchAndParse(rawURL string, cfg *PackageConfig) (string, error) {
-	// Fetch content
-	content, err := c.fetchContent(rawURL, cfg.Headers, c.operationTimeout(cfg))
+func (c *Checker) fetchAndParse(pkg, rawURL string, cfg *PackageConfig) (string, []byte, error) {
+	rawURL, headers, err := c.sourceURL(pkg, rawURL, cfg)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	// Fetch content; a GraphQL source is queried with a POST instead, a git
+	// remote lists its tags through git, and a header source is only asked
+	// for its response headers.
+	var content []byte
+	switch cfg.Parser {
+	case "graphql":
+		content, err = c.fetchGraphQL(rawURL, cfg)
+	case "header":
+		content, err = c.fetchHeaders(rawURL, headers, c.operationTimeout(cfg))
+	case "git-tags":
+		content, err = c.lsRemoteTags(rawURL, c.operationTimeout(cfg))
+	default:
+		content, err = c.fetchContent(rawURL, headers, c.operationTimeout(cfg))
 	}
+	if err != nil {
+		return "", nil, &FetchError{Package: pkg, URL: rawURL, Err: err}
+	}
+	version, err := c.parseContent(pkg, cfg, content)
+	if err != nil {
+		return "", nil, err
+	}
+	return version, content, nil
+}
 
-	// select path: collect all candidates, transform each, then pick one.
-	if cfg.Select != "" && cfg.Select != "first" {
-		extractor, exErr := newSelectExtractor(cfg)
+// sourceURL resolves the URL fetchAndParse requests for rawURL, and the
+// headers it sends. Helm, milestone, Gitea and RPM packages may name just
+// the repository or instance; it is rewritten to the document that actually
+// lists versions. For RPM that takes a request of its own: the primary
+// metadata's name is only known from repomd.xml.
+func (c *Checker) sourceURL(pkg, rawURL string, cfg *PackageConfig) (string, map[string]string, error) {
+	headers := cfg.Headers
+	switch cfg.Parser {
+	case "helm":
+		rawURL = helmIndexURL(rawURL)
+	case "github-milestone":
+		rawURL = githubMilestonesURL(rawURL)
+	case "gitea":
+		if cfg.Gitea == nil {
+			return "", nil, &ConfigError{Package: pkg, Err: ErrInvalidGitea}
+		}
+		rawURL = giteaReleasesURL(rawURL, cfg.Gitea)
+		headers = giteaHeaders(cfg)
+	case "rpm":
+		primaryURL, err := c.rpmPrimaryURL(rawURL, cfg)
+		if err != nil {
+			return "", nil, &FetchError{Package: pkg, URL: repomdURL(rawURL), Err: err}
+		}
+		rawURL = primaryURL
+	}
+	return rawURL, headers, nil
+}
+
+// parseContent extracts the version from a fetched body: the parse half of
+// fetchAndParse, shared with ReparseCached.
+func (c *Checker) parseContent(pkg string, cfg *PackageConfig, content []byte) (string, error) {
+	if cfg.StripANSI {
+		content = stripANSI(content)
+	}
+	if cfg.StripJSONP {
+		content = unwrapJSONP(content)
+	}
+
+	// select path: collect all candidates, transform each, then pick one. An
+	// array match already pins a single element, so it bypasses selection.
+	// A version constraint needs the candidates too, and picks the highest
+	// one it allows unless select asks for the last; so do the stable and
+	// exclude patterns, which drop candidates before selection.
+	constraint := cfg.constraint()
+	filter := cfg.versionFilter()
+	mode := cfg.Select
+	if (len(constraint) > 0 || filter != nil) && mode != "last" {
+		mode = "max"
+	}
+	if mode != "" && mode != "first" && cfg.Match == nil {
+		extractor, exErr := newCandidateExtractor(cfg, filter)
 		if exErr != nil {
-			return "", fmt.Errorf("failed to create select extractor: %w", exErr)
+			return "", &ConfigError{Package: pkg, Err: fmt.Errorf("failed to create select extractor: %w", exErr)}
 		}
 		if extractor != nil {
 			cands, cErr := extractor.ExtractVersions(content)
 			if cErr != nil {
-				return "", fmt.Errorf("failed to extract version candidates: %w", cErr)
+				return "", &ParseError{Package: pkg, Parser: cfg.Parser,
+					Err: fmt.Errorf("failed to extract version candidates: %w", cErr)}
+			}
+			total := len(cands)
+			cands = filter.apply(cands)
+			var best string
+			if cfg.SnapshotPolicy != "" {
+				best = selectSnapshotVersion(cands, cfg.Transform, mode, constraint)
+			} else {
+				best = selectVersion(cands, cfg.Transform, mode, constraint)
 			}
-			best := selectVersion(cands, cfg.Transform, cfg.Select)
 			if best == "" {
-				return "", fmt.Errorf("%w: no comparable version among %d candidate(s) for select=%q",
-					ErrNoVersionFound, len(cands), cfg.Select)
+				return "", &ParseError{Package: pkg, Parser: cfg.Parser,
+					Err: fmt.Errorf("%w: no comparable version among %d candidate(s) (%d after stable/exclude patterns) for select=%q version_constraint=%q",
+						ErrNoVersionFound, total, len(cands), mode, cfg.VersionConstraint)}
 			}
 			return best, nil
 		}
 		// Not list-capable (e.g. parser="script"): warn and use first match.
 		warnLogf("select=%q requested but parser %q cannot extract a list; using first match",
-			cfg.Select, cfg.Parser)
+			mode, cfg.Parser)
 	}
 
 	// Create parser. NewParserFromConfig handles json/regex/html uniformly.
 	parser, err := NewParserFromConfig(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to create parser: %w", err)
+		return "", &ConfigError{Package: pkg, Err: fmt.Errorf("failed to create parser: %w", err)}
 	}
 
 	// Parse content, then apply transform to the single extracted version.
 	version, err := parser.Parse(content)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse version: %w", err)
+		return "", &ParseError{Package: pkg, Parser: cfg.Parser, Err: fmt.Errorf("failed to parse version: %w", err)}
+	}
+	if !filter.allows(version) {
+		return "", &ParseError{Package: pkg, Parser: cfg.Parser,
+			Err: fmt.Errorf("%w: %s is rejected by stable_pattern/exclude_pattern", ErrNoVersionFound, version)}
 	}
 	version = applyTransforms(version, cfg.Transform)
 
+	// Without a candidate list the constraint can only vet the one version.
+	if !constraint.allows(snapshotComparable(cfg, version)) {
+		return "", &ParseError{Package: pkg, Parser: cfg.Parser,
+			Err: fmt.Errorf("%w: %s is outside version_constraint %q", ErrNoVersionFound, version, cfg.VersionConstraint)}
+	}
+
 	return version, nil
 }
 
@@ -1410,7 +1859,23 @@ func (c *Checker) parseLive(cfg *PackageConfig) (string, error) {
 // URLs, the configured GitHub token. Passing them through GetWithHeadersContext
 // (rather than the bare GetWithContext) is what actually puts the User-Agent,
 // the Authorization token, and any TOML-declared headers on the wire.
+//
+// With a content cache (see WithContentCache) the client makes the request
+// conditional, and a failed fetch may be answered by staleContent.
 func (c *Checker) fetchContent(rawURL string, headers map[string]string, opTimeout time.Duration) ([]byte, error) {
+	content, err := c.fetchWith(rawURL, opTimeout, func(ctx context.Context) (*http.Response, error) {
+		return c.httpClient.GetWithHeadersContext(ctx, rawURL, headers)
+	})
+	if err != nil && c.contentCache != nil {
+		return c.staleContent(rawURL, err)
+	}
+	return content, err
+}
+
+// fetchWith is fetchContent with the request left to send, so a source that
+// must POST (see fetchGraphQL) shares the rate limiting, timeout and status
+// handling of a plain GET.
+func (c *Checker) fetchWith(rawURL string, opTimeout time.Duration, send func(ctx context.Context) (*http.Response, error)) ([]byte, error) {
 	// Gate on the per-host rate limiter FIRST, waiting on the parent context
 	// rather than an opTimeout-bounded one. The wait must not be charged against
 	// the per-request HTTP deadline: when many packages share a host, a queued
@@ -1444,7 +1909,7 @@ func (c *Checker) fetchContent(rawURL string, headers map[string]string, opTimeo
 	ctx, cancel := context.WithTimeout(c.ctx, opTimeout)
 	defer cancel()
 
-	resp, err := c.httpClient.GetWithHeadersContext(ctx, rawURL, headers)
+	resp, err := send(ctx)
 	if err != nil {
 		// Name the host and the per-request cap so a timeout points the user at
 		// the slow endpoint and the knob to raise (autoupdate.http_timeout /
@@ -1461,7 +1926,7 @@ func (c *Checker) fetchContent(rawURL string, headers map[string]string, opTimeo
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
 		// Translate an http.MaxBytesReader overflow into ErrResponseTooLarge
-		// (R11.3); GetWithContext caps the body at httputil.MaxBodyBytes.
+		// (R11.3); the client caps the body at RetryConfig.MaxBodySize.
 		return nil, fmt.Errorf("failed to read response body: %w", classifyBodyReadError(err))
 	}
 
@@ -1490,6 +1955,13 @@ func (c *Checker) fetchContent(rawURL string, headers map[string]string, opTimeo
 // has joined (wg.Wait), so callers may invoke its methods (ExitCode,
 // FormatFailures) directly.
 func (c *Checker) CheckAll(force bool) BatchResult[CheckResult] {
+	return c.checkSelected(force, nil)
+}
+
+// checkSelected is CheckAll restricted to the packages keep accepts. A nil
+// keep selects every package; the enabled, hold and type filters apply either
+// way.
+func (c *Checker) checkSelected(force bool, keep func(pkg string) bool) BatchResult[CheckResult] {
 	// Reconcile status with the overlay BEFORE filtering: the overlay — not
 	// packages.toml — is the source of truth for whether a package exists. A
 	// package auto-disabled (enabled = false) when its ebuild vanished must not
@@ -1519,20 +1991,8 @@ func (c *Checker) CheckAll(force bool) BatchResult[CheckResult] {
 	}
 
 	// Narrow the package set up front so excluded packages incur no network
-	// fetch and are absent from progress and totals. Three filters apply:
-	//   - enabled = false: always skipped, silently (no log, no count);
-	//   - hold = true: maintainer-held, skipped silently like a disabled entry;
-	//   - type filter (when active): keep only the matching bin/source class.
-	pkgs := make(map[string]PackageConfig, len(c.config.Packages))
-	for name, pkg := range c.config.Packages {
-		if !pkg.IsEnabled() || pkg.IsHeld() {
-			continue
-		}
-		if c.typeFilter != "" && c.resolveType(name, &pkg) != c.typeFilter {
-			continue
-		}
-		pkgs[name] = pkg
-	}
+	// fetch and are absent from progress and totals.
+	pkgs := c.selectPackages(keep)
 
 	var (
 		sem      = make(chan struct{}, c.concurrency)
@@ -1580,7 +2040,16 @@ func (c *Checker) CheckAll(force bool) BatchResult[CheckResult] {
 				}
 			}()
 
-			result, err := c.CheckPackage(n, force)
+			result, err := c.checkPackage(n, force)
+			// A not-comparable upstream is a complete result shown as a
+			// warning, not a failure of the check.
+			var cmpErr *ComparisonError
+			if errors.As(err, &cmpErr) {
+				err = nil
+			}
+			if !errors.Is(err, ErrNoEbuildFound) {
+				c.recordCheck(n, result, err)
+			}
 
 			mu.Lock()
 			switch {
@@ -1607,6 +2076,7 @@ func (c *Checker) CheckAll(force bool) BatchResult[CheckResult] {
 	// Join every worker before touching the shared state so the BatchResult is
 	// fully populated and safe to return.
 	wg.Wait()
+	c.flushContentCache()
 
 	// Auto-disable packages whose ebuild vanished from the overlay. A single
 	// batched write keeps the hand-maintained packages.toml's comments intact;
@@ -1618,6 +2088,8 @@ func (c *Checker) CheckAll(force bool) BatchResult[CheckResult] {
 		}
 	}
 
+	c.updateFailureStreaks(results, failures)
+
 	// Deterministic final ordering, independent of completion order.
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Package < results[j].Package
@@ -1626,6 +2098,35 @@ func (c *Checker) CheckAll(force bool) BatchResult[CheckResult] {
 	return BatchResult[CheckResult]{Items: results, Failures: failures}
 }
 
+// selectPackages returns the packages a batch run covers. Five filters
+// apply:
+//   - enabled = false: always skipped, silently (no log, no count);
+//   - hold = true: maintainer-held, skipped silently like a disabled entry;
+//   - quarantined after repeated failures: skipped with a debug log, until
+//     cleared (see ClearQuarantine);
+//   - keep (when non-nil): the caller's selection, e.g. CheckMine;
+//   - type filter (when active): keep only the matching bin/source class.
+func (c *Checker) selectPackages(keep func(pkg string) bool) map[string]PackageConfig {
+	pkgs := make(map[string]PackageConfig, len(c.config.Packages))
+	for name, pkg := range c.config.Packages {
+		if !pkg.IsEnabled() || pkg.IsHeld() {
+			continue
+		}
+		if keep != nil && !keep(name) {
+			continue
+		}
+		if c.pending.IsQuarantined(name) {
+			logger.Debug("skipping %s: quarantined after repeated check failures", name)
+			continue
+		}
+		if c.typeFilter != "" && c.resolveType(name, &pkg) != c.typeFilter {
+			continue
+		}
+		pkgs[name] = pkg
+	}
+	return pkgs
+}
+
 // Config returns the packages configuration.
 func (c *Checker) Config() *PackagesConfig {
 	return c.config
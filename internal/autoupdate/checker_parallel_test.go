@@ -354,6 +354,47 @@ func TestCheckAll_ResultsSorted(t *testing.T) {
 	}
 }
 
+// TestCheckAll_ConcurrentPendingWrites verifies that every update found by a
+// parallel batch lands in the pending list, both in memory and in the saved
+// document, so concurrent PendingList.Add calls never lose an entry. Run with
+// -race to also check the writes for data races.
+func TestCheckAll_ConcurrentPendingWrites(t *testing.T) {
+	const numPkgs = 40
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"version": "1.0.0"})
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	checker, names := buildParallelChecker(t, numPkgs, server.URL,
+		WithConfigDir(configDir),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithConcurrency(8),
+	)
+
+	batch := checker.CheckAll(true)
+	if len(batch.Items) != numPkgs || len(batch.Failures) != 0 {
+		t.Fatalf("CheckAll produced %d items and %d failures, want %d and 0",
+			len(batch.Items), len(batch.Failures), numPkgs)
+	}
+
+	reloaded, err := NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList failed: %v", err)
+	}
+	for _, list := range []*PendingList{checker.Pending(), reloaded} {
+		if got := len(list.List()); got != numPkgs {
+			t.Errorf("pending list holds %d entries, want %d", got, numPkgs)
+		}
+		for _, name := range names {
+			if u, ok := list.Get(name); !ok || u.NewVersion != "1.0.0" {
+				t.Errorf("pending entry for %s = %+v, %v; want an update to 1.0.0", name, u, ok)
+			}
+		}
+	}
+}
+
 // TestCheckAll_ContextCancelMidFlight verifies that cancelling the Checker's
 // parent context part-way through a large batch stops dispatch: only about
 // `concurrency` packages do real work and the remainder are recorded in
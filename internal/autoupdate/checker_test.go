@@ -3,6 +3,7 @@ package autoupdate
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -654,6 +655,64 @@ func TestCheckPackageUsesCache(t *testing.T) {
 	}
 }
 
+// TestCheckPackagePerPackageCacheTTL tests CheckPackage consults a package's
+// cache_ttl before the global TTL: two hours after caching, a package cached
+// for a week is still answered from cache while one on the 1-hour global TTL
+// is fetched again.
+func TestCheckPackagePerPackageCacheTTL(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"version": "3.0.0"})
+	}))
+	defer server.Close()
+
+	createTestEbuild(t, overlayDir, "test-cat/slow", "1.0.0")
+	createTestEbuild(t, overlayDir, "test-cat/fast", "1.0.0")
+	config := &PackagesConfig{
+		Packages: map[string]PackageConfig{
+			"test-cat/slow": {URL: server.URL, Parser: "json", Path: "version", CacheTTL: 7 * 24 * 3600},
+			"test-cat/fast": {URL: server.URL, Parser: "json", Path: "version"},
+		},
+	}
+
+	fixedNow := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	now := fixedNow
+	cache, _ := NewCache(configDir, WithNowFunc(func() time.Time { return now }))
+	cache.Set("test-cat/slow", "2.0.0", server.URL)
+	cache.Set("test-cat/fast", "2.0.0", server.URL)
+
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(configDir),
+		WithPackagesConfig(config),
+		WithCache(cache),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	now = fixedNow.Add(2 * time.Hour)
+	for pkg, want := range map[string]struct {
+		fromCache bool
+		version   string
+	}{
+		"test-cat/slow": {true, "2.0.0"},
+		"test-cat/fast": {false, "3.0.0"},
+	} {
+		result, err := checker.CheckPackage(pkg, false)
+		if err != nil {
+			t.Fatalf("CheckPackage(%s) error = %v", pkg, err)
+		}
+		if result.FromCache != want.fromCache || result.UpstreamVersion != want.version {
+			t.Errorf("CheckPackage(%s) = FromCache %v, %q; want %v, %q",
+				pkg, result.FromCache, result.UpstreamVersion, want.fromCache, want.version)
+		}
+	}
+}
+
 // TestCheckPackageBypassesCache tests that force flag bypasses cache
 func TestCheckPackageBypassesCache(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -837,8 +896,12 @@ func TestCheckPackageNotComparable(t *testing.T) {
 	}
 
 	result, err := checker.CheckPackage(pkgName, true)
-	if err != nil {
-		t.Fatalf("Unexpected error: %v", err)
+	var cmpErr *ComparisonError
+	if !errors.As(err, &cmpErr) || cmpErr.Package != pkgName || cmpErr.Stage() != StageCompare {
+		t.Fatalf("CheckPackage() error = %v, want a *ComparisonError for %s", err, pkgName)
+	}
+	if result.Error != nil {
+		t.Errorf("result.Error = %v, want nil for a not-comparable result", result.Error)
 	}
 
 	if !result.NotComparable {
@@ -852,6 +915,12 @@ func TestCheckPackageNotComparable(t *testing.T) {
 	if _, ok := checker.pending.Get(pkgName); ok {
 		t.Error("Expected non-comparable package NOT to be added to the pending list")
 	}
+
+	// A batch keeps it as a warning result rather than a failure.
+	batch := checker.CheckAll(true)
+	if batch.HasFailures() || len(batch.Items) != 1 || !batch.Items[0].NotComparable {
+		t.Errorf("CheckAll() = %+v (failures %v), want one NotComparable result", batch.Items, batch.Failures)
+	}
 }
 
 // TestCheckPackageStripsVPrefix verifies that a leading "v" on the upstream
@@ -1514,6 +1583,79 @@ func TestFetchUpstreamVersionFallback(t *testing.T) {
 	}
 }
 
+// TestFetchUpstreamVersionFallbackStripsBody verifies the fallback inherits
+// the primary's strip_ansi and strip_jsonp preprocessing.
+func TestFetchUpstreamVersionFallbackStripsBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		pattern string
+		cfg     PackageConfig
+	}{
+		{
+			name:    "strip_ansi",
+			body:    "pkgver=\x1b[32m3.0.0\x1b[0m",
+			pattern: `pkgver=([0-9.]+)`,
+			cfg:     PackageConfig{StripANSI: true},
+		},
+		{
+			name:    "strip_jsonp",
+			body:    `cb({"version":"3.0.0"});`,
+			pattern: `^\{"version":"([0-9.]+)"\}$`,
+			cfg:     PackageConfig{StripJSONP: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			overlayDir := filepath.Join(tmpDir, "overlay")
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/primary" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			pkgName := "test-cat/test-pkg"
+			createTestEbuild(t, overlayDir, pkgName, "1.0.0")
+
+			pkgCfg := tt.cfg
+			pkgCfg.URL = server.URL + "/primary"
+			pkgCfg.Parser = "regex"
+			pkgCfg.Pattern = tt.pattern
+			pkgCfg.FallbackURL = server.URL + "/fallback"
+			pkgCfg.FallbackParser = "regex"
+			pkgCfg.FallbackPattern = tt.pattern
+
+			if got := fallbackConfig(&pkgCfg); got.StripANSI != pkgCfg.StripANSI || got.StripJSONP != pkgCfg.StripJSONP {
+				t.Fatalf("fallbackConfig() strip_ansi=%v strip_jsonp=%v, want %v/%v",
+					got.StripANSI, got.StripJSONP, pkgCfg.StripANSI, pkgCfg.StripJSONP)
+			}
+
+			checker, err := NewChecker(overlayDir,
+				WithConfigDir(filepath.Join(tmpDir, "config")),
+				WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{pkgName: pkgCfg}}),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			result, err := checker.CheckPackage(pkgName, true)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.UpstreamVersion != "3.0.0" {
+				t.Errorf("Expected upstream version '3.0.0' from fallback, got %q", result.UpstreamVersion)
+			}
+		})
+	}
+}
+
 // =============================================================================
 // Helper Functions for Tests
 // =============================================================================
@@ -142,3 +142,17 @@ func TestValidatePackageConfig_NegativeTimeout(t *testing.T) {
 		t.Errorf("zero timeout should be valid (use global), got: %v", err)
 	}
 }
+
+// TestValidatePackageConfig_NegativeCacheTTL asserts a negative per-package
+// cache_ttl is rejected, while zero (the "use global" sentinel) is accepted.
+func TestValidatePackageConfig_NegativeCacheTTL(t *testing.T) {
+	bad := &PackageConfig{URL: "https://example.com", Parser: "regex", Pattern: "v(.+)", CacheTTL: -60}
+	if err := ValidatePackageConfig("cat/pkg", bad); err == nil {
+		t.Error("expected an error for a negative cache_ttl, got nil")
+	}
+
+	ok := &PackageConfig{URL: "https://example.com", Parser: "regex", Pattern: "v(.+)", CacheTTL: 0}
+	if err := ValidatePackageConfig("cat/pkg", ok); err != nil {
+		t.Errorf("zero cache_ttl should be valid (use global), got: %v", err)
+	}
+}
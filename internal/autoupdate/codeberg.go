@@ -0,0 +1,145 @@
+// Package autoupdate provides Codeberg and Gitea/Forgejo releases discovery
+// for ebuild autoupdate.
+package autoupdate
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PriorityGitea is the priority for a Gitea or Forgejo repository's releases
+// API. Like GitLab it ranks with GitHub.
+const PriorityGitea = 10
+
+// giteaReleasesPath is the JSON path of the newest release's tag in a Gitea
+// releases API response, which lists releases newest first like GitHub's.
+const giteaReleasesPath = "[0].tag_name"
+
+// DefaultGiteaHosts are the Gitea-family instances discovery always
+// recognizes; WithGiteaHosts adds self-hosted ones.
+var DefaultGiteaHosts = []string{"codeberg.org"}
+
+// DiscoverOption configures DiscoverDataSources.
+type DiscoverOption func(*discoverOptions)
+
+// discoverOptions holds the settings DiscoverOption values set.
+type discoverOptions struct {
+	// giteaHosts are the hosts, lower-cased, whose project URLs are taken
+	// for Gitea repositories
+	giteaHosts []string
+}
+
+// WithGiteaHosts adds hosts of self-hosted Gitea or Forgejo instances
+// ("git.example.org") to DefaultGiteaHosts, so their project URLs in
+// HOMEPAGE or SRC_URI are discovered as release sources. A scheme or
+// trailing slash is ignored, so "https://git.example.org/" works too.
+func WithGiteaHosts(hosts ...string) DiscoverOption {
+	return func(o *discoverOptions) {
+		for _, h := range hosts {
+			h = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(h)), "https://"), "http://")
+			if h = strings.TrimRight(h, "/"); h != "" {
+				o.giteaHosts = append(o.giteaHosts, h)
+			}
+		}
+	}
+}
+
+// WithAnalyzerGiteaHosts sets the self-hosted Gitea or Forgejo instances the
+// analyzer's source discovery recognizes besides codeberg.org (see
+// WithGiteaHosts).
+func WithAnalyzerGiteaHosts(hosts []string) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.giteaHosts = hosts
+		return nil
+	}
+}
+
+// newDiscoverOptions applies opts over the defaults.
+func newDiscoverOptions(opts []DiscoverOption) *discoverOptions {
+	o := &discoverOptions{giteaHosts: append([]string(nil), DefaultGiteaHosts...)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// giteaReservedOwners are the first path segments of a Gitea URL that are
+// routes of the instance rather than a user or organization.
+var giteaReservedOwners = map[string]bool{
+	"api": true, "user": true, "explore": true, "assets": true, "attachments": true, "repo": true,
+}
+
+// findGiteaRepo returns the host, owner and repository of the first URL in s
+// that points into a repository on one of hosts:
+// https://<host>/<owner>/<repo>[.git][/...].
+func findGiteaRepo(s string, hosts []string) (host, owner, repo string, ok bool) {
+	for _, field := range strings.Fields(s) {
+		field = strings.Trim(field, `"'`)
+		u, err := url.Parse(field)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		h := strings.ToLower(u.Host)
+		known := false
+		for _, candidate := range hosts {
+			if h == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			continue
+		}
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(segments) < 2 || giteaReservedOwners[segments[0]] {
+			continue
+		}
+		repo := strings.TrimSuffix(segments[1], ".git")
+		if segments[0] == "" || repo == "" {
+			continue
+		}
+		return h, segments[0], repo, true
+	}
+	return "", "", "", false
+}
+
+// discoverGiteaSource attempts to discover a Gitea releases API endpoint from
+// a repository URL on codeberg.org or a configured instance in HOMEPAGE or
+// SRC_URI (an archive such as <host>/<owner>/${PN}/archive/v${PV}.tar.gz).
+func discoverGiteaSource(meta *EbuildMetadata, hosts []string) *DataSource {
+	for _, s := range []string{meta.Homepage, meta.SrcURI} {
+		host, owner, repo, ok := findGiteaRepo(s, hosts)
+		if !ok {
+			continue
+		}
+		owner, repo = expandPN(owner, meta.Package), expandPN(repo, meta.Package)
+		if owner != "" && repo != "" {
+			return createGiteaSource(host, owner, repo)
+		}
+	}
+	return nil
+}
+
+// createGiteaSource creates a releases API data source for owner/repo on
+// host.
+func createGiteaSource(host, owner, repo string) *DataSource {
+	return &DataSource{
+		URL:         giteaReleasesURL("https://"+host, &GiteaConfig{Owner: owner, Repo: repo}),
+		Type:        "gitea",
+		Priority:    PriorityGitea,
+		ContentType: ContentTypeJSON,
+	}
+}
+
+// giteaSourceCovers reports whether rawURL is a page of the repository whose
+// releases source is, so the homepage is not also listed as a candidate.
+func giteaSourceCovers(rawURL string, source DataSource) bool {
+	api, err := url.Parse(source.URL)
+	if err != nil {
+		return false
+	}
+	repoPath := strings.TrimSuffix(strings.TrimPrefix(api.Path, "/api/v1/repos"), "/releases")
+	host, owner, repo, ok := findGiteaRepo(rawURL, []string{strings.ToLower(api.Host)})
+	return ok && host == strings.ToLower(api.Host) && fmt.Sprintf("/%s/%s", owner, repo) == repoPath
+}
@@ -0,0 +1,84 @@
+package autoupdate
+
+import (
+	"testing"
+)
+
+// giteaSources returns the sources DiscoverDataSources finds for meta with
+// opts, and the gitea one among them.
+func giteaSources(meta *EbuildMetadata, opts ...DiscoverOption) ([]DataSource, *DataSource) {
+	sources := DiscoverDataSources(meta, "", opts...)
+	for i := range sources {
+		if sources[i].Type == "gitea" {
+			return sources, &sources[i]
+		}
+	}
+	return sources, nil
+}
+
+// TestDiscoverGiteaSource verifies the releases API URL is derived from
+// codeberg.org and configured Gitea homepages and SRC_URIs, and that the
+// analyzer reads [0].tag_name from it.
+func TestDiscoverGiteaSource(t *testing.T) {
+	const codeberg = "https://codeberg.org/api/v1/repos/forgejo/forgejo/releases"
+	tests := []struct {
+		name  string
+		meta  EbuildMetadata
+		hosts []string
+		want  string
+	}{
+		{"codeberg homepage", EbuildMetadata{Homepage: "https://codeberg.org/forgejo/forgejo"}, nil, codeberg},
+		{"codeberg .git", EbuildMetadata{Homepage: "https://Codeberg.org/forgejo/forgejo.git/"}, nil, codeberg},
+		{
+			"codeberg src_uri archive",
+			EbuildMetadata{
+				Package:  "www-apps/forgejo",
+				Homepage: "https://forgejo.org",
+				SrcURI:   "https://codeberg.org/forgejo/${PN}/archive/v${PV}.tar.gz -> ${P}.tar.gz",
+			},
+			nil,
+			codeberg,
+		},
+		{
+			"configured host",
+			EbuildMetadata{Homepage: "https://git.example.org/team/tool"},
+			[]string{"https://git.example.org/"},
+			"https://git.example.org/api/v1/repos/team/tool/releases",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources, found := giteaSources(&tt.meta, WithGiteaHosts(tt.hosts...))
+			if found == nil || found.URL != tt.want {
+				t.Fatalf("gitea source = %+v, want %s", found, tt.want)
+			}
+			if found.Priority <= PriorityProvided || found.Priority >= PriorityHomepage {
+				t.Errorf("Priority = %d, want between provided and homepage", found.Priority)
+			}
+			for _, s := range sources {
+				if s.Type == "homepage" && s.URL == tt.meta.Homepage && tt.meta.SrcURI == "" {
+					t.Errorf("Gitea homepage also listed for scraping: %+v", s)
+				}
+			}
+			schema, err := (&Analyzer{}).analyzeContent(nil, []byte(`[{"tag_name": "v1.2.0"}]`), &tt.meta, "", found)
+			if err != nil || schema.Parser != "json" || schema.Path != "[0].tag_name" || schema.URL != tt.want {
+				t.Errorf("analyzeContent() = %+v, %v; want json at [0].tag_name", schema, err)
+			}
+		})
+	}
+}
+
+// TestDiscoverGiteaSource_NotARepo verifies URLs on unknown hosts, instance
+// routes and owner pages are not turned into a source.
+func TestDiscoverGiteaSource_NotARepo(t *testing.T) {
+	for _, meta := range []EbuildMetadata{
+		{Homepage: "https://git.example.org/team/tool"},
+		{Homepage: "https://codeberg.org/forgejo"},
+		{Homepage: "https://codeberg.org/explore/repos"},
+		{Homepage: "https://notcodeberg.org/forgejo/forgejo"},
+	} {
+		if _, found := giteaSources(&meta); found != nil {
+			t.Errorf("DiscoverDataSources(%+v) found %+v, want no gitea source", meta, found)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package autoupdate provides conditional GET support for the autoupdate
+// HTTP client.
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ConditionalStore holds the bodies RetryableHTTPClient replays for a
+// 304 Not Modified, with the ETag and Last-Modified validators that make the
+// next request for the same URL conditional. ContentCache implements it, so
+// the Checker's content cache backs its client's conditional requests and
+// survives across runs; the client's default store lives in memory.
+type ConditionalStore interface {
+	// Get returns the entry stored for url.
+	Get(url string) (ContentEntry, bool)
+	// Set stores entry for url. It is called with every full 200 answer to
+	// a GET, and again with the stored entry when a 304 confirms it.
+	Set(url string, entry ContentEntry) error
+}
+
+// EnableConditionalCache turns conditional GETs on or off. While on, every
+// GET whose URL has a stored entry carries its validators as If-None-Match
+// and If-Modified-Since, a 304 answer is returned as a 200 carrying the stored
+// body, and a 200 answer is stored once its body has been read to the end.
+// Requests that set either header themselves are left alone.
+//
+// Entries go to the store set with SetConditionalStore, or to an in-memory
+// one created here when none was set.
+func (c *RetryableHTTPClient) EnableConditionalCache(enabled bool) *RetryableHTTPClient {
+	if enabled && c.conditionalStore == nil {
+		c.conditionalStore = newMemoryConditionalStore()
+	}
+	c.conditionalEnabled = enabled
+	return c
+}
+
+// SetConditionalStore sets the store behind EnableConditionalCache. It does
+// not enable conditional GETs by itself.
+func (c *RetryableHTTPClient) SetConditionalStore(store ConditionalStore) {
+	c.conditionalStore = store
+}
+
+// conditionalStoreFor returns the store req goes through, or nil when req is
+// sent as is.
+func (c *RetryableHTTPClient) conditionalStoreFor(req *http.Request) ConditionalStore {
+	if !c.conditionalEnabled || c.conditionalStore == nil || req.Method != http.MethodGet {
+		return nil
+	}
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return nil
+	}
+	return c.conditionalStore
+}
+
+// doConditional is DoWithContext through store. The 304 is rewritten after
+// the retry loop, which passes it through untouched like any other 3xx.
+func (c *RetryableHTTPClient) doConditional(ctx context.Context, req *http.Request, store ConditionalStore) (*http.Response, error) {
+	key := req.URL.String()
+	entry, cached := store.Get(key)
+	conditional := cached && (entry.ETag != "" || entry.LastModified != "")
+	if conditional {
+		req = req.Clone(ctx)
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	switch {
+	case conditional && resp.StatusCode == http.StatusNotModified:
+		io.Copy(io.Discard, resp.Body) //nolint:errcheck // a 304 has no body worth reading
+		resp.Body.Close()              //nolint:errcheck
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.ContentLength = int64(len(entry.Body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(entry.Body)))
+		resp.Body = io.NopCloser(bytes.NewReader(entry.Body))
+		// Storing it again re-stamps the entry, so a store that ages its
+		// entries counts from this confirmation.
+		if err := store.Set(key, entry); err != nil {
+			warnLogf("failed to store the body of %s for conditional requests: %v", req.URL.Redacted(), err)
+		}
+	case resp.StatusCode == http.StatusOK:
+		resp.Body = &recordingBody{
+			ReadCloser: resp.Body,
+			url:        key,
+			redacted:   req.URL.Redacted(),
+			store:      store,
+			etag:       resp.Header.Get("ETag"),
+			modified:   resp.Header.Get("Last-Modified"),
+			limit:      c.config.maxBodySize(),
+		}
+	}
+	return resp, nil
+}
+
+// recordingBody copies a 200 body as it is read and stores it when the read
+// reaches the end. A body abandoned early, or larger than limit (the client's
+// RetryConfig.MaxBodySize), is never stored.
+type recordingBody struct {
+	io.ReadCloser
+	url      string
+	redacted string
+	store    ConditionalStore
+	etag     string
+	modified string
+	limit    int64
+	buf      bytes.Buffer
+	overflow bool
+	done     bool
+}
+
+// Read reads from the response body, recording what it returns.
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.overflow {
+		if int64(b.buf.Len()+n) > b.limit {
+			b.overflow = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !b.overflow && !b.done {
+		b.done = true
+		entry := ContentEntry{Body: b.buf.Bytes(), ETag: b.etag, LastModified: b.modified}
+		if serr := b.store.Set(b.url, entry); serr != nil {
+			warnLogf("failed to store the body of %s for conditional requests: %v", b.redacted, serr)
+		}
+	}
+	return n, err
+}
+
+// memoryConditionalStore is the ConditionalStore EnableConditionalCache
+// falls back to. It keeps only entries with a validator, the only ones a
+// conditional request can use, for the lifetime of the client.
+type memoryConditionalStore struct {
+	mu      sync.RWMutex
+	entries map[string]ContentEntry
+}
+
+// newMemoryConditionalStore returns an empty memoryConditionalStore.
+func newMemoryConditionalStore() *memoryConditionalStore {
+	return &memoryConditionalStore{entries: make(map[string]ContentEntry)}
+}
+
+// Get returns the entry stored for url.
+func (s *memoryConditionalStore) Get(url string) (ContentEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[url]
+	return entry, ok
+}
+
+// Set stores entry for url, or drops what was stored when entry has no
+// validator.
+func (s *memoryConditionalStore) Set(url string, entry ContentEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry.ETag == "" && entry.LastModified == "" {
+		delete(s.entries, url)
+		return nil
+	}
+	s.entries[url] = entry
+	return nil
+}
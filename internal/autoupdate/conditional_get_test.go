@@ -0,0 +1,143 @@
+package autoupdate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/httputil"
+)
+
+// conditionalTestClient returns a client without retries for tests.
+func conditionalTestClient() *RetryableHTTPClient {
+	return NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})
+}
+
+// getBody GETs url with client and returns the status and body.
+func getBody(t *testing.T, client *RetryableHTTPClient, url string, headers map[string]string) (int, string) {
+	t.Helper()
+	resp, err := client.GetWithHeadersContext(context.Background(), url, headers)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading %s error = %v", url, err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// TestEnableConditionalCache verifies the second GET of a URL is conditional
+// and its 304 is returned as a 200 carrying the first body.
+func TestEnableConditionalCache(t *testing.T) {
+	srv := newETagServer(t, "version 1.2.3")
+	client := conditionalTestClient().EnableConditionalCache(true)
+
+	for i := 0; i < 3; i++ {
+		status, body := getBody(t, client, srv.URL, nil)
+		if status != http.StatusOK || body != "version 1.2.3" {
+			t.Errorf("GET #%d = %d %q, want 200 with the stored body", i+1, status, body)
+		}
+	}
+	if srv.full.Load() != 1 || srv.notModified.Load() != 2 {
+		t.Errorf("full answers = %d, 304s = %d; want 1 and 2", srv.full.Load(), srv.notModified.Load())
+	}
+}
+
+// TestEnableConditionalCache_Off verifies no validator is sent while the
+// cache is off, including after it has been turned off again.
+func TestEnableConditionalCache_Off(t *testing.T) {
+	srv := newETagServer(t, "body")
+
+	client := conditionalTestClient()
+	getBody(t, client, srv.URL, nil)
+	getBody(t, client, srv.URL, nil)
+
+	client.EnableConditionalCache(true)
+	getBody(t, client, srv.URL, nil)
+	client.EnableConditionalCache(false)
+	getBody(t, client, srv.URL, nil)
+
+	if srv.full.Load() != 4 || srv.notModified.Load() != 0 {
+		t.Errorf("full answers = %d, 304s = %d; want 4 and 0", srv.full.Load(), srv.notModified.Load())
+	}
+}
+
+// TestEnableConditionalCache_CallerValidators verifies a request that sets
+// its own If-None-Match gets the server's answer untouched.
+func TestEnableConditionalCache_CallerValidators(t *testing.T) {
+	srv := newETagServer(t, "body")
+	client := conditionalTestClient().EnableConditionalCache(true)
+	getBody(t, client, srv.URL, nil)
+
+	status, body := getBody(t, client, srv.URL, map[string]string{"If-None-Match": `"v2"`})
+	if status != http.StatusNotModified || body != "" {
+		t.Errorf("GET with If-None-Match = %d %q, want the bare 304", status, body)
+	}
+}
+
+// TestEnableConditionalCache_MaxBodySize verifies bodies are recorded up to
+// the client's RetryConfig.MaxBodySize rather than the package default.
+func TestEnableConditionalCache_MaxBodySize(t *testing.T) {
+	body := strings.Repeat("x", int(httputil.MaxBodyBytes)+1)
+	srv := newETagServer(t, body)
+	client := NewRetryableHTTPClientWithConfig(RetryConfig{
+		MaxRetries:  0,
+		Timeout:     5 * time.Second,
+		MaxBodySize: httputil.MaxBodyBytes + 1024,
+	}).EnableConditionalCache(true)
+
+	for i := 0; i < 2; i++ {
+		if status, got := getBody(t, client, srv.URL, nil); status != http.StatusOK || len(got) != len(body) {
+			t.Errorf("GET #%d = %d with %d bytes, want 200 with %d", i+1, status, len(got), len(body))
+		}
+	}
+	if srv.full.Load() != 1 || srv.notModified.Load() != 1 {
+		t.Errorf("full answers = %d, 304s = %d; want 1 and 1", srv.full.Load(), srv.notModified.Load())
+	}
+}
+
+// TestSetConditionalStore verifies a ContentCache backs the client: a body
+// stored by one client makes a fresh client's first request conditional,
+// and a body abandoned before its end is not stored.
+func TestSetConditionalStore(t *testing.T) {
+	srv := newETagServer(t, "version 2.0")
+	store, err := NewContentCache(t.TempDir(), WithContentStore(NewMemoryStore()))
+	if err != nil {
+		t.Fatalf("NewContentCache() error = %v", err)
+	}
+
+	abandoning := conditionalTestClient()
+	abandoning.SetConditionalStore(store)
+	abandoning.EnableConditionalCache(true)
+	resp, err := abandoning.GetWithContext(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+	if store.Len() != 0 {
+		t.Fatalf("store holds %d entries after an unread body, want 0", store.Len())
+	}
+
+	first := conditionalTestClient()
+	first.SetConditionalStore(store)
+	first.EnableConditionalCache(true)
+	getBody(t, first, srv.URL, nil)
+	if entry, ok := store.Get(srv.URL); !ok || entry.ETag != `"v2"` {
+		t.Fatalf("stored entry = %+v, %v; want the body with its ETag", entry, ok)
+	}
+
+	second := conditionalTestClient()
+	second.SetConditionalStore(store)
+	second.EnableConditionalCache(true)
+	if status, body := getBody(t, second, srv.URL, nil); status != http.StatusOK || body != "version 2.0" {
+		t.Errorf("GET through the shared store = %d %q, want 200 with the stored body", status, body)
+	}
+	if srv.full.Load() != 2 || srv.notModified.Load() != 1 {
+		t.Errorf("full answers = %d, 304s = %d; want 2 and 1", srv.full.Load(), srv.notModified.Load())
+	}
+}
@@ -17,7 +17,7 @@ var (
 	// ErrPackagesConfigNotFound is returned when packages.toml is not found in the overlay
 	ErrPackagesConfigNotFound = errors.New("packages.toml not found in overlay")
 	// ErrInvalidParserType is returned when an invalid parser type is specified
-	ErrInvalidParserType = errors.New("invalid parser type: must be 'json', 'regex', 'html', or 'script'")
+	ErrInvalidParserType = errors.New("invalid parser type: must be 'json', 'yaml', 'regex', 'html', 'xml', 'plist', 'gnu-ftp', 'helm', 'github-milestone', 'graphql', 'gitea', 'json-feed', 'dcf', 'readme-txt', 'deb', 'rpm', 'git-tags', 'fileindex', 'header', or 'script'")
 	// ErrMissingURL is returned when a package configuration is missing the required URL field
 	ErrMissingURL = errors.New("missing required field: url")
 	// ErrMissingParser is returned when a package configuration is missing the required parser field
@@ -28,8 +28,14 @@ var (
 	ErrMissingPattern = errors.New("missing required field: pattern (required for regex parser)")
 	// ErrMissingSelectorOrXPath is returned when an HTML parser is missing both selector and xpath fields
 	ErrMissingSelectorOrXPath = errors.New("missing required field: selector or xpath (required for html parser)")
+	// ErrMissingXPath is returned when an XML parser is missing the xpath field
+	ErrMissingXPath = errors.New("missing required field: xpath (required for xml parser)")
 	// ErrMissingScript is returned when a script parser is missing the required script field
 	ErrMissingScript = errors.New("missing required field: script (required for script parser)")
+	// ErrMissingQuery is returned when a graphql parser is missing the required query field
+	ErrMissingQuery = errors.New("missing required field: query (required for graphql parser)")
+	// ErrMissingFilePattern is returned when a fileindex parser is missing the required file_pattern field
+	ErrMissingFilePattern = errors.New("missing required field: file_pattern (required for fileindex parser)")
 	// ErrInvalidSelect is returned when the select field has an unsupported value
 	ErrInvalidSelect = errors.New("invalid select value: must be '', 'first', 'max', or 'last'")
 	// ErrInvalidType is returned when the type field has an unsupported value
@@ -59,12 +65,39 @@ type PackageConfig struct {
 	Hold bool `toml:"hold,omitempty"`
 	// URL is the primary URL to query for version information
 	URL string `toml:"url"`
-	// Parser specifies the parser type: "json", "regex", or "html"
+	// Parser specifies the parser type: "json", "yaml", "regex", "html", "xml", "plist",
+	// "gnu-ftp", "helm", "github-milestone", "graphql", "gitea", "readme-txt",
+	// "deb", "rpm", "git-tags", "fileindex" or "header"
 	Parser string `toml:"parser"`
-	// Path is the JSON path for extracting version (used with json parser)
+	// Path is the JSON path for extracting version (used with json and yaml
+	// parsers; may end with "| length", "| first", "| last" or "| max", see
+	// JSONParser), the top-level dict key to read (plist parser, default
+	// CFBundleShortVersionString), the tarball name (gnu-ftp parser,
+	// default the listing URL's last path segment), the chart name (helm
+	// parser), the JSON path within the response's "data" (graphql parser),
+	// the header read when Stable tag is missing or "trunk" (readme-txt
+	// parser), the binary package name (deb and rpm parsers), or the HTTP
+	// response header holding the version (header parser)
 	Path string `toml:"path,omitempty"`
-	// Pattern is the regex pattern with capture group (used with regex parser)
+	// Pattern is the regex pattern with capture group (used with regex parser,
+	// matched against milestone titles by the github-milestone parser,
+	// filtering tag names for the git-tags parser, and extracting the version
+	// from the header value for the header parser)
 	Pattern string `toml:"pattern,omitempty"`
+	// FilePattern matches the release file names of a directory listing, its
+	// capture group the version, e.g. `^foo-([0-9.]+)\.tar\.gz$` (fileindex
+	// parser), or the file names of the URLs an xml parser's XPath selects
+	FilePattern string `toml:"file_pattern,omitempty"`
+	// Query is the GraphQL document POSTed to URL (graphql parser)
+	Query string `toml:"query,omitempty"`
+	// Variables are sent with Query as its GraphQL variables (graphql parser)
+	Variables map[string]interface{} `toml:"variables,omitempty"`
+	// Gitea names the repository on the Gitea/Forgejo instance at URL
+	// (gitea parser)
+	Gitea *GiteaConfig `toml:"gitea,omitempty"`
+	// Feed locates the entries of a date-ordered JSON feed and their date and
+	// version (json-feed parser)
+	Feed *FeedConfig `toml:"feed,omitempty"`
 	// Binary indicates if this is a binary package (manifest-only testing)
 	Binary bool `toml:"binary,omitempty"`
 	// Type classifies the package as binary ("bin") or source-built
@@ -73,6 +106,9 @@ type PackageConfig struct {
 	// heuristic. Used for reporting and the --only filter; it does not change
 	// apply/compile behavior.
 	Type string `toml:"type,omitempty"`
+	// PreFetch, when set, is fetched before URL and its extracted value is
+	// substituted into URL as {{.PreFetch}} (see PreFetchConfig)
+	PreFetch *PreFetchConfig `toml:"prefetch,omitempty"`
 	// FallbackURL is an alternative URL to try if primary fails
 	FallbackURL string `toml:"fallback_url,omitempty"`
 	// FallbackParser is the parser type for the fallback URL
@@ -81,11 +117,31 @@ type PackageConfig struct {
 	FallbackPattern string `toml:"fallback_pattern,omitempty"`
 	// LLMPrompt is the prompt to use for LLM-based version extraction
 	LLMPrompt string `toml:"llm_prompt,omitempty"`
+	// LLM overrides the global LLM provider/model for this package's analysis
+	// and LLM version extraction. Nil uses the global provider.
+	LLM *PackageLLMConfig `toml:"llm,omitempty"`
+	// CrossValidate is a structured source an LLM-extracted version must agree
+	// with; see CrossValidateConfig and LLMMinConfidence.
+	CrossValidate *CrossValidateConfig `toml:"cross_validate,omitempty"`
+	// LLMMinConfidence is the confidence (0 to 1) an LLM-extracted version
+	// needs to be queued; below it the result is marked NeedsReview instead.
+	// A missed expect_format costs 0.5, disagreeing with cross_validate 0.5,
+	// and an unreachable cross_validate source 0.25. Nil means
+	// DefaultLLMMinConfidence (1: every configured check must pass); 0 queues
+	// any plausible version. Read via MinLLMConfidence.
+	LLMMinConfidence *float64 `toml:"llm_min_confidence,omitempty"`
+
+	// Match selects one element of a JSON array by a field value before Path is
+	// applied to it, e.g. match = { field = "name", equals = "stable" } picks
+	// the stable entry wherever it appears in the array. Set match.array when
+	// the array is nested rather than the document root. json parser only.
+	Match *ArrayMatch `toml:"match,omitempty"`
 
 	// New fields for HTML parser
 	// Selector is the CSS selector for extracting version (used with html parser)
 	Selector string `toml:"selector,omitempty"`
-	// XPath is the XPath expression for extracting version (used with html parser)
+	// XPath is the XPath expression for extracting version (used with html and
+	// xml parsers)
 	XPath string `toml:"xpath,omitempty"`
 
 	// New fields for authentication
@@ -102,6 +158,12 @@ type PackageConfig struct {
 	// (or pass --timeout) instead.
 	Timeout int `toml:"timeout,omitempty"`
 
+	// CacheTTL overrides how long (in seconds) a cached upstream version of
+	// THIS package is served before it is checked again, e.g. 604800 (a week)
+	// for a package that releases once a year or 900 for a nightly. Zero/absent
+	// means the global autoupdate.cache_ttl.
+	CacheTTL int `toml:"cache_ttl,omitempty"`
+
 	// Meta holds free-form key/value annotations for packages with special
 	// acquisition requirements (e.g. a purchased serial, a platform selector,
 	// a download endpoint). It is documentation only — the checker ignores it
@@ -110,7 +172,8 @@ type PackageConfig struct {
 	Meta map[string]string `toml:"meta,omitempty"`
 
 	// New fields for version history
-	// VersionsPath is the JSON path for extracting version list
+	// VersionsPath is the JSON path for extracting version list. A trailing
+	// function ("releases | max") reduces the list to that single version.
 	VersionsPath string `toml:"versions_path,omitempty"`
 	// VersionsSelector is the CSS selector for extracting version list
 	VersionsSelector string `toml:"versions_selector,omitempty"`
@@ -118,6 +181,8 @@ type PackageConfig struct {
 	// Transform applies ordered regex substitutions to the extracted version,
 	// e.g. [["-", "."]] turns "7.1.2-24" into "7.1.2.24". Each rule is
 	// [regex, repl]; repl follows regexp.ReplaceAllString semantics ($1 etc.).
+	// A one-element rule names a built-in instead: "strip-v", "strip-prefix",
+	// "underscore-to-dot" or "dash-to-dot" (see namedTransforms).
 	// Rules run in order, before selection and before the Gentoo comparison.
 	Transform [][]string `toml:"transform,omitempty"`
 	// Select chooses which match to return when several are present.
@@ -125,6 +190,25 @@ type PackageConfig struct {
 	// "last" = last match. Requires a parser that can extract a list
 	// (json/regex/html); ignored by the "script" parser.
 	Select string `toml:"select,omitempty"`
+	// VersionConstraint keeps the package on a version line, e.g. "~1.4"
+	// (>=1.4 <1.5) for an LTS branch, "^2.1" or explicit bounds
+	// ">=1.4, <1.5"; see parseVersionConstraint for the grammar. Every
+	// candidate is extracted (as with select) and the highest one satisfying
+	// the constraint is proposed; select = "last" picks the last satisfying
+	// one instead.
+	VersionConstraint string `toml:"version_constraint,omitempty"`
+	// StablePattern, when set, keeps only the candidate versions it matches,
+	// e.g. `^v?[0-9.]+$` to accept plain release tags only.
+	StablePattern string `toml:"stable_pattern,omitempty"`
+	// ExcludePattern drops the candidate versions it matches, e.g.
+	// `(?i)(alpha|beta|rc|pre)` to skip pre-releases. Both patterns are
+	// unanchored regexes tested against the raw extracted version, before
+	// transform. Either one turns on candidate selection like
+	// version_constraint: every candidate is extracted (from versions_path
+	// when set on a json package, so a releases array can be searched), the
+	// rejected ones dropped, and the highest remaining one proposed
+	// (select = "last" picks the last instead).
+	ExcludePattern string `toml:"exclude_pattern,omitempty"`
 	// Script is a JS expression/IIFE evaluated against the live DOM by the
 	// "script" parser; its string result is the version. Inline, or "@file.js"
 	// to load from .autoupdate/scripts/<file>.
@@ -172,6 +256,73 @@ type PackageConfig struct {
 	// body used for version detection, that yields the value for AuxVar. Set
 	// together with aux_var.
 	AuxPattern string `toml:"aux_pattern,omitempty"`
+	// PreferReleases makes a GitHub releases URL (".../repos/{owner}/{repo}/
+	// releases[/latest]") keep the release version while cross-checking it
+	// against the repository's plain tags, which can include one pushed
+	// without a release: a newer tag is flagged for review. Unset counts as
+	// true; false skips the cross-check and its second API request. Other
+	// URLs ignore it.
+	PreferReleases *bool `toml:"prefer_releases,omitempty"`
+
+	// ChecksumPath is the JSON path, in the SAME response used for version
+	// detection, to the checksum of the upstream release artifact (e.g.
+	// "assets[0].digest" for a GitHub release). When set and upstream reports
+	// the version already in the overlay, the checksum is compared with the
+	// package Manifest to detect a same-version re-release. The value may carry
+	// an "<algo>:" prefix ("sha512:…"), which overrides ChecksumAlgo.
+	ChecksumPath string `toml:"checksum_path,omitempty"`
+	// ChecksumAlgo names the Manifest hash the checksum is compared against:
+	// "SHA512" (default), "BLAKE2B", or any other hash the Manifest records.
+	ChecksumAlgo string `toml:"checksum_algo,omitempty"`
+
+	// SigningKey enables GPG verification of the upstream release at apply
+	// time: an ASCII-armored OpenPGP public key, given inline or as a file path
+	// (relative paths resolve against the overlay root, so a key committed as
+	// "metadata/keys/foo.asc" works; /usr/share/openpgp-keys/*.asc does too).
+	// The update only reaches StatusValidated when SignedURL's detached
+	// signature verifies against this key.
+	SigningKey string `toml:"signing_key,omitempty"`
+	// SignedURL is the release artifact whose signature is checked, with
+	// {version} substituted by the new version. Required with signing_key.
+	SignedURL string `toml:"signed_url,omitempty"`
+	// SignatureURL is the detached signature of SignedURL ({version}
+	// substituted). Empty tries SignedURL + ".sig", then SignedURL + ".asc".
+	SignatureURL string `toml:"signature_url,omitempty"`
+
+	// ExpectFormat is the regex every extracted upstream version must match in
+	// full (it is anchored implicitly), e.g. `[0-9]+\.[0-9]+\.[0-9]+`. A value
+	// that does not match fails the check with ErrUnexpectedVersionFormat and
+	// is neither cached nor queued, so a restructured upstream page whose
+	// selector now grabs a date or a heading is caught instead of stored.
+	ExpectFormat string `toml:"expect_format,omitempty"`
+
+	// Group names a package family that must be bumped in lockstep, as
+	// "category/glob" (e.g. "media-plugins/gst-*"), matched like the package
+	// pattern of `bentoo overlay rename`. When any member has an update, the
+	// members at the same version are queued as one coordinated pending entry
+	// and applied together or not at all. Typically only one member carries
+	// the upstream source; the others need no packages.toml entry.
+	Group string `toml:"group,omitempty"`
+
+	// StripANSI removes ANSI escape sequences (colors, cursor movement) from
+	// the fetched body before parsing, for "status" endpoints that serve
+	// terminal-colored text where "\x1b[32m1.2.3\x1b[0m" would otherwise
+	// defeat a regex anchored on the surrounding text.
+	StripANSI bool `toml:"strip_ansi,omitempty"`
+
+	// StripJSONP unwraps a JSONP body (`callback({...});`) before parsing.
+	// The json parser already does this on its own; the flag is for the other
+	// parsers reading JSON (graphql, json-feed, ...) or a regex that must not
+	// see the wrapper.
+	StripJSONP bool `toml:"strip_jsonp,omitempty"`
+
+	// SnapshotPolicy says what to do with a version in `git describe` form,
+	// "v1.2.3-10-gdeadbee" (10 commits past v1.2.3), as tag listings and
+	// version endpoints built from a checkout report it: "ignore" checks it
+	// as 1.2.3, "flag" does too and sets CheckResult.Snapshot, and "use"
+	// keeps the full string, ordered as 1.2.3_p10. Empty leaves such a
+	// version alone, where it is not comparable.
+	SnapshotPolicy string `toml:"snapshot_policy,omitempty"`
 }
 
 // IsEnabled reports whether the checker should process this package. An absent
@@ -182,6 +333,13 @@ func (c *PackageConfig) IsEnabled() bool {
 	return c.Enabled == nil || *c.Enabled
 }
 
+// PrefersReleases reports whether a GitHub release is cross-checked against
+// the repository's newer plain tags. An absent (nil) prefer_releases counts as
+// true.
+func (c *PackageConfig) PrefersReleases() bool {
+	return c.PreferReleases == nil || *c.PreferReleases
+}
+
 // IsHeld reports whether the maintainer has explicitly held the package out of
 // autoupdate (hold = true). A held package is skipped by CheckAll exactly like a
 // disabled one, but — unlike enabled — the overlay-driven status reconciliation
@@ -228,6 +386,7 @@ func LoadPackagesConfig(overlayPath string) (*PackagesConfig, error) {
 		Packages: make(map[string]PackageConfig),
 	}
 	for pkg, cfg := range fileConfig {
+		applyManifestDefaults(&cfg)
 		config.Packages[pkg] = cfg
 	}
 
@@ -406,13 +565,22 @@ func ValidatePackageConfig(pkg string, cfg *PackageConfig) error {
 	if cfg.Timeout < 0 {
 		return fmt.Errorf("package %s: timeout must be >= 0 seconds, got %d", pkg, cfg.Timeout)
 	}
+	if cfg.CacheTTL < 0 {
+		return fmt.Errorf("package %s: cache_ttl must be >= 0 seconds, got %d", pkg, cfg.CacheTTL)
+	}
 
 	// Validate parser type and required fields
+	if _, ok := lookupParser(cfg.Parser); !ok {
+		return fmt.Errorf("package %s: %w: got %q", pkg, ErrInvalidParserType, cfg.Parser)
+	}
 	switch cfg.Parser {
-	case "json":
+	case "json", "yaml":
 		if cfg.Path == "" {
 			return fmt.Errorf("package %s: %w", pkg, ErrMissingPath)
 		}
+		if err := validateJSONPathExpr(cfg.Path); err != nil {
+			return fmt.Errorf("package %s: path: %w", pkg, err)
+		}
 	case "regex":
 		if cfg.Pattern == "" {
 			return fmt.Errorf("package %s: %w", pkg, ErrMissingPattern)
@@ -421,12 +589,153 @@ func ValidatePackageConfig(pkg string, cfg *PackageConfig) error {
 		if cfg.Selector == "" && cfg.XPath == "" {
 			return fmt.Errorf("package %s: %w", pkg, ErrMissingSelectorOrXPath)
 		}
+	case "xml":
+		if cfg.XPath == "" {
+			return fmt.Errorf("package %s: %w", pkg, ErrMissingXPath)
+		}
+		if err := validateXPath(cfg.XPath); err != nil {
+			return fmt.Errorf("package %s: xpath: %w", pkg, err)
+		}
+		if cfg.FilePattern != "" {
+			if _, err := NewXMLFileParser(cfg.XPath, cfg.FilePattern); err != nil {
+				return fmt.Errorf("package %s: %w", pkg, err)
+			}
+		}
+	case "plist":
+		// Path is optional; an empty key reads DefaultPlistKey.
+	case "dcf":
+		// Path is optional; an empty field reads DefaultDCFField.
+	case "readme-txt":
+		// Path is optional; it names the header read when Stable tag is
+		// missing or "trunk".
+	case "gnu-ftp":
+		if _, err := NewGNUFTPParser(cfg.Path, cfg.URL); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	case "helm":
+		if cfg.Path == "" {
+			return fmt.Errorf("package %s: %w: helm parser needs the chart name", pkg, ErrMissingPath)
+		}
+	case "deb", "rpm":
+		if cfg.Path == "" {
+			return fmt.Errorf("package %s: %w: %s parser needs the package name", pkg, ErrMissingPath, cfg.Parser)
+		}
+	case "github-milestone":
+		if cfg.Pattern == "" {
+			return fmt.Errorf("package %s: %w", pkg, ErrMissingPattern)
+		}
+		if _, err := NewGitHubMilestoneParser(cfg.Pattern); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	case "graphql":
+		if cfg.Query == "" {
+			return fmt.Errorf("package %s: %w", pkg, ErrMissingQuery)
+		}
+		if cfg.Path == "" {
+			return fmt.Errorf("package %s: %w", pkg, ErrMissingPath)
+		}
+		if err := validateJSONPathExpr(cfg.Path); err != nil {
+			return fmt.Errorf("package %s: path: %w", pkg, err)
+		}
+	case "gitea":
+		if err := validateGitea(cfg.URL, cfg.Gitea); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	case "json-feed":
+		if err := validateFeed(cfg); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	case "git-tags":
+		if _, err := NewGitTagsParser(cfg.Pattern); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	case "fileindex":
+		if _, err := NewFileIndexParser(cfg.FilePattern); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	case "header":
+		if _, err := NewHeaderParser(cfg.Path, cfg.Pattern); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
 	case "script":
 		if cfg.Script == "" {
 			return fmt.Errorf("package %s: %w", pkg, ErrMissingScript)
 		}
-	default:
-		return fmt.Errorf("package %s: %w: got %q", pkg, ErrInvalidParserType, cfg.Parser)
+	}
+
+	// Validate the LLM override's provider name up front, so a typo surfaces
+	// at load time rather than as a warning on the first LLM call.
+	if cfg.LLM != nil {
+		switch cfg.LLM.Provider {
+		case "", "claude", "openai", "ollama", "gemini", "claude-code":
+		default:
+			return fmt.Errorf("package %s: llm: %w: %s", pkg, ErrLLMUnsupportedProvider, cfg.LLM.Provider)
+		}
+	}
+
+	// A re-release checksum is read from the JSON version response.
+	if cfg.ChecksumPath != "" && cfg.Parser != "json" {
+		return fmt.Errorf("package %s: checksum_path requires parser=\"json\"", pkg)
+	}
+	if cfg.ChecksumAlgo != "" && cfg.ChecksumPath == "" {
+		warnLogf("package %s: checksum_algo is set but checksum_path is empty; it will be ignored", pkg)
+	}
+
+	// Signature verification needs both the key and the artifact it signs.
+	if cfg.SigningKey != "" && cfg.SignedURL == "" {
+		return fmt.Errorf("package %s: signing_key requires signed_url", pkg)
+	}
+	if cfg.SigningKey == "" && (cfg.SignedURL != "" || cfg.SignatureURL != "") {
+		warnLogf("package %s: signed_url/signature_url are set but signing_key is empty; they will be ignored", pkg)
+	}
+
+	if cfg.Group != "" {
+		if _, err := groupSpec(cfg.Group); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+		if !groupIncludes(cfg.Group, pkg) {
+			return fmt.Errorf("package %s: %w: %q does not match the package itself", pkg, ErrInvalidGroup, cfg.Group)
+		}
+	}
+
+	if cfg.ExpectFormat != "" {
+		if _, err := compileExpectFormat(cfg.ExpectFormat); err != nil {
+			return fmt.Errorf("package %s: expect_format: %w", pkg, err)
+		}
+	}
+
+	if err := validateCrossValidate(pkg, cfg); err != nil {
+		return err
+	}
+
+	if _, err := parseVersionConstraint(cfg.VersionConstraint); err != nil {
+		return fmt.Errorf("package %s: %w", pkg, err)
+	}
+
+	if _, err := compileVersionFilter(cfg.StablePattern, cfg.ExcludePattern); err != nil {
+		return fmt.Errorf("package %s: %w", pkg, err)
+	}
+
+	// Validate the array match. It narrows a JSON document only, and a missing
+	// field would match nothing, so both are configuration errors.
+	if cfg.Match != nil {
+		if cfg.Parser != "json" {
+			return fmt.Errorf("package %s: match requires parser=\"json\", got %q", pkg, cfg.Parser)
+		}
+		if cfg.Match.Field == "" {
+			return fmt.Errorf("package %s: match requires a field", pkg)
+		}
+		if _, err := parseJSONPath(cfg.Match.Field); err != nil {
+			return fmt.Errorf("package %s: invalid match field %q: %w", pkg, cfg.Match.Field, err)
+		}
+		if cfg.Match.Array != "" {
+			if _, err := parseJSONPath(cfg.Match.Array); err != nil {
+				return fmt.Errorf("package %s: invalid match array %q: %w", pkg, cfg.Match.Array, err)
+			}
+		}
+		if cfg.Select != "" && cfg.Select != "first" {
+			warnLogf("package %s: select=%q is ignored when match is set (match already picks a single element)", pkg, cfg.Select)
+		}
 	}
 
 	// Validate the select field. An unrecognized value is almost certainly a
@@ -438,6 +747,10 @@ func ValidatePackageConfig(pkg string, cfg *PackageConfig) error {
 		return fmt.Errorf("package %s: %w: got %q", pkg, ErrInvalidSelect, cfg.Select)
 	}
 
+	if err := validateSnapshotPolicy(cfg.SnapshotPolicy); err != nil {
+		return fmt.Errorf("package %s: %w", pkg, err)
+	}
+
 	// Validate the type field. Like select, an unrecognized value is almost
 	// certainly a typo in packages.toml, so fail hard rather than silently
 	// auto-detecting and masking the mistake.
@@ -448,16 +761,13 @@ func ValidatePackageConfig(pkg string, cfg *PackageConfig) error {
 		return fmt.Errorf("package %s: %w: got %q", pkg, ErrInvalidType, cfg.Type)
 	}
 
-	// Validate transform rules. A malformed rule (wrong arity or uncompilable
-	// regex) is warned and ignored at apply time (applyTransforms does the same),
-	// so we warn here rather than fail — a bad rule must not block the whole run.
+	// Validate transform rules. A malformed rule (wrong arity, unknown name or
+	// uncompilable regex) is warned and ignored at apply time (applyTransforms
+	// does the same), so we warn here rather than fail — a bad rule must not
+	// block the whole run.
 	for i, r := range cfg.Transform {
-		if len(r) != 2 {
-			warnLogf("package %s: transform rule #%d has %d elements, want 2 ([regex, repl]); it will be ignored", pkg, i, len(r))
-			continue
-		}
-		if _, err := regexp.Compile(r[0]); err != nil {
-			warnLogf("package %s: transform rule #%d has bad regex %q (%v); it will be ignored", pkg, i, r[0], err)
+		if _, _, err := compileTransform(r); err != nil {
+			warnLogf("package %s: transform rule #%d: %v; it will be ignored", pkg, i, err)
 		}
 	}
 
@@ -523,17 +833,23 @@ func ValidatePackageConfig(pkg string, cfg *PackageConfig) error {
 		}
 	}
 
+	if cfg.PreFetch != nil {
+		if err := validatePreFetch(pkg, cfg); err != nil {
+			return err
+		}
+	}
+
 	// Validate fallback configuration if present
 	if cfg.FallbackURL != "" && cfg.FallbackParser != "" {
 		switch cfg.FallbackParser {
-		case "json":
-			// JSON fallback doesn't require pattern, uses Path from main config or FallbackPattern
+		case "json", "yaml":
+			// JSON and YAML fallbacks don't require pattern, they use Path from main config
 		case "regex":
 			if cfg.FallbackPattern == "" {
 				return fmt.Errorf("package %s: fallback_pattern required for regex fallback parser", pkg)
 			}
-		case "html":
-			// HTML fallback uses Selector or XPath from main config
+		case "html", "xml":
+			// HTML and XML fallbacks use Selector or XPath from main config
 		default:
 			return fmt.Errorf("package %s: invalid fallback_parser type: %q", pkg, cfg.FallbackParser)
 		}
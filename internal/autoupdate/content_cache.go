@@ -0,0 +1,376 @@
+// Package autoupdate provides the content cache behind conditional requests
+// and Prefetch for ebuild autoupdate.
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// contentCacheFileName is the content cache file in the config directory.
+const contentCacheFileName = "content_cache.json"
+
+// DefaultContentMaxBody is the largest body the content cache stores. A
+// larger one is still returned to the caller, just never kept.
+const DefaultContentMaxBody = 1 << 20
+
+// DefaultContentMaxAge is how old a stored body may be and still stand in
+// for a source that fails to answer.
+const DefaultContentMaxAge = 24 * time.Hour
+
+// ContentEntry is a fetched body with the validators that make refetching it
+// conditional.
+type ContentEntry struct {
+	// Body is the response body as fetched
+	Body []byte `json:"body"`
+	// ETag is the response's ETag header, sent back as If-None-Match
+	ETag string `json:"etag,omitempty"`
+	// LastModified is the response's Last-Modified header, sent back as
+	// If-Modified-Since
+	LastModified string `json:"last_modified,omitempty"`
+	// FetchedAt is when the body was last fetched or confirmed unchanged
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// contentCacheFile represents the JSON structure stored on disk
+type contentCacheFile struct {
+	Entries map[string]ContentEntry `json:"entries"`
+}
+
+// ContentCache stores fetched bodies keyed by URL. Unlike Cache, which holds
+// a package's parsed version, it holds what the source served, so the next
+// request for the URL can be conditional and a 304 Not Modified answered
+// from it without a full transfer.
+type ContentCache struct {
+	// entries holds the stored bodies, keyed by URL
+	entries map[string]ContentEntry
+	// store persists the cache; a FileStore on the config directory unless
+	// set with WithContentStore
+	store Store
+	// mu protects concurrent access to entries
+	mu sync.RWMutex
+	// nowFunc allows injecting time for testing
+	nowFunc func() time.Time
+	// maxBody is the largest body Set keeps
+	maxBody int
+	// maxAge is how old an entry may be for Stale to return it
+	maxAge time.Duration
+	// readOnly keeps every change in memory and skips the save. Set by
+	// NewChecker under WithReadOnly.
+	readOnly bool
+	// dirty is set by a change Flush has not saved yet
+	dirty bool
+}
+
+// ContentCacheOption is a functional option for configuring ContentCache
+type ContentCacheOption func(*ContentCache)
+
+// WithContentNowFunc sets a custom time function for testing
+func WithContentNowFunc(fn func() time.Time) ContentCacheOption {
+	return func(c *ContentCache) {
+		c.nowFunc = fn
+	}
+}
+
+// WithContentMaxAge sets how old a stored body may be to stand in for a
+// failed fetch. A non-positive age keeps DefaultContentMaxAge.
+func WithContentMaxAge(d time.Duration) ContentCacheOption {
+	return func(c *ContentCache) {
+		if d > 0 {
+			c.maxAge = d
+		}
+	}
+}
+
+// WithContentStore persists the content cache in store instead of
+// content_cache.json in the config directory.
+func WithContentStore(store Store) ContentCacheOption {
+	return func(c *ContentCache) {
+		c.store = store
+	}
+}
+
+// NewContentCache creates or loads the content cache in configDir. A missing
+// or corrupted file starts an empty cache, which the next Flush overwrites.
+func NewContentCache(configDir string, opts ...ContentCacheOption) (*ContentCache, error) {
+	cache := &ContentCache{
+		entries: make(map[string]ContentEntry),
+		nowFunc: time.Now,
+		maxBody: DefaultContentMaxBody,
+		maxAge:  DefaultContentMaxAge,
+	}
+	for _, opt := range opts {
+		opt(cache)
+	}
+
+	if cache.store == nil {
+		if err := os.MkdirAll(configDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		cache.store = NewFileStore(configDir)
+	}
+
+	data, err := cache.store.Load(contentCacheStoreName)
+	if err == nil {
+		var cf contentCacheFile
+		if json.Unmarshal(data, &cf) == nil && cf.Entries != nil {
+			cache.entries = cf.Entries
+		}
+	}
+
+	return cache, nil
+}
+
+// HasContentCache reports whether configDir holds a content cache, that is,
+// whether Prefetch or a check with WithContentCache has run there.
+func HasContentCache(configDir string) bool {
+	_, err := os.Stat(filepath.Join(configDir, contentCacheFileName))
+	return err == nil
+}
+
+// Get returns the entry stored for url.
+func (c *ContentCache) Get(url string) (ContentEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[url]
+	return entry, ok
+}
+
+// Stale returns the entry stored for url when it is younger than the
+// cache's maximum age, for use when the source itself cannot be reached.
+func (c *ContentCache) Stale(url string) (ContentEntry, bool) {
+	entry, ok := c.Get(url)
+	if !ok || c.nowFunc().Sub(entry.FetchedAt) >= c.maxAge {
+		return ContentEntry{}, false
+	}
+	return entry, true
+}
+
+// Set stores entry for url, stamped with the current time. An entry without
+// a body, or with one over the size limit, removes what was stored instead:
+// it could not answer a 304.
+//
+// The change is kept in memory until Flush. Set runs for every response a
+// check reads, and the file holds up to a megabyte per URL, so writing it
+// each time would make a run's I/O grow with the square of its sources.
+func (c *ContentCache) Set(url string, entry ContentEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(entry.Body) == 0 || len(entry.Body) > c.maxBody {
+		if _, ok := c.entries[url]; ok {
+			delete(c.entries, url)
+			c.dirty = true
+		}
+		return nil
+	}
+	entry.FetchedAt = c.nowFunc()
+	c.entries[url] = entry
+	c.dirty = true
+	return nil
+}
+
+// Flush saves the changes made since the last Flush, if any. The Checker
+// flushes once at the end of CheckPackage, CheckAll and Prefetch.
+func (c *ContentCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	if err := c.saveUnsafe(); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (c *ContentCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// saveUnsafe persists the cache to its store without locking.
+// Caller must hold the write lock.
+func (c *ContentCache) saveUnsafe() error {
+	if c.readOnly {
+		return nil
+	}
+
+	data, err := json.Marshal(contentCacheFile{Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("failed to marshal content cache: %w", err)
+	}
+
+	if err := c.store.Save(contentCacheStoreName, data); err != nil {
+		return fmt.Errorf("failed to save content cache: %w", err)
+	}
+	return nil
+}
+
+// WithContentCache makes the Checker keep every body it fetches, with its
+// ETag and Last-Modified validators, in content_cache.json in the config
+// directory. Each later request for the same URL is then conditional: a
+// source that answers 304 Not Modified costs no transfer, and the stored
+// body is parsed as if it had been served again. When a source fails to
+// answer at all, a body fetched within DefaultContentMaxAge is used instead,
+// with a warning. See Prefetch for warming the cache ahead of a check.
+func WithContentCache(enabled bool) CheckerOption {
+	return func(c *Checker) error {
+		c.contentCacheEnabled = enabled
+		return nil
+	}
+}
+
+// useContentCache makes contentCache the Checker's content cache and the
+// store behind its HTTP client's conditional GETs.
+func (c *Checker) useContentCache(contentCache *ContentCache) {
+	c.contentCache = contentCache
+	c.httpClient.SetConditionalStore(contentCache)
+	c.httpClient.EnableConditionalCache(true)
+}
+
+// staleContent answers a failed fetch of rawURL from the content cache: a
+// body fetched within its maximum age stands in for the source, with a
+// warning. Otherwise, or when the run was cancelled and must stop rather than
+// carry on with old bodies, fetchErr is returned.
+func (c *Checker) staleContent(rawURL string, fetchErr error) ([]byte, error) {
+	if c.ctx.Err() != nil {
+		return nil, fetchErr
+	}
+	stale, ok := c.contentCache.Stale(rawURL)
+	if !ok {
+		return nil, fetchErr
+	}
+	warnLogf("fetching %s failed (%v); using the body fetched %s ago",
+		rawURL, fetchErr, c.contentCache.nowFunc().Sub(stale.FetchedAt).Round(time.Second))
+	return stale.Body, nil
+}
+
+// Prefetch fetches the primary source of every package a CheckAll would
+// check, without parsing or comparing anything, and stores each body in the
+// content cache with its current validators. Run during off-peak hours, it
+// leaves the following check with conditional requests that mostly come back
+// 304, and with a recent body for any source that is down by then.
+//
+// The Checker's content cache is opened here when WithContentCache was not
+// given. The returned Items are the prefetched package names, sorted, and a
+// source that could not be fetched is recorded in Failures. Script and
+// GraphQL packages are skipped: a script runs in a browser and a GraphQL
+// query is a POST, neither of which a conditional GET replays.
+//
+// Packages are fetched concurrently under the same limit, rate limiter and
+// cancellation as CheckAll. The version cache, the pending list and the
+// check history are left untouched.
+func (c *Checker) Prefetch() (BatchResult[string], error) {
+	if c.contentCache == nil {
+		contentCache, err := NewContentCache(c.stateDir, c.contentCacheOptions()...)
+		if err != nil {
+			return BatchResult[string]{}, fmt.Errorf("failed to initialize content cache: %w", err)
+		}
+		contentCache.readOnly = c.readOnly
+		c.useContentCache(contentCache)
+	}
+
+	pkgs := c.selectPackages(func(pkg string) bool {
+		parser := c.config.Packages[pkg].Parser
+		return parser != "script" && parser != "graphql" && parser != "git-tags" && parser != "header"
+	})
+	var (
+		sem      = make(chan struct{}, c.concurrency)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		fetched  = make([]string, 0, len(pkgs))
+		failures = make(map[string]error)
+		progress atomic.Uint64
+		total    = uint64(len(pkgs))
+	)
+	fail := func(name string, err error) {
+		mu.Lock()
+		failures[name] = err
+		mu.Unlock()
+	}
+
+	for name, pkg := range pkgs {
+		if err := c.ctx.Err(); err != nil {
+			fail(name, err)
+			continue
+		}
+		select {
+		case <-c.ctx.Done():
+			fail(name, c.ctx.Err())
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(n string, p PackageConfig) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				if r := recover(); r != nil {
+					fail(n, fmt.Errorf("panic: %v", r))
+				}
+			}()
+
+			if err := c.prefetchPackage(n, &p); err != nil {
+				fail(n, err)
+			} else {
+				mu.Lock()
+				fetched = append(fetched, n)
+				mu.Unlock()
+			}
+
+			if c.progressCallback != nil {
+				c.progressCallback(progress.Add(1), total)
+			}
+		}(name, pkg)
+	}
+	wg.Wait()
+	c.flushContentCache()
+
+	sort.Strings(fetched)
+	return BatchResult[string]{Items: fetched, Failures: failures}, nil
+}
+
+// flushContentCache saves the content cache's pending changes. Like a failed
+// version cache write, a failure only logs: the bodies are an optimization.
+func (c *Checker) flushContentCache() {
+	if c.contentCache == nil {
+		return
+	}
+	if err := c.contentCache.Flush(); err != nil {
+		warnLogf("%v", err)
+	}
+}
+
+// prefetchPackage fetches pkg's primary source into the content cache,
+// resolving a two-stage source and rewriting the URL the same way
+// fetchAndParse does, so the check requests exactly the URL stored.
+func (c *Checker) prefetchPackage(pkg string, cfg *PackageConfig) error {
+	rawURL := cfg.URL
+	if cfg.PreFetch != nil {
+		resolved, err := c.resolvePreFetch(pkg, cfg)
+		if err != nil {
+			return err
+		}
+		rawURL = resolved
+	}
+	rawURL, headers, err := c.sourceURL(pkg, rawURL, cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := c.fetchContent(rawURL, headers, c.operationTimeout(cfg)); err != nil {
+		return &FetchError{Package: pkg, URL: rawURL, Err: err}
+	}
+	return nil
+}
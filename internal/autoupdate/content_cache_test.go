@@ -0,0 +1,255 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// etagServer serves body with an ETag, answering 304 to a matching
+// If-None-Match. It counts full (200) responses and 304s separately.
+type etagServer struct {
+	*httptest.Server
+	full, notModified atomic.Int32
+}
+
+func newETagServer(t *testing.T, body string) *etagServer {
+	t.Helper()
+	s := &etagServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v2"` {
+			s.notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.full.Add(1)
+		w.Header().Set("ETag", `"v2"`)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// TestPrefetch_CheckUsesConditionalRequests verifies a check after Prefetch
+// sends conditional requests, parses the stored body on 304 and makes no
+// second full fetch, including from a new Checker reading the cache file.
+func TestPrefetch_CheckUsesConditionalRequests(t *testing.T) {
+	srv := newETagServer(t, `{"version": "2.0.0"}`)
+	overlay, _ := writePackagesTOML(t, `["app-misc/foo"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "version"
+
+["app-misc/scripted"]
+url = "`+srv.URL+`"
+parser = "script"
+script = "return '1.0'"
+`)
+	createTestEbuild(t, overlay, "app-misc/foo", "1.0.0")
+	configDir := t.TempDir()
+
+	prefetcher, err := NewChecker(overlay,
+		WithConfigDir(configDir),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	batch, err := prefetcher.Prefetch()
+	if err != nil {
+		t.Fatalf("Prefetch: %v", err)
+	}
+	if len(batch.Items) != 1 || batch.Items[0] != "app-misc/foo" || batch.HasFailures() {
+		t.Fatalf("Prefetch = %v (failures %v), want [app-misc/foo]", batch.Items, batch.Failures)
+	}
+	if prefetcher.cache.Len() != 0 {
+		t.Errorf("Prefetch wrote %d version cache entries, want 0", prefetcher.cache.Len())
+	}
+	if !HasContentCache(configDir) {
+		t.Fatal("Prefetch did not write the content cache")
+	}
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(configDir),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithContentCache(true),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		result, err := checker.CheckPackage("app-misc/foo", true)
+		if err != nil {
+			t.Fatalf("CheckPackage: %v", err)
+		}
+		if !result.HasUpdate || result.UpstreamVersion != "2.0.0" {
+			t.Errorf("CheckPackage = %+v, want update to 2.0.0", result)
+		}
+	}
+	if got := srv.full.Load(); got != 1 {
+		t.Errorf("full fetches = %d, want 1 (the prefetch only)", got)
+	}
+	if got := srv.notModified.Load(); got != 2 {
+		t.Errorf("304 responses = %d, want 2", got)
+	}
+}
+
+// TestContentCache_StaleFallback verifies a failing source is answered from
+// a recent stored body, and not from one past the maximum age.
+func TestContentCache_StaleFallback(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version": "2.0.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, _ := writePackagesTOML(t, `["app-misc/foo"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "version"
+`)
+	createTestEbuild(t, overlay, "app-misc/foo", "1.0.0")
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	if batch, err := checker.Prefetch(); err != nil || batch.HasFailures() {
+		t.Fatalf("Prefetch = %v, %v", batch.Failures, err)
+	}
+
+	down.Store(true)
+	result, err := checker.CheckPackage("app-misc/foo", true)
+	if err != nil || result.UpstreamVersion != "2.0.0" {
+		t.Fatalf("CheckPackage with the source down = %+v, %v; want 2.0.0 from the stored body", result, err)
+	}
+
+	checker.contentCache.nowFunc = func() time.Time { return time.Now().Add(DefaultContentMaxAge) }
+	var fetchErr *FetchError
+	if _, err := checker.CheckPackage("app-misc/foo", true); !errors.As(err, &fetchErr) {
+		t.Errorf("CheckPackage past the maximum age error = %v, want a *FetchError", err)
+	}
+}
+
+// TestContentCache_Set covers the size limit and the read-only mode.
+func TestContentCache_Set(t *testing.T) {
+	cache, err := NewContentCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewContentCache: %v", err)
+	}
+	cache.maxBody = 4
+	if err := cache.Set("https://example.com/a", ContentEntry{Body: []byte("abc"), ETag: `"1"`}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Set("https://example.com/a", ContentEntry{Body: []byte("abcdef")}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Error("an oversized body left the previous entry in place")
+	}
+
+	readOnlyDir := t.TempDir()
+	readOnly, err := NewContentCache(readOnlyDir)
+	if err != nil {
+		t.Fatalf("NewContentCache: %v", err)
+	}
+	readOnly.readOnly = true
+	if err := readOnly.Set("https://example.com/a", ContentEntry{Body: []byte("abc")}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := readOnly.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if readOnly.Len() != 1 || HasContentCache(readOnlyDir) {
+		t.Error("read-only Set should keep the entry in memory only")
+	}
+}
+
+// countingStore is a MemoryStore that counts the saves of each document.
+type countingStore struct {
+	*MemoryStore
+	mu    sync.Mutex
+	saves map[string]int
+}
+
+func (s *countingStore) Save(name string, data []byte) error {
+	s.mu.Lock()
+	s.saves[name]++
+	s.mu.Unlock()
+	return s.MemoryStore.Save(name, data)
+}
+
+// TestContentCache_FlushSavesOnce verifies Set only changes memory, Flush
+// saves what changed once, and a batch check of several sources writes the
+// content cache a single time.
+func TestContentCache_FlushSavesOnce(t *testing.T) {
+	store := &countingStore{MemoryStore: NewMemoryStore(), saves: map[string]int{}}
+	cache, err := NewContentCache(t.TempDir(), WithContentStore(store))
+	if err != nil {
+		t.Fatalf("NewContentCache: %v", err)
+	}
+	for _, u := range []string{"https://example.com/a", "https://example.com/b"} {
+		if err := cache.Set(u, ContentEntry{Body: []byte("abc")}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if n := store.saves[contentCacheStoreName]; n != 0 {
+		t.Fatalf("Set saved %d times, want 0 before Flush", n)
+	}
+	for i := 0; i < 2; i++ {
+		if err := cache.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	}
+	if n := store.saves[contentCacheStoreName]; n != 1 {
+		t.Errorf("two Flushes saved %d times, want 1", n)
+	}
+	if reopened, _ := NewContentCache(t.TempDir(), WithContentStore(store)); reopened.Len() != 2 {
+		t.Errorf("reopened cache has %d entries, want 2", reopened.Len())
+	}
+
+	srv := newETagServer(t, `{"version": "2.0.0"}`)
+	var toml strings.Builder
+	for _, name := range []string{"one", "two", "three"} {
+		toml.WriteString(`["app-misc/` + name + `"]
+url = "` + srv.URL + `/` + name + `"
+parser = "json"
+path = "version"
+
+`)
+	}
+	overlay, _ := writePackagesTOML(t, toml.String())
+	for _, name := range []string{"one", "two", "three"} {
+		createTestEbuild(t, overlay, "app-misc/"+name, "1.0.0")
+	}
+	batchStore := &countingStore{MemoryStore: NewMemoryStore(), saves: map[string]int{}}
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithStore(batchStore),
+		WithContentCache(true),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	if batch := checker.CheckAll(true); batch.HasFailures() || len(batch.Items) != 3 {
+		t.Fatalf("CheckAll = %v (failures %v), want 3 results", batch.Items, batch.Failures)
+	}
+	if n := batchStore.saves[contentCacheStoreName]; n != 1 {
+		t.Errorf("CheckAll saved the content cache %d times, want 1", n)
+	}
+	if checker.contentCache.Len() != 3 {
+		t.Errorf("content cache holds %d bodies, want 3", checker.contentCache.Len())
+	}
+}
@@ -0,0 +1,102 @@
+// Package autoupdate provides R/CRAN DESCRIPTION file support for ebuild autoupdate.
+package autoupdate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultDCFField is the field the dcf parser reads when path is empty.
+const DefaultDCFField = "Version"
+
+// PriorityCRAN is the priority for a CRAN package's DESCRIPTION file
+const PriorityCRAN = 20
+
+// Regular expressions for CRAN URL matching
+var (
+	// cranURLRegex matches CRAN package pages: .../web/packages/<name>/,
+	// .../package=<name> and the index.html forms
+	cranURLRegex = regexp.MustCompile(`cran\.(?:r-project\.org|rstudio\.com)/(?:web/packages/|package=)([A-Za-z][A-Za-z0-9.]*)`)
+	// cranSrcRegex matches CRAN source tarballs: mirror://cran/src/contrib/<name>_<version>.tar.gz
+	cranSrcRegex = regexp.MustCompile(`(?:mirror://cran|cran\.r-project\.org)/src/contrib/(?:Archive/[^/]+/)?([A-Za-z][A-Za-z0-9.]*)_`)
+)
+
+// DCFParser reads one field of a Debian-control-format document: an R
+// package's DESCRIPTION file (Version: 1.2.3) or a Debian control file.
+// Field names match case-insensitively; a value continued on indented lines
+// is joined, though a version is always a single line.
+type DCFParser struct {
+	// Field is the field name; empty reads DefaultDCFField
+	Field string
+}
+
+// Parse returns the value of the field in the first paragraph that has it.
+func (p *DCFParser) Parse(content []byte) (string, error) {
+	field := p.Field
+	if field == "" {
+		field = DefaultDCFField
+	}
+
+	var (
+		value   strings.Builder
+		inField bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if inField {
+			if line != "" && (line[0] == ' ' || line[0] == '\t') {
+				value.WriteByte(' ')
+				value.WriteString(strings.TrimSpace(line))
+				continue
+			}
+			break
+		}
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok || name == "" || name[0] == ' ' || name[0] == '\t' {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(name), field) {
+			value.WriteString(strings.TrimSpace(rest))
+			inField = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read control file: %w", err)
+	}
+	if !inField || value.Len() == 0 {
+		return "", fmt.Errorf("%w: no %s field", ErrNoVersionFound, field)
+	}
+	return value.String(), nil
+}
+
+// discoverCRANSource finds the DESCRIPTION file of a CRAN package from a CRAN
+// homepage, a CRAN SRC_URI, or, for a dev-R package, its name.
+func discoverCRANSource(meta *EbuildMetadata) *DataSource {
+	if matches := cranURLRegex.FindStringSubmatch(meta.Homepage); matches != nil {
+		return createCRANSource(strings.TrimSuffix(matches[1], "."))
+	}
+	if matches := cranSrcRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		if name := expandPN(matches[1], meta.Package); name != "" {
+			return createCRANSource(name)
+		}
+	}
+	if category, name, ok := strings.Cut(meta.Package, "/"); ok && category == "dev-R" && name != "" {
+		return createCRANSource(name)
+	}
+	return nil
+}
+
+// createCRANSource creates a DESCRIPTION file data source for the CRAN
+// package name.
+func createCRANSource(name string) *DataSource {
+	return &DataSource{
+		URL:         fmt.Sprintf("https://cran.r-project.org/web/packages/%s/DESCRIPTION", name),
+		Type:        "cran",
+		Priority:    PriorityCRAN,
+		ContentType: ContentTypeText,
+	}
+}
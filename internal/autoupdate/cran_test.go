@@ -0,0 +1,70 @@
+package autoupdate
+
+import (
+	"errors"
+	"testing"
+)
+
+// sampleDESCRIPTION is an R package DESCRIPTION file, with fields before and
+// after Version and a continued value.
+const sampleDESCRIPTION = `Package: ggplot2
+Title: Create Elegant Data Visualisations Using the Grammar of Graphics
+Depends: R (>= 3.5)
+Description: A system for 'declaratively' creating graphics, based on "The
+    Grammar of Graphics". Version: 9.9.9 in prose is not the field.
+Version: 3.5.1
+Imports: cli, glue, grDevices, grid, gtable (>= 0.1.1)
+Packaged: 2024-04-22 10:22:31 UTC; thomas
+`
+
+// TestDCFParser verifies the Version field is read, other Field: lines and
+// continuation text are ignored, and another field can be selected.
+func TestDCFParser(t *testing.T) {
+	got, err := (&DCFParser{}).Parse([]byte(sampleDESCRIPTION))
+	if err != nil || got != "3.5.1" {
+		t.Errorf("Parse() = %q, %v; want 3.5.1", got, err)
+	}
+
+	got, err = (&DCFParser{Field: "package"}).Parse([]byte(sampleDESCRIPTION))
+	if err != nil || got != "ggplot2" {
+		t.Errorf("Parse(Field=package) = %q, %v; want ggplot2", got, err)
+	}
+
+	_, err = (&DCFParser{}).Parse([]byte("Package: foo\nTitle: no version here\n"))
+	if !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse() without Version: error = %v, want %v", err, ErrNoVersionFound)
+	}
+}
+
+// TestDiscoverCRANSource verifies the DESCRIPTION URL is derived from a CRAN
+// homepage, a CRAN SRC_URI, or a dev-R package name.
+func TestDiscoverCRANSource(t *testing.T) {
+	const want = "https://cran.r-project.org/web/packages/data.table/DESCRIPTION"
+	tests := []struct {
+		name string
+		meta EbuildMetadata
+	}{
+		{"homepage", EbuildMetadata{Package: "sci-libs/dt", Homepage: "https://cran.r-project.org/web/packages/data.table/index.html"}},
+		{"package= homepage", EbuildMetadata{Package: "sci-libs/dt", Homepage: "https://cran.r-project.org/package=data.table"}},
+		{"src_uri", EbuildMetadata{Package: "sci-libs/dt", SrcURI: "mirror://cran/src/contrib/data.table_1.15.4.tar.gz"}},
+		{"dev-R category", EbuildMetadata{Package: "dev-R/data.table"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found *DataSource
+			for _, s := range DiscoverDataSources(&tt.meta, "") {
+				if s.Type == "cran" {
+					found = &s
+					break
+				}
+			}
+			if found == nil || found.URL != want {
+				t.Fatalf("cran source = %+v, want %s", found, want)
+			}
+			schema, err := (&Analyzer{}).analyzeContent(nil, []byte(sampleDESCRIPTION), &tt.meta, "", found)
+			if err != nil || schema.Parser != "dcf" {
+				t.Errorf("analyzeContent() = %+v, %v; want the dcf parser", schema, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package autoupdate
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"sort"
 	"strings"
@@ -14,12 +15,19 @@ import (
 type DataSource struct {
 	// URL is the endpoint to query for version information
 	URL string
-	// Type identifies the source type: "github", "pypi", "npm", "crates", "homepage", "provided"
+	// Type identifies the source type: "github", "gitlab", "gitea", "pypi", "npm",
+	// "crates", "rubygems", "hex", "go-module-proxy", "gnu", "cran", "wordpress",
+	// "sourceforge", "manifest" (a raw package.json/composer.json), "homepage",
+	// "provided"
 	Type string
 	// Priority determines the order of sources (lower is higher priority)
 	Priority int
 	// ContentType is the expected content type (e.g., "application/json", "text/html")
 	ContentType string
+	// Headers are sent with every request to URL, by the analyzer and, once
+	// carried into the suggested schema, by the checker. Nil for a source
+	// that needs none.
+	Headers map[string]string
 }
 
 // Priority constants for data source ordering
@@ -34,6 +42,12 @@ const (
 	PriorityNPM = 20
 	// PriorityCrates is the priority for crates.io API
 	PriorityCrates = 20
+	// PriorityRubyGems is the priority for the RubyGems API
+	PriorityRubyGems = 20
+	// PriorityHex is the priority for the Hex.pm API
+	PriorityHex = 20
+	// PriorityGNU is the priority for GNU ftp / Savannah directory listings
+	PriorityGNU = 20
 	// PriorityHomepage is the lowest priority for generic homepage scraping
 	PriorityHomepage = 100
 )
@@ -42,6 +56,8 @@ const (
 const (
 	ContentTypeJSON = "application/json"
 	ContentTypeHTML = "text/html"
+	ContentTypeText = "text/plain"
+	ContentTypeXML  = "application/xml"
 )
 
 // Regular expressions for URL pattern matching
@@ -52,23 +68,55 @@ var (
 	pypiURLRegex = regexp.MustCompile(`pypi\.(?:org|io|python\.org)/project/([^/\s"'#?]+)`)
 	// pypiFilesRegex matches PyPI files URLs (pythonhosted.org)
 	pypiFilesRegex = regexp.MustCompile(`files\.pythonhosted\.org/packages/.*?/([^/]+)-[\d]`)
-	// npmURLRegex matches npm package URLs
-	npmURLRegex = regexp.MustCompile(`(?:npmjs\.(?:org|com)|registry\.npmjs\.org)/(?:package/)?([^/\s"'#?]+)`)
-	// cratesURLRegex matches crates.io URLs
-	cratesURLRegex = regexp.MustCompile(`crates\.io/crates/([^/\s"'#?]+)`)
+	// pypiMirrorRegex matches Gentoo's PyPI mirror: mirror://pypi/<letter>/<name>/
+	pypiMirrorRegex = regexp.MustCompile(`mirror://pypi/[^/]+/([^/\s"'#?]+)/`)
+	// pypiNameSeparatorRegex matches the runs of separators PyPI folds into
+	// one "-" when normalizing a project name
+	pypiNameSeparatorRegex = regexp.MustCompile(`[-_.]+`)
+	// npmURLRegex matches npm package URLs: the package page, the registry
+	// document and the tarballs under it. A scoped name keeps its "@scope/"
+	// prefix; one already encoded as "@scope%2fname" is a single segment.
+	npmURLRegex = regexp.MustCompile(`(?:npmjs\.(?:org|com)|registry\.npmjs\.org)/(?:package/)?((?:@[^/\s"'#?]+/)?[^/\s"'#?]+)`)
+	// cratesURLRegex matches crates.io URLs: the crate page, the API (including
+	// the /download URL a SRC_URI points at) and static.crates.io archives
+	cratesURLRegex = regexp.MustCompile(`crates\.io/(?:api/v1/)?crates/([^/\s"'#?]+)`)
+	// rubygemsURLRegex matches RubyGems gem pages and API documents
+	rubygemsURLRegex = regexp.MustCompile(`rubygems\.org/(?:api/v1/)?gems/([A-Za-z0-9_.${}-]+)`)
+	// rubygemsFileRegex matches gem downloads, directly or via
+	// mirror://rubygems, capturing the file name without ".gem"
+	rubygemsFileRegex = regexp.MustCompile(`(?:rubygems\.org/downloads|mirror://rubygems)/([^/\s"'#?]+)\.gem`)
+	// hexURLRegex matches Hex.pm package pages and API documents
+	hexURLRegex = regexp.MustCompile(`hex\.pm/(?:api/)?packages/([A-Za-z0-9_]+)`)
+	// hexTarballRegex matches Hex.pm release tarballs, capturing the file
+	// name without ".tar"
+	hexTarballRegex = regexp.MustCompile(`repo\.hex\.pm/tarballs/([^/\s"'#?]+)\.tar`)
+	// archiveNameRegex captures the name in a "<name>-<version>" archive file
+	// name, the version starting at a digit or ${PV}
+	archiveNameRegex = regexp.MustCompile(`^(.+?)-(?:\$\{PV\}|\d)`)
+	// gnuHomepageRegex matches gnu.org project homepages
+	gnuHomepageRegex = regexp.MustCompile(`(?:www\.)?gnu\.org/software/([^/\s"'#?]+)`)
+	// gnuFTPURLRegex matches GNU ftp release directories, directly or via mirror://gnu
+	gnuFTPURLRegex = regexp.MustCompile(`(?:ftp\.gnu\.org/(?:pub/)?gnu|mirror://gnu)/([^/\s"'#?]+)/`)
+	// savannahURLRegex matches Savannah release directories, directly or via mirror://nongnu
+	savannahURLRegex = regexp.MustCompile(`(?:download\.savannah\.(?:non)?gnu\.org/releases|mirror://nongnu)/([^/\s"'#?]+)/`)
 )
 
 // DiscoverDataSources finds candidate URLs for version checking.
 // It analyzes ebuild metadata and returns a prioritized list of data sources.
 // If providedURL is non-empty, it is included as the highest priority source.
-func DiscoverDataSources(meta *EbuildMetadata, providedURL string) []DataSource {
+func DiscoverDataSources(meta *EbuildMetadata, providedURL string, opts ...DiscoverOption) []DataSource {
+	options := newDiscoverOptions(opts)
 	var sources []DataSource
 
 	// Add provided URL as highest priority if specified
 	if providedURL != "" {
+		sourceType := "provided"
+		if manifestFileName(providedURL) != "" {
+			sourceType = "manifest"
+		}
 		sources = append(sources, DataSource{
 			URL:         providedURL,
-			Type:        "provided",
+			Type:        sourceType,
 			Priority:    PriorityProvided,
 			ContentType: detectContentType(providedURL),
 		})
@@ -79,6 +127,17 @@ func DiscoverDataSources(meta *EbuildMetadata, providedURL string) []DataSource
 		sources = append(sources, *source)
 	}
 
+	// Try to discover a GitLab releases source. An explicitly provided URL
+	// is never replaced; the same URL is just not listed twice.
+	if source := discoverGitLabSource(meta); source != nil && source.URL != providedURL {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a Codeberg or configured Gitea instance's releases
+	if source := discoverGiteaSource(meta, options.giteaHosts); source != nil && source.URL != providedURL {
+		sources = append(sources, *source)
+	}
+
 	// Try to discover PyPI source
 	if source := discoverPyPISource(meta); source != nil {
 		sources = append(sources, *source)
@@ -94,6 +153,41 @@ func DiscoverDataSources(meta *EbuildMetadata, providedURL string) []DataSource
 		sources = append(sources, *source)
 	}
 
+	// Try to discover a RubyGems source
+	if source := discoverRubyGemsSource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a Hex.pm source
+	if source := discoverHexSource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a Go module on the module proxy
+	if source := discoverGoProxySource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a GNU ftp / Savannah release listing
+	if source := discoverGNUSource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a CRAN package's DESCRIPTION file
+	if source := discoverCRANSource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a WordPress plugin's readme.txt
+	if source := discoverWordPressSource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
+	// Try to discover a SourceForge project's file RSS
+	if source := discoverSourceForgeSource(meta); source != nil {
+		sources = append(sources, *source)
+	}
+
 	// Add homepage as fallback if it's a valid URL
 	if meta.Homepage != "" && isValidURL(meta.Homepage) {
 		// Don't add homepage if it's already covered by a more specific source
@@ -155,6 +249,11 @@ func discoverPyPISource(meta *EbuildMetadata) *DataSource {
 		return createPyPISource(pkgName)
 	}
 
+	// Try to extract package name from a mirror://pypi URL in SRC_URI
+	if matches := pypiMirrorRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		return createPyPISource(matches[1])
+	}
+
 	// Check dependencies for Python indicators
 	hasPythonDep := false
 	for _, dep := range meta.Dependencies {
@@ -176,9 +275,15 @@ func discoverPyPISource(meta *EbuildMetadata) *DataSource {
 	return nil
 }
 
+// pypiVersionPath is the JSON path of the latest release in a PyPI JSON API
+// response.
+const pypiVersionPath = "info.version"
+
 // createPyPISource creates a PyPI API data source for the given package name.
+// The name is normalized first, so the sdist name "Foo_Bar" and the Gentoo
+// name "foo-bar" query the same project.
 func createPyPISource(pkgName string) *DataSource {
-	apiURL := fmt.Sprintf("https://pypi.org/pypi/%s/json", pkgName)
+	apiURL := fmt.Sprintf("https://pypi.org/pypi/%s/json", normalizePyPIName(pkgName))
 	return &DataSource{
 		URL:         apiURL,
 		Type:        "pypi",
@@ -187,6 +292,12 @@ func createPyPISource(pkgName string) *DataSource {
 	}
 }
 
+// normalizePyPIName normalizes a project name the way PyPI does (PEP 503):
+// lower-cased, with every run of "-", "_" and "." replaced by a single "-".
+func normalizePyPIName(name string) string {
+	return pypiNameSeparatorRegex.ReplaceAllString(strings.ToLower(name), "-")
+}
+
 // extractPyPIPackageName attempts to extract a PyPI package name from a Gentoo package atom.
 // For example, "dev-python/requests" -> "requests"
 func extractPyPIPackageName(pkg string) string {
@@ -208,14 +319,17 @@ func extractPyPIPackageName(pkg string) string {
 func discoverNPMSource(meta *EbuildMetadata) *DataSource {
 	// Try to extract package name from npm URL in HOMEPAGE
 	if matches := npmURLRegex.FindStringSubmatch(meta.Homepage); matches != nil {
-		pkgName := matches[1]
-		return createNPMSource(pkgName)
+		if pkgName := expandPN(matches[1], meta.Package); pkgName != "" {
+			return createNPMSource(pkgName)
+		}
 	}
 
-	// Try to extract package name from npm URL in SRC_URI
+	// Try to extract package name from npm URL in SRC_URI, typically a
+	// tarball: registry.npmjs.org/${PN}/-/${P}.tgz
 	if matches := npmURLRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
-		pkgName := matches[1]
-		return createNPMSource(pkgName)
+		if pkgName := expandPN(matches[1], meta.Package); pkgName != "" {
+			return createNPMSource(pkgName)
+		}
 	}
 
 	// Check dependencies for Node.js indicators
@@ -238,9 +352,13 @@ func discoverNPMSource(meta *EbuildMetadata) *DataSource {
 	return nil
 }
 
+// npmVersionPath is the JSON path of the release tagged latest in an npm
+// registry document.
+const npmVersionPath = "dist-tags.latest"
+
 // createNPMSource creates an npm registry API data source for the given package name.
 func createNPMSource(pkgName string) *DataSource {
-	apiURL := fmt.Sprintf("https://registry.npmjs.org/%s", pkgName)
+	apiURL := "https://registry.npmjs.org/" + escapeNPMName(pkgName)
 	return &DataSource{
 		URL:         apiURL,
 		Type:        "npm",
@@ -249,6 +367,21 @@ func createNPMSource(pkgName string) *DataSource {
 	}
 }
 
+// escapeNPMName returns the form of an npm package name used in a registry
+// URL. The registry serves a scoped package's document at "@scope%2fname":
+// "@scope/name" with a literal slash would be read as a version of "@scope".
+// A name copied from an already-encoded URL is decoded first, so it is not
+// encoded twice.
+func escapeNPMName(name string) string {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if scope, pkg, ok := strings.Cut(name, "/"); ok && strings.HasPrefix(scope, "@") {
+		return scope + "%2f" + pkg
+	}
+	return name
+}
+
 // extractNPMPackageName attempts to extract an npm package name from a Gentoo package atom.
 // For example, "dev-nodejs/typescript" -> "typescript"
 func extractNPMPackageName(pkg string) string {
@@ -300,7 +433,18 @@ func discoverCratesSource(meta *EbuildMetadata) *DataSource {
 	return nil
 }
 
-// createCratesSource creates a crates.io API data source for the given crate name.
+// cratesVersionPath is the JSON path of the newest stable release in a
+// crates.io API response. max_version would also pick a pre-release.
+const cratesVersionPath = "crate.max_stable_version"
+
+// registryUserAgent identifies bentoolkit to the package registries: crates.io,
+// whose crawler policy rejects API requests without a User-Agent naming the
+// tool and a contact, and RubyGems and Hex.pm, which ask API clients for one.
+const registryUserAgent = "bentoolkit (https://github.com/obentoo/bentoolkit)"
+
+// createCratesSource creates a crates.io API data source for the given crate
+// name. It carries the User-Agent crates.io requires, so the analyzer's
+// fetch and the suggested schema's checks are both accepted.
 func createCratesSource(crateName string) *DataSource {
 	apiURL := fmt.Sprintf("https://crates.io/api/v1/crates/%s", crateName)
 	return &DataSource{
@@ -308,6 +452,7 @@ func createCratesSource(crateName string) *DataSource {
 		Type:        "crates",
 		Priority:    PriorityCrates,
 		ContentType: ContentTypeJSON,
+		Headers:     map[string]string{"User-Agent": registryUserAgent},
 	}
 }
 
@@ -327,6 +472,137 @@ func extractCrateName(pkg string) string {
 	return parts[1]
 }
 
+// discoverRubyGemsSource attempts to discover a RubyGems API endpoint from a
+// rubygems.org page in HOMEPAGE or SRC_URI, or a gem download in SRC_URI.
+func discoverRubyGemsSource(meta *EbuildMetadata) *DataSource {
+	for _, s := range []string{meta.Homepage, meta.SrcURI} {
+		if matches := rubygemsURLRegex.FindStringSubmatch(s); matches != nil {
+			if name := expandPN(strings.TrimSuffix(matches[1], ".json"), meta.Package); name != "" {
+				return createRubyGemsSource(name)
+			}
+		}
+	}
+
+	if matches := rubygemsFileRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		if name := archiveName(matches[1], meta.Package); name != "" {
+			return createRubyGemsSource(name)
+		}
+	}
+
+	return nil
+}
+
+// rubygemsVersionPath is the JSON path of the latest release in a RubyGems
+// API gem document.
+const rubygemsVersionPath = "version"
+
+// createRubyGemsSource creates a RubyGems API data source for the given gem.
+func createRubyGemsSource(gem string) *DataSource {
+	return &DataSource{
+		URL:         fmt.Sprintf("https://rubygems.org/api/v1/gems/%s.json", gem),
+		Type:        "rubygems",
+		Priority:    PriorityRubyGems,
+		ContentType: ContentTypeJSON,
+		Headers:     map[string]string{"User-Agent": registryUserAgent},
+	}
+}
+
+// discoverHexSource attempts to discover a Hex.pm API endpoint from a hex.pm
+// page in HOMEPAGE or SRC_URI, or a release tarball in SRC_URI.
+func discoverHexSource(meta *EbuildMetadata) *DataSource {
+	for _, s := range []string{meta.Homepage, meta.SrcURI} {
+		if matches := hexURLRegex.FindStringSubmatch(s); matches != nil {
+			return createHexSource(matches[1])
+		}
+	}
+
+	if matches := hexTarballRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		if name := archiveName(matches[1], meta.Package); name != "" {
+			return createHexSource(name)
+		}
+	}
+
+	return nil
+}
+
+// hexVersionPath is the JSON path of the newest stable release in a Hex.pm
+// API package document. releases[0].version would also pick a pre-release.
+const hexVersionPath = "latest_stable_version"
+
+// createHexSource creates a Hex.pm API data source for the given package.
+func createHexSource(pkgName string) *DataSource {
+	return &DataSource{
+		URL:         fmt.Sprintf("https://hex.pm/api/packages/%s", pkgName),
+		Type:        "hex",
+		Priority:    PriorityHex,
+		ContentType: ContentTypeJSON,
+		Headers:     map[string]string{"User-Agent": registryUserAgent},
+	}
+}
+
+// archiveName returns the package name in an archive file name of the form
+// "<name>-<version>" ("rails-7.1.0", "${PN}-${PV}"), reading "${P}" as the
+// latter. It returns "" when no name can be told apart.
+func archiveName(stem, pkg string) string {
+	if stem == "${P}" {
+		stem = "${PN}-${PV}"
+	}
+	matches := archiveNameRegex.FindStringSubmatch(stem)
+	if matches == nil {
+		return ""
+	}
+	return expandPN(matches[1], pkg)
+}
+
+// discoverGNUSource attempts to discover a GNU release directory listing.
+// SRC_URI is checked first since it names the actual download directory
+// (ftp.gnu.org or Savannah); a gnu.org/software homepage falls back to the
+// conventional https://ftp.gnu.org/gnu/<name>/ listing.
+func discoverGNUSource(meta *EbuildMetadata) *DataSource {
+	if matches := gnuFTPURLRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		if name := expandPN(matches[1], meta.Package); name != "" {
+			return createGNUSource("https://ftp.gnu.org/gnu/" + name + "/")
+		}
+	}
+
+	if matches := savannahURLRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		if name := expandPN(matches[1], meta.Package); name != "" {
+			return createGNUSource("https://download.savannah.gnu.org/releases/" + name + "/")
+		}
+	}
+
+	if matches := gnuHomepageRegex.FindStringSubmatch(meta.Homepage); matches != nil {
+		if name := expandPN(matches[1], meta.Package); name != "" {
+			return createGNUSource("https://ftp.gnu.org/gnu/" + name + "/")
+		}
+	}
+
+	return nil
+}
+
+// createGNUSource creates a GNU directory listing data source for listingURL.
+func createGNUSource(listingURL string) *DataSource {
+	return &DataSource{
+		URL:         listingURL,
+		Type:        "gnu",
+		Priority:    PriorityGNU,
+		ContentType: ContentTypeHTML,
+	}
+}
+
+// expandPN substitutes ${PN} in segment with the package name of pkg, since
+// ebuilds commonly write SRC_URI="mirror://gnu/${PN}/${P}.tar.xz". A segment
+// still holding an unexpanded variable is rejected.
+func expandPN(segment, pkg string) string {
+	if pn := pkg[strings.LastIndex(pkg, "/")+1:]; pn != "" {
+		segment = strings.ReplaceAll(segment, "${PN}", pn)
+	}
+	if strings.Contains(segment, "$") {
+		return ""
+	}
+	return segment
+}
+
 // detectContentType attempts to detect the expected content type from a URL.
 // Returns ContentTypeJSON for known API endpoints, ContentTypeHTML otherwise.
 func detectContentType(url string) string {
@@ -336,6 +612,10 @@ func detectContentType(url string) string {
 		"pypi.org/pypi/",
 		"registry.npmjs.org",
 		"crates.io/api/",
+		"rubygems.org/api/",
+		"hex.pm/api/",
+		"proxy.golang.org/",
+		"/api/v4/projects/", // GitLab
 		".json",
 	}
 
@@ -363,6 +643,14 @@ func isURLCoveredBySource(url string, sources []DataSource) bool {
 			if githubURLRegex.MatchString(url) {
 				return true
 			}
+		case "gitlab":
+			if _, _, ok := findGitLabProject(url); ok {
+				return true
+			}
+		case "gitea":
+			if giteaSourceCovers(url, source) {
+				return true
+			}
 		case "pypi":
 			if pypiURLRegex.MatchString(url) {
 				return true
@@ -375,6 +663,34 @@ func isURLCoveredBySource(url string, sources []DataSource) bool {
 			if cratesURLRegex.MatchString(url) {
 				return true
 			}
+		case "rubygems":
+			if rubygemsURLRegex.MatchString(url) {
+				return true
+			}
+		case "hex":
+			if hexURLRegex.MatchString(url) {
+				return true
+			}
+		case "go-module-proxy":
+			if goProxySourceCovers(url, source) {
+				return true
+			}
+		case "gnu":
+			if gnuHomepageRegex.MatchString(url) {
+				return true
+			}
+		case "cran":
+			if cranURLRegex.MatchString(url) {
+				return true
+			}
+		case "wordpress":
+			if wordpressPluginRegex.MatchString(url) {
+				return true
+			}
+		case "sourceforge":
+			if sourceForgeProjectRegex.MatchString(url) || sourceForgeHostRegex.MatchString(url) {
+				return true
+			}
 		}
 	}
 	return false
@@ -609,6 +609,39 @@ func TestDiscoverDataSourcesNPM(t *testing.T) {
 			},
 			expected: "https://registry.npmjs.org/mypackage",
 		},
+		{
+			name: "scoped package homepage",
+			meta: &EbuildMetadata{
+				Package:  "dev-nodejs/node-types",
+				Homepage: "https://www.npmjs.com/package/@types/node",
+			},
+			expected: "https://registry.npmjs.org/@types%2fnode",
+		},
+		{
+			name: "scoped tarball in SRC_URI",
+			meta: &EbuildMetadata{
+				Package:  "app-misc/cli",
+				Homepage: "https://example.com",
+				SrcURI:   "https://registry.npmjs.org/@acme/cli/-/cli-1.2.0.tgz -> ${P}.tgz",
+			},
+			expected: "https://registry.npmjs.org/@acme%2fcli",
+		},
+		{
+			name: "already-encoded scoped name",
+			meta: &EbuildMetadata{
+				Package:  "app-misc/cli",
+				Homepage: "https://registry.npmjs.org/@acme%2Fcli",
+			},
+			expected: "https://registry.npmjs.org/@acme%2fcli",
+		},
+		{
+			name: "unscoped tarball with ${PN}",
+			meta: &EbuildMetadata{
+				Package: "dev-util/typescript",
+				SrcURI:  "https://registry.npmjs.org/${PN}/-/${P}.tgz",
+			},
+			expected: "https://registry.npmjs.org/typescript",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -646,6 +679,15 @@ func TestDiscoverDataSourcesCrates(t *testing.T) {
 			},
 			expected: "https://crates.io/api/v1/crates/serde",
 		},
+		{
+			name: "crates.io download in SRC_URI",
+			meta: &EbuildMetadata{
+				Package:  "dev-rust/ripgrep-bin",
+				Homepage: "https://example.com",
+				SrcURI:   "https://crates.io/api/v1/crates/ripgrep/14.1.0/download -> ripgrep-14.1.0.crate",
+			},
+			expected: "https://crates.io/api/v1/crates/ripgrep",
+		},
 		{
 			name: "Rust dependencies",
 			meta: &EbuildMetadata{
@@ -668,6 +710,9 @@ func TestDiscoverDataSourcesCrates(t *testing.T) {
 					if source.URL != tc.expected {
 						t.Errorf("Expected URL %q, got %q", tc.expected, source.URL)
 					}
+					if source.Headers["User-Agent"] != registryUserAgent {
+						t.Errorf("Expected the crates.io User-Agent, got headers %v", source.Headers)
+					}
 				}
 			}
 			if !hasCrates {
@@ -677,6 +722,82 @@ func TestDiscoverDataSourcesCrates(t *testing.T) {
 	}
 }
 
+// TestDiscoverDataSourcesRubyGemsHex tests RubyGems and Hex.pm source
+// discovery: the name is taken from pages, API URLs and archive downloads,
+// and both sources carry the registry User-Agent.
+func TestDiscoverDataSourcesRubyGemsHex(t *testing.T) {
+	testCases := []struct {
+		name       string
+		meta       *EbuildMetadata
+		sourceType string
+		expected   string
+	}{
+		{
+			name:       "rubygems homepage",
+			meta:       &EbuildMetadata{Package: "dev-ruby/rack", Homepage: "https://rubygems.org/gems/rack"},
+			sourceType: "rubygems",
+			expected:   "https://rubygems.org/api/v1/gems/rack.json",
+		},
+		{
+			name:       "rubygems API URL",
+			meta:       &EbuildMetadata{Package: "dev-ruby/rack", SrcURI: "https://rubygems.org/api/v1/gems/rack.json"},
+			sourceType: "rubygems",
+			expected:   "https://rubygems.org/api/v1/gems/rack.json",
+		},
+		{
+			name:       "gem download with a hyphenated name",
+			meta:       &EbuildMetadata{Package: "dev-ruby/net-http", Homepage: "https://example.com", SrcURI: "https://rubygems.org/downloads/net-http-0.4.1.gem"},
+			sourceType: "rubygems",
+			expected:   "https://rubygems.org/api/v1/gems/net-http.json",
+		},
+		{
+			name:       "rubygems mirror with ${P}",
+			meta:       &EbuildMetadata{Package: "dev-ruby/rake", SrcURI: "mirror://rubygems/${P}.gem"},
+			sourceType: "rubygems",
+			expected:   "https://rubygems.org/api/v1/gems/rake.json",
+		},
+		{
+			name:       "hex homepage",
+			meta:       &EbuildMetadata{Package: "dev-elixir/jason", Homepage: "https://hex.pm/packages/jason"},
+			sourceType: "hex",
+			expected:   "https://hex.pm/api/packages/jason",
+		},
+		{
+			name:       "hex tarball",
+			meta:       &EbuildMetadata{Package: "dev-erlang/cowboy", Homepage: "https://example.com", SrcURI: "https://repo.hex.pm/tarballs/${PN}-${PV}.tar -> ${P}.tar"},
+			sourceType: "hex",
+			expected:   "https://hex.pm/api/packages/cowboy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var found *DataSource
+			for _, source := range DiscoverDataSources(tc.meta, "") {
+				if source.Type == tc.sourceType {
+					found = &source
+				}
+			}
+			if found == nil {
+				t.Fatalf("no %s source", tc.sourceType)
+			}
+			if found.URL != tc.expected || found.ContentType != ContentTypeJSON {
+				t.Errorf("%s source = %s (%s), want %s as JSON", tc.sourceType, found.URL, found.ContentType, tc.expected)
+			}
+			if found.Headers["User-Agent"] != registryUserAgent {
+				t.Errorf("headers = %v, want the registry User-Agent", found.Headers)
+			}
+		})
+	}
+
+	// An ordinary homepage names neither registry.
+	for _, source := range DiscoverDataSources(&EbuildMetadata{Package: "dev-ruby/foo", Homepage: "https://example.com/foo"}, "") {
+		if source.Type == "rubygems" || source.Type == "hex" {
+			t.Errorf("unexpected %s source %s", source.Type, source.URL)
+		}
+	}
+}
+
 // TestDiscoverDataSourcesHomepageFallback tests homepage as fallback
 func TestDiscoverDataSourcesHomepageFallback(t *testing.T) {
 	meta := &EbuildMetadata{
@@ -744,3 +865,25 @@ func TestDiscoverDataSourcesContentType(t *testing.T) {
 		})
 	}
 }
+
+// TestDiscoverDataSourcesPyPI_NormalizesName verifies the PyPI project name is
+// normalized the way PyPI does, whichever reference it was taken from.
+func TestDiscoverDataSourcesPyPI_NormalizesName(t *testing.T) {
+	const want = "https://pypi.org/pypi/zope-interface/json"
+	for _, meta := range []*EbuildMetadata{
+		{Package: "dev-python/zope-interface", Homepage: "https://pypi.org/project/Zope.Interface/"},
+		{Package: "dev-python/zope-interface", SrcURI: "https://files.pythonhosted.org/packages/source/z/zope_interface/zope_interface-7.0.tar.gz"},
+		{Package: "dev-python/zope-interface", SrcURI: "mirror://pypi/z/zope.interface/zope.interface-7.0.tar.gz"},
+		{Package: "dev-python/zope__interface", Dependencies: []string{"dev-python/setuptools"}},
+	} {
+		var got string
+		for _, s := range DiscoverDataSources(meta, "") {
+			if s.Type == "pypi" {
+				got = s.URL
+			}
+		}
+		if got != want {
+			t.Errorf("pypi source for %+v = %q, want %q", meta, got, want)
+		}
+	}
+}
@@ -17,9 +17,10 @@
 // # Response body size cap
 //
 // Every HTTP response body is bounded so an oversized or malicious response
-// cannot exhaust memory. RetryableHTTPClient.GetWithContext wraps the response
-// body in an http.MaxBytesReader capped at httputil.MaxBodyBytes (10 MiB); a
-// read that exceeds the cap surfaces as an error wrapping ErrResponseTooLarge.
+// cannot exhaust memory. RetryableHTTPClient wraps every response body in an
+// http.MaxBytesReader capped at RetryConfig.MaxBodySize (default
+// httputil.MaxBodyBytes, 10 MiB); a read that exceeds the cap surfaces as an
+// error wrapping ErrResponseTooLarge.
 //
 // The LLM clients (ClaudeClient, OpenAIClient, OllamaClient) apply the same
 // 10 MiB default to their API response bodies, but the limit is per-client and
@@ -0,0 +1,41 @@
+// Package autoupdate provides per-package validation of extracted version
+// formats.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrUnexpectedVersionFormat is returned when an extracted upstream version
+// does not match the package's expect_format.
+var ErrUnexpectedVersionFormat = errors.New("extracted version does not match expect_format")
+
+// compileExpectFormat compiles an expect_format pattern anchored at both ends,
+// so "[0-9]+\.[0-9]+" accepts "1.2" but not "1.2 (beta)" or "v1.2".
+func compileExpectFormat(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+	}
+	return re, nil
+}
+
+// checkExpectFormat returns ErrUnexpectedVersionFormat when cfg sets
+// ExpectFormat and version does not match it. Validation already rejected an
+// uncompilable pattern, so a compile error here is reported the same way.
+func checkExpectFormat(cfg *PackageConfig, version string) error {
+	if cfg.ExpectFormat == "" {
+		return nil
+	}
+	re, err := compileExpectFormat(cfg.ExpectFormat)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnexpectedVersionFormat, err)
+	}
+	if !re.MatchString(version) {
+		return fmt.Errorf("%w: got %q, expected %q (upstream page may have changed)",
+			ErrUnexpectedVersionFormat, version, cfg.ExpectFormat)
+	}
+	return nil
+}
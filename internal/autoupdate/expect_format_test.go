@@ -0,0 +1,74 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCheckPackageExpectFormat verifies an extracted version matching
+// expect_format is accepted, and a value grabbed from a restructured page is
+// rejected with ErrUnexpectedVersionFormat and never queued.
+func TestCheckPackageExpectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		wantErr bool
+	}{
+		{"matching version", `<span class="ver">2.4.1</span>`, false},
+		{"page restructured", `<span class="ver">Released 2026-03-14</span>`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.page))
+			}))
+			t.Cleanup(srv.Close)
+
+			content := `["app-misc/tool"]
+url = "` + srv.URL + `"
+parser = "regex"
+pattern = '<span class="ver">([^<]+)</span>'
+expect_format = '[0-9]+\.[0-9]+\.[0-9]+'
+`
+			overlay, _ := writePackagesTOML(t, content)
+			createTestEbuild(t, overlay, "app-misc/tool", "2.0.0")
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("app-misc/tool", true)
+
+			if !tt.wantErr {
+				if err != nil || result.UpstreamVersion != "2.4.1" {
+					t.Fatalf("CheckPackage() = %q, %v; want 2.4.1", result.UpstreamVersion, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnexpectedVersionFormat) {
+				t.Fatalf("CheckPackage() error = %v, want %v", err, ErrUnexpectedVersionFormat)
+			}
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Errorf("CheckPackage() error = %T, want *ParseError", err)
+			}
+			if _, found := checker.Pending().Get("app-misc/tool"); found {
+				t.Error("rejected version must not be queued")
+			}
+		})
+	}
+}
+
+// TestValidateExpectFormat verifies an uncompilable expect_format is rejected
+// at load time.
+func TestValidateExpectFormat(t *testing.T) {
+	cfg := &PackageConfig{URL: "https://example.com", Parser: "regex", Pattern: "v(.+)", ExpectFormat: "[0-9"}
+	if err := ValidatePackageConfig("app-misc/tool", cfg); !errors.Is(err, ErrInvalidRegexPattern) {
+		t.Errorf("ValidatePackageConfig() error = %v, want %v", err, ErrInvalidRegexPattern)
+	}
+}
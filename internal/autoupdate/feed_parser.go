@@ -0,0 +1,164 @@
+// Package autoupdate provides date-ordered JSON feed parsing for ebuild autoupdate.
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFeed is returned for a json-feed package without a feed table or
+// with a missing date or version path.
+var ErrInvalidFeed = errors.New("invalid json-feed source: needs feed.date and feed.version")
+
+// FeedConfig describes a JSON feed (JSON Feed, OPDS 2.0, a release API) whose
+// entries are not in version order, so the newest is found by date:
+//
+//	parser = "json-feed"
+//	feed = { entries = "publications", date = "metadata.modified", version = "metadata.title" }
+//
+// The package's Pattern, when set, extracts the version from the entry's
+// version value with its first capture group ("Foo (\d+\.\d+)" from a title).
+type FeedConfig struct {
+	// Entries is the JSON path of the entry array; empty means the document
+	// itself is the array
+	Entries string `toml:"entries,omitempty"`
+	// Date is the JSON path, within an entry, of its date: RFC 3339, RFC 1123,
+	// a plain "2006-01-02" date, or Unix seconds
+	Date string `toml:"date"`
+	// Version is the JSON path, within an entry, of its version
+	Version string `toml:"version"`
+}
+
+// feedDateLayouts are the date formats a feed entry's date is tried in.
+var feedDateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// FeedParser extracts the version of the most recently dated entry of a JSON
+// feed. Entries without a parseable date are skipped; on equal dates the one
+// earlier in the document wins.
+type FeedParser struct {
+	// Feed locates the entries and their date and version
+	Feed FeedConfig
+	// pattern optionally extracts the version from the version value
+	pattern *regexp.Regexp
+}
+
+// NewFeedParser creates a FeedParser. pattern may be empty; otherwise it
+// needs a capture group.
+func NewFeedParser(feed *FeedConfig, pattern string) (*FeedParser, error) {
+	if feed == nil || feed.Date == "" || feed.Version == "" {
+		return nil, ErrInvalidFeed
+	}
+	p := &FeedParser{Feed: *feed}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, ErrNoCaptureGroup
+		}
+		p.pattern = re
+	}
+	return p, nil
+}
+
+// Parse returns the version of the newest-dated entry.
+func (p *FeedParser) Parse(content []byte) (string, error) {
+	var data interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return "", fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	list, err := navigateJSONPath(data, p.Feed.Entries)
+	if err != nil {
+		return "", err
+	}
+	entries, ok := list.([]interface{})
+	if !ok {
+		return "", fmt.Errorf("%w: feed entries at %q are not an array", ErrJSONPathNotFound, p.Feed.Entries)
+	}
+
+	var (
+		newest     time.Time
+		newestItem interface{}
+	)
+	for _, entry := range entries {
+		raw, err := navigateJSONPath(entry, p.Feed.Date)
+		if err != nil {
+			continue
+		}
+		date, ok := parseFeedDate(raw)
+		if !ok {
+			continue
+		}
+		if newestItem == nil || date.After(newest) {
+			newest, newestItem = date, entry
+		}
+	}
+	if newestItem == nil {
+		return "", fmt.Errorf("%w: no feed entry has a date at %q", ErrNoVersionFound, p.Feed.Date)
+	}
+
+	version, err := extractJSONPath(newestItem, p.Feed.Version)
+	if err != nil {
+		return "", err
+	}
+	if p.pattern != nil {
+		m := p.pattern.FindStringSubmatch(version)
+		if m == nil {
+			return "", fmt.Errorf("%w: pattern does not match %q", ErrNoVersionFound, version)
+		}
+		version = m[1]
+	}
+	return strings.TrimSpace(version), nil
+}
+
+// parseFeedDate reads a feed date: a string in one of feedDateLayouts, or a
+// number of Unix seconds.
+func parseFeedDate(raw interface{}) (time.Time, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case string:
+		v = strings.TrimSpace(v)
+		for _, layout := range feedDateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(secs, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// validateFeed checks a json-feed package's feed table and paths.
+func validateFeed(cfg *PackageConfig) error {
+	if _, err := NewFeedParser(cfg.Feed, cfg.Pattern); err != nil {
+		return err
+	}
+	for _, path := range []string{cfg.Feed.Entries, cfg.Feed.Date} {
+		if path == "" {
+			continue
+		}
+		if _, err := parseJSONPath(path); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidFeed, err)
+		}
+	}
+	if err := validateJSONPathExpr(cfg.Feed.Version); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFeed, err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFeedParser_NewestByDate verifies the entry with the latest date wins
+// regardless of document order, across date formats, and that undated
+// entries are skipped.
+func TestFeedParser_NewestByDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		feed    FeedConfig
+		pattern string
+		content string
+		want    string
+	}{
+		{
+			name: "out of order RFC 3339",
+			feed: FeedConfig{Entries: "items", Date: "date_published", Version: "version"},
+			content: `{"items":[
+				{"version":"2.1.0","date_published":"2024-03-01T10:00:00Z"},
+				{"version":"3.0.0","date_published":"2024-05-20T08:30:00+02:00"},
+				{"version":"2.9.1","date_published":"2024-04-11T00:00:00Z"},
+				{"version":"9.9.9"}]}`,
+			want: "3.0.0",
+		},
+		{
+			name:    "OPDS titles with pattern",
+			feed:    FeedConfig{Entries: "publications", Date: "metadata.modified", Version: "metadata.title"},
+			pattern: `Handbook (\d+(?:\.\d+)+)`,
+			content: `{"publications":[
+				{"metadata":{"title":"Handbook 1.4","modified":"2023-12-01"}},
+				{"metadata":{"title":"Handbook 1.3","modified":"2024-02-15"}}]}`,
+			want: "1.3",
+		},
+		{
+			name: "root array with Unix seconds",
+			feed: FeedConfig{Date: "published", Version: "tag"},
+			content: `[
+				{"tag":"v5","published":1700000000},
+				{"tag":"v6","published":1710000000},
+				{"tag":"v4","published":"1690000000"}]`,
+			want: "v6",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewFeedParser(&tt.feed, tt.pattern)
+			if err != nil {
+				t.Fatalf("NewFeedParser() error = %v", err)
+			}
+			got, err := p.Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFeedParser_NoDatedEntry verifies a feed without any parseable date
+// reports ErrNoVersionFound rather than guessing by document order.
+func TestFeedParser_NoDatedEntry(t *testing.T) {
+	p, err := NewFeedParser(&FeedConfig{Date: "date", Version: "version"}, "")
+	if err != nil {
+		t.Fatalf("NewFeedParser() error = %v", err)
+	}
+	_, err = p.Parse([]byte(`[{"version":"1.0","date":"yesterday"}]`))
+	if !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrNoVersionFound)
+	}
+}
+
+// TestCheckPackage_JSONFeed runs a json-feed package end to end.
+func TestCheckPackage_JSONFeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"releases":[
+			{"name":"1.8.2","date":"2024-01-10"},
+			{"name":"2.0.1","date":"2024-06-02"},
+			{"name":"1.9.0","date":"2024-03-15"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, _ := writePackagesTOML(t, `["app-misc/feed"]
+url = "`+srv.URL+`"
+parser = "json-feed"
+feed = { entries = "releases", date = "date", version = "name" }
+`)
+	createTestEbuild(t, overlay, "app-misc/feed", "1.9.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-misc/feed", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "2.0.1" || !result.HasUpdate {
+		t.Errorf("UpstreamVersion = %q (HasUpdate %v), want 2.0.1", result.UpstreamVersion, result.HasUpdate)
+	}
+}
+
+// TestValidateFeed verifies a json-feed source needs its date and version
+// paths.
+func TestValidateFeed(t *testing.T) {
+	for _, feed := range []*FeedConfig{nil, {Date: "date"}, {Version: "name"}} {
+		cfg := &PackageConfig{URL: "https://example.com/feed.json", Parser: "json-feed", Feed: feed}
+		if err := ValidatePackageConfig("app-misc/feed", cfg); !errors.Is(err, ErrInvalidFeed) {
+			t.Errorf("feed %+v: error = %v, want %v", feed, err, ErrInvalidFeed)
+		}
+	}
+}
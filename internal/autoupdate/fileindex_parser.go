@@ -0,0 +1,201 @@
+// Package autoupdate provides directory listing (file index) parsing for
+// ebuild autoupdate.
+package autoupdate
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// FileIndexParser extracts the newest release from a directory listing of
+// release files: an HTML autoindex (Apache, nginx, lighttpd, python -m
+// http.server) or a plain FTP-style listing, one file per line, either bare
+// names or `ls -l` lines. It is the general form of GNUFTPParser, for the
+// many mirrors (kernel.org, sourceware, X.org, project download dirs) that
+// publish releases as a bare file list.
+//
+// FilePattern is matched against each file name; its first capture group is
+// the version. Among the matching names the highest version per
+// ebuild.CompareVersions wins.
+type FileIndexParser struct {
+	// FilePattern is the file name regex, e.g. `^foo-([0-9.]+)\.tar\.xz$`.
+	FilePattern string
+
+	compiled *regexp.Regexp
+}
+
+// NewFileIndexParser returns a parser for the file names FilePattern matches.
+// The pattern must have a capture group, the version.
+func NewFileIndexParser(filePattern string) (*FileIndexParser, error) {
+	if filePattern == "" {
+		return nil, ErrMissingFilePattern
+	}
+	re, err := regexp.Compile(filePattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("%w: file_pattern needs a capture group for the version", ErrInvalidRegexPattern)
+	}
+	return &FileIndexParser{FilePattern: filePattern, compiled: re}, nil
+}
+
+// Parse returns the highest version among the listed files.
+func (p *FileIndexParser) Parse(content []byte) (string, error) {
+	versions, err := p.ExtractVersions(content)
+	if err != nil {
+		return "", err
+	}
+	return highestFileVersion(versions)
+}
+
+// highestFileVersion returns the highest valid version among versions.
+func highestFileVersion(versions []string) (string, error) {
+	best := ""
+	for _, v := range versions {
+		if !ebuild.IsValidVersion(v) {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(v, best) > 0 {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w: no listed file has a valid version", ErrNoVersionFound)
+	}
+	return best, nil
+}
+
+// ExtractVersions returns the version of every listed file FilePattern
+// matches, in listing order, each once, so select, version_constraint and
+// the stable/exclude patterns work on a listing as on any other list.
+func (p *FileIndexParser) ExtractVersions(content []byte) ([]string, error) {
+	if p.compiled == nil {
+		parsed, err := NewFileIndexParser(p.FilePattern)
+		if err != nil {
+			return nil, err
+		}
+		p.compiled = parsed.compiled
+	}
+	return p.versionsOf(fileIndexNames(content))
+}
+
+// versionsOf returns the version of every name FilePattern matches, in
+// order, each once.
+func (p *FileIndexParser) versionsOf(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var versions []string
+	for _, name := range names {
+		m := p.compiled.FindStringSubmatch(name)
+		if m == nil || m[1] == "" || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		versions = append(versions, m[1])
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: no listed file matches %s", ErrNoVersionFound, p.FilePattern)
+	}
+	return versions, nil
+}
+
+// fileIndexNames returns the file names of a listing: the base names of the
+// link targets of an HTML listing, or else the last field of each line of a
+// plain one, which is the name in both a bare list and `ls -l` output.
+func fileIndexNames(content []byte) []string {
+	var names []string
+	if links := gnuHrefRegex.FindAllSubmatch(content, -1); len(links) > 0 {
+		for _, m := range links {
+			target := string(m[1])
+			if unescaped, err := url.PathUnescape(target); err == nil {
+				target = unescaped
+			}
+			// Drop a query (autoindex sort links are "?C=M;O=A").
+			target, _, _ = strings.Cut(target, "?")
+			if name := path.Base(target); name != "." && name != "/" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) > 0 {
+			names = append(names, fields[len(fields)-1])
+		}
+	}
+	return names
+}
+
+// fileIndexMarkers match the heading of the HTML directory listings servers
+// generate: Apache and nginx "Index of /…", python's "Directory listing for".
+var fileIndexMarkers = regexp.MustCompile(`(?i)<(?:title|h1)>\s*(?:index of|directory listing for)\s`)
+
+// lsLongLine matches a line of `ls -l` style output, as FTP servers list a
+// directory.
+var lsLongLine = regexp.MustCompile(`^[-dl][-rwxsStT]{9}\s`)
+
+// looksLikeFileIndex reports whether content is a directory listing rather
+// than a page that merely links some files: an HTML autoindex, or plain text
+// whose every non-empty line is a bare file name or an `ls -l` line.
+func looksLikeFileIndex(content []byte) bool {
+	if fileIndexMarkers.Match(content) {
+		return true
+	}
+	if bytes.Contains(content, []byte("<")) {
+		return false
+	}
+	lines := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !lsLongLine.Match(line) && bytes.ContainsAny(line, " \t") {
+			return false
+		}
+		lines++
+	}
+	return lines > 0
+}
+
+// releaseFilePattern is the file_pattern the analyzer suggests for a listing
+// of name's releases: name-<version> with a tarball or zip extension.
+func releaseFilePattern(name string) string {
+	return `^` + regexp.QuoteMeta(name) + `-(\d[0-9A-Za-z._+-]*?)\.(?:tar\.(?:gz|bz2|xz|lz|zst)|tgz|tbz2|txz|zip)$`
+}
+
+// detectFileIndexSchema returns a fileindex schema for content when it is a
+// directory listing holding release files of the package meta describes, and
+// nil otherwise. The release name is the package name, matched
+// case-insensitively, since listings often capitalize it ("Foo-1.0.tar.gz").
+func detectFileIndexSchema(content []byte, meta *EbuildMetadata, source *DataSource) *PackageConfig {
+	if meta == nil || source == nil || !looksLikeFileIndex(content) {
+		return nil
+	}
+	_, name, ok := strings.Cut(meta.Package, "/")
+	if !ok || name == "" {
+		return nil
+	}
+	pattern := `(?i)` + releaseFilePattern(name)
+	parser, err := NewFileIndexParser(pattern)
+	if err != nil {
+		return nil
+	}
+	if _, err := parser.Parse(content); err != nil {
+		return nil
+	}
+	return &PackageConfig{
+		URL:         source.URL,
+		Parser:      "fileindex",
+		FilePattern: pattern,
+		Headers:     maps.Clone(source.Headers),
+	}
+}
@@ -0,0 +1,158 @@
+package autoupdate
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fooAutoindex is an nginx-style autoindex page of a project's releases.
+const fooAutoindex = `<html>
+<head><title>Index of /releases/foo/</title></head>
+<body>
+<h1>Index of /releases/foo/</h1><hr><pre><a href="../">../</a>
+<a href="old/">old/</a>                                               01-Jan-2020 00:00       -
+<a href="foo-1.9.tar.gz">foo-1.9.tar.gz</a>                           02-Mar-2023 10:12   81234
+<a href="foo-1.9.tar.gz.asc">foo-1.9.tar.gz.asc</a>                   02-Mar-2023 10:12     833
+<a href="foo-1.10.0.tar.xz">foo-1.10.0.tar.xz</a>                     14-Jun-2024 08:01   70123
+<a href="foo-1.10.0.tar.xz.sha256">foo-1.10.0.tar.xz.sha256</a>       14-Jun-2024 08:01      84
+<a href="foo-2.0_rc1.tar.gz">foo-2.0_rc1.tar.gz</a>                   01-Sep-2025 12:00   90000
+<a href="foo-doc-3.0.tar.gz">foo-doc-3.0.tar.gz</a>                   01-Sep-2025 12:00   10000
+<a href="https://mirror.example.com/releases/foo/foo-1.10.1.tar.gz">mirror</a>
+</pre><hr></body>
+</html>
+`
+
+// fooFTPListing is a plain FTP-style (ls -l) listing of the same releases.
+const fooFTPListing = `drwxr-xr-x    2 ftp      ftp          4096 Jan 01  2020 old
+-rw-r--r--    1 ftp      ftp         81234 Mar 02  2023 foo-1.9.tar.gz
+-rw-r--r--    1 ftp      ftp           833 Mar 02  2023 foo-1.9.tar.gz.asc
+-rw-r--r--    1 ftp      ftp         70123 Jun 14  2024 foo-1.10.0.tar.xz
+-rw-r--r--    1 ftp      ftp         10000 Sep 01  2025 foo-doc-3.0.tar.gz
+`
+
+// TestFileIndexParser_HTMLAutoindex verifies the highest release is taken
+// from an autoindex page by Gentoo ordering: link targets are reduced to
+// their base name, and signatures, checksums and another project sharing the
+// prefix are ignored.
+func TestFileIndexParser_HTMLAutoindex(t *testing.T) {
+	parser, err := NewFileIndexParser(releaseFilePattern("foo"))
+	if err != nil {
+		t.Fatalf("NewFileIndexParser() error = %v", err)
+	}
+
+	versions, err := parser.ExtractVersions([]byte(fooAutoindex))
+	if err != nil {
+		t.Fatalf("ExtractVersions() error = %v", err)
+	}
+	want := []string{"1.9", "1.10.0", "2.0_rc1", "1.10.1"}
+	if !slices.Equal(versions, want) {
+		t.Errorf("ExtractVersions() = %v, want %v", versions, want)
+	}
+
+	got, err := parser.Parse([]byte(fooAutoindex))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "2.0_rc1" {
+		t.Errorf("Parse() = %q, want 2.0_rc1", got)
+	}
+}
+
+// TestFileIndexParser_FTPListing verifies a plain listing is read by the last
+// field of each line.
+func TestFileIndexParser_FTPListing(t *testing.T) {
+	parser, err := NewFileIndexParser(`^foo-([0-9.]+)\.tar\.(?:gz|xz)$`)
+	if err != nil {
+		t.Fatalf("NewFileIndexParser() error = %v", err)
+	}
+	got, err := parser.Parse([]byte(fooFTPListing))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "1.10.0" {
+		t.Errorf("Parse() = %q, want 1.10.0", got)
+	}
+
+	bare := "foo-1.2.tar.gz\nfoo-1.12.tar.gz\nfoo-1.3.tar.gz\n"
+	if got, err := parser.Parse([]byte(bare)); err != nil || got != "1.12" {
+		t.Errorf("Parse(bare names) = %q, %v; want 1.12", got, err)
+	}
+}
+
+// TestFileIndexParser_Errors covers an unusable file_pattern and a listing
+// with no matching file.
+func TestFileIndexParser_Errors(t *testing.T) {
+	if _, err := NewFileIndexParser(""); !errors.Is(err, ErrMissingFilePattern) {
+		t.Errorf("empty pattern error = %v, want %v", err, ErrMissingFilePattern)
+	}
+	for _, pattern := range []string{`foo-(`, `^foo-[0-9.]+\.tar\.gz$`} {
+		if _, err := NewFileIndexParser(pattern); !errors.Is(err, ErrInvalidRegexPattern) {
+			t.Errorf("NewFileIndexParser(%q) error = %v, want %v", pattern, err, ErrInvalidRegexPattern)
+		}
+	}
+
+	parser, err := NewFileIndexParser(releaseFilePattern("bar"))
+	if err != nil {
+		t.Fatalf("NewFileIndexParser() error = %v", err)
+	}
+	if _, err := parser.Parse([]byte(fooAutoindex)); !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrNoVersionFound)
+	}
+}
+
+// TestValidatePackageConfig_FileIndex verifies a fileindex entry needs a
+// valid file_pattern.
+func TestValidatePackageConfig_FileIndex(t *testing.T) {
+	cfg := &PackageConfig{URL: "https://example.com/foo/", Parser: "fileindex"}
+	if err := ValidatePackageConfig("app-misc/foo", cfg); !errors.Is(err, ErrMissingFilePattern) {
+		t.Errorf("without file_pattern: error = %v, want %v", err, ErrMissingFilePattern)
+	}
+	cfg.FilePattern = `^foo-([0-9.]+)\.tar\.gz$`
+	if err := ValidatePackageConfig("app-misc/foo", cfg); err != nil {
+		t.Errorf("with file_pattern: error = %v", err)
+	}
+}
+
+// TestAnalyzeContent_DetectsFileIndex verifies the analyzer suggests a
+// fileindex schema for a listing of the package's tarballs without asking the
+// LLM, and leaves other content to it.
+func TestAnalyzeContent_DetectsFileIndex(t *testing.T) {
+	llm := &analyzeCountingLLM{}
+	analyzer, err := NewAnalyzer(t.TempDir(),
+		WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerLLMClient(llm),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+	meta := &EbuildMetadata{Package: "app-misc/foo", Version: "1.9"}
+
+	for name, content := range map[string]string{"autoindex": fooAutoindex, "ftp": fooFTPListing} {
+		source := &DataSource{URL: "https://example.com/releases/foo/", Type: "homepage", Headers: map[string]string{"X-Test": "1"}}
+		schema, err := analyzer.analyzeContent(llm, []byte(content), meta, "", source)
+		if err != nil {
+			t.Fatalf("%s: analyzeContent() error = %v", name, err)
+		}
+		if schema.Parser != "fileindex" || schema.URL != source.URL || schema.Headers["X-Test"] != "1" {
+			t.Fatalf("%s: schema = %+v, want a fileindex schema for the source", name, schema)
+		}
+		parser, err := NewFileIndexParser(schema.FilePattern)
+		if err != nil {
+			t.Fatalf("%s: suggested file_pattern: %v", name, err)
+		}
+		if _, err := parser.Parse([]byte(content)); err != nil {
+			t.Errorf("%s: suggested file_pattern does not parse the listing: %v", name, err)
+		}
+	}
+	if llm.calls.Load() != 0 {
+		t.Errorf("the LLM analyzed a directory listing %d time(s)", llm.calls.Load())
+	}
+
+	// A page that merely links a tarball is no listing.
+	page := `<html><head><title>Foo</title></head><body><a href="foo-1.9.tar.gz">Download</a></body></html>`
+	if schema := detectFileIndexSchema([]byte(page), meta, &DataSource{URL: "https://example.com/"}); schema != nil {
+		t.Errorf("detectFileIndexSchema(download page) = %+v, want nil", schema)
+	}
+}
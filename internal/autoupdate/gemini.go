@@ -0,0 +1,280 @@
+// Package autoupdate provides Google Gemini LLM integration for version extraction and schema analysis.
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/httputil"
+	"github.com/obentoo/bentoolkit/internal/common/secrets"
+)
+
+const (
+	// DefaultGeminiEndpoint is the default Generative Language API base URL.
+	DefaultGeminiEndpoint = "https://generativelanguage.googleapis.com/v1beta"
+	// DefaultGeminiModel is the default Gemini model.
+	DefaultGeminiModel = "gemini-1.5-flash"
+)
+
+// GeminiClient implements LLMProvider for Google's Generative Language API.
+type GeminiClient struct {
+	config     LLMConfig
+	httpClient *http.Client
+	apiKey     string
+	baseURL    string
+	// maxBodyBytes caps how many bytes are read from an API response body.
+	// It defaults to httputil.MaxBodyBytes and can be overridden via
+	// WithMaxBodyBytes (R11.2).
+	maxBodyBytes int64
+	// usageRecorder keeps the token usage the API reports, see UsageReporter
+	usageRecorder
+}
+
+// geminiRequest represents the request body for the generateContent method
+type geminiRequest struct {
+	Contents         []geminiContent        `json:"contents"`
+	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
+}
+
+// geminiContent represents a turn in the Gemini conversation
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiPart represents one part of a turn; only text parts are used
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+// geminiGenerationConfig bounds the generated answer. Temperature is sent
+// even when zero: omitting it would select the model's default, not
+// deterministic output.
+type geminiGenerationConfig struct {
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	Temperature     float64 `json:"temperature"`
+}
+
+// geminiResponse represents the response from the generateContent method
+type geminiResponse struct {
+	Candidates    []geminiCandidate `json:"candidates"`
+	UsageMetadata geminiUsage       `json:"usageMetadata"`
+}
+
+// geminiCandidate represents a candidate answer in the Gemini response
+type geminiCandidate struct {
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+// geminiUsage represents token usage information
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// tokenUsage converts the usage to a TokenUsage.
+func (u geminiUsage) tokenUsage() TokenUsage {
+	return TokenUsage{InputTokens: u.PromptTokenCount, OutputTokens: u.CandidatesTokenCount}
+}
+
+// geminiErrorResponse represents an error response from the Gemini API
+type geminiErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// NewGeminiClient creates a new Gemini client from configuration.
+// It validates the configuration and resolves the API key via the unified
+// secrets chain (env → user file → system file).
+func NewGeminiClient(cfg LLMConfig) (*GeminiClient, error) {
+	// Check API key environment variable name
+	if cfg.APIKeyEnv == "" {
+		return nil, fmt.Errorf("%w: api_key_env not specified", ErrLLMNotConfigured)
+	}
+
+	// Resolve the API key through the unified secrets chain, like Claude and
+	// OpenAI; a total miss names the env var and the searched paths.
+	apiKey, found, err := secrets.Lookup(cfg.APIKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s (export %s=... or add it to one of: %s)",
+			ErrLLMAPIKeyMissing, cfg.APIKeyEnv, cfg.APIKeyEnv, strings.Join(secrets.Paths(), ", "))
+	}
+
+	// Set default model if not specified
+	model := cfg.Model
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+
+	// Set default base URL
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultGeminiEndpoint
+	}
+
+	return &GeminiClient{
+		config: LLMConfig{
+			Provider:  "gemini",
+			APIKeyEnv: cfg.APIKeyEnv,
+			Model:     model,
+			BaseURL:   baseURL,
+		},
+		httpClient: &http.Client{
+			Timeout:   DefaultHTTPTimeout,
+			Transport: httputil.BuildTransport(),
+		},
+		apiKey:       apiKey,
+		baseURL:      baseURL,
+		maxBodyBytes: httputil.MaxBodyBytes,
+	}, nil
+}
+
+// WithMaxBodyBytes overrides the maximum number of bytes read from a Gemini API
+// response body and returns the client for chaining. Values <= 0 are ignored so
+// the default (httputil.MaxBodyBytes, 10 MiB) remains in effect (R11.2).
+func (c *GeminiClient) WithMaxBodyBytes(n int64) *GeminiClient {
+	if n > 0 {
+		c.maxBodyBytes = n
+	}
+	return c
+}
+
+// GetModel returns the model name being used by this Gemini client.
+func (c *GeminiClient) GetModel() string {
+	return c.config.Model
+}
+
+// ExtractVersion uses Gemini to extract a version string from content.
+func (c *GeminiClient) ExtractVersion(content []byte, prompt string) (string, error) {
+	// Version extraction needs minimal tokens
+	version, err := c.generate(buildVersionExtractionPrompt(content, prompt), 100)
+	if err != nil {
+		return "", err
+	}
+
+	// Clean up the version string
+	version = cleanVersionString(version)
+	if version == "" {
+		return "", ErrLLMEmptyResponse
+	}
+
+	return version, nil
+}
+
+// AnalyzeContent uses Gemini to analyze content and suggest a parser configuration.
+func (c *GeminiClient) AnalyzeContent(content []byte, meta *EbuildMetadata, hint string) (*SchemaAnalysis, error) {
+	text, err := c.generate(buildSchemaAnalysisPrompt(content, meta, hint), 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the schema analysis from the response
+	return parseSchemaAnalysis(text)
+}
+
+// generate sends message as a single user turn to the model's
+// generateContent method and returns the text of the first candidate. The
+// key travels in the x-goog-api-key header rather than the URL, so it never
+// shows up in an error that quotes the request URL.
+func (c *GeminiClient) generate(message string, maxTokens int) (string, error) {
+	reqBody := geminiRequest{
+		Contents: []geminiContent{
+			{
+				Role:  "user",
+				Parts: []geminiPart{{Text: message}},
+			},
+		},
+		GenerationConfig: geminiGenerationConfig{
+			MaxOutputTokens: maxTokens,
+			Temperature:     0, // Deterministic output
+		},
+	}
+
+	// Marshal request body
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	endpoint := c.baseURL + "/models/" + url.PathEscape(c.config.Model) + ":generateContent"
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(reqJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set required headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	// Send request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrLLMRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	// Read response body, capped at c.maxBodyBytes (R11.2)
+	body, err := readCappedBody(resp.Body, c.maxBodyBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Check for error response
+	if resp.StatusCode != http.StatusOK {
+		var errResp geminiErrorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+			return "", fmt.Errorf("%w: %s (status %d)", ErrLLMRequestFailed, errResp.Error.Message, resp.StatusCode)
+		}
+		return "", fmt.Errorf("%w: status %d", ErrLLMRequestFailed, resp.StatusCode)
+	}
+
+	// Parse response
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	c.record(geminiResp.UsageMetadata.tokenUsage())
+
+	// Extract text from response
+	text := extractTextFromGeminiResponse(geminiResp)
+	if text == "" {
+		return "", ErrLLMEmptyResponse
+	}
+	return text, nil
+}
+
+// SetHTTPClient sets a custom HTTP client (useful for testing)
+func (c *GeminiClient) SetHTTPClient(client *http.Client) {
+	c.httpClient = client
+}
+
+// SetBaseURL sets a custom base URL (useful for testing)
+func (c *GeminiClient) SetBaseURL(url string) {
+	c.baseURL = url
+}
+
+// extractTextFromGeminiResponse joins the text parts of Gemini's first
+// candidate
+func extractTextFromGeminiResponse(resp geminiResponse) string {
+	if len(resp.Candidates) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
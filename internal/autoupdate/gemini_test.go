@@ -0,0 +1,155 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// geminiTextResponse returns a generateContent answer carrying text.
+func geminiTextResponse(text string) geminiResponse {
+	return geminiResponse{
+		Candidates: []geminiCandidate{
+			{Content: geminiContent{Role: "model", Parts: []geminiPart{{Text: text}}}, FinishReason: "STOP"},
+		},
+	}
+}
+
+// newTestGeminiClient returns a Gemini client whose requests go to server.
+func newTestGeminiClient(t *testing.T, server *httptest.Server, model string) *GeminiClient {
+	t.Helper()
+	t.Setenv("GEMINI_TEST_KEY", "test-key")
+	client, err := NewGeminiClient(LLMConfig{APIKeyEnv: "GEMINI_TEST_KEY", Model: model})
+	if err != nil {
+		t.Fatalf("NewGeminiClient() error = %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &mockTransport{server: server}})
+	return client
+}
+
+// TestGeminiExtractVersionRequestFormat verifies the request names the model
+// in the generateContent path, carries the key in x-goog-api-key, and sends
+// the prompt as a single user turn with a deterministic, short answer.
+func TestGeminiExtractVersionRequestFormat(t *testing.T) {
+	var (
+		captured geminiRequest
+		path     string
+		key      string
+		rawQuery string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, key, rawQuery = r.URL.Path, r.Header.Get("x-goog-api-key"), r.URL.RawQuery
+		json.NewDecoder(r.Body).Decode(&captured)                 //nolint:errcheck
+		json.NewEncoder(w).Encode(geminiTextResponse("v1.2.3\n")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	client := newTestGeminiClient(t, server, "")
+	version, err := client.ExtractVersion([]byte("release 1.2.3 is out"), "Extract version")
+	if err != nil || version != "1.2.3" {
+		t.Fatalf("ExtractVersion() = %q, %v; want 1.2.3", version, err)
+	}
+
+	if want := "/v1beta/models/" + DefaultGeminiModel + ":generateContent"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if key != "test-key" || rawQuery != "" {
+		t.Errorf("x-goog-api-key = %q, query = %q; want the key in the header only", key, rawQuery)
+	}
+	if len(captured.Contents) != 1 || captured.Contents[0].Role != "user" || len(captured.Contents[0].Parts) != 1 {
+		t.Fatalf("contents = %+v, want a single user turn with one part", captured.Contents)
+	}
+	text := captured.Contents[0].Parts[0].Text
+	if !strings.Contains(text, "release 1.2.3 is out") || !strings.Contains(text, "Extract version") {
+		t.Errorf("prompt text = %q, want the content and the prompt", text)
+	}
+	if captured.GenerationConfig.MaxOutputTokens != 100 || captured.GenerationConfig.Temperature != 0 {
+		t.Errorf("generationConfig = %+v, want 100 tokens at temperature 0", captured.GenerationConfig)
+	}
+}
+
+// TestGeminiExtractVersionAPIError verifies an API error surfaces its
+// message wrapped in ErrLLMRequestFailed.
+func TestGeminiExtractVersionAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	_, err := newTestGeminiClient(t, server, "gemini-1.5-pro").ExtractVersion([]byte("content"), "")
+	if !errors.Is(err, ErrLLMRequestFailed) || !strings.Contains(err.Error(), "API key not valid") {
+		t.Errorf("ExtractVersion() error = %v, want ErrLLMRequestFailed with the API message", err)
+	}
+}
+
+// TestGeminiExtractVersionEmptyResponse verifies an answer without
+// candidates is ErrLLMEmptyResponse.
+func TestGeminiExtractVersionEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"candidates": []}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	_, err := newTestGeminiClient(t, server, "").ExtractVersion([]byte("content"), "")
+	if !errors.Is(err, ErrLLMEmptyResponse) {
+		t.Errorf("ExtractVersion() error = %v, want %v", err, ErrLLMEmptyResponse)
+	}
+}
+
+// TestGeminiAnalyzeContent verifies the schema analysis is parsed from the
+// answer and requested with the larger token budget.
+func TestGeminiAnalyzeContent(t *testing.T) {
+	var captured geminiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&captured)     //nolint:errcheck
+		json.NewEncoder(w).Encode(geminiTextResponse( //nolint:errcheck
+			`{"parser_type": "json", "path": "tag_name", "confidence": 0.9, "reasoning": "GitHub release"}`))
+	}))
+	defer server.Close()
+
+	client := newTestGeminiClient(t, server, "")
+	analysis, err := client.AnalyzeContent([]byte(`{"tag_name": "v1.0"}`), &EbuildMetadata{Package: "app-misc/foo"}, "")
+	if err != nil {
+		t.Fatalf("AnalyzeContent() error = %v", err)
+	}
+	if analysis.ParserType != "json" || analysis.Path != "tag_name" {
+		t.Errorf("AnalyzeContent() = %+v, want json at tag_name", analysis)
+	}
+	if captured.GenerationConfig.MaxOutputTokens != 1000 {
+		t.Errorf("maxOutputTokens = %d, want 1000", captured.GenerationConfig.MaxOutputTokens)
+	}
+}
+
+// TestNewLLMProvider_Gemini verifies "gemini" routes to a *GeminiClient with
+// the default model, and a missing key is ErrLLMAPIKeyMissing.
+func TestNewLLMProvider_Gemini(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	t.Setenv("GEMINI_TEST_KEY", "test-key")
+	provider, err := NewLLMProvider(LLMConfig{Provider: "gemini", APIKeyEnv: "GEMINI_TEST_KEY"})
+	if err != nil {
+		t.Fatalf("NewLLMProvider() error = %v", err)
+	}
+	if _, ok := provider.(*GeminiClient); !ok {
+		t.Fatalf("NewLLMProvider() = %T, want *GeminiClient", provider)
+	}
+	if provider.GetModel() != DefaultGeminiModel {
+		t.Errorf("GetModel() = %q, want %q", provider.GetModel(), DefaultGeminiModel)
+	}
+
+	_, err = NewLLMProvider(LLMConfig{Provider: "gemini", APIKeyEnv: "GEMINI_UNSET_TEST_KEY"})
+	if !errors.Is(err, ErrLLMAPIKeyMissing) {
+		t.Errorf("NewLLMProvider() without key error = %v, want %v", err, ErrLLMAPIKeyMissing)
+	}
+	_, err = NewLLMProvider(LLMConfig{Provider: "gemini"})
+	if !errors.Is(err, ErrLLMNotConfigured) {
+		t.Errorf("NewLLMProvider() without api_key_env error = %v, want %v", err, ErrLLMNotConfigured)
+	}
+}
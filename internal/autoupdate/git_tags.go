@@ -0,0 +1,200 @@
+// Package autoupdate provides a git tag data source for upstreams that
+// publish nothing but tags in a git repository.
+package autoupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// ErrGitLsRemote is returned when `git ls-remote` cannot list a remote's
+// tags: git is missing, the remote is unreachable, or it is not a repository.
+var ErrGitLsRemote = errors.New("git ls-remote failed")
+
+// gitTagsRefPrefix prefixes every tag ref in `git ls-remote --tags` output.
+const gitTagsRefPrefix = "refs/tags/"
+
+// WithGitExecCommand sets the context-aware exec.Command factory used to run
+// `git ls-remote` for parser="git-tags" packages. It mirrors
+// exec.CommandContext, the default, so tests can substitute the command.
+func WithGitExecCommand(fn func(ctx context.Context, name string, arg ...string) *exec.Cmd) CheckerOption {
+	return func(c *Checker) error {
+		c.gitCommand = fn
+		return nil
+	}
+}
+
+// GitTagsParser extracts the newest version from the output of
+// `git ls-remote --tags`, for upstreams with no release API to query.
+//
+// Each line is "<sha>\trefs/tags/<tag>"; an annotated tag is listed twice,
+// once more with a "^{}" suffix for the commit it points at, so the prefix
+// and suffix are stripped and duplicates dropped. A "v"-style prefix is
+// stripped from each tag, those that are still not valid Gentoo versions are
+// skipped, and the highest per ebuild.CompareVersions wins: tags come sorted
+// by name, which puts 1.10 before 1.9.
+type GitTagsParser struct {
+	// Pattern, when set, keeps only the tags it matches. With a capture group
+	// the first group is the version ("^release-(\d+\.\d+)$"); without one
+	// the whole tag is.
+	Pattern string
+	// compiled is the compiled Pattern, nil when Pattern is empty
+	compiled *regexp.Regexp
+}
+
+// NewGitTagsParser returns a GitTagsParser filtering tags with pattern, which
+// may be empty.
+func NewGitTagsParser(pattern string) (*GitTagsParser, error) {
+	p := &GitTagsParser{Pattern: pattern}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+		}
+		p.compiled = re
+	}
+	return p, nil
+}
+
+// Parse returns the highest version among the listed tags.
+func (p *GitTagsParser) Parse(content []byte) (string, error) {
+	versions, err := p.ExtractVersions(content)
+	if err != nil {
+		return "", err
+	}
+
+	best := ""
+	for _, v := range versions {
+		if !ebuild.IsValidVersion(v) {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(v, best) > 0 {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w: no tag is a valid version", ErrNoVersionFound)
+	}
+	return best, nil
+}
+
+// ExtractVersions returns the version of every tag Pattern keeps, prefix
+// stripped, in listing order, so select, version_constraint and the
+// stable/exclude patterns work on tags as on any other list.
+func (p *GitTagsParser) ExtractVersions(content []byte) ([]string, error) {
+	var versions []string
+	for _, tag := range gitTagNames(content) {
+		version := tag
+		if p.compiled != nil {
+			m := p.compiled.FindStringSubmatch(tag)
+			if m == nil {
+				continue
+			}
+			if len(m) > 1 {
+				version = m[1]
+			}
+		}
+		if version = stripVersionPrefix(strings.TrimSpace(version)); version != "" {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: no matching tags", ErrNoVersionFound)
+	}
+	return versions, nil
+}
+
+// gitTagNames returns the tag names in `git ls-remote --tags` output, without
+// the refs/tags/ prefix or the ^{} peeled-tag suffix, each once.
+func gitTagNames(content []byte) []string {
+	var names []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], gitTagsRefPrefix) {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(fields[1], gitTagsRefPrefix), "^{}")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lsRemoteTags runs `git ls-remote --tags` against remote and returns its
+// output, the content a parser="git-tags" package is parsed from.
+//
+// It gates on the per-host rate limiter like fetchContent, so a batch of
+// packages on one forge still paces its requests; a local path has no host
+// and does not wait. The command then runs under a child of the parent
+// context bounded by opTimeout, with terminal prompts disabled so a remote
+// asking for credentials fails instead of hanging the batch.
+func (c *Checker) lsRemoteTags(remote string, opTimeout time.Duration) ([]byte, error) {
+	if host := gitRemoteHost(remote); host != "" {
+		if err := c.rateLimiter.WaitHTTP(c.ctx, host); err != nil {
+			if ctxErr := c.ctx.Err(); ctxErr != nil {
+				return nil, fmt.Errorf("rate limiter wait cancelled: %w", ctxErr)
+			}
+			return nil, fmt.Errorf("rate limiter wait failed: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, opTimeout)
+	defer cancel()
+
+	command := c.gitCommand
+	if command == nil {
+		command = exec.CommandContext
+	}
+	// "--" keeps a remote beginning with "-" from being read as an option.
+	cmd := command(ctx, "git", "ls-remote", "--tags", "--", remote)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrGitLsRemote, remote, ctx.Err())
+		}
+		return nil, fmt.Errorf("%w: %s: %v: %s", ErrGitLsRemote, remote, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// gitRemoteHost returns the host of a git remote given as a URL
+// ("https://host/repo.git", "ssh://git@host/repo") or in scp form
+// ("git@host:repo.git"), or "" for a local path or file:// URL.
+func gitRemoteHost(remote string) string {
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	}
+	// scp form: a colon before the first slash separates host and path.
+	colon := strings.Index(remote, ":")
+	if colon <= 0 || strings.Contains(remote[:colon], "/") {
+		return ""
+	}
+	host := remote[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return host
+}
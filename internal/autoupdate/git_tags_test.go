@@ -0,0 +1,184 @@
+package autoupdate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// lsRemoteOutput is `git ls-remote --tags` output with an annotated tag (and
+// its peeled ^{} line), tags that sort by name before higher versions, and
+// tags that are not versions.
+const lsRemoteOutput = "1111111111111111111111111111111111111111\trefs/tags/v1.10.0\n" +
+	"2222222222222222222222222222222222222222\trefs/tags/v1.10.0^{}\n" +
+	"3333333333333333333333333333333333333333\trefs/tags/v1.9.2\n" +
+	"4444444444444444444444444444444444444444\trefs/tags/nightly\n" +
+	"5555555555555555555555555555555555555555\trefs/tags/release-2.0\n" +
+	"6666666666666666666666666666666666666666\trefs/heads/main\n"
+
+// TestGitTagsParser covers picking the highest version by Gentoo comparison,
+// a filtering pattern with and without a capture group, and no match.
+func TestGitTagsParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+		wantErr error
+	}{
+		{"highest version", "", "2.0", nil},
+		{"capture group", `^release-([0-9.]+)$`, "2.0", nil},
+		{"filter without group", `^v1\.`, "1.10.0", nil},
+		{"no match", `^stable-`, "", ErrNoVersionFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewGitTagsParser(tt.pattern)
+			if err != nil {
+				t.Fatalf("NewGitTagsParser() error = %v", err)
+			}
+			got, err := p.Parse([]byte(lsRemoteOutput))
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Parse() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := NewGitTagsParser("("); !errors.Is(err, ErrInvalidRegexPattern) {
+		t.Errorf("NewGitTagsParser(\"(\") error = %v, want %v", err, ErrInvalidRegexPattern)
+	}
+}
+
+// TestGitTagNames verifies the prefix and peeled suffix are stripped, each
+// tag is listed once, and other refs are ignored.
+func TestGitTagNames(t *testing.T) {
+	want := []string{"v1.10.0", "v1.9.2", "nightly", "release-2.0"}
+	if got := gitTagNames([]byte(lsRemoteOutput)); !slices.Equal(got, want) {
+		t.Errorf("gitTagNames() = %v, want %v", got, want)
+	}
+}
+
+// TestGitRemoteHost covers URL, scp-form and local remotes.
+func TestGitRemoteHost(t *testing.T) {
+	tests := map[string]string{
+		"https://git.example.com/foo.git": "git.example.com",
+		"ssh://git@example.org:2222/foo":  "example.org",
+		"git@github.com:foo/bar.git":      "github.com",
+		"/srv/git/foo.git":                "",
+		"./foo:bar/baz.git":               "",
+		"file:///srv/git/foo.git":         "",
+	}
+	for remote, want := range tests {
+		if got := gitRemoteHost(remote); got != want {
+			t.Errorf("gitRemoteHost(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+// initTaggedBareRepo creates a bare repository holding the given tags, some
+// annotated, and returns its path.
+func initTaggedBareRepo(t *testing.T, tags ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	tmpDir := t.TempDir()
+	work := filepath.Join(tmpDir, "work")
+	bare := filepath.Join(tmpDir, "upstream.git")
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	if err := os.MkdirAll(work, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	git(work, "init", "-q")
+	git(work, "commit", "-q", "--allow-empty", "-m", "initial")
+	for i, tag := range tags {
+		if i%2 == 0 {
+			git(work, "tag", "-a", "-m", tag, tag)
+		} else {
+			git(work, "tag", tag)
+		}
+	}
+	git(tmpDir, "clone", "-q", "--bare", work, bare)
+	return bare
+}
+
+// TestCheckPackage_GitTags checks a package against a local bare repository:
+// the highest version tag is the upstream version and the update is pending.
+func TestCheckPackage_GitTags(t *testing.T) {
+	bare := initTaggedBareRepo(t, "v1.9.0", "v1.10.0", "nightly", "v1.2.0")
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	createTestEbuild(t, overlayDir, "app-misc/foo", "1.9.0")
+
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(filepath.Join(tmpDir, "config")),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{
+			"app-misc/foo": {URL: bare, Parser: "git-tags"},
+		}}),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	result, err := checker.CheckPackage("app-misc/foo", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "1.10.0" || !result.HasUpdate {
+		t.Errorf("CheckPackage() = %+v, want an update to 1.10.0", result)
+	}
+	if _, ok := checker.Pending().Get("app-misc/foo"); !ok {
+		t.Error("update not added to the pending list")
+	}
+}
+
+// TestCheckPackage_GitTagsCommand verifies the command is built through
+// WithGitExecCommand with the remote after "--", and that a failing command
+// surfaces ErrGitLsRemote.
+func TestCheckPackage_GitTagsCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	createTestEbuild(t, overlayDir, "app-misc/foo", "1.0")
+
+	var gotArgs []string
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(filepath.Join(tmpDir, "config")),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{
+			"app-misc/foo": {URL: "https://git.example.com/foo.git", Parser: "git-tags"},
+		}}),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithGitExecCommand(func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+			gotArgs = append([]string{name}, arg...)
+			return mockExecCommandFailure(ctx, name, arg...)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	_, err = checker.CheckPackage("app-misc/foo", true)
+	if !errors.Is(err, ErrGitLsRemote) {
+		t.Errorf("CheckPackage() error = %v, want %v", err, ErrGitLsRemote)
+	}
+	want := []string{"git", "ls-remote", "--tags", "--", "https://git.example.com/foo.git"}
+	if !slices.Equal(gotArgs, want) {
+		t.Errorf("command = %v, want %v", gotArgs, want)
+	}
+}
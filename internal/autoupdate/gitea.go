@@ -0,0 +1,96 @@
+// Package autoupdate provides Gitea/Forgejo release lookups for ebuild autoupdate.
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidGitea is returned for a gitea source without owner and repo, or
+// with a base URL that is not http(s).
+var ErrInvalidGitea = errors.New("invalid gitea source: needs an http(s) base url, owner and repo")
+
+// ErrInvalidGiteaReleases is returned when the content is not a Gitea release
+// list.
+var ErrInvalidGiteaReleases = errors.New("invalid Gitea releases response")
+
+// GiteaConfig names a repository on a self-hosted Gitea or Forgejo instance.
+// The package URL is the instance's base URL ("https://git.example.org");
+// the releases are read from <base>/api/v1/repos/<owner>/<repo>/releases.
+type GiteaConfig struct {
+	// Owner is the user or organization owning the repository
+	Owner string `toml:"owner"`
+	// Repo is the repository name
+	Repo string `toml:"repo"`
+	// Token, when set, is sent as "Authorization: token <Token>" for
+	// private instances. Like a header value it may reference an allowed
+	// environment variable: token = "${BENTOO_GITEA_TOKEN}".
+	Token string `toml:"token,omitempty"`
+}
+
+// giteaRelease is the subset of a Gitea release object read here.
+type giteaRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// GiteaReleaseParser extracts the tag of the newest release from a Gitea or
+// Forgejo releases listing, which the API returns newest first. Drafts and
+// pre-releases are skipped, as GitHub's /releases/latest does.
+type GiteaReleaseParser struct{}
+
+// Parse returns the tag name of the newest published release.
+func (p *GiteaReleaseParser) Parse(content []byte) (string, error) {
+	var releases []giteaRelease
+	if err := json.Unmarshal(content, &releases); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidGiteaReleases, err)
+	}
+	for _, r := range releases {
+		if r.Draft || r.Prerelease || r.TagName == "" {
+			continue
+		}
+		return r.TagName, nil
+	}
+	return "", fmt.Errorf("%w: no published release", ErrNoVersionFound)
+}
+
+// validateGitea checks a gitea package's base URL and repository.
+func validateGitea(baseURL string, g *GiteaConfig) error {
+	if g == nil || g.Owner == "" || g.Repo == "" {
+		return ErrInvalidGitea
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: got url %q", ErrInvalidGitea, baseURL)
+	}
+	return nil
+}
+
+// giteaReleasesURL returns the releases endpoint of g on the instance at
+// baseURL. A base URL with a path prefix (an instance served under
+// https://example.org/git/) keeps it.
+func giteaReleasesURL(baseURL string, g *GiteaConfig) string {
+	return strings.TrimRight(baseURL, "/") + "/api/v1/repos/" +
+		url.PathEscape(g.Owner) + "/" + url.PathEscape(g.Repo) + "/releases"
+}
+
+// giteaHeaders returns the headers for a gitea request: the package's own,
+// plus the token's Authorization header unless one is configured already.
+func giteaHeaders(cfg *PackageConfig) map[string]string {
+	if cfg.Gitea == nil || cfg.Gitea.Token == "" {
+		return cfg.Headers
+	}
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	for k, v := range cfg.Headers {
+		if strings.EqualFold(k, "Authorization") {
+			return cfg.Headers
+		}
+		headers[k] = v
+	}
+	headers["Authorization"] = "token " + cfg.Gitea.Token
+	return headers
+}
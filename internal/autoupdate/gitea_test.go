@@ -0,0 +1,83 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestCheckPackage_GiteaSelfHosted verifies the releases endpoint is built
+// from the base URL (keeping its path prefix), owner and repo, the token is
+// sent as a Gitea token header, and drafts and pre-releases are skipped.
+func TestCheckPackage_GiteaSelfHosted(t *testing.T) {
+	t.Setenv("BENTOO_GITEA_TOKEN", "s3cret")
+	var (
+		mu   sync.Mutex
+		path string
+		auth string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		mu.Unlock()
+		_, _ = w.Write([]byte(`[
+			{"tag_name":"v3.0.0-rc1","prerelease":true},
+			{"tag_name":"v2.9.0","draft":true},
+			{"tag_name":"v2.8.1"},
+			{"tag_name":"v2.8.0"}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["dev-util/tool"]
+url = "` + srv.URL + `/git/"
+parser = "gitea"
+gitea = { owner = "team", repo = "tool", token = "${BENTOO_GITEA_TOKEN}" }
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "dev-util/tool", "2.8.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("dev-util/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "v2.8.1" || !result.HasUpdate {
+		t.Errorf("UpstreamVersion = %q (HasUpdate %v), want v2.8.1", result.UpstreamVersion, result.HasUpdate)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if path != "/git/api/v1/repos/team/tool/releases" {
+		t.Errorf("requested %q, want the instance's releases endpoint", path)
+	}
+	if auth != "token s3cret" {
+		t.Errorf("Authorization = %q, want %q", auth, "token s3cret")
+	}
+}
+
+// TestValidateGitea verifies a gitea source needs owner, repo and an http(s)
+// base URL.
+func TestValidateGitea(t *testing.T) {
+	tests := []struct {
+		url   string
+		gitea *GiteaConfig
+	}{
+		{"https://git.example.org", nil},
+		{"https://git.example.org", &GiteaConfig{Owner: "team"}},
+		{"git.example.org", &GiteaConfig{Owner: "team", Repo: "tool"}},
+	}
+	for _, tt := range tests {
+		cfg := &PackageConfig{URL: tt.url, Parser: "gitea", Gitea: tt.gitea}
+		if err := ValidatePackageConfig("dev-util/tool", cfg); !errors.Is(err, ErrInvalidGitea) {
+			t.Errorf("url %q, gitea %+v: error = %v, want %v", tt.url, tt.gitea, err, ErrInvalidGitea)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package autoupdate provides GitHub token resolution for ebuild autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/github"
+)
+
+// ErrEmptyTokenFile is returned when a GitHub token file holds no token.
+var ErrEmptyTokenFile = errors.New("token file is empty")
+
+// ReadTokenFile reads a GitHub token from path, a file holding only the
+// token (as `gh auth token > file` writes it); surrounding whitespace is
+// trimmed and a leading "~/" expands to the home directory. A missing,
+// unreadable or empty file is an error: a token file the user names is meant
+// to be used, and silently falling back to anonymous requests would only show
+// up later as rate-limit failures.
+func ReadTokenFile(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("reading token file %s: %w", path, err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%w: %s", ErrEmptyTokenFile, path)
+	}
+	return token, nil
+}
+
+// WithGitHubTokenFile authenticates the checker's GitHub API requests with
+// the token read from path (see ReadTokenFile). An empty path is ignored.
+// NewChecker fails when the file cannot be read.
+func WithGitHubTokenFile(path string) CheckerOption {
+	return func(c *Checker) error {
+		if path == "" {
+			return nil
+		}
+		token, err := ReadTokenFile(path)
+		if err != nil {
+			return err
+		}
+		c.githubTokenFromFile = token
+		return nil
+	}
+}
+
+// WithAnalyzerGitHubTokenFile is WithGitHubTokenFile for the analyzer.
+func WithAnalyzerGitHubTokenFile(path string) AnalyzerOption {
+	return func(a *Analyzer) error {
+		if path == "" {
+			return nil
+		}
+		token, err := ReadTokenFile(path)
+		if err != nil {
+			return err
+		}
+		a.githubTokenFromFile = token
+		return nil
+	}
+}
+
+// applyGitHubToken authenticates client's api.github.com requests. Anonymous
+// access is capped at 60 requests an hour per IP, which a batch run exhausts
+// quickly; GitHub then answers HTTP 403.
+//
+// The first token found wins:
+//
+//  1. the token the client already carries, set explicitly by the caller
+//     (SetGitHubToken on an injected client);
+//  2. fileToken, read from the configured token file (WithGitHubTokenFile);
+//  3. GITHUB_TOKEN, then GH_TOKEN, from the environment or the secrets file
+//     (github.ResolveToken). A resolution error warns and continues with
+//     unauthenticated access.
+func applyGitHubToken(client *RetryableHTTPClient, fileToken string) {
+	if client.GetGitHubToken() != "" {
+		return
+	}
+	if fileToken != "" {
+		client.SetGitHubToken(fileToken)
+		return
+	}
+	token, err := github.ResolveToken()
+	if err != nil {
+		warnLogf("resolving GitHub token: %v; continuing with unauthenticated GitHub API access", err)
+	}
+	if token != "" {
+		client.SetGitHubToken(token)
+	}
+}
@@ -0,0 +1,122 @@
+package autoupdate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTokenFile writes content to a token file in a temp dir.
+func writeTokenFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "github-token")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	return path
+}
+
+// TestReadTokenFile covers trimming, "~/" expansion and the unusable files.
+func TestReadTokenFile(t *testing.T) {
+	if got, err := ReadTokenFile(writeTokenFile(t, "  ghp_file\n")); err != nil || got != "ghp_file" {
+		t.Errorf("ReadTokenFile() = %q, %v; want ghp_file", got, err)
+	}
+
+	withSecretsFile(t, "")
+	home, _ := os.UserHomeDir()
+	if err := os.WriteFile(filepath.Join(home, "token"), []byte("ghp_home"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadTokenFile("~/token"); err != nil || got != "ghp_home" {
+		t.Errorf("ReadTokenFile(~/token) = %q, %v; want ghp_home", got, err)
+	}
+
+	if _, err := ReadTokenFile(writeTokenFile(t, " \n")); !errors.Is(err, ErrEmptyTokenFile) {
+		t.Errorf("empty file: error = %v, want %v", err, ErrEmptyTokenFile)
+	}
+	if _, err := ReadTokenFile(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+// TestGitHubTokenPrecedence pins the order NewChecker and NewAnalyzer take
+// the GitHub token from: a token already set on the client, then the token
+// file, then GITHUB_TOKEN/GH_TOKEN.
+func TestGitHubTokenPrecedence(t *testing.T) {
+	withSecretsFile(t, "")
+	t.Setenv("GITHUB_TOKEN", "ghp_env")
+	t.Setenv("GH_TOKEN", "")
+	tokenFile := writeTokenFile(t, "ghp_file\n")
+
+	tests := []struct {
+		name     string
+		explicit string
+		file     string
+		want     string
+	}{
+		{"explicit over file and env", "ghp_explicit", tokenFile, "ghp_explicit"},
+		{"file over env", "", tokenFile, "ghp_file"},
+		{"env without file", "", "", "ghp_env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewRetryableHTTPClient()
+			client.SetGitHubToken(tt.explicit)
+			checker, err := NewChecker(t.TempDir(),
+				WithConfigDir(t.TempDir()),
+				WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+				WithHTTPClient(client),
+				WithGitHubTokenFile(tt.file),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker: %v", err)
+			}
+			if got := checker.httpClient.GetGitHubToken(); got != tt.want {
+				t.Errorf("checker token = %q, want %q", got, tt.want)
+			}
+
+			client = NewRetryableHTTPClient()
+			client.SetGitHubToken(tt.explicit)
+			analyzer, err := NewAnalyzer(t.TempDir(),
+				WithAnalyzerConfigDir(t.TempDir()),
+				WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+				WithAnalyzerHTTPClient(client),
+				WithAnalyzerGitHubTokenFile(tt.file),
+			)
+			if err != nil {
+				t.Fatalf("NewAnalyzer: %v", err)
+			}
+			if got := analyzer.httpClient.GetGitHubToken(); got != tt.want {
+				t.Errorf("analyzer token = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("GH_TOKEN when GITHUB_TOKEN is unset", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "")
+		t.Setenv("GH_TOKEN", "gho_env")
+		checker, err := NewChecker(t.TempDir(), WithConfigDir(t.TempDir()),
+			WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}))
+		if err != nil {
+			t.Fatalf("NewChecker: %v", err)
+		}
+		if got := checker.httpClient.GetGitHubToken(); got != "gho_env" {
+			t.Errorf("checker token = %q, want gho_env", got)
+		}
+	})
+
+	t.Run("unreadable token file fails construction", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing")
+		if _, err := NewChecker(t.TempDir(), WithConfigDir(t.TempDir()),
+			WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+			WithGitHubTokenFile(missing)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("NewChecker with a missing token file: error = %v, want %v", err, os.ErrNotExist)
+		}
+		if _, err := NewAnalyzer(t.TempDir(), WithAnalyzerConfigDir(t.TempDir()),
+			WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+			WithAnalyzerGitHubTokenFile(missing)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("NewAnalyzer with a missing token file: error = %v, want %v", err, os.ErrNotExist)
+		}
+	})
+}
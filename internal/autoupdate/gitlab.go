@@ -0,0 +1,75 @@
+// Package autoupdate provides GitLab releases discovery for ebuild autoupdate.
+package autoupdate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PriorityGitLab is the priority for a GitLab project's releases API. It
+// ranks with GitHub: below a provided URL, above registries and homepage
+// scraping.
+const PriorityGitLab = 10
+
+// gitlabReleasesPath is the JSON path of the newest release's tag in a
+// GitLab releases API response, which lists releases newest first.
+const gitlabReleasesPath = "[0].tag_name"
+
+// gitlabURLRegex matches a URL on gitlab.com or a self-hosted instance named
+// gitlab.<domain> (gitlab.gnome.org, gitlab.freedesktop.org, ...), capturing
+// the host and the path after it.
+var gitlabURLRegex = regexp.MustCompile(`https?://(gitlab\.com|gitlab\.[a-z0-9.-]+\.[a-z]+)/([^\s"'#?]+)`)
+
+// gitlabPathStops are the path segments after which a GitLab URL no longer
+// names the project: "/-/" routes (archives, releases, tree) and the older
+// repository and uploads routes.
+var gitlabPathStops = []string{"/-/", "/repository/", "/uploads/"}
+
+// findGitLabProject returns the host and the full group/subgroup/project
+// path of the first GitLab project URL in s.
+func findGitLabProject(s string) (host, project string, ok bool) {
+	for _, m := range gitlabURLRegex.FindAllStringSubmatch(s, -1) {
+		path := "/" + m[2]
+		for _, stop := range gitlabPathStops {
+			if i := strings.Index(path, stop); i >= 0 {
+				path = path[:i]
+			}
+		}
+		path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+		// An API URL names a project by id, not by path.
+		if strings.HasPrefix(path, "api/") || strings.Count(path, "/") < 1 {
+			continue
+		}
+		return m[1], path, true
+	}
+	return "", "", false
+}
+
+// discoverGitLabSource attempts to discover a GitLab releases API endpoint.
+// It checks HOMEPAGE and SRC_URI for gitlab.com or gitlab.<domain> project
+// URLs; subgroups are kept, so gitlab.com/group/sub/project queries the
+// group/sub/project project.
+func discoverGitLabSource(meta *EbuildMetadata) *DataSource {
+	host, project, ok := findGitLabProject(meta.Homepage)
+	if !ok {
+		host, project, ok = findGitLabProject(meta.SrcURI)
+	}
+	if !ok {
+		return nil
+	}
+	return createGitLabSource(host, project)
+}
+
+// createGitLabSource creates a releases API data source for project on host.
+// The API takes the project's full path as a single URL-encoded segment, so
+// its slashes are escaped.
+func createGitLabSource(host, project string) *DataSource {
+	return &DataSource{
+		URL:         fmt.Sprintf("https://%s/api/v4/projects/%s/releases", host, url.PathEscape(project)),
+		Type:        "gitlab",
+		Priority:    PriorityGitLab,
+		ContentType: ContentTypeJSON,
+	}
+}
@@ -0,0 +1,108 @@
+package autoupdate
+
+import (
+	"testing"
+)
+
+// gitlabSources returns the sources DiscoverDataSources finds for meta, and
+// the gitlab one among them.
+func gitlabSources(meta *EbuildMetadata, provided string) ([]DataSource, *DataSource) {
+	sources := DiscoverDataSources(meta, provided)
+	for i := range sources {
+		if sources[i].Type == "gitlab" {
+			return sources, &sources[i]
+		}
+	}
+	return sources, nil
+}
+
+// TestDiscoverGitLabSource verifies the releases API URL is derived from
+// gitlab.com and self-hosted homepages and SRC_URIs, with the project path
+// URL-encoded and subgroups kept.
+func TestDiscoverGitLabSource(t *testing.T) {
+	tests := []struct {
+		name string
+		meta EbuildMetadata
+		want string
+	}{
+		{
+			"homepage",
+			EbuildMetadata{Homepage: "https://gitlab.com/group/project"},
+			"https://gitlab.com/api/v4/projects/group%2Fproject/releases",
+		},
+		{
+			"subgroups",
+			EbuildMetadata{Homepage: "https://gitlab.com/group/sub/project/"},
+			"https://gitlab.com/api/v4/projects/group%2Fsub%2Fproject/releases",
+		},
+		{
+			"src_uri archive",
+			EbuildMetadata{
+				Homepage: "https://example.org",
+				SrcURI:   "https://gitlab.com/group/sub/project/-/archive/v1.2.0/project-v1.2.0.tar.bz2",
+			},
+			"https://gitlab.com/api/v4/projects/group%2Fsub%2Fproject/releases",
+		},
+		{
+			"self-hosted",
+			EbuildMetadata{Homepage: "https://gitlab.freedesktop.org/mesa/drm.git"},
+			"https://gitlab.freedesktop.org/api/v4/projects/mesa%2Fdrm/releases",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources, found := gitlabSources(&tt.meta, "")
+			if found == nil || found.URL != tt.want {
+				t.Fatalf("gitlab source = %+v, want %s", found, tt.want)
+			}
+			if found.Priority <= PriorityProvided || found.Priority >= PriorityHomepage {
+				t.Errorf("Priority = %d, want between provided and homepage", found.Priority)
+			}
+			for _, s := range sources {
+				if s.Type == "homepage" && s.URL == tt.meta.Homepage && tt.meta.Homepage != "https://example.org" {
+					t.Errorf("GitLab homepage also listed for scraping: %+v", s)
+				}
+			}
+			schema, err := (&Analyzer{}).analyzeContent(nil, []byte(`[{"tag_name": "v1.2.0"}]`), &tt.meta, "", found)
+			if err != nil || schema.Parser != "json" || schema.Path != "[0].tag_name" {
+				t.Errorf("analyzeContent() = %+v, %v; want json at [0].tag_name", schema, err)
+			}
+		})
+	}
+}
+
+// TestDiscoverGitLabSource_NotAProject verifies URLs that name no project
+// path are not turned into a source.
+func TestDiscoverGitLabSource_NotAProject(t *testing.T) {
+	for _, meta := range []EbuildMetadata{
+		{Homepage: "https://gitlab.com/group"},
+		{SrcURI: "https://gitlab.com/api/v4/projects/1234/repository/archive.tar.gz"},
+		{Homepage: "https://notgitlab.com/group/project"},
+	} {
+		if _, found := gitlabSources(&meta, ""); found != nil {
+			t.Errorf("DiscoverDataSources(%+v) found %+v, want none", meta, found)
+		}
+	}
+}
+
+// TestDiscoverGitLabSource_KeepsProvidedURL verifies a provided URL stays
+// first and unchanged, and is not listed again when it is the API URL, which
+// is then recognized as JSON.
+func TestDiscoverGitLabSource_KeepsProvidedURL(t *testing.T) {
+	meta := &EbuildMetadata{Homepage: "https://gitlab.com/group/project"}
+
+	const custom = "https://gitlab.com/group/project/-/tags?format=atom"
+	sources, found := gitlabSources(meta, custom)
+	if sources[0].URL != custom || sources[0].Type != "provided" {
+		t.Errorf("first source = %+v, want the provided URL", sources[0])
+	}
+	if found == nil {
+		t.Error("gitlab source missing next to a provided URL")
+	}
+
+	const api = "https://gitlab.com/api/v4/projects/group%2Fproject/releases"
+	sources, found = gitlabSources(meta, api)
+	if found != nil || sources[0].Type != "provided" || sources[0].ContentType != ContentTypeJSON {
+		t.Errorf("sources = %+v, want the provided API URL as JSON and no gitlab duplicate", sources)
+	}
+}
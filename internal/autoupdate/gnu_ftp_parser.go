@@ -0,0 +1,91 @@
+// Package autoupdate provides GNU ftp directory listing parsing for ebuild autoupdate.
+package autoupdate
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// gnuHrefRegex captures every href target of an autoindex page. Both the
+// ftp.gnu.org and download.savannah.gnu.org listings are plain Apache
+// autoindex tables, so a link scan is all that is needed.
+var gnuHrefRegex = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+
+// GNUFTPParser extracts the newest release from a GNU-style directory listing
+// such as https://ftp.gnu.org/gnu/<pkg>/ or
+// https://download.savannah.gnu.org/releases/<pkg>/.
+//
+// Only release tarballs named "<Name>-<version>.tar.<ext>" count, where ext is
+// gz, bz2, xz, lz or zst. Detached signatures ("….tar.xz.sig"), checksum
+// files, patches, diffs and other packages sharing the directory never match,
+// and among the remaining versions the highest per ebuild.CompareVersions wins.
+type GNUFTPParser struct {
+	// Name is the tarball base name, usually the GNU package name (e.g. "grep").
+	Name string
+
+	// tarball is the compiled filename matcher, built once by NewGNUFTPParser
+	// and read-only afterwards so one parser can serve concurrent checks. A
+	// literal GNUFTPParser leaves it nil and compiles a local matcher per Parse.
+	tarball *regexp.Regexp
+}
+
+// NewGNUFTPParser returns a parser for tarballs named name-<version>.tar.*.
+// An empty name is taken from the last path segment of listingURL, so
+// "https://ftp.gnu.org/gnu/grep/" needs no explicit name.
+func NewGNUFTPParser(name, listingURL string) (*GNUFTPParser, error) {
+	if name == "" {
+		name = gnuNameFromURL(listingURL)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: gnu-ftp parser needs a path (tarball name) or a listing URL ending in it", ErrMissingPath)
+	}
+	return &GNUFTPParser{Name: name, tarball: gnuTarballRegex(name)}, nil
+}
+
+// gnuTarballRegex builds the release tarball filename matcher for name.
+func gnuTarballRegex(name string) *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(name) + `-(\d[0-9A-Za-z._+-]*?)\.tar\.(?:gz|bz2|xz|lz|zst)$`)
+}
+
+// gnuNameFromURL returns the last non-empty path segment of rawURL.
+func gnuNameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(strings.TrimRight(u.Path, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// Parse returns the highest tarball version linked from the listing.
+func (p *GNUFTPParser) Parse(content []byte) (string, error) {
+	tarball := p.tarball
+	if tarball == nil {
+		tarball = gnuTarballRegex(p.Name)
+	}
+
+	best := ""
+	for _, m := range gnuHrefRegex.FindAllSubmatch(content, -1) {
+		name := path.Base(string(m[1]))
+		vm := tarball.FindStringSubmatch(name)
+		if vm == nil || !ebuild.IsValidVersion(vm[1]) {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(vm[1], best) > 0 {
+			best = vm[1]
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("%w: no %s-<version>.tar.* tarball in listing", ErrNoVersionFound, p.Name)
+	}
+	return best, nil
+}
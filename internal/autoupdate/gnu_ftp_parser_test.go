@@ -0,0 +1,146 @@
+package autoupdate
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// gnuGrepListing is a trimmed https://ftp.gnu.org/gnu/grep/ autoindex page.
+const gnuGrepListing = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">
+<html>
+ <head>
+  <title>Index of /gnu/grep</title>
+ </head>
+ <body>
+<h1>Index of /gnu/grep</h1>
+<table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th></tr>
+<tr><td><a href="/gnu/">Parent Directory</a></td></tr>
+<tr><td><a href="grep-3.9.tar.gz">grep-3.9.tar.gz</a></td><td>2023-03-05 16:41</td></tr>
+<tr><td><a href="grep-3.9.tar.gz.sig">grep-3.9.tar.gz.sig</a></td><td>2023-03-05 16:41</td></tr>
+<tr><td><a href="grep-3.10.tar.xz">grep-3.10.tar.xz</a></td><td>2023-03-22 18:03</td></tr>
+<tr><td><a href="grep-3.10.tar.xz.sig">grep-3.10.tar.xz.sig</a></td><td>2023-03-22 18:03</td></tr>
+<tr><td><a href="grep-3.11.tar.xz">grep-3.11.tar.xz</a></td><td>2023-05-13 04:37</td></tr>
+<tr><td><a href="grep-3.11.tar.xz.sig">grep-3.11.tar.xz.sig</a></td><td>2023-05-13 04:37</td></tr>
+<tr><td><a href="grep-3.12.tar.xz.sig">grep-3.12.tar.xz.sig</a></td><td>2025-04-10 11:02</td></tr>
+<tr><td><a href="grep-3.12.tar.xz.sha256">grep-3.12.tar.xz.sha256</a></td><td>2025-04-10 11:02</td></tr>
+<tr><td><a href="grep-3.8-3.9.diff.gz">grep-3.8-3.9.diff.gz</a></td><td>2023-03-05 16:41</td></tr>
+<tr><td><a href="grep-tools-9.0.tar.gz">grep-tools-9.0.tar.gz</a></td><td>2023-01-01 00:00</td></tr>
+</table>
+</body></html>
+`
+
+// TestGNUFTPParserPicksHighestTarball verifies the highest tarball version is
+// chosen: 3.11, not the lexically larger 3.9, not 3.12 which only has a
+// signature and checksum, and not another package sharing the prefix.
+func TestGNUFTPParserPicksHighestTarball(t *testing.T) {
+	parser, err := NewGNUFTPParser("", "https://ftp.gnu.org/gnu/grep/")
+	if err != nil {
+		t.Fatalf("NewGNUFTPParser() error = %v", err)
+	}
+	if parser.Name != "grep" {
+		t.Errorf("Name = %q, want grep from the listing URL", parser.Name)
+	}
+
+	got, err := parser.Parse([]byte(gnuGrepListing))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "3.11" {
+		t.Errorf("Parse() = %q, want 3.11", got)
+	}
+}
+
+// TestGNUFTPParserConcurrentParse shares one parser across goroutines, as
+// concurrent checks of the same package config do; run with -race.
+func TestGNUFTPParserConcurrentParse(t *testing.T) {
+	parsers := []*GNUFTPParser{{Name: "grep"}}
+	if p, err := NewGNUFTPParser("grep", ""); err != nil {
+		t.Fatalf("NewGNUFTPParser() error = %v", err)
+	} else {
+		parsers = append(parsers, p)
+	}
+
+	for _, parser := range parsers {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got, err := parser.Parse([]byte(gnuGrepListing)); err != nil || got != "3.11" {
+					t.Errorf("Parse() = %q, %v, want 3.11", got, err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// TestGNUFTPParserErrors covers a listing without matching tarballs and a
+// parser with no name to match.
+func TestGNUFTPParserErrors(t *testing.T) {
+	parser := &GNUFTPParser{Name: "sed"}
+	if _, err := parser.Parse([]byte(gnuGrepListing)); !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrNoVersionFound)
+	}
+
+	if _, err := NewGNUFTPParser("", "https://ftp.gnu.org/"); !errors.Is(err, ErrMissingPath) {
+		t.Errorf("NewGNUFTPParser() error = %v, want %v", err, ErrMissingPath)
+	}
+}
+
+// TestDiscoverGNUSource covers discovering the release listing from SRC_URI
+// mirrors and gnu.org homepages.
+func TestDiscoverGNUSource(t *testing.T) {
+	tests := []struct {
+		name string
+		meta EbuildMetadata
+		want string
+	}{
+		{
+			name: "gnu mirror with PN",
+			meta: EbuildMetadata{Package: "sys-apps/grep", SrcURI: "mirror://gnu/${PN}/${P}.tar.xz"},
+			want: "https://ftp.gnu.org/gnu/grep/",
+		},
+		{
+			name: "ftp.gnu.org SRC_URI",
+			meta: EbuildMetadata{Package: "sys-apps/sed", SrcURI: "https://ftp.gnu.org/gnu/sed/sed-4.9.tar.xz"},
+			want: "https://ftp.gnu.org/gnu/sed/",
+		},
+		{
+			name: "savannah SRC_URI",
+			meta: EbuildMetadata{Package: "app-misc/foo", SrcURI: "https://download.savannah.gnu.org/releases/foo/foo-1.0.tar.gz"},
+			want: "https://download.savannah.gnu.org/releases/foo/",
+		},
+		{
+			name: "gnu.org homepage",
+			meta: EbuildMetadata{Package: "sys-apps/gawk", Homepage: "https://www.gnu.org/software/gawk/gawk.html"},
+			want: "https://ftp.gnu.org/gnu/gawk/",
+		},
+		{
+			name: "unrelated",
+			meta: EbuildMetadata{Package: "app-misc/bar", Homepage: "https://example.com/bar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := discoverGNUSource(&tt.meta)
+			if tt.want == "" {
+				if source != nil {
+					t.Errorf("discoverGNUSource() = %+v, want nil", source)
+				}
+				return
+			}
+			if source == nil || source.URL != tt.want || source.Type != "gnu" {
+				t.Errorf("discoverGNUSource() = %+v, want gnu source %s", source, tt.want)
+			}
+		})
+	}
+
+	// The gnu.org homepage must not also be scraped as a generic homepage.
+	sources := DiscoverDataSources(&tests[3].meta, "")
+	if len(sources) != 1 || sources[0].Type != "gnu" {
+		t.Errorf("DiscoverDataSources() = %+v, want only the gnu source", sources)
+	}
+}
@@ -0,0 +1,183 @@
+// Package autoupdate provides Go module proxy discovery for ebuild
+// autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PriorityGoProxy is the priority for the Go module proxy's @latest endpoint
+const PriorityGoProxy = 20
+
+// DefaultGoProxyURL is the module proxy a go-module-proxy source queries.
+const DefaultGoProxyURL = "https://proxy.golang.org"
+
+// goProxyVersionPath is the JSON path of the version in a module proxy
+// @latest response ({"Version":"v1.2.3","Time":"..."}).
+const goProxyVersionPath = "Version"
+
+// ErrInvalidModulePath is returned by EscapeGoModulePath for a path the
+// module proxy protocol cannot carry.
+var ErrInvalidModulePath = errors.New("invalid Go module path")
+
+// goRepoHosts are the code hosts whose module paths are a repository root,
+// host/owner/repo, so a package path under it is cut back to the module. A
+// following major version element (/v2) belongs to the module too.
+var goRepoHosts = map[string]bool{
+	"github.com": true, "gitlab.com": true, "bitbucket.org": true, "codeberg.org": true,
+}
+
+// goVanityHosts are the hosts of Go import paths that are not repository
+// URLs, recognized in a dev-go package's HOMEPAGE.
+var goVanityHosts = map[string]bool{
+	"golang.org": true, "gopkg.in": true, "go.uber.org": true, "google.golang.org": true,
+	"go.etcd.io": true, "k8s.io": true, "sigs.k8s.io": true, "honnef.co": true,
+}
+
+// EscapeGoModulePath encodes a module path for a module proxy URL: each
+// upper-case letter becomes "!" and its lower-case form, so the path is
+// unambiguous on case-insensitive file systems
+// (github.com/BurntSushi/toml is github.com/!burnt!sushi/toml). A path
+// holding "!" or a non-ASCII character is rejected.
+func EscapeGoModulePath(path string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '!' || c >= 0x80:
+			return "", fmt.Errorf("%w: %q", ErrInvalidModulePath, path)
+		case 'A' <= c && c <= 'Z':
+			sb.WriteByte('!')
+			sb.WriteByte(c + ('a' - 'A'))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// goModulePath returns the module path in ref, a module or package import
+// path with or without a scheme ("https://github.com/spf13/cobra/doc"), or
+// "" when it is not one. A version suffix, query or fragment is dropped, and
+// a package path on one of goRepoHosts is cut back to its repository.
+func goModulePath(ref string) string {
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+	if i := strings.IndexAny(ref, "?#@"); i >= 0 {
+		ref = ref[:i]
+	}
+	ref = strings.TrimSuffix(strings.TrimRight(ref, "/"), ".git")
+	segments := strings.Split(ref, "/")
+	if len(segments) < 2 || !strings.Contains(segments[0], ".") {
+		return ""
+	}
+	for _, s := range segments {
+		if s == "" || s == "." || s == ".." || !validGoPathElement(s) {
+			return ""
+		}
+	}
+	segments[0] = strings.ToLower(segments[0])
+	if goRepoHosts[segments[0]] {
+		if len(segments) < 3 {
+			return ""
+		}
+		end := 3
+		if len(segments) > 3 && isGoMajorVersion(segments[3]) {
+			end = 4
+		}
+		segments = segments[:end]
+	}
+	return strings.Join(segments, "/")
+}
+
+// validGoPathElement reports whether s uses only the characters a module
+// path element may: ASCII letters, digits and "-._~".
+func validGoPathElement(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// isGoMajorVersion reports whether s is a major version path element: v2 or
+// higher.
+func isGoMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' || s[1] == '0' || (s[1] == '1' && len(s) == 2) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pkgGoDevModule returns the module path of a pkg.go.dev page URL in s
+// (https://pkg.go.dev/github.com/spf13/cobra@v1.8.0), or "".
+func pkgGoDevModule(s string) string {
+	for _, field := range strings.Fields(s) {
+		field = strings.Trim(field, `"'`)
+		rest, ok := strings.CutPrefix(strings.TrimPrefix(strings.TrimPrefix(field, "https://"), "http://"), "pkg.go.dev/")
+		if !ok {
+			continue
+		}
+		if path := goModulePath(strings.TrimPrefix(rest, "mod/")); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// discoverGoProxySource attempts to discover a Go module's @latest endpoint
+// on the module proxy: from a pkg.go.dev page in HOMEPAGE or SRC_URI, or, for
+// a dev-go package, from a HOMEPAGE that is its import path (a repository on
+// one of goRepoHosts, or a vanity path such as golang.org/x/tools).
+func discoverGoProxySource(meta *EbuildMetadata) *DataSource {
+	for _, s := range []string{meta.Homepage, meta.SrcURI} {
+		if path := pkgGoDevModule(s); path != "" {
+			return createGoProxySource(path)
+		}
+	}
+
+	if !strings.HasPrefix(meta.Package, "dev-go/") {
+		return nil
+	}
+	for _, field := range strings.Fields(meta.Homepage) {
+		path := goModulePath(strings.Trim(field, `"'`))
+		if host, _, _ := strings.Cut(path, "/"); goRepoHosts[host] || goVanityHosts[host] {
+			return createGoProxySource(path)
+		}
+	}
+	return nil
+}
+
+// goProxySourceCovers reports whether rawURL is a page of the module whose
+// proxy endpoint source is: its pkg.go.dev page or its import path.
+func goProxySourceCovers(rawURL string, source DataSource) bool {
+	path := pkgGoDevModule(rawURL)
+	if path == "" {
+		path = goModulePath(rawURL)
+	}
+	covered := createGoProxySource(path)
+	return path != "" && covered != nil && covered.URL == source.URL
+}
+
+// createGoProxySource creates a module proxy data source for the module
+// path, or nil when the path cannot be escaped.
+func createGoProxySource(modulePath string) *DataSource {
+	escaped, err := EscapeGoModulePath(modulePath)
+	if err != nil {
+		return nil
+	}
+	return &DataSource{
+		URL:         DefaultGoProxyURL + "/" + escaped + "/@latest",
+		Type:        "go-module-proxy",
+		Priority:    PriorityGoProxy,
+		ContentType: ContentTypeJSON,
+	}
+}
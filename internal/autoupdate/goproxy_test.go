@@ -0,0 +1,123 @@
+package autoupdate
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestEscapeGoModulePath verifies upper-case letters are bang-encoded and
+// paths the proxy protocol cannot carry are rejected.
+func TestEscapeGoModulePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "golang.org/x/tools", want: "golang.org/x/tools"},
+		{path: "github.com/BurntSushi/toml", want: "github.com/!burnt!sushi/toml"},
+		{path: "github.com/Azure/azure-sdk-for-go/v68", want: "github.com/!azure/azure-sdk-for-go/v68"},
+		{path: "github.com/a/b!c", wantErr: true},
+		{path: "example.com/ünicode", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := EscapeGoModulePath(tt.path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidModulePath) {
+					t.Errorf("EscapeGoModulePath() error = %v, want %v", err, ErrInvalidModulePath)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("EscapeGoModulePath() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// goProxySources returns the sources DiscoverDataSources finds for meta, and
+// the go-module-proxy one among them.
+func goProxySources(meta *EbuildMetadata) ([]DataSource, *DataSource) {
+	sources := DiscoverDataSources(meta, "")
+	for i := range sources {
+		if sources[i].Type == "go-module-proxy" {
+			return sources, &sources[i]
+		}
+	}
+	return sources, nil
+}
+
+// TestDiscoverGoProxySource verifies the @latest endpoint is derived from
+// pkg.go.dev pages and a dev-go package's import path homepage, and that the
+// analyzer reads Version from it.
+func TestDiscoverGoProxySource(t *testing.T) {
+	tests := []struct {
+		name string
+		meta EbuildMetadata
+		want string
+	}{
+		{
+			"pkg.go.dev package page",
+			EbuildMetadata{Package: "app-misc/tool", Homepage: "https://pkg.go.dev/github.com/BurntSushi/toml/internal@v1.3.2#section-readme"},
+			"https://proxy.golang.org/github.com/!burnt!sushi/toml/@latest",
+		},
+		{
+			"pkg.go.dev module page with major version",
+			EbuildMetadata{Package: "app-misc/tool", Homepage: "https://example.com", SrcURI: "https://pkg.go.dev/mod/github.com/go-chi/chi/v5"},
+			"https://proxy.golang.org/github.com/go-chi/chi/v5/@latest",
+		},
+		{
+			"dev-go repository homepage",
+			EbuildMetadata{Package: "dev-go/cobra", Homepage: "https://github.com/spf13/cobra.git"},
+			"https://proxy.golang.org/github.com/spf13/cobra/@latest",
+		},
+		{
+			"dev-go vanity homepage",
+			EbuildMetadata{Package: "dev-go/go-tools", Homepage: "https://example.org/docs https://golang.org/x/tools"},
+			"https://proxy.golang.org/golang.org/x/tools/@latest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources, found := goProxySources(&tt.meta)
+			if found == nil || found.URL != tt.want {
+				t.Fatalf("go-module-proxy source = %+v, want %s", found, tt.want)
+			}
+			if found.Priority != PriorityGoProxy || found.ContentType != ContentTypeJSON {
+				t.Errorf("source = %+v, want a JSON source at PriorityGoProxy", found)
+			}
+			for _, s := range sources {
+				if s.Type == "homepage" && pkgGoDevModule(s.URL) != "" {
+					t.Errorf("pkg.go.dev page also listed for scraping: %+v", s)
+				}
+			}
+			schema, err := (&Analyzer{}).analyzeContent(nil, []byte(`{"Version":"v1.8.0","Time":"2023-11-06T12:37:11Z"}`), &tt.meta, "", found)
+			if err != nil || schema.Parser != "json" || schema.Path != "Version" || schema.URL != tt.want {
+				t.Fatalf("analyzeContent() = %+v, %v; want json at Version", schema, err)
+			}
+			parser, err := NewParserFromConfig(schema)
+			if err != nil {
+				t.Fatalf("NewParserFromConfig() error = %v", err)
+			}
+			if version, err := parser.Parse([]byte(`{"Version":"v1.8.0","Time":"2023-11-06T12:37:11Z","Origin":{"VCS":"git"}}`)); err != nil || version != "v1.8.0" {
+				t.Errorf("Parse() = %q, %v; want v1.8.0", version, err)
+			}
+		})
+	}
+}
+
+// TestDiscoverGoProxySource_NotAModule verifies standard library pages,
+// repository owner pages and the homepages of packages outside dev-go are
+// not turned into a source.
+func TestDiscoverGoProxySource_NotAModule(t *testing.T) {
+	for _, meta := range []EbuildMetadata{
+		{Package: "app-misc/tool", Homepage: "https://pkg.go.dev/net/http"},
+		{Package: "app-misc/tool", Homepage: "https://pkg.go.dev/github.com/spf13"},
+		{Package: "app-misc/tool", Homepage: "https://github.com/spf13/cobra"},
+		{Package: "dev-go/tool", Homepage: "https://example.com/tool"},
+	} {
+		if _, found := goProxySources(&meta); found != nil {
+			t.Errorf("DiscoverDataSources(%+v) found %+v, want no go-module-proxy source", meta, found)
+		}
+	}
+}
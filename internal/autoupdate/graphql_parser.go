@@ -0,0 +1,80 @@
+// Package autoupdate provides generic GraphQL version sources for ebuild autoupdate.
+package autoupdate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrGraphQLResponse is returned when a GraphQL response is not JSON, reports
+// errors, or carries no data.
+var ErrGraphQLResponse = errors.New("invalid GraphQL response")
+
+// graphqlResponse is the standard GraphQL response envelope.
+type graphqlResponse struct {
+	Data   interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQLParser extracts a version from a GraphQL response. Path is a JSON
+// path (with the same grammar as JSONParser, trailing functions included)
+// resolved against the response's "data" member, so a query for
+// { project { latestRelease { tagName } } } uses
+// path = "project.latestRelease.tagName".
+//
+// GraphQL servers answer 200 even when a query fails, putting the reason in
+// "errors"; that is reported as ErrGraphQLResponse with the first message
+// rather than as a confusing missing-path error.
+type GraphQLParser struct {
+	// Path is the JSON path within the response's data
+	Path string
+}
+
+// Parse extracts a version string from a GraphQL JSON response.
+func (p *GraphQLParser) Parse(content []byte) (string, error) {
+	if p.Path == "" {
+		return "", ErrInvalidJSONPath
+	}
+
+	var resp graphqlResponse
+	if err := json.Unmarshal(content, &resp); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrGraphQLResponse, err)
+	}
+	if len(resp.Errors) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrGraphQLResponse, resp.Errors[0].Message)
+	}
+	if resp.Data == nil {
+		return "", fmt.Errorf("%w: no data", ErrGraphQLResponse)
+	}
+	return extractJSONPath(resp.Data, p.Path)
+}
+
+// graphqlRequestBody encodes cfg's query and variables as a GraphQL POST
+// body. Variables are omitted when none are configured.
+func graphqlRequestBody(cfg *PackageConfig) ([]byte, error) {
+	req := struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables,omitempty"`
+	}{Query: strings.TrimSpace(cfg.Query), Variables: cfg.Variables}
+	return json.Marshal(req)
+}
+
+// fetchGraphQL POSTs the package's query to rawURL and returns the response.
+// The configured headers are sent as for any other source, so a dashboard
+// token goes in headers = { Authorization = "Bearer ${...}" }; requests to
+// api.github.com also pick up the GitHub token automatically.
+func (c *Checker) fetchGraphQL(rawURL string, cfg *PackageConfig) ([]byte, error) {
+	body, err := graphqlRequestBody(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode GraphQL request: %w", err)
+	}
+	return c.fetchWith(rawURL, c.operationTimeout(cfg), func(ctx context.Context) (*http.Response, error) {
+		return c.httpClient.PostWithHeadersContext(ctx, rawURL, "application/json", body, cfg.Headers)
+	})
+}
@@ -0,0 +1,93 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestCheckPackage_GraphQL verifies the query and variables are POSTed as a
+// GraphQL request with the configured headers, and the path is resolved
+// within the response's data.
+func TestCheckPackage_GraphQL(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		method string
+		ctype  string
+		auth   string
+		body   struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		method, ctype, auth = r.Method, r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"data":{"project":{"releases":{"nodes":[
+			{"tagName":"v2.3.0"},{"tagName":"v2.4.1"},{"tagName":"v2.4.0"}]}}}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-misc/dash"]
+url = "` + srv.URL + `/graphql"
+parser = "graphql"
+query = "query($name: String!) { project(name: $name) { releases { nodes { tagName } } } }"
+variables = { name = "dash" }
+path = "project.releases.nodes[*].tagName | max"
+headers = { Authorization = "Bearer test-token" }
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/dash", "2.3.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-misc/dash", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "v2.4.1" || !result.HasUpdate {
+		t.Errorf("UpstreamVersion = %q (HasUpdate %v), want v2.4.1", result.UpstreamVersion, result.HasUpdate)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if method != http.MethodPost || ctype != "application/json" {
+		t.Errorf("request = %s with Content-Type %q, want a JSON POST", method, ctype)
+	}
+	if auth != "Bearer test-token" {
+		t.Errorf("Authorization = %q, want the configured header", auth)
+	}
+	if body.Query == "" || body.Variables["name"] != "dash" {
+		t.Errorf("POSTed body = %+v, want the query and its variables", body)
+	}
+}
+
+// TestGraphQLParser_Errors verifies GraphQL-level errors and missing data are
+// reported as ErrGraphQLResponse, and a config without a query is rejected.
+func TestGraphQLParser_Errors(t *testing.T) {
+	p := &GraphQLParser{Path: "project.version"}
+	for _, content := range []string{
+		`{"errors":[{"message":"Field 'project' doesn't exist"}]}`,
+		`{"data":null}`,
+		`not json`,
+	} {
+		if _, err := p.Parse([]byte(content)); !errors.Is(err, ErrGraphQLResponse) {
+			t.Errorf("Parse(%s) error = %v, want %v", content, err, ErrGraphQLResponse)
+		}
+	}
+
+	cfg := &PackageConfig{URL: "https://example.com/graphql", Parser: "graphql", Path: "v"}
+	if err := ValidatePackageConfig("app-misc/dash", cfg); !errors.Is(err, ErrMissingQuery) {
+		t.Errorf("ValidatePackageConfig() error = %v, want %v", err, ErrMissingQuery)
+	}
+}
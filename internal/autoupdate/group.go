@@ -0,0 +1,241 @@
+// Package autoupdate provides lockstep bumps of package groups.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+)
+
+// ErrInvalidGroup is returned for a group value that is not "category/glob".
+var ErrInvalidGroup = errors.New("invalid group: must be \"category/package-glob\"")
+
+// ErrGroupApplyFailed is returned when any member of a group fails to bump;
+// every member bumped so far is rolled back.
+var ErrGroupApplyFailed = errors.New("group bump failed")
+
+// groupSpec parses a group value ("media-plugins/gst-*") into the rename
+// matcher's spec. Unlike a rename, the category must be concrete: a family is
+// bumped inside one category.
+func groupSpec(group string) (*overlay.RenameSpec, error) {
+	category, pattern, ok := strings.Cut(group, "/")
+	if !ok || category == "" || pattern == "" || strings.Contains(pattern, "/") ||
+		strings.ContainsAny(category, "*?[") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGroup, group)
+	}
+	return &overlay.RenameSpec{Category: category, PackagePattern: pattern}, nil
+}
+
+// groupIncludes reports whether pkg is matched by the group value.
+func groupIncludes(group, pkg string) bool {
+	spec, err := groupSpec(group)
+	if err != nil {
+		return false
+	}
+	category, name, _ := strings.Cut(pkg, "/")
+	if category != spec.Category {
+		return false
+	}
+	matched, err := filepath.Match(spec.PackagePattern, name)
+	return err == nil && matched
+}
+
+// GroupMembers returns the packages of group currently at version (ignoring
+// the ebuild revision), sorted. It reuses the overlay rename matcher, so group
+// membership follows exactly the rules `bentoo overlay rename` uses to pick
+// the ebuilds of a package family: a member lagging at another version is not
+// part of the lockstep bump.
+func GroupMembers(overlayPath, group, version string) ([]string, error) {
+	spec, err := groupSpec(group)
+	if err != nil {
+		return nil, err
+	}
+	spec.OldVersion = revisionSuffixRe.ReplaceAllString(version, "")
+
+	matched, err := overlay.NewEbuildMatcher(overlayPath).Match(spec)
+	if err != nil {
+		return nil, fmt.Errorf("matching group %s: %w", group, err)
+	}
+	seen := make(map[string]bool, len(matched.Matches))
+	var members []string
+	for _, m := range matched.Matches {
+		pkg := m.Category + "/" + m.Package
+		if !seen[pkg] {
+			seen[pkg] = true
+			members = append(members, pkg)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// groupPendingUpdate folds update into its group's coordinated entry when pkg
+// belongs to a group. The entry is keyed by the first member in sort order, so
+// whichever member detects the new version, the same single entry is written.
+// A group that matches no other member is left as a plain update.
+func (c *Checker) groupPendingUpdate(update *PendingUpdate) {
+	group := c.config.Packages[update.Package].Group
+	if group == "" {
+		return
+	}
+	members, err := GroupMembers(c.overlayPath, group, update.CurrentVersion)
+	if err != nil {
+		warnLogf("group %s: %v; queuing %s alone", group, err, update.Package)
+		return
+	}
+	if len(members) < 2 {
+		return
+	}
+	update.Package = members[0]
+	update.Group = group
+	update.GroupMembers = members
+}
+
+// groupBump is one member's part of a group apply.
+type groupBump struct {
+	pkg        string
+	oldVersion string
+	manifested bool
+}
+
+// applyGroup bumps every member of a coordinated group entry to newVersion,
+// all or none: the ebuilds are copied and the signatures of members pinning a
+// signing_key checked first, then each Manifest is generated (and with
+// WithApplierRunQA each member scanned by pkgcheck), and only then is the
+// entry validated and compiled. A failure at any step removes every
+// copied ebuild and regenerates the Manifests already touched, so the overlay
+// is left as it was and the entry is marked failed.
+//
+// Members already at or beyond newVersion are skipped; commit and aux
+// substitutions are per-package and do not apply to groups.
+func (a *Applier) applyGroup(leader string, update *PendingUpdate, newVersion string, compile bool, result *ApplyResult) (*ApplyResult, error) {
+	var bumps []*groupBump
+	for _, pkg := range update.GroupMembers {
+		current, err := a.resolveCurrentVersion(pkg)
+		if err != nil || ebuild.CompareVersions(current, newVersion) >= 0 {
+			continue
+		}
+		bumps = append(bumps, &groupBump{pkg: pkg, oldVersion: current})
+	}
+	if len(bumps) == 0 {
+		return a.pruneObsolete(leader, result,
+			fmt.Errorf("%w: every member of group %s is already at %s", ErrObsoletePending, update.Group, newVersion))
+	}
+	result.OldVersion = bumps[0].oldVersion
+
+	fail := func(member string, err error) (*ApplyResult, error) {
+		a.rollbackGroup(bumps, newVersion)
+		result.Error = fmt.Errorf("%w: %s: %w", ErrGroupApplyFailed, member, err)
+		if err := a.pending.SetStatus(leader, StatusFailed, result.Error.Error()); err != nil {
+			result.Error = fmt.Errorf("%w (also failed to update status: %v)", result.Error, err)
+		}
+		return result, result.Error
+	}
+
+	for _, b := range bumps {
+		if err := a.copyEbuild(b.pkg, b.oldVersion, newVersion); err != nil {
+			return fail(b.pkg, fmt.Errorf("failed to copy ebuild: %w", err))
+		}
+	}
+
+	for _, b := range bumps {
+		if err := a.verifyReleaseSignature(b.pkg, newVersion); err != nil {
+			return fail(b.pkg, err)
+		}
+	}
+
+	if a.skipMissingManifest && !a.manifestToolPresent() {
+		if err := a.qaNeedsManifest(leader); err != nil {
+			return fail(leader, err)
+		}
+		result.ManifestSkipped = true
+		result.Success = true
+		result.GroupMembers = bumpedPackages(bumps)
+		return result, nil
+	}
+
+	a.reporter.TaskStage(leader, "manifest")
+	for _, b := range bumps {
+		b.manifested = true
+		if err := a.runManifestWithFix(b.pkg, newVersion, result); err != nil {
+			return fail(b.pkg, fmt.Errorf("%w: %v", ErrManifestFailed, err))
+		}
+	}
+
+	if a.runQA {
+		a.reporter.TaskStage(leader, "qa")
+		for _, b := range bumps {
+			findings, err := a.runQAGate(b.pkg)
+			if findings != "" {
+				result.QASummary = strings.TrimSpace(result.QASummary + "\n" + findings)
+			}
+			if err != nil {
+				return fail(b.pkg, err)
+			}
+		}
+	}
+
+	if err := a.pending.SetStatus(leader, StatusValidated, ""); err != nil {
+		return fail(leader, fmt.Errorf("failed to update status: %w", err))
+	}
+
+	if compile {
+		a.reporter.TaskStage(leader, "compile")
+		for _, b := range bumps {
+			if logPath, err := a.runCompile(b.pkg, newVersion); err != nil {
+				result.LogPath = logPath
+				return fail(b.pkg, err)
+			}
+		}
+	}
+
+	result.Success = true
+	result.GroupMembers = bumpedPackages(bumps)
+	if err := a.pendingDeleteFn(leader); err != nil {
+		warnLogf("pending: failed to remove %s after successful apply: %v "+
+			"(apply itself succeeded; entry can be cleared manually)", leader, err)
+	}
+
+	if a.clean {
+		for _, b := range bumps {
+			if _, err := a.cleanOldEbuild(b.pkg, b.oldVersion, newVersion); err != nil {
+				warnLogf("clean: %v", err)
+				result.CleanWarning = err.Error()
+			}
+		}
+		result.CleanedOldVersion = result.OldVersion
+	}
+	return result, nil
+}
+
+// rollbackGroup removes the new ebuild of every member and regenerates the
+// Manifests already rewritten for newVersion. Failures are logged, never
+// returned: the caller is already reporting the error that caused the rollback.
+func (a *Applier) rollbackGroup(bumps []*groupBump, newVersion string) {
+	for _, b := range bumps {
+		path := a.EbuildPath(b.pkg, newVersion)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			warnLogf("group rollback: failed to remove %s: %v", path, err)
+		}
+		if b.manifested {
+			if err := a.runManifest(b.pkg, b.oldVersion); err != nil {
+				warnLogf("group rollback: failed to regenerate Manifest of %s: %v", b.pkg, err)
+			}
+		}
+	}
+}
+
+// bumpedPackages lists the package names of bumps.
+func bumpedPackages(bumps []*groupBump) []string {
+	pkgs := make([]string, len(bumps))
+	for i, b := range bumps {
+		pkgs[i] = b.pkg
+	}
+	return pkgs
+}
@@ -0,0 +1,150 @@
+package autoupdate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// TestCheckPackageGroupCoordinatedEntry verifies an update found for one
+// member of a group is queued as a single entry for every member at the same
+// version, keyed by the first member, while a lagging member is left out.
+func TestCheckPackageGroupCoordinatedEntry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"1.26.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["media-plugins/gst-plugins-good"]
+url = "` + srv.URL + `"
+parser = "json"
+path = "version"
+group = "media-plugins/gst-plugins-*"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "media-plugins/gst-plugins-good", "1.24.0")
+	createTestEbuild(t, overlay, "media-plugins/gst-plugins-base", "1.24.0-r1")
+	createTestEbuild(t, overlay, "media-plugins/gst-plugins-bad", "1.22.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	if _, err := checker.CheckPackage("media-plugins/gst-plugins-good", true); err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+
+	if _, found := checker.Pending().Get("media-plugins/gst-plugins-good"); found {
+		t.Error("group member must not get its own pending entry")
+	}
+	update, found := checker.Pending().Get("media-plugins/gst-plugins-base")
+	if !found {
+		t.Fatal("expected a coordinated entry keyed by the first member")
+	}
+	want := []string{"media-plugins/gst-plugins-base", "media-plugins/gst-plugins-good"}
+	if !reflect.DeepEqual(update.GroupMembers, want) {
+		t.Errorf("GroupMembers = %v, want %v", update.GroupMembers, want)
+	}
+	if update.NewVersion != "1.26.0" {
+		t.Errorf("NewVersion = %q, want 1.26.0", update.NewVersion)
+	}
+}
+
+// TestApply_GroupAllOrNone verifies a group entry bumps every member, and that
+// a manifest failure on one member rolls back the others.
+func TestApply_GroupAllOrNone(t *testing.T) {
+	members := []string{"media-plugins/gst-plugins-base", "media-plugins/gst-plugins-good"}
+
+	tests := []struct {
+		name   string
+		failAt int64 // 1-based manifest invocation that fails; 0 for none
+	}{
+		{"all members bumped", 0},
+		{"second member fails", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			overlayDir := filepath.Join(tmpDir, "overlay")
+			configDir := filepath.Join(tmpDir, "config")
+			createTestEbuildFile(t, overlayDir, members[0], "1.24.0-r1")
+			createTestEbuildFile(t, overlayDir, members[1], "1.24.0")
+
+			pending, _ := NewPendingList(configDir)
+			pending.Add(PendingUpdate{
+				Package:        members[0],
+				CurrentVersion: "1.24.0-r1",
+				NewVersion:     "1.26.0",
+				Status:         StatusPending,
+				Group:          "media-plugins/gst-plugins-*",
+				GroupMembers:   members,
+			})
+
+			var calls int64
+			applier, err := NewApplier(overlayDir, configDir,
+				WithApplierPendingList(pending),
+				WithExecCommand(func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+					if atomic.AddInt64(&calls, 1) == tt.failAt {
+						return exec.CommandContext(ctx, "false")
+					}
+					return exec.CommandContext(ctx, "true")
+				}),
+			)
+			if err != nil {
+				t.Fatalf("NewApplier failed: %v", err)
+			}
+
+			result, applyErr := applier.Apply(members[0], false)
+			if tt.failAt == 0 {
+				if applyErr != nil || !result.Success {
+					t.Fatalf("Apply() = %v (success %v), want success", applyErr, result.Success)
+				}
+				if !reflect.DeepEqual(result.GroupMembers, members) {
+					t.Errorf("GroupMembers = %v, want %v", result.GroupMembers, members)
+				}
+				for _, pkg := range members {
+					if _, err := os.Stat(applier.EbuildPath(pkg, "1.26.0")); err != nil {
+						t.Errorf("%s not bumped: %v", pkg, err)
+					}
+				}
+				if _, found := pending.Get(members[0]); found {
+					t.Error("applied group entry should be removed from pending")
+				}
+				return
+			}
+
+			if !errors.Is(applyErr, ErrGroupApplyFailed) || !errors.Is(applyErr, ErrManifestFailed) {
+				t.Fatalf("Apply() error = %v, want %v wrapping %v", applyErr, ErrGroupApplyFailed, ErrManifestFailed)
+			}
+			for _, pkg := range members {
+				if _, err := os.Stat(applier.EbuildPath(pkg, "1.26.0")); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("%s bump was not rolled back: %v", pkg, err)
+				}
+			}
+			if update, found := pending.Get(members[0]); !found || update.Status != StatusFailed {
+				t.Errorf("pending entry = %+v, want status %s", update, StatusFailed)
+			}
+		})
+	}
+}
+
+// TestValidateGroup verifies a group that is malformed or does not cover the
+// package itself is rejected.
+func TestValidateGroup(t *testing.T) {
+	for _, group := range []string{"gst-*", "*/gst-*", "media-plugins/other-*"} {
+		cfg := &PackageConfig{URL: "https://example.com", Parser: "json", Path: "v", Group: group}
+		if err := ValidatePackageConfig("media-plugins/gst-plugins-good", cfg); !errors.Is(err, ErrInvalidGroup) {
+			t.Errorf("group %q: error = %v, want %v", group, err, ErrInvalidGroup)
+		}
+	}
+}
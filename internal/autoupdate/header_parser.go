@@ -0,0 +1,119 @@
+// Package autoupdate provides HTTP response header version sources for ebuild
+// autoupdate.
+package autoupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrHeaderNotFound is returned when the response has no value for the
+// header a header parser reads.
+var ErrHeaderNotFound = errors.New("response header not found")
+
+// HeaderParser reads the version from an HTTP response header, for CDNs and
+// download redirectors that name the current release in X-Version or in the
+// file name of Content-Disposition. The checker requests the URL with HEAD,
+// so no body is downloaded, and hands the parser the response headers as a
+// MIME header block ("Name: value" lines), which is also what the content
+// cache keeps.
+//
+// Content-Disposition is reduced to its filename parameter
+// (attachment; filename="app-3.4.5.tar.gz" reads app-3.4.5.tar.gz). Pattern,
+// when set, is matched against the value and its first capture group is the
+// version; without it the whole value is.
+type HeaderParser struct {
+	// Name is the header read, matched case-insensitively
+	Name string
+	// Pattern is an optional regex whose first capture group is the version
+	Pattern string
+	// compiled is the compiled Pattern
+	compiled *regexp.Regexp
+}
+
+// NewHeaderParser creates a parser reading the header name, post-processed
+// by pattern when it is not empty.
+func NewHeaderParser(name, pattern string) (*HeaderParser, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: header parser needs the header name", ErrMissingPath)
+	}
+	p := &HeaderParser{Name: strings.TrimSpace(name), Pattern: pattern}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, ErrNoCaptureGroup
+		}
+		p.compiled = re
+	}
+	return p, nil
+}
+
+// Parse extracts the version from a header block.
+func (p *HeaderParser) Parse(content []byte) (string, error) {
+	header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(content))).ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read response headers: %w", err)
+	}
+	name := textproto.CanonicalMIMEHeaderKey(p.Name)
+	value := strings.TrimSpace(header.Get(name))
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrHeaderNotFound, name)
+	}
+	if name == "Content-Disposition" {
+		if _, params, err := mime.ParseMediaType(value); err == nil && params["filename"] != "" {
+			value = params["filename"]
+		}
+	}
+
+	if p.compiled == nil {
+		return value, nil
+	}
+	matches := p.compiled.FindStringSubmatch(value)
+	if len(matches) < 2 || matches[1] == "" {
+		return "", fmt.Errorf("%w: %s: %q", ErrRegexNoMatch, name, value)
+	}
+	return matches[1], nil
+}
+
+// encodeResponseHeaders writes h as the header block HeaderParser reads,
+// terminated by the blank line that ends a MIME header.
+func encodeResponseHeaders(h http.Header) []byte {
+	var buf bytes.Buffer
+	h.Write(&buf) //nolint:errcheck // a bytes.Buffer write cannot fail
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// fetchHeaders requests rawURL with HEAD and returns the response headers as
+// a header block, through fetchWith so the rate limiting, timeout and status
+// handling are those of any fetch. Redirects are followed, so the headers are
+// the final response's. A server that does not allow HEAD (405) is asked
+// with GET instead, whose body is closed unread.
+func (c *Checker) fetchHeaders(rawURL string, headers map[string]string, opTimeout time.Duration) ([]byte, error) {
+	return c.fetchWith(rawURL, opTimeout, func(ctx context.Context) (*http.Response, error) {
+		resp, err := c.httpClient.HeadWithHeadersContext(ctx, rawURL, headers)
+		if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+			resp.Body.Close()
+			resp, err = c.httpClient.GetWithHeadersContext(ctx, rawURL, headers)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(encodeResponseHeaders(resp.Header)))
+		return resp, nil
+	})
+}
@@ -0,0 +1,146 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestHeaderParser verifies the header is read case-insensitively, that
+// Content-Disposition is reduced to its file name, and that the pattern's
+// first capture group extracts the version from the value.
+func TestHeaderParser(t *testing.T) {
+	block := encodeResponseHeaders(http.Header{
+		"X-Version":           {" 3.4.5 "},
+		"Content-Disposition": {`attachment; filename="app-3.4.5.tar.gz"`},
+		"Location":            {"https://cdn.example.com/app/app-3.4.5.tar.gz"},
+	})
+
+	tests := []struct {
+		name    string
+		header  string
+		pattern string
+		want    string
+		wantErr error
+	}{
+		{name: "plain value", header: "X-Version", want: "3.4.5"},
+		{name: "case-insensitive name", header: "x-version", want: "3.4.5"},
+		{name: "content-disposition file name", header: "Content-Disposition", want: "app-3.4.5.tar.gz"},
+		{name: "content-disposition with pattern", header: "Content-Disposition", pattern: `app-([0-9.]+)\.tar`, want: "3.4.5"},
+		{name: "pattern on other header", header: "Location", pattern: `/app-([0-9.]+)\.tar\.gz$`, want: "3.4.5"},
+		{name: "missing header", header: "X-Release", wantErr: ErrHeaderNotFound},
+		{name: "pattern does not match", header: "X-Version", pattern: `v([0-9.]+)`, wantErr: ErrRegexNoMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewHeaderParser(tt.header, tt.pattern)
+			if err != nil {
+				t.Fatalf("NewHeaderParser() error = %v", err)
+			}
+			got, err := p.Parse(block)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Parse() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewHeaderParser_Invalid verifies a missing header name and an unusable
+// pattern are rejected.
+func TestNewHeaderParser_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		pattern string
+		wantErr error
+	}{
+		{name: "no header name", header: " ", wantErr: ErrMissingPath},
+		{name: "bad regex", header: "X-Version", pattern: `(`, wantErr: ErrInvalidRegexPattern},
+		{name: "no capture group", header: "X-Version", pattern: `[0-9.]+`, wantErr: ErrNoCaptureGroup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHeaderParser(tt.header, tt.pattern); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewHeaderParser() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCheckPackage_Header verifies the checker asks for the headers with HEAD
+// and the configured request headers, and falls back to GET when the server
+// does not allow HEAD.
+func TestCheckPackage_Header(t *testing.T) {
+	for _, allowHead := range []bool{true, false} {
+		name := "head"
+		if !allowHead {
+			name = "get fallback"
+		}
+		t.Run(name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				methods []string
+				auth    string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				methods = append(methods, r.Method)
+				auth = r.Header.Get("Authorization")
+				mu.Unlock()
+				if r.Method == http.MethodHead && !allowHead {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				w.Header().Set("Content-Disposition", `attachment; filename="app-3.4.5.tar.gz"`)
+				_, _ = w.Write([]byte("archive bytes"))
+			}))
+			t.Cleanup(srv.Close)
+
+			content := `["app-misc/app"]
+url = "` + srv.URL + `/latest"
+parser = "header"
+path = "Content-Disposition"
+pattern = 'app-([0-9.]+)\.tar\.gz'
+headers = { Authorization = "Bearer test-token" }
+`
+			overlay, _ := writePackagesTOML(t, content)
+			createTestEbuild(t, overlay, "app-misc/app", "3.4.0")
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("app-misc/app", true)
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != "3.4.5" || !result.HasUpdate {
+				t.Errorf("UpstreamVersion = %q (HasUpdate %v), want 3.4.5", result.UpstreamVersion, result.HasUpdate)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			want := []string{http.MethodHead}
+			if !allowHead {
+				want = append(want, http.MethodGet)
+			}
+			if len(methods) != len(want) || methods[0] != want[0] || methods[len(methods)-1] != want[len(want)-1] {
+				t.Errorf("requests = %v, want %v", methods, want)
+			}
+			if auth != "Bearer test-token" {
+				t.Errorf("Authorization = %q, want the configured header", auth)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package autoupdate provides Helm chart repository index parsing for ebuild
+// autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"gopkg.in/yaml.v3"
+)
+
+// Helm parser errors
+var (
+	// ErrInvalidHelmIndex is returned when the content is not a Helm repository index
+	ErrInvalidHelmIndex = errors.New("invalid Helm repository index")
+	// ErrHelmChartNotFound is returned when the index has no entries for the chart
+	ErrHelmChartNotFound = errors.New("chart not found in Helm repository index")
+)
+
+// helmIndex is the subset of a Helm repository index.yaml read here.
+type helmIndex struct {
+	APIVersion string                      `yaml:"apiVersion"`
+	Entries    map[string][]helmChartEntry `yaml:"entries"`
+}
+
+// helmChartEntry is one published version of a chart.
+type helmChartEntry struct {
+	Version string `yaml:"version"`
+}
+
+// HelmIndexParser extracts the newest version of a chart from a Helm
+// repository index.yaml.
+//
+// The index lists every published version under entries.<chart>. Helm sorts
+// them newest first, but mirrors and hand-maintained indexes do not always
+// keep that order, so the highest version per ebuild.CompareVersions wins
+// rather than the first entry. Pre-release versions ("1.2.0-rc.1") are not
+// valid Gentoo versions and are skipped, matching `helm search repo` without
+// --devel.
+type HelmIndexParser struct {
+	// Chart is the chart name, the key under entries.
+	Chart string
+}
+
+// Parse returns the highest version of p.Chart in the index.
+func (p *HelmIndexParser) Parse(content []byte) (string, error) {
+	var index helmIndex
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidHelmIndex, err)
+	}
+	if index.Entries == nil {
+		return "", fmt.Errorf("%w: no entries", ErrInvalidHelmIndex)
+	}
+
+	entries, ok := index.Entries[p.Chart]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrHelmChartNotFound, p.Chart)
+	}
+
+	best, bestNorm := "", ""
+	for _, entry := range entries {
+		norm := stripVersionPrefix(strings.TrimSpace(entry.Version))
+		if !ebuild.IsValidVersion(norm) {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(norm, bestNorm) > 0 {
+			best, bestNorm = entry.Version, norm
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("%w: no stable version of chart %s", ErrNoVersionFound, p.Chart)
+	}
+	return best, nil
+}
+
+// helmIndexURL returns the index.yaml URL of a Helm repository. A URL already
+// naming a .yaml/.yml file is kept, so both the repository URL helm itself is
+// given ("https://charts.example.com/stable") and the index URL work.
+func helmIndexURL(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return repoURL
+	}
+	if strings.HasSuffix(u.Path, ".yaml") || strings.HasSuffix(u.Path, ".yml") {
+		return repoURL
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/index.yaml"
+	return u.String()
+}
@@ -0,0 +1,106 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// helmIndexSample is a trimmed Helm repository index.yaml. The versions of
+// "mychart" are deliberately out of order and include a pre-release.
+const helmIndexSample = `apiVersion: v1
+entries:
+  mychart:
+    - apiVersion: v2
+      name: mychart
+      version: 1.9.0
+      appVersion: "3.1.0"
+      urls:
+        - https://charts.example.com/mychart-1.9.0.tgz
+    - apiVersion: v2
+      name: mychart
+      version: 1.10.2
+      appVersion: "3.2.0"
+    - apiVersion: v2
+      name: mychart
+      version: 2.0.0-rc.1
+    - apiVersion: v2
+      name: mychart
+      version: 1.10.0
+  otherchart:
+    - name: otherchart
+      version: 9.9.9
+generated: "2026-01-01T00:00:00Z"
+`
+
+// TestHelmIndexParserPicksHighestVersion verifies the highest stable chart
+// version is selected regardless of entry order and other charts.
+func TestHelmIndexParserPicksHighestVersion(t *testing.T) {
+	got, err := (&HelmIndexParser{Chart: "mychart"}).Parse([]byte(helmIndexSample))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "1.10.2" {
+		t.Errorf("Parse() = %q, want 1.10.2", got)
+	}
+
+	if _, err := (&HelmIndexParser{Chart: "missing"}).Parse([]byte(helmIndexSample)); !errors.Is(err, ErrHelmChartNotFound) {
+		t.Errorf("Parse() missing chart error = %v, want %v", err, ErrHelmChartNotFound)
+	}
+	if _, err := (&HelmIndexParser{Chart: "mychart"}).Parse([]byte("{}")); !errors.Is(err, ErrInvalidHelmIndex) {
+		t.Errorf("Parse() empty index error = %v, want %v", err, ErrInvalidHelmIndex)
+	}
+}
+
+// TestCheckPackageHelmRepository verifies a helm package configured with the
+// repository URL fetches its index.yaml.
+func TestCheckPackageHelmRepository(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stable/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(helmIndexSample))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-admin/mychart"]
+url = "` + srv.URL + `/stable/"
+parser = "helm"
+path = "mychart"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-admin/mychart", "1.9.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-admin/mychart", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "1.10.2" || !result.HasUpdate {
+		t.Errorf("CheckPackage() = %q (HasUpdate %v), want update to 1.10.2", result.UpstreamVersion, result.HasUpdate)
+	}
+}
+
+// TestHelmIndexURL covers deriving the index URL from a repository URL.
+func TestHelmIndexURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://charts.example.com", "https://charts.example.com/index.yaml"},
+		{"https://charts.example.com/stable/", "https://charts.example.com/stable/index.yaml"},
+		{"https://charts.example.com/stable/index.yaml", "https://charts.example.com/stable/index.yaml"},
+	}
+	for _, tt := range tests {
+		if got := helmIndexURL(tt.in); got != tt.want {
+			t.Errorf("helmIndexURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package autoupdate provides the check history log and run counters.
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/fileutil"
+)
+
+// historyFileName is the check history log in the config directory.
+const historyFileName = "history.jsonl"
+
+// HistoryEntry is one line of the check history log: the outcome of checking
+// one package in a CheckAll run.
+type HistoryEntry struct {
+	Time      time.Time `json:"time"`
+	Package   string    `json:"package"`
+	Current   string    `json:"current,omitempty"`
+	Upstream  string    `json:"upstream,omitempty"`
+	HasUpdate bool      `json:"has_update,omitempty"`
+	FromCache bool      `json:"from_cache,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// historyLog appends HistoryEntry lines to a JSON Lines file. CheckAll's
+// workers record concurrently, so every append is serialized by mu and made
+// with a single write to an O_APPEND file: a line is never interleaved with
+// another, in this process or in a concurrent bentoo run.
+type historyLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// append writes entry as one line. The file is opened per append rather than
+// held open, so the Checker needs no Close.
+func (h *historyLog) append(entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileutil.CacheFileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close() //nolint:errcheck // the write error is the one to report
+		return fmt.Errorf("failed to append to history log: %w", err)
+	}
+	return f.Close()
+}
+
+// WithHistoryLog makes CheckAll append one line per checked package to
+// history.jsonl in the config directory (see HistoryEntry). Off by default.
+func WithHistoryLog(enabled bool) CheckerOption {
+	return func(c *Checker) error {
+		c.historyEnabled = enabled
+		return nil
+	}
+}
+
+// CheckStats counts the outcomes of the Checker's CheckAll runs so far.
+type CheckStats struct {
+	// Checked is the number of packages checked successfully
+	Checked uint64
+	// Updates is how many of those had a newer upstream version
+	Updates uint64
+	// FromCache is how many of those were answered from the cache
+	FromCache uint64
+	// Failures is the number of packages whose check failed
+	Failures uint64
+}
+
+// checkCounters backs CheckStats. The fields are atomics because CheckAll's
+// workers update them concurrently.
+type checkCounters struct {
+	checked, updates, fromCache, failures atomic.Uint64
+}
+
+// Stats returns a snapshot of the Checker's run counters.
+func (c *Checker) Stats() CheckStats {
+	return CheckStats{
+		Checked:   c.counters.checked.Load(),
+		Updates:   c.counters.updates.Load(),
+		FromCache: c.counters.fromCache.Load(),
+		Failures:  c.counters.failures.Load(),
+	}
+}
+
+// recordCheck counts one CheckAll outcome and, when enabled, appends it to
+// the history log. Safe for concurrent use by the workers. A history write
+// failure is logged, not returned: the check itself succeeded.
+func (c *Checker) recordCheck(pkg string, result *CheckResult, checkErr error) {
+	entry := HistoryEntry{Time: time.Now().UTC(), Package: pkg}
+	if checkErr != nil {
+		c.counters.failures.Add(1)
+		entry.Error = checkErr.Error()
+	} else {
+		c.counters.checked.Add(1)
+		if result.HasUpdate {
+			c.counters.updates.Add(1)
+		}
+		if result.FromCache {
+			c.counters.fromCache.Add(1)
+		}
+		entry.Current = result.CurrentVersion
+		entry.Upstream = result.UpstreamVersion
+		entry.HasUpdate = result.HasUpdate
+		entry.FromCache = result.FromCache
+	}
+
+	if c.history == nil {
+		return
+	}
+	if err := c.history.append(entry); err != nil {
+		warnLogf("%v", err)
+	}
+}
+
+// newHistoryLog returns the history log of configDir.
+func newHistoryLog(configDir string) *historyLog {
+	return &historyLog{path: filepath.Join(configDir, historyFileName)}
+}
@@ -0,0 +1,106 @@
+package autoupdate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckAll_ConcurrentHistoryAndStats runs a parallel CheckAll over many
+// packages and verifies each one produced exactly one well-formed history
+// line and that the counters add up. Run under -race it also covers the
+// counters and the serialized appends.
+func TestCheckAll_ConcurrentHistoryAndStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/fail") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"2.0.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	const total = 60
+	var (
+		toml                strings.Builder
+		wantUpdates, wantOK int
+	)
+	pkgs := make([]string, total)
+	for i := range pkgs {
+		pkgs[i] = fmt.Sprintf("app-misc/pkg%02d", i)
+		path := "/ok"
+		if i%5 == 0 {
+			path = "/fail"
+		}
+		fmt.Fprintf(&toml, "[%q]\nurl = %q\nparser = \"json\"\npath = \"version\"\n\n", pkgs[i], srv.URL+path)
+	}
+	overlay, _ := writePackagesTOML(t, toml.String())
+	for i, pkg := range pkgs {
+		version := "1.0.0"
+		if i%3 == 0 {
+			version = "2.0.0"
+		}
+		createTestEbuild(t, overlay, pkg, version)
+		if i%5 != 0 {
+			wantOK++
+			if version == "1.0.0" {
+				wantUpdates++
+			}
+		}
+	}
+
+	configDir := t.TempDir()
+	checker, err := NewChecker(overlay,
+		WithConfigDir(configDir),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithConcurrency(16),
+		WithHistoryLog(true),
+		WithQuarantineThreshold(0),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	checker.CheckAll(true)
+
+	stats := checker.Stats()
+	if stats.Checked != uint64(wantOK) || stats.Failures != uint64(total-wantOK) || stats.Updates != uint64(wantUpdates) {
+		t.Errorf("Stats() = %+v, want %d checked, %d failures, %d updates", stats, wantOK, total-wantOK, wantUpdates)
+	}
+
+	f, err := os.Open(filepath.Join(configDir, historyFileName))
+	if err != nil {
+		t.Fatalf("history log: %v", err)
+	}
+	defer f.Close()
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("malformed history line %q: %v", scanner.Text(), err)
+		}
+		// Every fifth package (index ending in 0 or 5) has a failing source.
+		wantFail := strings.HasSuffix(entry.Package, "0") || strings.HasSuffix(entry.Package, "5")
+		if (entry.Error != "") != wantFail {
+			t.Errorf("entry %+v has the wrong outcome", entry)
+		}
+		seen[entry.Package]++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading history log: %v", err)
+	}
+	for _, pkg := range pkgs {
+		if seen[pkg] != 1 {
+			t.Errorf("%s has %d history lines, want 1", pkg, seen[pkg])
+		}
+	}
+	if len(seen) != total {
+		t.Errorf("history covers %d packages, want %d", len(seen), total)
+	}
+}
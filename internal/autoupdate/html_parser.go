@@ -132,11 +132,17 @@ func (p *HTMLParser) applyRegex(text string) (string, error) {
 		}
 		p.compiled = re
 	}
+	return extractRegexMatch(p.compiled, text)
+}
 
+// extractRegexMatch returns the first capture group of re in text if it has
+// one and it matched, otherwise the full match. The html and xml parsers
+// narrow their extracted text with it.
+func extractRegexMatch(re *regexp.Regexp, text string) (string, error) {
 	// Find submatch
-	matches := p.compiled.FindStringSubmatch(text)
+	matches := re.FindStringSubmatch(text)
 	if matches == nil {
-		return "", fmt.Errorf("%w: pattern %q did not match text", ErrRegexNoMatch, p.Regex)
+		return "", fmt.Errorf("%w: pattern %q did not match text", ErrRegexNoMatch, re.String())
 	}
 
 	// Return first capture group if present, otherwise full match
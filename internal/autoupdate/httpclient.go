@@ -2,14 +2,17 @@
 package autoupdate
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,8 +28,11 @@ var (
 	ErrMaxRetriesExceeded = errors.New("max retries exceeded")
 	// ErrRequestTimeout is returned when a request times out
 	ErrRequestTimeout = errors.New("request timeout")
+	// ErrServerError is returned when a response has a status the client
+	// retries: a 5xx, a 429, or one RetryConfig adds
+	ErrServerError = errors.New("server error")
 	// ErrResponseTooLarge is returned when an HTTP response body exceeds the
-	// MaxBodyBytes cap.
+	// RetryConfig.MaxBodySize cap.
 	ErrResponseTooLarge = errors.New("response body too large")
 )
 
@@ -61,16 +67,72 @@ type RetryConfig struct {
 	MaxDelay time.Duration
 	// Timeout is the timeout for each individual request (default: 30s)
 	Timeout time.Duration
+	// ForceHTTP1 disables HTTP/2 for every request. Use it when an upstream
+	// misbehaves over HTTP/2 (connection resets, GOAWAY storms).
+	ForceHTTP1 bool
+	// HTTP1Hosts lists hostnames (matched case-insensitively, without port)
+	// whose requests always go over HTTP/1.1 while other hosts keep HTTP/2.
+	HTTP1Hosts []string
+	// MaxIdleConnsPerHost caps the idle keep-alive connections kept per host
+	// (default: httputil.DefaultMaxIdleConnsPerHost).
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle keep-alive connection is retained
+	// (default: httputil.DefaultIdleConnTimeout).
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives closes every connection after a single request.
+	DisableKeepAlives bool
+	// MaxBodySize caps the bytes read from a response body; reading past it
+	// fails with ErrResponseTooLarge, so a URL pointing at a huge file is
+	// rejected instead of buffered (default: httputil.MaxBodyBytes, 10 MiB).
+	// Zero or less selects the default.
+	MaxBodySize int64
+	// Proxy sends every request through this HTTP or HTTPS proxy, e.g.
+	// http://proxy.example.com:3128 (credentials go in its user info). Nil
+	// takes the proxy from HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+	Proxy *url.URL
+	// RetryStatusCodes lists status codes retried besides 429 and the 5xx
+	// range, which always are: 403 for an API rate limiting with it
+	// (GitHub's secondary limits), 408, or 404 for an eventually consistent
+	// mirror.
+	RetryStatusCodes []int
+	// RetryIf, when set, makes the final retry decision for a response. It
+	// gets the decision the status codes made and returns the one acted on,
+	// so it can retry on a condition the status alone does not tell (a 403
+	// whose X-RateLimit-Remaining is 0) or veto a retry. It must not read
+	// the body.
+	RetryIf func(resp *http.Response, retry bool) bool
+}
+
+// maxBodySize returns MaxBodySize, or httputil.MaxBodyBytes when unset.
+func (rc RetryConfig) maxBodySize() int64 {
+	if rc.MaxBodySize > 0 {
+		return rc.MaxBodySize
+	}
+	return httputil.MaxBodyBytes
+}
+
+// transportOptions maps the connection tunables onto httputil's transport
+// options; forceHTTP1 is ORed with ForceHTTP1 so the dedicated HTTP/1.1
+// client can share the same tuning.
+func (rc RetryConfig) transportOptions(forceHTTP1 bool) httputil.TransportOptions {
+	return httputil.TransportOptions{
+		ForceHTTP1:          rc.ForceHTTP1 || forceHTTP1,
+		MaxIdleConnsPerHost: rc.MaxIdleConnsPerHost,
+		IdleConnTimeout:     rc.IdleConnTimeout,
+		DisableKeepAlives:   rc.DisableKeepAlives,
+		Proxy:               rc.Proxy,
+	}
 }
 
 // DefaultRetryConfig returns the default retry configuration.
 // Uses exponential backoff with delays of 1s, 2s, 4s.
 func DefaultRetryConfig() RetryConfig {
 	return RetryConfig{
-		MaxRetries: DefaultMaxRetries,
-		BaseDelay:  DefaultRetryBaseDelay,
-		MaxDelay:   4 * time.Second,
-		Timeout:    DefaultHTTPTimeout,
+		MaxRetries:  DefaultMaxRetries,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    4 * time.Second,
+		Timeout:     DefaultHTTPTimeout,
+		MaxBodySize: httputil.MaxBodyBytes,
 	}
 }
 
@@ -90,8 +152,15 @@ type RetryableHTTPClient struct {
 	defaultHeaders map[string]string
 	// githubToken is the GitHub API token for authentication
 	githubToken string
-	// h1Client performs the HTTP/1.1 fallback retry (nil disables the fallback)
+	// h1Client performs the HTTP/1.1 fallback retry and serves the hosts in
+	// http1Hosts (nil disables both)
 	h1Client *http.Client
+	// http1Hosts is the lower-cased set of RetryConfig.HTTP1Hosts
+	http1Hosts map[string]bool
+	// conditionalEnabled makes GETs conditional; see EnableConditionalCache
+	conditionalEnabled bool
+	// conditionalStore holds the bodies and validators conditional GETs use
+	conditionalStore ConditionalStore
 }
 
 // newDefaultBreaker creates a circuit breaker with the default settings.
@@ -115,19 +184,33 @@ func NewRetryableHTTPClient() *RetryableHTTPClient {
 
 // NewRetryableHTTPClientWithConfig creates a new HTTP client with custom retry configuration.
 // The circuit breaker is enabled by default.
+//
+// The connection tunables in config (ForceHTTP1, MaxIdleConnsPerHost,
+// IdleConnTimeout, DisableKeepAlives, Proxy) shape both the primary transport and the
+// HTTP/1.1 one; requests to a host listed in HTTP1Hosts are routed through the
+// latter.
 func NewRetryableHTTPClientWithConfig(config RetryConfig) *RetryableHTTPClient {
+	var http1Hosts map[string]bool
+	if len(config.HTTP1Hosts) > 0 {
+		http1Hosts = make(map[string]bool, len(config.HTTP1Hosts))
+		for _, h := range config.HTTP1Hosts {
+			http1Hosts[strings.ToLower(strings.TrimSpace(h))] = true
+		}
+	}
+
 	return &RetryableHTTPClient{
 		client: &http.Client{
 			Timeout:   config.Timeout,
-			Transport: httputil.BuildTransport(),
+			Transport: httputil.BuildTransportWithOptions(config.transportOptions(false)),
 		},
 		h1Client: &http.Client{
 			Timeout:   config.Timeout,
-			Transport: httputil.BuildTransportHTTP1(),
+			Transport: httputil.BuildTransportWithOptions(config.transportOptions(true)),
 		},
-		config:    config,
-		breaker:   newDefaultBreaker(),
-		delayFunc: time.Sleep,
+		config:     config,
+		http1Hosts: http1Hosts,
+		breaker:    newDefaultBreaker(),
+		delayFunc:  time.Sleep,
 		defaultHeaders: map[string]string{
 			"User-Agent": defaultUserAgent(),
 		},
@@ -222,7 +305,33 @@ func (c *RetryableHTTPClient) Do(req *http.Request) (*http.Response, error) {
 // DoWithContext executes an HTTP request with retry logic, context support, and circuit
 // breaker protection. Each individual attempt is wrapped by the circuit breaker so that
 // consecutive failures cause the circuit to open and subsequent requests fail fast.
+// A GET is made conditional when EnableConditionalCache is on.
+//
+// The returned response body is wrapped in an http.MaxBytesReader bounded by
+// RetryConfig.MaxBodySize. A read that exceeds the cap yields an
+// *http.MaxBytesError; callers should pass such read errors through
+// classifyBodyReadError so the overflow surfaces as ErrResponseTooLarge.
 func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Request) (*http.Response, error) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	if store := c.conditionalStoreFor(req); store != nil {
+		resp, err = c.doConditional(ctx, req, store)
+	} else {
+		resp, err = c.doWithRetry(ctx, req)
+	}
+	if resp != nil && resp.Body != nil {
+		// Cap the body so an oversized or malicious response cannot exhaust
+		// memory when a caller reads it (R11.1, AD-12).
+		resp.Body = http.MaxBytesReader(nil, resp.Body, c.config.maxBodySize())
+	}
+	return resp, err
+}
+
+// doWithRetry is DoWithContext without the conditional cache: the retry loop
+// itself.
+func (c *RetryableHTTPClient) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
 	var lastErr error
 	var lastResp *http.Response
 
@@ -241,6 +350,13 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 
 		// Clone the request for retry (body needs to be re-readable)
 		reqCopy := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			reqCopy.Body = body
+		}
 
 		// Execute the request, optionally wrapped in the circuit breaker
 		resp, err := c.executeRequest(reqCopy)
@@ -250,21 +366,22 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 				return nil, err
 			}
 			lastErr = err
-			// Check if it's a timeout error
+			// Check if it's a timeout error; the transport error stays in the
+			// chain so callers can still match it too
 			if isTimeoutError(err) {
-				lastErr = fmt.Errorf("%w: %v", ErrRequestTimeout, err)
+				lastErr = fmt.Errorf("%w: %w", ErrRequestTimeout, err)
 			}
 			continue
 		}
 
-		// Check if we should retry based on status code
-		if c.shouldRetry(resp.StatusCode) {
+		// Check if we should retry based on the response
+		if c.shouldRetry(resp) {
 			// Close the response body before retrying
 			if resp.Body != nil {
 				io.Copy(io.Discard, resp.Body) //nolint:errcheck // discarding response body, error is irrelevant
 				resp.Body.Close()
 			}
-			lastErr = fmt.Errorf("server error: status %d", resp.StatusCode)
+			lastErr = fmt.Errorf("%w: status %d", ErrServerError, resp.StatusCode)
 			lastResp = resp
 			continue
 		}
@@ -282,9 +399,11 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 		return resp, nil
 	}
 
-	// All retries exhausted
+	// All retries exhausted. Both errors are wrapped, so errors.Is matches
+	// ErrMaxRetriesExceeded as well as the cause of the last failure
+	// (ErrServerError, ErrRequestTimeout, a transport error).
 	if lastErr != nil {
-		return lastResp, fmt.Errorf("%w: %v", ErrMaxRetriesExceeded, lastErr)
+		return lastResp, fmt.Errorf("%w: %w", ErrMaxRetriesExceeded, lastErr)
 	}
 	return lastResp, ErrMaxRetriesExceeded
 }
@@ -344,24 +463,36 @@ func (c *RetryableHTTPClient) retryOverHTTP1(ctx context.Context, req *http.Requ
 	return h1Resp
 }
 
+// clientFor returns the client that should carry req: the HTTP/1.1 client for
+// a host listed in RetryConfig.HTTP1Hosts, the primary client otherwise. When
+// the HTTP/1.1 client is disabled (e.g. after SetHTTPClient) every request uses
+// the primary client.
+func (c *RetryableHTTPClient) clientFor(req *http.Request) *http.Client {
+	if c.h1Client != nil && c.http1Hosts[strings.ToLower(req.URL.Hostname())] {
+		return c.h1Client
+	}
+	return c.client
+}
+
 // executeRequest performs a single HTTP attempt, optionally through the circuit breaker.
 func (c *RetryableHTTPClient) executeRequest(req *http.Request) (*http.Response, error) {
+	client := c.clientFor(req)
 	if c.breaker == nil {
-		return c.client.Do(req)
+		return client.Do(req)
 	}
 
 	result, err := c.breaker.Execute(func() (interface{}, error) {
-		resp, err := c.client.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			return nil, err
 		}
-		// Treat retryable status codes as circuit-breaker failures
-		if c.shouldRetry(resp.StatusCode) {
+		// Treat retryable responses as circuit-breaker failures
+		if c.shouldRetry(resp) {
 			if resp.Body != nil {
 				io.Copy(io.Discard, resp.Body) //nolint:errcheck
 				resp.Body.Close()
 			}
-			return nil, fmt.Errorf("server error: status %d", resp.StatusCode)
+			return nil, fmt.Errorf("%w: status %d", ErrServerError, resp.StatusCode)
 		}
 		return resp, nil
 	})
@@ -390,26 +521,13 @@ func (c *RetryableHTTPClient) Get(url string) (*http.Response, error) {
 }
 
 // GetWithContext performs an HTTP GET request with retry logic and context support.
-//
-// The returned response body is wrapped in an http.MaxBytesReader bounded by
-// httputil.MaxBodyBytes (10 MiB). A subsequent read that exceeds the cap yields
-// an *http.MaxBytesError; callers should pass such read errors through
-// classifyBodyReadError so the overflow surfaces as ErrResponseTooLarge.
+// Its body is capped like every DoWithContext response.
 func (c *RetryableHTTPClient) GetWithContext(ctx context.Context, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.DoWithContext(ctx, req)
-	if err != nil {
-		return resp, err
-	}
-	if resp != nil && resp.Body != nil {
-		// Cap the body so an oversized or malicious response cannot exhaust
-		// memory when a caller reads it (R11.1, AD-12).
-		resp.Body = http.MaxBytesReader(nil, resp.Body, httputil.MaxBodyBytes)
-	}
-	return resp, nil
+	return c.DoWithContext(ctx, req)
 }
 
 // classifyBodyReadError maps an error returned while reading an HTTP response
@@ -448,9 +566,20 @@ func (c *RetryableHTTPClient) calculateDelay(attempt int) time.Duration {
 	return delay
 }
 
-// shouldRetry determines if a request should be retried based on status code.
-// Retries on 5xx server errors and 429 (Too Many Requests).
-func (c *RetryableHTTPClient) shouldRetry(statusCode int) bool {
+// shouldRetry determines if a request should be retried based on its
+// response. Retries on 5xx server errors, 429 (Too Many Requests) and the
+// RetryStatusCodes, subject to RetryIf.
+func (c *RetryableHTTPClient) shouldRetry(resp *http.Response) bool {
+	retry := c.retryableStatus(resp.StatusCode)
+	if c.config.RetryIf != nil {
+		return c.config.RetryIf(resp, retry)
+	}
+	return retry
+}
+
+// retryableStatus reports whether statusCode is retried by default or listed
+// in RetryStatusCodes.
+func (c *RetryableHTTPClient) retryableStatus(statusCode int) bool {
 	// Retry on server errors (5xx)
 	if statusCode >= 500 && statusCode < 600 {
 		return true
@@ -459,10 +588,11 @@ func (c *RetryableHTTPClient) shouldRetry(statusCode int) bool {
 	if statusCode == http.StatusTooManyRequests {
 		return true
 	}
-	return false
+	return slices.Contains(c.config.RetryStatusCodes, statusCode)
 }
 
-// isTimeoutError checks if an error is a timeout error.
+// isTimeoutError checks if an error is a timeout error, anywhere in its
+// chain.
 func isTimeoutError(err error) bool {
 	if err == nil {
 		return false
@@ -475,7 +605,8 @@ func isTimeoutError(err error) bool {
 	type timeoutError interface {
 		Timeout() bool
 	}
-	if te, ok := err.(timeoutError); ok {
+	var te timeoutError
+	if errors.As(err, &te) {
 		return te.Timeout()
 	}
 	return false
@@ -535,6 +666,37 @@ func (c *RetryableHTTPClient) GetWithHeadersContext(ctx context.Context, url str
 	return c.DoWithContext(ctx, req)
 }
 
+// HeadWithHeadersContext performs an HTTP HEAD request with custom headers,
+// context, and retry logic. Headers are applied exactly as for
+// GetWithHeadersContext.
+func (c *RetryableHTTPClient) HeadWithHeadersContext(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply headers to request
+	c.applyHeaders(req, url, headers)
+
+	return c.DoWithContext(ctx, req)
+}
+
+// PostWithHeadersContext performs an HTTP POST of body with the given
+// Content-Type, custom headers, context, and retry logic. Headers are applied
+// exactly as for GetWithHeadersContext; the body is replayed on each retry.
+func (c *RetryableHTTPClient) PostWithHeadersContext(ctx context.Context, url, contentType string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	// Apply headers to request
+	c.applyHeaders(req, url, headers)
+
+	return c.DoWithContext(ctx, req)
+}
+
 // applyHeaders applies headers to a request in the following order:
 // 1. Default headers (set via SetDefaultHeaders)
 // 2. GitHub token (if URL is GitHub API and token is configured)
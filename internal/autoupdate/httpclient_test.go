@@ -1,6 +1,7 @@
 package autoupdate
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -336,7 +338,7 @@ func TestRetryableHTTPClientMaxRetriesExceeded(t *testing.T) {
 	}
 
 	// Error should indicate max retries exceeded
-	if !containsError(err, ErrMaxRetriesExceeded) {
+	if !errors.Is(err, ErrMaxRetriesExceeded) {
 		t.Errorf("Expected ErrMaxRetriesExceeded, got: %v", err)
 	}
 }
@@ -527,7 +529,7 @@ func TestShouldRetry(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(http.StatusText(tc.statusCode), func(t *testing.T) {
-			result := client.shouldRetry(tc.statusCode)
+			result := client.shouldRetry(&http.Response{StatusCode: tc.statusCode})
 			if result != tc.shouldRetry {
 				t.Errorf("Status %d: expected shouldRetry=%v, got %v",
 					tc.statusCode, tc.shouldRetry, result)
@@ -593,17 +595,6 @@ func TestDefaultRetryConfig(t *testing.T) {
 	}
 }
 
-// containsError checks if err contains target error
-func containsError(err, target error) bool {
-	if err == nil {
-		return false
-	}
-	return err.Error() != "" && target.Error() != "" &&
-		(err == target || err.Error() == target.Error() ||
-			len(err.Error()) > len(target.Error()) &&
-				err.Error()[:len(target.Error())] == target.Error())
-}
-
 // =============================================================================
 // Property-Based Tests for Header Support
 // =============================================================================
@@ -1755,6 +1746,45 @@ func TestGetWithContext_BodyCapAllowsSmallBody(t *testing.T) {
 	}
 }
 
+// TestDoWithContext_MaxBodySize verifies RetryConfig.MaxBodySize replaces the
+// default cap on every request path, GetWithHeadersContext included, and that
+// a body within it still reads in full.
+func TestDoWithContext_MaxBodySize(t *testing.T) {
+	const limit = 4 * 1024
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := limit
+		if r.URL.Path == "/big" {
+			size = 16 * limit
+		}
+		w.Write(bytes.Repeat([]byte("x"), size)) //nolint:errcheck // test server
+	}))
+	defer server.Close()
+
+	client := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second, MaxBodySize: limit})
+	client.SetHTTPClient(server.Client())
+
+	read := func(path string) ([]byte, error) {
+		resp, err := client.GetWithHeadersContext(context.Background(), server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("GetWithHeadersContext(%s) error = %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return body, classifyBodyReadError(err)
+	}
+
+	if body, err := read("/big"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("reading a %d-byte body with a %d-byte cap: %d bytes, error %v; want %v", 16*limit, limit, len(body), err, ErrResponseTooLarge)
+	}
+	if body, err := read("/fits"); err != nil || len(body) != limit {
+		t.Errorf("reading a body at the cap: %d bytes, error %v; want %d bytes", len(body), err, limit)
+	}
+
+	if got := (RetryConfig{}).maxBodySize(); got != httputil.MaxBodyBytes {
+		t.Errorf("unset MaxBodySize = %d, want httputil.MaxBodyBytes", got)
+	}
+}
+
 // TestClassifyBodyReadError covers the error-classification helper directly.
 func TestClassifyBodyReadError(t *testing.T) {
 	if got := classifyBodyReadError(nil); got != nil {
@@ -1910,3 +1940,281 @@ func TestHTTP1FallbackNotUsedOnHTTP1_403(t *testing.T) {
 		t.Errorf("requests = %d, want exactly 1 (an HTTP/1.1 403 must not be retried)", got)
 	}
 }
+
+// =============================================================================
+// Forced HTTP/1.1 and per-host overrides
+// =============================================================================
+
+// newProtoRecordingServer starts an HTTP/2-capable TLS test server that answers
+// every request with 200 and records the protocol of the last request.
+func newProtoRecordingServer(t *testing.T) (*httptest.Server, *atomic.Value) {
+	t.Helper()
+	var proto atomic.Value
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto.Store(r.Proto)
+		fmt.Fprint(w, "ok")
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, &proto
+}
+
+// trustTestServer makes every transport of client trust srv's certificate
+// while keeping the transports bentoolkit built (and their HTTP/2 settings).
+func trustTestServer(t *testing.T, client *RetryableHTTPClient, srv *httptest.Server) {
+	t.Helper()
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	for _, c := range []*http.Client{client.client, client.h1Client} {
+		c.Transport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+}
+
+// getProto issues a GET through client and returns the protocol the server saw.
+func getProto(t *testing.T, client *RetryableHTTPClient, srv *httptest.Server, proto *atomic.Value) string {
+	t.Helper()
+	resp, err := client.GetWithContext(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("GetWithContext: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	resp.Body.Close()
+	return proto.Load().(string)
+}
+
+// TestForceHTTP1NegotiatesHTTP11 checks that ForceHTTP1 yields HTTP/1.1
+// against a server that would otherwise negotiate HTTP/2.
+func TestForceHTTP1NegotiatesHTTP11(t *testing.T) {
+	srv, proto := newProtoRecordingServer(t)
+
+	// Control: the default configuration negotiates HTTP/2.
+	def := NewRetryableHTTPClient()
+	trustTestServer(t, def, srv)
+	if got := getProto(t, def, srv, proto); got != "HTTP/2.0" {
+		t.Fatalf("default client negotiated %s, want HTTP/2.0 (test server must be HTTP/2-capable)", got)
+	}
+
+	cfg := DefaultRetryConfig()
+	cfg.ForceHTTP1 = true
+	forced := NewRetryableHTTPClientWithConfig(cfg)
+	trustTestServer(t, forced, srv)
+	if got := getProto(t, forced, srv, proto); got != "HTTP/1.1" {
+		t.Errorf("ForceHTTP1 client negotiated %s, want HTTP/1.1", got)
+	}
+}
+
+// TestHTTP1HostsOverride checks that only the listed hosts are forced onto
+// HTTP/1.1.
+func TestHTTP1HostsOverride(t *testing.T) {
+	srv, proto := newProtoRecordingServer(t)
+
+	cfg := DefaultRetryConfig()
+	cfg.HTTP1Hosts = []string{"127.0.0.1"}
+	client := NewRetryableHTTPClientWithConfig(cfg)
+	trustTestServer(t, client, srv)
+	if got := getProto(t, client, srv, proto); got != "HTTP/1.1" {
+		t.Errorf("listed host negotiated %s, want HTTP/1.1", got)
+	}
+
+	cfg.HTTP1Hosts = []string{"other.example.com"}
+	client = NewRetryableHTTPClientWithConfig(cfg)
+	trustTestServer(t, client, srv)
+	if got := getProto(t, client, srv, proto); got != "HTTP/2.0" {
+		t.Errorf("unlisted host negotiated %s, want HTTP/2.0", got)
+	}
+}
+
+// TestRetryConfigConnectionTunables checks that the pool tunables reach both
+// transports.
+func TestRetryConfigConnectionTunables(t *testing.T) {
+	cfg := DefaultRetryConfig()
+	cfg.MaxIdleConnsPerHost = 3
+	cfg.IdleConnTimeout = 5 * time.Second
+	cfg.DisableKeepAlives = true
+	client := NewRetryableHTTPClientWithConfig(cfg)
+
+	for name, c := range map[string]*http.Client{"primary": client.client, "http1": client.h1Client} {
+		tr := c.Transport.(*http.Transport)
+		if tr.MaxIdleConnsPerHost != 3 || tr.IdleConnTimeout != 5*time.Second || !tr.DisableKeepAlives {
+			t.Errorf("%s transport: MaxIdleConnsPerHost=%d IdleConnTimeout=%v DisableKeepAlives=%v",
+				name, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout, tr.DisableKeepAlives)
+		}
+	}
+}
+
+// TestRetryableHTTPClient_Proxy verifies RetryConfig.Proxy routes requests
+// through the proxy, on the HTTP/1.1 client too, with the upstream's absolute
+// URL in the request line.
+func TestRetryableHTTPClient_Proxy(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.URL.String())
+		mu.Unlock()
+		w.Write([]byte(`{"version":"1.2.3"}`)) //nolint:errcheck
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	config := DefaultRetryConfig()
+	config.MaxRetries = 0
+	config.Proxy = proxyURL
+	config.HTTP1Hosts = []string{"h1.example.invalid"}
+	client := NewRetryableHTTPClientWithConfig(config)
+
+	for _, target := range []string{"http://upstream.example.invalid/releases", "http://h1.example.invalid/feed"} {
+		resp, err := client.Get(target)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", target, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != `{"version":"1.2.3"}` {
+			t.Errorf("Get(%s) body = %q, want the proxy's answer", target, body)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 || seen[0] != "http://upstream.example.invalid/releases" || seen[1] != "http://h1.example.invalid/feed" {
+		t.Errorf("proxy saw %v, want both upstream URLs", seen)
+	}
+}
+
+// TestRetryableHTTPClient_ErrorsIs verifies an exhausted retry loop wraps
+// both ErrMaxRetriesExceeded and the last attempt's cause, so callers match
+// either with errors.Is instead of comparing messages.
+func TestRetryableHTTPClient_ErrorsIs(t *testing.T) {
+	t.Run("server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		client := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 1, Timeout: 5 * time.Second})
+		client.SetDelayFunc(func(time.Duration) {})
+
+		resp, err := client.Get(server.URL)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if !errors.Is(err, ErrMaxRetriesExceeded) || !errors.Is(err, ErrServerError) {
+			t.Errorf("error = %v, want ErrMaxRetriesExceeded wrapping ErrServerError", err)
+		}
+		if errors.Is(err, ErrRequestTimeout) {
+			t.Errorf("error = %v, matches ErrRequestTimeout", err)
+		}
+		wrapped := fmt.Errorf("fetching %s: %w", server.URL, err)
+		if !errors.Is(wrapped, ErrMaxRetriesExceeded) || !errors.Is(wrapped, ErrServerError) {
+			t.Errorf("wrapped error = %v, lost its sentinels", wrapped)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+		client := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 20 * time.Millisecond})
+
+		_, err := client.Get(server.URL) //nolint:bodyclose // the request fails, there is no body
+		if !errors.Is(err, ErrMaxRetriesExceeded) || !errors.Is(err, ErrRequestTimeout) {
+			t.Errorf("error = %v, want ErrMaxRetriesExceeded wrapping ErrRequestTimeout", err)
+		}
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) || !urlErr.Timeout() {
+			t.Errorf("error = %v, does not wrap the transport's *url.Error", err)
+		}
+	})
+}
+
+// netTimeoutError is a transport error that only its Timeout method marks
+// as a timeout, like a net.OpError for an i/o timeout.
+type netTimeoutError struct{}
+
+func (netTimeoutError) Error() string { return "i/o timeout" }
+func (netTimeoutError) Timeout() bool { return true }
+
+// TestIsTimeoutError_Wrapped verifies a timeout is recognized anywhere in the
+// error chain, not only as the outermost error.
+func TestIsTimeoutError_Wrapped(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", netTimeoutError{}, true},
+		{"wrapped timeout", fmt.Errorf("dial: %w", netTimeoutError{}), true},
+		{"url error", &url.Error{Op: "Get", URL: "https://example.com", Err: netTimeoutError{}}, true},
+		{"connection refused", fmt.Errorf("dial: %w", errors.New("connection refused")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTimeoutError(tt.err); got != tt.want {
+				t.Errorf("isTimeoutError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryableHTTPClient_RetryStatusCodes verifies a 403 is retried only
+// once RetryStatusCodes adds it, and that RetryIf decides last: retrying on a
+// header, or vetoing a default retry.
+func TestRetryableHTTPClient_RetryStatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		config       RetryConfig
+		wantRequests int32
+	}{
+		{name: "403 not retried by default", status: http.StatusForbidden, wantRequests: 1},
+		{name: "403 retried when added", status: http.StatusForbidden,
+			config: RetryConfig{RetryStatusCodes: []int{http.StatusRequestTimeout, http.StatusForbidden}}, wantRequests: 3},
+		{name: "other code added", status: http.StatusForbidden,
+			config: RetryConfig{RetryStatusCodes: []int{http.StatusNotFound}}, wantRequests: 1},
+		{name: "RetryIf retries on header", status: http.StatusForbidden,
+			config: RetryConfig{RetryIf: func(resp *http.Response, retry bool) bool {
+				return retry || resp.Header.Get("X-RateLimit-Remaining") == "0"
+			}}, wantRequests: 3},
+		{name: "RetryIf vetoes 503", status: http.StatusServiceUnavailable,
+			config: RetryConfig{RetryIf: func(resp *http.Response, retry bool) bool {
+				return retry && resp.StatusCode != http.StatusServiceUnavailable
+			}}, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			config := tt.config
+			config.MaxRetries = 2
+			config.Timeout = 5 * time.Second
+			client := NewRetryableHTTPClientWithConfig(config)
+			client.SetDelayFunc(func(time.Duration) {})
+
+			resp, err := client.Get(server.URL)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if retried := tt.wantRequests > 1; retried != errors.Is(err, ErrMaxRetriesExceeded) {
+				t.Errorf("error = %v, want ErrMaxRetriesExceeded only when retried", err)
+			}
+			if tt.wantRequests == 1 && (resp == nil || resp.StatusCode != tt.status) {
+				t.Errorf("response = %v, want the %d", resp, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package autoupdate provides aggregation functions for JSON path expressions.
+package autoupdate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// jsonPathFuncs are the functions a JSON path may end with. Each reduces the
+// array selected by the path to a single value.
+//
+// The grammar is:
+//
+//	expr     = path [ "|" function ]
+//	path     = segment { "." segment | "[" index "]" | "[*]" }
+//	function = "length" | "first" | "last" | "max"
+//
+// "[*]" may appear anywhere in path and maps the rest of the path over every
+// element of the array it follows, so "tags[*].name | max" is the highest
+// name of all tags. Without "[*]" the path itself must select an array, as in
+// "releases | last". Elements that lack the rest of the path, or whose value
+// is not a scalar, are skipped rather than failing the whole expression.
+var jsonPathFuncs = map[string]func([]string) (string, error){
+	"length": func(values []string) (string, error) {
+		return strconv.Itoa(len(values)), nil
+	},
+	"first": func(values []string) (string, error) {
+		if len(values) == 0 {
+			return "", fmt.Errorf("%w: first of empty array", ErrJSONPathNotFound)
+		}
+		return values[0], nil
+	},
+	"last": func(values []string) (string, error) {
+		if len(values) == 0 {
+			return "", fmt.Errorf("%w: last of empty array", ErrJSONPathNotFound)
+		}
+		return values[len(values)-1], nil
+	},
+	"max": maxVersion,
+}
+
+// splitJSONPathFunc splits a JSON path expression into the path and the name
+// of its trailing function ("" when there is none). An unknown function name
+// is an ErrInvalidJSONPath, so a typo is caught when the config is validated
+// rather than at the first check.
+func splitJSONPathFunc(expr string) (string, string, error) {
+	path, fn, found := strings.Cut(expr, "|")
+	if !found {
+		return expr, "", nil
+	}
+	path = strings.TrimSpace(path)
+	fn = strings.TrimSpace(fn)
+	if _, ok := jsonPathFuncs[fn]; !ok {
+		return "", "", fmt.Errorf("%w: unknown function %q (want length, first, last or max)", ErrInvalidJSONPath, fn)
+	}
+	return path, fn, nil
+}
+
+// validateJSONPathExpr checks the syntax of a JSON path expression, including
+// any trailing function, without needing a document.
+func validateJSONPathExpr(expr string) error {
+	path, _, err := splitJSONPathFunc(expr)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return nil // function applied to the document root
+	}
+	// "[*]" is only understood by collectJSONPath; any index parses alike.
+	_, err = parseJSONPath(strings.ReplaceAll(path, "[*]", "[0]"))
+	return err
+}
+
+// evalJSONPathFunc resolves path against data and reduces the array it
+// selects with the named function.
+func evalJSONPathFunc(data interface{}, path, fn string) (string, error) {
+	items, err := collectJSONPath(data, path)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := toString(item); ok {
+			values = append(values, s)
+		}
+	}
+	return jsonPathFuncs[fn](values)
+}
+
+// collectJSONPath resolves a path that selects many values: either one
+// containing "[*]", or one that ends at an array. The first "[*]" splits the
+// path; the part before it must select an array and the remainder (which may
+// hold further "[*]") is resolved against each element, dropping elements
+// where it does not exist.
+func collectJSONPath(data interface{}, path string) ([]interface{}, error) {
+	prefix, rest, wildcard := strings.Cut(path, "[*]")
+	arrVal, err := navigateJSONPath(data, strings.TrimSuffix(prefix, "."))
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := arrVal.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array at %q", ErrJSONPathNotFound, strings.TrimSuffix(prefix, "."))
+	}
+	if !wildcard {
+		return arr, nil
+	}
+
+	rest = strings.TrimPrefix(rest, ".")
+	var items []interface{}
+	for _, elem := range arr {
+		if strings.Contains(rest, "[*]") {
+			sub, err := collectJSONPath(elem, rest)
+			if err != nil {
+				continue
+			}
+			items = append(items, sub...)
+			continue
+		}
+		val, err := navigateJSONPath(elem, rest)
+		if err != nil {
+			continue
+		}
+		items = append(items, val)
+	}
+	return items, nil
+}
+
+// maxVersion returns the highest of values by ebuild version order, compared
+// after stripping a tag prefix such as "v". The value is returned as found
+// ("v2.0.0", not "2.0.0"), leaving normalization to the usual transforms.
+// Values that are not versions at all are ignored.
+func maxVersion(values []string) (string, error) {
+	best, bestNorm := "", ""
+	for _, v := range values {
+		norm := stripVersionPrefix(strings.TrimSpace(v))
+		if !ebuild.IsValidVersion(norm) {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(norm, bestNorm) > 0 {
+			best, bestNorm = v, norm
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w: no version in array for max", ErrNoVersionFound)
+	}
+	return best, nil
+}
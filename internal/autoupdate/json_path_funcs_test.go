@@ -0,0 +1,84 @@
+package autoupdate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestJSONParser_PathFunctions verifies the trailing path functions, and that
+// they compose with the navigation and "[*]" mapping before them.
+func TestJSONParser_PathFunctions(t *testing.T) {
+	doc := []byte(`{
+		"data": {"releases": ["1.9.0", "v1.10.2", "1.10.0", "nightly"]},
+		"tags": [
+			{"name": "v0.8.0"},
+			{"name": "v0.9.1"},
+			{"label": "no name"},
+			{"name": "v0.9.0"}
+		]
+	}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		// max compares as versions ("1.10.2" > "1.9.0") and skips "nightly".
+		{"data.releases | max", "v1.10.2"},
+		{"data.releases|last", "nightly"},
+		{"data.releases | first", "1.9.0"},
+		{"data.releases | length", "4"},
+		// [*] maps the rest of the path; elements without it are skipped.
+		{"tags[*].name | last", "v0.9.0"},
+		{"tags[*].name | max", "v0.9.1"},
+		{"tags[*].name | length", "3"},
+	}
+	for _, tt := range tests {
+		got, err := (&JSONParser{Path: tt.path}).Parse(doc)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestJSONParser_PathFunctionErrors covers unknown functions, a path that
+// does not select an array, and max over an array with no versions.
+func TestJSONParser_PathFunctionErrors(t *testing.T) {
+	doc := []byte(`{"version": "1.0", "names": ["alpha", "beta"]}`)
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"names | newest", ErrInvalidJSONPath},
+		{"version | last", ErrJSONPathNotFound},
+		{"names | max", ErrNoVersionFound},
+	}
+	for _, tt := range tests {
+		if _, err := (&JSONParser{Path: tt.path}).Parse(doc); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+
+	cfg := &PackageConfig{URL: "https://example.com", Parser: "json", Path: "tags[*].name | latest"}
+	if err := ValidatePackageConfig("app-misc/foo", cfg); !errors.Is(err, ErrInvalidJSONPath) {
+		t.Errorf("ValidatePackageConfig() error = %v, want %v", err, ErrInvalidJSONPath)
+	}
+}
+
+// TestJSONVersionHistory_PathFunction verifies a versions_path function
+// reduces the history to the single selected version.
+func TestJSONVersionHistory_PathFunction(t *testing.T) {
+	e := &JSONVersionHistoryExtractor{VersionsPath: "[*].tag_name | max"}
+	got, err := e.ExtractVersions([]byte(`[{"tag_name":"2.0.1"},{"tag_name":"2.1.0"},{"tag_name":"2.0.9"}]`))
+	if err != nil {
+		t.Fatalf("ExtractVersions() error = %v", err)
+	}
+	if want := []string{"2.1.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractVersions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+// Package autoupdate provides JSONP unwrapping for JSON version sources.
+package autoupdate
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// jsonpRe matches a JSONP body: an optional "/**/" guard (served by some
+// APIs against content sniffing), a JavaScript callback name, possibly dotted
+// ("jQuery.cb", "window.handlers.v"), the parenthesized payload and an
+// optional trailing semicolon.
+var jsonpRe = regexp.MustCompile(`(?s)^\s*(?:/\*\*/\s*)?[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*\s*\((.*)\)\s*;?\s*$`)
+
+// unwrapJSONP returns the payload of a JSONP body such as `callback({...});`,
+// or content unchanged when it is not one. Plain JSON can never match, since
+// no JSON document starts with an identifier followed by "(", so the json
+// parser applies it unconditionally; PackageConfig.StripJSONP extends it to
+// the other parsers.
+func unwrapJSONP(content []byte) []byte {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return content
+	}
+	m := jsonpRe.FindSubmatch(trimmed)
+	if m == nil {
+		return content
+	}
+	return m[1]
+}
@@ -0,0 +1,93 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestUnwrapJSONP covers the JSONP shapes seen in the wild and checks plain
+// JSON and non-JSONP text pass through untouched.
+func TestUnwrapJSONP(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain callback", `callback({"v":"1.0"})`, `{"v":"1.0"}`},
+		{"semicolon and whitespace", "  cb_1( {\"v\":\"1.0\"} );\n", ` {"v":"1.0"} `},
+		{"dotted name", `jQuery.handlers.$v([1,2])`, `[1,2]`},
+		{"content sniffing guard", `/**/ cb({"v":"1.0"});`, `{"v":"1.0"}`},
+		{"plain JSON object", `{"v":"1.0"}`, `{"v":"1.0"}`},
+		{"plain JSON array", `["cb(1)"]`, `["cb(1)"]`},
+		{"not JSONP", `version 1.0 (stable)`, `version 1.0 (stable)`},
+		{"empty", ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(unwrapJSONP([]byte(tt.in))); got != tt.want {
+				t.Errorf("unwrapJSONP(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONParser_JSONP verifies the json parser reads a JSONP-wrapped
+// payload without any configuration, for both a single path and select.
+func TestJSONParser_JSONP(t *testing.T) {
+	body := []byte(`releaseInfo({"latest":{"version":"2.4.1"},"versions":["2.3.0","2.4.1","2.4.0"]});`)
+
+	got, err := (&JSONParser{Path: "latest.version"}).Parse(body)
+	if err != nil || got != "2.4.1" {
+		t.Errorf("Parse() = %q, %v; want 2.4.1", got, err)
+	}
+
+	versions, err := (&JSONVersionHistoryExtractor{VersionsPath: "versions"}).ExtractVersions(body)
+	if err != nil || len(versions) != 3 {
+		t.Errorf("ExtractVersions() = %v, %v; want 3 versions", versions, err)
+	}
+}
+
+// TestCheckPackage_JSONP checks a JSONP source end to end, and that
+// strip_jsonp lets a regex anchored on the payload match.
+func TestCheckPackage_JSONP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte(`cb({"data":{"version":"1.5.0"}});`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		parser string
+	}{
+		{"json", `parser = "json"
+path = "data.version"`},
+		{"regex with strip_jsonp", `parser = "regex"
+pattern = '^\{"data":\{"version":"([^"]+)"'
+strip_jsonp = true`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay, _ := writePackagesTOML(t, `["app-misc/tool"]
+url = "`+srv.URL+`"
+`+tt.parser+"\n")
+			createTestEbuild(t, overlay, "app-misc/tool", "1.0.0")
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("app-misc/tool", true)
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != "1.5.0" || !result.HasUpdate {
+				t.Errorf("UpstreamVersion = %q (HasUpdate %v), want 1.5.0", result.UpstreamVersion, result.HasUpdate)
+			}
+		})
+	}
+}
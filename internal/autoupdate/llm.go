@@ -83,13 +83,14 @@ type SchemaAnalysis struct {
 // LLMConfig holds LLM provider configuration.
 // It defines which LLM service to use and how to authenticate.
 type LLMConfig struct {
-	// Provider is the LLM provider name ("claude", "openai", "ollama")
+	// Provider is the LLM provider name ("claude", "openai", "ollama",
+	// "gemini")
 	Provider string
 	// APIKeyEnv is the environment variable name containing the API key
 	APIKeyEnv string
 	// Model is the specific model to use (e.g., "claude-3-haiku-20240307")
 	Model string
-	// BaseURL is the base URL for the API (used by Ollama)
+	// BaseURL is the base URL for the API (used by Ollama, OpenAI and Gemini)
 	BaseURL string
 	// Bare selects the CLI bare-mode behavior: "auto" (default), "true", or "false"
 	Bare string
@@ -128,6 +129,8 @@ type ClaudeClient struct {
 	// It defaults to httputil.MaxBodyBytes and can be overridden via
 	// WithMaxBodyBytes (R11.2).
 	maxBodyBytes int64
+	// usageRecorder keeps the token usage the API reports, see UsageReporter
+	usageRecorder
 }
 
 // claudeRequest represents the request body for Claude Messages API
@@ -167,6 +170,11 @@ type claudeUsage struct {
 	OutputTokens int `json:"output_tokens"`
 }
 
+// tokenUsage converts the usage to a TokenUsage.
+func (u claudeUsage) tokenUsage() TokenUsage {
+	return TokenUsage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens}
+}
+
 // claudeErrorResponse represents an error response from Claude API
 type claudeErrorResponse struct {
 	Type  string `json:"type"`
@@ -177,7 +185,8 @@ type claudeErrorResponse struct {
 }
 
 // NewLLMProvider creates a new LLM provider based on the configuration.
-// It returns the appropriate provider implementation (Claude, OpenAI, or Ollama).
+// It returns the appropriate provider implementation (Claude, OpenAI, Ollama,
+// Gemini, or claude-code).
 func NewLLMProvider(cfg LLMConfig) (LLMProvider, error) {
 	switch cfg.Provider {
 	case "claude":
@@ -186,6 +195,8 @@ func NewLLMProvider(cfg LLMConfig) (LLMProvider, error) {
 		return NewOpenAIClient(cfg)
 	case "ollama":
 		return NewOllamaClient(cfg)
+	case "gemini":
+		return NewGeminiClient(cfg)
 	case "claude-code":
 		// NewClaudeCodeClient returns (*ClaudeCodeClient, error); since
 		// *ClaudeCodeClient implements LLMProvider, the pair satisfies the
@@ -327,6 +338,7 @@ func (c *ClaudeClient) ExtractVersion(content []byte, prompt string) (string, er
 	if err := json.Unmarshal(body, &claudeResp); err != nil {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.record(claudeResp.Usage.tokenUsage())
 
 	// Extract text from response
 	version := extractTextFromResponse(claudeResp)
@@ -404,6 +416,7 @@ func (c *ClaudeClient) AnalyzeContent(content []byte, meta *EbuildMetadata, hint
 	if err := json.Unmarshal(body, &claudeResp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.record(claudeResp.Usage.tokenUsage())
 
 	// Extract text from response
 	text := extractTextFromResponse(claudeResp)
@@ -719,6 +732,19 @@ func (c *LLMClient) GetModel() string {
 	return c.provider.GetModel()
 }
 
+// LastUsage delegates to the embedded provider.
+func (c *LLMClient) LastUsage() TokenUsage {
+	if reporter, ok := c.provider.(UsageReporter); ok {
+		return reporter.LastUsage()
+	}
+	return TokenUsage{}
+}
+
+// TotalUsage delegates to the embedded provider.
+func (c *LLMClient) TotalUsage() TokenUsage {
+	return LLMUsage(c.provider)
+}
+
 // SetHTTPClient sets a custom HTTP client (useful for testing)
 func (c *LLMClient) SetHTTPClient(client *http.Client) {
 	if claude, ok := c.provider.(*ClaudeClient); ok {
@@ -0,0 +1,304 @@
+// Package autoupdate provides caching of LLM results so unchanged content is
+// not sent to the provider twice.
+package autoupdate
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// llmCacheStoreName is the Store document the LLM cache is persisted under.
+const llmCacheStoreName = "llm_cache.json"
+
+// DefaultLLMCacheTTL is the default time-to-live for LLM cache entries (7
+// days). An entry is keyed by the exact content and prompt it answers, so it
+// never goes stale the way a version cache entry does; the TTL only bounds how
+// long a changed model behaviour can stay hidden behind an old answer.
+const DefaultLLMCacheTTL = 7 * 24 * time.Hour
+
+// Kinds of LLM call an entry answers, hashed into its key so a version and a
+// schema analysis of the same content never collide.
+const (
+	llmCacheKindExtract = "extract"
+	llmCacheKindAnalyze = "analyze"
+)
+
+// LLMCacheEntry is one cached LLM answer.
+type LLMCacheEntry struct {
+	// Value is the answer: the extracted version, or the schema analysis as
+	// JSON
+	Value string `json:"value"`
+	// CreatedAt is when the answer was cached
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// llmCacheFile represents the JSON structure stored on disk
+type llmCacheFile struct {
+	Entries map[string]LLMCacheEntry `json:"entries"`
+}
+
+// LLMCache caches ExtractVersion and AnalyzeContent answers keyed by a hash
+// of the provider, model, content and prompt, so a run over unchanged content
+// answers from disk instead of paying for the same call again. Only
+// successful answers are cached; an error always reaches the provider on the
+// next run.
+//
+// It is persisted as llm_cache.json next to the version cache, through a
+// FileStore unless WithLLMCacheStore gives another Store. A nil *LLMCache is
+// valid and caches nothing, so callers need no guard of their own.
+type LLMCache struct {
+	// Entries holds the cached answers, keyed by llmCacheKey
+	Entries map[string]LLMCacheEntry
+	// TTL is the time-to-live for cache entries (default: 7 days)
+	TTL time.Duration
+	// store persists the cache
+	store Store
+	// mu protects concurrent access to Entries
+	mu sync.RWMutex
+	// nowFunc allows injecting time for testing
+	nowFunc func() time.Time
+	// readOnly keeps every change in memory and skips the save. Set by
+	// NewChecker under WithReadOnly and NewAnalyzer under
+	// WithAnalyzerReadOnly.
+	readOnly bool
+}
+
+// LLMCacheOption is a functional option for configuring LLMCache
+type LLMCacheOption func(*LLMCache)
+
+// WithLLMCacheTTL sets a custom TTL for the LLM cache. Non-positive values
+// are ignored.
+func WithLLMCacheTTL(ttl time.Duration) LLMCacheOption {
+	return func(c *LLMCache) {
+		if ttl > 0 {
+			c.TTL = ttl
+		}
+	}
+}
+
+// WithLLMCacheNowFunc sets a custom time function for testing
+func WithLLMCacheNowFunc(fn func() time.Time) LLMCacheOption {
+	return func(c *LLMCache) {
+		c.nowFunc = fn
+	}
+}
+
+// WithLLMCacheStore persists the LLM cache in store instead of a file in the
+// config directory.
+func WithLLMCacheStore(store Store) LLMCacheOption {
+	return func(c *LLMCache) {
+		c.store = store
+	}
+}
+
+// NewLLMCache creates or loads the LLM cache kept in configDir. A missing or
+// corrupted cache starts empty; the corrupted document is replaced on the
+// next save.
+func NewLLMCache(configDir string, opts ...LLMCacheOption) (*LLMCache, error) {
+	cache := &LLMCache{
+		Entries: make(map[string]LLMCacheEntry),
+		TTL:     DefaultLLMCacheTTL,
+		nowFunc: time.Now,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(cache)
+	}
+
+	if cache.store == nil {
+		// Ensure config directory exists
+		if err := os.MkdirAll(configDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create LLM cache directory: %w", err)
+		}
+		cache.store = NewFileStore(configDir)
+	}
+
+	// Try to load existing cache
+	if err := cache.load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		cache.Entries = make(map[string]LLMCacheEntry)
+	}
+
+	return cache, nil
+}
+
+// load reads the LLM cache from its store
+func (c *LLMCache) load() error {
+	data, err := c.store.Load(llmCacheStoreName)
+	if err != nil {
+		return err
+	}
+
+	var cf llmCacheFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return fmt.Errorf("failed to parse LLM cache: %w", err)
+	}
+	if cf.Entries != nil {
+		c.Entries = cf.Entries
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, expired ones included.
+func (c *LLMCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.Entries)
+}
+
+// get returns the unexpired answer stored under key.
+func (c *LLMCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.Entries[key]
+	if !ok || c.nowFunc().Sub(entry.CreatedAt) > c.TTL {
+		return "", false
+	}
+	return entry.Value, true
+}
+
+// set stores value under key and saves the cache, dropping expired entries
+// on the way so the document does not grow without bound.
+func (c *LLMCache) set(key, value string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.nowFunc()
+	for k, entry := range c.Entries {
+		if now.Sub(entry.CreatedAt) > c.TTL {
+			delete(c.Entries, k)
+		}
+	}
+	c.Entries[key] = LLMCacheEntry{Value: value, CreatedAt: now}
+
+	if c.readOnly {
+		return nil
+	}
+	data, err := json.MarshalIndent(llmCacheFile{Entries: c.Entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal LLM cache: %w", err)
+	}
+	if err := c.store.Save(llmCacheStoreName, data); err != nil {
+		return fmt.Errorf("failed to save LLM cache: %w", err)
+	}
+	return nil
+}
+
+// ExtractVersion returns llm.ExtractVersion(content, prompt), answering from
+// the cache when the same provider and model already answered the same
+// content and prompt. A failure to save the new answer is logged, not
+// returned: the answer itself is good.
+func (c *LLMCache) ExtractVersion(llm LLMProvider, content []byte, prompt string) (string, error) {
+	if c == nil {
+		return llm.ExtractVersion(content, prompt)
+	}
+	key := llmCacheKey(llmCacheKindExtract, llmProviderName(llm), llm.GetModel(), content, prompt)
+	if version, ok := c.get(key); ok {
+		return version, nil
+	}
+
+	version, err := llm.ExtractVersion(content, prompt)
+	if err != nil {
+		return "", err
+	}
+	if err := c.set(key, version); err != nil {
+		warnLogf("failed to cache LLM version answer: %v", err)
+	}
+	return version, nil
+}
+
+// analysisKey returns the key an AnalyzeContent answer for content, meta and
+// hint is cached under. The prompt hashed is the one the providers build, so
+// a change to the metadata, the hint or the prompt template is a miss.
+func (c *LLMCache) analysisKey(llm LLMProvider, content []byte, meta *EbuildMetadata, hint string) string {
+	if c == nil {
+		return ""
+	}
+	prompt := buildSchemaAnalysisPrompt(content, meta, hint)
+	return llmCacheKey(llmCacheKindAnalyze, llmProviderName(llm), llm.GetModel(), content, prompt)
+}
+
+// getAnalysis returns the unexpired schema analysis stored under key.
+func (c *LLMCache) getAnalysis(key string) (*SchemaAnalysis, bool) {
+	value, ok := c.get(key)
+	if !ok {
+		return nil, false
+	}
+	var analysis SchemaAnalysis
+	if err := json.Unmarshal([]byte(value), &analysis); err != nil {
+		return nil, false
+	}
+	return &analysis, true
+}
+
+// setAnalysis stores analysis under key, logging a failure to save it.
+func (c *LLMCache) setAnalysis(key string, analysis *SchemaAnalysis) {
+	if c == nil || analysis == nil {
+		return
+	}
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		warnLogf("failed to cache LLM analysis: %v", err)
+		return
+	}
+	if err := c.set(key, string(data)); err != nil {
+		warnLogf("failed to cache LLM analysis: %v", err)
+	}
+}
+
+// llmCacheKey returns the hex SHA-256 of kind, provider, model, content and
+// prompt. Each field is prefixed with its length, so no choice of field
+// values can make two different tuples hash the same bytes, and the key
+// depends on nothing but the fields: it is stable across runs, processes and
+// platforms.
+func llmCacheKey(kind, provider, model string, content []byte, prompt string) string {
+	h := sha256.New()
+	var size [8]byte
+	for _, field := range [][]byte{[]byte(kind), []byte(provider), []byte(model), content, []byte(prompt)} {
+		binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+		h.Write(size[:])
+		h.Write(field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// llmProviderName returns the name of the provider behind llm as used in
+// the configuration, so two providers serving a model of the same name do
+// not share answers.
+func llmProviderName(llm LLMProvider) string {
+	switch p := llm.(type) {
+	case *LLMClient:
+		return llmProviderName(p.provider)
+	case *ClaudeClient:
+		return "claude"
+	case *OpenAIClient:
+		return "openai"
+	case *OllamaClient:
+		return "ollama"
+	case *GeminiClient:
+		return "gemini"
+	case *ClaudeCodeClient:
+		return "claude-code"
+	case *MultiLLMClient:
+		return "multi"
+	default:
+		return fmt.Sprintf("%T", llm)
+	}
+}
@@ -0,0 +1,209 @@
+package autoupdate
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// extractCountingLLM is an LLMProvider that counts ExtractVersion calls and
+// answers with version, or err when set.
+type extractCountingLLM struct {
+	stubLLMProvider
+	version string
+	err     error
+	calls   atomic.Int32
+}
+
+func (l *extractCountingLLM) ExtractVersion(_ []byte, _ string) (string, error) {
+	l.calls.Add(1)
+	return l.version, l.err
+}
+
+// newTestLLMCache returns an LLMCache kept in memory.
+func newTestLLMCache(t *testing.T, opts ...LLMCacheOption) *LLMCache {
+	t.Helper()
+	cache, err := NewLLMCache(t.TempDir(), append([]LLMCacheOption{WithLLMCacheStore(NewMemoryStore())}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewLLMCache() error = %v", err)
+	}
+	return cache
+}
+
+// TestLLMCache_ExtractVersion verifies identical inputs are answered from the
+// cache, while a changed prompt or content reaches the provider again.
+func TestLLMCache_ExtractVersion(t *testing.T) {
+	cache := newTestLLMCache(t)
+	llm := &extractCountingLLM{version: "1.2.3"}
+
+	calls := []struct {
+		content, prompt string
+		wantCalls       int32
+	}{
+		{"release 1.2.3", "Extract the version", 1},
+		{"release 1.2.3", "Extract the version", 1},
+		{"release 1.2.3", "Extract the latest version", 2},
+		{"release 1.2.4", "Extract the latest version", 3},
+		{"release 1.2.3", "Extract the version", 3},
+	}
+	for i, c := range calls {
+		version, err := cache.ExtractVersion(llm, []byte(c.content), c.prompt)
+		if err != nil || version != "1.2.3" {
+			t.Fatalf("call %d: ExtractVersion() = %q, %v; want 1.2.3", i+1, version, err)
+		}
+		if got := llm.calls.Load(); got != c.wantCalls {
+			t.Errorf("call %d: provider calls = %d, want %d", i+1, got, c.wantCalls)
+		}
+	}
+}
+
+// TestLLMCache_ExtractVersionError verifies a failed call is not cached.
+func TestLLMCache_ExtractVersionError(t *testing.T) {
+	cache := newTestLLMCache(t)
+	llm := &extractCountingLLM{err: ErrLLMEmptyResponse}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.ExtractVersion(llm, []byte("content"), "prompt"); !errors.Is(err, ErrLLMEmptyResponse) {
+			t.Fatalf("ExtractVersion() error = %v, want %v", err, ErrLLMEmptyResponse)
+		}
+	}
+	if llm.calls.Load() != 2 || cache.Len() != 0 {
+		t.Errorf("provider calls = %d, entries = %d; want 2 and 0", llm.calls.Load(), cache.Len())
+	}
+}
+
+// TestLLMCache_NilCache verifies a nil cache passes every call through.
+func TestLLMCache_NilCache(t *testing.T) {
+	var cache *LLMCache
+	llm := &extractCountingLLM{version: "1.0"}
+	for i := 0; i < 2; i++ {
+		if version, err := cache.ExtractVersion(llm, []byte("content"), "prompt"); err != nil || version != "1.0" {
+			t.Fatalf("ExtractVersion() = %q, %v; want 1.0", version, err)
+		}
+	}
+	if llm.calls.Load() != 2 {
+		t.Errorf("provider calls = %d, want 2", llm.calls.Load())
+	}
+}
+
+// TestLLMCacheKey_Stable verifies the key is a pure function of its fields,
+// that every field takes part in it, and that moving bytes between adjacent
+// fields changes it.
+func TestLLMCacheKey_Stable(t *testing.T) {
+	base := llmCacheKey(llmCacheKindExtract, "claude", "model", []byte("content"), "prompt")
+	if again := llmCacheKey(llmCacheKindExtract, "claude", "model", []byte("content"), "prompt"); again != base {
+		t.Fatalf("llmCacheKey() = %s then %s, want the same key", base, again)
+	}
+	if len(base) != 64 {
+		t.Errorf("llmCacheKey() = %q, want a hex SHA-256", base)
+	}
+
+	for name, key := range map[string]string{
+		"kind":     llmCacheKey(llmCacheKindAnalyze, "claude", "model", []byte("content"), "prompt"),
+		"provider": llmCacheKey(llmCacheKindExtract, "openai", "model", []byte("content"), "prompt"),
+		"model":    llmCacheKey(llmCacheKindExtract, "claude", "model-2", []byte("content"), "prompt"),
+		"content":  llmCacheKey(llmCacheKindExtract, "claude", "model", []byte("content2"), "prompt"),
+		"prompt":   llmCacheKey(llmCacheKindExtract, "claude", "model", []byte("content"), "prompt2"),
+		"boundary": llmCacheKey(llmCacheKindExtract, "claude", "model", []byte("contentp"), "rompt"),
+	} {
+		if key == base {
+			t.Errorf("changing the %s did not change the key", name)
+		}
+	}
+}
+
+// TestLLMCache_TTL verifies an expired entry is a miss and is dropped by
+// the next save.
+func TestLLMCache_TTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newTestLLMCache(t, WithLLMCacheTTL(time.Hour), WithLLMCacheNowFunc(func() time.Time { return now }))
+	llm := &extractCountingLLM{version: "2.0"}
+
+	cache.ExtractVersion(llm, []byte("a"), "prompt") //nolint:errcheck
+	now = now.Add(30 * time.Minute)
+	cache.ExtractVersion(llm, []byte("a"), "prompt") //nolint:errcheck
+	if llm.calls.Load() != 1 {
+		t.Fatalf("provider calls within the TTL = %d, want 1", llm.calls.Load())
+	}
+
+	now = now.Add(time.Hour)
+	cache.ExtractVersion(llm, []byte("b"), "prompt") //nolint:errcheck
+	if cache.Len() != 1 {
+		t.Errorf("entries after the TTL = %d, want the expired one dropped", cache.Len())
+	}
+	cache.ExtractVersion(llm, []byte("a"), "prompt") //nolint:errcheck
+	if llm.calls.Load() != 3 {
+		t.Errorf("provider calls after the TTL = %d, want 3", llm.calls.Load())
+	}
+}
+
+// TestLLMCache_Persistence verifies answers survive a reload of the same
+// store, and a read-only cache does not save them.
+func TestLLMCache_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	llm := &extractCountingLLM{version: "3.1"}
+
+	readOnly, err := NewLLMCache(dir)
+	if err != nil {
+		t.Fatalf("NewLLMCache() error = %v", err)
+	}
+	readOnly.readOnly = true
+	readOnly.ExtractVersion(llm, []byte("content"), "prompt") //nolint:errcheck
+
+	first, _ := NewLLMCache(dir)
+	if first.Len() != 0 {
+		t.Fatalf("entries after a read-only run = %d, want 0", first.Len())
+	}
+	first.ExtractVersion(llm, []byte("content"), "prompt") //nolint:errcheck
+
+	second, err := NewLLMCache(dir)
+	if err != nil {
+		t.Fatalf("NewLLMCache() error = %v", err)
+	}
+	if version, err := second.ExtractVersion(llm, []byte("content"), "prompt"); err != nil || version != "3.1" {
+		t.Fatalf("ExtractVersion() after reload = %q, %v; want 3.1", version, err)
+	}
+	if llm.calls.Load() != 2 {
+		t.Errorf("provider calls = %d, want 2", llm.calls.Load())
+	}
+}
+
+// TestAnalyzer_WithAnalyzerLLMCache verifies a second analysis of the same
+// content is answered from the cache without calling the provider, and a
+// different hint is a miss.
+func TestAnalyzer_WithAnalyzerLLMCache(t *testing.T) {
+	llm := &analyzeCountingLLM{}
+	analyzer, err := NewAnalyzer(t.TempDir(),
+		WithAnalyzerPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerLLMClient(llm),
+		WithAnalyzerLLMCache(newTestLLMCache(t)),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer() error = %v", err)
+	}
+
+	content := []byte(`{"version": "1.0"}`)
+	meta := &EbuildMetadata{Package: "app-misc/foo"}
+	source := &DataSource{URL: "https://example.com/foo.json", Type: "homepage"}
+	for i := 0; i < 2; i++ {
+		schema, err := analyzer.analyzeContent(llm, content, meta, "", source)
+		if err != nil {
+			t.Fatalf("analyzeContent() error = %v", err)
+		}
+		if schema.Parser != "json" || schema.URL != source.URL {
+			t.Errorf("analyzeContent() = %+v, want a json schema for %s", schema, source.URL)
+		}
+	}
+	if llm.calls.Load() != 1 {
+		t.Errorf("provider calls = %d, want 1", llm.calls.Load())
+	}
+
+	if _, err := analyzer.analyzeContent(llm, content, meta, "use the version key", source); err != nil {
+		t.Fatalf("analyzeContent() with a hint error = %v", err)
+	}
+	if llm.calls.Load() != 2 {
+		t.Errorf("provider calls after a new hint = %d, want 2", llm.calls.Load())
+	}
+}
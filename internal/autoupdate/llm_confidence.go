@@ -0,0 +1,158 @@
+// Package autoupdate provides plausibility checks for LLM-extracted versions.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// DefaultLLMMinConfidence is the confidence an LLM-extracted version needs to
+// be queued without review: every configured check must pass.
+const DefaultLLMMinConfidence = 1.0
+
+// Confidence penalties applied by assessLLMVersion.
+const (
+	// llmPenaltyFormat is subtracted when the version misses expect_format
+	llmPenaltyFormat = 0.5
+	// llmPenaltyDisagree is subtracted when the cross_validate source reports
+	// a different version
+	llmPenaltyDisagree = 0.5
+	// llmPenaltyUnverified is subtracted when the cross_validate source could
+	// not be read, so agreement is unknown
+	llmPenaltyUnverified = 0.25
+)
+
+// ErrImplausibleVersion is returned when the LLM answers with something that
+// is not a version at all ("unknown", a sentence, a URL).
+var ErrImplausibleVersion = errors.New("implausible version")
+
+// ErrInvalidCrossValidate is returned for a cross_validate table without a
+// URL or with an incomplete json/regex parser.
+var ErrInvalidCrossValidate = errors.New("invalid cross_validate")
+
+// plausibleVersionRegex accepts a leading number followed by dot, dash,
+// underscore, plus or tilde separated alphanumeric parts: 1.2.3, 2.0-rc1,
+// 20240101, 1.0_p3.
+var plausibleVersionRegex = regexp.MustCompile(`^[vV]?[0-9]+(?:[._+~-]?[0-9A-Za-z]+)*$`)
+
+// maxPlausibleVersionLen bounds a plausible version; anything longer is prose
+// or a hash.
+const maxPlausibleVersionLen = 64
+
+// IsPlausibleVersion reports whether v looks like a version number. It is a
+// shape check only: it starts with a digit (after an optional "v"), has no
+// whitespace, and is at most 64 characters.
+func IsPlausibleVersion(v string) bool {
+	return len(v) <= maxPlausibleVersionLen && plausibleVersionRegex.MatchString(v)
+}
+
+// CrossValidateConfig is a structured source an LLM-extracted version is
+// compared against, such as a release API that is incomplete but reliable
+// when it answers:
+//
+//	["app-misc/foo".cross_validate]
+//	url = "https://api.github.com/repos/foo/foo/releases/latest"
+//	parser = "json"
+//	path = "tag_name"
+//
+// The package's headers are sent with the request.
+type CrossValidateConfig struct {
+	// URL is the structured source
+	URL string `toml:"url"`
+	// Parser is "json" or "regex"
+	Parser string `toml:"parser"`
+	// Path is the JSON path of the version (json parser)
+	Path string `toml:"path,omitempty"`
+	// Pattern is the regex with a capture group for the version (regex parser)
+	Pattern string `toml:"pattern,omitempty"`
+}
+
+// parserConfig returns the source as a PackageConfig for the package parsers.
+func (v *CrossValidateConfig) parserConfig() *PackageConfig {
+	return &PackageConfig{URL: v.URL, Parser: v.Parser, Path: v.Path, Pattern: v.Pattern}
+}
+
+// validateCrossValidate checks cfg's cross_validate table and
+// llm_min_confidence.
+func validateCrossValidate(pkg string, cfg *PackageConfig) error {
+	if m := cfg.LLMMinConfidence; m != nil && (*m < 0 || *m > 1) {
+		return fmt.Errorf("package %s: llm_min_confidence must be between 0 and 1, got %v", pkg, *m)
+	}
+	cv := cfg.CrossValidate
+	if cv == nil {
+		return nil
+	}
+	if cv.Parser != "json" && cv.Parser != "regex" {
+		return fmt.Errorf("package %s: %w: parser must be json or regex, got %q", pkg, ErrInvalidCrossValidate, cv.Parser)
+	}
+	if err := ValidatePackageConfig(pkg, cv.parserConfig()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCrossValidate, err)
+	}
+	return nil
+}
+
+// assessLLMVersion scores an LLM-extracted version that already passed
+// IsPlausibleVersion. It starts at 1 and loses llmPenaltyFormat when the
+// version misses expect_format, and llmPenaltyDisagree (or
+// llmPenaltyUnverified when the source cannot be read) against
+// cross_validate. The notes say what cost confidence, for ReviewNote.
+func (c *Checker) assessLLMVersion(pkg string, cfg *PackageConfig, version string) (float64, []string) {
+	confidence := 1.0
+	var notes []string
+
+	if err := checkExpectFormat(cfg, version); err != nil {
+		confidence -= llmPenaltyFormat
+		notes = append(notes, fmt.Sprintf("LLM version %q does not match expect_format", version))
+	}
+
+	if cv := cfg.CrossValidate; cv != nil {
+		structured, err := c.crossValidateVersion(cfg)
+		switch {
+		case err != nil:
+			confidence -= llmPenaltyUnverified
+			notes = append(notes, fmt.Sprintf("cross_validate source unavailable: %v", err))
+		case ebuild.CompareVersions(stripVersionPrefix(structured), stripVersionPrefix(version)) != 0:
+			confidence -= llmPenaltyDisagree
+			notes = append(notes, fmt.Sprintf("LLM version %s disagrees with cross_validate %s", version, structured))
+		}
+	}
+
+	if confidence < 0 {
+		confidence = 0
+	}
+	logger.Debug("LLM version %s for %s: confidence %.2f", version, pkg, confidence)
+	return confidence, notes
+}
+
+// crossValidateVersion reads the version from cfg's cross_validate source.
+func (c *Checker) crossValidateVersion(cfg *PackageConfig) (string, error) {
+	cv := cfg.CrossValidate
+	content, err := c.fetchContent(cv.URL, cfg.Headers, c.operationTimeout(cfg))
+	if err != nil {
+		return "", err
+	}
+	parser, err := NewParserFromConfig(cv.parserConfig())
+	if err != nil {
+		return "", err
+	}
+	version, err := parser.Parse(content)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(version), nil
+}
+
+// MinLLMConfidence returns the confidence an LLM-extracted version needs to
+// be queued without review. An absent (nil) llm_min_confidence is
+// DefaultLLMMinConfidence.
+func (c *PackageConfig) MinLLMConfidence() float64 {
+	if c.LLMMinConfidence == nil {
+		return DefaultLLMMinConfidence
+	}
+	return *c.LLMMinConfidence
+}
@@ -0,0 +1,121 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestIsPlausibleVersion checks the shape test applied to LLM answers.
+func TestIsPlausibleVersion(t *testing.T) {
+	for v, want := range map[string]bool{
+		"1.2.3":                     true,
+		"v2.0-rc1":                  true,
+		"20240101":                  true,
+		"1.0_p3":                    true,
+		"unknown":                   false,
+		"The latest version is 1.2": false,
+		"https://example.com/1.2":   false,
+		"":                          false,
+		strings.Repeat("1.", 40):    false,
+	} {
+		if got := IsPlausibleVersion(v); got != want {
+			t.Errorf("IsPlausibleVersion(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+// TestCheckPackage_LLMConfidence verifies an implausible LLM answer fails the
+// check, a plausible one is queued, and one that disagrees with
+// cross_validate or misses expect_format is sent to review instead of pending.
+func TestCheckPackage_LLMConfidence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" {
+			_, _ = w.Write([]byte(`{"tag_name":"v2.0.0"}`))
+			return
+		}
+		// Not JSON, so the json parser fails and the LLM runs.
+		_, _ = w.Write([]byte("<html>Download release two</html>"))
+	}))
+	t.Cleanup(srv.Close)
+
+	const pkg = "app-misc/llm"
+	crossValidate := &CrossValidateConfig{URL: srv.URL + "/api", Parser: "json", Path: "tag_name"}
+	lenient := 0.5
+
+	tests := []struct {
+		name        string
+		llmVersion  string
+		cfg         PackageConfig
+		wantErr     error
+		needsReview bool
+	}{
+		{name: "implausible rejected", llmVersion: "I could not find a version", wantErr: ErrImplausibleVersion},
+		{name: "plausible accepted", llmVersion: "2.0.0"},
+		{name: "agrees with cross_validate", llmVersion: "2.0.0", cfg: PackageConfig{CrossValidate: crossValidate}},
+		{name: "disagrees with cross_validate", llmVersion: "2.1.0", cfg: PackageConfig{CrossValidate: crossValidate}, needsReview: true},
+		{name: "misses expect_format", llmVersion: "2.0", cfg: PackageConfig{ExpectFormat: `[0-9]+\.[0-9]+\.[0-9]+`}, needsReview: true},
+		{name: "lowered threshold", llmVersion: "2.0", cfg: PackageConfig{ExpectFormat: `[0-9]+\.[0-9]+\.[0-9]+`, LLMMinConfidence: &lenient}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay := t.TempDir()
+			createTestEbuild(t, overlay, pkg, "1.0.0")
+			cfg := tt.cfg
+			cfg.URL, cfg.Parser, cfg.Path, cfg.LLMPrompt = srv.URL, "json", "version", "extract the version"
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{pkg: cfg}}),
+				WithRateLimiter(unlimitedRateLimiter()),
+				WithLLMClient(&fakeLLMProvider{version: tt.llmVersion}),
+				WithLLMProviderConfigured(true),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage(pkg, true)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CheckPackage() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+
+			if result.NeedsReview != tt.needsReview {
+				t.Errorf("NeedsReview = %v (confidence %.2f, note %q), want %v",
+					result.NeedsReview, result.LLMConfidence, result.ReviewNote, tt.needsReview)
+			}
+			_, queued := checker.pending.Get(pkg)
+			if queued == tt.needsReview {
+				t.Errorf("queued = %v, want %v", queued, !tt.needsReview)
+			}
+			_, cached := checker.cache.GetEntry(pkg)
+			if cached == tt.needsReview {
+				t.Errorf("cached = %v, want %v", cached, !tt.needsReview)
+			}
+		})
+	}
+}
+
+// TestValidateCrossValidate verifies the cross_validate table and threshold
+// are checked.
+func TestValidateCrossValidate(t *testing.T) {
+	tooHigh := 1.5
+	tests := []*PackageConfig{
+		{CrossValidate: &CrossValidateConfig{URL: "https://example.com", Parser: "html"}},
+		{CrossValidate: &CrossValidateConfig{URL: "https://example.com", Parser: "json"}},
+		{LLMMinConfidence: &tooHigh},
+	}
+	for _, cfg := range tests {
+		cfg.URL, cfg.Parser, cfg.Path = "https://example.com", "json", "version"
+		if err := ValidatePackageConfig("app-misc/llm", cfg); err == nil {
+			t.Errorf("ValidatePackageConfig(%+v) = nil, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package autoupdate provides an LLM provider that falls back through an
+// ordered chain of providers.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// ErrLLMAllProvidersFailed is returned by MultiLLMClient when every provider
+// of the chain failed. It wraps each provider's error, so errors.Is still
+// matches, say, ErrLLMRequestFailed.
+var ErrLLMAllProvidersFailed = errors.New("every LLM provider failed")
+
+// MultiLLMClient is an LLMProvider that tries an ordered chain of providers,
+// moving to the next when one fails, so a rate-limited or unavailable primary
+// does not fail the call while a backup could answer. It is safe for
+// concurrent use when its providers are.
+type MultiLLMClient struct {
+	providers []LLMProvider
+	// names identifies each provider, "provider:model", in errors and in
+	// LastProvider.
+	names []string
+
+	mu sync.Mutex
+	// lastProvider is the name of the provider that answered the last
+	// successful call.
+	lastProvider string
+	// lastUsage is the usage of that provider's answer
+	lastUsage TokenUsage
+}
+
+// NewMultiLLMClient builds a MultiLLMClient trying the providers of cfgs in
+// order, each built by NewLLMProvider. A provider that cannot be built (an
+// unknown name, a missing API key) fails the whole chain rather than being
+// left out: a backup silently missing is only noticed when the primary fails.
+func NewMultiLLMClient(cfgs []LLMConfig) (*MultiLLMClient, error) {
+	if len(cfgs) == 0 {
+		return nil, ErrLLMNotConfigured
+	}
+	providers := make([]LLMProvider, 0, len(cfgs))
+	names := make([]string, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		provider, err := NewLLMProvider(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("LLM provider %d (%s): %w", i+1, cfg.Provider, err)
+		}
+		providers = append(providers, provider)
+		names = append(names, cfg.Provider+":"+provider.GetModel())
+	}
+	return &MultiLLMClient{providers: providers, names: names}, nil
+}
+
+// NewMultiLLMClientFromProviders builds a MultiLLMClient over already
+// constructed providers, tried in the order given.
+func NewMultiLLMClientFromProviders(providers ...LLMProvider) *MultiLLMClient {
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		names[i] = llmProviderName(provider) + ":" + provider.GetModel()
+	}
+	return &MultiLLMClient{providers: providers, names: names}
+}
+
+// ExtractVersion asks each provider in turn and returns the first version
+// that IsPlausibleVersion: an empty or free-text answer counts as that
+// provider failing, so the next one is asked. When all fail, the error wraps
+// ErrLLMAllProvidersFailed and every provider's error.
+func (m *MultiLLMClient) ExtractVersion(content []byte, prompt string) (string, error) {
+	return tryLLMChain(m, func(provider LLMProvider) (string, error) {
+		version, err := provider.ExtractVersion(content, prompt)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(version) == "" {
+			return "", ErrLLMEmptyResponse
+		}
+		if !IsPlausibleVersion(version) {
+			return "", fmt.Errorf("%w %q", ErrImplausibleVersion, version)
+		}
+		return version, nil
+	})
+}
+
+// AnalyzeContent asks each provider in turn and returns the first analysis.
+// When all fail, the error wraps ErrLLMAllProvidersFailed and every
+// provider's error.
+func (m *MultiLLMClient) AnalyzeContent(content []byte, meta *EbuildMetadata, hint string) (*SchemaAnalysis, error) {
+	return tryLLMChain(m, func(provider LLMProvider) (*SchemaAnalysis, error) {
+		analysis, err := provider.AnalyzeContent(content, meta, hint)
+		if err == nil && analysis == nil {
+			err = ErrLLMEmptyResponse
+		}
+		return analysis, err
+	})
+}
+
+// GetModel returns the chain's models, comma-separated. It names the chain as
+// a whole, so an LLM cache key built from it does not change with whichever
+// provider happens to answer.
+func (m *MultiLLMClient) GetModel() string {
+	models := make([]string, len(m.providers))
+	for i, provider := range m.providers {
+		models[i] = provider.GetModel()
+	}
+	return strings.Join(models, ",")
+}
+
+// LastProvider returns the provider, as "provider:model", that answered the
+// last successful call, or "" before any.
+func (m *MultiLLMClient) LastProvider() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastProvider
+}
+
+// LastUsage returns the token usage of the answer to the last successful
+// call. Tokens spent on providers that failed before it are not included;
+// TotalUsage counts them.
+func (m *MultiLLMClient) LastUsage() TokenUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastUsage
+}
+
+// TotalUsage returns the token usage of every call made to the chain's
+// providers, the failed ones included.
+func (m *MultiLLMClient) TotalUsage() TokenUsage {
+	var total TokenUsage
+	for _, provider := range m.providers {
+		total = total.Add(LLMUsage(provider))
+	}
+	return total
+}
+
+// tryLLMChain runs call against each of m's providers until one succeeds,
+// recording it, and aggregates the errors when none does. A generic function
+// rather than a method, which cannot have type parameters.
+func tryLLMChain[T any](m *MultiLLMClient, call func(LLMProvider) (T, error)) (T, error) {
+	var errs []error
+	for i, provider := range m.providers {
+		result, err := call(provider)
+		if err == nil {
+			if i > 0 {
+				logger.Debug("LLM provider %s answered after %d failed", m.names[i], i)
+			}
+			var usage TokenUsage
+			if reporter, ok := provider.(UsageReporter); ok {
+				usage = reporter.LastUsage()
+			}
+			m.mu.Lock()
+			m.lastProvider = m.names[i]
+			m.lastUsage = usage
+			m.mu.Unlock()
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.names[i], err))
+	}
+	var zero T
+	if len(errs) == 0 {
+		return zero, ErrLLMNotConfigured
+	}
+	return zero, fmt.Errorf("%w: %w", ErrLLMAllProvidersFailed, errors.Join(errs...))
+}
@@ -0,0 +1,138 @@
+package autoupdate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// chainLLMProvider is an LLMProvider answering with a fixed version and
+// analysis, or err, counting its calls.
+type chainLLMProvider struct {
+	model   string
+	version string
+	err     error
+	calls   int
+}
+
+func (p *chainLLMProvider) ExtractVersion(_ []byte, _ string) (string, error) {
+	p.calls++
+	return p.version, p.err
+}
+
+func (p *chainLLMProvider) AnalyzeContent(_ []byte, _ *EbuildMetadata, _ string) (*SchemaAnalysis, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &SchemaAnalysis{ParserType: "regex", Pattern: p.model}, nil
+}
+
+func (p *chainLLMProvider) GetModel() string { return p.model }
+
+// TestMultiLLMClient_FallsBack verifies a failing primary hands over to the
+// next provider, which is recorded as the one that answered, and that a
+// working primary is the only one asked.
+func TestMultiLLMClient_FallsBack(t *testing.T) {
+	primary := &chainLLMProvider{model: "primary", err: ErrLLMRequestFailed}
+	backup := &chainLLMProvider{model: "backup", version: "1.2.3"}
+	client := NewMultiLLMClientFromProviders(primary, backup)
+
+	if client.LastProvider() != "" {
+		t.Errorf("LastProvider() before any call = %q, want empty", client.LastProvider())
+	}
+	version, err := client.ExtractVersion([]byte("content"), "prompt")
+	if err != nil || version != "1.2.3" {
+		t.Fatalf("ExtractVersion() = %q, %v; want 1.2.3", version, err)
+	}
+	if primary.calls != 1 || backup.calls != 1 {
+		t.Errorf("calls = %d, %d; want each provider asked once", primary.calls, backup.calls)
+	}
+	if got := client.LastProvider(); !strings.HasSuffix(got, ":backup") {
+		t.Errorf("LastProvider() = %q, want the backup", got)
+	}
+
+	analysis, err := client.AnalyzeContent([]byte("content"), nil, "")
+	if err != nil || analysis.Pattern != "backup" {
+		t.Fatalf("AnalyzeContent() = %+v, %v; want the backup's analysis", analysis, err)
+	}
+
+	primary.err = nil
+	primary.version = "2.0"
+	backup.calls = 0
+	if version, err := client.ExtractVersion(nil, ""); err != nil || version != "2.0" || backup.calls != 0 {
+		t.Errorf("ExtractVersion() = %q, %v with %d backup calls; want the primary's answer alone", version, err, backup.calls)
+	}
+	if got := client.LastProvider(); !strings.HasSuffix(got, ":primary") {
+		t.Errorf("LastProvider() = %q, want the primary", got)
+	}
+	if got := client.GetModel(); got != "primary,backup" {
+		t.Errorf("GetModel() = %q, want the whole chain", got)
+	}
+}
+
+// TestMultiLLMClient_SkipsImplausibleVersion verifies a provider answering
+// with something that is not a version does not end the chain.
+func TestMultiLLMClient_SkipsImplausibleVersion(t *testing.T) {
+	primary := &chainLLMProvider{model: "primary", version: "The latest version is unknown"}
+	backup := &chainLLMProvider{model: "backup", version: "1.2.3"}
+	client := NewMultiLLMClientFromProviders(primary, backup)
+
+	version, err := client.ExtractVersion([]byte("content"), "prompt")
+	if err != nil || version != "1.2.3" {
+		t.Fatalf("ExtractVersion() = %q, %v; want the backup's 1.2.3", version, err)
+	}
+	if got := client.LastProvider(); !strings.HasSuffix(got, ":backup") {
+		t.Errorf("LastProvider() = %q, want the backup", got)
+	}
+
+	backup.version = "n/a"
+	if _, err := client.ExtractVersion(nil, ""); !errors.Is(err, ErrLLMAllProvidersFailed) || !errors.Is(err, ErrImplausibleVersion) {
+		t.Errorf("ExtractVersion() error = %v, want %v wrapping %v", err, ErrLLMAllProvidersFailed, ErrImplausibleVersion)
+	}
+}
+
+// TestMultiLLMClient_AllFail verifies the errors of every provider are
+// aggregated, an empty version counting as a failure.
+func TestMultiLLMClient_AllFail(t *testing.T) {
+	client := NewMultiLLMClientFromProviders(
+		&chainLLMProvider{model: "primary", err: ErrLLMRequestFailed},
+		&chainLLMProvider{model: "backup", version: "  "},
+	)
+
+	_, err := client.ExtractVersion(nil, "")
+	if !errors.Is(err, ErrLLMAllProvidersFailed) || !errors.Is(err, ErrLLMRequestFailed) || !errors.Is(err, ErrLLMEmptyResponse) {
+		t.Errorf("ExtractVersion() error = %v, want every provider's error under %v", err, ErrLLMAllProvidersFailed)
+	}
+	for _, name := range []string{"primary", "backup"} {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("ExtractVersion() error = %v, want it to name %s", err, name)
+		}
+	}
+	if client.LastProvider() != "" {
+		t.Errorf("LastProvider() = %q after failures only, want empty", client.LastProvider())
+	}
+}
+
+// TestNewMultiLLMClient verifies the chain is built from configurations and
+// that a provider which cannot be built fails the whole chain.
+func TestNewMultiLLMClient(t *testing.T) {
+	if _, err := NewMultiLLMClient(nil); !errors.Is(err, ErrLLMNotConfigured) {
+		t.Errorf("NewMultiLLMClient(nil) error = %v, want %v", err, ErrLLMNotConfigured)
+	}
+	_, err := NewMultiLLMClient([]LLMConfig{{Provider: "ollama", Model: "llama3"}, {Provider: "nope"}})
+	if !errors.Is(err, ErrLLMUnsupportedProvider) {
+		t.Errorf("NewMultiLLMClient(unknown provider) error = %v, want %v", err, ErrLLMUnsupportedProvider)
+	}
+
+	client, err := NewMultiLLMClient([]LLMConfig{
+		{Provider: "ollama", Model: "llama3"},
+		{Provider: "ollama", Model: "qwen2"},
+	})
+	if err != nil {
+		t.Fatalf("NewMultiLLMClient() error = %v", err)
+	}
+	if client.GetModel() != "llama3,qwen2" {
+		t.Errorf("GetModel() = %q, want llama3,qwen2", client.GetModel())
+	}
+}
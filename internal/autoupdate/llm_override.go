@@ -0,0 +1,126 @@
+// Package autoupdate provides per-package LLM provider overrides.
+package autoupdate
+
+import (
+	"sync"
+)
+
+// PackageLLMConfig overrides the global LLM provider for one package, so an
+// expensive model is spent only on the few packages that need it. Empty
+// fields inherit from the global configuration.
+//
+// In packages.toml:
+//
+//	["dev-util/hard-one"]
+//	llm = { provider = "claude", model = "claude-3-5-sonnet-latest" }
+type PackageLLMConfig struct {
+	// Provider is the LLM provider name ("claude", "openai", "ollama",
+	// "gemini", "claude-code"). Empty keeps the global provider.
+	Provider string `toml:"provider,omitempty"`
+	// Model is the model to use. Empty keeps the global model when the
+	// provider is unchanged, or the provider's default otherwise.
+	Model string `toml:"model,omitempty"`
+	// APIKeyEnv is the environment variable holding the API key.
+	APIKeyEnv string `toml:"api_key_env,omitempty"`
+	// BaseURL is the API base URL (used by OpenAI, Ollama and Gemini).
+	BaseURL string `toml:"base_url,omitempty"`
+}
+
+// Apply returns base with the override's non-empty fields applied. Switching
+// to a different provider drops base's model, key variable and base URL, which
+// belong to the global provider and would be meaningless (or leak a key) for
+// another one; the CLI-only Bare and MaxBudgetUSD settings are kept.
+func (o *PackageLLMConfig) Apply(base LLMConfig) LLMConfig {
+	cfg := base
+	if o.Provider != "" && o.Provider != base.Provider {
+		cfg = LLMConfig{
+			Provider:     o.Provider,
+			Bare:         base.Bare,
+			MaxBudgetUSD: base.MaxBudgetUSD,
+		}
+	}
+	if o.Model != "" {
+		cfg.Model = o.Model
+	}
+	if o.APIKeyEnv != "" {
+		cfg.APIKeyEnv = o.APIKeyEnv
+	}
+	if o.BaseURL != "" {
+		cfg.BaseURL = o.BaseURL
+	}
+	return cfg
+}
+
+// llmProviderPool builds and memoizes the providers for per-package overrides,
+// keyed by the effective configuration, so packages sharing an override share
+// one client and a failing configuration is reported once. It is safe for
+// concurrent use by CheckAll's workers.
+type llmProviderPool struct {
+	// base is the global configuration overrides are applied on top of.
+	base LLMConfig
+	// factory builds a provider; NewLLMProvider outside tests.
+	factory func(LLMConfig) (LLMProvider, error)
+
+	mu      sync.Mutex
+	entries map[LLMConfig]llmPoolEntry
+}
+
+// llmPoolEntry is a memoized factory outcome.
+type llmPoolEntry struct {
+	provider LLMProvider
+	err      error
+}
+
+// newLLMProviderPool returns a pool applying overrides on top of base.
+func newLLMProviderPool(base LLMConfig) *llmProviderPool {
+	return &llmProviderPool{
+		base:    base,
+		factory: NewLLMProvider,
+		entries: make(map[LLMConfig]llmPoolEntry),
+	}
+}
+
+// built returns the providers the pool has built so far. A nil pool has none.
+func (p *llmProviderPool) built() []LLMProvider {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var providers []LLMProvider
+	for _, entry := range p.entries {
+		if entry.provider != nil {
+			providers = append(providers, entry.provider)
+		}
+	}
+	return providers
+}
+
+// providerFor returns the provider for a package. A nil override yields
+// fallback, the globally wired provider. Otherwise the override's provider is
+// built on first use; a construction failure is logged once per configuration
+// and yields nil, so the package proceeds as if no LLM were configured rather
+// than silently using the global model it opted out of. A nil pool (a Checker
+// or Analyzer built without its constructor) always yields fallback.
+func (p *llmProviderPool) providerFor(pkg string, override *PackageLLMConfig, fallback LLMProvider) LLMProvider {
+	if override == nil || p == nil {
+		return fallback
+	}
+	cfg := override.Apply(p.base)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[cfg]
+	if !ok {
+		entry.provider, entry.err = p.factory(cfg)
+		if entry.err != nil {
+			warnLogf("package %q: LLM override (provider %q, model %q) unavailable: %v",
+				pkg, cfg.Provider, cfg.Model, entry.err)
+			// Never keep a typed nil from a failed constructor; see
+			// Checker.llmClient.
+			entry.provider = nil
+		}
+		p.entries[cfg] = entry
+	}
+	return entry.provider
+}
@@ -0,0 +1,221 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ollamaRecorder is a fake Ollama server that records the model of every
+// generate request and answers with a fixed response.
+type ollamaRecorder struct {
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	models []string
+}
+
+// newOllamaRecorder starts a recording Ollama server replying with response.
+func newOllamaRecorder(t *testing.T, response string) *ollamaRecorder {
+	t.Helper()
+	rec := &ollamaRecorder{}
+	rec.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rec.mu.Lock()
+		rec.models = append(rec.models, req.Model)
+		rec.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"response": response})
+	}))
+	t.Cleanup(rec.srv.Close)
+	return rec
+}
+
+// takeModels returns and clears the recorded models.
+func (r *ollamaRecorder) takeModels() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	models := r.models
+	r.models = nil
+	return models
+}
+
+// TestCheckerUsesPerPackageLLMOverride verifies that LLM version extraction
+// for a package with an llm override goes to the overriding model, while a
+// package without one uses the global provider.
+func TestCheckerUsesPerPackageLLMOverride(t *testing.T) {
+	llm := newOllamaRecorder(t, "2.0.0")
+	// The primary regex never matches, forcing the LLM fallback.
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("release notes without a parsable version"))
+	}))
+	t.Cleanup(page.Close)
+
+	content := `["app-misc/easy"]
+url = "` + page.URL + `"
+parser = "regex"
+pattern = "version-(\\d+)"
+llm_prompt = "find the version"
+
+["app-misc/hard"]
+url = "` + page.URL + `"
+parser = "regex"
+pattern = "version-(\\d+)"
+llm_prompt = "find the version"
+llm = { model = "big-model" }
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/easy", "1.0.0")
+	createTestEbuild(t, overlay, "app-misc/hard", "1.0.0")
+
+	global := LLMConfig{Provider: "ollama", Model: "global-model", BaseURL: llm.srv.URL}
+	globalClient, err := NewOllamaClient(global)
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithLLMClient(globalClient),
+		WithLLMConfig(global),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	tests := []struct {
+		pkg       string
+		wantModel string
+	}{
+		{"app-misc/easy", "global-model"},
+		{"app-misc/hard", "big-model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			result, err := checker.CheckPackage(tt.pkg, true)
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != "2.0.0" {
+				t.Errorf("UpstreamVersion = %q, want the LLM's 2.0.0", result.UpstreamVersion)
+			}
+			models := llm.takeModels()
+			if len(models) != 1 || models[0] != tt.wantModel {
+				t.Errorf("LLM requests used models %v, want [%s]", models, tt.wantModel)
+			}
+		})
+	}
+}
+
+// TestAnalyzerUsesPerPackageLLMOverride verifies that a forced re-analysis of
+// a package whose schema sets an llm override is sent to the overriding
+// provider rather than the global one.
+func TestAnalyzerUsesPerPackageLLMOverride(t *testing.T) {
+	globalLLM := newOllamaRecorder(t, "{}")
+	overrideLLM := newOllamaRecorder(t, "{}")
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":"1.0.0"}`))
+	}))
+	t.Cleanup(source.Close)
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"easy", "hard"} {
+		pkgDir := filepath.Join(tmpDir, "app-misc", name)
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		ebuild := "EAPI=8\nHOMEPAGE=\"https://example.com\"\n"
+		if err := os.WriteFile(filepath.Join(pkgDir, name+"-1.0.0.ebuild"), []byte(ebuild), 0644); err != nil {
+			t.Fatalf("write ebuild: %v", err)
+		}
+	}
+
+	config := &PackagesConfig{
+		Packages: map[string]PackageConfig{
+			"app-misc/easy": {URL: source.URL, Parser: "json", Path: "version"},
+			"app-misc/hard": {
+				URL: source.URL, Parser: "json", Path: "version",
+				LLM: &PackageLLMConfig{Model: "big-model", BaseURL: overrideLLM.srv.URL},
+			},
+		},
+	}
+
+	global := LLMConfig{Provider: "ollama", Model: "global-model", BaseURL: globalLLM.srv.URL}
+	globalClient, err := NewOllamaClient(global)
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+	rateLimiter := createFastRateLimiter()
+	setFastHTTPLimit(rateLimiter, source.URL)
+
+	analyzer, err := NewAnalyzer(tmpDir,
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerPackagesConfig(config),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{Timeout: 5 * time.Second})),
+		WithAnalyzerLLMClient(globalClient),
+		WithAnalyzerLLMConfig(global),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer() error = %v", err)
+	}
+
+	opts := AnalyzeOptions{URL: source.URL, Force: true, NoCache: true}
+	// The fake replies are not usable schemas; only the routing matters here.
+	_, _ = analyzer.Analyze("app-misc/easy", opts)
+	_, _ = analyzer.Analyze("app-misc/hard", opts)
+
+	if got := globalLLM.takeModels(); len(got) == 0 || got[0] != "global-model" {
+		t.Errorf("global provider saw models %v, want global-model for app-misc/easy", got)
+	}
+	for _, m := range globalLLM.takeModels() {
+		t.Errorf("global provider unexpectedly saw %q", m)
+	}
+	got := overrideLLM.takeModels()
+	if len(got) == 0 {
+		t.Fatal("override provider saw no request for app-misc/hard")
+	}
+	for _, m := range got {
+		if m != "big-model" {
+			t.Errorf("override provider saw model %q, want big-model", m)
+		}
+	}
+}
+
+// TestPackageLLMConfigApply covers inheriting from and replacing the global
+// configuration.
+func TestPackageLLMConfigApply(t *testing.T) {
+	base := LLMConfig{Provider: "claude", Model: "haiku", APIKeyEnv: "ANTHROPIC_API_KEY", Bare: "auto"}
+
+	got := (&PackageLLMConfig{Model: "sonnet"}).Apply(base)
+	want := LLMConfig{Provider: "claude", Model: "sonnet", APIKeyEnv: "ANTHROPIC_API_KEY", Bare: "auto"}
+	if got != want {
+		t.Errorf("model-only override = %+v, want %+v", got, want)
+	}
+
+	got = (&PackageLLMConfig{Provider: "openai", Model: "gpt-4o"}).Apply(base)
+	want = LLMConfig{Provider: "openai", Model: "gpt-4o", Bare: "auto"}
+	if got != want {
+		t.Errorf("provider switch = %+v, want %+v (global key must not carry over)", got, want)
+	}
+}
+
+// TestValidatePackageConfigRejectsUnknownLLMProvider verifies a typo in the
+// override's provider fails validation.
+func TestValidatePackageConfigRejectsUnknownLLMProvider(t *testing.T) {
+	cfg := &PackageConfig{URL: "https://example.com", Parser: "json", Path: "version",
+		LLM: &PackageLLMConfig{Provider: "claud"}}
+	if err := ValidatePackageConfig("app-misc/foo", cfg); err == nil {
+		t.Error("ValidatePackageConfig() = nil, want an unsupported provider error")
+	}
+}
@@ -0,0 +1,89 @@
+// Package autoupdate provides LLM token usage accounting.
+package autoupdate
+
+import (
+	"sync"
+)
+
+// TokenUsage counts the tokens of one or more LLM calls, as the provider
+// reported them. Providers bill input and output tokens at different rates,
+// so the two are kept apart.
+type TokenUsage struct {
+	// InputTokens is the number of prompt tokens sent
+	InputTokens int
+	// OutputTokens is the number of tokens generated
+	OutputTokens int
+}
+
+// Total returns the input and output tokens together.
+func (u TokenUsage) Total() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Add returns the sum of u and other.
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+	}
+}
+
+// Sub returns u minus other.
+func (u TokenUsage) Sub(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:  u.InputTokens - other.InputTokens,
+		OutputTokens: u.OutputTokens - other.OutputTokens,
+	}
+}
+
+// UsageReporter is implemented by the LLM providers whose API reports token
+// usage: Claude, OpenAI, Ollama and Gemini, and the wrappers around them. It
+// is kept out of LLMProvider so a provider that cannot tell (the claude-code
+// CLI) or a test double need not implement it.
+type UsageReporter interface {
+	// LastUsage returns the usage of the provider's most recent call that
+	// got an answer, failed or not. With concurrent callers it may belong to
+	// another goroutine's call; TotalUsage is exact either way.
+	LastUsage() TokenUsage
+	// TotalUsage returns the usage of every call made so far.
+	TotalUsage() TokenUsage
+}
+
+// LLMUsage returns llm's TotalUsage, or zero when llm is nil or does not
+// report usage.
+func LLMUsage(llm LLMProvider) TokenUsage {
+	if reporter, ok := llm.(UsageReporter); ok {
+		return reporter.TotalUsage()
+	}
+	return TokenUsage{}
+}
+
+// usageRecorder implements UsageReporter for a provider embedding it. It is
+// safe for concurrent use.
+type usageRecorder struct {
+	mu    sync.Mutex
+	last  TokenUsage
+	total TokenUsage
+}
+
+// record notes the usage of a call.
+func (r *usageRecorder) record(u TokenUsage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = u
+	r.total = r.total.Add(u)
+}
+
+// LastUsage returns the usage of the most recent call.
+func (r *usageRecorder) LastUsage() TokenUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// TotalUsage returns the usage of every call so far.
+func (r *usageRecorder) TotalUsage() TokenUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
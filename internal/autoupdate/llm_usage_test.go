@@ -0,0 +1,206 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// usageProvider is an LLM provider that reports its token usage.
+type usageProvider interface {
+	LLMProvider
+	UsageReporter
+}
+
+// TestLLMUsage_Providers verifies each provider records the token usage its
+// API reports, per call in LastUsage and summed in TotalUsage.
+func TestLLMUsage_Providers(t *testing.T) {
+	t.Setenv("USAGE_TEST_KEY", "test-key")
+
+	tests := []struct {
+		name     string
+		response any
+		client   func(t *testing.T, url string) usageProvider
+	}{
+		{
+			name: "claude",
+			response: claudeResponse{
+				Content: []contentBlock{{Type: "text", Text: "1.2.3"}},
+				Usage:   claudeUsage{InputTokens: 120, OutputTokens: 7},
+			},
+			client: func(t *testing.T, url string) usageProvider {
+				t.Setenv("CLAUDE_API_ENDPOINT", url)
+				c, err := NewClaudeClient(LLMConfig{APIKeyEnv: "USAGE_TEST_KEY"})
+				if err != nil {
+					t.Fatalf("NewClaudeClient: %v", err)
+				}
+				return c
+			},
+		},
+		{
+			name: "openai",
+			response: openAIResponse{
+				Choices: []openAIChoice{{Message: openAIMessage{Role: "assistant", Content: "1.2.3"}}},
+				Usage:   openAIUsage{PromptTokens: 120, CompletionTokens: 7, TotalTokens: 127},
+			},
+			client: func(t *testing.T, url string) usageProvider {
+				c, err := NewOpenAIClient(LLMConfig{APIKeyEnv: "USAGE_TEST_KEY"})
+				if err != nil {
+					t.Fatalf("NewOpenAIClient: %v", err)
+				}
+				c.SetBaseURL(url)
+				return c
+			},
+		},
+		{
+			name:     "ollama",
+			response: ollamaResponse{Response: "1.2.3", Done: true, PromptEvalCount: 120, EvalCount: 7},
+			client: func(t *testing.T, url string) usageProvider {
+				c, err := NewOllamaClient(LLMConfig{})
+				if err != nil {
+					t.Fatalf("NewOllamaClient: %v", err)
+				}
+				c.SetBaseURL(url)
+				return c
+			},
+		},
+		{
+			name: "gemini",
+			response: geminiResponse{
+				Candidates:    []geminiCandidate{{Content: geminiContent{Parts: []geminiPart{{Text: "1.2.3"}}}}},
+				UsageMetadata: geminiUsage{PromptTokenCount: 120, CandidatesTokenCount: 7, TotalTokenCount: 127},
+			},
+			client: func(t *testing.T, url string) usageProvider {
+				c, err := NewGeminiClient(LLMConfig{APIKeyEnv: "USAGE_TEST_KEY"})
+				if err != nil {
+					t.Fatalf("NewGeminiClient: %v", err)
+				}
+				c.SetBaseURL(url)
+				return c
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(tt.response) //nolint:errcheck
+			}))
+			defer server.Close()
+			client := tt.client(t, server.URL)
+
+			if got := client.LastUsage(); got != (TokenUsage{}) {
+				t.Errorf("LastUsage() before any call = %+v, want zero", got)
+			}
+			for i := 0; i < 2; i++ {
+				if version, err := client.ExtractVersion([]byte("release 1.2.3"), ""); err != nil || version != "1.2.3" {
+					t.Fatalf("ExtractVersion() = %q, %v", version, err)
+				}
+			}
+			if got, want := client.LastUsage(), (TokenUsage{InputTokens: 120, OutputTokens: 7}); got != want {
+				t.Errorf("LastUsage() = %+v, want %+v", got, want)
+			}
+			if got, want := LLMUsage(client), (TokenUsage{InputTokens: 240, OutputTokens: 14}); got != want {
+				t.Errorf("TotalUsage() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestLLMUsage_Wrappers verifies the legacy and chain clients report the
+// usage of the providers they wrap, and that a provider without usage counts
+// as zero.
+func TestLLMUsage_Wrappers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ollamaResponse{Response: "1.2.3", Done: true, PromptEvalCount: 50, EvalCount: 3}) //nolint:errcheck
+	}))
+	defer server.Close()
+	ollama, err := NewOllamaClient(LLMConfig{})
+	if err != nil {
+		t.Fatalf("NewOllamaClient: %v", err)
+	}
+	ollama.SetBaseURL(server.URL)
+
+	failing := &chainLLMProvider{model: "down", err: ErrLLMRequestFailed}
+	chain := NewMultiLLMClientFromProviders(failing, ollama)
+	if _, err := chain.ExtractVersion([]byte("x"), ""); err != nil {
+		t.Fatalf("ExtractVersion() error = %v", err)
+	}
+	want := TokenUsage{InputTokens: 50, OutputTokens: 3}
+	if chain.LastUsage() != want || chain.TotalUsage() != want {
+		t.Errorf("chain usage = %+v last, %+v total; want %+v", chain.LastUsage(), chain.TotalUsage(), want)
+	}
+
+	legacy := &LLMClient{provider: ollama}
+	if got := legacy.TotalUsage(); got != want {
+		t.Errorf("LLMClient.TotalUsage() = %+v, want %+v", got, want)
+	}
+	if got := LLMUsage(failing); got != (TokenUsage{}) {
+		t.Errorf("LLMUsage(no reporter) = %+v, want zero", got)
+	}
+	if got := LLMUsage(nil); got != (TokenUsage{}) {
+		t.Errorf("LLMUsage(nil) = %+v, want zero", got)
+	}
+}
+
+// TestAnalyzer_LLMUsage verifies the analyzer sums the tokens of every LLM
+// analysis of an AnalyzeAll run, leaving out what its client used before.
+func TestAnalyzer_LLMUsage(t *testing.T) {
+	analysis, _ := json.Marshal(map[string]any{"parser_type": "regex", "pattern": `release (\d+\.\d+\.\d+)`, "confidence": 0.9})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/generate" {
+			json.NewEncoder(w).Encode(ollamaResponse{Response: string(analysis), Done: true, PromptEvalCount: 400, EvalCount: 60}) //nolint:errcheck
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>release 1.2.3</body></html>")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	for _, pkg := range []string{"foo", "bar"} {
+		pkgDir := filepath.Join(tmpDir, "app-misc", pkg)
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		ebuild := []byte("EAPI=8\nHOMEPAGE=\"" + server.URL + "/" + pkg + "\"\n")
+		if err := os.WriteFile(filepath.Join(pkgDir, pkg+"-1.2.3.ebuild"), ebuild, 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	ollama, err := NewOllamaClient(LLMConfig{})
+	if err != nil {
+		t.Fatalf("NewOllamaClient: %v", err)
+	}
+	ollama.SetBaseURL(server.URL)
+	// Usage from before the analyzer existed is not the run's.
+	ollama.record(TokenUsage{InputTokens: 1000, OutputTokens: 100})
+
+	cache, err := NewAnalysisCache(filepath.Join(tmpDir, "cachedir"))
+	if err != nil {
+		t.Fatalf("NewAnalysisCache: %v", err)
+	}
+	rateLimiter := createFastRateLimiter()
+	setFastHTTPLimit(rateLimiter, server.URL)
+	analyzer, err := NewAnalyzer(tmpDir,
+		WithAnalyzerLLMClient(ollama),
+		WithAnalyzerCache(cache),
+		WithAnalyzerRateLimiter(rateLimiter),
+		WithAnalyzerHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+
+	batch := analyzer.AnalyzeAll(AnalyzeOptions{DryRun: true})
+	if batch.HasFailures() || len(batch.Items) != 2 {
+		t.Fatalf("AnalyzeAll() = %d items, failures %v; want 2 items", len(batch.Items), batch.Failures)
+	}
+	if got, want := analyzer.LLMUsage(), (TokenUsage{InputTokens: 800, OutputTokens: 120}); got != want {
+		t.Errorf("LLMUsage() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// Package autoupdate provides maintainer-scoped checking based on metadata.xml.
+package autoupdate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pkgMetadata is the subset of a package's metadata.xml read here.
+type pkgMetadata struct {
+	Maintainers []struct {
+		Email string `xml:"email"`
+	} `xml:"maintainer"`
+}
+
+// PackageMaintainers returns the maintainer emails listed in the metadata.xml
+// of pkg (category/package) in overlayPath. A package without metadata.xml has
+// no maintainers and yields an empty list, not an error.
+func PackageMaintainers(overlayPath, pkg string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(overlayPath, pkg, "metadata.xml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata.xml: %w", err)
+	}
+
+	var meta pkgMetadata
+	if err := xml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/metadata.xml: %w", pkg, err)
+	}
+
+	var emails []string
+	for _, m := range meta.Maintainers {
+		if email := strings.TrimSpace(m.Email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails, nil
+}
+
+// CheckMine is CheckAll restricted to the packages whose metadata.xml lists
+// email as a maintainer, for shared overlays where each maintainer only tends
+// their own packages. Emails compare case-insensitively. A package whose
+// metadata.xml cannot be parsed is skipped with a warning rather than failing
+// the batch, since its ownership is unknown.
+func (c *Checker) CheckMine(email string, force bool) BatchResult[CheckResult] {
+	email = strings.TrimSpace(email)
+	return c.checkSelected(force, func(pkg string) bool {
+		emails, err := PackageMaintainers(c.overlayPath, pkg)
+		if err != nil {
+			warnLogf("skipping %s: %v", pkg, err)
+			return false
+		}
+		for _, e := range emails {
+			if strings.EqualFold(e, email) {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,83 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMetadataXML writes a metadata.xml for pkg listing the given maintainer
+// emails.
+func writeMetadataXML(t *testing.T, overlay, pkg string, emails ...string) {
+	t.Helper()
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE pkgmetadata SYSTEM "https://www.gentoo.org/dtd/metadata.dtd">
+<pkgmetadata>
+`
+	for _, email := range emails {
+		content += "\t<maintainer type=\"person\">\n\t\t<email>" + email + "</email>\n\t</maintainer>\n"
+	}
+	content += "</pkgmetadata>\n"
+	if err := os.WriteFile(filepath.Join(overlay, pkg, "metadata.xml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write metadata.xml: %v", err)
+	}
+}
+
+// TestCheckMineChecksOnlyMaintainersPackages verifies CheckMine checks the
+// packages listing the given maintainer (case-insensitively, including
+// co-maintained ones) and skips the others, issuing no request for them.
+func TestCheckMineChecksOnlyMaintainersPackages(t *testing.T) {
+	requested := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested[r.URL.Path] = true
+		_, _ = w.Write([]byte(`{"version":"2.0.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	pkgs := []string{"app-misc/alice-one", "app-misc/alice-two", "app-misc/bob-one", "app-misc/nobody"}
+	content := ""
+	for _, pkg := range pkgs {
+		content += `["` + pkg + `"]
+url = "` + srv.URL + "/" + pkg + `"
+parser = "json"
+path = "version"
+
+`
+	}
+	overlay, _ := writePackagesTOML(t, content)
+	for _, pkg := range pkgs {
+		createTestEbuild(t, overlay, pkg, "1.0.0")
+	}
+	writeMetadataXML(t, overlay, "app-misc/alice-one", "alice@example.com")
+	writeMetadataXML(t, overlay, "app-misc/alice-two", "bob@example.com", "Alice@Example.com")
+	writeMetadataXML(t, overlay, "app-misc/bob-one", "bob@example.com")
+	// app-misc/nobody has no metadata.xml at all.
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithConcurrency(1),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	result := checker.CheckMine("alice@example.com", true)
+	if result.HasFailures() {
+		t.Fatalf("CheckMine() failures = %v", result.Failures)
+	}
+	var checked []string
+	for _, item := range result.Items {
+		checked = append(checked, item.Package)
+	}
+	if len(checked) != 2 || checked[0] != "app-misc/alice-one" || checked[1] != "app-misc/alice-two" {
+		t.Errorf("CheckMine() checked %v, want [app-misc/alice-one app-misc/alice-two]", checked)
+	}
+	for _, pkg := range []string{"app-misc/bob-one", "app-misc/nobody"} {
+		if requested["/"+pkg] {
+			t.Errorf("%s was fetched, want it skipped", pkg)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package autoupdate provides package.json/composer.json sources for ebuild autoupdate.
+package autoupdate
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+)
+
+// manifestFiles are the package manifests whose top-level "version" field can
+// be read straight from a raw file URL (for example
+// https://raw.githubusercontent.com/owner/repo/main/composer.json).
+var manifestFiles = map[string]bool{
+	"package.json":  true,
+	"composer.json": true,
+}
+
+// manifestPlaceholderVersions are versions left in a manifest by projects that
+// do not maintain the field: npm init's default and semantic-release's
+// markers, which the real version only replaces at publish time.
+var manifestPlaceholderVersions = map[string]bool{
+	"0.0.0":                       true,
+	"0.0.0-development":           true,
+	"0.0.0-semantically-released": true,
+	"0.0.0-semantic-release":      true,
+}
+
+// manifestFileName returns "package.json" or "composer.json" when rawURL
+// points at one of those files, and "" otherwise.
+func manifestFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if name := path.Base(u.Path); manifestFiles[name] {
+		return name
+	}
+	return ""
+}
+
+// applyManifestDefaults makes a raw package.json or composer.json URL work
+// without parser settings: parser defaults to "json" and, for json, path to
+// "version". Explicit settings are kept.
+func applyManifestDefaults(cfg *PackageConfig) {
+	if manifestFileName(cfg.URL) == "" {
+		return
+	}
+	if cfg.Parser == "" {
+		cfg.Parser = "json"
+	}
+	if cfg.Parser == "json" && cfg.Path == "" {
+		cfg.Path = "version"
+	}
+}
+
+// flagManifestPlaceholder marks result NeedsReview when version was read from
+// a package.json or composer.json and is a placeholder such as 0.0.0: many
+// repositories never update the field, so it says nothing about releases.
+func flagManifestPlaceholder(url, version string, result *CheckResult) {
+	name := manifestFileName(url)
+	if name == "" || !manifestPlaceholderVersions[version] {
+		return
+	}
+	result.NeedsReview = true
+	note := fmt.Sprintf("%s declares placeholder version %s; use a release or tag source instead", name, version)
+	if result.ReviewNote != "" {
+		note = result.ReviewNote + "; " + note
+	}
+	result.ReviewNote = note
+}
@@ -0,0 +1,90 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCheckPackage_ManifestFile verifies a raw package.json or composer.json
+// URL needs no parser settings, and that a placeholder version is flagged for
+// review.
+func TestCheckPackage_ManifestFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/main/package.json":
+			_, _ = w.Write([]byte(`{"name":"tool","version":"4.2.0","private":false}`))
+		case "/main/composer.json":
+			_, _ = w.Write([]byte(`{"name":"vendor/lib","version":"1.7.3","require":{"php":">=8.1"}}`))
+		case "/placeholder/package.json":
+			_, _ = w.Write([]byte(`{"name":"app","version":"0.0.0-development"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name        string
+		path        string
+		want        string
+		needsReview bool
+	}{
+		{"package.json", "/main/package.json", "4.2.0", false},
+		{"composer.json", "/main/composer.json", "1.7.3", false},
+		{"placeholder", "/placeholder/package.json", "0.0.0-development", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay, _ := writePackagesTOML(t, `["dev-php/lib"]
+url = "`+srv.URL+tt.path+`"
+`)
+			createTestEbuild(t, overlay, "dev-php/lib", "1.0.0")
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("dev-php/lib", true)
+			// The placeholder is not comparable; the result still holds it.
+			var cmpErr *ComparisonError
+			if err != nil && !errors.As(err, &cmpErr) {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != tt.want {
+				t.Errorf("UpstreamVersion = %q, want %q", result.UpstreamVersion, tt.want)
+			}
+			if result.NeedsReview != tt.needsReview {
+				t.Errorf("NeedsReview = %v (note %q), want %v", result.NeedsReview, result.ReviewNote, tt.needsReview)
+			}
+			if tt.needsReview && !strings.Contains(result.ReviewNote, "placeholder") {
+				t.Errorf("ReviewNote = %q, want it to name the placeholder", result.ReviewNote)
+			}
+		})
+	}
+}
+
+// TestDiscoverDataSources_ManifestFile verifies a provided manifest URL is
+// discovered as a manifest source and gets a json/version schema without
+// LLM analysis.
+func TestDiscoverDataSources_ManifestFile(t *testing.T) {
+	const raw = "https://raw.githubusercontent.com/vendor/lib/main/composer.json"
+	sources := DiscoverDataSources(&EbuildMetadata{}, raw)
+	if len(sources) == 0 || sources[0].Type != "manifest" {
+		t.Fatalf("DiscoverDataSources() = %+v, want a manifest source first", sources)
+	}
+
+	a := &Analyzer{}
+	schema, err := a.analyzeContent(nil, []byte(`{"version":"1.7.3"}`), &EbuildMetadata{}, "", &sources[0])
+	if err != nil {
+		t.Fatalf("analyzeContent() error = %v", err)
+	}
+	if schema.Parser != "json" || schema.Path != "version" || schema.URL != raw {
+		t.Errorf("schema = %+v, want json parser with path \"version\"", schema)
+	}
+}
@@ -0,0 +1,102 @@
+// Package autoupdate provides GitHub milestone parsing for ebuild autoupdate.
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidMilestones is returned when the content is not a GitHub milestone
+// list.
+var ErrInvalidMilestones = errors.New("invalid GitHub milestones response")
+
+// githubMilestonesQuery asks for closed milestones, most recently due first.
+const githubMilestonesQuery = "state=closed&sort=due_on&direction=desc"
+
+// githubMilestone is the subset of a GitHub milestone object read here.
+type githubMilestone struct {
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+// GitHubMilestoneParser extracts a version from the title of the newest closed
+// milestone of a GitHub repository.
+//
+// A few projects plan and announce releases as milestones ("Release 4.2") and
+// tag late or not at all, so the closed milestone is the earliest reliable
+// signal. This is deliberately opt-in: it is only used when a package sets
+// parser = "github-milestone", and discovery never proposes it, because for
+// most repositories milestones track sprints or wish lists, not releases.
+//
+// The response is expected newest first (see githubMilestonesURL); the first
+// closed milestone whose title matches Pattern wins, so titles such as
+// "Backlog" or "Someday" in between are skipped.
+type GitHubMilestoneParser struct {
+	// Pattern is a regex with one capture group for the version in the title.
+	Pattern  string
+	compiled *regexp.Regexp
+}
+
+// NewGitHubMilestoneParser returns a parser for pattern, which must compile
+// and have a capture group.
+func NewGitHubMilestoneParser(pattern string) (*GitHubMilestoneParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, ErrNoCaptureGroup
+	}
+	return &GitHubMilestoneParser{Pattern: pattern, compiled: re}, nil
+}
+
+// Parse returns the version captured from the newest matching closed
+// milestone title.
+func (p *GitHubMilestoneParser) Parse(content []byte) (string, error) {
+	var milestones []githubMilestone
+	if err := json.Unmarshal(content, &milestones); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMilestones, err)
+	}
+
+	for _, m := range milestones {
+		if m.State != "" && m.State != "closed" {
+			continue
+		}
+		if match := p.compiled.FindStringSubmatch(m.Title); match != nil && match[1] != "" {
+			return match[1], nil
+		}
+	}
+	return "", fmt.Errorf("%w: no closed milestone title matches %q", ErrNoVersionFound, p.Pattern)
+}
+
+// githubMilestonesURL returns the milestones API URL for a package URL. A
+// github.com or api.github.com repository URL is rewritten to
+// /repos/<owner>/<repo>/milestones; an explicit .../milestones URL is kept.
+// Either way the closed, due-date-descending query is added when the URL has
+// no query of its own. Keeping the request on api.github.com means the
+// configured GitHub token is applied by the HTTP client.
+func githubMilestonesURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if !strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/milestones") {
+		m := githubRepoURLRe.FindStringSubmatch(rawURL)
+		if m == nil {
+			return rawURL
+		}
+		u = &url.URL{
+			Scheme: "https",
+			Host:   "api.github.com",
+			Path:   fmt.Sprintf("/repos/%s/%s/milestones", m[1], strings.TrimSuffix(m[2], ".git")),
+		}
+	}
+	if u.RawQuery == "" {
+		u.RawQuery = githubMilestonesQuery
+	}
+	return u.String()
+}
@@ -0,0 +1,74 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// milestonesSample is a GitHub milestones response, newest due first. The
+// first entry is not a release and must be skipped.
+const milestonesSample = `[
+  {"title": "Backlog grooming", "state": "closed"},
+  {"title": "Release 4.2.0", "state": "closed"},
+  {"title": "Release 4.1.3", "state": "closed"}
+]`
+
+// TestCheckPackageGitHubMilestone verifies the newest closed milestone whose
+// title matches the pattern supplies the version, and that the closed,
+// due-date-descending query is sent.
+func TestCheckPackageGitHubMilestone(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/tool/milestones" {
+			http.NotFound(w, r)
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte(milestonesSample))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-misc/tool"]
+url = "` + srv.URL + `/repos/acme/tool/milestones"
+parser = "github-milestone"
+pattern = "^Release ([0-9.]+)$"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/tool", "4.1.3")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-misc/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "4.2.0" || !result.HasUpdate {
+		t.Errorf("CheckPackage() = %q (HasUpdate %v), want update to 4.2.0", result.UpstreamVersion, result.HasUpdate)
+	}
+	if gotQuery != githubMilestonesQuery {
+		t.Errorf("query = %q, want %q", gotQuery, githubMilestonesQuery)
+	}
+}
+
+// TestGitHubMilestonesURL covers deriving the milestones API URL.
+func TestGitHubMilestonesURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://github.com/acme/tool", "https://api.github.com/repos/acme/tool/milestones?" + githubMilestonesQuery},
+		{"https://github.com/acme/tool.git", "https://api.github.com/repos/acme/tool/milestones?" + githubMilestonesQuery},
+		{"https://api.github.com/repos/acme/tool/milestones?state=all", "https://api.github.com/repos/acme/tool/milestones?state=all"},
+		{"https://example.com/tool", "https://example.com/tool"},
+	}
+	for _, tt := range tests {
+		if got := githubMilestonesURL(tt.in); got != tt.want {
+			t.Errorf("githubMilestonesURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package autoupdate provides negative caching of failed upstream lookups.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCachedFailure is returned by CheckPackage when the package's upstream
+// failed recently and the failure is served from the cache instead of asking
+// the upstream again. It wraps nothing: the original error only survives as
+// text, since the cache persists across runs.
+var ErrCachedFailure = errors.New("upstream lookup failed recently")
+
+// FailureEntry is a cached failed upstream lookup.
+type FailureEntry struct {
+	// Error is the failure's message
+	Error string `json:"error"`
+	// Timestamp is when the lookup failed
+	Timestamp time.Time `json:"timestamp"`
+	// Source is the URL that failed
+	Source string `json:"source"`
+}
+
+// WithNegativeTTL enables negative caching: a failure recorded with
+// SetFailure is served by GetFailure for ttl, so a dead upstream is not asked
+// again (with all its retries) on every run. A non-positive ttl, the default,
+// disables it.
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.negativeTTL = max(ttl, 0)
+	}
+}
+
+// WithNegativeCacheTTL sets the negative TTL of the default Cache constructed
+// by NewChecker (see WithNegativeTTL); ignored when a Cache is injected via
+// WithCache. While a package's failure is cached, CheckPackage fails with
+// ErrCachedFailure without a request; force bypasses it, and a success clears
+// it.
+func WithNegativeCacheTTL(ttl time.Duration) CheckerOption {
+	return func(c *Checker) error {
+		if ttl < 0 {
+			return fmt.Errorf("negative cache TTL must not be negative, got %s", ttl)
+		}
+		c.negativeTTL = ttl
+		return nil
+	}
+}
+
+// NegativeTTL returns how long failures are cached, zero when negative
+// caching is disabled.
+func (c *Cache) NegativeTTL() time.Duration {
+	return c.negativeTTL
+}
+
+// failureExpired reports whether a cached failure is past the negative TTL.
+func (c *Cache) failureExpired(failure FailureEntry) bool {
+	return c.nowFunc().Sub(failure.Timestamp) >= c.negativeTTL
+}
+
+// SetFailure records that looking up pkg at source failed with err. It is a
+// no-op when negative caching is disabled. A later Set or SetWithBody for pkg
+// clears it.
+func (c *Cache) SetFailure(pkg, source string, err error) error {
+	if c.negativeTTL <= 0 || err == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures[pkg] = FailureEntry{
+		Error:     err.Error(),
+		Timestamp: c.nowFunc(),
+		Source:    source,
+	}
+	return c.saveUnsafe()
+}
+
+// GetFailure returns pkg's cached failure while it is younger than the
+// negative TTL. It reports false when negative caching is disabled, which
+// also ignores failures a previous run with it enabled left in the file.
+func (c *Cache) GetFailure(pkg string) (FailureEntry, bool) {
+	if c.negativeTTL <= 0 {
+		return FailureEntry{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	failure, exists := c.failures[pkg]
+	if !exists || c.failureExpired(failure) {
+		return FailureEntry{}, false
+	}
+	return failure, true
+}
+
+// GetFailureWithForce is GetFailure, always missing when force is set, like
+// GetWithForce.
+func (c *Cache) GetFailureWithForce(pkg string, force bool) (FailureEntry, bool) {
+	if force {
+		return FailureEntry{}, false
+	}
+	return c.GetFailure(pkg)
+}
+
+// cachedFailureError builds the error CheckPackage returns for a cached
+// failure: a FetchError, as the failure it stands for, matching both
+// ErrCachedFailure and ErrFetchFailed.
+func (c *Checker) cachedFailureError(pkg string, failure FailureEntry) error {
+	retryIn := failure.Timestamp.Add(c.cache.NegativeTTL()).Sub(c.cache.nowFunc()).Round(time.Second)
+	return &FetchError{
+		Package: pkg,
+		URL:     failure.Source,
+		Err:     fmt.Errorf("%w (retrying in %s; force to retry now): %s", ErrCachedFailure, retryIn, failure.Error),
+	}
+}
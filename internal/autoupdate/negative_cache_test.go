@@ -0,0 +1,198 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCache_NegativeTTL covers recording, serving, forcing past, expiring,
+// persisting and clearing a cached failure, and the disabled default.
+func TestCache_NegativeTTL(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	lookupErr := errors.New("connection refused")
+
+	disabled, err := NewCache(t.TempDir(), WithNowFunc(clock))
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if err := disabled.SetFailure("app-misc/foo", "https://example.com", lookupErr); err != nil {
+		t.Fatalf("SetFailure() error = %v", err)
+	}
+	if _, ok := disabled.GetFailure("app-misc/foo"); ok {
+		t.Error("GetFailure() hit with negative caching disabled")
+	}
+
+	cache, err := NewCache(dir, WithNowFunc(clock), WithNegativeTTL(15*time.Minute))
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if err := cache.SetFailure("app-misc/foo", "https://example.com", lookupErr); err != nil {
+		t.Fatalf("SetFailure() error = %v", err)
+	}
+	failure, ok := cache.GetFailure("app-misc/foo")
+	if !ok || failure.Error != "connection refused" || failure.Source != "https://example.com" || !failure.Timestamp.Equal(now) {
+		t.Fatalf("GetFailure() = %+v, %v; want the recorded failure", failure, ok)
+	}
+	if _, ok := cache.GetFailureWithForce("app-misc/foo", true); ok {
+		t.Error("GetFailureWithForce(force) hit, want a miss")
+	}
+	if _, ok := cache.Get("app-misc/foo"); ok {
+		t.Error("a failure must not be served as a version")
+	}
+
+	reloaded, err := NewCache(dir, WithNowFunc(clock), WithNegativeTTL(15*time.Minute))
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if _, ok := reloaded.GetFailure("app-misc/foo"); !ok {
+		t.Error("failure not persisted across a reload")
+	}
+
+	now = now.Add(15 * time.Minute)
+	if _, ok := cache.GetFailure("app-misc/foo"); ok {
+		t.Error("GetFailure() hit after the negative TTL")
+	}
+	if dropped, err := cache.Prune(); err != nil || dropped != 1 {
+		t.Errorf("Prune() = %d, %v; want the expired failure dropped", dropped, err)
+	}
+
+	if err := cache.SetFailure("app-misc/foo", "https://example.com", lookupErr); err != nil {
+		t.Fatalf("SetFailure() error = %v", err)
+	}
+	if err := cache.Set("app-misc/foo", "1.0", "https://example.com"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, ok := cache.GetFailure("app-misc/foo"); ok {
+		t.Error("a successful Set did not clear the failure")
+	}
+}
+
+// TestCheckPackage_NegativeCache verifies a failed lookup is cached and the
+// next check short-circuits without a request, force bypasses it, it is
+// retried after expiry, and a success clears it.
+func TestCheckPackage_NegativeCache(t *testing.T) {
+	var requests atomic.Int32
+	var up atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !up.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"2.0"}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	createTestEbuild(t, overlayDir, "app-misc/foo", "1.0")
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache, err := NewCache(filepath.Join(tmpDir, "config"),
+		WithNowFunc(func() time.Time { return now }),
+		WithNegativeTTL(15*time.Minute))
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	client := NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(filepath.Join(tmpDir, "config")),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{
+			"app-misc/foo": {URL: server.URL, Parser: "json", Path: "version"},
+		}}),
+		WithCache(cache),
+		WithHTTPClient(client),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	check := func(force bool) error {
+		t.Helper()
+		_, err := checker.CheckPackage("app-misc/foo", force)
+		return err
+	}
+
+	if err := check(false); err == nil || errors.Is(err, ErrCachedFailure) {
+		t.Fatalf("first check error = %v, want a live fetch failure", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("requests after the first check = %d, want 1", got)
+	}
+
+	err = check(false)
+	var fetchErr *FetchError
+	if !errors.Is(err, ErrCachedFailure) || !errors.Is(err, ErrFetchFailed) || !errors.As(err, &fetchErr) || fetchErr.URL != server.URL {
+		t.Errorf("second check error = %v, want a cached FetchError for %s", err, server.URL)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests after the cached check = %d, want still 1", got)
+	}
+
+	if err := check(true); errors.Is(err, ErrCachedFailure) {
+		t.Errorf("forced check error = %v, want a live fetch", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests after the forced check = %d, want 2", got)
+	}
+
+	now = now.Add(16 * time.Minute)
+	up.Store(true)
+	if err := check(false); err != nil {
+		t.Fatalf("check after expiry error = %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests after expiry = %d, want 3", got)
+	}
+	if _, ok := cache.GetFailure("app-misc/foo"); ok {
+		t.Error("the success did not clear the cached failure")
+	}
+}
+
+// TestCheckAll_CachedFailureNotCountedForQuarantine verifies a failure served
+// from the negative cache does not advance the quarantine streak.
+func TestCheckAll_CachedFailureNotCountedForQuarantine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	createTestEbuild(t, overlayDir, "app-misc/foo", "1.0")
+
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(filepath.Join(tmpDir, "config")),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{
+			"app-misc/foo": {URL: server.URL, Parser: "json", Path: "version"},
+		}}),
+		WithNegativeCacheTTL(time.Hour),
+		WithQuarantineThreshold(2),
+		WithHTTPClient(NewRetryableHTTPClientWithConfig(RetryConfig{MaxRetries: 0, Timeout: 5 * time.Second})),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+
+	for run := 1; run <= 2; run++ {
+		batch := checker.CheckAll(false)
+		if _, failed := batch.Failures["app-misc/foo"]; !failed {
+			t.Fatalf("run %d: app-misc/foo did not fail", run)
+		}
+	}
+	if !errors.Is(checker.CheckAll(false).Failures["app-misc/foo"], ErrCachedFailure) {
+		t.Fatal("the later runs were not served from the negative cache")
+	}
+	if checker.Pending().IsQuarantined("app-misc/foo") {
+		t.Error("cached failures advanced the quarantine streak")
+	}
+}
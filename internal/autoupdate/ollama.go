@@ -21,6 +21,8 @@ type OllamaClient struct {
 	// It defaults to httputil.MaxBodyBytes and can be overridden via
 	// WithMaxBodyBytes (R11.2).
 	maxBodyBytes int64
+	// usageRecorder keeps the token usage the API reports, see UsageReporter
+	usageRecorder
 }
 
 // ollamaRequest represents the request body for Ollama Generate API
@@ -52,6 +54,12 @@ type ollamaResponse struct {
 	EvalDuration       int64  `json:"eval_duration,omitempty"`
 }
 
+// tokenUsage returns the response's usage: Ollama counts the prompt tokens it
+// evaluated and the tokens it generated.
+func (r ollamaResponse) tokenUsage() TokenUsage {
+	return TokenUsage{InputTokens: r.PromptEvalCount, OutputTokens: r.EvalCount}
+}
+
 // ollamaErrorResponse represents an error response from Ollama API
 type ollamaErrorResponse struct {
 	Error string `json:"error"`
@@ -172,6 +180,7 @@ func (c *OllamaClient) ExtractVersion(content []byte, prompt string) (string, er
 	if err := json.Unmarshal(body, &ollamaResp); err != nil {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.record(ollamaResp.tokenUsage())
 
 	// Extract text from response
 	version := ollamaResp.Response
@@ -246,6 +255,7 @@ func (c *OllamaClient) AnalyzeContent(content []byte, meta *EbuildMetadata, hint
 	if err := json.Unmarshal(body, &ollamaResp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.record(ollamaResp.tokenUsage())
 
 	// Extract text from response
 	text := ollamaResp.Response
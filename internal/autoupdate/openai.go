@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/obentoo/bentoolkit/internal/common/httputil"
@@ -15,6 +16,9 @@ import (
 const (
 	// DefaultOpenAIEndpoint is the default OpenAI API base URL.
 	DefaultOpenAIEndpoint = "https://api.openai.com/v1"
+	// openAIChatCompletionsPath is the Chat Completions endpoint relative to
+	// an OpenAI-compatible base URL.
+	openAIChatCompletionsPath = "/chat/completions"
 	// DefaultOpenAIModel is the default OpenAI model.
 	DefaultOpenAIModel = "gpt-4o-mini"
 )
@@ -29,6 +33,8 @@ type OpenAIClient struct {
 	// It defaults to httputil.MaxBodyBytes and can be overridden via
 	// WithMaxBodyBytes (R11.2).
 	maxBodyBytes int64
+	// usageRecorder keeps the token usage the API reports, see UsageReporter
+	usageRecorder
 }
 
 // openAIRequest represents the request body for OpenAI Chat Completions API
@@ -69,6 +75,11 @@ type openAIUsage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// tokenUsage converts the usage to a TokenUsage.
+func (u openAIUsage) tokenUsage() TokenUsage {
+	return TokenUsage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens}
+}
+
 // openAIErrorResponse represents an error response from OpenAI API
 type openAIErrorResponse struct {
 	Error struct {
@@ -106,7 +117,8 @@ func NewOpenAIClient(cfg LLMConfig) (*OpenAIClient, error) {
 		model = DefaultOpenAIModel
 	}
 
-	// Set default base URL
+	// Set default base URL. Any OpenAI-compatible server (LocalAI, vLLM,
+	// OpenRouter, a gateway) is reached by pointing BaseURL at it.
 	baseURL := cfg.BaseURL
 	if baseURL == "" {
 		baseURL = DefaultOpenAIEndpoint
@@ -171,7 +183,7 @@ func (c *OpenAIClient) ExtractVersion(content []byte, prompt string) (string, er
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewReader(reqJSON))
+	req, err := http.NewRequest("POST", openAIChatCompletionsURL(c.baseURL), bytes.NewReader(reqJSON))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -207,6 +219,7 @@ func (c *OpenAIClient) ExtractVersion(content []byte, prompt string) (string, er
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.record(openAIResp.Usage.tokenUsage())
 
 	// Extract text from response
 	version := extractTextFromOpenAIResponse(openAIResp)
@@ -248,7 +261,7 @@ func (c *OpenAIClient) AnalyzeContent(content []byte, meta *EbuildMetadata, hint
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewReader(reqJSON))
+	req, err := http.NewRequest("POST", openAIChatCompletionsURL(c.baseURL), bytes.NewReader(reqJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -284,6 +297,7 @@ func (c *OpenAIClient) AnalyzeContent(content []byte, meta *EbuildMetadata, hint
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.record(openAIResp.Usage.tokenUsage())
 
 	// Extract text from response
 	text := extractTextFromOpenAIResponse(openAIResp)
@@ -305,6 +319,27 @@ func (c *OpenAIClient) SetBaseURL(url string) {
 	c.baseURL = url
 }
 
+// openAIChatCompletionsURL returns the Chat Completions URL for an
+// OpenAI-compatible base URL. Self-hosted servers are documented with every
+// shape of base URL, so all of them are accepted:
+//
+//   - a bare host ("http://localhost:8080", trailing slash or not) gets the
+//     "/v1" prefix every OpenAI-compatible server serves the API under;
+//   - a URL with a path ("https://api.openai.com/v1",
+//     "https://openrouter.ai/api/v1", a gateway's "/openai") is the API root
+//     as given, since not every gateway puts its API under /v1;
+//   - a URL already ending in "/chat/completions" is used unchanged.
+func openAIChatCompletionsURL(baseURL string) string {
+	base := strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(base, openAIChatCompletionsPath) {
+		return base
+	}
+	if u, err := url.Parse(base); err == nil && u.Host != "" && u.Path == "" {
+		base += "/v1"
+	}
+	return base + openAIChatCompletionsPath
+}
+
 // extractTextFromOpenAIResponse extracts the text content from OpenAI's response
 func extractTextFromOpenAIResponse(resp openAIResponse) string {
 	if len(resp.Choices) == 0 {
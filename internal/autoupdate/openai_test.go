@@ -194,3 +194,55 @@ func TestOpenAIClient_WithCustomMaxBody(t *testing.T) {
 		t.Errorf("expected ErrResponseTooLarge, got: %v", err)
 	}
 }
+
+// TestOpenAIClient_BaseURLShapes verifies the Chat Completions request lands
+// on the right path for every shape of OpenAI-compatible base URL, configured
+// through LLMConfig.BaseURL, and that an empty BaseURL means the OpenAI API.
+func TestOpenAIClient_BaseURLShapes(t *testing.T) {
+	t.Setenv("OPENAI_TEST_KEY", "test-key")
+
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewEncoder(w).Encode(openAIResponse{
+			Choices: []openAIChoice{{Message: openAIMessage{Role: "assistant", Content: "1.2.3"}}},
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		baseURL  string
+		wantPath string
+	}{
+		{server.URL, "/v1/chat/completions"},
+		{server.URL + "/", "/v1/chat/completions"},
+		{server.URL + "/v1", "/v1/chat/completions"},
+		{server.URL + "/v1/", "/v1/chat/completions"},
+		{server.URL + "/api/v1", "/api/v1/chat/completions"},
+		{server.URL + "/v1beta/openai/", "/v1beta/openai/chat/completions"},
+		{server.URL + "/v1/chat/completions", "/v1/chat/completions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			gotPath = ""
+			client, err := NewOpenAIClient(LLMConfig{APIKeyEnv: "OPENAI_TEST_KEY", BaseURL: tt.baseURL})
+			if err != nil {
+				t.Fatalf("NewOpenAIClient() error = %v", err)
+			}
+			if _, err := client.ExtractVersion([]byte("some content"), ""); err != nil {
+				t.Fatalf("ExtractVersion() error = %v", err)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("request path = %q, want %q", gotPath, tt.wantPath)
+			}
+		})
+	}
+
+	client, err := NewOpenAIClient(LLMConfig{APIKeyEnv: "OPENAI_TEST_KEY"})
+	if err != nil {
+		t.Fatalf("NewOpenAIClient() error = %v", err)
+	}
+	if got, want := openAIChatCompletionsURL(client.baseURL), "https://api.openai.com/v1/chat/completions"; got != want {
+		t.Errorf("default endpoint = %q, want %q", got, want)
+	}
+}
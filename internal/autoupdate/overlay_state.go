@@ -0,0 +1,48 @@
+// Package autoupdate provides per-overlay state directories, so several
+// overlays can be checked from one configuration without their caches and
+// pending lists colliding.
+package autoupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+)
+
+// overlayStateSubdir is the subdirectory of the config directory holding the
+// state of the overlays after the primary one.
+const overlayStateSubdir = "overlays"
+
+// OverlayStateDir returns the directory, under configDir, holding the state
+// (version and content caches, pending list, history) of overlayPath when it
+// is not the primary overlay, whose state stays in configDir itself so a
+// single-overlay setup keeps its files where they always were.
+//
+// The directory is named after the overlay's base name, for a reader browsing
+// configDir, plus a hash of its cleaned path, so two overlays sharing a base
+// name in different parents do not share state either.
+func OverlayStateDir(configDir, overlayPath string) string {
+	clean := filepath.Clean(overlayPath)
+	sum := sha256.Sum256([]byte(clean))
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, filepath.Base(clean))
+	return filepath.Join(configDir, overlayStateSubdir, name+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// WithStateDir keeps the Checker's per-overlay state (the version and content
+// caches, the pending list and the history) in dir instead of the config
+// directory set via WithConfigDir, which still supplies the user's settings
+// shared by every overlay, such as ratelimits.toml. An empty dir keeps the
+// state in the config directory.
+func WithStateDir(dir string) CheckerOption {
+	return func(c *Checker) error {
+		c.stateDir = dir
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package autoupdate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOverlayStateDir verifies each overlay gets its own directory under the
+// config directory, stable across calls and distinct for same-named overlays.
+func TestOverlayStateDir(t *testing.T) {
+	configDir := "/home/me/.config/bentoo/autoupdate"
+
+	a := OverlayStateDir(configDir, "/srv/one/my overlay")
+	if a != OverlayStateDir(configDir, "/srv/one/my overlay/") {
+		t.Errorf("OverlayStateDir() is not stable across equivalent paths")
+	}
+	if filepath.Dir(a) != filepath.Join(configDir, "overlays") {
+		t.Errorf("OverlayStateDir() = %q, want a directory under %s/overlays", a, configDir)
+	}
+	if base := filepath.Base(a); !strings.HasPrefix(base, "my_overlay-") {
+		t.Errorf("OverlayStateDir() base = %q, want the sanitized overlay name first", base)
+	}
+	if b := OverlayStateDir(configDir, "/srv/two/my overlay"); a == b {
+		t.Errorf("two overlays named alike share the state directory %q", a)
+	}
+}
+
+// TestWithStateDir verifies the Checker keeps its cache and pending list in
+// the state directory while still reading ratelimits.toml from the config
+// directory.
+func TestWithStateDir(t *testing.T) {
+	overlayDir := t.TempDir()
+	configDir := t.TempDir()
+	stateDir := filepath.Join(configDir, "overlays", "other")
+	writeRateLimits(t, configDir, "[default]\nrps = 2\n")
+
+	limiter := NewRateLimiter()
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(configDir),
+		WithStateDir(stateDir),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithRateLimiter(limiter),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	if err := checker.Cache().Set("app-misc/foo", "1.0", "https://example.com"); err != nil {
+		t.Fatalf("Cache().Set() error = %v", err)
+	}
+	if err := checker.Pending().Add(PendingUpdate{Package: "app-misc/foo", CurrentVersion: "1.0", NewVersion: "2.0"}); err != nil {
+		t.Fatalf("Pending().Add() error = %v", err)
+	}
+
+	for _, name := range []string{cacheStoreName, pendingStoreName} {
+		if _, err := os.Stat(filepath.Join(stateDir, name)); err != nil {
+			t.Errorf("%s not in the state directory: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(configDir, name)); err == nil {
+			t.Errorf("%s written to the config directory too", name)
+		}
+	}
+	if got := limiter.HTTPLimit("example.com"); got != 2 {
+		t.Errorf("HTTPLimit() = %v, want ratelimits.toml from the config directory applied", got)
+	}
+}
@@ -34,11 +34,35 @@ type Parser interface {
 	Parse(content []byte) (string, error)
 }
 
+// ArrayMatch selects one element of a JSON array by comparing a field of each
+// element against a fixed value. It addresses responses such as
+// [{"name":"stable","version":"1.2.3"},{"name":"beta","version":"2.0.0-rc"}]
+// where positional indexing ([0]) breaks as soon as upstream reorders entries.
+type ArrayMatch struct {
+	// Array is the JSON path to the array to search. Empty means the document
+	// root is the array.
+	Array string `toml:"array,omitempty"`
+	// Field is the JSON path, relative to each element, of the value to compare
+	// (e.g. "name", "channel.id").
+	Field string `toml:"field"`
+	// Equals is the value the element's Field must equal. Non-string JSON
+	// scalars are compared by their string form (e.g. true -> "true").
+	Equals string `toml:"equals"`
+}
+
 // JSONParser extracts version using a JSON path.
 // The path supports dot notation and array indexing (e.g., "notes[0].version").
+// It may end with "| length", "| first", "| last" or "| max" to reduce an
+// array instead of selecting one element, optionally through a "[*]"
+// wildcard: "releases | max", "tags[*].name | last". See jsonPathFuncs for
+// the grammar.
 type JSONParser struct {
-	// Path is the JSON path to the version field (e.g., "notes[0].version", "tag_name")
+	// Path is the JSON path to the version field (e.g., "notes[0].version",
+	// "tag_name", "tags[*].name | max")
 	Path string
+	// Match, when set, first selects the array element whose field equals the
+	// given value; Path is then resolved relative to that element.
+	Match *ArrayMatch
 }
 
 // Parse extracts a version string from JSON content using the configured path.
@@ -50,12 +74,36 @@ func (p *JSONParser) Parse(content []byte) (string, error) {
 
 	// Parse JSON into generic interface
 	var data interface{}
-	if err := json.Unmarshal(content, &data); err != nil {
+	if err := json.Unmarshal(unwrapJSONP(content), &data); err != nil {
 		return "", fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
+	// Narrow the document to the matching array element, if configured
+	if p.Match != nil {
+		elem, err := matchJSONArrayElement(data, p.Match)
+		if err != nil {
+			return "", err
+		}
+		data = elem
+	}
+
+	return extractJSONPath(data, p.Path)
+}
+
+// extractJSONPath resolves a JSON path expression, including any trailing
+// function, against decoded JSON and returns the scalar it selects as a
+// string.
+func extractJSONPath(data interface{}, expr string) (string, error) {
+	path, fn, err := splitJSONPathFunc(expr)
+	if err != nil {
+		return "", err
+	}
+	if fn != "" {
+		return evalJSONPathFunc(data, path, fn)
+	}
+
 	// Navigate the path
-	result, err := navigateJSONPath(data, p.Path)
+	result, err := navigateJSONPath(data, path)
 	if err != nil {
 		return "", err
 	}
@@ -111,6 +159,40 @@ func navigateJSONPath(data interface{}, path string) (interface{}, error) {
 	return current, nil
 }
 
+// matchJSONArrayElement returns the first element of the array at m.Array
+// whose m.Field value equals m.Equals. Elements lacking the field, or whose
+// field is not a scalar, are skipped rather than treated as errors, so a
+// heterogeneous array still matches on the entries that carry the field.
+func matchJSONArrayElement(data interface{}, m *ArrayMatch) (interface{}, error) {
+	if m.Field == "" {
+		return nil, fmt.Errorf("%w: match requires a field", ErrInvalidJSONPath)
+	}
+
+	node, err := navigateJSONPath(data, m.Array)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: match target %q is not an array", ErrJSONPathNotFound, m.Array)
+	}
+
+	for _, elem := range arr {
+		val, err := navigateJSONPath(elem, m.Field)
+		if err != nil {
+			if errors.Is(err, ErrInvalidJSONPath) {
+				return nil, err
+			}
+			continue
+		}
+		if s, ok := toString(val); ok && s == m.Equals {
+			return elem, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no array element with %s == %q", ErrJSONPathNotFound, m.Field, m.Equals)
+}
+
 // segmentType represents the type of path segment
 type segmentType int
 
@@ -280,13 +362,16 @@ func (p *RegexParser) Parse(content []byte) (string, error) {
 }
 
 // NewParser creates a parser based on the specified type.
-// parserType must be "json", "regex", or "html".
-// pathOrPattern is the JSON path for json parser or regex pattern for regex parser.
+// parserType must be "json", "yaml", "regex", "html", or "plist".
+// pathOrPattern is the JSON path for json and yaml parsers, regex pattern for regex
+// parser, or dict key for plist parser.
 // For HTML parser, use NewParserFromConfig instead.
 func NewParser(parserType, pathOrPattern string) (Parser, error) {
 	switch parserType {
 	case "json":
 		return &JSONParser{Path: pathOrPattern}, nil
+	case "yaml":
+		return &YAMLParser{Path: pathOrPattern}, nil
 	case "regex":
 		// Validate regex pattern upfront
 		re, err := regexp.Compile(pathOrPattern)
@@ -300,6 +385,8 @@ func NewParser(parserType, pathOrPattern string) (Parser, error) {
 	case "html":
 		// HTML parser requires selector or xpath, use NewParserFromConfig
 		return nil, fmt.Errorf("%w: use NewParserFromConfig for html parser", ErrInvalidParserType)
+	case "plist":
+		return &PlistParser{Path: pathOrPattern}, nil
 	default:
 		return nil, fmt.Errorf("%w: got %q", ErrInvalidParserType, parserType)
 	}
@@ -307,30 +394,26 @@ func NewParser(parserType, pathOrPattern string) (Parser, error) {
 
 // NewParserFromConfig creates a parser from a PackageConfig.
 // This supports all parser types including HTML which requires additional fields.
+// Parsers are built from parserRegistry (see ParserSpecs).
 func NewParserFromConfig(cfg *PackageConfig) (Parser, error) {
-	switch cfg.Parser {
-	case "json":
-		return &JSONParser{Path: cfg.Path}, nil
-	case "regex":
-		re, err := regexp.Compile(cfg.Pattern)
-		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
-		}
-		if re.NumSubexp() < 1 {
-			return nil, ErrNoCaptureGroup
-		}
-		return &RegexParser{Pattern: cfg.Pattern, compiled: re}, nil
-	case "html":
-		return NewHTMLParser(cfg.Selector, cfg.XPath, cfg.Pattern)
-	default:
+	spec, ok := lookupParser(cfg.Parser)
+	if !ok || spec.build == nil {
 		return nil, fmt.Errorf("%w: got %q", ErrInvalidParserType, cfg.Parser)
 	}
+	return spec.build(cfg)
 }
 
 // ParseVersion attempts to extract version using configured parsers with fallback logic.
 // It tries the primary parser first, then fallback parser if configured, and returns
 // the first successful result.
 func ParseVersion(content []byte, cfg *PackageConfig) (string, error) {
+	if cfg.StripANSI {
+		content = stripANSI(content)
+	}
+	if cfg.StripJSONP {
+		content = unwrapJSONP(content)
+	}
+
 	// Try primary parser
 	parser, err := NewParserFromConfig(cfg)
 	if err != nil {
@@ -364,6 +447,19 @@ func ParseVersion(content []byte, cfg *PackageConfig) (string, error) {
 		}
 	}
 
-	// All parsers failed
-	return "", fmt.Errorf("%w: %v", ErrNoVersionFound, primaryErr)
+	// All parsers failed. The primary parser's error stays matchable, so a
+	// caller can tell ErrNoElementFound (the page changed) from a version
+	// that did not parse.
+	return "", fmt.Errorf("%w: %w", ErrNoVersionFound, primaryErr)
+}
+
+// ansiEscapeRe matches ANSI escape sequences: CSI sequences such as colors
+// ("\x1b[1;32m") and cursor control, OSC sequences such as terminal titles and
+// hyperlinks (terminated by BEL or ST), and the remaining two-byte escapes.
+var ansiEscapeRe = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// stripANSI removes ANSI escape sequences from content (see
+// PackageConfig.StripANSI).
+func stripANSI(content []byte) []byte {
+	return ansiEscapeRe.ReplaceAll(content, nil)
 }
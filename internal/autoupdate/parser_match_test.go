@@ -0,0 +1,173 @@
+package autoupdate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestJSONParserMatchSelectsElementRegardlessOfOrder verifies that an array
+// match picks the element by field value, not by position.
+func TestJSONParserMatchSelectsElementRegardlessOfOrder(t *testing.T) {
+	match := &ArrayMatch{Field: "name", Equals: "stable"}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"stable first", `[{"name":"stable","version":"1.2.3"},{"name":"beta","version":"2.0.0-rc"}]`},
+		{"stable last", `[{"name":"beta","version":"2.0.0-rc"},{"name":"stable","version":"1.2.3"}]`},
+		{"stable middle", `[{"name":"nightly","version":"3.0"},{"name":"stable","version":"1.2.3"},{"name":"beta","version":"2.0.0-rc"}]`},
+		{"heterogeneous", `[{"id":7},{"name":"beta","version":"2.0.0-rc"},{"name":"stable","version":"1.2.3"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &JSONParser{Path: "version", Match: match}
+			got, err := p.Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != "1.2.3" {
+				t.Errorf("Parse() = %q, want %q", got, "1.2.3")
+			}
+		})
+	}
+}
+
+// TestJSONParserMatchNestedArrayAndField covers a nested array and a nested
+// comparison field.
+func TestJSONParserMatchNestedArrayAndField(t *testing.T) {
+	content := `{"data":{"channels":[
+		{"meta":{"channel":"beta"},"release":{"version":"5.0b1"}},
+		{"meta":{"channel":"stable"},"release":{"version":"4.9.2"}}
+	]}}`
+
+	p := &JSONParser{
+		Path:  "release.version",
+		Match: &ArrayMatch{Array: "data.channels", Field: "meta.channel", Equals: "stable"},
+	}
+	got, err := p.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "4.9.2" {
+		t.Errorf("Parse() = %q, want %q", got, "4.9.2")
+	}
+}
+
+// TestJSONParserMatchNonStringField compares a boolean field by its string form.
+func TestJSONParserMatchNonStringField(t *testing.T) {
+	content := `[{"prerelease":true,"tag":"2.0-rc1"},{"prerelease":false,"tag":"1.9"}]`
+
+	p := &JSONParser{Path: "tag", Match: &ArrayMatch{Field: "prerelease", Equals: "false"}}
+	got, err := p.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "1.9" {
+		t.Errorf("Parse() = %q, want %q", got, "1.9")
+	}
+}
+
+// TestJSONParserMatchErrors covers the failure modes of an array match.
+func TestJSONParserMatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		match   *ArrayMatch
+		wantErr error
+	}{
+		{"no matching element", `[{"name":"beta","version":"2.0"}]`, &ArrayMatch{Field: "name", Equals: "stable"}, ErrJSONPathNotFound},
+		{"target not an array", `{"name":"stable","version":"1.0"}`, &ArrayMatch{Field: "name", Equals: "stable"}, ErrJSONPathNotFound},
+		{"missing array path", `{"other":[]}`, &ArrayMatch{Array: "channels", Field: "name", Equals: "stable"}, ErrJSONPathNotFound},
+		{"empty field", `[{"name":"stable"}]`, &ArrayMatch{Equals: "stable"}, ErrInvalidJSONPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &JSONParser{Path: "version", Match: tt.match}
+			_, err := p.Parse([]byte(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNewParserFromConfigPassesMatch verifies the config's match reaches the
+// JSON parser.
+func TestNewParserFromConfigPassesMatch(t *testing.T) {
+	cfg := &PackageConfig{
+		Parser: "json",
+		Path:   "version",
+		Match:  &ArrayMatch{Field: "name", Equals: "stable"},
+	}
+	p, err := NewParserFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewParserFromConfig() error = %v", err)
+	}
+	got, err := p.Parse([]byte(`[{"name":"beta","version":"2.0"},{"name":"stable","version":"1.5"}]`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got != "1.5" {
+		t.Errorf("Parse() = %q, want %q", got, "1.5")
+	}
+}
+
+// TestValidatePackageConfigMatch covers validation of the match table.
+func TestValidatePackageConfigMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PackageConfig
+		wantErr bool
+	}{
+		{"valid root array", PackageConfig{URL: "https://x", Parser: "json", Path: "version", Match: &ArrayMatch{Field: "name", Equals: "stable"}}, false},
+		{"valid nested array", PackageConfig{URL: "https://x", Parser: "json", Path: "version", Match: &ArrayMatch{Array: "data.items", Field: "name", Equals: "stable"}}, false},
+		{"non-json parser", PackageConfig{URL: "https://x", Parser: "regex", Pattern: `v(\d+)`, Match: &ArrayMatch{Field: "name", Equals: "stable"}}, true},
+		{"missing field", PackageConfig{URL: "https://x", Parser: "json", Path: "version", Match: &ArrayMatch{Equals: "stable"}}, true},
+		{"bad array path", PackageConfig{URL: "https://x", Parser: "json", Path: "version", Match: &ArrayMatch{Array: "items[x]", Field: "name", Equals: "stable"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePackageConfig("test/pkg", &tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePackageConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLoadPackagesConfigMatch verifies the inline-table TOML form decodes.
+func TestLoadPackagesConfigMatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".autoupdate")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+
+	data := `["app-misc/channels"]
+url = "https://example.com/channels.json"
+parser = "json"
+path = "version"
+match = { array = "channels", field = "name", equals = "stable" }
+`
+	if err := os.WriteFile(filepath.Join(configDir, "packages.toml"), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write TOML: %v", err)
+	}
+
+	config, err := LoadPackagesConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadPackagesConfig() error = %v", err)
+	}
+	m := config.Packages["app-misc/channels"].Match
+	if m == nil {
+		t.Fatal("Match not decoded")
+	}
+	if m.Array != "channels" || m.Field != "name" || m.Equals != "stable" {
+		t.Errorf("Match = %+v, want {channels name stable}", *m)
+	}
+}
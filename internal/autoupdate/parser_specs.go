@@ -0,0 +1,300 @@
+// Package autoupdate provides the parser registry and its field reference.
+package autoupdate
+
+// ParserSpec documents one packages.toml parser: which PackageConfig fields
+// it reads and an example entry. Every package also needs url and parser;
+// they are not repeated in Required.
+type ParserSpec struct {
+	// Name is the parser value in packages.toml
+	Name string
+	// Description says what the parser reads
+	Description string
+	// Required are the TOML keys the parser cannot work without. A key of
+	// the form "selector|xpath" means at least one of them; a dotted key
+	// ("gitea.owner") is a field of a nested table.
+	Required []string
+	// Optional are TOML keys the parser also honours
+	Optional []string
+	// Example is a complete packages.toml entry using the parser
+	Example string
+	// build constructs the parser; nil for parsers that do not parse fetched
+	// content (script drives a browser itself)
+	build func(cfg *PackageConfig) (Parser, error)
+}
+
+// parserRegistry is every parser packages.toml accepts. NewParserFromConfig
+// builds parsers from it and ParserSpecs documents it, so the reference
+// cannot drift from what is supported.
+var parserRegistry = []ParserSpec{
+	{
+		Name:        "json",
+		Description: "Reads a value from a JSON document by path (dot notation, [n] and [*] indexing, |length, |first, |last, |max).",
+		Required:    []string{"path"},
+		Optional:    []string{"match", "select", "transform", "version_constraint", "checksum_path"},
+		Example: `["app-misc/foo"]
+url = "https://api.github.com/repos/foo/foo/releases/latest"
+parser = "json"
+path = "tag_name"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &JSONParser{Path: cfg.Path, Match: cfg.Match}, nil
+		},
+	},
+	{
+		Name:        "yaml",
+		Description: "Reads a value from a YAML document (versions.yaml, Chart.yaml) by path, with the json parser's path syntax.",
+		Required:    []string{"path"},
+		Optional:    []string{"transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://raw.githubusercontent.com/foo/foo/main/versions.yaml"
+parser = "yaml"
+path = "channels.stable.version"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &YAMLParser{Path: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "regex",
+		Description: "Matches a regular expression against the body; the first capture group is the version.",
+		Required:    []string{"pattern"},
+		Optional:    []string{"select", "transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://example.com/download"
+parser = "regex"
+pattern = 'foo-([0-9.]+)\.tar\.gz'`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewParser("regex", cfg.Pattern)
+		},
+	},
+	{
+		Name:        "html",
+		Description: "Scrapes an HTML page by CSS selector or XPath, optionally narrowed by a regex capture group.",
+		Required:    []string{"selector|xpath"},
+		Optional:    []string{"pattern", "select", "transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://example.com/releases"
+parser = "html"
+selector = ".release .version"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewHTMLParser(cfg.Selector, cfg.XPath, cfg.Pattern)
+		},
+	},
+	{
+		Name:        "xml",
+		Description: "Reads an XML document (maven-metadata.xml, Sparkle appcast) strictly by XPath, element, attribute or text(), optionally narrowed by a regex capture group; with file_pattern, the selected nodes are file URLs (a SourceForge RSS) and the highest version among the file names it matches wins.",
+		Required:    []string{"xpath"},
+		Optional:    []string{"pattern", "file_pattern", "select", "transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://repo1.maven.org/maven2/org/example/foo/maven-metadata.xml"
+parser = "xml"
+xpath = "//metadata/versioning/release"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			if cfg.FilePattern != "" {
+				return NewXMLFileParser(cfg.XPath, cfg.FilePattern)
+			}
+			return NewXMLParser(cfg.XPath, cfg.Pattern)
+		},
+	},
+	{
+		Name:        "plist",
+		Description: "Reads a key from an Apple property list (default CFBundleShortVersionString).",
+		Optional:    []string{"path", "transform"},
+		Example: `["app-misc/foo"]
+url = "https://example.com/Info.plist"
+parser = "plist"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &PlistParser{Path: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "gnu-ftp",
+		Description: "Finds the newest <name>-<version>.tar.* in a GNU ftp or Savannah directory listing.",
+		Optional:    []string{"path", "transform", "version_constraint"},
+		Example: `["app-misc/hello"]
+url = "https://ftp.gnu.org/gnu/hello/"
+parser = "gnu-ftp"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewGNUFTPParser(cfg.Path, cfg.URL)
+		},
+	},
+	{
+		Name:        "helm",
+		Description: "Reads the newest version of a chart from a Helm repository index.yaml.",
+		Required:    []string{"path"},
+		Optional:    []string{"transform"},
+		Example: `["app-admin/foo-chart"]
+url = "https://charts.example.com"
+parser = "helm"
+path = "foo"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &HelmIndexParser{Chart: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "deb",
+		Description: "Reads the newest upstream version of a binary package from a Debian repository Packages or Packages.gz index.",
+		Required:    []string{"path"},
+		Optional:    []string{"transform"},
+		Example: `["app-misc/foo"]
+url = "https://apt.example.com/dists/stable/main/binary-amd64/Packages.gz"
+parser = "deb"
+path = "foo"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &DebPackagesParser{Package: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "rpm",
+		Description: "Reads the newest version of a package from an RPM repository, following repodata/repomd.xml to its primary metadata.",
+		Required:    []string{"path"},
+		Optional:    []string{"transform"},
+		Example: `["app-misc/foo"]
+url = "https://yum.example.com/el9/x86_64/"
+parser = "rpm"
+path = "foo"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &RPMPrimaryParser{Package: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "github-milestone",
+		Description: "Reads the version from the title of the newest GitHub milestone matching a regex.",
+		Required:    []string{"pattern"},
+		Optional:    []string{"transform"},
+		Example: `["app-misc/foo"]
+url = "https://api.github.com/repos/foo/foo/milestones"
+parser = "github-milestone"
+pattern = 'v([0-9.]+)'`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewGitHubMilestoneParser(cfg.Pattern)
+		},
+	},
+	{
+		Name:        "graphql",
+		Description: "POSTs a GraphQL query and reads a value from the response data by JSON path.",
+		Required:    []string{"query", "path"},
+		Optional:    []string{"variables", "transform"},
+		Example: `["app-misc/foo"]
+url = "https://api.example.com/graphql"
+parser = "graphql"
+query = "{ project { latestRelease { version } } }"
+path = "project.latestRelease.version"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &GraphQLParser{Path: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "gitea",
+		Description: "Reads the newest published release of a repository on a Gitea or Forgejo instance.",
+		Required:    []string{"gitea.owner", "gitea.repo"},
+		Optional:    []string{"gitea.token", "transform"},
+		Example: `["app-misc/foo"]
+url = "https://codeberg.org"
+parser = "gitea"
+gitea = { owner = "foo", repo = "foo" }`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &GiteaReleaseParser{}, nil
+		},
+	},
+	{
+		Name:        "json-feed",
+		Description: "Reads the version of the most recently dated entry of a JSON feed.",
+		Required:    []string{"feed.date", "feed.version"},
+		Optional:    []string{"feed.entries", "pattern", "transform"},
+		Example: `["app-misc/foo"]
+url = "https://example.com/feed.json"
+parser = "json-feed"
+feed = { entries = "items", date = "date_published", version = "title" }`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewFeedParser(cfg.Feed, cfg.Pattern)
+		},
+	},
+	{
+		Name:        "dcf",
+		Description: "Reads a field of a Debian-control-format file such as an R package's DESCRIPTION (default field Version).",
+		Optional:    []string{"path", "transform"},
+		Example: `["dev-R/foo"]
+url = "https://cran.r-project.org/web/packages/foo/DESCRIPTION"
+parser = "dcf"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &DCFParser{Field: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "readme-txt",
+		Description: "Reads the Stable tag header of a WordPress-style readme.txt; path names a header to read instead when it is missing or \"trunk\".",
+		Optional:    []string{"path", "transform"},
+		Example: `["www-apps/foo"]
+url = "https://plugins.svn.wordpress.org/foo/trunk/readme.txt"
+parser = "readme-txt"
+path = "Version"`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return &ReadmeTxtParser{Field: cfg.Path}, nil
+		},
+	},
+	{
+		Name:        "git-tags",
+		Description: "Lists a git remote's tags with git ls-remote and reads the highest version; pattern keeps only the tags it matches, its first capture group being the version.",
+		Optional:    []string{"pattern", "select", "transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://git.example.com/foo.git"
+parser = "git-tags"
+pattern = '^v([0-9.]+)$'`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewGitTagsParser(cfg.Pattern)
+		},
+	},
+	{
+		Name:        "fileindex",
+		Description: "Reads a directory listing (an HTML autoindex or a plain FTP-style listing) and takes the highest version among the file names file_pattern matches, its first capture group being the version.",
+		Required:    []string{"file_pattern"},
+		Optional:    []string{"select", "transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://download.example.com/foo/"
+parser = "fileindex"
+file_pattern = '^foo-([0-9.]+)\.tar\.gz$'`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewFileIndexParser(cfg.FilePattern)
+		},
+	},
+	{
+		Name:        "header",
+		Description: "Reads the version from an HTTP response header, requested with HEAD; a Content-Disposition header is reduced to its file name, and pattern's first capture group, when set, extracts the version from the value.",
+		Required:    []string{"path"},
+		Optional:    []string{"pattern", "transform", "version_constraint"},
+		Example: `["app-misc/foo"]
+url = "https://download.example.com/foo/latest"
+parser = "header"
+path = "Content-Disposition"
+pattern = 'foo-([0-9.]+)\.tar\.gz'`,
+		build: func(cfg *PackageConfig) (Parser, error) {
+			return NewHeaderParser(cfg.Path, cfg.Pattern)
+		},
+	},
+	{
+		Name:        "script",
+		Description: "Evaluates JavaScript against the rendered page in a headless browser; its result is the version.",
+		Required:    []string{"script"},
+		Example: `["app-office/foo"]
+url = "https://example.com/download"
+parser = "script"
+script = "document.querySelector('.version').textContent"`,
+	},
+}
+
+// ParserSpecs returns the reference of every supported parser, in
+// registry order.
+func ParserSpecs() []ParserSpec {
+	specs := make([]ParserSpec, len(parserRegistry))
+	copy(specs, parserRegistry)
+	return specs
+}
+
+// lookupParser returns the registry entry for name.
+func lookupParser(name string) (*ParserSpec, bool) {
+	for i := range parserRegistry {
+		if parserRegistry[i].Name == name {
+			return &parserRegistry[i], true
+		}
+	}
+	return nil, false
+}
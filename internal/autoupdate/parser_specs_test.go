@@ -0,0 +1,139 @@
+package autoupdate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+// specExampleConfig decodes spec's example entry.
+func specExampleConfig(t *testing.T, spec ParserSpec) (string, PackageConfig) {
+	t.Helper()
+	var file packagesConfigFile
+	if err := toml.Unmarshal([]byte(spec.Example), &file); err != nil {
+		t.Fatalf("%s: example does not decode: %v", spec.Name, err)
+	}
+	if len(file) != 1 {
+		t.Fatalf("%s: example has %d entries, want 1", spec.Name, len(file))
+	}
+	for pkg, cfg := range file {
+		return pkg, cfg
+	}
+	return "", PackageConfig{}
+}
+
+// clearTOMLField zeroes the PackageConfig field with the given TOML key,
+// following a dotted key into a nested table. It reports whether the key
+// names a field.
+func clearTOMLField(cfg *PackageConfig, key string) bool {
+	v := reflect.ValueOf(cfg).Elem()
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		field, ok := fieldByTOMLKey(v, part)
+		if !ok {
+			return false
+		}
+		if i == len(parts)-1 {
+			field.Set(reflect.Zero(field.Type()))
+			return true
+		}
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return true
+			}
+			field = field.Elem()
+		}
+		v = field
+	}
+	return false
+}
+
+// fieldByTOMLKey returns the field of struct v tagged with key.
+func fieldByTOMLKey(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		tag := strings.Split(v.Type().Field(i).Tag.Get("toml"), ",")[0]
+		if tag == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// TestParserSpecs_MatchRegistry verifies every parser the validator accepts
+// has a spec, each spec's example validates and builds, and each spec names
+// real fields.
+func TestParserSpecs_MatchRegistry(t *testing.T) {
+	specs := ParserSpecs()
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		if seen[spec.Name] {
+			t.Errorf("parser %q has two specs", spec.Name)
+		}
+		seen[spec.Name] = true
+		if spec.Description == "" || spec.Example == "" {
+			t.Errorf("parser %q lacks a description or example", spec.Name)
+		}
+
+		pkg, cfg := specExampleConfig(t, spec)
+		if cfg.Parser != spec.Name {
+			t.Errorf("%s: example uses parser %q", spec.Name, cfg.Parser)
+		}
+		if err := ValidatePackageConfig(pkg, &cfg); err != nil {
+			t.Errorf("%s: example does not validate: %v", spec.Name, err)
+		}
+		if spec.build != nil {
+			if _, err := NewParserFromConfig(&cfg); err != nil {
+				t.Errorf("%s: example does not build: %v", spec.Name, err)
+			}
+		}
+		for _, key := range append(append([]string{}, spec.Required...), spec.Optional...) {
+			for _, alt := range strings.Split(key, "|") {
+				_, probe := specExampleConfig(t, spec)
+				if !clearTOMLField(&probe, alt) {
+					t.Errorf("%s: field %q is not a packages.toml key", spec.Name, alt)
+				}
+			}
+		}
+	}
+
+	// Every name in the validator's error message is registered, and nothing
+	// unregistered validates.
+	msg := ErrInvalidParserType.Error()
+	for _, spec := range specs {
+		if !strings.Contains(msg, "'"+spec.Name+"'") {
+			t.Errorf("ErrInvalidParserType does not list %q", spec.Name)
+		}
+	}
+	cfg := &PackageConfig{URL: "https://example.com", Parser: "toml", Path: "version"}
+	if err := ValidatePackageConfig("app-misc/foo", cfg); !errors.Is(err, ErrInvalidParserType) {
+		t.Errorf("unregistered parser: error = %v, want %v", err, ErrInvalidParserType)
+	}
+}
+
+// TestParserSpecs_RequiredFields verifies the required fields are accurate:
+// dropping any one (both alternatives of an "a|b" field) from the example
+// fails validation, while dropping an optional one does not.
+func TestParserSpecs_RequiredFields(t *testing.T) {
+	for _, spec := range ParserSpecs() {
+		// Nested tables are pointers, so each probe decodes a fresh copy.
+		for _, key := range append([]string{"url"}, spec.Required...) {
+			pkg, cfg := specExampleConfig(t, spec)
+			for _, alt := range strings.Split(key, "|") {
+				clearTOMLField(&cfg, alt)
+			}
+			if err := ValidatePackageConfig(pkg, &cfg); err == nil {
+				t.Errorf("%s: validates without required %q", spec.Name, key)
+			}
+		}
+		for _, key := range spec.Optional {
+			pkg, cfg := specExampleConfig(t, spec)
+			clearTOMLField(&cfg, key)
+			if err := ValidatePackageConfig(pkg, &cfg); err != nil {
+				t.Errorf("%s: optional %q is required: %v", spec.Name, key, err)
+			}
+		}
+	}
+}
@@ -653,3 +653,24 @@ func TestRegexParser_NoMatch(t *testing.T) {
 		t.Errorf("expected ErrRegexNoMatch, got %v", err)
 	}
 }
+
+// TestParseVersion_StripANSI verifies a regex anchored on plain text matches a
+// version wrapped in ANSI color codes once strip_ansi is set, and not before.
+func TestParseVersion_StripANSI(t *testing.T) {
+	body := []byte("\x1b]0;status\x07\x1b[1mService status\x1b[0m\n" +
+		"Latest: \x1b[1;32m1.2.3\x1b[0m (\x1b[33mstable\x1b[0m)\n")
+	cfg := &PackageConfig{Parser: "regex", Pattern: `Latest: ([0-9.]+) \(stable\)`}
+
+	if _, err := ParseVersion(body, cfg); err == nil {
+		t.Fatal("expected no match without strip_ansi")
+	}
+
+	cfg.StripANSI = true
+	got, err := ParseVersion(body, cfg)
+	if err != nil {
+		t.Fatalf("ParseVersion() error = %v", err)
+	}
+	if got != "1.2.3" {
+		t.Errorf("ParseVersion() = %q, want 1.2.3", got)
+	}
+}
@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
-
-	"github.com/obentoo/bentoolkit/internal/common/fileutil"
 )
 
 // Error variables for pending list errors
@@ -69,6 +70,16 @@ type PendingUpdate struct {
 	// (e.g. "esr-bb24"). When non-empty, Apply substitutes the aux_var assignment
 	// in the copied ebuild with this value.
 	AuxValue string `json:"aux_value,omitempty"`
+	// ChangelogURL is a best-effort link to the upstream release notes or a
+	// compare view of the change (see Checker.resolveChangelogURL), shown to
+	// speed up review. Empty when the source offers none.
+	ChangelogURL string `json:"changelog_url,omitempty"`
+	// Group is the packages.toml group ("category/glob") this entry bumps in
+	// lockstep. Empty for a single-package update.
+	Group string `json:"group,omitempty"`
+	// GroupMembers lists every package of Group at CurrentVersion, sorted;
+	// Package is the first of them. Apply bumps them all or none.
+	GroupMembers []string `json:"group_members,omitempty"`
 	// Status is the current status of this update
 	Status UpdateStatus `json:"status"`
 	// DetectedAt is when this update was first detected
@@ -80,6 +91,9 @@ type PendingUpdate struct {
 // pendingFile represents the JSON structure stored on disk
 type pendingFile struct {
 	Updates map[string]PendingUpdate `json:"updates"`
+	// Failures holds the consecutive-failure records behind quarantine (see
+	// quarantine.go). Omitted while no package is failing.
+	Failures map[string]FailureRecord `json:"failures,omitempty"`
 }
 
 // PendingList manages the list of pending updates.
@@ -87,12 +101,19 @@ type pendingFile struct {
 type PendingList struct {
 	// Updates holds all pending updates, keyed by package name
 	Updates map[string]PendingUpdate `json:"updates"`
-	// path is the file path where pending list is persisted
-	path string
+	// failures tracks consecutive check failures per package, keyed by
+	// package name. Clear leaves it alone: it is not an update.
+	failures map[string]FailureRecord
+	// store persists the pending list; a FileStore on the config directory
+	// unless set with WithPendingStore
+	store Store
 	// mu protects concurrent access to Updates
 	mu sync.RWMutex
 	// nowFunc allows injecting time for testing
 	nowFunc func() time.Time
+	// readOnly keeps every change in memory and skips the save. Set by
+	// NewChecker under WithReadOnly.
+	readOnly bool
 }
 
 // PendingListOption is a functional option for configuring PendingList
@@ -105,22 +126,23 @@ func WithPendingNowFunc(fn func() time.Time) PendingListOption {
 	}
 }
 
+// WithPendingStore persists the pending list in store instead of
+// pending.json in the config directory.
+func WithPendingStore(store Store) PendingListOption {
+	return func(p *PendingList) {
+		p.store = store
+	}
+}
+
 // NewPendingList creates or loads a pending list from disk.
 // If the pending file exists, it loads existing entries.
 // If the pending file doesn't exist or is corrupted, it creates a new empty list.
 // The configDir should be the bentoo config directory (e.g., ~/.config/bentoo/autoupdate).
 func NewPendingList(configDir string, opts ...PendingListOption) (*PendingList, error) {
-	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0o750); err != nil {
-		return nil, fmt.Errorf("failed to create pending directory: %w", err)
-	}
-
-	pendingPath := filepath.Join(configDir, "pending.json")
-
 	pending := &PendingList{
-		Updates: make(map[string]PendingUpdate),
-		path:    pendingPath,
-		nowFunc: time.Now,
+		Updates:  make(map[string]PendingUpdate),
+		failures: make(map[string]FailureRecord),
+		nowFunc:  time.Now,
 	}
 
 	// Apply options
@@ -128,22 +150,31 @@ func NewPendingList(configDir string, opts ...PendingListOption) (*PendingList,
 		opt(pending)
 	}
 
+	if pending.store == nil {
+		// Ensure config directory exists
+		if err := os.MkdirAll(configDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create pending directory: %w", err)
+		}
+		pending.store = NewFileStore(configDir)
+	}
+
 	// Try to load existing pending list
 	if err := pending.load(); err != nil {
 		// If file doesn't exist, that's fine - start with empty list
-		if !os.IsNotExist(err) {
+		if !errors.Is(err, fs.ErrNotExist) {
 			// Log corruption but continue with empty list
 			// The corrupted file will be overwritten on next Save
 			pending.Updates = make(map[string]PendingUpdate)
+			pending.failures = make(map[string]FailureRecord)
 		}
 	}
 
 	return pending, nil
 }
 
-// load reads the pending list from disk
+// load reads the pending list from its store
 func (p *PendingList) load() error {
-	data, err := os.ReadFile(p.path)
+	data, err := p.store.Load(pendingStoreName)
 	if err != nil {
 		return err
 	}
@@ -156,6 +187,9 @@ func (p *PendingList) load() error {
 	if pf.Updates != nil {
 		p.Updates = pf.Updates
 	}
+	if pf.Failures != nil {
+		p.failures = pf.Failures
+	}
 
 	return nil
 }
@@ -222,30 +256,136 @@ func (p *PendingList) SetStatus(pkg string, status UpdateStatus, errMsg string)
 	return p.saveUnsafe()
 }
 
-// List returns all pending updates as a slice.
-// The returned slice contains copies of the updates.
-func (p *PendingList) List() []PendingUpdate {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+// PendingSort is the order List and its filtered variants return entries in.
+type PendingSort string
 
-	updates := make([]PendingUpdate, 0, len(p.Updates))
-	for _, update := range p.Updates {
-		updates = append(updates, update)
+const (
+	// SortByPackage orders entries by package name, the default.
+	SortByPackage PendingSort = "package"
+	// SortByDetectedAt orders entries oldest detection first, ties by
+	// package name.
+	SortByDetectedAt PendingSort = "detected"
+)
+
+// ErrInvalidPendingSort is returned by ParsePendingSort for an unknown order.
+var ErrInvalidPendingSort = errors.New("invalid sort order: must be 'package' or 'detected'")
+
+// ParsePendingSort parses a PendingSort by name; empty is SortByPackage.
+func ParsePendingSort(s string) (PendingSort, error) {
+	switch PendingSort(s) {
+	case "", SortByPackage:
+		return SortByPackage, nil
+	case SortByDetectedAt:
+		return SortByDetectedAt, nil
 	}
-	return updates
+	return "", fmt.Errorf("%w: got %q", ErrInvalidPendingSort, s)
 }
 
-// ListByStatus returns all pending updates with the specified status.
-func (p *PendingList) ListByStatus(status UpdateStatus) []PendingUpdate {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+// ErrInvalidAge is returned by ParseAge for a malformed or non-positive age.
+var ErrInvalidAge = errors.New("invalid age: want a positive duration such as 7d or 36h")
+
+// ParseAge parses an age for WithDetectedWithin and ExpireStale: a whole
+// number of days ("7d") or a Go duration ("36h", "90m").
+func ParseAge(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%w: got %q", ErrInvalidAge, s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("%w: got %q", ErrInvalidAge, s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%w: got %q", ErrInvalidAge, s)
+	}
+	return d, nil
+}
+
+// ListOption configures List, ListByStatus and ListByCategory.
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	sort PendingSort
+	// within keeps only the entries detected at most this long ago; zero
+	// keeps every entry
+	within time.Duration
+}
+
+// WithSort returns the entries in order instead of by package name.
+func WithSort(order PendingSort) ListOption {
+	return func(o *listOptions) {
+		o.sort = order
+	}
+}
+
+// WithDetectedWithin returns only the entries detected at most d ago, by the
+// list's clock (see WithPendingNowFunc); one detected exactly d ago is kept.
+// Zero or less returns every entry.
+func WithDetectedWithin(d time.Duration) ListOption {
+	return func(o *listOptions) {
+		o.within = d
+	}
+}
+
+// List returns all pending updates as a slice of copies, by package name
+// unless WithSort says otherwise. Updates is a map, so without the sort the
+// order would change from run to run.
+func (p *PendingList) List(opts ...ListOption) []PendingUpdate {
+	return p.listWhere(func(PendingUpdate) bool { return true }, opts)
+}
+
+// ListByStatus returns all pending updates with the specified status, in
+// List's order.
+func (p *PendingList) ListByStatus(status UpdateStatus, opts ...ListOption) []PendingUpdate {
+	return p.listWhere(func(u PendingUpdate) bool { return u.Status == status }, opts)
+}
 
-	updates := make([]PendingUpdate, 0)
+// ListByCategory returns the pending updates of the packages in category cat
+// (the part of "category/package" before the slash), in List's order. A
+// group's entry is listed under the category of its Package.
+func (p *PendingList) ListByCategory(cat string, opts ...ListOption) []PendingUpdate {
+	return p.listWhere(func(u PendingUpdate) bool {
+		category, _, _ := strings.Cut(u.Package, "/")
+		return category == cat
+	}, opts)
+}
+
+// listWhere returns copies of the entries keep selects, sorted per opts.
+func (p *PendingList) listWhere(keep func(PendingUpdate) bool, opts []ListOption) []PendingUpdate {
+	o := listOptions{sort: SortByPackage}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p.mu.RLock()
+	var cutoff time.Time
+	if o.within > 0 {
+		cutoff = p.nowFunc().Add(-o.within)
+	}
+	updates := make([]PendingUpdate, 0, len(p.Updates))
 	for _, update := range p.Updates {
-		if update.Status == status {
+		if keep(update) && !update.DetectedAt.Before(cutoff) {
 			updates = append(updates, update)
 		}
 	}
+	p.mu.RUnlock()
+
+	byPackage := func(a, b PendingUpdate) int { return strings.Compare(a.Package, b.Package) }
+	if o.sort == SortByDetectedAt {
+		slices.SortFunc(updates, func(a, b PendingUpdate) int {
+			if c := a.DetectedAt.Compare(b.DetectedAt); c != 0 {
+				return c
+			}
+			return byPackage(a, b)
+		})
+	} else {
+		slices.SortFunc(updates, byPackage)
+	}
 	return updates
 }
 
@@ -260,8 +400,12 @@ func (p *PendingList) Save() error {
 // saveUnsafe persists the pending list to disk without locking.
 // Caller must hold the write lock.
 func (p *PendingList) saveUnsafe() error {
+	if p.readOnly {
+		return nil
+	}
 	pf := pendingFile{
-		Updates: p.Updates,
+		Updates:  p.Updates,
+		Failures: p.failures,
 	}
 
 	data, err := json.MarshalIndent(pf, "", "  ")
@@ -269,23 +413,8 @@ func (p *PendingList) saveUnsafe() error {
 		return fmt.Errorf("failed to marshal pending list: %w", err)
 	}
 
-	// Write to temp file first, then rename for atomicity. Pending files use
-	// 0600 (owner-only) because they may hold sensitive upstream metadata.
-	tmpPath := p.path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, fileutil.CacheFileMode); err != nil {
-		return fmt.Errorf("failed to write pending file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, p.path); err != nil {
-		// Clean up temp file on rename failure
-		os.Remove(tmpPath) //nolint:errcheck
-		return fmt.Errorf("failed to rename pending file: %w", err)
-	}
-
-	// os.Rename keeps the temp file's mode, which umask may have widened.
-	// Re-apply the restrictive mode; tolerate filesystems without chmod.
-	if err := fileutil.SafeChmod(p.path, fileutil.CacheFileMode, warnLogger{}); err != nil {
-		return fmt.Errorf("failed to set pending file permissions: %w", err)
+	if err := p.store.Save(pendingStoreName, data); err != nil {
+		return fmt.Errorf("failed to save pending list: %w", err)
 	}
 
 	return nil
@@ -301,6 +430,31 @@ func (p *PendingList) Delete(pkg string) error {
 	return p.saveUnsafe()
 }
 
+// ExpireStale removes the entries detected more than olderThan ago, by the
+// list's clock, and returns how many were removed. An entry detected exactly
+// olderThan ago stays, as WithDetectedWithin lists it. The list is saved only
+// when something was removed.
+func (p *PendingList) ExpireStale(olderThan time.Duration) (int, error) {
+	if olderThan <= 0 {
+		return 0, fmt.Errorf("%w: got %s", ErrInvalidAge, olderThan)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := p.nowFunc().Add(-olderThan)
+	removed := 0
+	for pkg, update := range p.Updates {
+		if update.DetectedAt.Before(cutoff) {
+			delete(p.Updates, pkg)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, p.saveUnsafe()
+}
+
 // Clear removes all entries from the pending list.
 // It automatically saves the pending list to disk after clearing.
 func (p *PendingList) Clear() error {
@@ -0,0 +1,88 @@
+// Package autoupdate provides CSV and JSON export of the pending update list.
+package autoupdate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportFormat is the format ExportPending writes.
+type ExportFormat string
+
+const (
+	// ExportCSV writes one row per update under a header row.
+	ExportCSV ExportFormat = "csv"
+	// ExportJSON writes an indented JSON array.
+	ExportJSON ExportFormat = "json"
+)
+
+// ErrInvalidExportFormat is returned by ParseExportFormat and ExportPending
+// for a format that is neither csv nor json.
+var ErrInvalidExportFormat = errors.New("invalid export format: must be 'csv' or 'json'")
+
+// ParseExportFormat parses an ExportFormat by name.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(s); f {
+	case ExportCSV, ExportJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: got %q", ErrInvalidExportFormat, s)
+}
+
+// pendingExportColumns are the CSV header, in column order. They are the
+// JSON field names too, so both formats read the same.
+var pendingExportColumns = []string{"package", "current", "new", "status", "detected_at", "error"}
+
+// pendingExportJSON is the exported form of a PendingUpdate: the fields a
+// report needs, under names kept apart from pending.json's so the storage
+// format can change without breaking the report.
+type pendingExportJSON struct {
+	Package    string       `json:"package"`
+	Current    string       `json:"current"`
+	New        string       `json:"new"`
+	Status     UpdateStatus `json:"status"`
+	DetectedAt string       `json:"detected_at"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// ExportPending writes updates to w in format, in the order given, so a
+// caller scopes and sorts the report with List, ListByStatus or
+// ListByCategory. Detection times are written in RFC 3339. An empty list
+// still writes the CSV header, or an empty JSON array.
+func ExportPending(w io.Writer, updates []PendingUpdate, format ExportFormat) error {
+	rows := make([]pendingExportJSON, len(updates))
+	for i, u := range updates {
+		rows[i] = pendingExportJSON{
+			Package:    u.Package,
+			Current:    u.CurrentVersion,
+			New:        u.NewVersion,
+			Status:     u.Status,
+			DetectedAt: u.DetectedAt.Format(time.RFC3339),
+			Error:      u.Error,
+		}
+	}
+
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(pendingExportColumns); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := cw.Write([]string{r.Package, r.Current, r.New, string(r.Status), r.DetectedAt, r.Error}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("%w: got %q", ErrInvalidExportFormat, format)
+}
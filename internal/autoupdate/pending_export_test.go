@@ -0,0 +1,128 @@
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newExportPendingList returns a pending list with a pending, a failed and a
+// validated entry. The failed one's package name and error need CSV quoting.
+func newExportPendingList(t *testing.T) *PendingList {
+	t.Helper()
+	pending, err := NewPendingList(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPendingList() error = %v", err)
+	}
+	detected := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	for _, u := range []PendingUpdate{
+		{Package: "app-misc/foo", CurrentVersion: "1.0", NewVersion: "1.1", Status: StatusPending, DetectedAt: detected},
+		{Package: `dev-util/"quoted",pkg`, CurrentVersion: "2.0", NewVersion: "2.1", Status: StatusFailed,
+			DetectedAt: detected.Add(time.Hour), Error: "manifest failed:\nfetch error, 404"},
+		{Package: "net-misc/bar", CurrentVersion: "3.0", NewVersion: "3.2", Status: StatusValidated, DetectedAt: detected},
+	} {
+		if err := pending.Add(u); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return pending
+}
+
+// TestExportPending_CSV verifies the header row, one row per update in the
+// order given, and that commas, quotes and newlines survive a round trip
+// through a CSV reader.
+func TestExportPending_CSV(t *testing.T) {
+	pending := newExportPendingList(t)
+
+	var buf bytes.Buffer
+	if err := ExportPending(&buf, pending.List(), ExportCSV); err != nil {
+		t.Fatalf("ExportPending() error = %v", err)
+	}
+	exported := buf.String()
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV does not parse: %v\n%s", err, exported)
+	}
+	want := [][]string{
+		{"package", "current", "new", "status", "detected_at", "error"},
+		{"app-misc/foo", "1.0", "1.1", "pending", "2026-03-01T09:30:00Z", ""},
+		{`dev-util/"quoted",pkg`, "2.0", "2.1", "failed", "2026-03-01T10:30:00Z", "manifest failed:\nfetch error, 404"},
+		{"net-misc/bar", "3.0", "3.2", "validated", "2026-03-01T09:30:00Z", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d:\n%s", len(records), len(want), exported)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if records[i][j] != want[i][j] {
+				t.Errorf("record %d column %d = %q, want %q", i, j, records[i][j], want[i][j])
+			}
+		}
+	}
+	if !strings.Contains(exported, `"dev-util/""quoted"",pkg"`) {
+		t.Errorf("package name not quoted and escaped:\n%s", exported)
+	}
+}
+
+// TestExportPending_JSON verifies the JSON array is indented, scoped by
+// ListByStatus, and carries the error only when there is one.
+func TestExportPending_JSON(t *testing.T) {
+	pending := newExportPendingList(t)
+
+	var buf bytes.Buffer
+	if err := ExportPending(&buf, pending.ListByStatus(StatusFailed), ExportJSON); err != nil {
+		t.Fatalf("ExportPending() error = %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("[\n  {\n    \"package\"")) {
+		t.Errorf("JSON is not indented:\n%s", buf.String())
+	}
+	var rows []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("exported JSON does not parse: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want only the failed entry", len(rows))
+	}
+	want := map[string]string{
+		"package": `dev-util/"quoted",pkg`, "current": "2.0", "new": "2.1", "status": "failed",
+		"detected_at": "2026-03-01T10:30:00Z", "error": "manifest failed:\nfetch error, 404",
+	}
+	for k, v := range want {
+		if rows[0][k] != v {
+			t.Errorf("%s = %q, want %q", k, rows[0][k], v)
+		}
+	}
+
+	buf.Reset()
+	if err := ExportPending(&buf, pending.ListByStatus(StatusPending), ExportJSON); err != nil {
+		t.Fatalf("ExportPending() error = %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte(`"error"`)) {
+		t.Errorf("entry without error carries an error field:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ExportPending(&buf, nil, ExportJSON); err != nil || buf.String() != "[]\n" {
+		t.Errorf("empty export = %q, %v; want an empty array", buf.String(), err)
+	}
+}
+
+// TestParseExportFormat verifies the accepted names and that an unknown one
+// is rejected by both ParseExportFormat and ExportPending.
+func TestParseExportFormat(t *testing.T) {
+	for _, name := range []string{"csv", "json"} {
+		if f, err := ParseExportFormat(name); err != nil || string(f) != name {
+			t.Errorf("ParseExportFormat(%q) = %q, %v", name, f, err)
+		}
+	}
+	if _, err := ParseExportFormat("xml"); !errors.Is(err, ErrInvalidExportFormat) {
+		t.Errorf("ParseExportFormat(xml) error = %v, want %v", err, ErrInvalidExportFormat)
+	}
+	if err := ExportPending(&bytes.Buffer{}, nil, "yaml"); !errors.Is(err, ErrInvalidExportFormat) {
+		t.Errorf("ExportPending(yaml) error = %v, want %v", err, ErrInvalidExportFormat)
+	}
+}
@@ -2,8 +2,11 @@ package autoupdate
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -596,6 +599,208 @@ func TestPendingListListByStatus(t *testing.T) {
 	}
 }
 
+// pendingPackages returns the package names of updates, in order.
+func pendingPackages(updates []PendingUpdate) []string {
+	names := make([]string, len(updates))
+	for i, u := range updates {
+		names[i] = u.Package
+	}
+	return names
+}
+
+// addCategorizedPending fills pending with entries across categories, each
+// detected at a different time, out of name order.
+func addCategorizedPending(t *testing.T, pending *PendingList) {
+	t.Helper()
+	base := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	entries := []struct {
+		pkg    string
+		status UpdateStatus
+		ago    time.Duration
+	}{
+		{"net-misc/zsync", StatusPending, time.Hour},
+		{"app-misc/foo", StatusValidated, 2 * time.Hour},
+		{"net-misc/curl-extra", StatusPending, 3 * time.Hour},
+		{"app-misc/bar", StatusPending, 0},
+		{"dev-libs/baz", StatusFailed, time.Hour},
+	}
+	for _, e := range entries {
+		if err := pending.Add(PendingUpdate{
+			Package: e.pkg, CurrentVersion: "1.0", NewVersion: "1.1",
+			Status: e.status, DetectedAt: base.Add(-e.ago),
+		}); err != nil {
+			t.Fatalf("Add(%s): %v", e.pkg, err)
+		}
+	}
+}
+
+// TestPendingListListSorted verifies List orders entries by package name by
+// default and oldest detection first with SortByDetectedAt, ties broken by
+// name.
+func TestPendingListListSorted(t *testing.T) {
+	pending, err := NewPendingList(t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	addCategorizedPending(t, pending)
+
+	byName := []string{"app-misc/bar", "app-misc/foo", "dev-libs/baz", "net-misc/curl-extra", "net-misc/zsync"}
+	if got := pendingPackages(pending.List()); !slices.Equal(got, byName) {
+		t.Errorf("List() = %v, want %v", got, byName)
+	}
+	if got := pendingPackages(pending.List(WithSort(SortByPackage))); !slices.Equal(got, byName) {
+		t.Errorf("List(SortByPackage) = %v, want %v", got, byName)
+	}
+
+	byDetected := []string{"net-misc/curl-extra", "app-misc/foo", "dev-libs/baz", "net-misc/zsync", "app-misc/bar"}
+	if got := pendingPackages(pending.List(WithSort(SortByDetectedAt))); !slices.Equal(got, byDetected) {
+		t.Errorf("List(SortByDetectedAt) = %v, want %v", got, byDetected)
+	}
+
+	pendingOnly := []string{"app-misc/bar", "net-misc/curl-extra", "net-misc/zsync"}
+	if got := pendingPackages(pending.ListByStatus(StatusPending)); !slices.Equal(got, pendingOnly) {
+		t.Errorf("ListByStatus(pending) = %v, want %v", got, pendingOnly)
+	}
+}
+
+// TestPendingListListByCategory verifies ListByCategory keeps the entries of
+// one category, matching the category exactly, in List's order.
+func TestPendingListListByCategory(t *testing.T) {
+	pending, err := NewPendingList(t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	addCategorizedPending(t, pending)
+
+	tests := []struct {
+		cat  string
+		opts []ListOption
+		want []string
+	}{
+		{"net-misc", nil, []string{"net-misc/curl-extra", "net-misc/zsync"}},
+		{"net-misc", []ListOption{WithSort(SortByDetectedAt)}, []string{"net-misc/curl-extra", "net-misc/zsync"}},
+		{"app-misc", []ListOption{WithSort(SortByDetectedAt)}, []string{"app-misc/foo", "app-misc/bar"}},
+		{"dev-libs", nil, []string{"dev-libs/baz"}},
+		{"net", nil, []string{}},
+		{"", nil, []string{}},
+	}
+	for _, tt := range tests {
+		if got := pendingPackages(pending.ListByCategory(tt.cat, tt.opts...)); !slices.Equal(got, tt.want) {
+			t.Errorf("ListByCategory(%q) = %v, want %v", tt.cat, got, tt.want)
+		}
+	}
+}
+
+// TestParsePendingSort covers the accepted orders and a typo.
+func TestParsePendingSort(t *testing.T) {
+	for in, want := range map[string]PendingSort{"": SortByPackage, "package": SortByPackage, "detected": SortByDetectedAt} {
+		if got, err := ParsePendingSort(in); err != nil || got != want {
+			t.Errorf("ParsePendingSort(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePendingSort("date"); !errors.Is(err, ErrInvalidPendingSort) {
+		t.Errorf("ParsePendingSort(date) error = %v, want %v", err, ErrInvalidPendingSort)
+	}
+}
+
+// newAgedPendingList returns a pending list in dir whose clock is now,
+// holding an entry detected exactly 7 days before now and one a second either
+// side.
+func newAgedPendingList(t *testing.T, dir string, now time.Time) *PendingList {
+	t.Helper()
+	pending, err := NewPendingList(dir, WithPendingNowFunc(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	week := 7 * 24 * time.Hour
+	for pkg, ago := range map[string]time.Duration{
+		"app-misc/newer":    week - time.Second,
+		"app-misc/boundary": week,
+		"app-misc/older":    week + time.Second,
+	} {
+		if err := pending.Add(PendingUpdate{
+			Package: pkg, CurrentVersion: "1.0", NewVersion: "1.1",
+			Status: StatusPending, DetectedAt: now.Add(-ago),
+		}); err != nil {
+			t.Fatalf("Add(%s): %v", pkg, err)
+		}
+	}
+	return pending
+}
+
+// TestPendingListDetectedWithin verifies WithDetectedWithin keeps an entry
+// detected exactly at the cutoff and drops one a second older, combined with
+// ListByStatus and sorting, and that zero keeps every entry.
+func TestPendingListDetectedWithin(t *testing.T) {
+	now := time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC)
+	pending := newAgedPendingList(t, t.TempDir(), now)
+	week := 7 * 24 * time.Hour
+
+	want := []string{"app-misc/boundary", "app-misc/newer"}
+	if got := pendingPackages(pending.List(WithDetectedWithin(week))); !slices.Equal(got, want) {
+		t.Errorf("List(within 7d) = %v, want %v", got, want)
+	}
+	if got := pendingPackages(pending.ListByStatus(StatusPending, WithDetectedWithin(week), WithSort(SortByDetectedAt))); !slices.Equal(got, want) {
+		t.Errorf("ListByStatus(pending, within 7d, by detection) = %v, want %v", got, want)
+	}
+	if got := pending.ListByStatus(StatusFailed, WithDetectedWithin(week)); len(got) != 0 {
+		t.Errorf("ListByStatus(failed, within 7d) = %v, want none", pendingPackages(got))
+	}
+	if got := pending.List(WithDetectedWithin(0)); len(got) != 3 {
+		t.Errorf("List(within 0) returned %d entries, want all 3", len(got))
+	}
+}
+
+// TestPendingListExpireStale verifies ExpireStale removes only the entries
+// detected before the cutoff, persists the removal, and leaves the list
+// untouched when nothing is stale or the age is not positive.
+func TestPendingListExpireStale(t *testing.T) {
+	now := time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	pending := newAgedPendingList(t, dir, now)
+	week := 7 * 24 * time.Hour
+
+	removed, err := pending.ExpireStale(week)
+	if err != nil || removed != 1 {
+		t.Fatalf("ExpireStale(7d) = %d, %v; want 1 removed", removed, err)
+	}
+	if pending.Has("app-misc/older") || !pending.Has("app-misc/boundary") || !pending.Has("app-misc/newer") {
+		t.Errorf("after ExpireStale(7d) = %v, want boundary and newer kept", pendingPackages(pending.List()))
+	}
+
+	reloaded, err := NewPendingList(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reloaded.Len() != 2 || reloaded.Has("app-misc/older") {
+		t.Errorf("reloaded list = %v, want the removal persisted", pendingPackages(reloaded.List()))
+	}
+
+	if removed, err := pending.ExpireStale(week); err != nil || removed != 0 {
+		t.Errorf("second ExpireStale(7d) = %d, %v; want 0 removed", removed, err)
+	}
+	if _, err := pending.ExpireStale(0); !errors.Is(err, ErrInvalidAge) {
+		t.Errorf("ExpireStale(0) error = %v, want %v", err, ErrInvalidAge)
+	}
+	if pending.Len() != 2 {
+		t.Errorf("Len() = %d after rejected calls, want 2", pending.Len())
+	}
+}
+
+// TestParseAge covers day and Go duration ages and the rejected forms.
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "1d": 24 * time.Hour, "36h": 36 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "1.5d", "0d", "-2d", "0s", "-1h", "week"} {
+		if _, err := ParseAge(in); !errors.Is(err, ErrInvalidAge) {
+			t.Errorf("ParseAge(%q) error = %v, want %v", in, err, ErrInvalidAge)
+		}
+	}
+}
+
 // TestPendingListDelete tests Delete operation
 func TestPendingListDelete(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -683,7 +888,7 @@ func TestPendingListAtomicWrite(t *testing.T) {
 	}
 
 	for _, f := range files {
-		if f.Name() == "pending.json.tmp" {
+		if strings.HasPrefix(f.Name(), "pending.json.tmp") {
 			t.Error("Temp file should not remain after successful write")
 		}
 	}
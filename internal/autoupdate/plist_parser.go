@@ -0,0 +1,100 @@
+// Package autoupdate provides Apple property list parsing for ebuild autoupdate.
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultPlistKey is the key PlistParser reads when Path is empty. It is the
+// user-facing "marketing" version of a macOS application bundle, which is what
+// upstream release numbers track; CFBundleVersion is usually a build counter.
+const DefaultPlistKey = "CFBundleShortVersionString"
+
+// Error variables for plist parser errors
+var (
+	// ErrInvalidPlist is returned when the content is not an XML property list
+	ErrInvalidPlist = errors.New("invalid plist")
+	// ErrPlistKeyNotFound is returned when the top-level dict lacks the key
+	ErrPlistKeyNotFound = errors.New("plist key not found")
+)
+
+// PlistParser extracts a version from an XML property list (e.g. a macOS
+// application's Info.plist). It reads the value paired with Path in the
+// plist's top-level <dict>, defaulting to DefaultPlistKey.
+//
+// Only the XML plist format is supported; binary plists ("bplist00") are
+// rejected with ErrInvalidPlist. The value must be a scalar (<string>,
+// <integer> or <real>); a nested dict, array or boolean is not a version.
+type PlistParser struct {
+	// Path is the top-level dict key to read. Empty means DefaultPlistKey.
+	Path string
+}
+
+// plistNode is a generic XML element used to walk a plist document with the
+// standard encoding/xml decoder, the same way the provider registry decodes
+// its XML feeds.
+type plistNode struct {
+	XMLName xml.Name
+	Content string      `xml:",chardata"`
+	Nodes   []plistNode `xml:",any"`
+}
+
+// Parse extracts the value of the configured key from plist content.
+func (p *PlistParser) Parse(content []byte) (string, error) {
+	if bytes.HasPrefix(content, []byte("bplist")) {
+		return "", fmt.Errorf("%w: binary plists are not supported", ErrInvalidPlist)
+	}
+
+	var root plistNode
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPlist, err)
+	}
+	if root.XMLName.Local != "plist" {
+		return "", fmt.Errorf("%w: root element is <%s>, want <plist>", ErrInvalidPlist, root.XMLName.Local)
+	}
+
+	var dict *plistNode
+	for i := range root.Nodes {
+		if root.Nodes[i].XMLName.Local == "dict" {
+			dict = &root.Nodes[i]
+			break
+		}
+	}
+	if dict == nil {
+		return "", fmt.Errorf("%w: no top-level <dict>", ErrInvalidPlist)
+	}
+
+	key := p.Path
+	if key == "" {
+		key = DefaultPlistKey
+	}
+
+	// A dict is a flat sequence of <key> elements, each followed by its value
+	// element.
+	nodes := dict.Nodes
+	for i := 0; i < len(nodes); i++ {
+		if nodes[i].XMLName.Local != "key" || strings.TrimSpace(nodes[i].Content) != key {
+			continue
+		}
+		if i+1 >= len(nodes) {
+			return "", fmt.Errorf("%w: key %q has no value", ErrInvalidPlist, key)
+		}
+		value := nodes[i+1]
+		switch value.XMLName.Local {
+		case "string", "integer", "real":
+		default:
+			return "", fmt.Errorf("%w: key %q holds <%s>, want a string", ErrNoVersionFound, key, value.XMLName.Local)
+		}
+		version := strings.TrimSpace(value.Content)
+		if version == "" {
+			return "", ErrNoVersionFound
+		}
+		return version, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrPlistKeyNotFound, key)
+}
@@ -0,0 +1,99 @@
+package autoupdate
+
+import (
+	"errors"
+	"testing"
+)
+
+// sampleInfoPlist is a trimmed macOS application Info.plist. The nested dict
+// deliberately reuses CFBundleShortVersionString to prove only the top-level
+// dict is consulted.
+const sampleInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>org.example.App</string>
+	<key>CFBundleDocumentTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleShortVersionString</key>
+			<string>0.0.1</string>
+		</dict>
+	</array>
+	<key>CFBundleShortVersionString</key>
+	<string>4.12.1</string>
+	<key>CFBundleVersion</key>
+	<string>4121</string>
+	<key>LSRequiresNativeExecution</key>
+	<true/>
+</dict>
+</plist>
+`
+
+// TestPlistParserExtractsBundleVersionByKey verifies extraction of the bundle
+// version from a sample Info.plist, by default key and by configured key.
+func TestPlistParserExtractsBundleVersionByKey(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"default key", "", "4.12.1"},
+		{"explicit short version", "CFBundleShortVersionString", "4.12.1"},
+		{"build version", "CFBundleVersion", "4121"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParserFromConfig(&PackageConfig{Parser: "plist", Path: tt.path})
+			if err != nil {
+				t.Fatalf("NewParserFromConfig() error = %v", err)
+			}
+			got, err := p.Parse([]byte(sampleInfoPlist))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlistParserErrors covers missing keys, non-scalar values and documents
+// that are not XML plists.
+func TestPlistParserErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		wantErr error
+	}{
+		{"missing key", "CFBundleMissing", sampleInfoPlist, ErrPlistKeyNotFound},
+		{"boolean value", "LSRequiresNativeExecution", sampleInfoPlist, ErrNoVersionFound},
+		{"array value", "CFBundleDocumentTypes", sampleInfoPlist, ErrNoVersionFound},
+		{"binary plist", "", "bplist00\x00\x01", ErrInvalidPlist},
+		{"not a plist", "", `<html><body>4.12.1</body></html>`, ErrInvalidPlist},
+		{"malformed xml", "", `<plist><dict>`, ErrInvalidPlist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PlistParser{Path: tt.path}
+			_, err := p.Parse([]byte(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidatePackageConfigAcceptsPlist verifies the plist parser needs no
+// path, since the key defaults to CFBundleShortVersionString.
+func TestValidatePackageConfigAcceptsPlist(t *testing.T) {
+	cfg := &PackageConfig{URL: "https://example.com/Info.plist", Parser: "plist"}
+	if err := ValidatePackageConfig("app-misc/example", cfg); err != nil {
+		t.Errorf("ValidatePackageConfig() error = %v", err)
+	}
+}
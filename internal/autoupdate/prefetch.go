@@ -0,0 +1,121 @@
+// Package autoupdate provides two-stage fetches for ebuild autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// ErrInvalidPreFetch is returned for a prefetch table that cannot work: a
+// missing URL or parser, or a package URL template that does not parse or
+// does not use {{.PreFetch}}.
+var ErrInvalidPreFetch = errors.New("invalid prefetch")
+
+// PreFetchConfig is the first stage of a two-stage fetch. Some sources only
+// reveal where the version lives from an index: a "latest" document naming
+// the current release directory, say. The pre-fetch GETs URL, extracts one
+// value with Parser (json, regex or html, configured like the package's own
+// parser), and that value is substituted into the package URL as
+// {{.PreFetch}} before the real fetch:
+//
+//	url = "https://example.com{{.PreFetch}}/version.json"
+//	["app-misc/foo".prefetch]
+//	url = "https://example.com/latest.json"
+//	parser = "json"
+//	path = "current.dir"
+//
+// If the substituted URL is relative (url = "{{.PreFetch}}" with a value
+// such as "releases/2.0/info.json"), it is resolved against the pre-fetch URL.
+// The package's headers are sent with both requests.
+type PreFetchConfig struct {
+	// URL is the index to fetch first
+	URL string `toml:"url"`
+	// Parser is the parser type for the index: "json", "regex", or "html"
+	Parser string `toml:"parser"`
+	// Path is the JSON path of the value (json parser)
+	Path string `toml:"path,omitempty"`
+	// Pattern is the regex with a capture group for the value (regex parser,
+	// or applied to the selected text with html)
+	Pattern string `toml:"pattern,omitempty"`
+	// Selector is the CSS selector of the value (html parser)
+	Selector string `toml:"selector,omitempty"`
+	// XPath is the XPath of the value (html parser)
+	XPath string `toml:"xpath,omitempty"`
+}
+
+// parserConfig returns the pre-fetch as a PackageConfig, so the package
+// parsers and their validation apply unchanged.
+func (p *PreFetchConfig) parserConfig() *PackageConfig {
+	return &PackageConfig{
+		URL:      p.URL,
+		Parser:   p.Parser,
+		Path:     p.Path,
+		Pattern:  p.Pattern,
+		Selector: p.Selector,
+		XPath:    p.XPath,
+	}
+}
+
+// validatePreFetch checks cfg's prefetch table and that its URL template
+// consumes the pre-fetched value.
+func validatePreFetch(pkg string, cfg *PackageConfig) error {
+	pf := cfg.PreFetch
+	switch pf.Parser {
+	case "json", "regex", "html":
+	default:
+		return fmt.Errorf("package %s: %w: parser must be json, regex or html, got %q", pkg, ErrInvalidPreFetch, pf.Parser)
+	}
+	if err := ValidatePackageConfig(pkg, pf.parserConfig()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPreFetch, err)
+	}
+	if !strings.Contains(cfg.URL, ".PreFetch") {
+		return fmt.Errorf("package %s: %w: url does not use {{.PreFetch}}", pkg, ErrInvalidPreFetch)
+	}
+	if _, err := template.New("url").Option("missingkey=error").Parse(cfg.URL); err != nil {
+		return fmt.Errorf("package %s: %w: url template: %v", pkg, ErrInvalidPreFetch, err)
+	}
+	return nil
+}
+
+// resolvePreFetch runs the first stage of pkg's two-stage fetch and returns
+// the package URL with the extracted value substituted.
+func (c *Checker) resolvePreFetch(pkg string, cfg *PackageConfig) (string, error) {
+	pf := cfg.PreFetch
+	content, err := c.fetchContent(pf.URL, cfg.Headers, c.operationTimeout(cfg))
+	if err != nil {
+		return "", &FetchError{Package: pkg, URL: pf.URL, Err: fmt.Errorf("prefetch: %w", err)}
+	}
+	parser, err := NewParserFromConfig(pf.parserConfig())
+	if err != nil {
+		return "", &ConfigError{Package: pkg, Err: fmt.Errorf("%w: %v", ErrInvalidPreFetch, err)}
+	}
+	value, err := parser.Parse(content)
+	if err != nil {
+		return "", &ParseError{Package: pkg, Parser: pf.Parser, Err: fmt.Errorf("prefetch: %w", err)}
+	}
+
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(cfg.URL)
+	if err != nil {
+		return "", &ConfigError{Package: pkg, Err: fmt.Errorf("%w: url template: %v", ErrInvalidPreFetch, err)}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, struct{ PreFetch string }{strings.TrimSpace(value)}); err != nil {
+		return "", &ConfigError{Package: pkg, Err: fmt.Errorf("%w: url template: %v", ErrInvalidPreFetch, err)}
+	}
+
+	target, err := url.Parse(b.String())
+	if err != nil {
+		return "", &FetchError{Package: pkg, URL: b.String(), Err: fmt.Errorf("prefetch produced an invalid URL: %w", err)}
+	}
+	if !target.IsAbs() {
+		base, err := url.Parse(pf.URL)
+		if err != nil {
+			return "", &FetchError{Package: pkg, URL: pf.URL, Err: err}
+		}
+		target = base.ResolveReference(target)
+	}
+	return target.String(), nil
+}
@@ -0,0 +1,164 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newPreFetchServer serves an index at /latest.json and /index.txt naming the
+// current release, and the release's version document under /releases/2.1/.
+func newPreFetchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"current":{"dir":"/releases/2.1"}}`))
+	})
+	mux.HandleFunc("/index.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("stable: releases/2.1/info.json\n"))
+	})
+	mux.HandleFunc("/releases/2.1/info.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"2.1.0"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestCheckPackage_PreFetch verifies the pre-fetched value is substituted into
+// the package URL, both into an absolute template and as a relative URL
+// resolved against the index.
+func TestCheckPackage_PreFetch(t *testing.T) {
+	srv := newPreFetchServer(t)
+
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"json index into absolute template", `["app-misc/two-stage"]
+url = "` + srv.URL + `{{.PreFetch}}/info.json"
+parser = "json"
+path = "version"
+
+["app-misc/two-stage".prefetch]
+url = "` + srv.URL + `/latest.json"
+parser = "json"
+path = "current.dir"
+`},
+		{"regex index into relative URL", `["app-misc/two-stage"]
+url = "{{.PreFetch}}"
+parser = "json"
+path = "version"
+
+["app-misc/two-stage".prefetch]
+url = "` + srv.URL + `/index.txt"
+parser = "regex"
+pattern = 'stable: (\S+)'
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay, _ := writePackagesTOML(t, tt.config)
+			createTestEbuild(t, overlay, "app-misc/two-stage", "2.0.0")
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("app-misc/two-stage", true)
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != "2.1.0" || !result.HasUpdate {
+				t.Errorf("UpstreamVersion = %q (HasUpdate %v), want 2.1.0", result.UpstreamVersion, result.HasUpdate)
+			}
+		})
+	}
+}
+
+// TestCheckPackage_PreFetchFailureFallsThrough verifies an index that cannot
+// be resolved fails only the primary: the fallback URL still answers, and the
+// LLM stage reads the index page.
+func TestCheckPackage_PreFetchFailureFallsThrough(t *testing.T) {
+	srv := newPreFetchServer(t)
+
+	tests := []struct {
+		name   string
+		config string
+		llm    *fakeLLMProvider
+	}{
+		{"missing index, fallback answers", `["app-misc/two-stage"]
+url = "` + srv.URL + `{{.PreFetch}}/info.json"
+parser = "json"
+path = "version"
+fallback_url = "` + srv.URL + `/releases/2.1/info.json"
+fallback_parser = "json"
+
+["app-misc/two-stage".prefetch]
+url = "` + srv.URL + `/missing.json"
+parser = "json"
+path = "current.dir"
+`, nil},
+		{"unmatched index, LLM reads it", `["app-misc/two-stage"]
+url = "{{.PreFetch}}"
+parser = "json"
+path = "version"
+llm_prompt = "Extract the stable version"
+
+["app-misc/two-stage".prefetch]
+url = "` + srv.URL + `/index.txt"
+parser = "regex"
+pattern = 'beta: (\S+)'
+`, &fakeLLMProvider{version: "2.1.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay, _ := writePackagesTOML(t, tt.config)
+			createTestEbuild(t, overlay, "app-misc/two-stage", "2.0.0")
+
+			opts := []CheckerOption{WithConfigDir(t.TempDir()), WithRateLimiter(unlimitedRateLimiter())}
+			if tt.llm != nil {
+				opts = append(opts, WithLLMClient(tt.llm))
+			}
+			checker, err := NewChecker(overlay, opts...)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("app-misc/two-stage", true)
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != "2.1.0" || !result.HasUpdate {
+				t.Errorf("UpstreamVersion = %q (HasUpdate %v), want 2.1.0", result.UpstreamVersion, result.HasUpdate)
+			}
+			if tt.llm != nil && !strings.Contains(string(tt.llm.gotContent), "stable: releases/2.1") {
+				t.Errorf("LLM read %q, want the index page", tt.llm.gotContent)
+			}
+		})
+	}
+}
+
+// TestValidatePreFetch verifies a prefetch whose value the URL never uses, or
+// whose parser is incomplete, is rejected.
+func TestValidatePreFetch(t *testing.T) {
+	tests := []struct {
+		url string
+		pf  PreFetchConfig
+	}{
+		{"https://example.com/info.json", PreFetchConfig{URL: "https://example.com/i", Parser: "json", Path: "dir"}},
+		{"https://example.com{{.PreFetch}}", PreFetchConfig{URL: "https://example.com/i", Parser: "regex"}},
+		{"https://example.com{{.PreFetch}}", PreFetchConfig{URL: "https://example.com/i", Parser: "plist"}},
+	}
+	for _, tt := range tests {
+		pf := tt.pf
+		cfg := &PackageConfig{URL: tt.url, Parser: "json", Path: "version", PreFetch: &pf}
+		if err := ValidatePackageConfig("app-misc/two-stage", cfg); !errors.Is(err, ErrInvalidPreFetch) {
+			t.Errorf("url %q, prefetch %+v: error = %v, want %v", tt.url, pf, err, ErrInvalidPreFetch)
+		}
+	}
+}
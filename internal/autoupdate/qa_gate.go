@@ -0,0 +1,149 @@
+// Package autoupdate provides the blocking pkgcheck preflight of an apply.
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// ErrQAFailed is returned (wrapped) when the pkgcheck preflight of an apply
+// with WithApplierRunQA reports errors for the new ebuild.
+var ErrQAFailed = errors.New("QA check failed")
+
+// WithApplierRunQA makes every apply run `pkgcheck scan` on the package once
+// its Manifest is generated, and fail the apply when pkgcheck reports errors:
+// the new ebuild is removed, the Manifest restored and the pkgcheck output
+// attached to ApplyResult.QASummary. Warnings do not block. When pkgcheck is
+// not installed the preflight is skipped; when pkgdev is not, and
+// WithApplierSkipMissingManifest would skip the Manifest, the apply fails
+// instead of going unchecked. Off by default, in which case
+// pkgcheck only runs as the advisory pass after an LLM manifest fix.
+func WithApplierRunQA(run bool) ApplierOption {
+	return func(a *Applier) {
+		a.runQA = run
+	}
+}
+
+// defaultQATool is the QA tool run unless WithApplierQATool names another.
+const defaultQATool = "pkgcheck"
+
+// WithApplierQATool runs tool, a pkgcheck binary named on PATH or by path,
+// for the QA preflight, the advisory pass after an LLM fix and Validate. An
+// empty tool keeps the default, "pkgcheck".
+func WithApplierQATool(tool string) ApplierOption {
+	return func(a *Applier) {
+		if tool != "" {
+			a.qaTool = tool
+		}
+	}
+}
+
+// runQAGate runs the blocking preflight for pkg and returns pkgcheck's
+// findings. The error wraps ErrQAFailed when the scan exited non-zero with
+// findings; `--exit error` limits that to error-level results.
+//
+// A non-zero exit without findings, which is how a pkgcheck crash looks, is
+// logged and lets the apply through: the gate guards against bad ebuilds, not
+// a broken QA tool.
+func (a *Applier) runQAGate(pkg string) (string, error) {
+	if _, err := lookPath(a.qaTool); err != nil {
+		logger.Debug("qa: %s not found; skipping QA preflight for %s", a.qaTool, pkg)
+		return "", nil
+	}
+
+	findings, err := a.pkgcheckScan(filepath.Join(a.overlayPath, pkg), pkg, "--exit", "error")
+	if errors.Is(err, ErrQAToolFailed) {
+		warnLogf("qa: skipping QA preflight: %v", err)
+		return "", nil
+	}
+	return findings, err
+}
+
+// pkgcheckScan runs `scan` of the QA tool on pkg from dir, with args before
+// the package atom, bounded by qaCheckTimeout. It is the one place the
+// preflight, the advisory pass after an LLM fix and Validate read pkgcheck's
+// output.
+//
+// Only stdout counts as findings: pkgcheck's reporter writes them there, while
+// diagnostics and crashes (e.g. a GitAddon traceback when the overlay's git
+// history confuses pkgcheck) go to stderr. The error wraps ErrQAFailed when
+// the scan exited non-zero with findings, and ErrQAToolFailed, carrying the
+// stderr, when it exited non-zero without any; each caller decides what the
+// latter means for it.
+func (a *Applier) pkgcheckScan(dir, pkg string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, qaCheckTimeout)
+	defer cancel()
+
+	argv := append(append([]string{"scan"}, args...), pkg)
+	cmd := a.execCommand(ctx, a.qaTool, argv...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	findings := strings.TrimSpace(stdout.String())
+	if runErr == nil {
+		return findings, nil
+	}
+	if findings == "" {
+		return "", fmt.Errorf("%w: %s scan of %s: %v (%s)", ErrQAToolFailed, a.qaTool, pkg, runErr, strings.TrimSpace(stderr.String()))
+	}
+	return findings, fmt.Errorf("%w: %s reported errors for %s", ErrQAFailed, a.qaTool, pkg)
+}
+
+// qaNeedsManifest returns the error for an apply whose manifest step is
+// skipped because pkgdev is missing while the QA preflight was asked for:
+// pkgcheck would scan a Manifest that was never regenerated, so rather than
+// pass the apply unchecked it is refused. Without pkgcheck the preflight is
+// skipped anyway, as runQAGate does, and nil is returned.
+func (a *Applier) qaNeedsManifest(pkg string) error {
+	if !a.runQA {
+		return nil
+	}
+	if _, err := lookPath(a.qaTool); err != nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not installed, so the Manifest of %s cannot be generated for the QA preflight (install dev-util/pkgdev or apply without QA)",
+		ErrQAFailed, manifestTool, pkg)
+}
+
+// fileSnapshot is the content of a file before an apply step rewrote it, so a
+// failed step can put it back.
+type fileSnapshot struct {
+	path    string
+	data    []byte
+	existed bool
+}
+
+// snapshotFile records the current content of path; a missing file is
+// recorded as such and restore then removes it.
+func snapshotFile(path string) (*fileSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &fileSnapshot{path: path}, nil
+		}
+		return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+	return &fileSnapshot{path: path, data: data, existed: true}, nil
+}
+
+// restore writes the snapshot back.
+func (s *fileSnapshot) restore() error {
+	if !s.existed {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(s.path, s.data, 0o644)
+}
@@ -0,0 +1,193 @@
+package autoupdate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// applyWithQA applies app-misc/tool 1.0 → 1.1 with the QA preflight on, a
+// pkgdev that rewrites the Manifest, and pkgcheck replaced by script.
+func applyWithQA(t *testing.T, script string) (*Applier, *PendingList, *ApplyResult, error) {
+	t.Helper()
+	stubLookPathFound(t)
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	pkg := "app-misc/tool"
+	createTestEbuildFile(t, overlayDir, pkg, "1.0")
+	manifest := filepath.Join(overlayDir, pkg, "Manifest")
+	if err := os.WriteFile(manifest, []byte("DIST tool-1.0.tar.gz 1 SHA512 aa\n"), 0o644); err != nil {
+		t.Fatalf("failed to write Manifest: %v", err)
+	}
+
+	pending, _ := NewPendingList(configDir)
+	pending.Add(PendingUpdate{
+		Package:        pkg,
+		CurrentVersion: "1.0",
+		NewVersion:     "1.1",
+		Status:         StatusPending,
+	})
+
+	seam := func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		switch name {
+		case "pkgdev":
+			return exec.CommandContext(ctx, "sh", "-c", "echo 'DIST tool-1.1.tar.gz 1 SHA512 bb' >> Manifest")
+		case "pkgcheck":
+			return exec.CommandContext(ctx, "sh", "-c", script)
+		default:
+			return exec.CommandContext(ctx, "true")
+		}
+	}
+
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(seam),
+		WithApplierRunQA(true),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier: %v", err)
+	}
+	result, applyErr := applier.Apply(pkg, false)
+	return applier, pending, result, applyErr
+}
+
+// TestApply_QAFailureReverts verifies pkgcheck errors fail the apply, remove
+// the new ebuild, restore the Manifest and attach the QA output.
+func TestApply_QAFailureReverts(t *testing.T) {
+	const finding = "app-misc/tool-1.1: ERROR: MissingLicense"
+	applier, pending, result, err := applyWithQA(t, "echo '"+finding+"'; exit 1")
+
+	if !errors.Is(err, ErrQAFailed) {
+		t.Fatalf("Apply error = %v, want ErrQAFailed", err)
+	}
+	if result.Success {
+		t.Error("Success = true, want false")
+	}
+	if !strings.Contains(result.QASummary, "MissingLicense") {
+		t.Errorf("QASummary = %q, want the pkgcheck output", result.QASummary)
+	}
+	if _, statErr := os.Stat(applier.EbuildPath("app-misc/tool", "1.1")); !os.IsNotExist(statErr) {
+		t.Errorf("new ebuild not reverted: %v", statErr)
+	}
+	data, _ := os.ReadFile(filepath.Join(applier.OverlayPath(), "app-misc/tool", "Manifest"))
+	if strings.Contains(string(data), "tool-1.1") {
+		t.Errorf("Manifest not restored:\n%s", data)
+	}
+	if update, _ := pending.Get("app-misc/tool"); update == nil || update.Status != StatusFailed {
+		t.Errorf("pending entry = %+v, want status failed", update)
+	}
+}
+
+// TestApply_QAPassCompletes verifies a clean (or warnings-only) pkgcheck run
+// lets the apply through.
+func TestApply_QAPassCompletes(t *testing.T) {
+	applier, pending, result, err := applyWithQA(t, "echo 'app-misc/tool-1.1: WARNING: RedundantVersion'; exit 0")
+	if err != nil {
+		t.Fatalf("Apply error = %v", err)
+	}
+	if !result.Success {
+		t.Fatal("Success = false, want true")
+	}
+	if _, statErr := os.Stat(applier.EbuildPath("app-misc/tool", "1.1")); statErr != nil {
+		t.Errorf("new ebuild missing: %v", statErr)
+	}
+	if _, found := pending.Get("app-misc/tool"); found {
+		t.Error("pending entry not removed after successful apply")
+	}
+}
+
+// TestApply_QACrashDoesNotBlock verifies a pkgcheck that fails without
+// findings (a crash on stderr) does not block the apply.
+func TestApply_QACrashDoesNotBlock(t *testing.T) {
+	_, _, result, err := applyWithQA(t, "echo 'Traceback' >&2; exit 2")
+	if err != nil || !result.Success {
+		t.Fatalf("Apply = %v (success %v), want success", err, result.Success)
+	}
+}
+
+// TestApply_QASkippedWithoutPkgcheck verifies the preflight is skipped when
+// pkgcheck is not installed.
+func TestApply_QASkippedWithoutPkgcheck(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+	createTestEbuildFile(t, overlayDir, "app-misc/tool", "1.0")
+	pending, _ := NewPendingList(configDir)
+	pending.Add(PendingUpdate{Package: "app-misc/tool", CurrentVersion: "1.0", NewVersion: "1.1", Status: StatusPending})
+
+	lookPath = func(name string) (string, error) {
+		if name == "pkgcheck" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + name, nil
+	}
+	seam := func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if name == "pkgcheck" {
+			t.Error("pkgcheck run although it is not installed")
+		}
+		return exec.CommandContext(ctx, "true")
+	}
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(seam),
+		WithApplierRunQA(true),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier: %v", err)
+	}
+	result, err := applier.Apply("app-misc/tool", false)
+	if err != nil || !result.Success {
+		t.Fatalf("Apply = %v (success %v), want success", err, result.Success)
+	}
+}
+
+// TestApply_QARefusedWhenManifestSkipped verifies that with pkgcheck installed
+// but pkgdev missing, an apply asking for the QA preflight fails instead of
+// skipping the Manifest and the scan with it.
+func TestApply_QARefusedWhenManifestSkipped(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(name string) (string, error) {
+		if name == "pkgdev" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + name, nil
+	}
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+	createTestEbuildFile(t, overlayDir, "app-misc/tool", "1.0")
+	pending, _ := NewPendingList(configDir)
+	pending.Add(PendingUpdate{Package: "app-misc/tool", CurrentVersion: "1.0", NewVersion: "1.1", Status: StatusPending})
+
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(mockExecCommandSuccess),
+		WithApplierSkipMissingManifest(true),
+		WithApplierRunQA(true),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier: %v", err)
+	}
+	result, err := applier.Apply("app-misc/tool", false)
+	if !errors.Is(err, ErrQAFailed) || result.ManifestSkipped {
+		t.Fatalf("Apply = %v (ManifestSkipped %v), want %v", err, result.ManifestSkipped, ErrQAFailed)
+	}
+	if _, statErr := os.Stat(applier.EbuildPath("app-misc/tool", "1.1")); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("new ebuild was not rolled back: %v", statErr)
+	}
+	if update, _ := pending.Get("app-misc/tool"); update.Status != StatusFailed {
+		t.Errorf("pending status = %s, want %s", update.Status, StatusFailed)
+	}
+}
@@ -0,0 +1,186 @@
+// Package autoupdate provides quarantine of packages that keep failing checks.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// DefaultQuarantineThreshold is the number of consecutive failed CheckAll
+// runs after which a package is quarantined, unless WithQuarantineThreshold
+// says otherwise.
+const DefaultQuarantineThreshold = 5
+
+// ErrNotQuarantined is returned by ClearQuarantine for a package that is not
+// quarantined.
+var ErrNotQuarantined = errors.New("package is not quarantined")
+
+// FailureRecord counts the consecutive runs in which a package's check
+// failed. A success deletes the record, so Count is always a current streak.
+type FailureRecord struct {
+	// Count is the number of consecutive failed runs
+	Count int `json:"count"`
+	// LastError is the error of the most recent failure
+	LastError string `json:"last_error"`
+	// LastFailure is when the most recent failure was recorded
+	LastFailure time.Time `json:"last_failure"`
+	// QuarantinedAt is when Count reached the threshold; zero while the
+	// package is still checked
+	QuarantinedAt time.Time `json:"quarantined_at,omitempty"`
+}
+
+// Quarantined reports whether the package is skipped by CheckAll.
+func (r FailureRecord) Quarantined() bool {
+	return !r.QuarantinedAt.IsZero()
+}
+
+// QuarantinedPackage pairs a quarantined package with its failure record.
+type QuarantinedPackage struct {
+	Package string
+	FailureRecord
+}
+
+// WithQuarantineThreshold sets after how many consecutive failed CheckAll
+// runs a package is quarantined. Zero disables quarantine: failures are
+// neither counted nor acted on, though existing quarantines still apply until
+// cleared. A negative value is rejected.
+func WithQuarantineThreshold(n int) CheckerOption {
+	return func(c *Checker) error {
+		if n < 0 {
+			return fmt.Errorf("checker quarantine threshold must be >= 0, got %d", n)
+		}
+		c.quarantineThreshold = n
+		return nil
+	}
+}
+
+// recordRunOutcomes updates the failure streaks after a CheckAll run: each
+// failed package's count goes up (quarantining it on reaching threshold) and
+// each succeeded package's record is dropped. It writes the store once, and
+// only when something changed, and returns the packages quarantined by this
+// run, sorted.
+func (p *PendingList) recordRunOutcomes(failed map[string]error, succeeded []string, threshold int) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for _, pkg := range succeeded {
+		if _, exists := p.failures[pkg]; exists {
+			delete(p.failures, pkg)
+			changed = true
+		}
+	}
+
+	var quarantined []string
+	now := p.nowFunc()
+	for pkg, err := range failed {
+		rec := p.failures[pkg]
+		rec.Count++
+		rec.LastFailure = now
+		if err != nil {
+			rec.LastError = err.Error()
+		}
+		if !rec.Quarantined() && rec.Count >= threshold {
+			rec.QuarantinedAt = now
+			quarantined = append(quarantined, pkg)
+		}
+		p.failures[pkg] = rec
+		changed = true
+	}
+	sort.Strings(quarantined)
+
+	if !changed {
+		return quarantined, nil
+	}
+	return quarantined, p.saveUnsafe()
+}
+
+// IsQuarantined reports whether CheckAll skips pkg.
+func (p *PendingList) IsQuarantined(pkg string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.failures[pkg].Quarantined()
+}
+
+// FailureCount returns the current consecutive-failure streak of pkg.
+func (p *PendingList) FailureCount(pkg string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.failures[pkg].Count
+}
+
+// Quarantined lists the quarantined packages, sorted by name.
+func (p *PendingList) Quarantined() []QuarantinedPackage {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var list []QuarantinedPackage
+	for pkg, rec := range p.failures {
+		if rec.Quarantined() {
+			list = append(list, QuarantinedPackage{Package: pkg, FailureRecord: rec})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Package < list[j].Package })
+	return list
+}
+
+// ClearQuarantine returns pkg to CheckAll and resets its failure streak, for
+// a maintainer who has fixed the package's entry. It returns
+// ErrNotQuarantined when pkg is not quarantined.
+func (p *PendingList) ClearQuarantine(pkg string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.failures[pkg].Quarantined() {
+		return fmt.Errorf("%w: %s", ErrNotQuarantined, pkg)
+	}
+	delete(p.failures, pkg)
+	return p.saveUnsafe()
+}
+
+// updateFailureStreaks feeds a finished CheckAll run into the quarantine
+// bookkeeping. A cancelled run is not recorded: its failures say nothing
+// about the packages. Orphaned results (ebuild removed) count as neither
+// outcome, since they are disabled instead, and neither do failures served
+// from the negative cache, which would otherwise count one failed lookup
+// once per run until it expires.
+func (c *Checker) updateFailureStreaks(results []CheckResult, failures map[string]error) {
+	if c.quarantineThreshold == 0 || c.ctx.Err() != nil {
+		return
+	}
+	succeeded := make([]string, 0, len(results))
+	for _, r := range results {
+		if !r.Orphaned {
+			succeeded = append(succeeded, r.Package)
+		}
+	}
+	counted := make(map[string]error, len(failures))
+	for pkg, err := range failures {
+		if !errors.Is(err, ErrCachedFailure) {
+			counted[pkg] = err
+		}
+	}
+	quarantined, err := c.pending.recordRunOutcomes(counted, succeeded, c.quarantineThreshold)
+	if err != nil {
+		warnLogf("failed to record check failures for quarantine: %v", err)
+	}
+	for _, pkg := range quarantined {
+		logger.Warn("quarantined %s after %d consecutive failed checks; clear it with --clear-quarantine once fixed",
+			pkg, c.quarantineThreshold)
+	}
+}
+
+// Quarantined lists the packages CheckAll currently skips.
+func (c *Checker) Quarantined() []QuarantinedPackage {
+	return c.pending.Quarantined()
+}
+
+// ClearQuarantine returns pkg to CheckAll and resets its failure streak. It
+// returns ErrNotQuarantined when pkg is not quarantined.
+func (c *Checker) ClearQuarantine(pkg string) error {
+	return c.pending.ClearQuarantine(pkg)
+}
@@ -0,0 +1,109 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newQuarantineChecker builds a checker for app-misc/flaky whose version
+// source fails while *failing is true, over a config dir shared by the runs.
+func newQuarantineChecker(t *testing.T, configDir string, failing *atomic.Bool, requests *atomic.Int32) *Checker {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			_, _ = w.Write([]byte(`{"schema":"rotted"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"1.1.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-misc/flaky"]
+url = "` + srv.URL + `"
+parser = "json"
+path = "version"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/flaky", "1.0.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(configDir),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithQuarantineThreshold(3),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	return checker
+}
+
+// TestCheckAll_QuarantineAfterThreshold verifies a package failing the
+// threshold of consecutive runs is quarantined and then skipped without a
+// fetch, and that ClearQuarantine brings it back.
+func TestCheckAll_QuarantineAfterThreshold(t *testing.T) {
+	configDir := t.TempDir()
+	var failing atomic.Bool
+	var requests atomic.Int32
+	failing.Store(true)
+
+	for run := 1; run <= 3; run++ {
+		checker := newQuarantineChecker(t, configDir, &failing, &requests)
+		result := checker.CheckAll(true)
+		if _, failed := result.Failures["app-misc/flaky"]; !failed {
+			t.Fatalf("run %d: expected a failure, got %+v", run, result)
+		}
+		if got := checker.Pending().FailureCount("app-misc/flaky"); got != run {
+			t.Errorf("run %d: FailureCount = %d", run, got)
+		}
+	}
+
+	checker := newQuarantineChecker(t, configDir, &failing, &requests)
+	if q := checker.Quarantined(); len(q) != 1 || q[0].Package != "app-misc/flaky" || q[0].LastError == "" {
+		t.Fatalf("Quarantined() = %+v, want app-misc/flaky with its last error", q)
+	}
+	before := requests.Load()
+	result := checker.CheckAll(true)
+	if len(result.Items) != 0 || len(result.Failures) != 0 {
+		t.Errorf("quarantined package was checked: %+v", result)
+	}
+	if requests.Load() != before {
+		t.Error("quarantined package must not be fetched")
+	}
+
+	if err := checker.ClearQuarantine("app-misc/flaky"); err != nil {
+		t.Fatalf("ClearQuarantine() error = %v", err)
+	}
+	if err := checker.ClearQuarantine("app-misc/flaky"); !errors.Is(err, ErrNotQuarantined) {
+		t.Errorf("second ClearQuarantine() error = %v, want %v", err, ErrNotQuarantined)
+	}
+	failing.Store(false)
+	checker = newQuarantineChecker(t, configDir, &failing, &requests)
+	if result := checker.CheckAll(true); len(result.Items) != 1 || !result.Items[0].HasUpdate {
+		t.Errorf("cleared package not checked: %+v", result)
+	}
+}
+
+// TestCheckAll_SuccessResetsFailureCount verifies a success between failures
+// restarts the streak, so intermittent failures never quarantine.
+func TestCheckAll_SuccessResetsFailureCount(t *testing.T) {
+	configDir := t.TempDir()
+	var failing atomic.Bool
+	var requests atomic.Int32
+
+	for _, fail := range []bool{true, true, false, true, true} {
+		failing.Store(fail)
+		newQuarantineChecker(t, configDir, &failing, &requests).CheckAll(true)
+	}
+
+	checker := newQuarantineChecker(t, configDir, &failing, &requests)
+	if got := checker.Pending().FailureCount("app-misc/flaky"); got != 2 {
+		t.Errorf("FailureCount = %d, want 2 (reset by the success)", got)
+	}
+	if checker.Pending().IsQuarantined("app-misc/flaky") {
+		t.Error("package quarantined despite an intervening success")
+	}
+}
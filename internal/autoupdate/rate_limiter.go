@@ -361,11 +361,65 @@ func (r *RateLimiter) DomainCount() int {
 	return len(r.httpLimiters)
 }
 
-// Reset clears all HTTP domain limiters and resets the LLM limiter.
-// Useful for testing.
-func (r *RateLimiter) Reset() {
+// LimitInfo describes the HTTP rate limit state of one host.
+type LimitInfo struct {
+	// Limit is the sustained rate in requests per second.
+	Limit rate.Limit
+	// Burst is the maximum number of requests allowed at once.
+	Burst int
+	// Tokens is the number of requests that may be made right now; it can be
+	// negative when reservations are outstanding.
+	Tokens float64
+	// Throttled reports whether the next request would have to wait.
+	Throttled bool
+	// Tracked is false for a host known only from a policy (WithHostPolicy,
+	// WithTunedHostPolicies) that has not been used yet.
+	Tracked bool
+	// LastUsed is when the host's limiter was last used; zero if not Tracked.
+	LastUsed time.Time
+}
+
+// Limits returns the HTTP rate limit state of every tracked host and of every
+// host with a configured policy, keyed by host. It is a snapshot: it neither
+// consumes tokens nor creates limiters, so inspecting a host does not change
+// how it is throttled.
+func (r *RateLimiter) Limits() map[string]LimitInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limits := make(map[string]LimitInfo, len(r.httpLimiters)+len(r.hostPolicies))
+	for host, pol := range r.hostPolicies {
+		limit := rate.Every(pol.interval)
+		limits[host] = LimitInfo{Limit: limit, Burst: pol.burst, Tokens: float64(pol.burst)}
+	}
+	for host, entry := range r.httpLimiters {
+		tokens := entry.limiter.Tokens()
+		limits[host] = LimitInfo{
+			Limit:     entry.limiter.Limit(),
+			Burst:     entry.limiter.Burst(),
+			Tokens:    tokens,
+			Throttled: tokens < 1,
+			Tracked:   true,
+			LastUsed:  entry.lastUsed,
+		}
+	}
+	return limits
+}
+
+// Reset clears rate limiter state. With no hosts it clears all HTTP domain
+// limiters and resets the LLM limiter. Otherwise only the named hosts are
+// forgotten: their next request starts from a fresh limiter built from the
+// host policy (or the fallback interval), dropping any throttling and any
+// SetHTTPLimit override. Unknown hosts are ignored.
+func (r *RateLimiter) Reset(hosts ...string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if len(hosts) > 0 {
+		for _, host := range hosts {
+			delete(r.httpLimiters, host)
+		}
+		return
+	}
 	r.httpLimiters = make(map[string]*domainEntry)
 	r.llmLimiter = rate.NewLimiter(rate.Every(DefaultLLMInterval), DefaultLLMBurst)
 }
@@ -360,6 +360,48 @@ func TestRateLimiter_ResetClears(t *testing.T) {
 	}
 }
 
+// TestRateLimiter_LimitsAndResetHost sets limits, reads them back through
+// Limits, resets one host, and checks only that host's state is dropped.
+func TestRateLimiter_LimitsAndResetHost(t *testing.T) {
+	rl := NewRateLimiter(WithHostPolicy("api.github.com", 100*time.Millisecond, 2))
+
+	rl.SetHTTPLimit("example.com", rate.Limit(5), 3)
+	rl.SetHTTPLimit("example.org", rate.Every(time.Hour), 1)
+	if !rl.AllowHTTP("example.org") {
+		t.Fatal("first request to example.org should be allowed")
+	}
+
+	limits := rl.Limits()
+	if got := limits["example.com"]; got.Limit != 5 || got.Burst != 3 || !got.Tracked || got.Throttled {
+		t.Errorf("Limits()[example.com] = %+v, want tracked 5/s burst 3, not throttled", got)
+	}
+	if got := limits["example.org"]; !got.Throttled {
+		t.Errorf("Limits()[example.org] = %+v, want throttled after its only token", got)
+	}
+	if got := limits["api.github.com"]; got.Tracked || got.Limit != rate.Every(100*time.Millisecond) || got.Burst != 2 {
+		t.Errorf("Limits()[api.github.com] = %+v, want untracked policy 10/s burst 2", got)
+	}
+	if rl.DomainCount() != 2 {
+		t.Errorf("Limits() must not create limiters, DomainCount = %d", rl.DomainCount())
+	}
+
+	rl.Reset("example.org")
+
+	limits = rl.Limits()
+	if _, ok := limits["example.org"]; ok {
+		t.Errorf("Limits() still has example.org after Reset: %+v", limits["example.org"])
+	}
+	if got := limits["example.com"]; got.Limit != 5 || got.Burst != 3 {
+		t.Errorf("Reset(example.org) changed example.com: %+v", got)
+	}
+	if !rl.AllowHTTP("example.org") {
+		t.Error("example.org should be allowed again after Reset")
+	}
+	if got := rl.HTTPLimit("example.org"); got != rate.Every(DefaultHTTPInterval) {
+		t.Errorf("example.org limit after Reset = %v, want the default %v", got, rate.Every(DefaultHTTPInterval))
+	}
+}
+
 // =============================================================================
 // Property-Based Tests
 // =============================================================================
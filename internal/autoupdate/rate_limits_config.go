@@ -0,0 +1,156 @@
+// Package autoupdate provides per-host HTTP rate limits loaded from the user's
+// configuration directory.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// RateLimitsFileName is the file, in the autoupdate configuration directory,
+// holding user-tuned HTTP rate limits.
+const RateLimitsFileName = "ratelimits.toml"
+
+// ErrInvalidRateLimit is returned when ratelimits.toml holds a limit that
+// cannot be applied: a non-positive rps, a negative burst, or an unknown key.
+var ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+// HostRateLimit is one HTTP rate limit: RPS requests per second sustained,
+// with up to Burst at once. A zero Burst means 1.
+type HostRateLimit struct {
+	RPS   float64 `toml:"rps"`
+	Burst int     `toml:"burst"`
+}
+
+// RateLimitsConfig is the content of ratelimits.toml:
+//
+//	[default]
+//	rps = 0.2
+//
+//	[hosts."api.github.com"]
+//	rps = 0.5
+//	burst = 2
+//
+// Hosts are matched exactly, as WaitHTTP is called with them (a port is part
+// of the host). A host without an entry falls back to Default, and without
+// Default to DefaultHTTPInterval. The hosts live in their own table so that a
+// host literally named "default" cannot collide with the fallback.
+type RateLimitsConfig struct {
+	// Default is the limit for hosts not listed in Hosts, nil to keep the
+	// built-in fallback.
+	Default *HostRateLimit `toml:"default"`
+	// Hosts maps a host to its limit.
+	Hosts map[string]HostRateLimit `toml:"hosts"`
+}
+
+// interval converts the limit to the minimum spacing between requests the
+// RateLimiter works in.
+func (l HostRateLimit) interval() time.Duration {
+	return time.Duration(float64(time.Second) / l.RPS)
+}
+
+// burst returns Burst, defaulting to 1.
+func (l HostRateLimit) burst() int {
+	return max(l.Burst, 1)
+}
+
+// validate rejects a limit the RateLimiter would silently ignore, so a typo
+// in the file is reported instead of leaving the host at its old limit.
+func (l HostRateLimit) validate(name string) error {
+	if l.RPS <= 0 {
+		return fmt.Errorf("%w: %s: rps must be positive, got %g", ErrInvalidRateLimit, name, l.RPS)
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("%w: %s: burst must not be negative, got %d", ErrInvalidRateLimit, name, l.Burst)
+	}
+	if l.interval() <= 0 {
+		return fmt.Errorf("%w: %s: rps %g is too high", ErrInvalidRateLimit, name, l.RPS)
+	}
+	return nil
+}
+
+// LoadRateLimitsConfig reads ratelimits.toml from configDir. A missing file is
+// not an error: it returns nil, and the limiter keeps its built-in limits.
+// Keys the file sets that map to no limit (a misspelt "rsp", a host written
+// outside [hosts]) are rejected with ErrInvalidRateLimit rather than ignored.
+func LoadRateLimitsConfig(configDir string) (*RateLimitsConfig, error) {
+	path := filepath.Join(configDir, RateLimitsFileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", RateLimitsFileName, err)
+	}
+
+	var cfg RateLimitsConfig
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RateLimitsFileName, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("%w: %s: unknown keys %s", ErrInvalidRateLimit, RateLimitsFileName, strings.Join(keys, ", "))
+	}
+
+	if cfg.Default != nil {
+		if err := cfg.Default.validate("default"); err != nil {
+			return nil, err
+		}
+	}
+	hosts := make([]string, 0, len(cfg.Hosts))
+	for host := range cfg.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		if host == "" {
+			return nil, fmt.Errorf("%w: empty host name", ErrInvalidRateLimit)
+		}
+		if err := cfg.Hosts[host].validate(host); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+// ApplyRateLimits installs cfg on the limiter: Default replaces the fallback
+// interval and each host entry replaces that host's policy, built-in tuning
+// included, so the file always has the last word. A nil cfg is a no-op.
+//
+// Only limiters created afterwards see the change; a host already tracked
+// keeps its limiter until Reset. NewChecker and NewAnalyzer apply the file
+// before any request, so in practice every host honours it.
+func (r *RateLimiter) ApplyRateLimits(cfg *RateLimitsConfig) {
+	if cfg == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cfg.Default != nil {
+		r.httpInterval, r.httpBurst = cfg.Default.interval(), cfg.Default.burst()
+	}
+	for host, limit := range cfg.Hosts {
+		r.hostPolicies[host] = hostPolicy{interval: limit.interval(), burst: limit.burst()}
+	}
+}
+
+// loadRateLimits applies configDir's ratelimits.toml to limiter.
+func loadRateLimits(configDir string, limiter *RateLimiter) error {
+	cfg, err := LoadRateLimitsConfig(configDir)
+	if err != nil {
+		return err
+	}
+	limiter.ApplyRateLimits(cfg)
+	return nil
+}
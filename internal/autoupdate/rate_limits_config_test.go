@@ -0,0 +1,149 @@
+package autoupdate
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/time/rate"
+)
+
+// writeRateLimits writes content as configDir's ratelimits.toml.
+func writeRateLimits(t *testing.T, configDir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(configDir, RateLimitsFileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+// TestLoadRateLimitsConfig_RoundTrip verifies a config encoded as TOML loads
+// back unchanged, and that a missing file loads as nil.
+func TestLoadRateLimitsConfig_RoundTrip(t *testing.T) {
+	configDir := t.TempDir()
+	if cfg, err := LoadRateLimitsConfig(configDir); cfg != nil || err != nil {
+		t.Fatalf("LoadRateLimitsConfig(missing) = %+v, %v; want nil, nil", cfg, err)
+	}
+
+	want := &RateLimitsConfig{
+		Default: &HostRateLimit{RPS: 0.2, Burst: 1},
+		Hosts: map[string]HostRateLimit{
+			"api.github.com": {RPS: 0.5, Burst: 2},
+			"localhost:8080": {RPS: 100},
+		},
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(want); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	writeRateLimits(t, configDir, buf.String())
+
+	got, err := LoadRateLimitsConfig(configDir)
+	if err != nil {
+		t.Fatalf("LoadRateLimitsConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadRateLimitsConfig() = %+v, want %+v", got, want)
+	}
+}
+
+// TestLoadRateLimitsConfig_Invalid verifies limits the limiter would ignore,
+// and misspelt keys, are reported instead.
+func TestLoadRateLimitsConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"zero rps":        "[hosts.\"example.com\"]\nrps = 0\n",
+		"negative burst":  "[default]\nrps = 1\nburst = -1\n",
+		"misspelt key":    "[hosts.\"example.com\"]\nrsp = 1\n",
+		"host outside":    "[\"example.com\"]\nrps = 1\n",
+		"empty host name": "[hosts.\"\"]\nrps = 1\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configDir := t.TempDir()
+			writeRateLimits(t, configDir, content)
+			if _, err := LoadRateLimitsConfig(configDir); !errors.Is(err, ErrInvalidRateLimit) {
+				t.Errorf("LoadRateLimitsConfig() error = %v, want %v", err, ErrInvalidRateLimit)
+			}
+		})
+	}
+
+	configDir := t.TempDir()
+	writeRateLimits(t, configDir, "[default\n")
+	if _, err := LoadRateLimitsConfig(configDir); err == nil {
+		t.Error("LoadRateLimitsConfig(malformed) succeeded, want a parse error")
+	}
+}
+
+// TestApplyRateLimits verifies listed hosts take their configured limit, over
+// the built-in tuning too, and unknown hosts fall back to the default entry.
+func TestApplyRateLimits(t *testing.T) {
+	limiter := NewRateLimiter(WithTunedHostPolicies())
+	limiter.ApplyRateLimits(&RateLimitsConfig{
+		Default: &HostRateLimit{RPS: 0.5},
+		Hosts:   map[string]HostRateLimit{"api.github.com": {RPS: 0.25, Burst: 3}},
+	})
+
+	if got := limiter.HTTPLimit("api.github.com"); got != rate.Limit(0.25) {
+		t.Errorf("HTTPLimit(api.github.com) = %v, want 0.25", got)
+	}
+	if got := limiter.Limits()["api.github.com"].Burst; got != 3 {
+		t.Errorf("api.github.com burst = %d, want 3", got)
+	}
+	if got := limiter.HTTPLimit("unknown.example"); got != rate.Limit(0.5) {
+		t.Errorf("HTTPLimit(unknown.example) = %v, want the 0.5 default", got)
+	}
+	if got := limiter.HTTPLimit("gitlab.com"); got != rate.Every(300*time.Millisecond) {
+		t.Errorf("HTTPLimit(gitlab.com) = %v, want its built-in tuning untouched", got)
+	}
+
+	plain := NewRateLimiter()
+	plain.ApplyRateLimits(nil)
+	if got := plain.HTTPLimit("unknown.example"); got != rate.Every(DefaultHTTPInterval) {
+		t.Errorf("HTTPLimit() after a nil config = %v, want the built-in default", got)
+	}
+}
+
+// TestRateLimitsFile_AppliedAtConstruction verifies NewChecker and NewAnalyzer
+// apply the configuration directory's ratelimits.toml to their limiter, and
+// refuse an invalid one.
+func TestRateLimitsFile_AppliedAtConstruction(t *testing.T) {
+	overlayDir := t.TempDir()
+	configDir := t.TempDir()
+	writeRateLimits(t, configDir, "[default]\nrps = 2\n\n[hosts.\"api.github.com\"]\nrps = 0.1\n")
+
+	checkerLimiter := NewRateLimiter()
+	if _, err := NewChecker(overlayDir,
+		WithConfigDir(configDir),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+		WithRateLimiter(checkerLimiter),
+	); err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	analyzerLimiter := NewRateLimiter()
+	if _, err := NewAnalyzer(overlayDir, WithAnalyzerConfigDir(configDir), WithAnalyzerRateLimiter(analyzerLimiter)); err != nil {
+		t.Fatalf("NewAnalyzer() error = %v", err)
+	}
+	for name, limiter := range map[string]*RateLimiter{"checker": checkerLimiter, "analyzer": analyzerLimiter} {
+		if got := limiter.HTTPLimit("api.github.com"); got != rate.Limit(0.1) {
+			t.Errorf("%s: HTTPLimit(api.github.com) = %v, want 0.1", name, got)
+		}
+		if got := limiter.HTTPLimit("example.com"); got != rate.Limit(2) {
+			t.Errorf("%s: HTTPLimit(example.com) = %v, want the default 2", name, got)
+		}
+	}
+
+	writeRateLimits(t, configDir, "[hosts.\"api.github.com\"]\nrps = -1\n")
+	if _, err := NewChecker(overlayDir,
+		WithConfigDir(configDir),
+		WithPackagesConfig(&PackagesConfig{Packages: map[string]PackageConfig{}}),
+	); !errors.Is(err, ErrInvalidRateLimit) {
+		t.Errorf("NewChecker(invalid file) error = %v, want %v", err, ErrInvalidRateLimit)
+	}
+	if _, err := NewAnalyzer(overlayDir, WithAnalyzerConfigDir(configDir)); !errors.Is(err, ErrInvalidRateLimit) {
+		t.Errorf("NewAnalyzer(invalid file) error = %v, want %v", err, ErrInvalidRateLimit)
+	}
+}
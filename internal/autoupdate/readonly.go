@@ -0,0 +1,38 @@
+// Package autoupdate provides the read-only mode of the Checker and Analyzer.
+package autoupdate
+
+import "github.com/obentoo/bentoolkit/internal/common/logger"
+
+// WithReadOnly turns the Checker into a dry run: upstream sources are still
+// fetched and every CheckResult is reported as usual, but nothing is written.
+// The version cache, the pending list and the quarantine records are updated
+// in memory only, so a run stays self-consistent (a package checked twice is
+// answered from the in-memory cache), while the files behind them are never
+// saved. The history log is not appended to, and orphaned or revived
+// packages are reported instead of being flipped in packages.toml.
+//
+// A Cache or PendingList passed with WithCache or WithPendingList is switched
+// to read-only as well, for the Checker's lifetime and any other holder.
+func WithReadOnly(readOnly bool) CheckerOption {
+	return func(c *Checker) error {
+		c.readOnly = readOnly
+		return nil
+	}
+}
+
+// WithAnalyzerReadOnly is WithReadOnly for the Analyzer: schemas are still
+// suggested and validated against the live source, but SaveSchema,
+// LoadAndMergeSchema and AcceptSchema leave packages.toml untouched and the
+// analysis cache is not saved.
+func WithAnalyzerReadOnly(readOnly bool) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.readOnly = readOnly
+		return nil
+	}
+}
+
+// logReadOnly reports a write skipped under read-only mode, phrased as what
+// would have happened.
+func logReadOnly(format string, args ...interface{}) {
+	logger.Info("read-only: would "+format, args...)
+}
@@ -0,0 +1,131 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readFileOrEmpty returns the content of path, or "" when it does not exist.
+func readFileOrEmpty(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", path, err)
+	}
+	return string(data)
+}
+
+// TestCheckAll_ReadOnlyWritesNothing verifies a read-only CheckAll still
+// detects the update but leaves the cache, the pending list, the history log
+// and packages.toml unchanged on disk.
+func TestCheckAll_ReadOnlyWritesNothing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version": "2.0.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, configPath := writePackagesTOML(t, `["app-misc/foo"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "version"
+
+["app-misc/gone"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "version"
+`)
+	createTestEbuild(t, overlay, "app-misc/foo", "1.0.0")
+
+	// Seed the stores so "unchanged" is checked against real files.
+	configDir := t.TempDir()
+	cache, err := NewCache(configDir)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if err := cache.Set("app-misc/other", "3.0.0", "https://example.com"); err != nil {
+		t.Fatalf("cache.Set: %v", err)
+	}
+	pending, err := NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList: %v", err)
+	}
+	if err := pending.Add(PendingUpdate{Package: "app-misc/other", CurrentVersion: "2.0.0", NewVersion: "3.0.0"}); err != nil {
+		t.Fatalf("pending.Add: %v", err)
+	}
+
+	files := []string{
+		filepath.Join(configDir, "cache.json"),
+		filepath.Join(configDir, "pending.json"),
+		filepath.Join(configDir, historyFileName),
+		configPath,
+	}
+	before := make(map[string]string, len(files))
+	for _, f := range files {
+		before[f] = readFileOrEmpty(t, f)
+	}
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(configDir),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithHistoryLog(true),
+		WithReadOnly(true),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	batch := checker.CheckAll(true)
+
+	var found bool
+	for _, r := range batch.Items {
+		if r.Package == "app-misc/foo" {
+			found = true
+			if !r.HasUpdate || r.UpstreamVersion != "2.0.0" {
+				t.Errorf("CheckAll result = %+v, want update to 2.0.0", r)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("CheckAll did not report app-misc/foo: %+v (failures %v)", batch.Items, batch.Failures)
+	}
+	if !checker.pending.Has("app-misc/foo") {
+		t.Error("update not recorded in the in-memory pending list")
+	}
+
+	for _, f := range files {
+		if after := readFileOrEmpty(t, f); after != before[f] {
+			t.Errorf("%s changed in read-only mode:\nbefore: %s\nafter:  %s", filepath.Base(f), before[f], after)
+		}
+	}
+}
+
+// TestSaveSchema_ReadOnly verifies a read-only Analyzer does not write
+// packages.toml.
+func TestSaveSchema_ReadOnly(t *testing.T) {
+	overlay, configPath := writePackagesTOML(t, `["app-misc/other"]
+url = "https://example.com/other"
+parser = "json"
+path = "version"
+`)
+	before := readFileOrEmpty(t, configPath)
+
+	analyzer, err := NewAnalyzer(overlay,
+		WithAnalyzerConfigDir(t.TempDir()),
+		WithAnalyzerReadOnly(true),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+	schema := &PackageConfig{URL: "https://example.com/foo", Parser: "json", Path: "version"}
+	if err := analyzer.SaveSchema("app-misc/foo", schema); err != nil {
+		t.Fatalf("SaveSchema: %v", err)
+	}
+	if after := readFileOrEmpty(t, configPath); after != before {
+		t.Errorf("packages.toml changed in read-only mode:\n%s", after)
+	}
+}
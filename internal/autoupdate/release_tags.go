@@ -0,0 +1,78 @@
+// Package autoupdate provides release-versus-tag reconciliation for GitHub
+// releases URLs.
+package autoupdate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// githubReleasesPathRe matches the path of a GitHub REST releases endpoint and
+// captures the repository prefix. It ignores the host so GitHub Enterprise
+// ("/api/v3/repos/...") is covered too.
+var githubReleasesPathRe = regexp.MustCompile(`^(.*/repos/[^/]+/[^/]+)/releases(?:/latest)?/?$`)
+
+// releaseTagsURL returns the tags endpoint of the repository behind a GitHub
+// releases URL, or false when rawURL is not one. The page size is raised to
+// the API maximum so a burst of recent tags does not hide the newest one.
+func releaseTagsURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	m := githubReleasesPathRe.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", false
+	}
+	u.Path = m[1] + "/tags"
+	u.RawQuery = "per_page=100"
+	return u.String(), true
+}
+
+// reconcileReleaseTags compares a version obtained from a GitHub releases URL
+// against the repository's plain tags, unless the package sets
+// prefer_releases = false. Releases are usually the curated truth, but a quick
+// fix is occasionally tagged without a release; this surfaces it.
+//
+// The release version is kept either way: when a tag is newer, result is only
+// flagged NeedsReview with a note naming the tag. The cross-check is
+// best-effort — a failed tags fetch is logged at debug level and never fails
+// the check.
+func (c *Checker) reconcileReleaseTags(cfg *PackageConfig, release string, result *CheckResult) {
+	if cfg.Parser == "script" || !cfg.PrefersReleases() {
+		return
+	}
+	tagsURL, ok := releaseTagsURL(cfg.URL)
+	if !ok {
+		return
+	}
+	rel := stripVersionPrefix(strings.TrimSpace(release))
+	if !ebuild.IsValidVersion(rel) {
+		return
+	}
+
+	content, err := c.fetchContent(tagsURL, cfg.Headers, c.operationTimeout(cfg))
+	if err != nil {
+		logger.Debug("release tags check for %s skipped: %v", result.Package, err)
+		return
+	}
+	extractor := &JSONVersionHistoryExtractor{VersionsPath: "[*].name", Limit: -1}
+	cands, err := extractor.ExtractVersions(content)
+	if err != nil {
+		logger.Debug("release tags check for %s skipped: %v", result.Package, err)
+		return
+	}
+
+	tag := selectVersion(cands, cfg.Transform, "max", cfg.constraint())
+	if tag == "" || ebuild.CompareVersions(tag, rel) <= 0 {
+		return
+	}
+
+	result.NeedsReview = true
+	result.ReviewNote = fmt.Sprintf("tag %s is newer than the latest release %s but has no release", tag, rel)
+}
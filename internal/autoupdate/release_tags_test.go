@@ -0,0 +1,169 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// releasesAndTagsServer serves a GitHub-shaped repository: the latest release
+// at /repos/acme/tool/releases/latest and the plain tags at /repos/acme/tool/tags.
+// The returned counter counts the tags requests.
+func releasesAndTagsServer(t *testing.T, release string, tags ...string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var tagRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/acme/tool/releases/latest":
+			_, _ = w.Write([]byte(`{"tag_name":"` + release + `"}`))
+		case "/repos/acme/tool/tags":
+			tagRequests.Add(1)
+			names := make([]string, len(tags))
+			for i, tag := range tags {
+				names[i] = `{"name":"` + tag + `"}`
+			}
+			_, _ = w.Write([]byte("[" + strings.Join(names, ",") + "]"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &tagRequests
+}
+
+// newReleaseChecker returns a checker for app-misc/tool at 1.0.0 against
+// srv's releases endpoint, with the given extra TOML lines.
+func newReleaseChecker(t *testing.T, srv *httptest.Server, extra string) *Checker {
+	t.Helper()
+	pkg := "app-misc/tool"
+	content := `["app-misc/tool"]
+url = "` + srv.URL + `/repos/acme/tool/releases/latest"
+parser = "json"
+path = "tag_name"
+` + extra
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, pkg, "1.0.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	return checker
+}
+
+// checkReleasePackage runs a single check of app-misc/tool at 1.0.0 against
+// srv's releases endpoint, with the given extra TOML lines.
+func checkReleasePackage(t *testing.T, srv *httptest.Server, extra string) *CheckResult {
+	t.Helper()
+	result, err := newReleaseChecker(t, srv, extra).CheckPackage("app-misc/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	return result
+}
+
+// TestPreferReleasesFlagsNewerUnreleasedTag covers a repository whose newest
+// tag has no release, with prefer_releases omitted: the release is still
+// used, and the tag is surfaced for review, by the check and by the cache hit
+// that follows it.
+func TestPreferReleasesFlagsNewerUnreleasedTag(t *testing.T) {
+	srv, tagRequests := releasesAndTagsServer(t, "v1.2.0", "v1.2.1", "v1.2.0", "v1.1.0", "nightly")
+
+	checker := newReleaseChecker(t, srv, "")
+	result, err := checker.CheckPackage("app-misc/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+
+	if result.UpstreamVersion != "v1.2.0" {
+		t.Errorf("UpstreamVersion = %q, want the release v1.2.0", result.UpstreamVersion)
+	}
+	if !result.NeedsReview {
+		t.Fatal("NeedsReview = false, want true for an unreleased newer tag")
+	}
+	if !strings.Contains(result.ReviewNote, "1.2.1") || !strings.Contains(result.ReviewNote, "1.2.0") {
+		t.Errorf("ReviewNote = %q, want it to name tag 1.2.1 and release 1.2.0", result.ReviewNote)
+	}
+
+	cached, err := checker.CheckPackage("app-misc/tool", false)
+	if err != nil {
+		t.Fatalf("CheckPackage() from cache error = %v", err)
+	}
+	if !cached.FromCache || !cached.NeedsReview || cached.ReviewNote != result.ReviewNote {
+		t.Errorf("cache hit: FromCache=%v NeedsReview=%v ReviewNote=%q, want the review kept", cached.FromCache, cached.NeedsReview, cached.ReviewNote)
+	}
+	if n := tagRequests.Load(); n != 1 {
+		t.Errorf("tags fetched %d times, want 1", n)
+	}
+}
+
+// TestPreferReleasesTrueFlagsNewerTag verifies an explicit prefer_releases =
+// true behaves like the omitted field.
+func TestPreferReleasesTrueFlagsNewerTag(t *testing.T) {
+	srv, _ := releasesAndTagsServer(t, "v1.2.0", "v1.2.1", "v1.2.0")
+
+	result := checkReleasePackage(t, srv, "prefer_releases = true\n")
+
+	if result.UpstreamVersion != "v1.2.0" || !result.NeedsReview {
+		t.Errorf("UpstreamVersion = %q, NeedsReview = %v; want the release, flagged", result.UpstreamVersion, result.NeedsReview)
+	}
+}
+
+// TestPreferReleasesKeepsReleaseWhenTagsNotNewer verifies release preference
+// picks the release, unflagged, when no tag is newer than it.
+func TestPreferReleasesKeepsReleaseWhenTagsNotNewer(t *testing.T) {
+	srv, _ := releasesAndTagsServer(t, "v1.2.0", "v1.2.0", "v1.1.0", "v1.0.0")
+
+	result := checkReleasePackage(t, srv, "prefer_releases = true\n")
+
+	if result.UpstreamVersion != "v1.2.0" {
+		t.Errorf("UpstreamVersion = %q, want v1.2.0", result.UpstreamVersion)
+	}
+	if result.NeedsReview || result.ReviewNote != "" {
+		t.Errorf("NeedsReview = %v (%q), want no review", result.NeedsReview, result.ReviewNote)
+	}
+	if !result.HasUpdate {
+		t.Error("HasUpdate = false, want the release to be an update over 1.0.0")
+	}
+}
+
+// TestPreferReleasesFalseSkipsTags verifies prefer_releases = false neither
+// fetches the tags nor flags a newer one.
+func TestPreferReleasesFalseSkipsTags(t *testing.T) {
+	srv, tagRequests := releasesAndTagsServer(t, "v1.2.0", "v1.2.1", "v1.2.0")
+
+	result := checkReleasePackage(t, srv, "prefer_releases = false\n")
+
+	if result.UpstreamVersion != "v1.2.0" || result.NeedsReview {
+		t.Errorf("UpstreamVersion = %q, NeedsReview = %v; want the release, unflagged", result.UpstreamVersion, result.NeedsReview)
+	}
+	if n := tagRequests.Load(); n != 0 {
+		t.Errorf("tags fetched %d times with prefer_releases = false, want 0", n)
+	}
+}
+
+// TestReleaseTagsURL covers which URLs get the tags cross-check.
+func TestReleaseTagsURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.github.com/repos/acme/tool/releases/latest", "https://api.github.com/repos/acme/tool/tags?per_page=100"},
+		{"https://api.github.com/repos/acme/tool/releases?per_page=5", "https://api.github.com/repos/acme/tool/tags?per_page=100"},
+		{"https://ghe.example.com/api/v3/repos/acme/tool/releases", "https://ghe.example.com/api/v3/repos/acme/tool/tags?per_page=100"},
+		{"https://api.github.com/repos/acme/tool/tags", ""},
+		{"https://pypi.org/pypi/tool/json", ""},
+	}
+	for _, tt := range tests {
+		got, ok := releaseTagsURL(tt.url)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("releaseTagsURL(%q) = %q, %v; want %q", tt.url, got, ok, tt.want)
+		}
+	}
+}
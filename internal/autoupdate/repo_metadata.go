@@ -0,0 +1,283 @@
+// Package autoupdate provides Debian and RPM repository metadata parsing for
+// ebuild autoupdate.
+package autoupdate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// Repository metadata errors
+var (
+	// ErrInvalidRepoMetadata is returned when the content is not a Debian
+	// Packages index, an RPM repomd.xml or an RPM primary.xml
+	ErrInvalidRepoMetadata = errors.New("invalid repository metadata")
+	// ErrRepoPackageNotFound is returned when the repository does not list
+	// the package
+	ErrRepoPackageNotFound = errors.New("package not found in repository metadata")
+)
+
+// maybeGunzip returns content decompressed when it starts with the gzip
+// magic, and unchanged otherwise. Packages.gz and primary.xml.gz are served
+// as application/gzip, so the HTTP client does not decode them; sniffing
+// lets url point at either the compressed or the plain file.
+func maybeGunzip(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, gzipMagic) {
+		return content, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRepoMetadata, err)
+	}
+	defer zr.Close()
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRepoMetadata, err)
+	}
+	return plain, nil
+}
+
+// DebPackagesParser extracts the newest version of a binary package from a
+// Debian repository Packages index (dists/<suite>/<component>/binary-<arch>/
+// Packages, plain or .gz).
+//
+// The index is a sequence of control paragraphs; a package may appear
+// several times (one per version kept in the pool). Each Version is reduced
+// to its upstream part: the epoch ("1:") and the Debian revision ("-1ubuntu2")
+// are dropped, as is a repack suffix ("+dfsg"). Versions that are still not
+// valid Gentoo versions, notably pre-releases ("2.0~rc1"), are skipped. The
+// highest remaining one per ebuild.CompareVersions is returned, in that
+// reduced form.
+type DebPackagesParser struct {
+	// Package is the binary package name, the Package field
+	Package string
+}
+
+// Parse returns the highest upstream version of p.Package in the index.
+func (p *DebPackagesParser) Parse(content []byte) (string, error) {
+	content, err := maybeGunzip(content)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		best    string
+		found   bool
+		name    string
+		version string
+	)
+	consider := func() {
+		if name != p.Package {
+			return
+		}
+		found = true
+		upstream := debianUpstreamVersion(version)
+		if !ebuild.IsValidVersion(upstream) {
+			return
+		}
+		if best == "" || ebuild.CompareVersions(upstream, best) > 0 {
+			best = upstream
+		}
+	}
+
+	paragraphs := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			if name != "" {
+				paragraphs++
+				consider()
+			}
+			name, version = "", ""
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue // continuation of a multi-line field (Description)
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(field) {
+		case "package":
+			name = strings.TrimSpace(value)
+		case "version":
+			version = strings.TrimSpace(value)
+		}
+	}
+	if name != "" {
+		paragraphs++
+		consider()
+	}
+
+	switch {
+	case paragraphs == 0:
+		return "", fmt.Errorf("%w: no Package paragraphs", ErrInvalidRepoMetadata)
+	case !found:
+		return "", fmt.Errorf("%w: %s", ErrRepoPackageNotFound, p.Package)
+	case best == "":
+		return "", fmt.Errorf("%w: no stable version of %s", ErrNoVersionFound, p.Package)
+	}
+	return best, nil
+}
+
+// debianUpstreamVersion strips the epoch, the Debian revision and a repack
+// suffix from a Debian version: "1:2.4.1+dfsg-3" becomes "2.4.1".
+func debianUpstreamVersion(v string) string {
+	if epoch, rest, ok := strings.Cut(v, ":"); ok && epoch != "" && strings.Trim(epoch, "0123456789") == "" {
+		v = rest
+	}
+	if i := strings.LastIndexByte(v, '-'); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// rpmPackage is the subset of a primary.xml <package> element read here.
+type rpmPackage struct {
+	Type    string `xml:"type,attr"`
+	Name    string `xml:"name"`
+	Version struct {
+		Ver string `xml:"ver,attr"`
+	} `xml:"version"`
+}
+
+// RPMPrimaryParser extracts the newest version of a package from an RPM
+// repository's primary.xml (plain or .gz), the file repomd.xml points to.
+// The checker resolves that indirection itself (see rpmPrimaryURL), so url
+// in packages.toml is the repository base or its repodata/repomd.xml.
+//
+// Every architecture and every version kept in the repository is listed;
+// the highest ver per ebuild.CompareVersions wins. The release and epoch are
+// distribution bookkeeping and are ignored, and vers that are not valid
+// Gentoo versions ("1.0~rc1", "1.0^git…") are skipped.
+type RPMPrimaryParser struct {
+	// Package is the RPM package name, the <name> element
+	Package string
+}
+
+// Parse returns the highest version of p.Package in primary.xml. The
+// document is streamed, since a distribution's primary.xml runs to tens of
+// megabytes once decompressed.
+func (p *RPMPrimaryParser) Parse(content []byte) (string, error) {
+	content, err := maybeGunzip(content)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		best     string
+		found    bool
+		packages int
+	)
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRepoMetadata, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+		var pkg rpmPackage
+		if err := dec.DecodeElement(&pkg, &start); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRepoMetadata, err)
+		}
+		packages++
+		if pkg.Name != p.Package {
+			continue
+		}
+		found = true
+		ver := strings.TrimSpace(pkg.Version.Ver)
+		if !ebuild.IsValidVersion(ver) {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(ver, best) > 0 {
+			best = ver
+		}
+	}
+
+	switch {
+	case packages == 0:
+		return "", fmt.Errorf("%w: no <package> elements", ErrInvalidRepoMetadata)
+	case !found:
+		return "", fmt.Errorf("%w: %s", ErrRepoPackageNotFound, p.Package)
+	case best == "":
+		return "", fmt.Errorf("%w: no stable version of %s", ErrNoVersionFound, p.Package)
+	}
+	return best, nil
+}
+
+// repomd is the subset of repodata/repomd.xml read here.
+type repomd struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// repomdURL returns the repomd.xml URL of an RPM repository. A URL already
+// naming repomd.xml is kept, so both the base URL dnf is given
+// ("https://example.com/el9/x86_64/") and the metadata URL work.
+func repomdURL(repoURL string) string {
+	if strings.HasSuffix(repoURL, "/repomd.xml") {
+		return repoURL
+	}
+	return strings.TrimSuffix(repoURL, "/") + "/repodata/repomd.xml"
+}
+
+// rpmPrimaryLocation resolves the primary metadata URL listed in a
+// repomd.xml fetched from repomdLocation. The href is relative to the
+// repository base, the directory above repodata/, and carries a checksum in
+// its file name, which is why it must be read rather than guessed.
+func rpmPrimaryLocation(content []byte, repomdLocation string) (string, error) {
+	var md repomd
+	if err := xml.Unmarshal(content, &md); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRepoMetadata, err)
+	}
+	for _, d := range md.Data {
+		if d.Type != "primary" || d.Location.Href == "" {
+			continue
+		}
+		base, err := url.Parse(strings.TrimSuffix(repomdLocation, "repodata/repomd.xml"))
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRepoMetadata, err)
+		}
+		href, err := url.Parse(d.Location.Href)
+		if err != nil {
+			return "", fmt.Errorf("%w: primary location %q: %v", ErrInvalidRepoMetadata, d.Location.Href, err)
+		}
+		return base.ResolveReference(href).String(), nil
+	}
+	return "", fmt.Errorf("%w: repomd.xml lists no primary data", ErrInvalidRepoMetadata)
+}
+
+// rpmPrimaryURL fetches the repomd.xml of the repository at repoURL and
+// returns the URL of its primary metadata: the first of the two requests an
+// rpm package costs. It goes through fetchContent, so the repomd request is
+// rate limited and timed like any other.
+func (c *Checker) rpmPrimaryURL(repoURL string, cfg *PackageConfig) (string, error) {
+	mdURL := repomdURL(repoURL)
+	content, err := c.fetchContent(mdURL, cfg.Headers, c.operationTimeout(cfg))
+	if err != nil {
+		return "", err
+	}
+	return rpmPrimaryLocation(content, mdURL)
+}
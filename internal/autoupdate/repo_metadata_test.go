@@ -0,0 +1,221 @@
+package autoupdate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// debPackagesSample is a trimmed Debian Packages index. "foo" is listed
+// out of order with an epoch, Debian revisions, a repack suffix and a
+// pre-release; "foo-doc" is a higher-versioned package with a prefix name.
+const debPackagesSample = `Package: foo
+Version: 1:2.4.1+dfsg-3
+Architecture: amd64
+Description: a tool
+ with a long description
+ Version: 9.9.9
+
+Package: foo-doc
+Version: 3.0.0-1
+Architecture: all
+
+Package: foo
+Version: 1:2.10.0-1ubuntu2
+Architecture: amd64
+
+Package: foo
+Version: 1:3.0~rc1-1
+Architecture: amd64
+
+Package: bar
+Version: 0.1-1
+`
+
+// rpmPrimarySample is a trimmed RPM primary.xml. "foo" has two versions
+// across architectures plus a pre-release; "foo-devel" is higher.
+const rpmPrimarySample = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" xmlns:rpm="http://linux.duke.edu/metadata/rpm" packages="4">
+<package type="rpm">
+  <name>foo</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="1.9.3" rel="1.el9"/>
+  <summary>a tool</summary>
+</package>
+<package type="rpm">
+  <name>foo</name>
+  <arch>aarch64</arch>
+  <version epoch="0" ver="1.10.0" rel="2.el9"/>
+</package>
+<package type="rpm">
+  <name>foo</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="2.0~rc1" rel="1.el9"/>
+</package>
+<package type="rpm">
+  <name>foo-devel</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="5.0.0" rel="1.el9"/>
+</package>
+</metadata>
+`
+
+// rpmRepomdSample is a trimmed repomd.xml pointing at the primary metadata.
+const rpmRepomdSample = `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo">
+  <data type="filelists">
+    <location href="repodata/aaa-filelists.xml.gz"/>
+  </data>
+  <data type="primary">
+    <checksum type="sha256">bbb</checksum>
+    <location href="repodata/bbb-primary.xml.gz"/>
+  </data>
+</repomd>
+`
+
+// gzipBytes returns s gzip-compressed.
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestDebPackagesParser verifies the highest upstream version of the named
+// package is extracted from a plain and a gzipped Packages index.
+func TestDebPackagesParser(t *testing.T) {
+	p := &DebPackagesParser{Package: "foo"}
+	for name, content := range map[string][]byte{
+		"plain": []byte(debPackagesSample),
+		"gzip":  gzipBytes(t, debPackagesSample),
+	} {
+		got, err := p.Parse(content)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", name, err)
+		}
+		if got != "2.10.0" {
+			t.Errorf("%s: Parse() = %q, want 2.10.0", name, got)
+		}
+	}
+
+	if _, err := (&DebPackagesParser{Package: "missing"}).Parse([]byte(debPackagesSample)); !errors.Is(err, ErrRepoPackageNotFound) {
+		t.Errorf("Parse() missing package error = %v, want %v", err, ErrRepoPackageNotFound)
+	}
+	if _, err := p.Parse([]byte("\n\n")); !errors.Is(err, ErrInvalidRepoMetadata) {
+		t.Errorf("Parse() empty index error = %v, want %v", err, ErrInvalidRepoMetadata)
+	}
+}
+
+// TestDebianUpstreamVersion covers reducing Debian versions to upstream.
+func TestDebianUpstreamVersion(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"2.4.1", "2.4.1"},
+		{"2.4.1-3", "2.4.1"},
+		{"1:2.4.1+dfsg-3", "2.4.1"},
+		{"1.0-2-1", "1.0-2"},
+		{"3.0~rc1-1", "3.0~rc1"},
+	}
+	for _, tt := range tests {
+		if got := debianUpstreamVersion(tt.in); got != tt.want {
+			t.Errorf("debianUpstreamVersion(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestRPMPrimaryParser verifies the highest version of the named package is
+// extracted from a plain and a gzipped primary.xml.
+func TestRPMPrimaryParser(t *testing.T) {
+	p := &RPMPrimaryParser{Package: "foo"}
+	for name, content := range map[string][]byte{
+		"plain": []byte(rpmPrimarySample),
+		"gzip":  gzipBytes(t, rpmPrimarySample),
+	} {
+		got, err := p.Parse(content)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", name, err)
+		}
+		if got != "1.10.0" {
+			t.Errorf("%s: Parse() = %q, want 1.10.0", name, got)
+		}
+	}
+
+	if _, err := (&RPMPrimaryParser{Package: "missing"}).Parse([]byte(rpmPrimarySample)); !errors.Is(err, ErrRepoPackageNotFound) {
+		t.Errorf("Parse() missing package error = %v, want %v", err, ErrRepoPackageNotFound)
+	}
+	if _, err := p.Parse([]byte("<metadata></metadata>")); !errors.Is(err, ErrInvalidRepoMetadata) {
+		t.Errorf("Parse() empty metadata error = %v, want %v", err, ErrInvalidRepoMetadata)
+	}
+}
+
+// TestRPMPrimaryLocation covers resolving the primary href against the
+// repository base.
+func TestRPMPrimaryLocation(t *testing.T) {
+	got, err := rpmPrimaryLocation([]byte(rpmRepomdSample), "https://yum.example.com/el9/x86_64/repodata/repomd.xml")
+	if err != nil {
+		t.Fatalf("rpmPrimaryLocation() error = %v", err)
+	}
+	if want := "https://yum.example.com/el9/x86_64/repodata/bbb-primary.xml.gz"; got != want {
+		t.Errorf("rpmPrimaryLocation() = %q, want %q", got, want)
+	}
+	if _, err := rpmPrimaryLocation([]byte("<repomd/>"), "https://yum.example.com/repodata/repomd.xml"); !errors.Is(err, ErrInvalidRepoMetadata) {
+		t.Errorf("rpmPrimaryLocation() without primary error = %v, want %v", err, ErrInvalidRepoMetadata)
+	}
+
+	if got := repomdURL("https://yum.example.com/el9/x86_64/"); got != "https://yum.example.com/el9/x86_64/repodata/repomd.xml" {
+		t.Errorf("repomdURL(base) = %q", got)
+	}
+	if got := repomdURL("https://yum.example.com/repodata/repomd.xml"); got != "https://yum.example.com/repodata/repomd.xml" {
+		t.Errorf("repomdURL(repomd) = %q", got)
+	}
+}
+
+// TestCheckPackageRPMRepository verifies an rpm package configured with the
+// repository base fetches repomd.xml and then the gzipped primary.xml.
+func TestCheckPackageRPMRepository(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/el9/repodata/repomd.xml":
+			_, _ = w.Write([]byte(rpmRepomdSample))
+		case "/el9/repodata/bbb-primary.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			_, _ = w.Write(gzipBytes(t, rpmPrimarySample))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	content := `["app-misc/foo"]
+url = "` + srv.URL + `/el9/"
+parser = "rpm"
+path = "foo"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, "app-misc/foo", "1.9.3")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-misc/foo", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "1.10.0" || !result.HasUpdate {
+		t.Errorf("CheckPackage() = %q (HasUpdate %v), want update to 1.10.0", result.UpstreamVersion, result.HasUpdate)
+	}
+}
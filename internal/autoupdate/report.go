@@ -0,0 +1,211 @@
+// Package autoupdate provides a consolidated CI report of an overlay's update state.
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/overlay"
+)
+
+// Report is the single artifact a CI job publishes about an overlay: which
+// packages are behind upstream, how much of the overlay packages.toml covers,
+// which version sources are unhealthy, and which entries need a maintainer
+// (orphaned or quarantined). See Checker.GenerateReport.
+type Report struct {
+	// GeneratedAt is when the report was produced
+	GeneratedAt time.Time `json:"generated_at"`
+	// Checked is the number of packages whose check succeeded
+	Checked int `json:"checked"`
+	// Behind lists the packages with a newer upstream version, by name
+	Behind []ReportUpdate `json:"behind"`
+	// Coverage measures packages.toml against the overlay's packages
+	Coverage ReportCoverage `json:"coverage"`
+	// Unhealthy lists the packages whose version source failed or whose
+	// SRC_URI host did not answer, by name
+	Unhealthy []ReportIssue `json:"unhealthy"`
+	// Orphaned lists the packages.toml entries with no ebuild in the overlay
+	Orphaned []string `json:"orphaned"`
+	// Quarantined lists the packages skipped after repeated failures
+	Quarantined []ReportIssue `json:"quarantined"`
+}
+
+// ReportUpdate is one package behind upstream.
+type ReportUpdate struct {
+	Package  string `json:"package"`
+	Current  string `json:"current"`
+	Upstream string `json:"upstream"`
+	// Changelog links what changed, when the source offers a link (see
+	// CheckResult.ChangelogURL)
+	Changelog string `json:"changelog,omitempty"`
+}
+
+// ReportIssue is one package needing attention, with the reason.
+type ReportIssue struct {
+	Package string `json:"package"`
+	Reason  string `json:"reason"`
+}
+
+// ReportCoverage counts the overlay packages that have a packages.toml entry.
+type ReportCoverage struct {
+	// Packages is the number of packages with at least one ebuild
+	Packages int `json:"packages"`
+	// Configured is how many of them have a packages.toml entry
+	Configured int `json:"configured"`
+	// Percent is Configured/Packages as a percentage, rounded to one decimal
+	Percent float64 `json:"percent"`
+	// Unconfigured lists the packages without an entry, by name
+	Unconfigured []string `json:"unconfigured"`
+}
+
+// GenerateReport runs CheckAll and combines its outcome with a scan of the
+// overlay into a Report. Results come from the cache where fresh, as for a
+// plain --check. Per-package check failures are part of the report (as
+// unhealthy sources), not errors; an error is returned only when the overlay
+// cannot be scanned or the run was cancelled, since a partial report would
+// understate every count.
+func (c *Checker) GenerateReport() (*Report, error) {
+	batch := c.CheckAll(false)
+	if err := c.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("report cancelled: %w", err)
+	}
+	scan, err := overlay.ScanOverlay(c.overlayPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan overlay: %w", err)
+	}
+
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Behind:      []ReportUpdate{},
+		Unhealthy:   []ReportIssue{},
+		Orphaned:    []string{},
+		Quarantined: []ReportIssue{},
+	}
+
+	for _, r := range batch.Items {
+		if r.Orphaned {
+			continue
+		}
+		report.Checked++
+		if r.HasUpdate {
+			report.Behind = append(report.Behind, ReportUpdate{
+				Package: r.Package, Current: r.CurrentVersion, Upstream: r.UpstreamVersion,
+				Changelog: r.ChangelogURL,
+			})
+		}
+		if r.SourceUnreachable {
+			report.Unhealthy = append(report.Unhealthy, ReportIssue{Package: r.Package, Reason: r.SourceNote})
+		}
+	}
+	for pkg, ferr := range batch.Failures {
+		reason := "check failed"
+		if ferr != nil {
+			reason = ferr.Error()
+		}
+		report.Unhealthy = append(report.Unhealthy, ReportIssue{Package: pkg, Reason: reason})
+	}
+	sort.Slice(report.Unhealthy, func(i, j int) bool {
+		return report.Unhealthy[i].Package < report.Unhealthy[j].Package
+	})
+
+	inOverlay := make(map[string]bool, len(scan.Packages))
+	report.Coverage.Unconfigured = []string{}
+	for _, p := range scan.Packages {
+		name := p.Category + "/" + p.Package
+		inOverlay[name] = true
+		if _, ok := c.config.Packages[name]; ok {
+			report.Coverage.Configured++
+		} else {
+			report.Coverage.Unconfigured = append(report.Coverage.Unconfigured, name)
+		}
+	}
+	report.Coverage.Packages = len(scan.Packages)
+	if report.Coverage.Packages > 0 {
+		pct := float64(report.Coverage.Configured) * 100 / float64(report.Coverage.Packages)
+		report.Coverage.Percent = math.Round(pct*10) / 10
+	}
+
+	for name := range c.config.Packages {
+		if !inOverlay[name] {
+			report.Orphaned = append(report.Orphaned, name)
+		}
+	}
+	sort.Strings(report.Orphaned)
+
+	for _, q := range c.Quarantined() {
+		report.Quarantined = append(report.Quarantined, ReportIssue{Package: q.Package, Reason: q.LastError})
+	}
+
+	return report, nil
+}
+
+// FormatJSON writes the report as indented JSON. Empty lists are written as
+// [] rather than null, so consumers can iterate without a nil check.
+func (r *Report) FormatJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// FormatMarkdown writes the report as a Markdown document suited to a CI job
+// summary: a counts table followed by one section per non-empty list.
+func (r *Report) FormatMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Autoupdate report\n\n")
+	fmt.Fprintf(&b, "Generated %s.\n\n", r.GeneratedAt.Format(time.RFC3339))
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Checked | %d |\n", r.Checked)
+	fmt.Fprintf(&b, "| Behind upstream | %d |\n", len(r.Behind))
+	fmt.Fprintf(&b, "| Coverage | %d/%d (%.1f%%) |\n", r.Coverage.Configured, r.Coverage.Packages, r.Coverage.Percent)
+	fmt.Fprintf(&b, "| Unhealthy sources | %d |\n", len(r.Unhealthy))
+	fmt.Fprintf(&b, "| Orphaned | %d |\n", len(r.Orphaned))
+	fmt.Fprintf(&b, "| Quarantined | %d |\n", len(r.Quarantined))
+
+	if len(r.Behind) > 0 {
+		b.WriteString("\n## Behind upstream\n\n| Package | Current | Upstream | Changes |\n|---|---|---|---|\n")
+		for _, u := range r.Behind {
+			changes := ""
+			if u.Changelog != "" {
+				changes = "[changes](" + u.Changelog + ")"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", u.Package, u.Current, u.Upstream, changes)
+		}
+	}
+	writeMarkdownIssues(&b, "Unhealthy sources", r.Unhealthy)
+	writeMarkdownList(&b, "Orphaned entries", r.Orphaned)
+	writeMarkdownIssues(&b, "Quarantined", r.Quarantined)
+	writeMarkdownList(&b, "Not in packages.toml", r.Coverage.Unconfigured)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownIssues writes a package/reason table under title, if any.
+// Pipes and newlines in a reason would break the table row, so they are
+// escaped and flattened.
+func writeMarkdownIssues(b *strings.Builder, title string, issues []ReportIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n| Package | Reason |\n|---|---|\n", title)
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, i := range issues {
+		fmt.Fprintf(b, "| %s | %s |\n", i.Package, cell.Replace(i.Reason))
+	}
+}
+
+// writeMarkdownList writes a bullet list of packages under title, if any.
+func writeMarkdownList(b *strings.Builder, title string, pkgs []string) {
+	if len(pkgs) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, p := range pkgs {
+		fmt.Fprintf(b, "- %s\n", p)
+	}
+}
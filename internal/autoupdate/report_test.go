@@ -0,0 +1,97 @@
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestGenerateReport builds an overlay with a package behind upstream, one up
+// to date, one whose source fails, an entry without ebuild and an ebuild
+// without entry, and checks every section of the report.
+func TestGenerateReport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"2.0.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	entry := func(pkg, path string) string {
+		return "[\"" + pkg + "\"]\nurl = \"" + srv.URL + path + "\"\nparser = \"json\"\npath = \"version\"\n\n"
+	}
+	overlay, _ := writePackagesTOML(t, entry("app-misc/behind", "/v")+
+		entry("app-misc/current", "/v")+
+		entry("app-misc/broken", "/gone")+
+		entry("app-misc/removed", "/v"))
+	createTestEbuild(t, overlay, "app-misc/behind", "1.0.0")
+	createTestEbuild(t, overlay, "app-misc/current", "2.0.0")
+	createTestEbuild(t, overlay, "app-misc/broken", "1.0.0")
+	createTestEbuild(t, overlay, "app-misc/untracked", "1.0.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	report, err := checker.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+
+	if report.Checked != 2 {
+		t.Errorf("Checked = %d, want 2", report.Checked)
+	}
+	wantBehind := []ReportUpdate{{Package: "app-misc/behind", Current: "1.0.0", Upstream: "2.0.0"}}
+	if !reflect.DeepEqual(report.Behind, wantBehind) {
+		t.Errorf("Behind = %+v, want %+v", report.Behind, wantBehind)
+	}
+	if len(report.Unhealthy) != 1 || report.Unhealthy[0].Package != "app-misc/broken" ||
+		!strings.Contains(report.Unhealthy[0].Reason, "404") {
+		t.Errorf("Unhealthy = %+v, want app-misc/broken with its 404", report.Unhealthy)
+	}
+	wantCoverage := ReportCoverage{Packages: 4, Configured: 3, Percent: 75, Unconfigured: []string{"app-misc/untracked"}}
+	if !reflect.DeepEqual(report.Coverage, wantCoverage) {
+		t.Errorf("Coverage = %+v, want %+v", report.Coverage, wantCoverage)
+	}
+	if !reflect.DeepEqual(report.Orphaned, []string{"app-misc/removed"}) {
+		t.Errorf("Orphaned = %v, want [app-misc/removed]", report.Orphaned)
+	}
+
+	var buf bytes.Buffer
+	if err := report.FormatJSON(&buf); err != nil {
+		t.Fatalf("FormatJSON() error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("FormatJSON output does not decode: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"quarantined": []`) {
+		t.Errorf("empty list should encode as []:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.FormatMarkdown(&buf); err != nil {
+		t.Fatalf("FormatMarkdown() error = %v", err)
+	}
+	for _, want := range []string{
+		"| Behind upstream | 1 |",
+		"| Coverage | 3/4 (75.0%) |",
+		"| app-misc/behind | 1.0.0 | 2.0.0 |",
+		"## Unhealthy sources",
+		"- app-misc/removed",
+		"- app-misc/untracked",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, buf.String())
+		}
+	}
+}
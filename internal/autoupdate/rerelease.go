@@ -0,0 +1,138 @@
+// Package autoupdate provides same-version re-release detection.
+package autoupdate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// DefaultChecksumAlgo is the Manifest hash compared when checksum_algo is unset.
+const DefaultChecksumAlgo = "SHA512"
+
+// revisionSuffixRe matches the Gentoo revision suffix ("-r3") of a version.
+var revisionSuffixRe = regexp.MustCompile(`-r[0-9]+$`)
+
+// detectReRelease flags result when upstream re-released the version already
+// in the overlay, i.e. the version is unchanged but the artifact is not.
+//
+// It only runs for packages with checksum_path, and only when the upstream
+// version equals the current version ignoring the ebuild revision, so a local
+// "-r3" bump still counts as the same release. The upstream checksum is then
+// compared with the DIST entries of the package Manifest whose filename
+// contains the version; when at least one of them records the configured hash
+// and none matches, result is marked ReReleased and NeedsReview.
+//
+// The checksum is read from body, the primary source's document CheckPackage
+// already fetched, so the check costs no request and sees the URL a PreFetch
+// resolved to; a nil body (the fallback or the LLM answered) skips it. Like
+// the release tags cross-check, this is best-effort: a parse or Manifest
+// problem is logged at debug level and never fails the check.
+func (c *Checker) detectReRelease(cfg *PackageConfig, body []byte, upstream, current string, result *CheckResult) {
+	if cfg.ChecksumPath == "" {
+		return
+	}
+	u := stripVersionPrefix(strings.TrimSpace(upstream))
+	base := revisionSuffixRe.ReplaceAllString(stripVersionPrefix(strings.TrimSpace(current)), "")
+	if !ebuild.IsValidVersion(u) || !ebuild.IsValidVersion(base) || ebuild.CompareVersions(u, base) != 0 {
+		return
+	}
+
+	if body == nil {
+		logger.Debug("re-release check for %s skipped: the primary source did not answer", result.Package)
+		return
+	}
+	raw, err := (&JSONParser{Path: cfg.ChecksumPath}).Parse(body)
+	if err != nil {
+		logger.Debug("re-release check for %s skipped: checksum at %q: %v", result.Package, cfg.ChecksumPath, err)
+		return
+	}
+	algo, sum := splitChecksum(raw, cfg.ChecksumAlgo)
+
+	dists, err := readManifestDists(filepath.Join(c.overlayPath, result.Package, "Manifest"))
+	if err != nil {
+		logger.Debug("re-release check for %s skipped: %v", result.Package, err)
+		return
+	}
+
+	compared := ""
+	for _, dist := range dists {
+		if !strings.Contains(dist.name, u) {
+			continue
+		}
+		known, ok := dist.hashes[algo]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(known, sum) {
+			return
+		}
+		compared = dist.name
+	}
+	if compared == "" {
+		logger.Debug("re-release check for %s skipped: no %s hash for %s in Manifest", result.Package, algo, u)
+		return
+	}
+
+	result.ReReleased = true
+	result.NeedsReview = true
+	note := fmt.Sprintf("upstream re-released %s: %s of %s differs from the Manifest", u, algo, compared)
+	if result.ReviewNote != "" {
+		note = result.ReviewNote + "; " + note
+	}
+	result.ReviewNote = note
+}
+
+// splitChecksum separates an optional "<algo>:" prefix from a checksum value,
+// falling back to defaultAlgo (or DefaultChecksumAlgo). The algorithm is
+// upper-cased to match Manifest hash names.
+func splitChecksum(raw, defaultAlgo string) (algo, sum string) {
+	raw = strings.TrimSpace(raw)
+	algo = defaultAlgo
+	if i := strings.IndexByte(raw, ':'); i > 0 {
+		algo, raw = raw[:i], raw[i+1:]
+	}
+	if algo == "" {
+		algo = DefaultChecksumAlgo
+	}
+	return strings.ToUpper(algo), raw
+}
+
+// manifestDist is one "DIST <name> <size> <ALGO> <hash>..." Manifest line.
+type manifestDist struct {
+	name   string
+	hashes map[string]string
+}
+
+// readManifestDists returns the DIST entries of the Manifest at path.
+func readManifestDists(path string) ([]manifestDist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Manifest: %w", err)
+	}
+
+	var dists []manifestDist
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "DIST" {
+			continue
+		}
+		dist := manifestDist{name: fields[1], hashes: make(map[string]string)}
+		for i := 3; i+1 < len(fields); i += 2 {
+			dist.hashes[strings.ToUpper(fields[i])] = fields[i+1]
+		}
+		dists = append(dists, dist)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Manifest: %w", err)
+	}
+	return dists, nil
+}
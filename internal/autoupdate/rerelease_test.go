@@ -0,0 +1,131 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// reReleaseManifest is the Manifest of app-misc/tool with the 1.2.0 tarball.
+const reReleaseManifest = "DIST tool-1.2.0.tar.gz 1024 BLAKE2B 1111 SHA512 aaaa\n" +
+	"DIST tool-extras-1.1.0.tar.gz 512 BLAKE2B 2222 SHA512 bbbb\n"
+
+// checkReRelease checks app-misc/tool at 1.2.0-r3 against an upstream
+// reporting 1.2.0 with the given checksum value.
+func checkReRelease(t *testing.T, checksum, extra string) *CheckResult {
+	t.Helper()
+	checker, _ := newReReleaseChecker(t, checksum, extra)
+	result, err := checker.CheckPackage("app-misc/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	return result
+}
+
+// newReReleaseChecker returns a Checker for app-misc/tool at 1.2.0-r3 against
+// an upstream reporting 1.2.0 with the given checksum value, and the count of
+// requests the upstream served.
+func newReReleaseChecker(t *testing.T, checksum, extra string) (*Checker, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":"1.2.0","digest":"` + checksum + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	pkg := "app-misc/tool"
+	content := `["app-misc/tool"]
+url = "` + srv.URL + `"
+parser = "json"
+path = "version"
+checksum_path = "digest"
+` + extra
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuild(t, overlay, pkg, "1.2.0-r3")
+	manifest := filepath.Join(overlay, "app-misc", "tool", "Manifest")
+	if err := os.WriteFile(manifest, []byte(reReleaseManifest), 0644); err != nil {
+		t.Fatalf("failed to write Manifest: %v", err)
+	}
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	return checker, &requests
+}
+
+// TestCheckPackageFlagsSameVersionReRelease verifies that a matching version
+// whose upstream checksum differs from the Manifest is flagged as re-released
+// without being reported as an update.
+func TestCheckPackageFlagsSameVersionReRelease(t *testing.T) {
+	result := checkReRelease(t, "cccc", "")
+
+	if result.HasUpdate {
+		t.Error("HasUpdate = true, want false for the same version")
+	}
+	if !result.ReReleased || !result.NeedsReview {
+		t.Fatalf("ReReleased = %v, NeedsReview = %v, want both true", result.ReReleased, result.NeedsReview)
+	}
+	if !strings.Contains(result.ReviewNote, "re-released 1.2.0") || !strings.Contains(result.ReviewNote, "tool-1.2.0.tar.gz") {
+		t.Errorf("ReviewNote = %q, want it to name the version and distfile", result.ReviewNote)
+	}
+}
+
+// TestCheckPackageReReleaseNotFlagged covers checksums that match the
+// Manifest, including via an "<algo>:" prefix selecting another hash.
+func TestCheckPackageReReleaseNotFlagged(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum string
+		extra    string
+	}{
+		{"default SHA512 matches", "AAAA", ""},
+		{"checksum_algo selects BLAKE2B", "1111", "checksum_algo = \"blake2b\"\n"},
+		{"prefix selects BLAKE2B", "blake2b:1111", ""},
+		{"hash absent from Manifest", "sha256:dddd", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkReRelease(t, tt.checksum, tt.extra)
+			if result.ReReleased || result.NeedsReview {
+				t.Errorf("ReReleased = %v, NeedsReview = %v (%q), want no flag",
+					result.ReReleased, result.NeedsReview, result.ReviewNote)
+			}
+		})
+	}
+}
+
+// TestCheckPackageReReleaseReusesBody verifies the re-release check reads the
+// checksum from the document the check already fetched, and that its review
+// flag is cached so a later cache hit still reports it.
+func TestCheckPackageReReleaseReusesBody(t *testing.T) {
+	checker, requests := newReReleaseChecker(t, "cccc", "")
+
+	if _, err := checker.CheckPackage("app-misc/tool", true); err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("upstream served %d requests, want 1", n)
+	}
+
+	cached, err := checker.CheckPackage("app-misc/tool", false)
+	if err != nil {
+		t.Fatalf("CheckPackage(cached) error = %v", err)
+	}
+	if !cached.FromCache || !cached.NeedsReview || !strings.Contains(cached.ReviewNote, "re-released 1.2.0") {
+		t.Errorf("cache hit = FromCache %v, NeedsReview %v (%q); want the re-release flagged",
+			cached.FromCache, cached.NeedsReview, cached.ReviewNote)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("upstream served %d requests after the cache hit, want 1", n)
+	}
+}
@@ -0,0 +1,66 @@
+// Package autoupdate provides a stable JSON form of check results for
+// scripts and CI.
+package autoupdate
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// checkResultJSON is the serialized form of a CheckResult. Its field names
+// are the contract scripts rely on, kept apart from the Go field names so a
+// rename in CheckResult does not break them.
+type checkResultJSON struct {
+	Package       string `json:"package"`
+	Current       string `json:"current"`
+	Upstream      string `json:"upstream"`
+	HasUpdate     bool   `json:"has_update"`
+	FromCache     bool   `json:"from_cache"`
+	NotComparable bool   `json:"not_comparable,omitempty"`
+	Orphaned      bool   `json:"orphaned,omitempty"`
+	NeedsReview   bool   `json:"needs_review,omitempty"`
+	ReviewNote    string `json:"review_note,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the result with stable snake_case field names. Error
+// is encoded as its message, and left out when nil: an error value would
+// otherwise encode as an empty object.
+func (r CheckResult) MarshalJSON() ([]byte, error) {
+	out := checkResultJSON{
+		Package:       r.Package,
+		Current:       r.CurrentVersion,
+		Upstream:      r.UpstreamVersion,
+		HasUpdate:     r.HasUpdate,
+		FromCache:     r.FromCache,
+		NotComparable: r.NotComparable,
+		Orphaned:      r.Orphaned,
+		NeedsReview:   r.NeedsReview,
+		ReviewNote:    r.ReviewNote,
+	}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
+// WriteResultsJSON writes a batch of check results to w as one indented JSON
+// array: the checked packages in order, then one entry per failure, sorted by
+// package, carrying only the package and the error. CI can then gate on
+// `jq 'any(.has_update)'` or on any entry having an error.
+func WriteResultsJSON(w io.Writer, result BatchResult[CheckResult]) error {
+	results := append([]CheckResult{}, result.Items...)
+	failed := make([]string, 0, len(result.Failures))
+	for pkg := range result.Failures {
+		failed = append(failed, pkg)
+	}
+	sort.Strings(failed)
+	for _, pkg := range failed {
+		results = append(results, CheckResult{Package: pkg, Error: result.Failures[pkg]})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
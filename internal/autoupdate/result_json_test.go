@@ -0,0 +1,71 @@
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestCheckResultMarshalJSON verifies the stable field names, that an error
+// encodes as its message, and that a nil error is left out.
+func TestCheckResultMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(CheckResult{
+		Package:         "app-misc/foo",
+		CurrentVersion:  "1.0",
+		UpstreamVersion: "2.0",
+		HasUpdate:       true,
+		FromCache:       true,
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"package":"app-misc/foo","current":"1.0","upstream":"2.0","has_update":true,"from_cache":true}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	data, err = json.Marshal(CheckResult{Package: "app-misc/bar", Error: errors.New("boom")})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"error":"boom"`) {
+		t.Errorf("Marshal() = %s, want the error as a string", data)
+	}
+}
+
+// TestWriteResultsJSON verifies the items come first in order, then the
+// failures sorted by package.
+func TestWriteResultsJSON(t *testing.T) {
+	batch := BatchResult[CheckResult]{
+		Items: []CheckResult{{Package: "app-misc/b", CurrentVersion: "1.0", UpstreamVersion: "1.0"}},
+		Failures: map[string]error{
+			"dev-util/z": errors.New("timeout"),
+			"dev-util/y": ErrNoVersionFound,
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteResultsJSON(&buf, batch); err != nil {
+		t.Fatalf("WriteResultsJSON() error = %v", err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3:\n%s", len(got), buf.String())
+	}
+	for i, want := range []string{"app-misc/b", "dev-util/y", "dev-util/z"} {
+		if got[i]["package"] != want {
+			t.Errorf("entry %d package = %v, want %s", i, got[i]["package"], want)
+		}
+	}
+	if _, ok := got[0]["error"]; ok {
+		t.Errorf("successful entry has an error field: %v", got[0])
+	}
+	if got[2]["error"] != "timeout" {
+		t.Errorf("failure error = %v, want \"timeout\"", got[2]["error"])
+	}
+}
@@ -0,0 +1,64 @@
+// Package autoupdate provides text/template rendering of check results.
+package autoupdate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// ErrInvalidTemplate is returned when a result template does not parse or
+// fails while rendering a result (e.g. it names a field CheckResult lacks).
+var ErrInvalidTemplate = errors.New("invalid result template")
+
+// DefaultResultTemplate renders one summary line per result:
+// "app-misc/foo 1.0.0 -> 1.2.0 (update)". It is what NewResultFormatter uses
+// for an empty template.
+const DefaultResultTemplate = `{{.Package}} {{.CurrentVersion}} -> {{or .UpstreamVersion "?"}}` +
+	`{{if .HasUpdate}} (update){{else if .NotComparable}} (not comparable){{end}}` +
+	`{{if .Error}} error: {{.Error}}{{end}}`
+
+// ResultFormatter renders CheckResults through a text/template, one line per
+// result, so scripts can pick the fields and layout they need
+// (`--format '{{.Package}} {{.CurrentVersion}}->{{.UpstreamVersion}}'`)
+// instead of parsing the human-oriented table.
+type ResultFormatter struct {
+	tmpl *template.Template
+}
+
+// NewResultFormatter parses text as a template over CheckResult. An empty
+// text selects DefaultResultTemplate. A parse error is returned wrapped in
+// ErrInvalidTemplate.
+func NewResultFormatter(text string) (*ResultFormatter, error) {
+	if text == "" {
+		text = DefaultResultTemplate
+	}
+	tmpl, err := template.New("result").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return &ResultFormatter{tmpl: tmpl}, nil
+}
+
+// Format writes each result rendered through the template to w, each on its
+// own line (a template already ending in a newline is not given a second one,
+// and a result rendering to nothing is skipped).
+// Every result is rendered before anything is written, so a template that
+// fails on some result produces an ErrInvalidTemplate and no partial output.
+func (f *ResultFormatter) Format(w io.Writer, results []CheckResult) error {
+	var buf bytes.Buffer
+	for i := range results {
+		start := buf.Len()
+		if err := f.tmpl.Execute(&buf, &results[i]); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, results[i].Package, err)
+		}
+		// An empty rendering lets a template filter: "{{if .HasUpdate}}...{{end}}".
+		if buf.Len() > start && !bytes.HasSuffix(buf.Bytes()[start:], []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,84 @@
+package autoupdate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestResultFormatter_CustomTemplate verifies results render through a
+// custom template one line each, and empty renderings are skipped.
+func TestResultFormatter_CustomTemplate(t *testing.T) {
+	results := []CheckResult{
+		{Package: "app-misc/foo", CurrentVersion: "1.0.0", UpstreamVersion: "1.2.0", HasUpdate: true},
+		{Package: "app-misc/bar", CurrentVersion: "2.0.0", UpstreamVersion: "2.0.0"},
+	}
+
+	f, err := NewResultFormatter(`{{.Package}} {{.CurrentVersion}}->{{.UpstreamVersion}}`)
+	if err != nil {
+		t.Fatalf("NewResultFormatter() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, results); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "app-misc/foo 1.0.0->1.2.0\napp-misc/bar 2.0.0->2.0.0\n"; buf.String() != want {
+		t.Errorf("Format() = %q, want %q", buf.String(), want)
+	}
+
+	f, err = NewResultFormatter("{{if .HasUpdate}}{{.Package}}\n{{end}}")
+	if err != nil {
+		t.Fatalf("NewResultFormatter() error = %v", err)
+	}
+	buf.Reset()
+	if err := f.Format(&buf, results); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "app-misc/foo\n"; buf.String() != want {
+		t.Errorf("filtering Format() = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestResultFormatter_DefaultTemplate verifies the empty template falls back
+// to DefaultResultTemplate.
+func TestResultFormatter_DefaultTemplate(t *testing.T) {
+	f, err := NewResultFormatter("")
+	if err != nil {
+		t.Fatalf("NewResultFormatter() error = %v", err)
+	}
+	var buf bytes.Buffer
+	err = f.Format(&buf, []CheckResult{
+		{Package: "app-misc/foo", CurrentVersion: "1.0.0", UpstreamVersion: "1.2.0", HasUpdate: true},
+		{Package: "app-misc/bad", CurrentVersion: "1.0.0", Error: fmt.Errorf("boom")},
+	})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := "app-misc/foo 1.0.0 -> 1.2.0 (update)\napp-misc/bad 1.0.0 -> ? error: boom\n"
+	if buf.String() != want {
+		t.Errorf("Format() = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestResultFormatter_InvalidTemplate verifies a malformed template and one
+// naming a missing field are reported as ErrInvalidTemplate, with no output.
+func TestResultFormatter_InvalidTemplate(t *testing.T) {
+	if _, err := NewResultFormatter("{{.Package"); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("parse error = %v, want %v", err, ErrInvalidTemplate)
+	}
+
+	f, err := NewResultFormatter("{{.Package}} {{.NoSuchField}}")
+	if err != nil {
+		t.Fatalf("NewResultFormatter() error = %v", err)
+	}
+	var buf bytes.Buffer
+	err = f.Format(&buf, []CheckResult{{Package: "app-misc/foo"}})
+	if !errors.Is(err, ErrInvalidTemplate) || !strings.Contains(err.Error(), "app-misc/foo") {
+		t.Errorf("execution error = %v, want %v naming the package", err, ErrInvalidTemplate)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("partial output written: %q", buf.String())
+	}
+}
@@ -0,0 +1,145 @@
+// Package autoupdate provides a git-based rollback for applied updates.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// Error variables for revert errors
+var (
+	// ErrUncommittedChanges is returned when the package directory has changes
+	// that are not committed yet. RevertApplied only undoes committed updates,
+	// so it refuses rather than discard work it cannot restore.
+	ErrUncommittedChanges = errors.New("package has uncommitted changes")
+	// ErrNothingToRevert is returned when the most recent commit touching the
+	// package did not add an ebuild, i.e. it does not look like an applied
+	// update.
+	ErrNothingToRevert = errors.New("no applied update to revert")
+)
+
+// RevertResult describes a rollback performed by RevertApplied.
+type RevertResult struct {
+	// Package is the full package name (category/package)
+	Package string
+	// Commit is the hash of the commit whose package changes were undone
+	Commit string
+	// RevertedVersion is the version whose ebuild the commit added and the
+	// rollback removed
+	RevertedVersion string
+	// RestoredVersion is the highest version present after the rollback
+	RestoredVersion string
+}
+
+// RevertApplied undoes the most recent committed update of pkg: the package
+// directory is restored, in the index and the working tree, to its state
+// before the last commit that touched it, and the update goes back to
+// StatusPending so a later apply can retry it.
+//
+// It is meant for an update found broken before it is pushed. The restore is
+// staged but not committed, leaving the maintainer to commit the rollback or
+// fold it away with `git reset`/`git commit --amend`. A dirty package
+// directory fails with ErrUncommittedChanges, and a last commit that added no
+// ebuild (so is not an applied update) fails with ErrNothingToRevert; in both
+// cases nothing is touched.
+//
+// A successful Apply deletes the pending entry, so one is re-added with the
+// restored and reverted versions. An entry that still exists keeps its
+// details and only has its status reset.
+func (a *Applier) RevertApplied(pkg string) (*RevertResult, error) {
+	parts := strings.Split(pkg, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid package name format: %s", pkg)
+	}
+	// git pathspecs always use forward slashes.
+	pkgPath := path.Join(parts[0], parts[1])
+	runner := git.NewGitRunner(a.overlayPath)
+
+	dirty, err := runner.PathStatus(pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git status of %s: %w", pkg, err)
+	}
+	if len(dirty) > 0 {
+		return nil, fmt.Errorf("%w: %s (commit or discard them first)", ErrUncommittedChanges, pkg)
+	}
+
+	commit, err := runner.LastCommit(pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find last commit of %s: %w", pkg, err)
+	}
+	if commit == "" {
+		return nil, fmt.Errorf("%w: %s has no commits", ErrNothingToRevert, pkg)
+	}
+
+	changes, err := runner.CommitChanges(commit, pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes of commit %s: %w", commit, err)
+	}
+	reverted := addedEbuildVersion(changes)
+	if reverted == "" {
+		return nil, fmt.Errorf("%w: last commit %.12s of %s added no ebuild", ErrNothingToRevert, commit, pkg)
+	}
+
+	// A root commit has no parent; restoring from the empty tree then removes
+	// everything it added.
+	parent, err := runner.Parent(commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find parent of commit %.12s: %w", commit, err)
+	}
+	if err := runner.Restore(parent, pkgPath); err != nil {
+		return nil, fmt.Errorf("failed to restore %s to before %.12s: %w", pkg, commit, err)
+	}
+
+	result := &RevertResult{
+		Package:         pkg,
+		Commit:          commit,
+		RevertedVersion: reverted,
+	}
+	// The package may not have existed before the commit (a fresh add), in
+	// which case nothing remains and RestoredVersion stays empty.
+	if restored, err := a.resolveCurrentVersion(pkg); err == nil {
+		result.RestoredVersion = restored
+	}
+
+	if _, found := a.pending.Get(pkg); found {
+		err = a.pending.SetStatus(pkg, StatusPending, "")
+	} else {
+		err = a.pending.Add(PendingUpdate{
+			Package:        pkg,
+			CurrentVersion: result.RestoredVersion,
+			NewVersion:     reverted,
+			Status:         StatusPending,
+		})
+	}
+	if err != nil {
+		return result, fmt.Errorf("reverted %s but failed to update pending status: %w", pkg, err)
+	}
+
+	return result, nil
+}
+
+// addedEbuildVersion returns the highest non-live version among the ebuilds a
+// commit added, or an empty string when it added none.
+func addedEbuildVersion(changes []git.StatusEntry) string {
+	var best string
+	for _, c := range changes {
+		if c.Status != "A" || !strings.HasSuffix(c.FilePath, ".ebuild") {
+			continue
+		}
+		name := path.Base(c.FilePath)
+		dir := path.Dir(c.FilePath)
+		eb, err := ebuild.ParsePath(path.Join(path.Base(path.Dir(dir)), path.Base(dir), name))
+		if err != nil || eb.Version == "9999" {
+			continue
+		}
+		if best == "" || ebuild.CompareVersions(eb.Version, best) > 0 {
+			best = eb.Version
+		}
+	}
+	return best
+}
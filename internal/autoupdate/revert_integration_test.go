@@ -0,0 +1,175 @@
+//go:build integration
+// +build integration
+
+package autoupdate
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// setupRevertRepo creates a git-backed overlay holding app-misc/foo at 1.0,
+// committed, plus a pending 1.0 -> 1.1 update. It returns the overlay path and
+// an applier wired to a stubbed manifest command.
+func setupRevertRepo(t *testing.T) (string, *Applier) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	createTestEbuildFile(t, overlayDir, "app-misc/foo", "1.0")
+	runGit(t, overlayDir, "init")
+	runGit(t, overlayDir, "config", "user.name", "Test User")
+	runGit(t, overlayDir, "config", "user.email", "test@example.com")
+	runGit(t, overlayDir, "add", "-A")
+	runGit(t, overlayDir, "commit", "-m", "app-misc/foo: add 1.0")
+
+	pending, err := NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList() error = %v", err)
+	}
+	if err := pending.Add(PendingUpdate{
+		Package:        "app-misc/foo",
+		CurrentVersion: "1.0",
+		NewVersion:     "1.1",
+		Status:         StatusPending,
+	}); err != nil {
+		t.Fatalf("pending.Add() error = %v", err)
+	}
+
+	applier, err := NewApplier(overlayDir, configDir,
+		WithApplierPendingList(pending),
+		WithExecCommand(mockExecCommandSuccess),
+		WithApplierClean(true),
+	)
+	if err != nil {
+		t.Fatalf("NewApplier() error = %v", err)
+	}
+	return overlayDir, applier
+}
+
+// runGit runs a git command in dir, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+// TestRevertAppliedRestoresCommittedUpdate applies and commits an update,
+// reverts it, and checks the old ebuild is back and the update pending again.
+func TestRevertAppliedRestoresCommittedUpdate(t *testing.T) {
+	overlayDir, applier := setupRevertRepo(t)
+	pkgDir := filepath.Join(overlayDir, "app-misc", "foo")
+	oldEbuild := filepath.Join(pkgDir, "foo-1.0.ebuild")
+	newEbuild := filepath.Join(pkgDir, "foo-1.1.ebuild")
+
+	oldContent, err := os.ReadFile(oldEbuild)
+	if err != nil {
+		t.Fatalf("failed to read old ebuild: %v", err)
+	}
+
+	result, err := applier.Apply("app-misc/foo", false)
+	if err != nil || !result.Success {
+		t.Fatalf("Apply() = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(oldEbuild); !os.IsNotExist(err) {
+		t.Fatalf("clean should have removed the old ebuild, stat err = %v", err)
+	}
+	runGit(t, overlayDir, "add", "-A")
+	runGit(t, overlayDir, "commit", "-m", "app-misc/foo: add 1.1, drop 1.0")
+
+	if _, found := applier.Pending().Get("app-misc/foo"); found {
+		t.Fatal("a successful apply should remove the pending entry")
+	}
+
+	revert, err := applier.RevertApplied("app-misc/foo")
+	if err != nil {
+		t.Fatalf("RevertApplied() error = %v", err)
+	}
+	if revert.RevertedVersion != "1.1" || revert.RestoredVersion != "1.0" {
+		t.Errorf("RevertApplied() = %+v, want 1.1 reverted to 1.0", revert)
+	}
+
+	restored, err := os.ReadFile(oldEbuild)
+	if err != nil {
+		t.Fatalf("old ebuild not restored: %v", err)
+	}
+	if string(restored) != string(oldContent) {
+		t.Errorf("restored ebuild content differs:\n%s\nwant:\n%s", restored, oldContent)
+	}
+	if _, err := os.Stat(newEbuild); !os.IsNotExist(err) {
+		t.Errorf("new ebuild should be removed, stat err = %v", err)
+	}
+
+	update, found := applier.Pending().Get("app-misc/foo")
+	if !found {
+		t.Fatal("pending entry should be re-added")
+	}
+	if update.Status != StatusPending || update.CurrentVersion != "1.0" || update.NewVersion != "1.1" {
+		t.Errorf("pending entry = %+v, want pending 1.0 -> 1.1", update)
+	}
+}
+
+// TestRevertAppliedRootCommit reverts an update added by the repository's
+// first commit, which has no parent to restore from: the package is removed.
+func TestRevertAppliedRootCommit(t *testing.T) {
+	overlayDir, applier := setupRevertRepo(t)
+
+	revert, err := applier.RevertApplied("app-misc/foo")
+	if err != nil {
+		t.Fatalf("RevertApplied() error = %v", err)
+	}
+	if revert.RevertedVersion != "1.0" || revert.RestoredVersion != "" {
+		t.Errorf("RevertApplied() = %+v, want 1.0 reverted to nothing", revert)
+	}
+	if _, err := os.Stat(filepath.Join(overlayDir, "app-misc", "foo", "foo-1.0.ebuild")); !os.IsNotExist(err) {
+		t.Errorf("ebuild added by the root commit should be removed, stat err = %v", err)
+	}
+}
+
+// TestRevertAppliedRefusesUnsafeReverts verifies RevertApplied leaves the tree
+// alone when the package is dirty or its last commit is not an update.
+func TestRevertAppliedRefusesUnsafeReverts(t *testing.T) {
+	t.Run("uncommitted apply", func(t *testing.T) {
+		overlayDir, applier := setupRevertRepo(t)
+		if _, err := applier.Apply("app-misc/foo", false); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+
+		_, err := applier.RevertApplied("app-misc/foo")
+		if !errors.Is(err, ErrUncommittedChanges) {
+			t.Errorf("RevertApplied() error = %v, want %v", err, ErrUncommittedChanges)
+		}
+		if _, err := os.Stat(filepath.Join(overlayDir, "app-misc", "foo", "foo-1.1.ebuild")); err != nil {
+			t.Errorf("uncommitted ebuild should be left alone: %v", err)
+		}
+	})
+
+	t.Run("last commit added no ebuild", func(t *testing.T) {
+		overlayDir, applier := setupRevertRepo(t)
+		metadata := filepath.Join(overlayDir, "app-misc", "foo", "metadata.xml")
+		if err := os.WriteFile(metadata, []byte("<pkgmetadata/>\n"), 0644); err != nil {
+			t.Fatalf("failed to write metadata: %v", err)
+		}
+		runGit(t, overlayDir, "add", "-A")
+		runGit(t, overlayDir, "commit", "-m", "app-misc/foo: add metadata")
+
+		_, err := applier.RevertApplied("app-misc/foo")
+		if !errors.Is(err, ErrNothingToRevert) {
+			t.Errorf("RevertApplied() error = %v, want %v", err, ErrNothingToRevert)
+		}
+		if _, err := os.Stat(metadata); err != nil {
+			t.Errorf("metadata.xml should be left alone: %v", err)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+// Package autoupdate provides GPG verification of upstream release signatures.
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Error variables for signature verification.
+var (
+	// ErrSignatureInvalid is returned when the upstream release signature is
+	// missing, malformed, or not made by the configured signing key.
+	ErrSignatureInvalid = errors.New("upstream signature verification failed")
+	// ErrGPGUnavailable is returned when gpg is needed but not on PATH.
+	ErrGPGUnavailable = errors.New("gpg not available on PATH (install app-crypt/gnupg)")
+)
+
+// gpgTool is the OpenPGP implementation used for verification.
+const gpgTool = "gpg"
+
+// signatureFetchTimeout bounds each artifact/signature download. The artifact
+// is a full release tarball, so it gets the same budget as authenticated fetch.
+const signatureFetchTimeout = 5 * time.Minute
+
+// armoredKeyHeader starts an inline ASCII-armored public key.
+const armoredKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// verifyReleaseSignature checks the detached signature of the release
+// artifact configured for pkg against its signing_key. It returns nil when the
+// package configures no signing key.
+//
+// The artifact and signature are downloaded into a throwaway directory that
+// also holds a private GnuPG home, so verification never touches (or trusts)
+// the user's keyring: the only key gpg knows is the configured one.
+func (a *Applier) verifyReleaseSignature(pkg, version string) error {
+	cfg, ok := a.configs[pkg]
+	if !ok || cfg.SigningKey == "" {
+		return nil
+	}
+
+	key, err := a.loadSigningKey(cfg.SigningKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignatureInvalid, err)
+	}
+
+	workDir, err := os.MkdirTemp("", "bentoo-verify-*")
+	if err != nil {
+		return fmt.Errorf("failed to create verification directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	artifactURL := strings.ReplaceAll(cfg.SignedURL, "{version}", version)
+	artifactPath := filepath.Join(workDir, "artifact")
+	if err := downloadTo(a.ctx, artifactURL, artifactPath); err != nil {
+		return fmt.Errorf("%w: artifact: %v", ErrSignatureInvalid, err)
+	}
+
+	sigURLs := []string{artifactURL + ".sig", artifactURL + ".asc"}
+	if cfg.SignatureURL != "" {
+		sigURLs = []string{strings.ReplaceAll(cfg.SignatureURL, "{version}", version)}
+	}
+	sigPath := filepath.Join(workDir, "artifact.sig")
+	var sigErr error
+	for _, sigURL := range sigURLs {
+		if sigErr = downloadTo(a.ctx, sigURL, sigPath); sigErr == nil {
+			break
+		}
+	}
+	if sigErr != nil {
+		return fmt.Errorf("%w: signature: %v", ErrSignatureInvalid, sigErr)
+	}
+
+	return verifyDetachedSignature(a.ctx, workDir, key, artifactPath, sigPath)
+}
+
+// loadSigningKey returns the armored key configured as signing_key: the value
+// itself when it is an inline key block, otherwise the contents of the file it
+// names, resolved against the overlay root when relative.
+func (a *Applier) loadSigningKey(signingKey string) ([]byte, error) {
+	if strings.Contains(signingKey, armoredKeyHeader) {
+		return []byte(signingKey), nil
+	}
+	path := signingKey
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.overlayPath, path)
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	return key, nil
+}
+
+// verifyDetachedSignature verifies sigPath over artifactPath with gpg, using
+// a fresh GnuPG home under workDir that holds only key. Success requires a
+// VALIDSIG status line: gpg exits 0 for a good signature by an untrusted key,
+// which is exactly the case here, but that status is the one unambiguous
+// marker of a cryptographically valid signature.
+func verifyDetachedSignature(ctx context.Context, workDir string, key []byte, artifactPath, sigPath string) error {
+	if _, err := exec.LookPath(gpgTool); err != nil {
+		return ErrGPGUnavailable
+	}
+
+	home := filepath.Join(workDir, "gnupg")
+	if err := os.Mkdir(home, 0o700); err != nil {
+		return fmt.Errorf("failed to create GnuPG home: %w", err)
+	}
+	keyPath := filepath.Join(workDir, "signing-key.asc")
+	if err := os.WriteFile(keyPath, key, 0o600); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	base := []string{"--batch", "--no-tty", "--homedir", home}
+	if out, err := exec.CommandContext(ctx, gpgTool, append(base, "--import", keyPath)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: importing signing key: %v: %s", ErrSignatureInvalid, err, strings.TrimSpace(string(out)))
+	}
+
+	var status, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, gpgTool, append(base, "--status-fd", "1", "--verify", sigPath, artifactPath)...)
+	cmd.Stdout = &status
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil || !strings.Contains(status.String(), "[GNUPG:] VALIDSIG ") {
+		return fmt.Errorf("%w: %s", ErrSignatureInvalid, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// downloadTo fetches rawURL into path.
+func downloadTo(ctx context.Context, rawURL, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: signatureFetchTimeout}
+	// One-shot download: do not leave a pooled idle connection behind.
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", rawURL, resp.StatusCode)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
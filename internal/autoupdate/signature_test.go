@@ -0,0 +1,229 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// signedFixture holds a release artifact, its detached signature and the
+// armored public key that made it.
+type signedFixture struct {
+	artifact  []byte
+	signature []byte
+	publicKey []byte
+}
+
+// newSignedFixture generates a throwaway ed25519 key in a private GnuPG home
+// and detach-signs a small artifact with it. The test is skipped when gpg is
+// not installed.
+func newSignedFixture(t *testing.T) signedFixture {
+	t.Helper()
+	if _, err := exec.LookPath(gpgTool); err != nil {
+		t.Skip("gpg not available")
+	}
+
+	dir := t.TempDir()
+	home := filepath.Join(dir, "gnupg")
+	if err := os.Mkdir(home, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	gpg := func(args ...string) []byte {
+		t.Helper()
+		cmd := exec.Command(gpgTool, append([]string{"--batch", "--no-tty", "--homedir", home, "--passphrase", "", "--pinentry-mode", "loopback"}, args...)...)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("gpg %v: %v", args, err)
+		}
+		return out
+	}
+
+	const uid = "Release Signer <release@example.com>"
+	gpg("--quick-gen-key", uid, "ed25519", "sign", "never")
+
+	artifact := []byte("tool-1.2.0 release tarball contents\n")
+	artifactPath := filepath.Join(dir, "tool-1.2.0.tar.gz")
+	if err := os.WriteFile(artifactPath, artifact, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gpg("--armor", "--detach-sign", "--output", artifactPath+".asc", artifactPath)
+	signature, err := os.ReadFile(artifactPath + ".asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signedFixture{
+		artifact:  artifact,
+		signature: signature,
+		publicKey: gpg("--armor", "--export", uid),
+	}
+}
+
+// TestVerifyDetachedSignature verifies a good signature passes and a tampered
+// artifact fails with ErrSignatureInvalid.
+func TestVerifyDetachedSignature(t *testing.T) {
+	fx := newSignedFixture(t)
+
+	verify := func(artifact []byte) error {
+		dir := t.TempDir()
+		artifactPath := filepath.Join(dir, "artifact")
+		sigPath := filepath.Join(dir, "artifact.sig")
+		if err := os.WriteFile(artifactPath, artifact, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(sigPath, fx.signature, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return verifyDetachedSignature(t.Context(), dir, fx.publicKey, artifactPath, sigPath)
+	}
+
+	if err := verify(fx.artifact); err != nil {
+		t.Errorf("good signature: error = %v, want nil", err)
+	}
+	tampered := append([]byte("x"), fx.artifact...)
+	if err := verify(tampered); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("tampered artifact: error = %v, want %v", err, ErrSignatureInvalid)
+	}
+}
+
+// TestApply_SignatureGatesValidation verifies Apply reaches a successful
+// result only when the upstream signature checks out, and otherwise marks the
+// entry failed and rolls back the new ebuild.
+func TestApply_SignatureGatesValidation(t *testing.T) {
+	fx := newSignedFixture(t)
+
+	tests := []struct {
+		name     string
+		artifact []byte
+		wantOK   bool
+	}{
+		{"good signature", fx.artifact, true},
+		{"tampered artifact", append([]byte("x"), fx.artifact...), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/tool-2.0.0.tar.gz":
+					_, _ = w.Write(tt.artifact)
+				case "/tool-2.0.0.tar.gz.asc":
+					_, _ = w.Write(fx.signature)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			tmpDir := t.TempDir()
+			overlayDir := filepath.Join(tmpDir, "overlay")
+			configDir := filepath.Join(tmpDir, "config")
+			pkg := "test-cat/tool"
+			createTestEbuildFile(t, overlayDir, pkg, "1.0.0")
+
+			pending, _ := NewPendingList(configDir)
+			pending.Add(PendingUpdate{Package: pkg, CurrentVersion: "1.0.0", NewVersion: "2.0.0", Status: StatusPending})
+
+			cfg := &PackagesConfig{Packages: map[string]PackageConfig{
+				pkg: {
+					SigningKey: string(fx.publicKey),
+					SignedURL:  srv.URL + "/tool-{version}.tar.gz",
+				},
+			}}
+			applier, err := NewApplier(overlayDir, configDir,
+				WithApplierPendingList(pending),
+				WithApplierPackagesConfig(cfg),
+				WithExecCommand(mockExecCommandSuccess),
+			)
+			if err != nil {
+				t.Fatalf("NewApplier failed: %v", err)
+			}
+
+			result, applyErr := applier.Apply(pkg, false)
+			if tt.wantOK {
+				if applyErr != nil || !result.Success {
+					t.Fatalf("Apply() = %v (success %v), want success", applyErr, result.Success)
+				}
+				return
+			}
+
+			if !errors.Is(applyErr, ErrSignatureInvalid) {
+				t.Fatalf("Apply() error = %v, want %v", applyErr, ErrSignatureInvalid)
+			}
+			if update, found := pending.Get(pkg); !found || update.Status != StatusFailed {
+				t.Errorf("pending status = %+v, want %s", update, StatusFailed)
+			}
+			if _, statErr := os.Stat(applier.EbuildPath(pkg, "2.0.0")); !errors.Is(statErr, os.ErrNotExist) {
+				t.Errorf("new ebuild was not rolled back: %v", statErr)
+			}
+		})
+	}
+}
+
+// TestApply_SignatureCheckedWhenManifestSkipped verifies a missing pkgdev does
+// not let an unverified release through: with WithApplierSkipMissingManifest,
+// a package or group member pinning a signing_key whose artifact cannot be
+// verified still fails the apply and rolls back every new ebuild.
+func TestApply_SignatureCheckedWhenManifestSkipped(t *testing.T) {
+	stubManifestToolMissing(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name    string
+		members []string
+	}{
+		{"single package", nil},
+		{"group", []string{"test-cat/tool", "test-cat/tool-plugins"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			overlayDir := filepath.Join(tmpDir, "overlay")
+			configDir := filepath.Join(tmpDir, "config")
+			pkgs := tt.members
+			if pkgs == nil {
+				pkgs = []string{"test-cat/tool"}
+			}
+			for _, pkg := range pkgs {
+				createTestEbuildFile(t, overlayDir, pkg, "1.0.0")
+			}
+
+			pending, _ := NewPendingList(configDir)
+			pending.Add(PendingUpdate{Package: pkgs[0], CurrentVersion: "1.0.0", NewVersion: "2.0.0",
+				Status: StatusPending, GroupMembers: tt.members})
+
+			cfg := &PackagesConfig{Packages: map[string]PackageConfig{
+				pkgs[len(pkgs)-1]: {
+					SigningKey: armoredKeyHeader + "\n",
+					SignedURL:  srv.URL + "/tool-{version}.tar.gz",
+				},
+			}}
+			applier, err := NewApplier(overlayDir, configDir,
+				WithApplierPendingList(pending),
+				WithApplierPackagesConfig(cfg),
+				WithApplierSkipMissingManifest(true),
+				WithExecCommand(mockExecCommandSuccess),
+			)
+			if err != nil {
+				t.Fatalf("NewApplier failed: %v", err)
+			}
+
+			result, applyErr := applier.Apply(pkgs[0], false)
+			if !errors.Is(applyErr, ErrSignatureInvalid) {
+				t.Fatalf("Apply() error = %v, want %v", applyErr, ErrSignatureInvalid)
+			}
+			if result.Success || result.ManifestSkipped {
+				t.Errorf("Success=%v ManifestSkipped=%v, want both false", result.Success, result.ManifestSkipped)
+			}
+			for _, pkg := range pkgs {
+				if _, statErr := os.Stat(applier.EbuildPath(pkg, "2.0.0")); !errors.Is(statErr, os.ErrNotExist) {
+					t.Errorf("%s: new ebuild was not rolled back: %v", pkg, statErr)
+				}
+			}
+		})
+	}
+}
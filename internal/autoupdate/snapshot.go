@@ -0,0 +1,126 @@
+// Package autoupdate provides `git describe` snapshot version handling for
+// ebuild autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Snapshot policies for PackageConfig.SnapshotPolicy.
+const (
+	// SnapshotIgnore reports a describe version as its base tag:
+	// "1.2.3-10-gdeadbee" is checked as "1.2.3".
+	SnapshotIgnore = "ignore"
+	// SnapshotFlag reports the base tag like SnapshotIgnore and records the
+	// snapshot on the CheckResult, so the commits past the tag are visible
+	// without being treated as a release.
+	SnapshotFlag = "flag"
+	// SnapshotUse keeps the describe version as the upstream version and
+	// orders it as the base tag plus that many commits.
+	SnapshotUse = "use"
+)
+
+// ErrInvalidSnapshotPolicy is returned for a snapshot_policy other than
+// ignore, flag or use.
+var ErrInvalidSnapshotPolicy = errors.New("invalid snapshot_policy: must be 'ignore', 'flag' or 'use'")
+
+// describeRe matches the suffix `git describe` appends to the nearest tag
+// when HEAD is past it: -<commits>-g<abbreviated hash>.
+var describeRe = regexp.MustCompile(`^(.+)-([0-9]+)-g([0-9a-f]{4,40})$`)
+
+// describeVersion is a version in `git describe` form split into its parts.
+type describeVersion struct {
+	// Base is the tag the snapshot is counted from
+	Base string
+	// Commits is how many commits HEAD is past Base
+	Commits int
+	// Hash is the abbreviated commit hash, without the "g" prefix
+	Hash string
+}
+
+// parseDescribe splits a `git describe` version such as
+// "v1.2.3-10-gdeadbee". ok is false for anything else, including a plain
+// tag: describe prints only the tag when HEAD is exactly on it.
+func parseDescribe(v string) (d describeVersion, ok bool) {
+	m := describeRe.FindStringSubmatch(v)
+	if m == nil {
+		return describeVersion{}, false
+	}
+	commits, err := strconv.Atoi(m[2])
+	if err != nil {
+		return describeVersion{}, false
+	}
+	return describeVersion{Base: m[1], Commits: commits, Hash: m[3]}, true
+}
+
+// describeComparable rewrites a describe version into the Gentoo form it
+// orders as, the base tag with a _p<commits> suffix: "1.2.3-10-gdeadbee"
+// becomes "1.2.3_p10", newer than 1.2.3 and older than 1.2.4. Other
+// versions are returned unchanged.
+func describeComparable(v string) string {
+	if d, ok := parseDescribe(v); ok {
+		return fmt.Sprintf("%s_p%d", d.Base, d.Commits)
+	}
+	return v
+}
+
+// snapshotComparable is the form a package's upstream version is ordered
+// in: its describeComparable form when the package has a snapshot_policy,
+// and the version itself otherwise, so a describe version without a policy
+// stays not comparable as before. Under ignore and flag it only matters
+// before applySnapshotPolicy has reduced the version to its base tag.
+func snapshotComparable(cfg *PackageConfig, v string) string {
+	if cfg.SnapshotPolicy != "" {
+		return describeComparable(stripVersionPrefix(strings.TrimSpace(v)))
+	}
+	return v
+}
+
+// validateSnapshotPolicy checks a package's snapshot_policy.
+func validateSnapshotPolicy(policy string) error {
+	switch policy {
+	case "", SnapshotIgnore, SnapshotFlag, SnapshotUse:
+		return nil
+	}
+	return fmt.Errorf("%w: got %q", ErrInvalidSnapshotPolicy, policy)
+}
+
+// selectSnapshotVersion is selectVersion for a package with a
+// snapshot_policy. Describe versions are not valid Gentoo versions, so
+// selectVersion would drop them; here every candidate is compared in its
+// describeComparable form and the winner is returned as extracted (after
+// transform and prefix stripping), describe suffix included.
+func selectSnapshotVersion(cands []string, transform [][]string, mode string, constraint versionConstraint) string {
+	original := make(map[string]string, len(cands))
+	comparable := make([]string, 0, len(cands))
+	for _, c := range cands {
+		full := stripVersionPrefix(applyTransforms(strings.TrimSpace(c), transform))
+		cmp := describeComparable(full)
+		original[cmp] = full
+		comparable = append(comparable, cmp)
+	}
+	return original[selectVersion(comparable, nil, mode, constraint)]
+}
+
+// applySnapshotPolicy applies cfg.SnapshotPolicy to an extracted version.
+// ignore and flag reduce a describe version to its base tag; flag also
+// records the snapshot on result when result is non-nil. use, an unset
+// policy and a version that is not in describe form are returned unchanged.
+func applySnapshotPolicy(cfg *PackageConfig, version string, result *CheckResult) string {
+	if cfg.SnapshotPolicy == "" || cfg.SnapshotPolicy == SnapshotUse {
+		return version
+	}
+	d, ok := parseDescribe(version)
+	if !ok {
+		return version
+	}
+	if cfg.SnapshotPolicy == SnapshotFlag && result != nil {
+		result.Snapshot = version
+		result.SnapshotCommits = d.Commits
+	}
+	return d.Base
+}
@@ -0,0 +1,103 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestParseDescribe covers splitting `git describe` versions.
+func TestParseDescribe(t *testing.T) {
+	d, ok := parseDescribe("1.2.3-10-gdeadbee")
+	if !ok || d.Base != "1.2.3" || d.Commits != 10 || d.Hash != "deadbee" {
+		t.Errorf("parseDescribe(1.2.3-10-gdeadbee) = %+v, %v", d, ok)
+	}
+	for _, v := range []string{"1.2.3", "1.2.3-10", "1.2.3-rc1", "1.2.3-10-gXYZ"} {
+		if _, ok := parseDescribe(v); ok {
+			t.Errorf("parseDescribe(%q) ok, want not a describe version", v)
+		}
+	}
+	if got := describeComparable("1.2.3-10-gdeadbee"); got != "1.2.3_p10" {
+		t.Errorf("describeComparable = %q, want 1.2.3_p10", got)
+	}
+}
+
+// TestValidateSnapshotPolicy verifies an unknown policy is rejected.
+func TestValidateSnapshotPolicy(t *testing.T) {
+	cfg := &PackageConfig{URL: "https://example.com", Parser: "json", Path: "tag", SnapshotPolicy: "keep"}
+	if err := ValidatePackageConfig("app-misc/foo", cfg); !errors.Is(err, ErrInvalidSnapshotPolicy) {
+		t.Errorf("ValidatePackageConfig() = %v, want %v", err, ErrInvalidSnapshotPolicy)
+	}
+	for _, policy := range []string{"", SnapshotIgnore, SnapshotFlag, SnapshotUse} {
+		cfg.SnapshotPolicy = policy
+		if err := ValidatePackageConfig("app-misc/foo", cfg); err != nil {
+			t.Errorf("ValidatePackageConfig(%q) = %v", policy, err)
+		}
+	}
+}
+
+// TestCheckPackage_SnapshotPolicy checks a describe-style tag 10 commits
+// past the packaged 1.2.3 under each policy, through both the single-value
+// path and versions_path selection.
+func TestCheckPackage_SnapshotPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tag": "v1.2.3-10-gdeadbee",
+			"tags": ["v1.2.2-3-gabc1234", "v1.2.3-10-gdeadbee", "v1.2.3"]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		policy       string
+		selectMax    bool
+		wantUpstream string
+		wantUpdate   bool
+		wantSnapshot string
+	}{
+		{policy: SnapshotIgnore, wantUpstream: "v1.2.3"},
+		{policy: SnapshotFlag, wantUpstream: "v1.2.3", wantSnapshot: "v1.2.3-10-gdeadbee"},
+		{policy: SnapshotUse, wantUpstream: "v1.2.3-10-gdeadbee", wantUpdate: true},
+		{policy: SnapshotIgnore, selectMax: true, wantUpstream: "1.2.3"},
+		{policy: SnapshotFlag, selectMax: true, wantUpstream: "1.2.3", wantSnapshot: "1.2.3-10-gdeadbee"},
+		{policy: SnapshotUse, selectMax: true, wantUpstream: "1.2.3-10-gdeadbee", wantUpdate: true},
+	}
+	for _, tt := range tests {
+		name := tt.policy
+		entry := `path = "tag"`
+		if tt.selectMax {
+			name += "/select"
+			entry = `path = "tags"
+select = "max"`
+		}
+		t.Run(name, func(t *testing.T) {
+			overlay, _ := writePackagesTOML(t, `["app-misc/foo"]
+url = "`+srv.URL+`"
+parser = "json"
+`+entry+`
+snapshot_policy = "`+tt.policy+`"
+`)
+			createTestEbuild(t, overlay, "app-misc/foo", "1.2.3")
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("app-misc/foo", true)
+			if err != nil {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != tt.wantUpstream || result.HasUpdate != tt.wantUpdate || result.NotComparable {
+				t.Errorf("CheckPackage() = %q (HasUpdate %v, NotComparable %v), want %q (HasUpdate %v)",
+					result.UpstreamVersion, result.HasUpdate, result.NotComparable, tt.wantUpstream, tt.wantUpdate)
+			}
+			if result.Snapshot != tt.wantSnapshot {
+				t.Errorf("Snapshot = %q, want %q", result.Snapshot, tt.wantSnapshot)
+			}
+			if tt.wantSnapshot != "" && result.SnapshotCommits != 10 {
+				t.Errorf("SnapshotCommits = %d, want 10", result.SnapshotCommits)
+			}
+		})
+	}
+}
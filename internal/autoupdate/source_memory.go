@@ -0,0 +1,38 @@
+// Package autoupdate provides the per-package last successful source memory.
+package autoupdate
+
+// Sources a version can come from, as recorded by Cache.SetLastSource.
+const (
+	// SourcePrimary is the package's url and parser
+	SourcePrimary = "primary"
+	// SourceFallback is the package's fallback_url and fallback_parser
+	SourceFallback = "fallback"
+	// SourceLLM is LLM extraction from the primary url's content
+	SourceLLM = "llm"
+)
+
+// LastSource returns which source answered pkg's last successful check
+// (SourcePrimary, SourceFallback or SourceLLM), or "" when none is recorded.
+// Unlike entries it does not expire.
+func (c *Cache) LastSource(pkg string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sources[pkg]
+}
+
+// SetLastSource records source as the one that answered pkg. The file is only
+// rewritten when the source changes, so steady runs cost no extra write. An
+// empty source is ignored.
+func (c *Cache) SetLastSource(pkg, source string) error {
+	if source == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sources[pkg] == source {
+		return nil
+	}
+	c.sources[pkg] = source
+	return c.saveUnsafe()
+}
@@ -0,0 +1,119 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestCheckPackage_LastSuccessfulSourceFirst verifies a package whose primary
+// fails and fallback succeeds records the fallback, the next run asks the
+// fallback first, and the primary is tried again once the fallback fails.
+func TestCheckPackage_LastSuccessfulSourceFirst(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		requests     []string
+		primaryUp    atomic.Bool
+		fallbackDown atomic.Bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/primary" && primaryUp.Load():
+			_, _ = w.Write([]byte(`{"version":"2.1.0"}`))
+		case r.URL.Path == "/fallback" && !fallbackDown.Load():
+			_, _ = w.Write([]byte(`{"version":"2.0.0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, _ := writePackagesTOML(t, `["app-misc/mirrored"]
+url = "`+srv.URL+`/primary"
+parser = "json"
+path = "version"
+fallback_url = "`+srv.URL+`/fallback"
+fallback_parser = "json"
+`)
+	createTestEbuild(t, overlay, "app-misc/mirrored", "1.0.0")
+	configDir := t.TempDir()
+
+	// check runs one CheckPackage with a fresh Checker, as a new CLI run
+	// would, and returns the paths it requested.
+	check := func(wantVersion string) []string {
+		t.Helper()
+		mu.Lock()
+		requests = nil
+		mu.Unlock()
+		checker, err := NewChecker(overlay,
+			WithConfigDir(configDir),
+			WithRateLimiter(unlimitedRateLimiter()),
+		)
+		if err != nil {
+			t.Fatalf("NewChecker() error = %v", err)
+		}
+		result, err := checker.CheckPackage("app-misc/mirrored", true)
+		if err != nil {
+			t.Fatalf("CheckPackage() error = %v", err)
+		}
+		if result.UpstreamVersion != wantVersion {
+			t.Errorf("UpstreamVersion = %q, want %q", result.UpstreamVersion, wantVersion)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+
+	if got := check("2.0.0"); !reflect.DeepEqual(got, []string{"/primary", "/fallback"}) {
+		t.Errorf("first run requested %v, want primary then fallback", got)
+	}
+	if got := check("2.0.0"); !reflect.DeepEqual(got, []string{"/fallback"}) {
+		t.Errorf("second run requested %v, want the remembered fallback only", got)
+	}
+
+	primaryUp.Store(true)
+	fallbackDown.Store(true)
+	if got := check("2.1.0"); !reflect.DeepEqual(got, []string{"/fallback", "/primary"}) {
+		t.Errorf("third run requested %v, want fallback then primary", got)
+	}
+	if got := check("2.1.0"); !reflect.DeepEqual(got, []string{"/primary"}) {
+		t.Errorf("fourth run requested %v, want the primary first again", got)
+	}
+}
+
+// TestCache_LastSourceOutlivesEntries verifies the source memory is saved
+// without any entry, survives a reload of a compacting cache, and is dropped
+// by Delete.
+func TestCache_LastSourceOutlivesEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, WithCompaction(true))
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if err := cache.SetLastSource("app-misc/a", SourceFallback); err != nil {
+		t.Fatalf("SetLastSource() error = %v", err)
+	}
+	if err := cache.SetLastSource("app-misc/b", SourcePrimary); err != nil {
+		t.Fatalf("SetLastSource() error = %v", err)
+	}
+	if err := cache.Delete("app-misc/b"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	reloaded, err := NewCache(dir, WithCompaction(true))
+	if err != nil {
+		t.Fatalf("NewCache() reload error = %v", err)
+	}
+	if got := reloaded.LastSource("app-misc/a"); got != SourceFallback {
+		t.Errorf("LastSource(a) = %q, want %q", got, SourceFallback)
+	}
+	if got := reloaded.LastSource("app-misc/b"); got != "" {
+		t.Errorf("LastSource(b) = %q after Delete, want none", got)
+	}
+}
@@ -0,0 +1,109 @@
+// Package autoupdate provides SourceForge project RSS support for ebuild
+// autoupdate.
+package autoupdate
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// PrioritySourceForge is the priority for a SourceForge project's file RSS
+const PrioritySourceForge = 20
+
+// sourceForgeXPath selects the enclosure URL of every file in a SourceForge
+// RSS: https://sourceforge.net/projects/<proj>/files/<path>/<file>/download.
+const sourceForgeXPath = "//item/media:content/@url"
+
+// Regular expressions for SourceForge URL matching
+var (
+	// sourceForgeProjectRegex matches project pages:
+	// sourceforge.net/projects/<proj>, sf.net/projects/<proj>
+	sourceForgeProjectRegex = regexp.MustCompile(`(?:^|[/.])(?:sourceforge|sf)\.net/projects?/([A-Za-z0-9][A-Za-z0-9_.-]*)`)
+	// sourceForgeHostRegex matches project web hosting:
+	// <proj>.sourceforge.net, <proj>.sourceforge.io, <proj>.sf.net
+	sourceForgeHostRegex = regexp.MustCompile(`^https?://([A-Za-z0-9][A-Za-z0-9-]*)\.(?:sourceforge\.(?:net|io)|sf\.net)(?:[/:]|$)`)
+	// sourceForgeDownloadRegex matches release files in SRC_URI, via the
+	// mirror or the download redirector:
+	// mirror://sourceforge/<proj>/…, downloads.sourceforge.net/[project/]<proj>/…
+	sourceForgeDownloadRegex = regexp.MustCompile(`(?:mirror://sourceforge|downloads\.(?:sourceforge|sf)\.net(?:/project)?)/([^/\s"'#?]+)/(\S*)`)
+)
+
+// sourceForgeHosts are the sourceforge.net subdomains that are SourceForge's
+// own, not a project's.
+var sourceForgeHosts = map[string]bool{"www": true, "downloads": true, "sourceforge": true, "prdownloads": true}
+
+// discoverSourceForgeSource finds the file RSS of a SourceForge project from
+// a sourceforge.net SRC_URI or homepage. SRC_URI is checked first since it
+// names the project the release files come from, which a homepage on project
+// web hosting need not.
+func discoverSourceForgeSource(meta *EbuildMetadata) *DataSource {
+	if matches := sourceForgeDownloadRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		if proj := expandPN(matches[1], meta.Package); proj != "" {
+			return createSourceForgeSource(proj)
+		}
+	}
+	if matches := sourceForgeProjectRegex.FindStringSubmatch(meta.Homepage); matches != nil {
+		if proj := expandPN(matches[1], meta.Package); proj != "" {
+			return createSourceForgeSource(proj)
+		}
+	}
+	if matches := sourceForgeHostRegex.FindStringSubmatch(meta.Homepage); matches != nil && !sourceForgeHosts[strings.ToLower(matches[1])] {
+		return createSourceForgeSource(strings.ToLower(matches[1]))
+	}
+	return nil
+}
+
+// createSourceForgeSource creates a file RSS data source for the project.
+// The feed lists the project's most recent files, newest first, whatever
+// folder they were uploaded to.
+func createSourceForgeSource(proj string) *DataSource {
+	return &DataSource{
+		URL:         fmt.Sprintf("https://sourceforge.net/projects/%s/rss", proj),
+		Type:        "sourceforge",
+		Priority:    PrioritySourceForge,
+		ContentType: ContentTypeXML,
+	}
+}
+
+// sourceForgeSchema is the schema suggested for a SourceForge RSS: the xml
+// parser over the enclosure URLs, with a file_pattern for the package's
+// release files. The feed mixes every file a project uploads (binaries for
+// other platforms, older branches, documentation), so unlike the other
+// discovered sources the schema cannot be fixed: the file name is taken from
+// the SRC_URI the ebuild downloads, and falls back to <PN>-<version>.
+func sourceForgeSchema(meta *EbuildMetadata, source *DataSource) *PackageConfig {
+	return &PackageConfig{
+		URL:         source.URL,
+		Parser:      "xml",
+		XPath:       sourceForgeXPath,
+		FilePattern: sourceForgeFilePattern(meta),
+		Headers:     maps.Clone(source.Headers),
+	}
+}
+
+// sourceForgeFilePattern returns the file_pattern for the release files of
+// the package meta describes. The SRC_URI file name is used when it names the
+// version exactly once, as ${PV}, ${P} or the ebuild's version spelled out;
+// anything else it expands to must be known, so ${MY_P} and the like fall
+// back to releaseFilePattern.
+func sourceForgeFilePattern(meta *EbuildMetadata) string {
+	if meta == nil {
+		return ""
+	}
+	pn := meta.Package[strings.LastIndex(meta.Package, "/")+1:]
+	if matches := sourceForgeDownloadRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		name := path.Base(matches[2])
+		name = strings.ReplaceAll(name, "${P}", "${PN}-${PV}")
+		name = strings.ReplaceAll(name, "${PN}", pn)
+		if !strings.Contains(name, "${PV}") && meta.Version != "" {
+			name = strings.Replace(name, meta.Version, "${PV}", 1)
+		}
+		if before, after, ok := strings.Cut(name, "${PV}"); ok && !strings.Contains(before+after, "$") {
+			return `(?i)^` + regexp.QuoteMeta(before) + `(\d[0-9A-Za-z._+-]*?)` + regexp.QuoteMeta(after) + `$`
+		}
+	}
+	return `(?i)` + releaseFilePattern(pn)
+}
@@ -0,0 +1,171 @@
+package autoupdate
+
+import (
+	"errors"
+	"testing"
+)
+
+// sampleSourceForgeRSS is a trimmed capture of a SourceForge project RSS,
+// newest upload first: a Windows build and a signature come before the
+// tarball, an older branch's point release is uploaded after the newest
+// release, and the first item's <link> has no enclosure.
+const sampleSourceForgeRSS = `<?xml version="1.0" encoding="utf-8"?>
+<rss xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:files="https://sourceforge.net/api/files.rdf#" xmlns:media="http://video.search.yahoo.com/mrss/" xmlns:doap="http://usefulinc.com/ns/doap#" xmlns:sf="https://sourceforge.net/api/sfelements.rdf#" version="2.0">
+  <channel xmlns:files="https://sourceforge.net/api/files.rdf#" xmlns:media="http://video.search.yahoo.com/mrss/" xmlns:doap="http://usefulinc.com/ns/doap#" xmlns:sf="https://sourceforge.net/api/sfelements.rdf#">
+    <title>Foobar</title>
+    <link>https://sourceforge.net</link>
+    <description>Files from Foobar (foobar)</description>
+    <pubDate>Tue, 03 Sep 2024 10:12:44 UT</pubDate>
+    <managingEditor>noreply@sourceforge.net (SourceForge.net)</managingEditor>
+    <item>
+      <title><![CDATA[/README.md]]></title>
+      <link>https://sourceforge.net/projects/foobar/files/README.md/download</link>
+      <guid>https://sourceforge.net/projects/foobar/files/README.md/download</guid>
+      <pubDate>Tue, 03 Sep 2024 10:12:44 UT</pubDate>
+    </item>
+    <item>
+      <title><![CDATA[/foobar/1.9.4/foobar-1.9.4.tar.gz]]></title>
+      <link>https://sourceforge.net/projects/foobar/files/foobar/1.9.4/foobar-1.9.4.tar.gz/download</link>
+      <guid>https://sourceforge.net/projects/foobar/files/foobar/1.9.4/foobar-1.9.4.tar.gz/download</guid>
+      <pubDate>Mon, 02 Sep 2024 08:01:10 UT</pubDate>
+      <files:sf-file-id xmlns:files="https://sourceforge.net/api/files.rdf#">78901234</files:sf-file-id>
+      <media:content xmlns:media="http://video.search.yahoo.com/mrss/" type="application/x-gzip; charset=binary" url="https://sourceforge.net/projects/foobar/files/foobar/1.9.4/foobar-1.9.4.tar.gz/download" filesize="1048576"><media:hash algo="md5">0123456789abcdef0123456789abcdef</media:hash></media:content>
+    </item>
+    <item>
+      <title><![CDATA[/foobar/2.1.0/foobar-2.1.0-win64.zip]]></title>
+      <link>https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0-win64.zip/download</link>
+      <guid>https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0-win64.zip/download</guid>
+      <pubDate>Sun, 01 Sep 2024 12:00:00 UT</pubDate>
+      <media:content xmlns:media="http://video.search.yahoo.com/mrss/" type="application/zip; charset=binary" url="https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0-win64.zip/download" filesize="2097152"><media:hash algo="md5">fedcba9876543210fedcba9876543210</media:hash></media:content>
+    </item>
+    <item>
+      <title><![CDATA[/foobar/2.1.0/foobar-2.1.0.tar.gz.asc]]></title>
+      <link>https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0.tar.gz.asc/download</link>
+      <guid>https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0.tar.gz.asc/download</guid>
+      <pubDate>Sun, 01 Sep 2024 11:59:00 UT</pubDate>
+      <media:content xmlns:media="http://video.search.yahoo.com/mrss/" type="text/plain; charset=us-ascii" url="https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0.tar.gz.asc/download" filesize="833"><media:hash algo="md5">00000000000000000000000000000000</media:hash></media:content>
+    </item>
+    <item>
+      <title><![CDATA[/foobar/2.1.0/foobar-2.1.0.tar.gz]]></title>
+      <link>https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0.tar.gz/download</link>
+      <guid>https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0.tar.gz/download</guid>
+      <pubDate>Sun, 01 Sep 2024 11:58:00 UT</pubDate>
+      <media:content xmlns:media="http://video.search.yahoo.com/mrss/" type="application/x-gzip; charset=binary" url="https://sourceforge.net/projects/foobar/files/foobar/2.1.0/foobar-2.1.0.tar.gz/download" filesize="1153433"><media:hash algo="md5">11111111111111111111111111111111</media:hash></media:content>
+    </item>
+  </channel>
+</rss>
+`
+
+// TestXMLFileParser verifies that with a file pattern the xml parser reads
+// the highest version among the matching file names, whatever the feed
+// order, and lists them for select.
+func TestXMLFileParser(t *testing.T) {
+	p, err := NewXMLFileParser(sourceForgeXPath, `^foobar-([0-9.]+)\.tar\.gz$`)
+	if err != nil {
+		t.Fatalf("NewXMLFileParser() error = %v", err)
+	}
+	got, err := p.Parse([]byte(sampleSourceForgeRSS))
+	if err != nil || got != "2.1.0" {
+		t.Errorf("Parse() = %q, %v; want 2.1.0", got, err)
+	}
+	versions, err := p.ExtractVersions([]byte(sampleSourceForgeRSS))
+	if err != nil || len(versions) != 2 || versions[0] != "1.9.4" || versions[1] != "2.1.0" {
+		t.Errorf("ExtractVersions() = %v, %v; want [1.9.4 2.1.0]", versions, err)
+	}
+
+	// The <link> of every item, the README's included, works the same.
+	links, err := NewXMLFileParser("//item/link", `^foobar-([0-9.]+)\.tar\.gz$`)
+	if err != nil {
+		t.Fatalf("NewXMLFileParser(link) error = %v", err)
+	}
+	if got, err := links.Parse([]byte(sampleSourceForgeRSS)); err != nil || got != "2.1.0" {
+		t.Errorf("Parse(link) = %q, %v; want 2.1.0", got, err)
+	}
+
+	none, _ := NewXMLFileParser(sourceForgeXPath, `^bazqux-([0-9.]+)\.tar\.gz$`)
+	if _, err := none.Parse([]byte(sampleSourceForgeRSS)); !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse(no match) error = %v, want %v", err, ErrNoVersionFound)
+	}
+
+	if _, err := newXMLParser("//item/link", `(\d+)`, `^foo-(\d+)$`); !errors.Is(err, ErrInvalidRegexPattern) {
+		t.Errorf("pattern with file_pattern error = %v, want %v", err, ErrInvalidRegexPattern)
+	}
+	if _, err := NewXMLFileParser("//item/link", `^foo-\d+$`); !errors.Is(err, ErrInvalidRegexPattern) {
+		t.Errorf("file_pattern without a group error = %v, want %v", err, ErrInvalidRegexPattern)
+	}
+}
+
+// TestDiscoverSourceForgeSource verifies the project RSS is derived from a
+// SourceForge SRC_URI or homepage, and that the analyzer suggests an xml
+// schema whose file pattern follows the SRC_URI file name.
+func TestDiscoverSourceForgeSource(t *testing.T) {
+	const want = "https://sourceforge.net/projects/foobar/rss"
+	tests := []struct {
+		name        string
+		meta        EbuildMetadata
+		filePattern string
+	}{
+		{
+			"mirror",
+			EbuildMetadata{Package: "app-misc/foobar", Version: "1.9.4", SrcURI: "mirror://sourceforge/${PN}/${P}.tar.gz"},
+			`(?i)^foobar-(\d[0-9A-Za-z._+-]*?)\.tar\.gz$`,
+		},
+		{
+			"downloads",
+			EbuildMetadata{Package: "app-misc/foobar", Version: "1.9.4", SrcURI: "https://downloads.sourceforge.net/project/foobar/foobar/1.9.4/foobar-1.9.4.tar.gz"},
+			`(?i)^foobar-(\d[0-9A-Za-z._+-]*?)\.tar\.gz$`,
+		},
+		{
+			"project homepage",
+			EbuildMetadata{Package: "app-misc/foobar", Version: "1.9.4", Homepage: "https://sourceforge.net/projects/foobar/"},
+			`(?i)` + releaseFilePattern("foobar"),
+		},
+		{
+			"project web",
+			EbuildMetadata{Package: "app-misc/foobar", Version: "1.9.4", Homepage: "https://foobar.sourceforge.net/"},
+			`(?i)` + releaseFilePattern("foobar"),
+		},
+		{
+			"unknown variable",
+			EbuildMetadata{Package: "app-misc/foobar", Version: "1.9.4", Homepage: "https://foobar.sourceforge.io", SrcURI: "mirror://sourceforge/foobar/${MY_P}.tar.gz"},
+			`(?i)` + releaseFilePattern("foobar"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found *DataSource
+			for _, s := range DiscoverDataSources(&tt.meta, "") {
+				if s.Type == "homepage" {
+					t.Errorf("SourceForge homepage added as a homepage source: %+v", s)
+				}
+				if s.Type == "sourceforge" {
+					found = &s
+				}
+			}
+			if found == nil || found.URL != want {
+				t.Fatalf("sourceforge source = %+v, want %s", found, want)
+			}
+			schema, err := (&Analyzer{}).analyzeContent(nil, []byte(sampleSourceForgeRSS), &tt.meta, "", found)
+			if err != nil || schema.Parser != "xml" || schema.XPath != sourceForgeXPath || schema.FilePattern != tt.filePattern {
+				t.Fatalf("analyzeContent() = %+v, %v; want xml with file_pattern %s", schema, err, tt.filePattern)
+			}
+			if err := ValidatePackageConfig(tt.meta.Package, schema); err != nil {
+				t.Errorf("ValidatePackageConfig() = %v", err)
+			}
+			parser, err := NewParserFromConfig(schema)
+			if err != nil {
+				t.Fatalf("NewParserFromConfig() error = %v", err)
+			}
+			if got, err := parser.Parse([]byte(sampleSourceForgeRSS)); err != nil || got != "2.1.0" {
+				t.Errorf("Parse() = %q, %v; want 2.1.0", got, err)
+			}
+		})
+	}
+
+	meta := EbuildMetadata{Package: "app-misc/foobar", Homepage: "https://www.sourceforge.net/"}
+	for _, s := range DiscoverDataSources(&meta, "") {
+		if s.Type == "sourceforge" {
+			t.Errorf("www.sourceforge.net taken for a project: %+v", s)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package autoupdate provides SRC_URI reachability probing for version checks.
+package autoupdate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sourceProbeTimeout bounds the single HEAD request of a SRC_URI probe. The
+// probe only asks "is anyone home", so it gets far less than a version fetch.
+const sourceProbeTimeout = 15 * time.Second
+
+// WithSourceProbe makes CheckPackage HEAD the current ebuild's SRC_URI and
+// flag an unreachable source host on the result (see
+// CheckResult.SourceUnreachable). Off by default: it costs one extra request
+// per package, to a host other than the version source.
+func WithSourceProbe(enabled bool) CheckerOption {
+	return func(c *Checker) error {
+		c.sourceProbe = enabled
+		return nil
+	}
+}
+
+// probeSource checks that the distfile host of pkg's current ebuild answers,
+// recording the outcome on result. It never fails the check: a version found
+// upstream is still reported, the note just warns the bump may not fetch.
+//
+// The first http(s) URL of SRC_URI is HEADed after expanding ${PN}, ${PV} and
+// ${P}. When other variables remain, only the host root is probed, so just a
+// dead host counts, not a 404 for a URL that was never fully resolved.
+// mirror:// URIs are resolved by Portage's mirror list and are not probed.
+//
+// The probe uses a plain one-shot client rather than the checker's retrying
+// one: a dead mirror must not burn the retry budget or trip the circuit
+// breaker that guards the version sources. It still waits on the per-host
+// rate limiter.
+func (c *Checker) probeSource(pkg string, result *CheckResult) {
+	meta, err := ExtractEbuildMetadata(c.overlayPath, pkg)
+	if err != nil || meta.SrcURI == "" {
+		return
+	}
+	probeURL, exact := sourceProbeURL(meta.SrcURI, pkg, meta.Version)
+	if probeURL == "" {
+		return
+	}
+
+	u, err := url.Parse(probeURL)
+	if err != nil {
+		return
+	}
+	if err := c.rateLimiter.WaitHTTP(c.ctx, u.Host); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, sourceProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, probeURL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("User-Agent", defaultUserAgent())
+
+	client := &http.Client{Timeout: sourceProbeTimeout}
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		if c.ctx.Err() != nil {
+			return // cancelled run, not a dead host
+		}
+		result.SourceUnreachable = true
+		result.SourceNote = fmt.Sprintf("SRC_URI host %s unreachable: %v", u.Host, err)
+		return
+	}
+	_ = resp.Body.Close()
+
+	if sourceStatusUnreachable(resp.StatusCode, exact) {
+		result.SourceUnreachable = true
+		result.SourceNote = fmt.Sprintf("SRC_URI %s returned HTTP %d", probeURL, resp.StatusCode)
+	}
+}
+
+// sourceStatusUnreachable classifies a probe response. Server errors always
+// count; a 404/410 counts only for a fully expanded distfile URL. 405 and 501
+// mean the server merely refuses HEAD, which says nothing about the file.
+func sourceStatusUnreachable(status int, exact bool) bool {
+	switch {
+	case status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented:
+		return false
+	case status >= 500:
+		return true
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return exact
+	}
+	return false
+}
+
+// sourceProbeURL picks the URL to probe from a SRC_URI value: the first
+// http(s) URI with ${PN}, ${PV} and ${P} expanded (exact = true), or that
+// URI's host root when other variables remain (exact = false). It returns ""
+// when SRC_URI has no http(s) URI.
+func sourceProbeURL(srcURI, pkg, version string) (string, bool) {
+	pn := pkg[strings.LastIndex(pkg, "/")+1:]
+	pv := revisionSuffixRe.ReplaceAllString(version, "")
+	expand := strings.NewReplacer("${PN}", pn, "${PV}", pv, "${P}", pn+"-"+pv)
+
+	for _, token := range strings.Fields(srcURI) {
+		if !strings.HasPrefix(token, "http://") && !strings.HasPrefix(token, "https://") {
+			continue
+		}
+		expanded := expand.Replace(token)
+		resolved, _, unresolved := strings.Cut(expanded, "$")
+		if !unresolved {
+			return expanded, true
+		}
+		// Only the host is trustworthy now, and only if the variable comes
+		// after it: "https://${MY_HOST}/..." cannot be probed at all.
+		scheme, rest, _ := strings.Cut(resolved, "://")
+		host, _, complete := strings.Cut(rest, "/")
+		if !complete || host == "" {
+			return "", false
+		}
+		return scheme + "://" + host + "/", false
+	}
+	return "", false
+}
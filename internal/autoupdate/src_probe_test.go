@@ -0,0 +1,111 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// checkWithSourceProbe checks app-misc/tool 1.0.0, whose ebuild fetches from
+// srcURI, against a version source that reports 1.2.0.
+func checkWithSourceProbe(t *testing.T, srcURI string) *CheckResult {
+	t.Helper()
+	versionSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"1.2.0"}`))
+	}))
+	t.Cleanup(versionSrv.Close)
+
+	content := `["app-misc/tool"]
+url = "` + versionSrv.URL + `"
+parser = "json"
+path = "version"
+`
+	overlay, _ := writePackagesTOML(t, content)
+	createTestEbuildFileWithContent(t, overlay, "app-misc/tool", "1.0.0",
+		"EAPI=8\nSRC_URI=\""+srcURI+"\"\nSLOT=\"0\"\n")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithSourceProbe(true),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-misc/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if !result.HasUpdate || result.UpstreamVersion != "1.2.0" {
+		t.Errorf("update not detected: %q (HasUpdate %v)", result.UpstreamVersion, result.HasUpdate)
+	}
+	return result
+}
+
+// TestSourceProbe_UnreachableHost verifies a dead SRC_URI host is flagged
+// while the update from the working version source is still detected.
+func TestSourceProbe_UnreachableHost(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+
+	result := checkWithSourceProbe(t, deadURL+"/${P}.tar.gz")
+	if !result.SourceUnreachable || !strings.Contains(result.SourceNote, "unreachable") {
+		t.Errorf("SourceUnreachable = %v (%q), want flagged", result.SourceUnreachable, result.SourceNote)
+	}
+}
+
+// TestSourceProbe_MissingDistfile verifies a 404 for the expanded distfile is
+// flagged, and an answering host is not.
+func TestSourceProbe_MissingDistfile(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		probed string
+	)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		probed = r.Method + " " + r.URL.Path
+		mu.Unlock()
+		if r.URL.Path == "/dist/tool-1.0.0.tar.gz" {
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(mirror.Close)
+
+	result := checkWithSourceProbe(t, mirror.URL+"/dist/${P}.tar.gz")
+	if result.SourceUnreachable {
+		t.Errorf("reachable distfile flagged: %s", result.SourceNote)
+	}
+	mu.Lock()
+	if probed != "HEAD /dist/tool-1.0.0.tar.gz" {
+		t.Errorf("probed %q, want HEAD of the expanded distfile", probed)
+	}
+	mu.Unlock()
+
+	result = checkWithSourceProbe(t, mirror.URL+"/gone/${PN}-${PV}.tar.gz")
+	if !result.SourceUnreachable || !strings.Contains(result.SourceNote, "404") {
+		t.Errorf("SourceUnreachable = %v (%q), want a 404 note", result.SourceUnreachable, result.SourceNote)
+	}
+}
+
+// TestSourceProbeURL covers URL selection and variable expansion.
+func TestSourceProbeURL(t *testing.T) {
+	tests := []struct {
+		src, want string
+		exact     bool
+	}{
+		{"mirror://gentoo/${P}.tar.gz https://example.com/${PN}/${PV}.tgz", "https://example.com/tool/1.0.0.tgz", true},
+		{"https://example.com/${MY_P}.tgz -> ${P}.tgz", "https://example.com/", false},
+		{"https://${MY_HOST}/x.tgz", "", false},
+		{"mirror://sourceforge/tool/${P}.zip", "", false},
+	}
+	for _, tt := range tests {
+		got, exact := sourceProbeURL(tt.src, "app-misc/tool", "1.0.0-r2")
+		if got != tt.want || exact != tt.exact {
+			t.Errorf("sourceProbeURL(%q) = %q, %v; want %q, %v", tt.src, got, exact, tt.want, tt.exact)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package autoupdate provides staleness ranking of check results.
+package autoupdate
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// numericVersionRe captures the leading dotted numeric components of a
+// version ("1.2.3" of "1.2.3_rc1").
+var numericVersionRe = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)*`)
+
+// VersionDelta is how far one version is behind another, measured at the
+// first component that differs. Only that component is set: 1.9.4 → 3.0.0 is
+// two majors behind, not two majors and "-9" minors. A component past the
+// patch level (1.2.3.4 → 1.2.3.7) counts as one patch.
+type VersionDelta struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// String renders the delta as "+2 major", "+1 minor", "+3 patch", or "~" when
+// the versions differ only past their numeric components.
+func (d VersionDelta) String() string {
+	switch {
+	case d.Major > 0:
+		return fmt.Sprintf("+%d major", d.Major)
+	case d.Minor > 0:
+		return fmt.Sprintf("+%d minor", d.Minor)
+	case d.Patch > 0:
+		return fmt.Sprintf("+%d patch", d.Patch)
+	}
+	return "~"
+}
+
+// compare orders deltas major-weighted: any major gap outranks any minor gap,
+// which outranks any patch gap.
+func (d VersionDelta) compare(o VersionDelta) int {
+	for _, pair := range [][2]int{{d.Major, o.Major}, {d.Minor, o.Minor}, {d.Patch, o.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] > pair[1] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// RankedResult is a check result with how far behind upstream it is.
+type RankedResult struct {
+	CheckResult
+	// Delta is the version gap between CurrentVersion and UpstreamVersion.
+	Delta VersionDelta
+	// Age is how long the upstream version has been out, from
+	// CheckResult.ReleasedAt. Zero when the source reported no date.
+	Age time.Duration
+}
+
+// RankByStaleness returns the results that have an update, most stale first.
+//
+// Results are ordered by Delta (major-weighted), then by Age so that of two
+// packages equally far behind, the one whose upstream release has been waiting
+// longer comes first; a package without a release date sorts after dated ones
+// with the same delta. Package name breaks the remaining ties, keeping the
+// output deterministic.
+func RankByStaleness(results []CheckResult) []RankedResult {
+	return rankByStaleness(results, time.Now())
+}
+
+// rankByStaleness is RankByStaleness with an injectable clock.
+func rankByStaleness(results []CheckResult, now time.Time) []RankedResult {
+	ranked := make([]RankedResult, 0, len(results))
+	for _, r := range results {
+		if !r.HasUpdate {
+			continue
+		}
+		entry := RankedResult{
+			CheckResult: r,
+			Delta:       versionDelta(r.CurrentVersion, r.UpstreamVersion),
+		}
+		if !r.ReleasedAt.IsZero() {
+			entry.Age = now.Sub(r.ReleasedAt)
+		}
+		ranked = append(ranked, entry)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if c := a.Delta.compare(b.Delta); c != 0 {
+			return c > 0
+		}
+		if a.Age != b.Age {
+			return a.Age > b.Age
+		}
+		return a.Package < b.Package
+	})
+	return ranked
+}
+
+// versionDelta computes how far current is behind upstream. Prefixes such as
+// "v" and the ebuild revision are ignored; versions without numeric components
+// yield a zero delta.
+func versionDelta(current, upstream string) VersionDelta {
+	cur := numericComponents(revisionSuffixRe.ReplaceAllString(current, ""))
+	up := numericComponents(stripVersionPrefix(upstream))
+
+	for i := 0; i < len(cur) || i < len(up); i++ {
+		var c, u int
+		if i < len(cur) {
+			c = cur[i]
+		}
+		if i < len(up) {
+			u = up[i]
+		}
+		if c == u {
+			continue
+		}
+		if u < c {
+			return VersionDelta{}
+		}
+		switch i {
+		case 0:
+			return VersionDelta{Major: u - c}
+		case 1:
+			return VersionDelta{Minor: u - c}
+		case 2:
+			return VersionDelta{Patch: u - c}
+		default:
+			return VersionDelta{Patch: 1}
+		}
+	}
+	return VersionDelta{}
+}
+
+// numericComponents returns the leading dotted integers of v.
+func numericComponents(v string) []int {
+	m := numericVersionRe.FindString(v)
+	if m == "" {
+		return nil
+	}
+	parts := strings.Split(m, ".")
+	nums := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+// FormatStaleness writes ranked as an aligned table, one package per line,
+// with the delta and, when known, the release age in days.
+func FormatStaleness(w io.Writer, ranked []RankedResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tCURRENT\tUPSTREAM\tBEHIND\tAGE")
+	for _, r := range ranked {
+		age := "-"
+		if r.Age > 0 {
+			age = fmt.Sprintf("%dd", int(r.Age.Hours()/24))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Package, r.CurrentVersion, r.UpstreamVersion, r.Delta, age)
+	}
+	_ = tw.Flush()
+}
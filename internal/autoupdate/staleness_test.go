@@ -0,0 +1,61 @@
+package autoupdate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRankByStaleness_MajorOutranksMinor verifies a package two majors behind
+// ranks above one a minor behind, and up-to-date packages are dropped.
+func TestRankByStaleness_MajorOutranksMinor(t *testing.T) {
+	results := []CheckResult{
+		{Package: "app-misc/minor", CurrentVersion: "1.4.0", UpstreamVersion: "1.5.0", HasUpdate: true},
+		{Package: "app-misc/current", CurrentVersion: "2.0.0", UpstreamVersion: "2.0.0"},
+		{Package: "app-misc/major", CurrentVersion: "1.9.4-r1", UpstreamVersion: "v3.0.0", HasUpdate: true},
+	}
+
+	ranked := RankByStaleness(results)
+	if len(ranked) != 2 {
+		t.Fatalf("RankByStaleness() returned %d results, want 2", len(ranked))
+	}
+	if ranked[0].Package != "app-misc/major" || ranked[1].Package != "app-misc/minor" {
+		t.Errorf("order = %s, %s; want app-misc/major, app-misc/minor", ranked[0].Package, ranked[1].Package)
+	}
+	if want := (VersionDelta{Major: 2}); ranked[0].Delta != want {
+		t.Errorf("major delta = %+v, want %+v", ranked[0].Delta, want)
+	}
+	if want := (VersionDelta{Minor: 1}); ranked[1].Delta != want {
+		t.Errorf("minor delta = %+v, want %+v", ranked[1].Delta, want)
+	}
+}
+
+// TestRankByStaleness_AgeBreaksTies verifies that with equal deltas the older
+// upstream release ranks first, and an undated one last.
+func TestRankByStaleness_AgeBreaksTies(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	results := []CheckResult{
+		{Package: "app-misc/undated", CurrentVersion: "1.0.0", UpstreamVersion: "1.1.0", HasUpdate: true},
+		{Package: "app-misc/recent", CurrentVersion: "1.0.0", UpstreamVersion: "1.1.0", HasUpdate: true,
+			ReleasedAt: now.AddDate(0, 0, -3)},
+		{Package: "app-misc/old", CurrentVersion: "2.3.0", UpstreamVersion: "2.4.0", HasUpdate: true,
+			ReleasedAt: now.AddDate(0, 0, -90)},
+	}
+
+	ranked := rankByStaleness(results, now)
+	var got []string
+	for _, r := range ranked {
+		got = append(got, r.Package)
+	}
+	want := "app-misc/old,app-misc/recent,app-misc/undated"
+	if strings.Join(got, ",") != want {
+		t.Errorf("order = %v, want %s", got, want)
+	}
+
+	var buf bytes.Buffer
+	FormatStaleness(&buf, ranked)
+	if out := buf.String(); !strings.Contains(out, "+1 minor") || !strings.Contains(out, "90d") {
+		t.Errorf("FormatStaleness() output missing delta or age:\n%s", out)
+	}
+}
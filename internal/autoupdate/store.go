@@ -0,0 +1,156 @@
+// Package autoupdate provides the persistence backends for the autoupdate
+// cache and pending state.
+package autoupdate
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/obentoo/bentoolkit/internal/common/fileutil"
+)
+
+// Store names of the documents the Checker persists.
+const (
+	cacheStoreName        = "cache.json"
+	pendingStoreName      = "pending.json"
+	contentCacheStoreName = contentCacheFileName
+)
+
+// Store persists the version cache, the pending list and the content cache
+// as named documents. Each holder marshals its whole state into one document
+// and saves it after every change (the content cache once per check or batch,
+// see ContentCache.Flush), so a Store only ever reads and replaces whole
+// documents; it never sees partial updates.
+//
+// FileStore, the default, keeps each document as a file in the config
+// directory. MemoryStore keeps them in memory, for tests and for runs that
+// must not touch the disk. Another backend (SQLite, a shared directory, a
+// key-value service) is wired by passing it to WithStore.
+type Store interface {
+	// Load returns the document saved under name. A name never saved
+	// returns an error matching fs.ErrNotExist, which the holders treat as
+	// empty state rather than a failure.
+	Load(name string) ([]byte, error)
+	// Save replaces the document saved under name. A failed Save must leave
+	// the previous document intact.
+	Save(name string, data []byte) error
+}
+
+// FileStore is the Store that keeps each document as a file in a directory.
+// Saves are atomic (a temporary file synced and renamed into place) and use
+// fileutil.CacheFileMode, since the documents may hold sensitive upstream
+// metadata. Each save has a temporary file of its own, so concurrent saves
+// of one document, from two holders sharing the directory or two bentoo
+// runs, never tear it: the last rename wins with a whole document.
+type FileStore struct {
+	// dir is the directory the documents are kept in
+	dir string
+}
+
+// NewFileStore returns a FileStore keeping its documents in dir. The
+// directory is created on the first Save when missing.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Load reads the file name in the store's directory.
+func (s *FileStore) Load(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, name))
+}
+
+// Save writes data to the file name in the store's directory.
+func (s *FileStore) Save(name string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+	path := filepath.Join(s.dir, name)
+
+	// Write to a unique temp file first, synced so a crash cannot leave
+	// the renamed file empty, then rename for atomicity.
+	tmp, err := os.CreateTemp(s.dir, name+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath) //nolint:errcheck
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Clean up temp file on rename failure
+		os.Remove(tmpPath) //nolint:errcheck
+		return fmt.Errorf("failed to rename %s: %w", name, err)
+	}
+
+	// os.Rename keeps the temp file's mode, which umask may have widened.
+	// Re-apply the restrictive mode; tolerate filesystems without chmod.
+	if err := fileutil.SafeChmod(path, fileutil.CacheFileMode, warnLogger{}); err != nil {
+		return fmt.Errorf("failed to set %s permissions: %w", name, err)
+	}
+	return nil
+}
+
+// MemoryStore is a Store that keeps its documents in memory. It is safe for
+// concurrent use, and may be shared by several holders, or several Checkers,
+// to give them common state for the lifetime of the process.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	docs map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{docs: make(map[string][]byte)}
+}
+
+// Load returns a copy of the document saved under name.
+func (s *MemoryStore) Load(name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.docs[name]
+	if !ok {
+		return nil, fmt.Errorf("memory store: %s: %w", name, fs.ErrNotExist)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Save stores a copy of data under name.
+func (s *MemoryStore) Save(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// WithStore persists the Checker's version cache, pending list and content
+// cache in store instead of files in the config directory. A Cache or
+// PendingList passed with WithCache or WithPendingList keeps its own store.
+// A nil store is ignored. The check history log is not a Store document and
+// stays a file, off unless WithHistoryLog is also given.
+func WithStore(store Store) CheckerOption {
+	return func(c *Checker) error {
+		if store != nil {
+			c.store = store
+		}
+		return nil
+	}
+}
+
+// contentCacheOptions returns the options the Checker opens its content
+// cache with.
+func (c *Checker) contentCacheOptions() []ContentCacheOption {
+	if c.store == nil {
+		return nil
+	}
+	return []ContentCacheOption{WithContentStore(c.store)}
+}
@@ -0,0 +1,195 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// storeFactories returns one constructor per Store implementation, so every
+// property below is checked against both the file store and the in-memory
+// one.
+func storeFactories() map[string]func(t *testing.T) Store {
+	return map[string]func(t *testing.T) Store{
+		"file":   func(t *testing.T) Store { return NewFileStore(filepath.Join(t.TempDir(), "state")) },
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	}
+}
+
+// TestStore_RoundTrip verifies a saved document loads back unchanged, a save
+// replaces it, and a name never saved reports fs.ErrNotExist.
+func TestStore_RoundTrip(t *testing.T) {
+	for name, newStore := range storeFactories() {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			if _, err := store.Load("missing.json"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Load(missing) error = %v, want fs.ErrNotExist", err)
+			}
+
+			data := []byte(`{"a":1}`)
+			if err := store.Save("doc.json", data); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			data[2] = 'b' // the store must not alias the caller's slice
+			if got, err := store.Load("doc.json"); err != nil || string(got) != `{"a":1}` {
+				t.Errorf("Load() = %q, %v; want the saved document", got, err)
+			}
+
+			if err := store.Save("doc.json", []byte(`{}`)); err != nil {
+				t.Fatalf("Save(replace) error = %v", err)
+			}
+			if got, _ := store.Load("doc.json"); string(got) != `{}` {
+				t.Errorf("Load() after replace = %q, want {}", got)
+			}
+		})
+	}
+}
+
+// TestStore_CachePersistence verifies cache entries survive a reload from
+// the same store, compressed or not, and that a corrupted document resets
+// the cache instead of failing.
+func TestStore_CachePersistence(t *testing.T) {
+	now := time.Now()
+	for name, newStore := range storeFactories() {
+		for _, compress := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/compress=%v", name, compress), func(t *testing.T) {
+				store := newStore(t)
+				opts := []CacheOption{WithCacheStore(store), WithCompression(compress), WithNowFunc(func() time.Time { return now })}
+
+				cache, err := NewCache(t.TempDir(), opts...)
+				if err != nil {
+					t.Fatalf("NewCache() error = %v", err)
+				}
+				if err := cache.Set("app-misc/foo", "1.2.3", "https://example.com"); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+
+				reloaded, err := NewCache(t.TempDir(), opts...)
+				if err != nil {
+					t.Fatalf("NewCache(reload) error = %v", err)
+				}
+				if version, ok := reloaded.Get("app-misc/foo"); !ok || version != "1.2.3" {
+					t.Errorf("Get() after reload = %q, %v; want 1.2.3", version, ok)
+				}
+
+				if err := store.Save(cacheStoreName, []byte("not json")); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+				reset, err := NewCache(t.TempDir(), opts...)
+				if err != nil {
+					t.Fatalf("NewCache(corrupted) error = %v", err)
+				}
+				if _, ok := reset.Get("app-misc/foo"); ok {
+					t.Error("corrupted cache document was not reset")
+				}
+			})
+		}
+	}
+}
+
+// TestStore_PendingPersistence verifies pending updates survive a reload
+// from the same store and a corrupted document resets the list.
+func TestStore_PendingPersistence(t *testing.T) {
+	for name, newStore := range storeFactories() {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			pending, err := NewPendingList(t.TempDir(), WithPendingStore(store))
+			if err != nil {
+				t.Fatalf("NewPendingList() error = %v", err)
+			}
+			if err := pending.Add(PendingUpdate{Package: "app-misc/foo", CurrentVersion: "1.0", NewVersion: "1.1"}); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			reloaded, err := NewPendingList(t.TempDir(), WithPendingStore(store))
+			if err != nil {
+				t.Fatalf("NewPendingList(reload) error = %v", err)
+			}
+			if update, ok := reloaded.Get("app-misc/foo"); !ok || update.NewVersion != "1.1" {
+				t.Errorf("Get() after reload = %+v, %v; want 1.1", update, ok)
+			}
+
+			if err := store.Save(pendingStoreName, []byte("{")); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			reset, err := NewPendingList(t.TempDir(), WithPendingStore(store))
+			if err != nil {
+				t.Fatalf("NewPendingList(corrupted) error = %v", err)
+			}
+			if len(reset.List()) != 0 {
+				t.Errorf("corrupted pending document was not reset: %+v", reset.List())
+			}
+		})
+	}
+}
+
+// TestFileStore_AtomicSave verifies the file store leaves no temporary file
+// behind and writes with the cache file mode.
+func TestFileStore_AtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewFileStore(dir).Save(pendingStoreName, []byte("{}")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(dir, pendingStoreName+".tmp*")); len(leftover) > 0 {
+		t.Errorf("temporary files left behind: %v", leftover)
+	}
+	info, err := os.Stat(filepath.Join(dir, pendingStoreName))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("mode = %o, want 600", perm)
+	}
+}
+
+// TestWithStore_SharedAcrossCheckers verifies a Checker given a MemoryStore
+// writes no state into its config directory, and a second Checker on the
+// same store sees the first one's cache and pending list.
+func TestWithStore_SharedAcrossCheckers(t *testing.T) {
+	overlay, _ := writePackagesTOML(t, "")
+	configDir := t.TempDir()
+	store := NewMemoryStore()
+
+	first, err := NewChecker(overlay, WithConfigDir(configDir), WithStore(store), WithContentCache(true))
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	if err := first.cache.Set("app-misc/foo", "2.0", "https://example.com"); err != nil {
+		t.Fatalf("cache.Set() error = %v", err)
+	}
+	if err := first.pending.Add(PendingUpdate{Package: "app-misc/foo", CurrentVersion: "1.0", NewVersion: "2.0"}); err != nil {
+		t.Fatalf("pending.Add() error = %v", err)
+	}
+	if err := first.contentCache.Set("https://example.com", ContentEntry{Body: []byte("2.0"), ETag: `"v2"`}); err != nil {
+		t.Fatalf("contentCache.Set() error = %v", err)
+	}
+	first.flushContentCache()
+
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	for _, e := range entries {
+		t.Errorf("config directory holds %s, want nothing", e.Name())
+	}
+
+	second, err := NewChecker(overlay, WithConfigDir(configDir), WithStore(store), WithContentCache(true))
+	if err != nil {
+		t.Fatalf("NewChecker(second) error = %v", err)
+	}
+	if version, ok := second.cache.Get("app-misc/foo"); !ok || version != "2.0" {
+		t.Errorf("second cache.Get() = %q, %v; want 2.0", version, ok)
+	}
+	if _, ok := second.pending.Get("app-misc/foo"); !ok {
+		t.Error("second pending list misses app-misc/foo")
+	}
+	if entry, ok := second.contentCache.Get("https://example.com"); !ok || entry.ETag != `"v2"` {
+		t.Errorf("second contentCache.Get() = %+v, %v; want ETag \"v2\"", entry, ok)
+	}
+}
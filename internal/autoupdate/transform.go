@@ -3,27 +3,66 @@
 package autoupdate
 
 import (
+	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 )
 
+// namedTransforms are the transform rules that can be named instead of
+// spelled out, as a one-element rule: [["strip-v"], ["dash-to-dot"]] turns
+// "v2024-01-15" into "2024.01.15", [["strip-prefix"], ["underscore-to-dot"]]
+// "release_1_2_3" into "1.2.3". Each is the [regex, repl] rule it names.
+var namedTransforms = map[string][2]string{
+	// strip-v drops a "v" or "V" before the first digit, "v1.2" -> "1.2".
+	"strip-v": {`^[vV]([0-9])`, "$1"},
+	// strip-prefix drops everything before the first digit, such as a tag's
+	// "release_", "version-" or "foo-".
+	"strip-prefix": {`^[^0-9]+`, ""},
+	// underscore-to-dot turns every "_" into ".", "1_2_3" -> "1.2.3".
+	"underscore-to-dot": {`_`, "."},
+	// dash-to-dot turns every "-" into ".", "2024-01-15" -> "2024.01.15".
+	"dash-to-dot": {`-`, "."},
+}
+
+// compileTransform returns the regex and replacement of a transform rule:
+// [regex, repl], or [name] for one of namedTransforms.
+func compileTransform(rule []string) (*regexp.Regexp, string, error) {
+	switch len(rule) {
+	case 1:
+		named, ok := namedTransforms[rule[0]]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown named transform %q (want one of %s)", rule[0], strings.Join(slices.Sorted(maps.Keys(namedTransforms)), ", "))
+		}
+		rule = named[:]
+	case 2:
+	default:
+		return nil, "", fmt.Errorf("rule has %d elements, want 2 ([regex, repl]) or 1 ([name])", len(rule))
+	}
+	re, err := regexp.Compile(rule[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("bad regex %q: %w", rule[0], err)
+	}
+	return re, rule[1], nil
+}
+
 // applyTransforms applies ordered regex substitutions to an extracted version.
-// Each rule is [regex, repl]; repl follows regexp.ReplaceAllString semantics.
-// A malformed rule (wrong arity or uncompilable regex) is warned and skipped,
-// so a single bad rule never aborts a check (ValidatePackageConfig warns too).
+// Each rule is [regex, repl], repl following regexp.ReplaceAllString
+// semantics, or [name] for a named transform (see namedTransforms); named and
+// regex rules chain freely. A malformed rule (wrong arity, unknown name or
+// uncompilable regex) is warned and skipped, so a single bad rule never
+// aborts a check (ValidatePackageConfig warns too).
 func applyTransforms(v string, rules [][]string) string {
 	for _, r := range rules {
-		if len(r) != 2 {
-			continue
-		}
-		re, err := regexp.Compile(r[0])
+		re, repl, err := compileTransform(r)
 		if err != nil {
-			warnLogf("transform: bad regex %q: %v", r[0], err)
+			warnLogf("transform: %v", err)
 			continue
 		}
-		v = re.ReplaceAllString(v, r[1])
+		v = re.ReplaceAllString(v, repl)
 	}
 	return v
 }
@@ -37,13 +76,14 @@ func applyTransforms(v string, rules [][]string) string {
 //   - "last": the last candidate that is a comparable version (document order).
 //
 // Non-comparable candidates (per ebuild.IsValidVersion, after transform and
-// prefix stripping) are skipped. Returns "" when no candidate is comparable.
-func selectVersion(cands []string, transform [][]string, mode string) string {
+// prefix stripping) are skipped, as are candidates outside constraint (nil
+// allows all). Returns "" when no candidate qualifies.
+func selectVersion(cands []string, transform [][]string, mode string, constraint versionConstraint) string {
 	best := ""
 	for _, c := range cands {
 		c = applyTransforms(strings.TrimSpace(c), transform)
 		cc := stripVersionPrefix(c)
-		if !ebuild.IsValidVersion(cc) {
+		if !ebuild.IsValidVersion(cc) || !constraint.allows(cc) {
 			continue
 		}
 		switch mode {
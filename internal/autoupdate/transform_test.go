@@ -46,6 +46,35 @@ func TestApplyTransforms(t *testing.T) {
 	}
 }
 
+// TestApplyTransforms_Named verifies named transforms chain with each other
+// and with regex rules, and that an unknown name is warned and skipped.
+func TestApplyTransforms_Named(t *testing.T) {
+	tests := []struct {
+		in    string
+		rules [][]string
+		want  string
+	}{
+		{"v2024-01-15", [][]string{{"strip-v"}, {"dash-to-dot"}}, "2024.01.15"},
+		{"release_1_2_3", [][]string{{"strip-prefix"}, {"underscore-to-dot"}}, "1.2.3"},
+		{"V1.2", [][]string{{"strip-v"}}, "1.2"},
+		{"version", [][]string{{"strip-v"}}, "version"},
+		{"foo-1_2-rc1", [][]string{{"strip-prefix"}, {"-rc", "_rc"}, {"underscore-to-dot"}, {`\.rc`, "_rc"}}, "1.2_rc1"},
+	}
+	for _, tt := range tests {
+		if got := applyTransforms(tt.in, tt.rules); got != tt.want {
+			t.Errorf("applyTransforms(%q, %v) = %q, want %q", tt.in, tt.rules, got, tt.want)
+		}
+	}
+
+	lc := captureWarnLogs(t)
+	if got := applyTransforms("v1_2", [][]string{{"strip-x"}, {"strip-v"}}); got != "1_2" {
+		t.Errorf("applyTransforms(unknown name) = %q, want 1_2", got)
+	}
+	if len(lc.all()) == 0 {
+		t.Errorf("expected a warning for the unknown name, got none")
+	}
+}
+
 func TestApplyTransforms_BadRegexWarnsAndSkips(t *testing.T) {
 	lc := captureWarnLogs(t)
 	// "[" is an invalid regex: it must be skipped, the valid rule still applies.
@@ -121,7 +150,7 @@ func TestSelectVersion(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := selectVersion(tt.cands, tt.transform, tt.mode); got != tt.want {
+			if got := selectVersion(tt.cands, tt.transform, tt.mode, nil); got != tt.want {
 				t.Fatalf("selectVersion(%v, %v, %q) = %q, want %q",
 					tt.cands, tt.transform, tt.mode, got, tt.want)
 			}
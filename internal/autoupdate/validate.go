@@ -0,0 +1,150 @@
+// Package autoupdate provides the pkgcheck validation of a pending update.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+)
+
+var (
+	// ErrQAToolNotFound is returned by Validate when the QA tool (see
+	// WithApplierQATool) is not installed.
+	ErrQAToolNotFound = errors.New("QA tool not found")
+	// ErrQAToolFailed is returned by Validate when the QA tool fails without
+	// reporting findings, which is how a crash looks; the entry keeps its
+	// status, since nothing is known about the ebuild.
+	ErrQAToolFailed = errors.New("QA tool failed")
+	// ErrValidateGroup is returned by Validate for a group entry, whose
+	// members are bumped together and only checked by the apply's preflight.
+	ErrValidateGroup = errors.New("group entries cannot be validated")
+)
+
+// ValidateResult is the outcome of Validate.
+type ValidateResult struct {
+	// Package is the validated package (category/package)
+	Package string
+	// OldVersion is the overlay's current version, the ebuild copied
+	OldVersion string
+	// NewVersion is the pending version the ebuild was copied to
+	NewVersion string
+	// Valid reports that the QA tool found no errors; the entry is then
+	// marked validated, otherwise failed
+	Valid bool
+	// Output is the QA tool's findings, warnings included
+	Output string
+}
+
+// Validate checks a pending update with pkgcheck before it is applied: the
+// current ebuild is copied to the new version in place, with the commit hash
+// and aux value substituted as Apply would, `pkgcheck scan --exit error` runs
+// on the package, and the copy is removed again. The entry is marked
+// validated when the scan reports no errors and failed, with the findings as
+// its error, otherwise; in that case the returned error wraps ErrQAFailed.
+//
+// The scan runs in the overlay itself because pkgcheck needs the repository
+// around the package (profiles, eclasses, metadata). MissingManifest is
+// disabled, since the Manifest is only regenerated by the apply. An ebuild
+// already present at the new version is scanned as is and left in place.
+//
+// Validate fails without touching the entry when the QA tool is missing
+// (ErrQAToolNotFound) or crashes (ErrQAToolFailed), for an obsolete entry
+// (ErrObsoletePending) and for a group entry (ErrValidateGroup).
+func (a *Applier) Validate(pkg string) (*ValidateResult, error) {
+	result := &ValidateResult{Package: pkg}
+
+	if err := overlay.RequireOverlay(a.overlayPath); err != nil {
+		return result, err
+	}
+	update, found := a.pending.Get(pkg)
+	if !found {
+		return result, ErrPackageNotInPending
+	}
+	if len(update.GroupMembers) > 0 {
+		return result, fmt.Errorf("%w: %s bumps %s", ErrValidateGroup, pkg, strings.Join(update.GroupMembers, ", "))
+	}
+
+	newVersion := stripVersionPrefix(strings.TrimSpace(update.NewVersion))
+	if !ebuild.IsValidVersion(newVersion) {
+		return result, fmt.Errorf("%w: %q (from %q)", ErrInvalidNewVersion, newVersion, update.NewVersion)
+	}
+	result.NewVersion = newVersion
+
+	currentVersion, err := a.resolveCurrentVersion(pkg)
+	if err != nil {
+		return result, fmt.Errorf("%w: %s no longer in overlay (%v)", ErrObsoletePending, pkg, err)
+	}
+	result.OldVersion = currentVersion
+	if ebuild.CompareVersions(currentVersion, newVersion) > 0 {
+		return result, fmt.Errorf("%w: overlay already at %s (target %s)", ErrObsoletePending, currentVersion, newVersion)
+	}
+
+	if _, err := lookPath(a.qaTool); err != nil {
+		return result, fmt.Errorf("%w: %s: %v", ErrQAToolNotFound, a.qaTool, err)
+	}
+
+	newEbuild := a.EbuildPath(pkg, newVersion)
+	if _, err := os.Stat(newEbuild); os.IsNotExist(err) {
+		if err := a.prepareValidationEbuild(pkg, currentVersion, newVersion, update); err != nil {
+			if rmErr := os.Remove(newEbuild); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+				logger.Warn("failed to remove validation ebuild %s: %v", newEbuild, rmErr)
+			}
+			return result, err
+		}
+		defer func() {
+			if err := os.Remove(newEbuild); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.Warn("failed to remove validation ebuild %s: %v", newEbuild, err)
+			}
+		}()
+	}
+
+	findings, scanErr := a.runValidationScan(pkg)
+	result.Output = findings
+	if scanErr != nil && !errors.Is(scanErr, ErrQAFailed) {
+		return result, scanErr
+	}
+
+	result.Valid = scanErr == nil
+	status, errMsg := StatusValidated, ""
+	if !result.Valid {
+		status, errMsg = StatusFailed, scanErr.Error()+":\n"+findings
+	}
+	if err := a.pending.SetStatus(pkg, status, errMsg); err != nil {
+		return result, fmt.Errorf("failed to update status: %w", err)
+	}
+	return result, scanErr
+}
+
+// prepareValidationEbuild writes pkg's ebuild at newVersion the way Apply
+// does: a copy of currentVersion's with the entry's commit hash and aux value
+// substituted.
+func (a *Applier) prepareValidationEbuild(pkg, currentVersion, newVersion string, update *PendingUpdate) error {
+	if err := a.copyEbuild(pkg, currentVersion, newVersion); err != nil {
+		return fmt.Errorf("failed to copy ebuild: %w", err)
+	}
+	dstEbuild := a.EbuildPath(pkg, newVersion)
+	if update.CommitHash != "" {
+		if err := substituteCommitHash(dstEbuild, update.CommitHash); err != nil {
+			return fmt.Errorf("failed to substitute commit hash: %w", err)
+		}
+	}
+	if update.AuxValue != "" {
+		if err := substituteAuxVar(dstEbuild, a.configs[pkg].AuxVar, update.AuxValue); err != nil {
+			return fmt.Errorf("failed to substitute aux var: %w", err)
+		}
+	}
+	return nil
+}
+
+// runValidationScan runs the QA tool on pkg and returns its findings, with
+// pkgcheckScan's errors. The Manifest is not regenerated for a validation, so
+// its MissingManifest results are left out.
+func (a *Applier) runValidationScan(pkg string) (string, error) {
+	return a.pkgcheckScan(filepath.Join(a.overlayPath, pkg), pkg, "--exit", "error", "--keywords=-MissingManifest")
+}
@@ -0,0 +1,168 @@
+package autoupdate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// validateFixture is app-misc/tool 1.0 with a pending bump to 1.1 and the QA
+// tool replaced by script. The tool's argv, and whether the new ebuild
+// existed while it ran, are recorded.
+type validateFixture struct {
+	applier *Applier
+	pending *PendingList
+	argv    []string
+	tool    string
+	sawNew  bool
+}
+
+func newValidateFixture(t *testing.T, script string, opts ...ApplierOption) *validateFixture {
+	t.Helper()
+	stubLookPathFound(t)
+
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+	createTestEbuildFile(t, overlayDir, "app-misc/tool", "1.0")
+
+	pending, _ := NewPendingList(configDir)
+	if err := pending.Add(PendingUpdate{Package: "app-misc/tool", CurrentVersion: "1.0", NewVersion: "v1.1", Status: StatusPending}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	f := &validateFixture{pending: pending}
+	seam := func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		f.tool, f.argv = name, arg
+		_, err := os.Stat(filepath.Join(overlayDir, "app-misc/tool/tool-1.1.ebuild"))
+		f.sawNew = err == nil
+		return exec.CommandContext(ctx, "sh", "-c", script)
+	}
+	opts = append([]ApplierOption{WithApplierPendingList(pending), WithExecCommand(seam)}, opts...)
+	applier, err := NewApplier(overlayDir, configDir, opts...)
+	if err != nil {
+		t.Fatalf("NewApplier: %v", err)
+	}
+	f.applier = applier
+	return f
+}
+
+// TestValidate_Clean verifies a clean scan of the copied ebuild marks the
+// entry validated and removes the copy again.
+func TestValidate_Clean(t *testing.T) {
+	f := newValidateFixture(t, "echo 'app-misc/tool-1.1: WARNING: RedundantVersion'; exit 0")
+
+	result, err := f.applier.Validate("app-misc/tool")
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Valid || result.OldVersion != "1.0" || result.NewVersion != "1.1" {
+		t.Errorf("result = %+v, want a valid 1.0 -> 1.1", result)
+	}
+	if !strings.Contains(result.Output, "RedundantVersion") {
+		t.Errorf("Output = %q, want the warnings", result.Output)
+	}
+	if f.tool != "pkgcheck" || !slices.Contains(f.argv, "app-misc/tool") || !slices.Contains(f.argv, "--keywords=-MissingManifest") {
+		t.Errorf("ran %s %v, want pkgcheck scan of app-misc/tool without MissingManifest", f.tool, f.argv)
+	}
+	if !f.sawNew {
+		t.Error("the new ebuild did not exist while the scan ran")
+	}
+	if _, err := os.Stat(f.applier.EbuildPath("app-misc/tool", "1.1")); !os.IsNotExist(err) {
+		t.Errorf("validation ebuild left in the overlay: %v", err)
+	}
+	if update, _ := f.pending.Get("app-misc/tool"); update.Status != StatusValidated {
+		t.Errorf("status = %s, want validated", update.Status)
+	}
+}
+
+// TestValidate_Errors verifies pkgcheck errors mark the entry failed with the
+// findings.
+func TestValidate_Errors(t *testing.T) {
+	const finding = "app-misc/tool-1.1: ERROR: MissingLicense"
+	f := newValidateFixture(t, "echo '"+finding+"'; exit 1")
+
+	result, err := f.applier.Validate("app-misc/tool")
+	if !errors.Is(err, ErrQAFailed) {
+		t.Fatalf("Validate() error = %v, want %v", err, ErrQAFailed)
+	}
+	if result.Valid || result.Output != finding {
+		t.Errorf("result = %+v, want invalid with the finding", result)
+	}
+	update, _ := f.pending.Get("app-misc/tool")
+	if update.Status != StatusFailed || !strings.Contains(update.Error, "MissingLicense") {
+		t.Errorf("entry = %+v, want failed with the finding", update)
+	}
+	if _, err := os.Stat(f.applier.EbuildPath("app-misc/tool", "1.1")); !os.IsNotExist(err) {
+		t.Errorf("validation ebuild left in the overlay: %v", err)
+	}
+}
+
+// TestValidate_ToolProblems verifies a crashing or missing QA tool leaves the
+// entry's status alone, and that WithApplierQATool picks the binary.
+func TestValidate_ToolProblems(t *testing.T) {
+	f := newValidateFixture(t, "echo 'Traceback' >&2; exit 2", WithApplierQATool("/opt/pkgcore/bin/pkgcheck"))
+	if _, err := f.applier.Validate("app-misc/tool"); !errors.Is(err, ErrQAToolFailed) {
+		t.Errorf("crash: Validate() error = %v, want %v", err, ErrQAToolFailed)
+	}
+	if f.tool != "/opt/pkgcore/bin/pkgcheck" {
+		t.Errorf("ran %q, want the configured tool", f.tool)
+	}
+	if update, _ := f.pending.Get("app-misc/tool"); update.Status != StatusPending {
+		t.Errorf("crash: status = %s, want pending", update.Status)
+	}
+
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	f.tool = ""
+	if _, err := f.applier.Validate("app-misc/tool"); !errors.Is(err, ErrQAToolNotFound) {
+		t.Errorf("missing: Validate() error = %v, want %v", err, ErrQAToolNotFound)
+	}
+	if f.tool != "" {
+		t.Error("the QA tool ran although it is not installed")
+	}
+	if update, _ := f.pending.Get("app-misc/tool"); update.Status != StatusPending {
+		t.Errorf("missing: status = %s, want pending", update.Status)
+	}
+}
+
+// TestValidate_ExistingEbuildKept verifies an ebuild already at the new
+// version is scanned as is and not removed.
+func TestValidate_ExistingEbuildKept(t *testing.T) {
+	f := newValidateFixture(t, "exit 0")
+	createTestEbuildFile(t, f.applier.OverlayPath(), "app-misc/tool", "1.1")
+
+	if _, err := f.applier.Validate("app-misc/tool"); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if _, err := os.Stat(f.applier.EbuildPath("app-misc/tool", "1.1")); err != nil {
+		t.Errorf("existing ebuild removed: %v", err)
+	}
+}
+
+// TestValidate_Rejects covers entries Validate refuses without running the
+// tool.
+func TestValidate_Rejects(t *testing.T) {
+	f := newValidateFixture(t, "exit 0")
+	if _, err := f.applier.Validate("app-misc/other"); !errors.Is(err, ErrPackageNotInPending) {
+		t.Errorf("not pending: error = %v, want %v", err, ErrPackageNotInPending)
+	}
+
+	_ = f.pending.Add(PendingUpdate{Package: "app-misc/group", CurrentVersion: "1.0", NewVersion: "1.1",
+		Group: "app-misc/group*", GroupMembers: []string{"app-misc/group", "app-misc/group-extra"}})
+	if _, err := f.applier.Validate("app-misc/group"); !errors.Is(err, ErrValidateGroup) {
+		t.Errorf("group: error = %v, want %v", err, ErrValidateGroup)
+	}
+
+	createTestEbuildFile(t, f.applier.OverlayPath(), "app-misc/tool", "2.0")
+	if _, err := f.applier.Validate("app-misc/tool"); !errors.Is(err, ErrObsoletePending) {
+		t.Errorf("superseded: error = %v, want %v", err, ErrObsoletePending)
+	}
+	if f.tool != "" {
+		t.Errorf("ran %s for a rejected entry", f.tool)
+	}
+}
@@ -0,0 +1,83 @@
+// Package autoupdate provides installed-version lookup in the Portage vdb.
+package autoupdate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+)
+
+// DefaultVDBPath is where Portage records installed packages, one
+// <category>/<package>-<version> directory each.
+const DefaultVDBPath = "/var/db/pkg"
+
+// WithVDB makes CheckPackage look up the version of the package installed on
+// this system in the Portage vdb rooted at path, and attach it to the result
+// (see CheckResult.InstalledVersion). An empty path, the default, disables the
+// lookup: the overlay is usually checked on a machine that does not install
+// its packages. Tests point path at a fake vdb.
+func WithVDB(path string) CheckerOption {
+	return func(c *Checker) error {
+		c.vdbPath = path
+		return nil
+	}
+}
+
+// installedVersion returns the highest version of pkg ("category/name")
+// installed in the vdb at vdbPath, or "" when none is.
+//
+// Each installed version is a directory named "<name>-<version>" under the
+// category. Directories whose suffix is not a valid Gentoo version are
+// skipped, which also keeps "foo" from matching "foo-bar-1.0" and ignores
+// Portage's transient "-MERGING-" entries. Several versions are installed at
+// once for slotted packages; the highest is reported.
+func installedVersion(vdbPath, pkg string) (string, error) {
+	category, name, ok := strings.Cut(pkg, "/")
+	if !ok || category == "" || name == "" {
+		return "", fmt.Errorf("invalid package name format: %s", pkg)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(vdbPath, category))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read vdb: %w", err)
+	}
+
+	highest := ""
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		version, found := strings.CutPrefix(entry.Name(), name+"-")
+		if !found || !ebuild.IsValidVersion(version) {
+			continue
+		}
+		if highest == "" || ebuild.CompareVersions(version, highest) > 0 {
+			highest = version
+		}
+	}
+	return highest, nil
+}
+
+// attachInstalledVersion records the vdb version of pkg on result and notes
+// when it is ahead of the overlay, which happens after a manual install of a
+// newer release. Like the SRC_URI probe it is context only: an unreadable vdb
+// is logged at debug level and never fails the check.
+func (c *Checker) attachInstalledVersion(pkg string, result *CheckResult) {
+	version, err := installedVersion(c.vdbPath, pkg)
+	if err != nil {
+		logger.Debug("installed version of %s unknown: %v", pkg, err)
+		return
+	}
+	result.InstalledVersion = version
+	if version != "" && ebuild.IsValidVersion(result.CurrentVersion) &&
+		ebuild.CompareVersions(version, result.CurrentVersion) > 0 {
+		result.InstalledAhead = true
+	}
+}
@@ -0,0 +1,125 @@
+package autoupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeVDB creates a vdb with one directory per "category/name-version" entry.
+func fakeVDB(t *testing.T, entries ...string) string {
+	t.Helper()
+	vdb := t.TempDir()
+	for _, entry := range entries {
+		if err := os.MkdirAll(filepath.Join(vdb, entry), 0o755); err != nil {
+			t.Fatalf("failed to create vdb entry: %v", err)
+		}
+	}
+	return vdb
+}
+
+// TestInstalledVersion verifies the highest installed version is found and
+// that similarly named packages and transient entries are ignored.
+func TestInstalledVersion(t *testing.T) {
+	vdb := fakeVDB(t,
+		"app-misc/tool-1.2.0",
+		"app-misc/tool-1.10.0-r1",
+		"app-misc/tool-bin-9.0.0",
+		"app-misc/-MERGING-tool-2.0.0",
+		"app-misc/tool-extras-3.0",
+	)
+
+	tests := []struct {
+		pkg  string
+		want string
+	}{
+		{"app-misc/tool", "1.10.0-r1"},
+		{"app-misc/tool-bin", "9.0.0"},
+		{"app-misc/absent", ""},
+		{"dev-util/tool", ""},
+	}
+	for _, tt := range tests {
+		got, err := installedVersion(vdb, tt.pkg)
+		if err != nil {
+			t.Errorf("installedVersion(%q) error = %v", tt.pkg, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("installedVersion(%q) = %q, want %q", tt.pkg, got, tt.want)
+		}
+	}
+
+	if _, err := installedVersion(vdb, "tool"); err == nil {
+		t.Error("installedVersion() without category: expected error")
+	}
+}
+
+// checkWithVDB checks app-misc/tool 1.0.0 against a version source reporting
+// 1.2.0, reading installed versions from vdb.
+func checkWithVDB(t *testing.T, vdb string) *CheckResult {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"1.2.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, _ := writePackagesTOML(t, `["app-misc/tool"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "version"
+`)
+	createTestEbuild(t, overlay, "app-misc/tool", "1.0.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+		WithVDB(vdb),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("app-misc/tool", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if !result.HasUpdate || result.UpstreamVersion != "1.2.0" {
+		t.Errorf("update not detected: %q (HasUpdate %v)", result.UpstreamVersion, result.HasUpdate)
+	}
+	return result
+}
+
+// TestCheckPackage_InstalledAhead verifies a manual install newer than the
+// overlay is attached to the result and flagged.
+func TestCheckPackage_InstalledAhead(t *testing.T) {
+	result := checkWithVDB(t, fakeVDB(t, "app-misc/tool-1.1.0"))
+	if result.InstalledVersion != "1.1.0" {
+		t.Errorf("InstalledVersion = %q, want 1.1.0", result.InstalledVersion)
+	}
+	if !result.InstalledAhead {
+		t.Error("InstalledAhead = false, want true")
+	}
+}
+
+// TestCheckPackage_InstalledSameAsOverlay verifies the usual case, the
+// overlay version installed, is reported without the ahead flag.
+func TestCheckPackage_InstalledSameAsOverlay(t *testing.T) {
+	result := checkWithVDB(t, fakeVDB(t, "app-misc/tool-1.0.0"))
+	if result.InstalledVersion != "1.0.0" || result.InstalledAhead {
+		t.Errorf("InstalledVersion = %q, InstalledAhead = %v; want 1.0.0, false",
+			result.InstalledVersion, result.InstalledAhead)
+	}
+}
+
+// TestCheckPackage_NotInstalled verifies a missing vdb entry, or a missing
+// vdb, leaves the result untouched rather than failing the check.
+func TestCheckPackage_NotInstalled(t *testing.T) {
+	for _, vdb := range []string{fakeVDB(t), filepath.Join(t.TempDir(), "missing")} {
+		result := checkWithVDB(t, vdb)
+		if result.InstalledVersion != "" || result.InstalledAhead {
+			t.Errorf("vdb %s: InstalledVersion = %q, InstalledAhead = %v; want empty",
+				vdb, result.InstalledVersion, result.InstalledAhead)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package autoupdate provides version range constraints for ebuild autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// ErrInvalidVersionConstraint is returned for a version_constraint that does
+// not parse.
+var ErrInvalidVersionConstraint = errors.New("invalid version constraint")
+
+// versionBound is one comparison of a constraint: the version compared with
+// op (one of >=, >, <=, <, =) against bound. With release set only the
+// version's numeric release is compared (see releaseOf).
+type versionBound struct {
+	op      string
+	bound   string
+	release bool
+}
+
+// versionConstraint is a conjunction of bounds; a nil constraint allows every
+// version.
+type versionConstraint []versionBound
+
+// parseVersionConstraint parses a version_constraint. It accepts
+// whitespace- or comma-separated terms, all of which must hold:
+//
+//   - ">=1.4", ">1.4", "<=1.4", "<1.5", "=1.4.2" compare with Gentoo ordering
+//   - "~1.4" keeps the line: >=1.4 <1.5 ("~1" is >=1 <2, "~1.4.2" is
+//     >=1.4.2 <1.5)
+//   - "^1.4" keeps the major: >=1.4 <2 (for 0.x, the minor: "^0.4" is
+//     >=0.4 <0.5)
+//
+// The empty string is the nil constraint.
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	var vc versionConstraint
+	for _, term := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		bounds, err := parseConstraintTerm(term)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidVersionConstraint, s, err)
+		}
+		vc = append(vc, bounds...)
+	}
+	return vc, nil
+}
+
+// parseConstraintTerm parses one term of a constraint into its bounds.
+func parseConstraintTerm(term string) ([]versionBound, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if !strings.HasPrefix(term, op) {
+			continue
+		}
+		v := stripVersionPrefix(strings.TrimPrefix(term, op))
+		if !ebuild.IsValidVersion(v) {
+			return nil, fmt.Errorf("%q is not a version", v)
+		}
+		switch op {
+		case "~", "^":
+			upper, err := constraintUpperBound(v, op == "^")
+			if err != nil {
+				return nil, err
+			}
+			return []versionBound{{op: ">=", bound: v}, {op: "<", bound: upper, release: true}}, nil
+		default:
+			return []versionBound{{op: op, bound: v}}, nil
+		}
+	}
+	return nil, fmt.Errorf("term %q needs an operator (>=, >, <=, <, =, ~ or ^)", term)
+}
+
+// constraintUpperBound returns the exclusive upper bound of a tilde (caret
+// false) or caret range starting at v.
+func constraintUpperBound(v string, caret bool) (string, error) {
+	nums := releaseComponents(v)
+	if len(nums) == 0 {
+		return "", fmt.Errorf("%q has no numeric components", v)
+	}
+
+	// The component to increment: tilde bumps the minor (the major for a bare
+	// "~1"), caret the first non-zero of major and minor.
+	idx := 0
+	if caret {
+		if nums[0] == 0 && len(nums) > 1 {
+			idx = 1
+		}
+	} else if len(nums) > 1 {
+		idx = 1
+	}
+	parts := make([]string, idx+1)
+	for i := 0; i < idx; i++ {
+		parts[i] = strconv.Itoa(nums[i])
+	}
+	parts[idx] = strconv.Itoa(nums[idx] + 1)
+	return strings.Join(parts, "."), nil
+}
+
+// releaseComponents returns the leading numeric components of v: [1 4 2]
+// for "1.4.2_rc1".
+func releaseComponents(v string) []int {
+	var nums []int
+	for _, part := range strings.Split(v, ".") {
+		i := 0
+		for i < len(part) && part[i] >= '0' && part[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(part[:i])
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+		if i < len(part) {
+			break
+		}
+	}
+	return nums
+}
+
+// releaseOf returns the numeric release of v, "1.5" for "1.5_rc1", so a
+// pre-release of the next line does not slip under a ~ or ^ upper bound.
+func releaseOf(v string) string {
+	nums := releaseComponents(v)
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// allows reports whether v satisfies every bound. v is compared after
+// stripping a "v" prefix; a version Gentoo cannot compare is not allowed by a
+// non-nil constraint.
+func (vc versionConstraint) allows(v string) bool {
+	if len(vc) == 0 {
+		return true
+	}
+	v = stripVersionPrefix(strings.TrimSpace(v))
+	if !ebuild.IsValidVersion(v) {
+		return false
+	}
+	for _, b := range vc {
+		subject := v
+		if b.release {
+			subject = releaseOf(v)
+		}
+		cmp := ebuild.CompareVersions(subject, b.bound)
+		var ok bool
+		switch b.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// constraint returns the package's parsed VersionConstraint. Validation has
+// already rejected one that does not parse; such a value is treated as no
+// constraint here.
+func (c *PackageConfig) constraint() versionConstraint {
+	vc, err := parseVersionConstraint(c.VersionConstraint)
+	if err != nil {
+		warnLogf("%v; ignoring it", err)
+		return nil
+	}
+	return vc
+}
@@ -0,0 +1,87 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestVersionConstraint_Allows covers the tilde, caret and explicit forms,
+// including a pre-release of the next line.
+func TestVersionConstraint_Allows(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		denied     []string
+	}{
+		{"~1.4", []string{"1.4", "1.4.0", "1.4.9", "v1.4.12"}, []string{"1.3.9", "1.5.0", "1.5_rc1", "2.0"}},
+		{"~1.4.2", []string{"1.4.2", "1.4.7"}, []string{"1.4.1", "1.5"}},
+		{"~1", []string{"1.0", "1.9.9"}, []string{"0.9", "2.0"}},
+		{"^1.4", []string{"1.4", "1.9"}, []string{"1.3", "2.0_beta1"}},
+		{"^0.4", []string{"0.4.1"}, []string{"0.5.0"}},
+		{">=1.4, <1.5", []string{"1.4.3"}, []string{"1.5", "1.3"}},
+		{"=1.4.2", []string{"1.4.2"}, []string{"1.4.3"}},
+		{"", []string{"0.1", "99"}, nil},
+	}
+	for _, tt := range tests {
+		vc, err := parseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("parseVersionConstraint(%q) error = %v", tt.constraint, err)
+		}
+		for _, v := range tt.allowed {
+			if !vc.allows(v) {
+				t.Errorf("%q should allow %s", tt.constraint, v)
+			}
+		}
+		for _, v := range tt.denied {
+			if vc.allows(v) {
+				t.Errorf("%q should deny %s", tt.constraint, v)
+			}
+		}
+	}
+}
+
+// TestParseVersionConstraint_Invalid verifies malformed terms are rejected.
+func TestParseVersionConstraint_Invalid(t *testing.T) {
+	for _, s := range []string{"1.4", "~", ">=banana", "~1.4 <"} {
+		if _, err := parseVersionConstraint(s); !errors.Is(err, ErrInvalidVersionConstraint) {
+			t.Errorf("parseVersionConstraint(%q) error = %v, want %v", s, err, ErrInvalidVersionConstraint)
+		}
+	}
+}
+
+// TestCheckPackage_VersionConstraint verifies an LTS package proposes the
+// highest 1.4.x from a tag list spanning 1.3 to 1.6, ignoring the newer lines.
+func TestCheckPackage_VersionConstraint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"name":"v1.6.0"},{"name":"v1.5.2"},{"name":"v1.5.0"},
+			{"name":"v1.4.11"},{"name":"v1.4.9"},{"name":"v1.4.0"},
+			{"name":"v1.3.8"}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, _ := writePackagesTOML(t, `["dev-libs/lts"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "[0].name"
+version_constraint = "~1.4"
+`)
+	createTestEbuild(t, overlay, "dev-libs/lts", "1.4.9")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("dev-libs/lts", true)
+	if err != nil {
+		t.Fatalf("CheckPackage() error = %v", err)
+	}
+	if result.UpstreamVersion != "1.4.11" || !result.HasUpdate {
+		t.Errorf("UpstreamVersion = %q (HasUpdate %v), want 1.4.11", result.UpstreamVersion, result.HasUpdate)
+	}
+}
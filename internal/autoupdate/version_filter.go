@@ -0,0 +1,90 @@
+// Package autoupdate provides stable/exclude version filters for ebuild
+// autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidVersionFilter is returned for a stable_pattern or exclude_pattern
+// that is not a valid regular expression.
+var ErrInvalidVersionFilter = errors.New("invalid version filter")
+
+// versionFilter keeps the versions matching stable (when set) and not
+// matching exclude (when set). A nil filter keeps every version.
+type versionFilter struct {
+	stable  *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// compileVersionFilter compiles a package's stable_pattern and
+// exclude_pattern. Both are unanchored, like pattern; anchor them with ^ and
+// $ to match the whole version. It returns nil when neither is set.
+func compileVersionFilter(stable, exclude string) (*versionFilter, error) {
+	if stable == "" && exclude == "" {
+		return nil, nil
+	}
+	f := &versionFilter{}
+	var err error
+	if stable != "" {
+		if f.stable, err = regexp.Compile(stable); err != nil {
+			return nil, fmt.Errorf("%w: stable_pattern %q: %v", ErrInvalidVersionFilter, stable, err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("%w: exclude_pattern %q: %v", ErrInvalidVersionFilter, exclude, err)
+		}
+	}
+	return f, nil
+}
+
+// allows reports whether version passes the filter.
+func (f *versionFilter) allows(version string) bool {
+	if f == nil {
+		return true
+	}
+	if f.stable != nil && !f.stable.MatchString(version) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.MatchString(version)
+}
+
+// apply returns the candidates that pass the filter, in their original order.
+func (f *versionFilter) apply(cands []string) []string {
+	if f == nil {
+		return cands
+	}
+	kept := make([]string, 0, len(cands))
+	for _, v := range cands {
+		if f.allows(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// versionFilter returns the package's compiled StablePattern and
+// ExcludePattern. Validation has already rejected a pattern that does not
+// compile; such a filter is treated as no filter here.
+func (c *PackageConfig) versionFilter() *versionFilter {
+	f, err := compileVersionFilter(c.StablePattern, c.ExcludePattern)
+	if err != nil {
+		warnLogf("%v; ignoring it", err)
+		return nil
+	}
+	return f
+}
+
+// newCandidateExtractor builds the list extractor behind select,
+// version_constraint and the version filters. A filtered JSON package with
+// versions_path enumerates that list, so a releases array is searched for its
+// highest stable tag; every other package reuses newSelectExtractor.
+func newCandidateExtractor(cfg *PackageConfig, filter *versionFilter) (VersionHistoryExtractor, error) {
+	if filter != nil && cfg.VersionsPath != "" && cfg.Parser == "json" {
+		return &JSONVersionHistoryExtractor{VersionsPath: cfg.VersionsPath, Limit: -1}, nil
+	}
+	return newSelectExtractor(cfg)
+}
@@ -0,0 +1,141 @@
+package autoupdate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestVersionFilter_Allows covers the include and exclude patterns alone and
+// together.
+func TestVersionFilter_Allows(t *testing.T) {
+	tests := []struct {
+		stable, exclude string
+		allowed         []string
+		denied          []string
+	}{
+		{"", `(?i)(alpha|beta|rc)`, []string{"1.9.0", "v2.0"}, []string{"2.0.0-beta1", "2.0-RC2"}},
+		{`^v?[0-9.]+$`, "", []string{"1.9.0", "v2.0"}, []string{"2.0.0-beta1", "nightly"}},
+		{`^v?[0-9.]+$`, `^v?1\.`, []string{"2.0"}, []string{"1.9.0", "2.0rc1"}},
+		{"", "", []string{"anything"}, nil},
+	}
+	for _, tt := range tests {
+		f, err := compileVersionFilter(tt.stable, tt.exclude)
+		if err != nil {
+			t.Fatalf("compileVersionFilter(%q, %q) error = %v", tt.stable, tt.exclude, err)
+		}
+		for _, v := range tt.allowed {
+			if !f.allows(v) {
+				t.Errorf("stable=%q exclude=%q should allow %s", tt.stable, tt.exclude, v)
+			}
+		}
+		for _, v := range tt.denied {
+			if f.allows(v) {
+				t.Errorf("stable=%q exclude=%q should deny %s", tt.stable, tt.exclude, v)
+			}
+		}
+	}
+}
+
+// TestValidatePackageConfig_InvalidVersionFilter verifies an uncompilable
+// pattern is a configuration error.
+func TestValidatePackageConfig_InvalidVersionFilter(t *testing.T) {
+	for _, cfg := range []PackageConfig{
+		{URL: "https://example.com", Parser: "json", Path: "[0].tag_name", StablePattern: "("},
+		{URL: "https://example.com", Parser: "json", Path: "[0].tag_name", ExcludePattern: "[beta"},
+	} {
+		if err := ValidatePackageConfig("app-misc/foo", &cfg); !errors.Is(err, ErrInvalidVersionFilter) {
+			t.Errorf("ValidatePackageConfig(%+v) error = %v, want %v", cfg, err, ErrInvalidVersionFilter)
+		}
+	}
+}
+
+// TestCheckPackage_VersionFilter verifies a releases array whose newest tag
+// is a pre-release proposes the highest stable tag, whether pre-releases are
+// excluded or stable tags included, and that without a filter the path's
+// single value is still proposed as is.
+func TestCheckPackage_VersionFilter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"tag_name":"v2.0.0-beta1"},{"tag_name":"v2.0.0-rc.1"},
+			{"tag_name":"v1.10.0"},{"tag_name":"v1.9.3"},{"tag_name":"v1.9.2"}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{"exclude", `exclude_pattern = "(?i)(alpha|beta|rc)"`, "1.10.0"},
+		{"stable", `stable_pattern = '^v[0-9]+(\.[0-9]+)*$'`, "1.10.0"},
+		{"none", ``, "2.0.0-beta1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay, _ := writePackagesTOML(t, `["dev-libs/foo"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "[0].tag_name"
+versions_path = "[*].tag_name"
+transform = [["^v", ""]]
+`+tt.filter+`
+`)
+			createTestEbuild(t, overlay, "dev-libs/foo", "1.9.3")
+
+			checker, err := NewChecker(overlay,
+				WithConfigDir(t.TempDir()),
+				WithRateLimiter(unlimitedRateLimiter()),
+			)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			result, err := checker.CheckPackage("dev-libs/foo", true)
+			// A pre-release upstream is not comparable; the result still holds it.
+			var cmpErr *ComparisonError
+			if err != nil && !errors.As(err, &cmpErr) {
+				t.Fatalf("CheckPackage() error = %v", err)
+			}
+			if result.UpstreamVersion != tt.want {
+				t.Errorf("UpstreamVersion = %q, want %s", result.UpstreamVersion, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckPackage_VersionFilterRejectsAll verifies a filter that leaves no
+// candidate reports ErrNoVersionFound instead of proposing a pre-release.
+func TestCheckPackage_VersionFilterRejectsAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"tag_name":"3.0-beta2"},{"tag_name":"3.0-beta1"}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	overlay, _ := writePackagesTOML(t, `["dev-libs/foo"]
+url = "`+srv.URL+`"
+parser = "json"
+path = "[0].tag_name"
+versions_path = "[*].tag_name"
+exclude_pattern = "beta"
+`)
+	createTestEbuild(t, overlay, "dev-libs/foo", "2.0")
+
+	checker, err := NewChecker(overlay,
+		WithConfigDir(t.TempDir()),
+		WithRateLimiter(unlimitedRateLimiter()),
+	)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	result, err := checker.CheckPackage("dev-libs/foo", true)
+	if err == nil && result.Error == nil {
+		t.Fatalf("CheckPackage() = %+v, want an error", result)
+	}
+	if err == nil {
+		err = result.Error
+	}
+	if !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("CheckPackage() error = %v, want %v", err, ErrNoVersionFound)
+	}
+}
@@ -57,7 +57,7 @@ func (e *JSONVersionHistoryExtractor) ExtractVersions(content []byte) ([]string,
 
 	// Parse JSON into generic interface
 	var data interface{}
-	if err := json.Unmarshal(content, &data); err != nil {
+	if err := json.Unmarshal(unwrapJSONP(content), &data); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
@@ -77,7 +77,18 @@ func (e *JSONVersionHistoryExtractor) ExtractVersions(content []byte) ([]string,
 
 // extractVersionsFromPath extracts versions from JSON data using the configured path.
 func (e *JSONVersionHistoryExtractor) extractVersionsFromPath(data interface{}) ([]string, error) {
-	path := e.VersionsPath
+	path, fn, err := splitJSONPathFunc(e.VersionsPath)
+	if err != nil {
+		return nil, err
+	}
+	if fn != "" {
+		// A reducing function yields a single version, e.g. "releases | max".
+		version, err := evalJSONPathFunc(data, path, fn)
+		if err != nil {
+			return nil, err
+		}
+		return []string{version}, nil
+	}
 	lim := effectiveLimit(e.Limit)
 
 	// Handle wildcard array path: [*].field or [*]
@@ -369,6 +380,17 @@ func newSelectExtractor(cfg *PackageConfig) (VersionHistoryExtractor, error) {
 			return &XPathVersionHistoryExtractor{VersionsXPath: cfg.XPath, Regex: cfg.Pattern, Limit: -1}, nil
 		}
 		return &HTMLVersionHistoryExtractor{VersionsSelector: cfg.Selector, Regex: cfg.Pattern, Limit: -1}, nil
+	case "git-tags":
+		return NewGitTagsParser(cfg.Pattern)
+	case "fileindex":
+		return NewFileIndexParser(cfg.FilePattern)
+	case "xml":
+		// Only a feed of release files lists versions; a plain xml schema
+		// names one.
+		if cfg.FilePattern != "" {
+			return NewXMLFileParser(cfg.XPath, cfg.FilePattern)
+		}
+		return nil, nil
 	default:
 		return nil, nil // not list-capable (e.g. "script")
 	}
@@ -0,0 +1,117 @@
+// Package autoupdate provides WordPress plugin readme.txt support for ebuild
+// autoupdate.
+package autoupdate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// readmeStableTag is the readme.txt header naming the released version.
+const readmeStableTag = "Stable tag"
+
+// PriorityWordPress is the priority for a WordPress plugin's readme.txt
+const PriorityWordPress = 20
+
+// Regular expressions for WordPress plugin URL matching
+var (
+	// wordpressPluginRegex matches plugin directory pages:
+	// wordpress.org/plugins/<slug>/
+	wordpressPluginRegex = regexp.MustCompile(`wordpress\.org/plugins/([a-z0-9][a-z0-9-]*)`)
+	// wordpressDownloadRegex matches plugin zips:
+	// downloads.wordpress.org/plugin/<slug>.<version>.zip
+	wordpressDownloadRegex = regexp.MustCompile(`downloads\.wordpress\.org/plugin/([a-z0-9][a-z0-9-]*)\.`)
+)
+
+// ReadmeTxtParser reads the version of a WordPress-style readme.txt from its
+// "Stable tag:" header. Only the header block is read, from the
+// "=== Name ===" line to the first "== Section ==", so a "Stable tag" in the
+// description or changelog is not taken for it, and the other headers
+// ("Requires at least", "Tested up to", "Requires PHP") never are.
+//
+// A plugin released straight from trunk declares "Stable tag: trunk"; that,
+// or a readme without the header, reads Field instead when it is set (for
+// instance "Version", which some plugins repeat in their readme).
+type ReadmeTxtParser struct {
+	// Field is the header read when Stable tag is missing or "trunk"; empty
+	// disables the fallback
+	Field string
+}
+
+// Parse returns the Stable tag, or the Field header when that is missing or
+// "trunk".
+func (p *ReadmeTxtParser) Parse(content []byte) (string, error) {
+	headers, err := readmeHeaders(content)
+	if err != nil {
+		return "", err
+	}
+	if tag := headers[strings.ToLower(readmeStableTag)]; tag != "" && !strings.EqualFold(tag, "trunk") {
+		return tag, nil
+	}
+	if p.Field != "" {
+		if v := headers[strings.ToLower(p.Field)]; v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%w: no usable %s or %s header", ErrNoVersionFound, readmeStableTag, p.Field)
+	}
+	return "", fmt.Errorf("%w: no usable %s header", ErrNoVersionFound, readmeStableTag)
+}
+
+// readmeHeaders returns the "Name: value" headers of a readme.txt, keyed by
+// lower-cased name. Markdown emphasis around the name, as in a readme.md's
+// "**Stable tag:** 1.2.3", is ignored.
+func readmeHeaders(content []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// "=== Name ===" opens the header block; "== Section ==" ends it.
+		if strings.HasPrefix(line, "==") && !strings.HasPrefix(line, "===") {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.Trim(name, "*# \t")
+		value = strings.TrimSpace(strings.Trim(strings.TrimSpace(value), "*"))
+		if name == "" || value == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, seen := headers[key]; !seen {
+			headers[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read readme: %w", err)
+	}
+	return headers, nil
+}
+
+// discoverWordPressSource finds the trunk readme.txt of a WordPress plugin
+// from its plugin directory homepage or a downloads.wordpress.org SRC_URI.
+// The plugin's SVN trunk carries the readme that names the current stable
+// tag.
+func discoverWordPressSource(meta *EbuildMetadata) *DataSource {
+	if matches := wordpressPluginRegex.FindStringSubmatch(meta.Homepage); matches != nil {
+		return createWordPressSource(matches[1])
+	}
+	if matches := wordpressDownloadRegex.FindStringSubmatch(meta.SrcURI); matches != nil {
+		return createWordPressSource(matches[1])
+	}
+	return nil
+}
+
+// createWordPressSource creates a readme.txt data source for the plugin slug.
+func createWordPressSource(slug string) *DataSource {
+	return &DataSource{
+		URL:         fmt.Sprintf("https://plugins.svn.wordpress.org/%s/trunk/readme.txt", slug),
+		Type:        "wordpress",
+		Priority:    PriorityWordPress,
+		ContentType: ContentTypeText,
+	}
+}
@@ -0,0 +1,97 @@
+package autoupdate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// sampleReadmeTxt is a WordPress plugin readme.txt: other version-looking
+// headers come before Stable tag, and the changelog mentions a newer beta
+// and a Stable tag of its own.
+const sampleReadmeTxt = `=== Example Forms ===
+Contributors: alice, bob
+Tags: forms, contact
+Requires at least: 6.0
+Tested up to: 6.6.1
+Requires PHP: 7.4
+Stable tag: 2.4.1
+License: GPLv2 or later
+
+A short description of the plugin.
+
+== Description ==
+
+Stable tag: 9.9.9 in prose is not the header.
+
+== Changelog ==
+
+= 2.5.0-beta1 =
+* Not released yet.
+`
+
+// TestReadmeTxtParser verifies the Stable tag is read and the other headers
+// and sections are ignored, and that trunk falls back to the configured
+// field.
+func TestReadmeTxtParser(t *testing.T) {
+	got, err := (&ReadmeTxtParser{}).Parse([]byte(sampleReadmeTxt))
+	if err != nil || got != "2.4.1" {
+		t.Errorf("Parse() = %q, %v; want 2.4.1", got, err)
+	}
+
+	got, err = (&ReadmeTxtParser{}).Parse([]byte("# Foo\n\n**Stable tag:** 1.0.2  \n**Tested up to:** 6.5\n"))
+	if err != nil || got != "1.0.2" {
+		t.Errorf("Parse(readme.md) = %q, %v; want 1.0.2", got, err)
+	}
+
+	trunk := strings.Replace(sampleReadmeTxt, "Stable tag: 2.4.1", "Stable tag: trunk\nVersion: 2.4.2", 1)
+	if _, err := (&ReadmeTxtParser{}).Parse([]byte(trunk)); !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse(trunk) error = %v, want %v", err, ErrNoVersionFound)
+	}
+	got, err = (&ReadmeTxtParser{Field: "version"}).Parse([]byte(trunk))
+	if err != nil || got != "2.4.2" {
+		t.Errorf("Parse(trunk, Field=version) = %q, %v; want 2.4.2", got, err)
+	}
+
+	noHeader := strings.Replace(sampleReadmeTxt, "Stable tag: 2.4.1\n", "", 1)
+	if _, err := (&ReadmeTxtParser{Field: "Version"}).Parse([]byte(noHeader)); !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("Parse(no Stable tag) error = %v, want %v", err, ErrNoVersionFound)
+	}
+}
+
+// TestDiscoverWordPressSource verifies the trunk readme.txt URL is derived
+// from a plugin directory homepage or a plugin download SRC_URI, and that the
+// analyzer picks the readme-txt parser for it.
+func TestDiscoverWordPressSource(t *testing.T) {
+	const want = "https://plugins.svn.wordpress.org/example-forms/trunk/readme.txt"
+	tests := []struct {
+		name string
+		meta EbuildMetadata
+	}{
+		{"homepage", EbuildMetadata{Package: "www-apps/example-forms", Homepage: "https://wordpress.org/plugins/example-forms/"}},
+		{"src_uri", EbuildMetadata{Package: "www-apps/example-forms", SrcURI: "https://downloads.wordpress.org/plugin/example-forms.2.4.1.zip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found *DataSource
+			for _, s := range DiscoverDataSources(&tt.meta, "") {
+				if s.Type == "homepage" {
+					t.Errorf("plugin homepage added as a homepage source: %+v", s)
+				}
+				if s.Type == "wordpress" {
+					found = &s
+				}
+			}
+			if found == nil || found.URL != want {
+				t.Fatalf("wordpress source = %+v, want %s", found, want)
+			}
+			schema, err := (&Analyzer{}).analyzeContent(nil, []byte(sampleReadmeTxt), &tt.meta, "", found)
+			if err != nil || schema.Parser != "readme-txt" {
+				t.Errorf("analyzeContent() = %+v, %v; want the readme-txt parser", schema, err)
+			}
+			if err := ValidatePackageConfig(tt.meta.Package, schema); err != nil {
+				t.Errorf("ValidatePackageConfig() = %v", err)
+			}
+		})
+	}
+}
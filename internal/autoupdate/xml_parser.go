@@ -0,0 +1,409 @@
+// Package autoupdate provides XML parsing functionality for ebuild autoupdate.
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/xpath"
+)
+
+// XMLParser extracts a version from an XML document with an XPath
+// expression: maven-metadata.xml ("//metadata/versioning/release"), a Sparkle
+// appcast ("//item/enclosure/@sparkle:version") and the like.
+//
+// Unlike the html parser, the document is parsed strictly, so a malformed
+// file is an error rather than a silently repaired tree, and names keep their
+// namespace prefix as written: "sparkle:version" matches the attribute the
+// appcast declares, while an element in a default namespace is matched by
+// its bare name. Element, attribute and text() selections are supported; the
+// first match's text is the version, optionally narrowed by Regex.
+//
+// With FilePattern set, the selected nodes are instead file URLs or paths, as
+// in a feed of release files (a SourceForge project RSS): FilePattern is
+// matched against each one's file name, as by FileIndexParser, and the
+// highest version among them wins, whichever item comes first.
+type XMLParser struct {
+	// XPath is the XPath expression selecting the version
+	XPath string
+	// Regex is an optional regex pattern to apply to the extracted text
+	Regex string
+	// FilePattern is the file name regex whose capture group is the version;
+	// it cannot be combined with Regex
+	FilePattern string
+	// expr is the compiled XPath
+	expr *xpath.Expr
+	// compiled is the compiled Regex, nil when Regex is empty
+	compiled *regexp.Regexp
+	// files matches the file names, nil when FilePattern is empty
+	files *FileIndexParser
+}
+
+// NewXMLParser creates an XMLParser. The XPath expression is required and
+// compiled upfront, so a typo surfaces as ErrInvalidXPath when the parser is
+// built rather than on every check.
+func NewXMLParser(xpathExpr, regex string) (*XMLParser, error) {
+	return newXMLParser(xpathExpr, regex, "")
+}
+
+// NewXMLFileParser creates an XMLParser reading the highest version among the
+// file names of the nodes xpathExpr selects, per filePattern (see
+// NewFileIndexParser).
+func NewXMLFileParser(xpathExpr, filePattern string) (*XMLParser, error) {
+	if filePattern == "" {
+		return nil, ErrMissingFilePattern
+	}
+	return newXMLParser(xpathExpr, "", filePattern)
+}
+
+func newXMLParser(xpathExpr, regex, filePattern string) (*XMLParser, error) {
+	if xpathExpr == "" {
+		return nil, ErrMissingXPath
+	}
+	expr, err := xpath.Compile(xpathExpr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXPath, err)
+	}
+	p := &XMLParser{XPath: xpathExpr, Regex: regex, FilePattern: filePattern, expr: expr}
+	if filePattern != "" {
+		if regex != "" {
+			return nil, fmt.Errorf("%w: pattern cannot be combined with file_pattern", ErrInvalidRegexPattern)
+		}
+		files, err := NewFileIndexParser(filePattern)
+		if err != nil {
+			return nil, err
+		}
+		p.files = files
+	}
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRegexPattern, err)
+		}
+		p.compiled = re
+	}
+	return p, nil
+}
+
+// Parse extracts a version string from XML content.
+func (p *XMLParser) Parse(content []byte) (string, error) {
+	if err := p.compile(); err != nil {
+		return "", err
+	}
+	if p.files != nil {
+		versions, err := p.ExtractVersions(content)
+		if err != nil {
+			return "", err
+		}
+		return highestFileVersion(versions)
+	}
+
+	root, err := parseXMLDocument(content)
+	if err != nil {
+		return "", err
+	}
+
+	iter := p.expr.Select(&xmlNavigator{root: root, curr: root, attr: -1})
+	if !iter.MoveNext() {
+		return "", fmt.Errorf("%w: %s", ErrNoElementFound, p.XPath)
+	}
+	text := iter.Current().Value()
+
+	if p.compiled != nil {
+		text, err = extractRegexMatch(p.compiled, text)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ErrNoVersionFound
+	}
+	return text, nil
+}
+
+// ExtractVersions returns the version of every node XPath selects, in
+// document order, so select and version_constraint work on a feed: with
+// FilePattern, the versions of the matching file names, each once; without
+// it, each node's text, narrowed by Regex.
+func (p *XMLParser) ExtractVersions(content []byte) ([]string, error) {
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	root, err := parseXMLDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	iter := p.expr.Select(&xmlNavigator{root: root, curr: root, attr: -1})
+	for iter.MoveNext() {
+		values = append(values, iter.Current().Value())
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoElementFound, p.XPath)
+	}
+
+	if p.files != nil {
+		names := make([]string, 0, len(values))
+		for _, v := range values {
+			if name := xmlFileName(v); name != "" {
+				names = append(names, name)
+			}
+		}
+		return p.files.versionsOf(names)
+	}
+
+	var versions []string
+	for _, text := range values {
+		if p.compiled != nil {
+			if text, err = extractRegexMatch(p.compiled, text); err != nil {
+				continue
+			}
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			versions = append(versions, text)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, ErrNoVersionFound
+	}
+	return versions, nil
+}
+
+// compile builds the XPath and patterns of a parser constructed as a struct
+// literal rather than by NewXMLParser.
+func (p *XMLParser) compile() error {
+	if p.expr != nil {
+		return nil
+	}
+	built, err := newXMLParser(p.XPath, p.Regex, p.FilePattern)
+	if err != nil {
+		return err
+	}
+	*p = *built
+	return nil
+}
+
+// xmlFileName returns the file name of a file URL or path: its last path
+// segment, unescaped, without a query. SourceForge links end in "/download"
+// after the file name, which is dropped.
+func xmlFileName(value string) string {
+	value = strings.TrimSpace(value)
+	if u, err := url.Parse(value); err == nil {
+		value = u.Path
+	}
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "/"), "/download")
+	name := path.Base(value)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// xmlNode is one node of a parsed XML document: the root, an element, a text
+// run or a comment. Attributes hang off their element.
+type xmlNode struct {
+	typ    xpath.NodeType
+	prefix string
+	name   string
+	// data is the text of a text or comment node
+	data  string
+	attrs []xml.Attr
+
+	parent, firstChild, lastChild, prev, next *xmlNode
+}
+
+// appendChild links child as n's last child.
+func (n *xmlNode) appendChild(child *xmlNode) {
+	child.parent = n
+	if n.lastChild == nil {
+		n.firstChild = child
+	} else {
+		n.lastChild.next = child
+		child.prev = n.lastChild
+	}
+	n.lastChild = child
+}
+
+// text returns the concatenated text of n's descendants, the XPath string
+// value of an element.
+func (n *xmlNode) text() string {
+	if n.typ == xpath.TextNode || n.typ == xpath.CommentNode {
+		return n.data
+	}
+	var b strings.Builder
+	for c := n.firstChild; c != nil; c = c.next {
+		if c.typ != xpath.CommentNode {
+			b.WriteString(c.text())
+		}
+	}
+	return b.String()
+}
+
+// parseXMLDocument parses content strictly into a node tree. RawToken is used
+// instead of Token so names keep the prefix written in the document rather
+// than the namespace URL it maps to, which is what an XPath such as
+// "@sparkle:version" names. Processing instructions and directives (the XML
+// declaration, a DOCTYPE) carry no version and are dropped.
+func parseXMLDocument(content []byte) (*xmlNode, error) {
+	root := &xmlNode{typ: xpath.RootNode}
+	curr := root
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &xmlNode{typ: xpath.ElementNode, prefix: t.Name.Space, name: t.Name.Local, attrs: t.Copy().Attr}
+			curr.appendChild(el)
+			curr = el
+		case xml.EndElement:
+			if curr == root || curr.name != t.Name.Local || curr.prefix != t.Name.Space {
+				return nil, fmt.Errorf("failed to parse XML: unexpected end element </%s>", xmlQName(t.Name))
+			}
+			curr = curr.parent
+		case xml.CharData:
+			if curr != root {
+				curr.appendChild(&xmlNode{typ: xpath.TextNode, data: string(t)})
+			}
+		case xml.Comment:
+			curr.appendChild(&xmlNode{typ: xpath.CommentNode, data: string(t)})
+		}
+	}
+	if curr != root {
+		return nil, fmt.Errorf("failed to parse XML: element <%s> is not closed", xmlQName(xml.Name{Space: curr.prefix, Local: curr.name}))
+	}
+	if root.firstChild == nil {
+		return nil, errors.New("failed to parse XML: empty document")
+	}
+	return root, nil
+}
+
+// xmlQName formats a raw name as written, "prefix:local" or "local".
+func xmlQName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// xmlNavigator is the xpath.NodeNavigator over an xmlNode tree. attr is the
+// index of the current attribute of curr, or -1 when on curr itself.
+type xmlNavigator struct {
+	root, curr *xmlNode
+	attr       int
+}
+
+func (x *xmlNavigator) NodeType() xpath.NodeType {
+	if x.attr != -1 {
+		return xpath.AttributeNode
+	}
+	return x.curr.typ
+}
+
+func (x *xmlNavigator) LocalName() string {
+	if x.attr != -1 {
+		return x.curr.attrs[x.attr].Name.Local
+	}
+	return x.curr.name
+}
+
+func (x *xmlNavigator) Prefix() string {
+	if x.attr != -1 {
+		return x.curr.attrs[x.attr].Name.Space
+	}
+	return x.curr.prefix
+}
+
+func (x *xmlNavigator) Value() string {
+	if x.attr != -1 {
+		return x.curr.attrs[x.attr].Value
+	}
+	return x.curr.text()
+}
+
+func (x *xmlNavigator) Copy() xpath.NodeNavigator {
+	n := *x
+	return &n
+}
+
+func (x *xmlNavigator) MoveToRoot() {
+	x.curr, x.attr = x.root, -1
+}
+
+func (x *xmlNavigator) MoveToParent() bool {
+	if x.attr != -1 {
+		x.attr = -1
+		return true
+	}
+	if x.curr.parent == nil {
+		return false
+	}
+	x.curr = x.curr.parent
+	return true
+}
+
+func (x *xmlNavigator) MoveToNextAttribute() bool {
+	if x.attr >= len(x.curr.attrs)-1 {
+		return false
+	}
+	x.attr++
+	return true
+}
+
+func (x *xmlNavigator) MoveToChild() bool {
+	if x.attr != -1 || x.curr.firstChild == nil {
+		return false
+	}
+	x.curr = x.curr.firstChild
+	return true
+}
+
+func (x *xmlNavigator) MoveToFirst() bool {
+	if x.attr != -1 || x.curr.prev == nil {
+		return false
+	}
+	for x.curr.prev != nil {
+		x.curr = x.curr.prev
+	}
+	return true
+}
+
+func (x *xmlNavigator) MoveToNext() bool {
+	if x.attr != -1 || x.curr.next == nil {
+		return false
+	}
+	x.curr = x.curr.next
+	return true
+}
+
+func (x *xmlNavigator) MoveToPrevious() bool {
+	if x.attr != -1 || x.curr.prev == nil {
+		return false
+	}
+	x.curr = x.curr.prev
+	return true
+}
+
+func (x *xmlNavigator) MoveTo(other xpath.NodeNavigator) bool {
+	node, ok := other.(*xmlNavigator)
+	if !ok || node.root != x.root {
+		return false
+	}
+	x.curr, x.attr = node.curr, node.attr
+	return true
+}
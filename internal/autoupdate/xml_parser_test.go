@@ -0,0 +1,125 @@
+package autoupdate
+
+import (
+	"errors"
+	"testing"
+)
+
+// mavenMetadata is a maven-metadata.xml as published by Maven Central.
+const mavenMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>org.example</groupId>
+  <artifactId>foo</artifactId>
+  <versioning>
+    <latest>2.1.0-M1</latest>
+    <release>2.0.3</release>
+    <versions>
+      <version>1.9.0</version>
+      <version>2.0.3</version>
+      <version>2.1.0-M1</version>
+    </versions>
+  </versioning>
+</metadata>
+`
+
+// sparkleAppcast is a Sparkle appcast: an RSS feed whose enclosures carry
+// the version in sparkle-namespaced attributes, under a default namespace
+// the XPath does not have to name.
+const sparkleAppcast = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0" xmlns:sparkle="http://www.andymatuschak.org/xml-namespaces/sparkle">
+  <channel>
+    <title>Foo Changelog</title>
+    <item>
+      <title>Version 3.4</title>
+      <!-- the newest release comes first -->
+      <enclosure url="https://example.com/Foo-3.4.zip" sparkle:version="1204" sparkle:shortVersionString="3.4.0" length="0" type="application/octet-stream"/>
+    </item>
+    <item>
+      <title>Version 3.3</title>
+      <enclosure url="https://example.com/Foo-3.3.zip" sparkle:version="1187" sparkle:shortVersionString="3.3.2" length="0" type="application/octet-stream"/>
+    </item>
+  </channel>
+</rss>
+`
+
+// TestXMLParser covers element, text(), attribute and prefixed-attribute
+// selections, regex narrowing, and the no-match error.
+func TestXMLParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		xpath   string
+		regex   string
+		want    string
+		wantErr error
+	}{
+		{"maven release element", mavenMetadata, "//metadata/versioning/release", "", "2.0.3", nil},
+		{"maven text()", mavenMetadata, "/metadata/versioning/versions/version[last()]/text()", "", "2.1.0-M1", nil},
+		{"sparkle version attribute", sparkleAppcast, "//item/enclosure/@sparkle:version", "", "1204", nil},
+		{"sparkle short version", sparkleAppcast, "//item/enclosure/@sparkle:shortVersionString", "", "3.4.0", nil},
+		{"regex on element text", sparkleAppcast, "//item/title", `Version ([0-9.]+)`, "3.4", nil},
+		{"plain attribute", sparkleAppcast, "//item[2]/enclosure/@url", `Foo-([0-9.]+)\.zip`, "3.3", nil},
+		{"no match", mavenMetadata, "//metadata/versioning/snapshot", "", "", ErrNoElementFound},
+		{"unprefixed name does not match prefixed attribute", sparkleAppcast, "//enclosure/@version", "", "", ErrNoElementFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewXMLParser(tt.xpath, tt.regex)
+			if err != nil {
+				t.Fatalf("NewXMLParser() error = %v", err)
+			}
+			got, err := p.Parse([]byte(tt.content))
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Parse() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestXMLParser_Errors verifies an invalid XPath and a missing one are
+// rejected when the parser is built, and that malformed XML, which the html
+// parser would repair, fails to parse.
+func TestXMLParser_Errors(t *testing.T) {
+	if _, err := NewXMLParser("//release[", ""); !errors.Is(err, ErrInvalidXPath) {
+		t.Errorf("NewXMLParser(invalid) error = %v, want %v", err, ErrInvalidXPath)
+	}
+	if _, err := NewXMLParser("", ""); !errors.Is(err, ErrMissingXPath) {
+		t.Errorf("NewXMLParser(\"\") error = %v, want %v", err, ErrMissingXPath)
+	}
+
+	p, err := NewXMLParser("//release", "")
+	if err != nil {
+		t.Fatalf("NewXMLParser() error = %v", err)
+	}
+	for _, content := range []string{
+		"<metadata><release>1.0</metadata>",
+		"<metadata><release>1.0</release>",
+		"not xml at all",
+	} {
+		if _, err := p.Parse([]byte(content)); err == nil || errors.Is(err, ErrNoElementFound) {
+			t.Errorf("Parse(%q) error = %v, want a parse error", content, err)
+		}
+	}
+}
+
+// TestParseVersion_XML verifies ParseVersion dispatches parser = "xml" and
+// surfaces the XPath errors like the html parser.
+func TestParseVersion_XML(t *testing.T) {
+	cfg := &PackageConfig{Parser: "xml", XPath: "//metadata/versioning/release"}
+	if got, err := ParseVersion([]byte(mavenMetadata), cfg); err != nil || got != "2.0.3" {
+		t.Errorf("ParseVersion() = %q, %v; want 2.0.3", got, err)
+	}
+
+	cfg.XPath = "//metadata/versioning/snapshot"
+	if _, err := ParseVersion([]byte(mavenMetadata), cfg); !errors.Is(err, ErrNoElementFound) {
+		t.Errorf("ParseVersion(no match) error = %v, want %v", err, ErrNoElementFound)
+	}
+
+	cfg.XPath = "//release["
+	if _, err := ParseVersion([]byte(mavenMetadata), cfg); !errors.Is(err, ErrInvalidXPath) {
+		t.Errorf("ParseVersion(invalid xpath) error = %v, want %v", err, ErrInvalidXPath)
+	}
+	if err := ValidatePackageConfig("app-misc/foo", &PackageConfig{URL: "https://example.com", Parser: "xml", XPath: "//release["}); !errors.Is(err, ErrInvalidXPath) {
+		t.Errorf("ValidatePackageConfig(invalid xpath) error = %v, want %v", err, ErrInvalidXPath)
+	}
+}
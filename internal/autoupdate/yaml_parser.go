@@ -0,0 +1,89 @@
+// Package autoupdate provides YAML document parsing for ebuild autoupdate.
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidYAML is returned when the content is not a YAML document.
+var ErrInvalidYAML = errors.New("failed to parse YAML")
+
+// YAMLParser extracts a version from a YAML document, such as a project's
+// versions.yaml or a Helm Chart.yaml, by path. Path uses the JSON parser's
+// syntax (dot notation, [n] and [*] indexing and a trailing | length,
+// | first, | last or | max) and resolves through the same code, so a
+// missing key fails with ErrJSONPathNotFound exactly as it does for json
+// and a fallback parser takes over the same way.
+//
+// Scalars are read as the text written in the document rather than as the
+// type YAML would infer: "version: 1.10" yields "1.10", where decoding to a
+// float would give 1.1.
+type YAMLParser struct {
+	// Path is the path to the version field (e.g., "version",
+	// "releases[0].version", "channels.stable")
+	Path string
+}
+
+// Parse extracts a version string from YAML content using the configured path.
+func (p *YAMLParser) Parse(content []byte) (string, error) {
+	if p.Path == "" {
+		return "", ErrInvalidJSONPath
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidYAML, err)
+	}
+	data, err := yamlNodeValue(&doc)
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return "", fmt.Errorf("%w: empty YAML document", ErrJSONPathNotFound)
+	}
+	return extractJSONPath(data, p.Path)
+}
+
+// yamlNodeValue converts a YAML node into the shape encoding/json decodes
+// into (map[string]interface{}, []interface{} and scalars), so the JSON path
+// resolver can walk it. Every non-null scalar becomes its literal string.
+func yamlNodeValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(n.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeValue(n.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := yamlNodeValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		s := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := yamlNodeValue(c)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		return s, nil
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil, nil
+		}
+		return n.Value, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported node kind %d", ErrInvalidYAML, n.Kind)
+}
@@ -0,0 +1,125 @@
+package autoupdate
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// yamlVersionsSample is a trimmed versions.yaml with an anchor, a list and
+// a version YAML would read as a float.
+const yamlVersionsSample = `# release channels
+defaults: &defaults
+  arch: [amd64, arm64]
+channels:
+  stable:
+    <<: *defaults
+    version: 1.10
+  beta:
+    version: "2.0.0_beta1"
+releases:
+  - version: 1.10
+    date: 2026-01-02
+  - version: 1.9.4
+legacy: ~
+`
+
+// chartYAMLSample is a trimmed Helm Chart.yaml.
+const chartYAMLSample = `apiVersion: v2
+name: mychart
+version: 0.4.2
+appVersion: "3.2.0"
+`
+
+// TestYAMLParser covers path resolution over YAML documents.
+func TestYAMLParser(t *testing.T) {
+	tests := []struct {
+		content, path, want string
+	}{
+		{yamlVersionsSample, "channels.stable.version", "1.10"},
+		{yamlVersionsSample, "channels.beta.version", "2.0.0_beta1"},
+		{yamlVersionsSample, "releases[1].version", "1.9.4"},
+		{yamlVersionsSample, "releases[*].version | max", "1.10"},
+		{yamlVersionsSample, "releases[*].version | length", "2"},
+		{chartYAMLSample, "appVersion", "3.2.0"},
+		{chartYAMLSample, "version", "0.4.2"},
+	}
+	for _, tt := range tests {
+		got, err := (&YAMLParser{Path: tt.path}).Parse([]byte(tt.content))
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestYAMLParser_Errors verifies the JSON path errors are returned, so
+// fallback chains treat a yaml miss like a json one.
+func TestYAMLParser_Errors(t *testing.T) {
+	for _, path := range []string{"channels.nightly.version", "releases[5].version", "legacy"} {
+		if _, err := (&YAMLParser{Path: path}).Parse([]byte(yamlVersionsSample)); !errors.Is(err, ErrJSONPathNotFound) {
+			t.Errorf("Parse(%q) error = %v, want %v", path, err, ErrJSONPathNotFound)
+		}
+	}
+	if _, err := (&YAMLParser{Path: "version"}).Parse([]byte("version: [unclosed")); !errors.Is(err, ErrInvalidYAML) {
+		t.Errorf("Parse(invalid) error = %v, want %v", err, ErrInvalidYAML)
+	}
+	if _, err := (&YAMLParser{}).Parse([]byte(chartYAMLSample)); !errors.Is(err, ErrInvalidJSONPath) {
+		t.Errorf("Parse(no path) error = %v, want %v", err, ErrInvalidJSONPath)
+	}
+
+	cfg := &PackageConfig{Parser: "yaml", Path: "missing", FallbackParser: "regex", FallbackPattern: `appVersion: "([0-9.]+)"`}
+	got, err := ParseVersion([]byte(chartYAMLSample), cfg)
+	if err != nil || got != "3.2.0" {
+		t.Errorf("ParseVersion with regex fallback = %q, %v; want 3.2.0", got, err)
+	}
+	cfg.FallbackParser = ""
+	if _, err := ParseVersion([]byte(chartYAMLSample), cfg); !errors.Is(err, ErrNoVersionFound) {
+		t.Errorf("ParseVersion without fallback error = %v, want %v", err, ErrNoVersionFound)
+	}
+}
+
+// TestYAMLParser_PackagesTOMLRoundTrip verifies a yaml entry survives being
+// written to and loaded from packages.toml, and parses the same afterwards.
+func TestYAMLParser_PackagesTOMLRoundTrip(t *testing.T) {
+	want := PackageConfig{
+		URL:            "https://example.com/versions.yaml",
+		Parser:         "yaml",
+		Path:           "channels.stable.version",
+		FallbackURL:    "https://example.com/Chart.yaml",
+		FallbackParser: "yaml",
+		Transform:      [][]string{{`^v`, ""}},
+	}
+
+	var buf bytes.Buffer
+	if err := encodePackagesTOML(&buf, map[string]PackageConfig{"app-misc/foo": want}); err != nil {
+		t.Fatalf("encodePackagesTOML: %v", err)
+	}
+	overlay := t.TempDir()
+	configPath := filepath.Join(overlay, ".autoupdate", "packages.toml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(configPath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadPackagesConfig(overlay)
+	if err != nil {
+		t.Fatalf("LoadPackagesConfig: %v\n%s", err, buf.String())
+	}
+	got := cfg.Packages["app-misc/foo"]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round-trip mismatch:\nwant %+v\ngot  %+v\n%s", want, got, buf.String())
+	}
+	version, err := ParseVersion([]byte(yamlVersionsSample), &got)
+	if err != nil || version != "1.10" {
+		t.Errorf("ParseVersion after round-trip = %q, %v; want 1.10", version, err)
+	}
+}
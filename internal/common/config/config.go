@@ -28,16 +28,25 @@ type Config struct {
 	Repositories map[string]*RepoConfig `yaml:"repositories,omitempty"`
 }
 
-// OverlayConfig holds overlay-specific settings
+// OverlayConfig holds overlay-specific settings.
+//
+// Path is the primary overlay, the one every overlay command works on. Paths
+// lists further overlays for the commands that run across several (autoupdate
+// --check); with Path unset, the first of Paths is the primary. A config with
+// only `path` keeps working unchanged.
 type OverlayConfig struct {
-	Path   string `yaml:"path"`
-	Remote string `yaml:"remote"`
+	Path   string   `yaml:"path"`
+	Paths  []string `yaml:"paths,omitempty"`
+	Remote string   `yaml:"remote"`
 }
 
 // GitConfig holds git user settings
 type GitConfig struct {
 	User  string `yaml:"user"`
 	Email string `yaml:"email"`
+	// CommitFormat is the style of generated commit messages: "bentoo"
+	// (default) or "conventional"
+	CommitFormat string `yaml:"commit_format,omitempty"`
 }
 
 // RepoConfig holds configuration for a custom repository.
@@ -55,10 +64,18 @@ type RepoConfig struct {
 
 // AutoupdateConfig holds autoupdate-specific settings
 type AutoupdateConfig struct {
-	CacheTTL    int          `yaml:"cache_ttl"`    // Cache TTL in seconds (default: 3600)
-	HTTPTimeout int          `yaml:"http_timeout"` // Per-request HTTP timeout in seconds (default: 30)
-	LLM         LLMConfig    `yaml:"llm"`          // LLM provider configuration
-	Search      SearchConfig `yaml:"search"`       // Search provider configuration
+	CacheTTL         int          `yaml:"cache_ttl"`                   // Cache TTL in seconds (default: 3600)
+	HTTPTimeout      int          `yaml:"http_timeout"`                // Per-request HTTP timeout in seconds (default: 30)
+	CacheCompact     bool         `yaml:"cache_compact"`               // Gzip the version cache and drop expired entries on save
+	CachePolicy      string       `yaml:"cache_policy"`                // What the cache keeps: "version-only" (default), "raw" or "raw-small"
+	LLMCacheTTL      int          `yaml:"llm_cache_ttl"`               // LLM answer cache TTL in seconds (default: 604800)
+	NegativeCacheTTL int          `yaml:"negative_cache_ttl"`          // Failed upstream lookup cache TTL in seconds (default: 0, disabled)
+	Pkgcheck         string       `yaml:"pkgcheck,omitempty"`          // pkgcheck binary for --qa and --validate, a name on PATH or a path (default: pkgcheck)
+	GitHubTokenFile  string       `yaml:"github_token_file,omitempty"` // File holding the GitHub API token; takes precedence over GITHUB_TOKEN/GH_TOKEN
+	GiteaHosts       []string     `yaml:"gitea_hosts,omitempty"`       // Self-hosted Gitea/Forgejo hosts analyze discovers releases on, besides codeberg.org
+	LLM              LLMConfig    `yaml:"llm"`                         // LLM provider configuration
+	LLMFallbacks     []LLMConfig  `yaml:"llm_fallbacks,omitempty"`     // Backup LLM providers analyze tries, in order, when llm fails
+	Search           SearchConfig `yaml:"search"`                      // Search provider configuration
 }
 
 // LLMConfig holds LLM provider configuration for autoupdate
@@ -68,6 +85,7 @@ type LLMConfig struct {
 	Model        string  `yaml:"model"`                    // Model name to use
 	Bare         string  `yaml:"bare,omitempty"`           // CLI bare-mode selector: "auto" (default), "true", or "false"
 	MaxBudgetUSD float64 `yaml:"max_budget_usd,omitempty"` // Optional spend cap passed to the CLI provider via --max-budget-usd
+	BaseURL      string  `yaml:"base_url,omitempty"`       // API base URL for the openai, ollama and gemini providers (e.g. a LocalAI or vLLM server)
 }
 
 // SearchConfig holds search provider configuration for autoupdate
@@ -321,14 +339,71 @@ func (c *Config) GetOverlayPathNoValidation() (string, error) {
 	return c.getOverlayPathWithValidation(false)
 }
 
-// getOverlayPathWithValidation returns the overlay path with optional structure validation
+// GetOverlayPaths returns every configured overlay, validated, the primary
+// first: overlay.path followed by overlay.paths, with duplicates dropped.
+func (c *Config) GetOverlayPaths() ([]string, error) {
+	return c.getOverlayPathsWithValidation(true)
+}
+
+// GetOverlayPathsNoValidation is GetOverlayPaths without structure validation.
+func (c *Config) GetOverlayPathsNoValidation() ([]string, error) {
+	return c.getOverlayPathsWithValidation(false)
+}
+
+// configuredOverlayPaths returns overlay.path and overlay.paths as written,
+// the primary first, skipping empty and repeated entries.
+func (c *Config) configuredOverlayPaths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, path := range append([]string{c.Overlay.Path}, c.Overlay.Paths...) {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// getOverlayPathsWithValidation resolves every configured overlay. One that
+// is missing or invalid fails the whole call, naming it, rather than being
+// skipped: a run across the overlays silently missing one would look like
+// that overlay had nothing to report.
+func (c *Config) getOverlayPathsWithValidation(validate bool) ([]string, error) {
+	configured := c.configuredOverlayPaths()
+	if len(configured) == 0 {
+		return nil, ErrOverlayPathNotSet
+	}
+	resolved := make([]string, 0, len(configured))
+	seen := make(map[string]bool)
+	for _, raw := range configured {
+		path, err := resolveOverlayPath(raw, validate)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", raw, err)
+		}
+		// "~/x" and "/home/me/x" are the same overlay.
+		if key := filepath.Clean(path); !seen[key] {
+			seen[key] = true
+			resolved = append(resolved, path)
+		}
+	}
+	return resolved, nil
+}
+
+// getOverlayPathWithValidation returns the primary overlay path with optional
+// structure validation
 func (c *Config) getOverlayPathWithValidation(validate bool) (string, error) {
-	if c.Overlay.Path == "" {
+	configured := c.configuredOverlayPaths()
+	if len(configured) == 0 {
 		return "", ErrOverlayPathNotSet
 	}
+	return resolveOverlayPath(configured[0], validate)
+}
 
+// resolveOverlayPath expands a leading "~" in a configured overlay path and
+// checks that it is a directory, and with validate an overlay.
+func resolveOverlayPath(path string, validate bool) (string, error) {
 	// Expand home directory if needed
-	path := c.Overlay.Path
 	if len(path) > 0 && path[0] == '~' {
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -474,6 +549,12 @@ func (e *OverlayValidationError) Error() string {
 	return msg
 }
 
+// Unwrap lets callers match any validation failure with
+// errors.Is(err, ErrOverlayInvalidStructure).
+func (e *OverlayValidationError) Unwrap() error {
+	return ErrOverlayInvalidStructure
+}
+
 // ValidateOverlayStructure checks if a path is a valid Gentoo overlay.
 // A valid overlay must have:
 // - profiles/ directory
@@ -513,6 +594,18 @@ func (c *AutoupdateConfig) GetCacheTTL() int {
 	return c.CacheTTL
 }
 
+// DefaultLLMCacheTTL is the default LLM answer cache TTL in seconds (7 days)
+const DefaultLLMCacheTTL = 604800
+
+// GetLLMCacheTTL returns the LLM answer cache TTL in seconds, using the
+// default if not configured.
+func (c *AutoupdateConfig) GetLLMCacheTTL() int {
+	if c.LLMCacheTTL <= 0 {
+		return DefaultLLMCacheTTL
+	}
+	return c.LLMCacheTTL
+}
+
 // DefaultHTTPTimeout is the default per-request HTTP timeout in seconds.
 const DefaultHTTPTimeout = 30
 
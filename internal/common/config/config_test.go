@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -796,6 +797,29 @@ func TestGetHTTPTimeout(t *testing.T) {
 	}
 }
 
+// TestGetLLMCacheTTL tests that GetLLMCacheTTL returns the default for
+// zero/negative values and the configured value otherwise.
+func TestGetLLMCacheTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		llmCacheTTL int
+		expected    int
+	}{
+		{name: "zero value returns default", llmCacheTTL: 0, expected: DefaultLLMCacheTTL},
+		{name: "negative value returns default", llmCacheTTL: -1, expected: DefaultLLMCacheTTL},
+		{name: "positive value returns configured", llmCacheTTL: 86400, expected: 86400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := AutoupdateConfig{LLMCacheTTL: tt.llmCacheTTL}
+			if got := cfg.GetLLMCacheTTL(); got != tt.expected {
+				t.Errorf("GetLLMCacheTTL() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
 // TestConfigPaths tests that ConfigPaths returns both XDG and legacy paths in priority order
 // _Requirements: 4.1_
 func TestConfigPaths(t *testing.T) {
@@ -2039,3 +2063,103 @@ func TestLLMConfigNormalizeHelper(t *testing.T) {
 		}
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Multiple overlays
+// ---------------------------------------------------------------------------
+
+// loadOverlayYAML writes content as a config file and loads it.
+func loadOverlayYAML(t *testing.T, content string) *Config {
+	t.Helper()
+	yamlPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := LoadFrom(yamlPath)
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+	return cfg
+}
+
+// TestOverlayPaths_SinglePathShape verifies a config with only overlay.path
+// still resolves to that one overlay, which is the primary.
+func TestOverlayPaths_SinglePathShape(t *testing.T) {
+	dir := t.TempDir()
+	cfg := loadOverlayYAML(t, "overlay:\n  path: "+dir+"\n  remote: origin\n")
+
+	if len(cfg.Overlay.Paths) != 0 {
+		t.Errorf("Overlay.Paths = %v, want none", cfg.Overlay.Paths)
+	}
+	paths, err := cfg.GetOverlayPathsNoValidation()
+	if err != nil {
+		t.Fatalf("GetOverlayPathsNoValidation() error = %v", err)
+	}
+	if len(paths) != 1 || paths[0] != dir {
+		t.Errorf("GetOverlayPathsNoValidation() = %v, want [%s]", paths, dir)
+	}
+	if primary, err := cfg.GetOverlayPathNoValidation(); err != nil || primary != dir {
+		t.Errorf("GetOverlayPathNoValidation() = %q, %v; want %s", primary, err, dir)
+	}
+}
+
+// TestOverlayPaths_ListShape verifies overlay.paths alone configures several
+// overlays, the first being the primary, and that overlay.path combines with
+// it, first, with repeats dropped.
+func TestOverlayPaths_ListShape(t *testing.T) {
+	one, two, three := t.TempDir(), t.TempDir(), t.TempDir()
+
+	cfg := loadOverlayYAML(t, "overlay:\n  paths:\n    - "+one+"\n    - "+two+"\n")
+	paths, err := cfg.GetOverlayPathsNoValidation()
+	if err != nil {
+		t.Fatalf("GetOverlayPathsNoValidation() error = %v", err)
+	}
+	if len(paths) != 2 || paths[0] != one || paths[1] != two {
+		t.Errorf("GetOverlayPathsNoValidation() = %v, want [%s %s]", paths, one, two)
+	}
+	if primary, err := cfg.GetOverlayPathNoValidation(); err != nil || primary != one {
+		t.Errorf("GetOverlayPathNoValidation() = %q, %v; want the first listed, %s", primary, err, one)
+	}
+
+	cfg = loadOverlayYAML(t, "overlay:\n  path: "+three+"\n  paths:\n    - "+one+"\n    - "+three+"/\n")
+	paths, err = cfg.GetOverlayPathsNoValidation()
+	if err != nil {
+		t.Fatalf("GetOverlayPathsNoValidation() error = %v", err)
+	}
+	if len(paths) != 2 || paths[0] != three || paths[1] != one {
+		t.Errorf("GetOverlayPathsNoValidation() = %v, want [%s %s]", paths, three, one)
+	}
+}
+
+// TestOverlayPaths_Errors verifies a missing overlay among several is
+// reported by name, and that a config with neither key reports the path as
+// not set.
+func TestOverlayPaths_Errors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "gone")
+	cfg := &Config{Overlay: OverlayConfig{Path: t.TempDir(), Paths: []string{missing}}}
+	_, err := cfg.GetOverlayPathsNoValidation()
+	if !errors.Is(err, ErrOverlayPathNotFound) || !strings.Contains(err.Error(), missing) {
+		t.Errorf("GetOverlayPathsNoValidation() error = %v, want %v naming %s", err, ErrOverlayPathNotFound, missing)
+	}
+
+	if _, err := (&Config{}).GetOverlayPaths(); !errors.Is(err, ErrOverlayPathNotSet) {
+		t.Errorf("GetOverlayPaths() error = %v, want %v", err, ErrOverlayPathNotSet)
+	}
+}
+
+// TestOverlayPaths_SaveOmitsEmptyList verifies a single-overlay config is
+// saved without an overlay.paths key, as before the list existed.
+func TestOverlayPaths_SaveOmitsEmptyList(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := &Config{Overlay: OverlayConfig{Path: "/overlay", Remote: "origin"}}
+	if err := cfg.SaveTo(yamlPath); err != nil {
+		t.Fatalf("SaveTo() error = %v", err)
+	}
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if strings.Contains(string(data), "paths") {
+		t.Errorf("saved config has a paths key:\n%s", data)
+	}
+}
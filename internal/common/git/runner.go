@@ -19,6 +19,10 @@ var (
 	ErrGitCommand         = errors.New("git command failed")
 )
 
+// EmptyTree is the hash of git's empty tree object, which every repository
+// can resolve. It stands in for the missing parent of a root commit.
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
 // GitRunner executes git commands in a specific working directory
 type GitRunner struct {
 	workDir string
@@ -410,5 +414,125 @@ func (g *GitRunner) Merge(branch string) error {
 	})
 }
 
+// PathStatus returns the git status entries limited to the given pathspecs,
+// including untracked files. An empty result means the paths are clean.
+func (g *GitRunner) PathStatus(paths ...string) ([]StatusEntry, error) {
+	args := append([]string{"status", "--porcelain", "--"}, paths...)
+	stdout, _, err := g.runCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseStatusOutput(stdout), nil
+}
+
+// LastCommit returns the hash of the most recent commit touching path, or an
+// empty string when no commit does.
+func (g *GitRunner) LastCommit(path string) (string, error) {
+	stdout, _, err := g.runCommand("log", "-1", "--format=%H", "--", path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// Parent returns the hash of rev's first parent, or EmptyTree when rev is a
+// root commit, so the result is always usable as a diff or Restore source.
+func (g *GitRunner) Parent(rev string) (string, error) {
+	stdout, _, err := g.runCommand("rev-list", "--parents", "-n", "1", rev, "--")
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(stdout)
+	if len(fields) < 2 {
+		return EmptyTree, nil
+	}
+	return fields[1], nil
+}
+
+// CommitChanges returns the files a single commit changed under the given
+// pathspecs, as "A"/"M"/"D" entries. A root commit is diffed against the empty
+// tree, so everything it introduced is reported as added.
+func (g *GitRunner) CommitChanges(rev string, paths ...string) ([]StatusEntry, error) {
+	args := append([]string{"diff-tree", "--no-commit-id", "--name-status", "-r", "--root", rev, "--"}, paths...)
+	stdout, _, err := g.runCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StatusEntry
+	for _, line := range strings.Split(stdout, "\n") {
+		status, filePath, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		entries = append(entries, StatusEntry{
+			Status:   strings.TrimSpace(status),
+			FilePath: filePath,
+		})
+	}
+	return entries, nil
+}
+
+// Restore resets the given pathspecs, in both the index and the working tree,
+// to their content at source. Files absent from source are removed, so a
+// directory ends up exactly as it was at that revision. The result is staged
+// but not committed.
+func (g *GitRunner) Restore(source string, paths ...string) error {
+	return g.staged("restore", func() error {
+		args := append([]string{"restore", "--source=" + source, "--staged", "--worktree", "--"}, paths...)
+		_, _, err := g.runCommand(args...)
+		return err
+	})
+}
+
+// DiffOptions configures Diff and DiffFiles.
+type DiffOptions struct {
+	// Staged diffs the index against HEAD, as `git diff --staged`, instead of
+	// the working tree against the index.
+	Staged bool
+	// Color forces ANSI colors in the output, for a caller that prints it
+	// to a terminal; git would drop them since stdout is captured.
+	Color bool
+}
+
+// diffArgs returns the leading `git diff` arguments for opts.
+func (o DiffOptions) diffArgs() []string {
+	args := []string{"diff"}
+	if o.Color {
+		args = append(args, "--color=always")
+	}
+	if o.Staged {
+		args = append(args, "--staged")
+	}
+	return args
+}
+
+// Diff returns the unified diff of the changes under the given pathspecs,
+// unstaged or, with opts.Staged, staged. Untracked files are not part of it,
+// as with git. An empty result means there is nothing to show.
+func (g *GitRunner) Diff(opts DiffOptions, paths ...string) (string, error) {
+	args := append(append(opts.diffArgs(), "--"), paths...)
+	stdout, _, err := g.runCommand(args...)
+	if err != nil {
+		return "", err
+	}
+	return stdout, nil
+}
+
+// DiffFiles returns the unified diff between two files, tracked or not, with
+// `git diff --no-index`. That command exits 1 whenever the files differ,
+// which is its answer rather than a failure, so only other exits are errors.
+// opts.Staged has no meaning here and is ignored.
+func (g *GitRunner) DiffFiles(opts DiffOptions, oldPath, newPath string) (string, error) {
+	args := append(DiffOptions{Color: opts.Color}.diffArgs(), "--no-index", "--", oldPath, newPath)
+	stdout, _, err := g.runCommand(args...)
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", err
+	}
+	return stdout, nil
+}
+
 // Ensure GitRunner implements GitExecutor interface
 var _ GitExecutor = (*GitRunner)(nil)
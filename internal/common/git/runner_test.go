@@ -671,3 +671,153 @@ func TestValidateAndAddPath_DotDotPath(t *testing.T) {
 		t.Errorf(".. path resolving inside overlay should not return ErrInvalidPath")
 	}
 }
+
+func TestGitRunnerRestoreRevertsLastCommit(t *testing.T) {
+	runner, dir := initTestRepo(t)
+
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	write("pkg/a.txt", "one")
+	if err := runner.Add("pkg/a.txt"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := runner.Commit("first", "", ""); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	write("pkg/a.txt", "two")
+	write("pkg/b.txt", "new")
+	if err := runner.Add("pkg"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := runner.Commit("second", "", ""); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	commit, err := runner.LastCommit("pkg")
+	if err != nil || commit == "" {
+		t.Fatalf("LastCommit() = %q, %v", commit, err)
+	}
+
+	changes, err := runner.CommitChanges(commit, "pkg")
+	if err != nil {
+		t.Fatalf("CommitChanges() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, e := range changes {
+		got[e.FilePath] = e.Status
+	}
+	if got["pkg/a.txt"] != "M" || got["pkg/b.txt"] != "A" || len(got) != 2 {
+		t.Errorf("CommitChanges() = %v, want pkg/a.txt M and pkg/b.txt A", got)
+	}
+
+	parent, err := runner.Parent(commit)
+	if err != nil {
+		t.Fatalf("Parent() error = %v", err)
+	}
+	if err := runner.Restore(parent, "pkg"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "pkg/a.txt"))
+	if err != nil || string(data) != "one" {
+		t.Errorf("pkg/a.txt = %q, %v; want %q", data, err, "one")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg/b.txt")); !os.IsNotExist(err) {
+		t.Errorf("pkg/b.txt should be removed, stat err = %v", err)
+	}
+
+	staged, err := runner.PathStatus("pkg")
+	if err != nil {
+		t.Fatalf("PathStatus() error = %v", err)
+	}
+	if len(staged) != 2 {
+		t.Errorf("PathStatus() = %v, want the two restored paths", staged)
+	}
+
+	// The first commit has no parent: Parent falls back to the empty tree, and
+	// restoring from it removes everything that commit added.
+	root, err := runner.Parent(parent)
+	if err != nil || root != EmptyTree {
+		t.Fatalf("Parent(root) = %q, %v; want %s", root, err, EmptyTree)
+	}
+	if err := runner.Restore(root, "pkg"); err != nil {
+		t.Fatalf("Restore(EmptyTree) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg/a.txt")); !os.IsNotExist(err) {
+		t.Errorf("pkg/a.txt should be removed, stat err = %v", err)
+	}
+}
+
+func TestGitRunnerDiff(t *testing.T) {
+	runner, dir := initTestRepo(t)
+
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	write("a/file.txt", "one\n")
+	write("b/file.txt", "one\n")
+	if err := runner.Add("a", "b"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := runner.Commit("first", "", ""); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	write("a/file.txt", "staged\n")
+	if err := runner.Add("a/file.txt"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	write("b/file.txt", "unstaged\n")
+
+	unstaged, err := runner.Diff(DiffOptions{})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if !strings.Contains(unstaged, "+unstaged") || strings.Contains(unstaged, "+staged") {
+		t.Errorf("Diff() = %q, want only the unstaged change", unstaged)
+	}
+	staged, err := runner.Diff(DiffOptions{Staged: true})
+	if err != nil {
+		t.Fatalf("Diff(staged) error = %v", err)
+	}
+	if !strings.Contains(staged, "+staged") || strings.Contains(staged, "+unstaged") {
+		t.Errorf("Diff(staged) = %q, want only the staged change", staged)
+	}
+	if scoped, err := runner.Diff(DiffOptions{}, "a"); err != nil || scoped != "" {
+		t.Errorf("Diff(a) = %q, %v; want no unstaged change under a", scoped, err)
+	}
+	if colored, err := runner.Diff(DiffOptions{Color: true}, "b"); err != nil || !strings.Contains(colored, "\x1b[") {
+		t.Errorf("Diff(color) = %q, %v; want ANSI colors", colored, err)
+	}
+
+	files, err := runner.DiffFiles(DiffOptions{}, "a/file.txt", "b/file.txt")
+	if err != nil {
+		t.Fatalf("DiffFiles() error = %v", err)
+	}
+	if !strings.Contains(files, "-staged") || !strings.Contains(files, "+unstaged") {
+		t.Errorf("DiffFiles() = %q, want the two files' difference", files)
+	}
+	if same, err := runner.DiffFiles(DiffOptions{}, "a/file.txt", "a/file.txt"); err != nil || same != "" {
+		t.Errorf("DiffFiles(same) = %q, %v; want an empty diff", same, err)
+	}
+	if _, err := runner.DiffFiles(DiffOptions{}, "a/file.txt", "missing.txt"); err == nil {
+		t.Error("DiffFiles(missing) succeeded, want an error")
+	}
+}
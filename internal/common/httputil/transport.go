@@ -3,6 +3,7 @@ package httputil
 import (
 	"crypto/tls"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 )
@@ -20,12 +21,40 @@ const (
 	EnvDisableHTTP2 = "BENTOO_DISABLE_HTTP2"
 )
 
+// Default connection-pool tunables applied by BuildTransport.
+const (
+	// DefaultMaxIdleConnsPerHost is the number of idle keep-alive connections
+	// retained per host.
+	DefaultMaxIdleConnsPerHost = 16
+	// DefaultIdleConnTimeout is how long an idle keep-alive connection is kept
+	// before being closed.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportOptions overrides BuildTransport's connection tuning. The zero
+// value reproduces BuildTransport exactly.
+type TransportOptions struct {
+	// ForceHTTP1 disables HTTP/2 negotiation, as BuildTransportHTTP1 does.
+	ForceHTTP1 bool
+	// MaxIdleConnsPerHost overrides DefaultMaxIdleConnsPerHost when positive.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout overrides DefaultIdleConnTimeout when positive.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives closes every connection after a single request.
+	DisableKeepAlives bool
+	// Proxy routes every request through this proxy. Nil selects the proxy
+	// from HTTP_PROXY, HTTPS_PROXY and NO_PROXY, as BuildTransport does.
+	Proxy *url.URL
+}
+
 // BuildTransport returns a freshly constructed *http.Transport tuned with the
 // bentoolkit's standard connection-pool limits and timeouts.
 //
 // The returned transport sets MaxIdleConnsPerHost, MaxConnsPerHost,
 // IdleConnTimeout, TLSHandshakeTimeout, ExpectContinueTimeout, and enables
-// ForceAttemptHTTP2.
+// ForceAttemptHTTP2. Like http.DefaultTransport it honours the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables (http.ProxyFromEnvironment),
+// which never proxies requests to localhost.
 //
 // When the environment variable named by EnvDisableHTTP2 is set to "1", HTTP/2
 // is disabled: ForceAttemptHTTP2 is set to false and TLSNextProto is set to a
@@ -35,16 +64,35 @@ const (
 // Each call returns an independent transport; callers own the returned value
 // and may mutate it further before use.
 func BuildTransport() *http.Transport {
+	return BuildTransportWithOptions(TransportOptions{})
+}
+
+// BuildTransportWithOptions returns a transport built like BuildTransport's,
+// with the connection tuning in opts applied on top. Non-positive numeric
+// fields keep the defaults, and EnvDisableHTTP2 is honoured as usual, so
+// ForceHTTP1 can only turn HTTP/2 off, never back on.
+func BuildTransportWithOptions(opts TransportOptions) *http.Transport {
 	t := &http.Transport{
-		MaxIdleConnsPerHost:   16,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
 		MaxConnsPerHost:       32,
-		IdleConnTimeout:       90 * time.Second,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 		ForceAttemptHTTP2:     true,
+		DisableKeepAlives:     opts.DisableKeepAlives,
+		Proxy:                 http.ProxyFromEnvironment,
+	}
+	if opts.Proxy != nil {
+		t.Proxy = http.ProxyURL(opts.Proxy)
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = opts.IdleConnTimeout
 	}
 
-	if os.Getenv(EnvDisableHTTP2) == "1" {
+	if opts.ForceHTTP1 || os.Getenv(EnvDisableHTTP2) == "1" {
 		disableHTTP2(t)
 	}
 
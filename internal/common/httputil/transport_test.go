@@ -1,6 +1,8 @@
 package httputil
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -64,6 +66,54 @@ func TestBuildTransport_HTTP2Disabled(t *testing.T) {
 	}
 }
 
+// TestBuildTransportWithOptions verifies that the tunables override the
+// defaults and that ForceHTTP1 disables HTTP/2 negotiation.
+func TestBuildTransportWithOptions(t *testing.T) {
+	tr := BuildTransportWithOptions(TransportOptions{
+		ForceHTTP1:          true,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     15 * time.Second,
+		DisableKeepAlives:   true,
+	})
+
+	if tr.MaxIdleConnsPerHost != 4 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 4", tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != 15*time.Second {
+		t.Errorf("IdleConnTimeout = %v, want 15s", tr.IdleConnTimeout)
+	}
+	if !tr.DisableKeepAlives {
+		t.Error("DisableKeepAlives = false, want true")
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil {
+		t.Errorf("ForceHTTP1 must disable HTTP/2 (ForceAttemptHTTP2=%v, TLSNextProto=%v)",
+			tr.ForceAttemptHTTP2, tr.TLSNextProto)
+	}
+
+	// The zero value keeps BuildTransport's defaults.
+	def := BuildTransportWithOptions(TransportOptions{})
+	if def.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || def.IdleConnTimeout != DefaultIdleConnTimeout ||
+		def.DisableKeepAlives || !def.ForceAttemptHTTP2 {
+		t.Errorf("zero TransportOptions changed the defaults: %+v", def)
+	}
+}
+
+// TestBuildTransport_Proxy verifies the proxy comes from the environment by
+// default and that TransportOptions.Proxy takes over from it.
+func TestBuildTransport_Proxy(t *testing.T) {
+	if BuildTransport().Proxy == nil {
+		t.Error("BuildTransport().Proxy = nil, want http.ProxyFromEnvironment")
+	}
+
+	proxy, _ := url.Parse("http://proxy.example.com:3128")
+	tr := BuildTransportWithOptions(TransportOptions{Proxy: proxy})
+	req, _ := http.NewRequest(http.MethodGet, "https://upstream.example.org/releases", nil)
+	got, err := tr.Proxy(req)
+	if err != nil || got == nil || got.String() != proxy.String() {
+		t.Errorf("Proxy(req) = %v, %v; want %v", got, err, proxy)
+	}
+}
+
 // TestMaxBodyBytes_Value verifies that the MaxBodyBytes constant equals 10 MiB.
 func TestMaxBodyBytes_Value(t *testing.T) {
 	const want int64 = 10485760 // 10 * 1024 * 1024
@@ -0,0 +1,301 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// Errors for bump operations
+var (
+	// ErrInvalidBumpVersion is returned when the new version is not a valid
+	// Gentoo version.
+	ErrInvalidBumpVersion = errors.New("invalid version")
+	// ErrNoEbuildToBump is returned when the package has no ebuild to copy:
+	// none at all, or only live ones, when the old version is omitted.
+	ErrNoEbuildToBump = errors.New("no ebuild to bump from")
+	// ErrBumpTargetExists is returned when the package already has an ebuild
+	// for the new version.
+	ErrBumpTargetExists = errors.New("ebuild for the new version already exists")
+)
+
+// BumpOptions controls Bump.
+type BumpOptions struct {
+	// OldVersion is the version whose ebuild is copied, revision included
+	// ("1.2.3-r1"); empty copies the highest non-live ebuild
+	OldVersion string
+	// UpdateLiterals replaces the old version spelled out in the ebuild
+	// (MY_PV="1.2.3", a hardcoded SRC_URI, its "1_2_3" form) with the new one.
+	// Ebuilds normally derive everything from ${PV}, so it is off by default.
+	UpdateLiterals bool
+	NoManifest     bool // Skip Manifest regeneration
+	NoStage        bool // Leave the new ebuild and the Manifest unstaged
+	DryRun         bool // Report what would be done without writing anything
+}
+
+// BumpResult contains the outcome of a Bump.
+type BumpResult struct {
+	Category   string
+	Package    string
+	OldVersion string
+	NewVersion string
+	OldPath    string // Full path to the copied ebuild
+	NewPath    string // Full path to the new ebuild
+	// Literals is the number of version literals UpdateLiterals replaced
+	Literals int
+	// Manifest is the Manifest regeneration result, nil when it was not run
+	Manifest *ManifestUpdate
+	// ManifestSkipped is true when Manifest regeneration was skipped because
+	// pkgdev is not installed; the new ebuild is still written.
+	ManifestSkipped bool
+	// Staged lists the paths staged, relative to the overlay
+	Staged []string
+}
+
+// Bump creates a new version of pkg (category/package) by copying one of its
+// ebuilds to newVersion. Unlike Rename, which moves the ebuild, the old
+// version stays: this is the usual way to add a release while keeping the
+// previous one around. With no opts.OldVersion the highest non-live ebuild is
+// copied, the one the autoupdate checker takes for the current version.
+//
+// The new ebuild is then given a Manifest entry and staged along with the
+// Manifest. A failure of either is reported in the result or the returned
+// error, but leaves the new ebuild in place.
+func Bump(cfg *config.Config, pkg, newVersion string, opts *BumpOptions) (*BumpResult, error) {
+	if opts == nil {
+		opts = &BumpOptions{}
+	}
+	overlayPath := cfg.Overlay.Path
+	if err := RequireOverlay(overlayPath); err != nil {
+		return nil, err
+	}
+	category, name, ok := strings.Cut(pkg, "/")
+	if !ok || category == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPackageAtom, pkg)
+	}
+	if !ebuild.IsValidVersion(newVersion) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBumpVersion, newVersion)
+	}
+
+	pkgDir := filepath.Join(overlayPath, category, name)
+	if info, err := os.Stat(pkgDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)
+	}
+
+	oldVersion := opts.OldVersion
+	if oldVersion == "" {
+		oldVersion = highestEbuildVersion(pkgDir, category, name)
+		if oldVersion == "" {
+			return nil, fmt.Errorf("%w: %s has no non-live ebuild", ErrNoEbuildToBump, pkg)
+		}
+	}
+	result := &BumpResult{
+		Category:   category,
+		Package:    name,
+		OldVersion: oldVersion,
+		NewVersion: newVersion,
+		OldPath:    filepath.Join(pkgDir, name+"-"+oldVersion+".ebuild"),
+		NewPath:    filepath.Join(pkgDir, name+"-"+newVersion+".ebuild"),
+	}
+
+	content, err := os.ReadFile(result.OldPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s-%s", ErrEbuildVersionNotFound, pkg, oldVersion)
+		}
+		return nil, err
+	}
+	if _, err := os.Stat(result.NewPath); err == nil {
+		return result, fmt.Errorf("%w: %s", ErrBumpTargetExists, result.NewPath)
+	}
+
+	if opts.UpdateLiterals {
+		oldBase, _ := splitRevision(oldVersion)
+		newBase, _ := splitRevision(newVersion)
+		var replaced string
+		replaced, result.Literals = replaceVersionLiterals(string(content), oldBase, newBase)
+		content = []byte(replaced)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// O_EXCL closes the window between the Stat above and the write: a file
+	// created meanwhile is a conflict too, never overwritten.
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(result.OldPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.OpenFile(result.NewPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		if os.IsExist(err) {
+			return result, fmt.Errorf("%w: %s", ErrBumpTargetExists, result.NewPath)
+		}
+		return nil, err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(result.NewPath)
+		return nil, fmt.Errorf("failed to write %s: %w", result.NewPath, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(result.NewPath)
+		return nil, fmt.Errorf("failed to write %s: %w", result.NewPath, err)
+	}
+
+	toStage := []string{filepath.Join(category, name, filepath.Base(result.NewPath))}
+	if !opts.NoManifest {
+		if ManifestToolAvailable() {
+			// Keep the existing entries: the new version usually shares
+			// nothing with the old one's distfiles, but the old ebuild
+			// stays and still needs them.
+			updates := RegenerateManifests(overlayPath, []ManifestUpdate{{Category: category, Package: name}}, &ManifestOptions{Keep: true})
+			if len(updates) == 1 {
+				result.Manifest = &updates[0]
+				if updates[0].Success {
+					toStage = append(toStage, filepath.Join(category, name, "Manifest"))
+				}
+			}
+		} else {
+			result.ManifestSkipped = true
+		}
+	}
+
+	if !opts.NoStage {
+		if err := git.NewGitRunner(overlayPath).Add(toStage...); err != nil {
+			return result, fmt.Errorf("failed to stage %s: %w", strings.Join(toStage, ", "), err)
+		}
+		result.Staged = toStage
+	}
+	return result, nil
+}
+
+// highestEbuildVersion returns the highest version among the non-live
+// ebuilds of the package in pkgDir, "" when there is none. It selects like
+// the autoupdate checker's current version, revisions included.
+func highestEbuildVersion(pkgDir, category, name string) string {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return ""
+	}
+	var highest string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".ebuild") {
+			continue
+		}
+		eb, err := ebuild.ParsePath(filepath.Join(category, name, entry.Name()))
+		if err != nil || eb.Package != name || isLiveVersion(eb.Version) {
+			continue
+		}
+		if highest == "" || ebuild.CompareVersions(eb.Version, highest) > 0 {
+			highest = eb.Version
+		}
+	}
+	return highest
+}
+
+// replaceVersionLiterals replaces every standalone occurrence of oldVersion in
+// content with newVersion, along with its underscore and dash forms
+// ("1_2_3", "1-2-3", as MY_PV often spells it), and returns the count. An
+// occurrence inside a longer version is left alone: "1.2" is not replaced in
+// "1.2.3" or "11.2", nor "1.2.3" in "1.2.3.4", while "v1.2.3" and
+// "1.2.3.tar.gz" are.
+func replaceVersionLiterals(content, oldVersion, newVersion string) (string, int) {
+	if oldVersion == "" || oldVersion == newVersion {
+		return content, 0
+	}
+	total := 0
+	content, n := replaceVersionLiteral(content, oldVersion, newVersion)
+	total += n
+	if strings.Contains(oldVersion, ".") {
+		for _, sep := range []string{"_", "-"} {
+			content, n = replaceVersionLiteral(content,
+				strings.ReplaceAll(oldVersion, ".", sep),
+				strings.ReplaceAll(newVersion, ".", sep))
+			total += n
+		}
+	}
+	return content, total
+}
+
+// replaceVersionLiteral is replaceVersionLiterals for one spelling.
+func replaceVersionLiteral(content, old, replacement string) (string, int) {
+	var sb strings.Builder
+	count := 0
+	for {
+		i := strings.Index(content, old)
+		if i < 0 {
+			sb.WriteString(content)
+			return sb.String(), count
+		}
+		end := i + len(old)
+		if isVersionBoundary(content, i, end) {
+			sb.WriteString(content[:i])
+			sb.WriteString(replacement)
+			count++
+		} else {
+			sb.WriteString(content[:end])
+		}
+		content = content[end:]
+	}
+}
+
+// isVersionBoundary reports whether content[start:end] stands alone as a
+// version: not preceded by a digit or a dot, nor followed by a letter, a
+// digit, or a dot and a digit.
+func isVersionBoundary(content string, start, end int) bool {
+	if start > 0 {
+		if c := content[start-1]; isDigit(c) || c == '.' {
+			return false
+		}
+	}
+	if end < len(content) {
+		c := content[end]
+		if isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+			return false
+		}
+		if c == '.' && end+1 < len(content) && isDigit(content[end+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// FormatBumpResult formats the bump result for display.
+func FormatBumpResult(result *BumpResult, dryRun bool) string {
+	var sb strings.Builder
+	verb := "Bumped"
+	if dryRun {
+		verb = "Dry run: would bump"
+	}
+	fmt.Fprintf(&sb, "%s %s/%s: %s → %s\n", verb, result.Category, result.Package,
+		filepath.Base(result.OldPath), filepath.Base(result.NewPath))
+	if result.Literals > 0 {
+		fmt.Fprintf(&sb, "  %d version literal(s) updated\n", result.Literals)
+	}
+	switch {
+	case result.ManifestSkipped:
+		sb.WriteString("\nManifest generation skipped: pkgdev not found (install dev-util/pkgdev)\n")
+		fmt.Fprintf(&sb, "Run 'bentoo overlay manifest %s/%s' manually\n", result.Category, result.Package)
+	case result.Manifest != nil && result.Manifest.Success:
+		sb.WriteString("  Manifest updated\n")
+	case result.Manifest != nil:
+		fmt.Fprintf(&sb, "\nManifest update failed: %s\n", result.Manifest.Error)
+	}
+	if len(result.Staged) > 0 {
+		fmt.Fprintf(&sb, "  Staged %s\n", strings.Join(result.Staged, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,153 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// setupBumpOverlay creates a test overlay holding app-misc/foo 1.0, 1.2-r1
+// and a live 9999 ebuild, with pkgdev reported missing.
+func setupBumpOverlay(t *testing.T) (string, *config.Config) {
+	t.Helper()
+	dir, cfg, cleanup := setupTestOverlay(t)
+	t.Cleanup(cleanup)
+
+	oldLook := lookPath
+	t.Cleanup(func() { lookPath = oldLook })
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+
+	pkgDir := filepath.Join(dir, "app-misc", "foo")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	for name, content := range map[string]string{
+		"foo-1.0.ebuild":    "EAPI=8\nSRC_URI=\"https://example.com/${P}.tar.gz\"\n",
+		"foo-1.2-r1.ebuild": "EAPI=8\nMY_PV=\"1_2\"\nSRC_URI=\"https://example.com/foo-1.2.tar.gz\"\nKEYWORDS=\"~amd64\"\n",
+		"foo-9999.ebuild":   "EAPI=8\ninherit git-r3\n",
+	} {
+		if err := os.WriteFile(filepath.Join(pkgDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	return dir, cfg
+}
+
+// TestBump verifies the highest non-live ebuild is copied, not moved, that
+// the copy is staged, and that the Manifest is skipped without pkgdev.
+func TestBump(t *testing.T) {
+	dir, cfg := setupBumpOverlay(t)
+
+	result, err := Bump(cfg, "app-misc/foo", "1.3", nil)
+	if err != nil {
+		t.Fatalf("Bump() error = %v", err)
+	}
+	if result.OldVersion != "1.2-r1" {
+		t.Errorf("OldVersion = %q, want 1.2-r1 (the highest non-live ebuild)", result.OldVersion)
+	}
+	old, _ := os.ReadFile(filepath.Join(dir, "app-misc/foo/foo-1.2-r1.ebuild"))
+	bumped, err := os.ReadFile(filepath.Join(dir, "app-misc/foo/foo-1.3.ebuild"))
+	if err != nil || string(bumped) != string(old) {
+		t.Errorf("new ebuild = %q, %v; want a copy of %q", bumped, err, old)
+	}
+	if !result.ManifestSkipped || result.Manifest != nil {
+		t.Errorf("ManifestSkipped = %v, Manifest = %+v; want skipped without pkgdev", result.ManifestSkipped, result.Manifest)
+	}
+
+	cmd := exec.Command("git", "diff", "--cached", "--name-only")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git diff --cached: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "app-misc/foo/foo-1.3.ebuild" {
+		t.Errorf("staged = %q, want only the new ebuild", got)
+	}
+
+	// An explicit old version is copied instead, and a dry run writes nothing.
+	result, err = Bump(cfg, "app-misc/foo", "1.1", &BumpOptions{OldVersion: "1.0", DryRun: true})
+	if err != nil || result.OldPath != filepath.Join(dir, "app-misc/foo/foo-1.0.ebuild") {
+		t.Fatalf("Bump(dry run) = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(result.NewPath); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote %s", result.NewPath)
+	}
+}
+
+// TestBumpLiterals verifies UpdateLiterals replaces the old version spelled
+// out in the ebuild, in its dotted and underscore forms, without the
+// revision.
+func TestBumpLiterals(t *testing.T) {
+	dir, cfg := setupBumpOverlay(t)
+
+	result, err := Bump(cfg, "app-misc/foo", "1.3", &BumpOptions{UpdateLiterals: true, NoStage: true})
+	if err != nil {
+		t.Fatalf("Bump() error = %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "app-misc/foo/foo-1.3.ebuild"))
+	want := "EAPI=8\nMY_PV=\"1_3\"\nSRC_URI=\"https://example.com/foo-1.3.tar.gz\"\nKEYWORDS=\"~amd64\"\n"
+	if string(got) != want || result.Literals != 2 {
+		t.Errorf("new ebuild = %q (%d literals), want %q (2)", got, result.Literals, want)
+	}
+}
+
+// TestReplaceVersionLiterals verifies only standalone versions are replaced.
+func TestReplaceVersionLiterals(t *testing.T) {
+	tests := []struct {
+		content, old, new, want string
+		count                   int
+	}{
+		{`SRC_URI="https://x/v1.2/foo-1.2.tar.gz"`, "1.2", "1.3", `SRC_URI="https://x/v1.3/foo-1.3.tar.gz"`, 2},
+		{`MY_PV="1.2.3" DEP=">=dev-libs/bar-1.2"`, "1.2", "1.3", `MY_PV="1.2.3" DEP=">=dev-libs/bar-1.3"`, 1},
+		{`A="11.2 1.20 1.2b 1.2"`, "1.2", "2.0", `A="11.2 1.20 1.2b 2.0"`, 1},
+		{`MY_PV="1-2-3"`, "1.2.3", "1.3.0", `MY_PV="1-3-0"`, 1},
+		{`EAPI=8`, "8", "9", `EAPI=9`, 1},
+		{`same 1.2`, "1.2", "1.2", `same 1.2`, 0},
+	}
+	for _, tt := range tests {
+		got, n := replaceVersionLiterals(tt.content, tt.old, tt.new)
+		if got != tt.want || n != tt.count {
+			t.Errorf("replaceVersionLiterals(%q, %s, %s) = %q, %d; want %q, %d", tt.content, tt.old, tt.new, got, n, tt.want, tt.count)
+		}
+	}
+}
+
+// TestBumpConflicts verifies an existing target is never overwritten and
+// that bad input is rejected before anything is written.
+func TestBumpConflicts(t *testing.T) {
+	dir, cfg := setupBumpOverlay(t)
+
+	if _, err := Bump(cfg, "app-misc/foo", "1.0", nil); !errors.Is(err, ErrBumpTargetExists) {
+		t.Errorf("Bump(existing version) error = %v, want %v", err, ErrBumpTargetExists)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "app-misc/foo/foo-1.0.ebuild")); !strings.Contains(string(got), "${P}") {
+		t.Errorf("existing ebuild overwritten: %q", got)
+	}
+	if _, err := Bump(cfg, "app-misc/foo", "2.0", &BumpOptions{OldVersion: "1.1"}); !errors.Is(err, ErrEbuildVersionNotFound) {
+		t.Errorf("Bump(missing old version) error = %v, want %v", err, ErrEbuildVersionNotFound)
+	}
+	if _, err := Bump(cfg, "app-misc/foo", "v2", nil); !errors.Is(err, ErrInvalidBumpVersion) {
+		t.Errorf("Bump(invalid version) error = %v, want %v", err, ErrInvalidBumpVersion)
+	}
+	if _, err := Bump(cfg, "foo", "2.0", nil); !errors.Is(err, ErrInvalidPackageAtom) {
+		t.Errorf("Bump(no category) error = %v, want %v", err, ErrInvalidPackageAtom)
+	}
+	if _, err := Bump(cfg, "app-misc/bar", "2.0", nil); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("Bump(unknown package) error = %v, want %v", err, ErrPackageNotFound)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "app-misc/live"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app-misc/live/live-9999.ebuild"), []byte("EAPI=8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Bump(cfg, "app-misc/live", "1.0", nil); !errors.Is(err, ErrNoEbuildToBump) {
+		t.Errorf("Bump(live only) error = %v, want %v", err, ErrNoEbuildToBump)
+	}
+}
@@ -364,7 +364,12 @@ func formatRepoFileChangeGroup(ct ChangeType, files []RepoFileChange) string {
 	if len(files) == 0 {
 		return ""
 	}
+	return string(ct) + "(" + formatRepoFileList(files) + ")"
+}
 
+// formatRepoFileList formats file changes of the same type, grouped by kind,
+// without the surrounding "type(...)".
+func formatRepoFileList(files []RepoFileChange) string {
 	byKind := make(map[FileKind][]RepoFileChange)
 	for _, f := range files {
 		byKind[f.Kind] = append(byKind[f.Kind], f)
@@ -381,7 +386,7 @@ func formatRepoFileChangeGroup(ct ChangeType, files []RepoFileChange) string {
 		kindParts = append(kindParts, formatFileKindGroup(kind, group))
 	}
 
-	return string(ct) + "(" + strings.Join(kindParts, ", ") + ")"
+	return strings.Join(kindParts, ", ")
 }
 
 // formatFileKindGroup formats a group of file changes sharing the same kind.
@@ -425,7 +430,12 @@ func formatChangeGroup(ct ChangeType, changes []Change) string {
 	if len(changes) == 0 {
 		return ""
 	}
+	return string(ct) + "(" + formatChangeList(ct, changes) + ")"
+}
 
+// formatChangeList formats changes of the same type, grouped by category,
+// without the surrounding "type(...)".
+func formatChangeList(ct ChangeType, changes []Change) string {
 	// Group by category
 	byCategory := make(map[string][]Change)
 	for _, c := range changes {
@@ -446,7 +456,7 @@ func formatChangeGroup(ct ChangeType, changes []Change) string {
 		categoryParts = append(categoryParts, part)
 	}
 
-	return string(ct) + "(" + strings.Join(categoryParts, ", ") + ")"
+	return strings.Join(categoryParts, ", ")
 }
 
 // formatCategoryChanges formats changes within a single category
@@ -0,0 +1,153 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidMessageFormat is returned by ParseMessageFormat for an unknown
+// commit message format.
+var ErrInvalidMessageFormat = errors.New("invalid commit message format")
+
+// MessageFormat selects the style of generated commit messages.
+type MessageFormat string
+
+const (
+	// FormatBentoo is the default style: "add(app-misc/test-1.0)",
+	// "up(app-misc/test-1.0 -> 2.0)"
+	FormatBentoo MessageFormat = "bentoo"
+	// FormatConventional is the Conventional Commits style:
+	// "feat(app-misc/test): add 1.0", "feat(app-misc/test): bump 1.0 -> 2.0"
+	FormatConventional MessageFormat = "conventional"
+)
+
+// ParseMessageFormat parses a format name as set in git.commit_format. An
+// empty name is FormatBentoo.
+func ParseMessageFormat(name string) (MessageFormat, error) {
+	switch f := MessageFormat(strings.ToLower(strings.TrimSpace(name))); f {
+	case "":
+		return FormatBentoo, nil
+	case FormatBentoo, FormatConventional:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q (valid: %s, %s)", ErrInvalidMessageFormat, name, FormatBentoo, FormatConventional)
+	}
+}
+
+// conventionalTypes maps each change type to its Conventional Commits type
+// and the verb describing it. A version bump is a feature, like an addition;
+// a downgrade usually backs out a broken release.
+var conventionalTypes = map[ChangeType]struct {
+	commitType string
+	verb       string
+}{
+	Add:  {"feat", "add"},
+	Up:   {"feat", "bump"},
+	Down: {"fix", "downgrade"},
+	Del:  {"chore", "drop"},
+	Mod:  {"chore", "update"},
+}
+
+// conventionalRank orders commit types by precedence: a message mixing
+// changes takes the type of its most significant one.
+var conventionalRank = map[string]int{"feat": 2, "fix": 1, "chore": 0}
+
+// GenerateFormattedMessage generates a commit message in the given format.
+// FormatBentoo is GenerateCommitMessage; FormatConventional renders the same
+// analysis, version bumps and grouping included, as
+// "<type>(<scope>): <description>".
+//
+// The scope is the one package ("app-misc/test") or category ("app-misc")
+// all package changes fall in, or the directory ("eclass") of file-only
+// changes of one kind; otherwise it is left out. The description lists each
+// change type with its verb in the usual add, del, mod, up, down order:
+// "feat(app-misc): add foo-1.0, bump bar-1.0 -> 2.0".
+func GenerateFormattedMessage(changes []Change, files []RepoFileChange, format MessageFormat) string {
+	if format != FormatConventional {
+		return GenerateCommitMessage(changes, files)
+	}
+	if len(changes) == 0 && len(files) == 0 {
+		return "chore: update package files"
+	}
+
+	byType := make(map[ChangeType][]Change)
+	for _, c := range changes {
+		byType[c.Type] = append(byType[c.Type], c)
+	}
+	filesByType := make(map[ChangeType][]RepoFileChange)
+	for _, f := range files {
+		filesByType[f.Type] = append(filesByType[f.Type], f)
+	}
+
+	scope, trim := conventionalScope(changes, files)
+	commitType := "chore"
+	var parts []string
+	for _, ct := range []ChangeType{Add, Del, Mod, Up, Down} {
+		if len(byType[ct]) == 0 && len(filesByType[ct]) == 0 {
+			continue
+		}
+		conv := conventionalTypes[ct]
+		if conventionalRank[conv.commitType] > conventionalRank[commitType] {
+			commitType = conv.commitType
+		}
+
+		var items []string
+		if len(byType[ct]) > 0 {
+			items = append(items, trim(formatChangeList(ct, byType[ct])))
+		}
+		if len(filesByType[ct]) > 0 {
+			items = append(items, trim(formatRepoFileList(filesByType[ct])))
+		}
+		parts = append(parts, conv.verb+" "+strings.Join(items, ", "))
+	}
+
+	if scope != "" {
+		commitType += "(" + scope + ")"
+	}
+	return commitType + ": " + strings.Join(parts, ", ")
+}
+
+// conventionalScope returns the scope shared by all changes, or "", and a
+// function trimming that scope from the formatted change lists: a package
+// scope leaves "1.0" of "app-misc/test-1.0", a category or directory scope
+// leaves "test-1.0" of "app-misc/test-1.0".
+func conventionalScope(changes []Change, files []RepoFileChange) (string, func(string) string) {
+	none := func(s string) string { return s }
+
+	if len(changes) > 0 {
+		if len(files) > 0 {
+			return "", none
+		}
+		category, pkg := changes[0].Category, changes[0].Package
+		samePackage, sameCategory := true, true
+		for _, c := range changes[1:] {
+			if c.Category != category {
+				sameCategory = false
+			}
+			if c.Category != category || c.Package != pkg {
+				samePackage = false
+			}
+		}
+		switch {
+		case samePackage:
+			scope := category + "/" + pkg
+			return scope, func(s string) string { return strings.TrimPrefix(s, scope+"-") }
+		case sameCategory:
+			return category, func(s string) string { return strings.TrimPrefix(s, category+"/") }
+		}
+		return "", none
+	}
+
+	dir, ok := kindDirectory[files[0].Kind]
+	if !ok {
+		return "", none
+	}
+	for _, f := range files[1:] {
+		if f.Kind != files[0].Kind {
+			return "", none
+		}
+	}
+	return dir, func(s string) string { return strings.TrimPrefix(s, dir+"/") }
+}
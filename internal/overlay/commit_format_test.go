@@ -0,0 +1,103 @@
+package overlay
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseMessageFormat covers the default, case folding and rejection of an
+// unknown format.
+func TestParseMessageFormat(t *testing.T) {
+	for name, want := range map[string]MessageFormat{"": FormatBentoo, "bentoo": FormatBentoo, " Conventional ": FormatConventional} {
+		if got, err := ParseMessageFormat(name); err != nil || got != want {
+			t.Errorf("ParseMessageFormat(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseMessageFormat("angular"); !errors.Is(err, ErrInvalidMessageFormat) {
+		t.Errorf("ParseMessageFormat(\"angular\") error = %v, want %v", err, ErrInvalidMessageFormat)
+	}
+}
+
+// TestGenerateFormattedMessage covers add, bump and remove in both formats,
+// and the conventional scope and type for mixed changes.
+func TestGenerateFormattedMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		changes      []Change
+		files        []RepoFileChange
+		bentoo       string
+		conventional string
+	}{
+		{
+			name:         "add",
+			changes:      []Change{{Type: Add, Category: "app-misc", Package: "test", Version: "1.0"}},
+			bentoo:       "add(app-misc/test-1.0)",
+			conventional: "feat(app-misc/test): add 1.0",
+		},
+		{
+			name:         "bump",
+			changes:      []Change{{Type: Up, Category: "app-misc", Package: "test", OldVersion: "1.0", Version: "2.0"}},
+			bentoo:       "up(app-misc/test-1.0 -> 2.0)",
+			conventional: "feat(app-misc/test): bump 1.0 -> 2.0",
+		},
+		{
+			name:         "remove",
+			changes:      []Change{{Type: Del, Category: "app-misc", Package: "test", Version: "1.0"}},
+			bentoo:       "del(app-misc/test-1.0)",
+			conventional: "chore(app-misc/test): drop 1.0",
+		},
+		{
+			name:         "downgrade",
+			changes:      []Change{{Type: Down, Category: "app-misc", Package: "test", OldVersion: "2.0", Version: "1.0"}},
+			bentoo:       "down(app-misc/test-2.0 -> 1.0)",
+			conventional: "fix(app-misc/test): downgrade 2.0 -> 1.0",
+		},
+		{
+			name: "one category",
+			changes: []Change{
+				{Type: Del, Category: "app-misc", Package: "old", Version: "0.1"},
+				{Type: Up, Category: "app-misc", Package: "test", OldVersion: "1.0", Version: "2.0"},
+			},
+			bentoo:       "del(app-misc/old-0.1), up(app-misc/test-1.0 -> 2.0)",
+			conventional: "feat(app-misc): drop old-0.1, bump test-1.0 -> 2.0",
+		},
+		{
+			name: "shared version across categories",
+			changes: []Change{
+				{Type: Add, Category: "app-misc", Package: "a", Version: "1.0"},
+				{Type: Add, Category: "app-misc", Package: "b", Version: "1.0"},
+				{Type: Add, Category: "dev-util", Package: "c", Version: "3"},
+			},
+			bentoo:       "add(app-misc/{a, b}-1.0, dev-util/c-3)",
+			conventional: "feat: add app-misc/{a, b}-1.0, dev-util/c-3",
+		},
+		{
+			name:         "eclass only",
+			files:        []RepoFileChange{{Type: Mod, Kind: KindEclass, Path: "eclass/rpm.eclass", Name: "rpm.eclass"}},
+			bentoo:       "mod(eclass/rpm.eclass)",
+			conventional: "chore(eclass): update rpm.eclass",
+		},
+		{
+			name:         "packages and files",
+			changes:      []Change{{Type: Add, Category: "app-misc", Package: "test", Version: "1.0"}},
+			files:        []RepoFileChange{{Type: Add, Kind: KindLicense, Path: "licenses/foo", Name: "foo"}},
+			bentoo:       "add(app-misc/test-1.0), add(licenses/foo)",
+			conventional: "feat: add app-misc/test-1.0, licenses/foo",
+		},
+		{
+			name:         "nothing",
+			bentoo:       "update: package files",
+			conventional: "chore: update package files",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateFormattedMessage(tt.changes, tt.files, FormatBentoo); got != tt.bentoo {
+				t.Errorf("bentoo format = %q, want %q", got, tt.bentoo)
+			}
+			if got := GenerateFormattedMessage(tt.changes, tt.files, FormatConventional); got != tt.conventional {
+				t.Errorf("conventional format = %q, want %q", got, tt.conventional)
+			}
+		})
+	}
+}
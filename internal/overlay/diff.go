@@ -0,0 +1,87 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+var (
+	// ErrInvalidPackageAtom is returned when a package is not given as
+	// category/package.
+	ErrInvalidPackageAtom = errors.New("package must be given as category/package")
+	// ErrEbuildVersionNotFound is returned by DiffVersions when the package has
+	// no ebuild for one of the versions.
+	ErrEbuildVersionNotFound = errors.New("no ebuild for version")
+)
+
+// DiffOptions configures Diff and DiffVersions.
+type DiffOptions struct {
+	// Staged shows what is staged for the next commit instead of the
+	// unstaged changes, mirroring `git diff --staged`. DiffVersions ignores it.
+	Staged bool
+	// Color keeps git's ANSI colors in the returned diff.
+	Color bool
+}
+
+// gitOptions converts the options to the git runner's.
+func (o *DiffOptions) gitOptions() git.DiffOptions {
+	if o == nil {
+		return git.DiffOptions{}
+	}
+	return git.DiffOptions{Staged: o.Staged, Color: o.Color}
+}
+
+// Diff returns the git diff of the overlay limited to the given paths, which
+// are a category/package, any other path relative to the overlay root, or an
+// absolute path inside it. With no paths the whole overlay is diffed. It is
+// what a maintainer reviews before Commit: the unstaged changes by default,
+// the staged ones with opts.Staged.
+func Diff(cfg *config.Config, opts *DiffOptions, paths ...string) (string, error) {
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		return "", err
+	}
+	return git.NewGitRunner(overlayPath).Diff(opts.gitOptions(), paths...)
+}
+
+// DiffVersions returns the diff between two ebuilds of pkg (category/package),
+// from oldVersion's to newVersion's, which after a version bump shows what
+// changed beyond the version. Versions are as in the file name, revision
+// included ("1.2.3-r1"). The ebuilds need not be tracked by git.
+func DiffVersions(cfg *config.Config, pkg, oldVersion, newVersion string, opts *DiffOptions) (string, error) {
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		return "", err
+	}
+	category, name, ok := strings.Cut(pkg, "/")
+	if !ok || category == "" || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPackageAtom, pkg)
+	}
+
+	pkgDir := filepath.Join(overlayPath, category, name)
+	if info, err := os.Stat(pkgDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)
+	}
+	ebuildPaths := make([]string, 0, 2)
+	for _, version := range []string{oldVersion, newVersion} {
+		path := filepath.Join(pkgDir, name+"-"+version+".ebuild")
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%w: %s-%s", ErrEbuildVersionNotFound, pkg, version)
+		}
+		ebuildPaths = append(ebuildPaths, path)
+	}
+
+	// Paths relative to the overlay keep the diff headers short and
+	// meaningful: a/category/package/package-1.0.ebuild.
+	runner := git.NewGitRunner(overlayPath)
+	oldRel, _ := filepath.Rel(overlayPath, ebuildPaths[0])
+	newRel, _ := filepath.Rel(overlayPath, ebuildPaths[1])
+	return runner.DiffFiles(opts.gitOptions(), oldRel, newRel)
+}
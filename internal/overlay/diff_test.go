@@ -0,0 +1,105 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// setupDiffOverlay creates a test overlay with app-misc/foo-1.0 committed, a
+// bump to 1.1 written but not added, and an unstaged edit to a second package.
+func setupDiffOverlay(t *testing.T) (*config.Config, func()) {
+	t.Helper()
+	dir, cfg, cleanup := setupTestOverlay(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	write("app-misc/foo/foo-1.0.ebuild", "EAPI=8\nKEYWORDS=\"~amd64\"\n")
+	write("app-misc/bar/bar-2.0.ebuild", "EAPI=8\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	write("app-misc/foo/foo-1.1.ebuild", "EAPI=8\nKEYWORDS=\"~amd64 ~arm64\"\n")
+	write("app-misc/foo/foo-1.0.ebuild", "EAPI=8\nKEYWORDS=\"amd64\"\n")
+	git("add", "app-misc/foo/foo-1.0.ebuild")
+	write("app-misc/bar/bar-2.0.ebuild", "EAPI=8\nIUSE=\"doc\"\n")
+	return cfg, cleanup
+}
+
+// TestDiff verifies the diff is scoped to the package and follows git's
+// staged/unstaged split.
+func TestDiff(t *testing.T) {
+	cfg, cleanup := setupDiffOverlay(t)
+	defer cleanup()
+
+	unstaged, err := Diff(cfg, nil, "app-misc/bar")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if !strings.Contains(unstaged, `+IUSE="doc"`) || strings.Contains(unstaged, "foo-1.0") {
+		t.Errorf("Diff(app-misc/bar) = %q, want only bar's unstaged change", unstaged)
+	}
+
+	staged, err := Diff(cfg, &DiffOptions{Staged: true}, "app-misc/foo")
+	if err != nil {
+		t.Fatalf("Diff(staged) error = %v", err)
+	}
+	if !strings.Contains(staged, `+KEYWORDS="amd64"`) || strings.Contains(staged, "bar-2.0") {
+		t.Errorf("Diff(app-misc/foo, staged) = %q, want only foo's staged change", staged)
+	}
+
+	if none, err := Diff(cfg, nil, "app-misc/foo"); err != nil || none != "" {
+		t.Errorf("Diff(app-misc/foo) = %q, %v; want nothing unstaged (the new ebuild is untracked)", none, err)
+	}
+}
+
+// TestDiffVersions verifies two ebuilds of a package are diffed even when one
+// is untracked, and that bad atoms and unknown versions are reported.
+func TestDiffVersions(t *testing.T) {
+	cfg, cleanup := setupDiffOverlay(t)
+	defer cleanup()
+
+	out, err := DiffVersions(cfg, "app-misc/foo", "1.0", "1.1", nil)
+	if err != nil {
+		t.Fatalf("DiffVersions() error = %v", err)
+	}
+	for _, want := range []string{"a/app-misc/foo/foo-1.0.ebuild", "b/app-misc/foo/foo-1.1.ebuild", `-KEYWORDS="amd64"`, `+KEYWORDS="~amd64 ~arm64"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DiffVersions() = %q, missing %q", out, want)
+		}
+	}
+
+	if _, err := DiffVersions(cfg, "app-misc/foo", "1.0", "9.9", nil); !errors.Is(err, ErrEbuildVersionNotFound) {
+		t.Errorf("DiffVersions(unknown version) error = %v, want %v", err, ErrEbuildVersionNotFound)
+	}
+	if _, err := DiffVersions(cfg, "app-misc/missing", "1.0", "1.1", nil); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("DiffVersions(unknown package) error = %v, want %v", err, ErrPackageNotFound)
+	}
+	for _, atom := range []string{"foo", "app-misc/", "/foo", "app-misc/foo/files"} {
+		if _, err := DiffVersions(cfg, atom, "1.0", "1.1", nil); !errors.Is(err, ErrInvalidPackageAtom) {
+			t.Errorf("DiffVersions(%q) error = %v, want %v", atom, err, ErrInvalidPackageAtom)
+		}
+	}
+}
@@ -31,6 +31,15 @@ var (
 	ErrManifestInvalidScope = errors.New("invalid manifest scope")
 )
 
+// ManifestToolAvailable reports whether pkgdev, the tool every Manifest
+// regeneration shells out to, is on PATH. Flows that regenerate Manifests as a
+// side effect (rename) check it up front so a missing tool produces a single
+// skip notice instead of one failure per package.
+func ManifestToolAvailable() bool {
+	_, err := lookPath("pkgdev")
+	return err == nil
+}
+
 // DefaultManifestJobs is the default number of pkgdev workers run in parallel
 // when ManifestOptions.Jobs is not set (or set to a non-positive value).
 const DefaultManifestJobs = 10
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -157,6 +158,10 @@ func (m *EbuildMatcher) matchPackageEbuilds(pkgPath, category, pkgName string, s
 		return nil, err
 	}
 
+	if spec.BumpRevision {
+		return m.matchRevisionBump(entries, pkgPath, category, pkgName, spec.OldVersion), nil
+	}
+
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -228,3 +233,78 @@ func (m *EbuildMatcher) buildRenameMatch(category, pkgName, oldFilename, oldVers
 		HasRevision: hasRevision,
 	}
 }
+
+// matchRevisionBump selects the ebuild a revision bump of oldVersion starts
+// from in one package: the ebuild of exactly oldVersion when it carries a
+// revision ("1.0.0-r1"), otherwise the highest revision of oldVersion, so
+// "1.0.0" bumps hello-1.0.0-r1.ebuild to -r2 rather than hello-1.0.0.ebuild
+// onto the existing -r1. It returns at most one match.
+func (m *EbuildMatcher) matchRevisionBump(entries []os.DirEntry, pkgPath, category, pkgName, oldVersion string) []RenameMatch {
+	exact := revisionRegex.MatchString(oldVersion)
+	best, bestRev := "", -1
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		version, ok := ebuildVersion(entry.Name(), pkgName)
+		if !ok {
+			continue
+		}
+		if exact {
+			if version == oldVersion {
+				best = version
+			}
+			continue
+		}
+		if base, rev := splitRevision(version); base == oldVersion && rev > bestRev {
+			best, bestRev = version, rev
+		}
+	}
+	if best == "" {
+		return nil
+	}
+
+	newVersion := bumpRevision(best)
+	match := RenameMatch{
+		Category:     category,
+		Package:      pkgName,
+		OldFilename:  pkgName + "-" + best + ".ebuild",
+		NewFilename:  pkgName + "-" + newVersion + ".ebuild",
+		HasRevision:  revisionRegex.MatchString(best),
+		RevisionBump: true,
+	}
+	match.OldPath = filepath.Join(pkgPath, match.OldFilename)
+	match.NewPath = filepath.Join(pkgPath, match.NewFilename)
+	return []RenameMatch{match}
+}
+
+// ebuildVersion returns the version, revision included, of an ebuild
+// filename of pkgName ("hello-1.0.0-r1.ebuild" -> "1.0.0-r1").
+func ebuildVersion(filename, pkgName string) (string, bool) {
+	prefix, suffix := pkgName+"-", ".ebuild"
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, suffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(filename, prefix), suffix), true
+}
+
+// splitRevision splits a version into its base and revision number, 0 when
+// it has no -rN suffix ("1.0.0-r2" -> "1.0.0", 2).
+func splitRevision(version string) (string, int) {
+	m := revisionRegex.FindStringSubmatch(version)
+	if m == nil {
+		return version, 0
+	}
+	rev, err := strconv.Atoi(m[1])
+	if err != nil {
+		return version, 0
+	}
+	return strings.TrimSuffix(version, m[0]), rev
+}
+
+// bumpRevision returns version one revision up: "1.0.0" -> "1.0.0-r1",
+// "1.0.0-r1" -> "1.0.0-r2".
+func bumpRevision(version string) string {
+	base, rev := splitRevision(version)
+	return base + "-r" + strconv.Itoa(rev+1)
+}
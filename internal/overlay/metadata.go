@@ -0,0 +1,191 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// Errors for metadata.xml generation
+var (
+	// ErrMetadataExists is returned by GenerateMetadata when the package
+	// already has a metadata.xml and Force is not set.
+	ErrMetadataExists = errors.New("metadata.xml already exists; use force to overwrite")
+	// ErrPackageNotFound is returned when the package directory holds no
+	// ebuild.
+	ErrPackageNotFound = errors.New("package not found in overlay")
+)
+
+// metadataHeader opens every generated metadata.xml: the XML declaration and
+// the DOCTYPE pkgcheck and repoman validate against.
+const metadataHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE pkgmetadata SYSTEM "https://www.gentoo.org/dtd/metadata.dtd">
+`
+
+var (
+	// metadataHomepageRegex matches HOMEPAGE="..." or HOMEPAGE='...'
+	metadataHomepageRegex = regexp.MustCompile(`(?m)^HOMEPAGE=["']([^"']+)["']`)
+	// remoteIDRegexes map a HOMEPAGE URL to an upstream remote-id, by
+	// remote-id type. The first group is the remote-id value.
+	remoteIDRegexes = []struct {
+		Type  string
+		Regex *regexp.Regexp
+	}{
+		{"github", regexp.MustCompile(`^https?://(?:www\.)?github\.com/([^/\s"'#?]+/[^/\s"'#?]+)`)},
+		{"gitlab", regexp.MustCompile(`^https?://(?:www\.)?gitlab\.com/([^\s"'#?]+?)(?:/-/.*)?/?$`)},
+		{"pypi", regexp.MustCompile(`^https?://(?:www\.)?pypi\.(?:org|python\.org)/project/([^/\s"'#?]+)`)},
+	}
+)
+
+// MetadataOptions controls metadata.xml generation.
+type MetadataOptions struct {
+	Force bool // Overwrite an existing metadata.xml
+	// MaintainerType is the maintainer's type attribute, "person" when empty
+	MaintainerType string
+}
+
+// packageMetadata is the metadata.xml written by GenerateMetadata.
+type packageMetadata struct {
+	XMLName xml.Name `xml:"pkgmetadata"`
+	// Comment explains an empty document: "maintainer-needed" when there is
+	// no maintainer
+	Comment    string              `xml:",comment"`
+	Maintainer *metadataMaintainer `xml:"maintainer,omitempty"`
+	Upstream   *metadataUpstream   `xml:"upstream,omitempty"`
+}
+
+// metadataMaintainer is a metadata.xml <maintainer> block.
+type metadataMaintainer struct {
+	Type  string `xml:"type,attr"`
+	Email string `xml:"email"`
+	Name  string `xml:"name,omitempty"`
+}
+
+// metadataUpstream is a metadata.xml <upstream> block.
+type metadataUpstream struct {
+	RemoteIDs []metadataRemoteID `xml:"remote-id"`
+}
+
+// metadataRemoteID is an <upstream> <remote-id>, e.g. type "github" and
+// value "owner/repo".
+type metadataRemoteID struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GenerateMetadata writes a minimal metadata.xml for category/pkg and returns
+// its path. The maintainer block names the configured git user (see
+// Config.GetGitUser); without one the package is left maintainer-needed,
+// which is still valid. The upstream block lists a remote-id for each
+// HOMEPAGE URL on GitHub, GitLab or PyPI, read from the package's ebuilds,
+// and is omitted when there is none.
+//
+// An existing metadata.xml is only replaced with opts.Force; otherwise
+// ErrMetadataExists is returned and nothing is written.
+func GenerateMetadata(cfg *config.Config, category, pkg string, opts *MetadataOptions) (string, error) {
+	if opts == nil {
+		opts = &MetadataOptions{}
+	}
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		return "", err
+	}
+
+	pkgDir := filepath.Join(overlayPath, category, pkg)
+	ebuilds, err := filepath.Glob(filepath.Join(pkgDir, pkg+"-*.ebuild"))
+	if err != nil || len(ebuilds) == 0 {
+		return "", fmt.Errorf("%w: %s/%s", ErrPackageNotFound, category, pkg)
+	}
+
+	metadataPath := filepath.Join(pkgDir, "metadata.xml")
+	if _, err := os.Stat(metadataPath); err == nil && !opts.Force {
+		return "", fmt.Errorf("%w: %s/%s", ErrMetadataExists, category, pkg)
+	}
+
+	meta := packageMetadata{}
+	if user, email, err := cfg.GetGitUser(); err == nil {
+		maintainerType := opts.MaintainerType
+		if maintainerType == "" {
+			maintainerType = "person"
+		}
+		meta.Maintainer = &metadataMaintainer{Type: maintainerType, Email: email, Name: user}
+	} else {
+		meta.Comment = " maintainer-needed "
+	}
+	if ids := upstreamRemoteIDs(ebuilds); len(ids) > 0 {
+		meta.Upstream = &metadataUpstream{RemoteIDs: ids}
+	}
+
+	content, err := formatMetadata(&meta)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(metadataPath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write metadata.xml: %w", err)
+	}
+	return metadataPath, nil
+}
+
+// formatMetadata renders meta as a metadata.xml document, tab-indented like
+// the ones in ::gentoo.
+func formatMetadata(meta *packageMetadata) ([]byte, error) {
+	body, err := xml.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata.xml: %w", err)
+	}
+	// MarshalIndent puts the closing tag right after a trailing comment.
+	body = bytes.Replace(body, []byte("--></pkgmetadata>"), []byte("-->\n</pkgmetadata>"), 1)
+	return []byte(metadataHeader + string(body) + "\n"), nil
+}
+
+// upstreamRemoteIDs returns the remote-ids for the HOMEPAGE URLs of ebuilds,
+// each once, sorted by type and value so regeneration is stable.
+func upstreamRemoteIDs(ebuilds []string) []metadataRemoteID {
+	seen := make(map[metadataRemoteID]bool)
+	var ids []metadataRemoteID
+	for _, path := range ebuilds {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		m := metadataHomepageRegex.FindSubmatch(content)
+		if m == nil {
+			continue
+		}
+		for _, homepage := range strings.Fields(string(m[1])) {
+			id, ok := remoteIDFromURL(homepage)
+			if ok && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Type != ids[j].Type {
+			return ids[i].Type < ids[j].Type
+		}
+		return ids[i].Value < ids[j].Value
+	})
+	return ids
+}
+
+// remoteIDFromURL returns the remote-id a homepage URL identifies, if its
+// host is one of remoteIDRegexes.
+func remoteIDFromURL(homepage string) (metadataRemoteID, bool) {
+	for _, r := range remoteIDRegexes {
+		if m := r.Regex.FindStringSubmatch(homepage); m != nil {
+			value := strings.TrimSuffix(strings.TrimSuffix(m[1], "/"), ".git")
+			return metadataRemoteID{Type: r.Type, Value: value}, true
+		}
+	}
+	return metadataRemoteID{}, false
+}
@@ -0,0 +1,125 @@
+package overlay
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// setupMetadataTestPackage creates an overlay with app-misc/hello holding an
+// ebuild with the given HOMEPAGE, and a HOME without a .gitconfig so the
+// maintainer comes from cfg.
+func setupMetadataTestPackage(t *testing.T, homepage string) (*config.Config, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	overlayPath := setupRenameTestOverlay(t)
+	t.Cleanup(func() { os.RemoveAll(overlayPath) })
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0")
+	ebuild := filepath.Join(overlayPath, "app-misc", "hello", "hello-1.0.ebuild")
+	if err := os.WriteFile(ebuild, []byte("EAPI=8\nHOMEPAGE=\""+homepage+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Overlay: config.OverlayConfig{Path: overlayPath},
+		Git:     config.GitConfig{User: "Jane Dev", Email: "jane@example.com"},
+	}
+	return cfg, filepath.Join(overlayPath, "app-misc", "hello", "metadata.xml")
+}
+
+// TestGenerateMetadata tests the maintainer block, the remote-ids derived
+// from HOMEPAGE, and that the result is a well-formed document.
+func TestGenerateMetadata(t *testing.T) {
+	cfg, metadataPath := setupMetadataTestPackage(t,
+		"https://github.com/example/hello.git https://pypi.org/project/hello/ https://gitlab.com/group/sub/hello/-/tree/main https://example.com")
+
+	path, err := GenerateMetadata(cfg, "app-misc", "hello", nil)
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+	if path != metadataPath {
+		t.Errorf("GenerateMetadata() = %q, want %q", path, metadataPath)
+	}
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.HasPrefix(content, metadataHeader) {
+		t.Errorf("metadata.xml does not start with the XML declaration and DOCTYPE:\n%s", content)
+	}
+
+	var meta packageMetadata
+	if err := xml.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("metadata.xml does not parse: %v\n%s", err, content)
+	}
+	if m := meta.Maintainer; m == nil || m.Type != "person" || m.Email != "jane@example.com" || m.Name != "Jane Dev" {
+		t.Errorf("maintainer = %+v, want Jane Dev <jane@example.com>", meta.Maintainer)
+	}
+	want := []metadataRemoteID{
+		{Type: "github", Value: "example/hello"},
+		{Type: "gitlab", Value: "group/sub/hello"},
+		{Type: "pypi", Value: "hello"},
+	}
+	if meta.Upstream == nil || len(meta.Upstream.RemoteIDs) != len(want) {
+		t.Fatalf("upstream = %+v, want %v", meta.Upstream, want)
+	}
+	for i, id := range meta.Upstream.RemoteIDs {
+		if id != want[i] {
+			t.Errorf("remote-id %d = %+v, want %+v", i, id, want[i])
+		}
+	}
+}
+
+// TestGenerateMetadataNoUpstream tests that a homepage on no known host and
+// a missing git user yield a maintainer-needed file without <upstream>.
+func TestGenerateMetadataNoUpstream(t *testing.T) {
+	cfg, metadataPath := setupMetadataTestPackage(t, "https://example.com/hello")
+	cfg.Git = config.GitConfig{}
+
+	if _, err := GenerateMetadata(cfg, "app-misc", "hello", nil); err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if strings.Contains(content, "<upstream>") || strings.Contains(content, "<maintainer") {
+		t.Errorf("metadata.xml has an upstream or maintainer block:\n%s", content)
+	}
+	if !strings.Contains(content, "maintainer-needed") {
+		t.Errorf("metadata.xml is not marked maintainer-needed:\n%s", content)
+	}
+}
+
+// TestGenerateMetadataOverwrite tests that an existing metadata.xml is only
+// replaced with Force, and that a missing package is reported.
+func TestGenerateMetadataOverwrite(t *testing.T) {
+	cfg, metadataPath := setupMetadataTestPackage(t, "https://github.com/example/hello")
+	if err := os.WriteFile(metadataPath, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GenerateMetadata(cfg, "app-misc", "hello", nil); !errors.Is(err, ErrMetadataExists) {
+		t.Errorf("GenerateMetadata() error = %v, want %v", err, ErrMetadataExists)
+	}
+	if data, _ := os.ReadFile(metadataPath); string(data) != "original" {
+		t.Errorf("metadata.xml overwritten without Force: %q", data)
+	}
+
+	if _, err := GenerateMetadata(cfg, "app-misc", "hello", &MetadataOptions{Force: true}); err != nil {
+		t.Fatalf("GenerateMetadata(Force) error = %v", err)
+	}
+	if data, _ := os.ReadFile(metadataPath); !strings.Contains(string(data), "example/hello") {
+		t.Errorf("metadata.xml not regenerated with Force:\n%s", data)
+	}
+
+	if _, err := GenerateMetadata(cfg, "app-misc", "missing", nil); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("GenerateMetadata(missing) error = %v, want %v", err, ErrPackageNotFound)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
@@ -13,6 +14,9 @@ import (
 // Errors for rename operations
 var (
 	ErrOverlayPathNotSet = errors.New("overlay path is not configured")
+	// ErrRevisionBumpVersion is returned when a revision bump names a new
+	// version: the bump keeps the version and only increments the revision.
+	ErrRevisionBumpVersion = errors.New("a revision bump keeps the version; new version must be empty or equal the old version")
 )
 
 // VersionFilesBlockError indicates that version-specific files were detected
@@ -43,11 +47,32 @@ func (e *ConflictError) Error() string {
 }
 
 // RenameSpec specifies what to rename.
+//
+// With BumpRevision the version stays and the revision goes up by one
+// instead: hello-1.0.0.ebuild becomes -r1, hello-1.0.0-r1.ebuild -r2. Each
+// package bumps its highest revision of OldVersion, or exactly OldVersion
+// when it carries a revision itself ("1.0.0-r1"); NewVersion is empty or
+// repeats OldVersion.
 type RenameSpec struct {
 	Category       string // "*" for all categories, or specific category
 	PackagePattern string // Glob pattern for package names
 	OldVersion     string // Exact old version to match
 	NewVersion     string // New version to rename to
+	BumpRevision   bool   // Increment the -rN revision instead of renaming
+}
+
+// validate checks the spec's pattern and, for a revision bump, that it
+// does not also change the version.
+func (s *RenameSpec) validate() error {
+	if err := NewPatternValidator().Validate(s.PackagePattern); err != nil {
+		return err
+	}
+	if s.BumpRevision && s.NewVersion != "" && s.NewVersion != s.OldVersion {
+		if base, _ := splitRevision(s.OldVersion); s.NewVersion != base {
+			return fmt.Errorf("%w: %s => %s", ErrRevisionBumpVersion, s.OldVersion, s.NewVersion)
+		}
+	}
+	return nil
 }
 
 // RenameOptions controls rename behavior.
@@ -56,6 +81,10 @@ type RenameOptions struct {
 	SkipPrompt bool // Skip confirmation prompts
 	NoManifest bool // Skip Manifest updates
 	Force      bool // Proceed despite warnings
+	// RenameFiles renames the files under files/ named after the old version
+	// along with the ebuild ("hello-1.0.0-fix.patch" to
+	// "hello-2.0.0-fix.patch") instead of leaving them to --force
+	RenameFiles bool
 }
 
 // RenameMatch represents a single ebuild to be renamed.
@@ -67,6 +96,19 @@ type RenameMatch struct {
 	OldPath     string // Full path to old file
 	NewPath     string // Full path to new file
 	HasRevision bool   // True if old filename had -rN suffix
+	// RevisionBump is true when NewFilename is the next revision of the
+	// same version (RenameSpec.BumpRevision)
+	RevisionBump bool
+	// Files are the files under files/ renamed along with the ebuild: the
+	// revision-specific ones of a revision bump, or the version-specific
+	// ones with RenameOptions.RenameFiles
+	Files []FileRename
+}
+
+// FileRename is a file under files/ renamed along with its ebuild.
+type FileRename struct {
+	OldPath string
+	NewPath string
 }
 
 // RenameResult contains the outcome of a rename operation.
@@ -78,6 +120,13 @@ type RenameResult struct {
 	Conflicts       []Conflict       // Target files that already exist
 	ManifestUpdates []ManifestUpdate // Manifest update results
 	Warnings        []string         // Non-fatal scan warnings
+	// ManifestSkipped is true when Manifest regeneration was skipped because
+	// pkgdev is not installed; the renames themselves still happened.
+	ManifestSkipped bool
+
+	// overlayPath is the overlay Rename worked in, for UndoRename's Manifest
+	// regeneration
+	overlayPath string
 }
 
 // RenameError represents a failed rename operation.
@@ -132,6 +181,12 @@ func ShouldBlockForVersionFiles(versionFiles []VersionFile, force bool) bool {
 // RenamePreview finds matching ebuilds and detects potential issues without executing.
 // Used to show a preview before confirmation.
 func RenamePreview(cfg *config.Config, spec *RenameSpec) (*RenameResult, error) {
+	return RenamePreviewWithOptions(cfg, spec, &RenameOptions{})
+}
+
+// RenamePreviewWithOptions is RenamePreview for the options Rename will run
+// with, so the preview lists the files RenameFiles moves along.
+func RenamePreviewWithOptions(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameResult, error) {
 	result := &RenameResult{}
 
 	// Get overlay path from config
@@ -141,8 +196,7 @@ func RenamePreview(cfg *config.Config, spec *RenameSpec) (*RenameResult, error)
 	}
 
 	// Validate pattern
-	validator := NewPatternValidator()
-	if err := validator.Validate(spec.PackagePattern); err != nil {
+	if err := spec.validate(); err != nil {
 		return nil, err
 	}
 
@@ -161,21 +215,64 @@ func RenamePreview(cfg *config.Config, spec *RenameSpec) (*RenameResult, error)
 	}
 
 	// Detect version-specific files
-	detector := NewVersionFilesDetector(overlayPath)
-	versionFiles := detector.Detect(result.Matches, spec.OldVersion)
-	result.VersionFiles = versionFiles
+	result.VersionFiles = detectVersionFiles(overlayPath, spec, opts, result.Matches)
 
 	// Check for conflicts (target files that already exist)
-	for _, match := range result.Matches {
-		if _, err := os.Stat(match.NewPath); err == nil {
-			result.Conflicts = append(result.Conflicts, Conflict{
-				Match:    match,
-				Existing: match.NewPath,
-			})
+	result.Conflicts = findConflicts(result.Matches)
+
+	return result, nil
+}
+
+// detectVersionFiles reports the files under files/ named after the old
+// version, which a rename leaves behind. A revision bump keeps the version,
+// so those files stay valid; its revision-specific files are renamed with
+// the ebuild instead (see DetectRevisionFiles) and nothing is reported.
+// With RenameFiles the files named after the version are set on the matches
+// to be renamed too (see DetectVersionFileRenames), and only the rest, which
+// merely contain the version, are reported.
+func detectVersionFiles(overlayPath string, spec *RenameSpec, opts *RenameOptions, matches []RenameMatch) []VersionFile {
+	detector := NewVersionFilesDetector(overlayPath)
+	if spec.BumpRevision {
+		detector.DetectRevisionFiles(matches)
+		return nil
+	}
+	versionFiles := detector.Detect(matches, spec.OldVersion)
+	if !opts.RenameFiles {
+		return versionFiles
+	}
+
+	detector.DetectVersionFileRenames(matches, spec.OldVersion, spec.NewVersion)
+	renamed := make(map[string]bool)
+	for _, match := range matches {
+		for _, f := range match.Files {
+			renamed[f.OldPath] = true
+		}
+	}
+	var left []VersionFile
+	for _, vf := range versionFiles {
+		if !renamed[vf.Path] {
+			left = append(left, vf)
 		}
 	}
+	return left
+}
 
-	return result, nil
+// findConflicts returns the rename targets, ebuilds and files alike, that
+// already exist.
+func findConflicts(matches []RenameMatch) []Conflict {
+	var conflicts []Conflict
+	for _, match := range matches {
+		targets := []string{match.NewPath}
+		for _, f := range match.Files {
+			targets = append(targets, f.NewPath)
+		}
+		for _, target := range targets {
+			if _, err := os.Stat(target); err == nil {
+				conflicts = append(conflicts, Conflict{Match: match, Existing: target})
+			}
+		}
+	}
+	return conflicts
 }
 
 // FormatRenamePreview formats the preview for display before confirmation.
@@ -191,9 +288,7 @@ func FormatRenamePreview(result *RenameResult, isGlobalSearch bool) string {
 	for _, match := range result.Matches {
 		fmt.Fprintf(&sb, "  %s/%s:\n", match.Category, match.Package)
 		fmt.Fprintf(&sb, "    %s → %s\n", match.OldFilename, match.NewFilename)
-		if match.HasRevision {
-			sb.WriteString("    (revision suffix will be stripped)\n")
-		}
+		writeRenameMatchNotes(&sb, match)
 	}
 
 	if len(result.VersionFiles) > 0 {
@@ -215,21 +310,37 @@ func FormatRenamePreview(result *RenameResult, isGlobalSearch bool) string {
 	return sb.String()
 }
 
+// writeRenameMatchNotes writes what a match does besides renaming the ebuild:
+// the files it moves along, or the revision it drops.
+func writeRenameMatchNotes(sb *strings.Builder, match RenameMatch) {
+	for _, f := range match.Files {
+		fmt.Fprintf(sb, "    files/%s → files/%s\n", filepath.Base(f.OldPath), filepath.Base(f.NewPath))
+	}
+	if match.HasRevision && !match.RevisionBump {
+		sb.WriteString("    (revision suffix will be stripped)\n")
+	}
+}
+
 // Rename performs bulk ebuild renaming.
 // It validates the pattern, finds matching ebuilds, detects version files,
 // and performs the rename operation (or simulates it in dry-run mode).
+// The result's Renamed list records every move made, which UndoRename
+// reverses.
 func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameResult, error) {
-	result := &RenameResult{}
-
 	// Get overlay path from config
 	overlayPath := cfg.Overlay.Path
 	if overlayPath == "" {
 		return nil, ErrOverlayPathNotSet
 	}
+	result := &RenameResult{overlayPath: overlayPath}
+
+	// Refuse to move files around in a directory that is not an overlay
+	if err := RequireOverlay(overlayPath); err != nil {
+		return nil, err
+	}
 
 	// Validate pattern
-	validator := NewPatternValidator()
-	if err := validator.Validate(spec.PackagePattern); err != nil {
+	if err := spec.validate(); err != nil {
 		return nil, err
 	}
 
@@ -248,8 +359,7 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 	}
 
 	// Detect version-specific files
-	detector := NewVersionFilesDetector(overlayPath)
-	versionFiles := detector.Detect(result.Matches, spec.OldVersion)
+	versionFiles := detectVersionFiles(overlayPath, spec, opts, result.Matches)
 	result.VersionFiles = versionFiles
 
 	// Check if version files should block the operation
@@ -258,14 +368,7 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 	}
 
 	// Check for conflicts (target files that already exist)
-	for _, match := range result.Matches {
-		if _, err := os.Stat(match.NewPath); err == nil {
-			result.Conflicts = append(result.Conflicts, Conflict{
-				Match:    match,
-				Existing: match.NewPath,
-			})
-		}
-	}
+	result.Conflicts = findConflicts(result.Matches)
 
 	// If conflicts exist and not forcing, return early
 	if len(result.Conflicts) > 0 && !opts.Force {
@@ -278,6 +381,8 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 	}
 
 	// Perform the actual rename operations
+	// A match's files follow its ebuild; one that fails to move is reported,
+	// but the ebuild stays renamed and Renamed lists only the moved files.
 	for _, match := range result.Matches {
 		err := os.Rename(match.OldPath, match.NewPath)
 		if err != nil {
@@ -285,14 +390,32 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 				Match:   match,
 				Message: err.Error(),
 			})
-		} else {
-			result.Renamed = append(result.Renamed, match)
+			continue
+		}
+		var moved []FileRename
+		for _, f := range match.Files {
+			if err := os.Rename(f.OldPath, f.NewPath); err != nil {
+				result.Failed = append(result.Failed, RenameError{
+					Match:   match,
+					Message: fmt.Sprintf("ebuild renamed, but not files/%s: %v", filepath.Base(f.OldPath), err),
+				})
+				continue
+			}
+			moved = append(moved, f)
 		}
+		match.Files = moved
+		result.Renamed = append(result.Renamed, match)
 	}
 
-	// Update Manifests unless --no-manifest is set
+	// Update Manifests unless --no-manifest is set. A missing pkgdev is
+	// detected once up front and reported as a single skip rather than as a
+	// failure for every renamed package.
 	if !opts.NoManifest && len(result.Renamed) > 0 {
-		result.ManifestUpdates = updateManifests(result.Renamed, overlayPath)
+		if ManifestToolAvailable() {
+			result.ManifestUpdates = updateManifests(result.Renamed, overlayPath)
+		} else {
+			result.ManifestSkipped = true
+		}
 	}
 
 	return result, nil
@@ -339,15 +462,16 @@ func FormatRenameResult(result *RenameResult, dryRun bool) string {
 		for _, match := range result.Matches {
 			fmt.Fprintf(&sb, "  %s/%s:\n", match.Category, match.Package)
 			fmt.Fprintf(&sb, "    %s → %s\n", match.OldFilename, match.NewFilename)
-			if match.HasRevision {
-				sb.WriteString("    (revision suffix will be stripped)\n")
-			}
+			writeRenameMatchNotes(&sb, match)
 		}
 	} else {
 		if len(result.Renamed) > 0 {
 			fmt.Fprintf(&sb, "Renamed %d ebuild(s):\n\n", len(result.Renamed))
 			for _, match := range result.Renamed {
 				fmt.Fprintf(&sb, "  %s/%s: %s → %s\n", match.Category, match.Package, match.OldFilename, match.NewFilename)
+				for _, f := range match.Files {
+					fmt.Fprintf(&sb, "    files/%s → files/%s\n", filepath.Base(f.OldPath), filepath.Base(f.NewPath))
+				}
 			}
 		}
 
@@ -358,6 +482,11 @@ func FormatRenameResult(result *RenameResult, dryRun bool) string {
 			}
 		}
 
+		if result.ManifestSkipped {
+			sb.WriteString("\nManifest generation skipped: pkgdev not found (install dev-util/pkgdev)\n")
+			sb.WriteString("Run 'bentoo overlay manifest <category>/<package>' manually for the renamed packages\n")
+		}
+
 		// Show Manifest update results
 		if len(result.ManifestUpdates) > 0 {
 			successCount := 0
@@ -1,8 +1,14 @@
 package overlay
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
@@ -274,6 +280,28 @@ func TestFormatRenamePreviewWithWarnings(t *testing.T) {
 }
 
 // TestRename tests the Rename function.
+// TestRenameRejectsInvalidOverlay tests that Rename refuses a directory that
+// is not an overlay and leaves its files alone.
+func TestRenameRejectsInvalidOverlay(t *testing.T) {
+	overlayPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(overlayPath, "metadata"), 0755); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0")
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0", NewVersion: "2.0.0"}
+	opts := &RenameOptions{SkipPrompt: true, NoManifest: true}
+
+	_, err := Rename(cfg, spec, opts)
+	if !errors.Is(err, config.ErrOverlayInvalidStructure) {
+		t.Fatalf("Rename() error = %v, want ErrOverlayInvalidStructure", err)
+	}
+	if _, err := os.Stat(filepath.Join(overlayPath, "app-misc", "hello", "hello-1.0.0.ebuild")); err != nil {
+		t.Errorf("ebuild touched despite invalid overlay: %v", err)
+	}
+}
+
 func TestRename(t *testing.T) {
 	overlayPath := setupRenameTestOverlay(t)
 	defer os.RemoveAll(overlayPath)
@@ -766,6 +794,56 @@ func TestFormatRenameResultWithManifestFailure(t *testing.T) {
 	}
 }
 
+// TestRenameSkipsManifestWhenPkgdevMissing verifies that with pkgdev missing
+// the renames still happen and the result carries a single skip flag instead
+// of one failed ManifestUpdate per package.
+func TestRenameSkipsManifestWhenPkgdevMissing(t *testing.T) {
+	oldLook := lookPath
+	t.Cleanup(func() { lookPath = oldLook })
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0")
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello-world", "1.0.0")
+
+	cfg := &config.Config{
+		Overlay: config.OverlayConfig{Path: overlayPath},
+	}
+	spec := &RenameSpec{
+		Category:       "app-misc",
+		PackagePattern: "hello*",
+		OldVersion:     "1.0.0",
+		NewVersion:     "2.0.0",
+	}
+
+	result, err := Rename(cfg, spec, &RenameOptions{SkipPrompt: true})
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if len(result.Renamed) != 2 {
+		t.Fatalf("Rename() got %d renamed, want 2", len(result.Renamed))
+	}
+	if !result.ManifestSkipped {
+		t.Error("ManifestSkipped = false with pkgdev missing")
+	}
+	if len(result.ManifestUpdates) != 0 {
+		t.Errorf("got %d manifest updates, want none when skipped", len(result.ManifestUpdates))
+	}
+
+	output := FormatRenameResult(result, false)
+	if n := strings.Count(output, "Manifest generation skipped"); n != 1 {
+		t.Errorf("skip notice printed %d times, want 1:\n%s", n, output)
+	}
+	if strings.Contains(output, "Manifest update failed") {
+		t.Errorf("skip must not be reported as per-package failures:\n%s", output)
+	}
+	if !strings.Contains(output, "bentoo overlay manifest") {
+		t.Errorf("output should recommend running manifests manually:\n%s", output)
+	}
+}
+
 // TestFormatRenameResultWithConflicts tests FormatRenameResult with conflicts.
 func TestFormatRenameResultWithConflicts(t *testing.T) {
 	result := &RenameResult{
@@ -1170,8 +1248,20 @@ func TestShouldBlockForVersionFilesEdgeCases(t *testing.T) {
 }
 
 // TestRenameWithManifestUpdate tests Rename with manifest update enabled.
-// This test will fail if pkgdev is not installed, which is expected.
+// pkgdev is stubbed as present (and its invocation as a no-op) so the manifest
+// step runs regardless of the host; a missing pkgdev is covered by
+// TestRenameSkipsManifestWhenPkgdevMissing.
 func TestRenameWithManifestUpdate(t *testing.T) {
+	oldLook := lookPath
+	t.Cleanup(func() { lookPath = oldLook })
+	lookPath = func(string) (string, error) { return "/usr/bin/pkgdev", nil }
+
+	oldExec := execCommand
+	t.Cleanup(func() { execCommand = oldExec })
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "true")
+	}
+
 	overlayPath := setupRenameTestOverlay(t)
 	defer os.RemoveAll(overlayPath)
 
@@ -1202,8 +1292,6 @@ func TestRenameWithManifestUpdate(t *testing.T) {
 		t.Errorf("Rename() got %d renamed, want 1", len(result.Renamed))
 	}
 
-	// Manifest update may fail if pkgdev is not installed
-	// This is expected behavior - we just verify the result structure
 	if len(result.ManifestUpdates) != 1 {
 		t.Errorf("Rename() got %d manifest updates, want 1", len(result.ManifestUpdates))
 	}
@@ -1287,3 +1375,213 @@ func TestIsTokenCompleteNoWildcard(t *testing.T) {
 		t.Error("isTokenComplete(\"hello\") should return true")
 	}
 }
+
+// TestRenameBumpRevision tests revision bumps: from no revision to -r1, and
+// from the highest revision up, moving its revision-specific files along.
+func TestRenameBumpRevision(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		files    []string
+		wantOld  string
+		wantNew  string
+		wantFile map[string]bool // files/ entries after the bump
+	}{
+		{
+			name:     "no revision to r1",
+			existing: []string{"1.0.0"},
+			files:    []string{"hello-1.0.0-fix.patch"},
+			wantOld:  "hello-1.0.0.ebuild",
+			wantNew:  "hello-1.0.0-r1.ebuild",
+			wantFile: map[string]bool{"hello-1.0.0-fix.patch": true},
+		},
+		{
+			name:     "r1 to r2",
+			existing: []string{"1.0.0", "1.0.0-r1", "0.9"},
+			files:    []string{"hello-1.0.0-r1-fix.patch", "hello-1.0.0-r10.patch", "hello-1.0.0.conf"},
+			wantOld:  "hello-1.0.0-r1.ebuild",
+			wantNew:  "hello-1.0.0-r2.ebuild",
+			wantFile: map[string]bool{
+				"hello-1.0.0-r2-fix.patch": true,
+				"hello-1.0.0-r1-fix.patch": false,
+				"hello-1.0.0-r10.patch":    true,
+				"hello-1.0.0.conf":         true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlayPath := setupRenameTestOverlay(t)
+			defer os.RemoveAll(overlayPath)
+			for _, v := range tt.existing {
+				createRenameTestEbuild(t, overlayPath, "app-misc", "hello", v)
+			}
+			filesDir := filepath.Join(overlayPath, "app-misc", "hello", "files")
+			if err := os.MkdirAll(filesDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(filesDir, f), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+			spec := &RenameSpec{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0", BumpRevision: true}
+			result, err := Rename(cfg, spec, &RenameOptions{NoManifest: true})
+			if err != nil {
+				t.Fatalf("Rename() error = %v", err)
+			}
+			if len(result.Renamed) != 1 || len(result.Failed) != 0 || len(result.VersionFiles) != 0 {
+				t.Fatalf("Rename() = %d renamed, %d failed, %d version files; want 1, 0, 0",
+					len(result.Renamed), len(result.Failed), len(result.VersionFiles))
+			}
+			m := result.Renamed[0]
+			if m.OldFilename != tt.wantOld || m.NewFilename != tt.wantNew || !m.RevisionBump {
+				t.Errorf("renamed %s → %s (bump %v), want %s → %s", m.OldFilename, m.NewFilename, m.RevisionBump, tt.wantOld, tt.wantNew)
+			}
+			if _, err := os.Stat(filepath.Join(overlayPath, "app-misc", "hello", tt.wantNew)); err != nil {
+				t.Errorf("new ebuild missing: %v", err)
+			}
+			for f, want := range tt.wantFile {
+				_, err := os.Stat(filepath.Join(filesDir, f))
+				if (err == nil) != want {
+					t.Errorf("files/%s exists = %v, want %v", f, err == nil, want)
+				}
+			}
+		})
+	}
+}
+
+// TestRenameBumpRevisionConflict tests that bumping an explicit revision
+// onto one that already exists is reported as a conflict.
+func TestRenameBumpRevisionConflict(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0-r1")
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0-r2")
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0-r1", BumpRevision: true}
+	_, err := Rename(cfg, spec, &RenameOptions{NoManifest: true})
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Rename() error = %v, want a ConflictError", err)
+	}
+	if len(conflictErr.Conflicts) != 1 || filepath.Base(conflictErr.Conflicts[0].Existing) != "hello-1.0.0-r2.ebuild" {
+		t.Errorf("conflicts = %+v, want hello-1.0.0-r2.ebuild", conflictErr.Conflicts)
+	}
+}
+
+// TestRenameBumpRevisionRejectsNewVersion tests that a revision bump which
+// also names a different version is rejected.
+func TestRenameBumpRevisionRejectsNewVersion(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0-r1")
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	for newVersion, wantErr := range map[string]bool{"": false, "1.0.0": false, "1.0.0-r1": false, "2.0.0": true} {
+		spec := &RenameSpec{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0-r1", NewVersion: newVersion, BumpRevision: true}
+		_, err := RenamePreview(cfg, spec)
+		if errors.Is(err, ErrRevisionBumpVersion) != wantErr {
+			t.Errorf("RenamePreview(new version %q) error = %v, want ErrRevisionBumpVersion: %v", newVersion, err, wantErr)
+		}
+	}
+}
+
+// TestRenameRenameFiles tests that RenameFiles renames the files named after
+// the old version, leaves those that merely contain it and files/
+// subdirectories alone, and moves nothing in a dry run.
+func TestRenameRenameFiles(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		t.Run(fmt.Sprintf("dry run %v", dryRun), func(t *testing.T) {
+			overlayPath := setupRenameTestOverlay(t)
+			defer os.RemoveAll(overlayPath)
+			createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0")
+			filesDir := filepath.Join(overlayPath, "app-misc", "hello", "files")
+			if err := os.MkdirAll(filepath.Join(filesDir, "1.0.0"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range []string{"hello-1.0.0-fix.patch", "1.0.0.conf", "hello-1.0.0.1.patch", "hello-11.0.0.patch", "1.0.0/hello-1.0.0.patch"} {
+				if err := os.WriteFile(filepath.Join(filesDir, f), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+			spec := &RenameSpec{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0", NewVersion: "2.0.0"}
+			opts := &RenameOptions{NoManifest: true, Force: true, RenameFiles: true, DryRun: dryRun}
+			result, err := Rename(cfg, spec, opts)
+			if err != nil {
+				t.Fatalf("Rename() error = %v", err)
+			}
+
+			var got []string
+			for _, f := range result.Matches[0].Files {
+				got = append(got, filepath.Base(f.OldPath)+" → "+filepath.Base(f.NewPath))
+			}
+			want := []string{"1.0.0.conf → 2.0.0.conf", "hello-1.0.0-fix.patch → hello-2.0.0-fix.patch"}
+			if !slices.Equal(got, want) {
+				t.Errorf("Files = %v, want %v", got, want)
+			}
+			// Only the files merely containing the version are still reported.
+			var left []string
+			for _, vf := range result.VersionFiles {
+				left = append(left, vf.Filename)
+			}
+			if want := []string{"hello-1.0.0.1.patch", "hello-11.0.0.patch"}; !slices.Equal(left, want) {
+				t.Errorf("VersionFiles = %v, want %v", left, want)
+			}
+
+			wantFiles := map[string]bool{
+				"hello-2.0.0-fix.patch":   !dryRun,
+				"hello-1.0.0-fix.patch":   dryRun,
+				"2.0.0.conf":              !dryRun,
+				"hello-1.0.0.1.patch":     true,
+				"hello-11.0.0.patch":      true,
+				"1.0.0/hello-1.0.0.patch": true,
+			}
+			for f, want := range wantFiles {
+				_, err := os.Stat(filepath.Join(filesDir, f))
+				if (err == nil) != want {
+					t.Errorf("files/%s exists = %v, want %v", f, err == nil, want)
+				}
+			}
+			if !dryRun && (len(result.Renamed) != 1 || len(result.Renamed[0].Files) != 2) {
+				t.Errorf("Renamed = %+v, want one match with both files", result.Renamed)
+			}
+		})
+	}
+}
+
+// TestRenameRenameFilesUnblocks tests that files RenameFiles renames no
+// longer block the rename without --force.
+func TestRenameRenameFilesUnblocks(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0")
+	filesDir := filepath.Join(overlayPath, "app-misc", "hello", "files")
+	if err := os.MkdirAll(filesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filesDir, "hello-1.0.0-fix.patch"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0", NewVersion: "2.0.0"}
+	if _, err := Rename(cfg, spec, &RenameOptions{NoManifest: true, DryRun: true}); err == nil {
+		t.Fatal("Rename() without RenameFiles succeeded, want a VersionFilesBlockError")
+	}
+	preview, err := RenamePreviewWithOptions(cfg, spec, &RenameOptions{RenameFiles: true})
+	if err != nil || len(preview.VersionFiles) != 0 || len(preview.Matches[0].Files) != 1 {
+		t.Fatalf("RenamePreviewWithOptions() = %+v, %v; want the patch as a file rename", preview, err)
+	}
+	if _, err := Rename(cfg, spec, &RenameOptions{NoManifest: true, RenameFiles: true}); err != nil {
+		t.Fatalf("Rename() with RenameFiles error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filesDir, "hello-2.0.0-fix.patch")); err != nil {
+		t.Errorf("patch not renamed: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrUndoRename is returned by UndoRename for each move it could not reverse.
+var ErrUndoRename = errors.New("cannot undo rename")
+
+// UndoOptions controls UndoRename behavior.
+type UndoOptions struct {
+	// UpdateManifest regenerates the Manifest of each restored package, as
+	// Rename does unless NoManifest is set. Off by default: a rename that
+	// is undone right away usually never had its Manifest committed.
+	UpdateManifest bool
+}
+
+// UndoRename reverses the moves recorded in result.Renamed, restoring the
+// original ebuild and files/ names, without touching Manifests. See
+// UndoRenameWithOptions.
+func UndoRename(result *RenameResult) error {
+	return UndoRenameWithOptions(result, &UndoOptions{})
+}
+
+// UndoRenameWithOptions reverses the moves recorded in result.Renamed, the
+// ones Rename actually performed, so a partially failed batch only undoes
+// what succeeded. Matches are undone in reverse order, each one's files
+// before its ebuild.
+//
+// A move that is already reversed (its new path gone and its old path back)
+// is skipped, so an undo that failed halfway can simply be run again. One
+// whose new path is missing, or whose old path has been taken since, is left
+// as is and reported with ErrUndoRename; the rest are still undone, and the
+// errors are joined.
+func UndoRenameWithOptions(result *RenameResult, opts *UndoOptions) error {
+	if result == nil {
+		return nil
+	}
+
+	var errs []error
+	var restored []RenameMatch
+	for i := len(result.Renamed) - 1; i >= 0; i-- {
+		match := result.Renamed[i]
+		ok := true
+		for j := len(match.Files) - 1; j >= 0; j-- {
+			if err := undoMove(match.Files[j].NewPath, match.Files[j].OldPath); err != nil {
+				errs = append(errs, err)
+				ok = false
+			}
+		}
+		if err := undoMove(match.NewPath, match.OldPath); err != nil {
+			errs = append(errs, err)
+			ok = false
+		}
+		if ok {
+			restored = append(restored, match)
+		}
+	}
+
+	if opts != nil && opts.UpdateManifest && len(restored) > 0 {
+		if !ManifestToolAvailable() {
+			errs = append(errs, fmt.Errorf("restoring Manifests: %w", ErrPkgdevNotFound))
+		} else {
+			for _, u := range updateManifests(restored, result.overlayPath) {
+				if !u.Success {
+					errs = append(errs, fmt.Errorf("restoring Manifest of %s/%s: %s", u.Category, u.Package, u.Error))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// undoMove moves from back to to, the reverse of a rename from to to from.
+// It refuses to overwrite an existing to, and does nothing if the move was
+// already undone.
+func undoMove(from, to string) error {
+	_, fromErr := os.Stat(from)
+	_, toErr := os.Stat(to)
+	switch {
+	case fromErr != nil && toErr == nil:
+		return nil
+	case fromErr != nil:
+		return fmt.Errorf("%w: %s is missing", ErrUndoRename, filepath.Base(from))
+	case toErr == nil:
+		return fmt.Errorf("%w: %s already exists", ErrUndoRename, to)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("%w: %v", ErrUndoRename, err)
+	}
+	return nil
+}
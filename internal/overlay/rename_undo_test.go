@@ -0,0 +1,95 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// TestUndoRename tests that undoing a rename brings back the original
+// ebuilds and files/ entries, and that undoing again is a no-op.
+func TestUndoRename(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+	createRenameTestEbuild(t, overlayPath, "app-misc", "gst-hello", "1.0.0-r1")
+	createRenameTestEbuild(t, overlayPath, "app-misc", "gst-world", "1.0.0")
+	filesDir := filepath.Join(overlayPath, "app-misc", "gst-hello", "files")
+	if err := os.MkdirAll(filesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filesDir, "gst-hello-1.0.0-fix.patch"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "app-misc", PackagePattern: "gst-*", OldVersion: "1.0.0", NewVersion: "2.0.0"}
+	result, err := Rename(cfg, spec, &RenameOptions{NoManifest: true, RenameFiles: true})
+	if err != nil || len(result.Renamed) != 2 {
+		t.Fatalf("Rename() = %+v, %v; want 2 renamed", result, err)
+	}
+
+	if err := UndoRename(result); err != nil {
+		t.Fatalf("UndoRename() error = %v", err)
+	}
+	want := map[string]bool{
+		"gst-hello/gst-hello-1.0.0-r1.ebuild":       true,
+		"gst-hello/files/gst-hello-1.0.0-fix.patch": true,
+		"gst-world/gst-world-1.0.0.ebuild":          true,
+		"gst-hello/gst-hello-2.0.0.ebuild":          false,
+		"gst-hello/files/gst-hello-2.0.0-fix.patch": false,
+		"gst-world/gst-world-2.0.0.ebuild":          false,
+	}
+	for f, exists := range want {
+		_, err := os.Stat(filepath.Join(overlayPath, "app-misc", f))
+		if (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", f, err == nil, exists)
+		}
+	}
+
+	if err := UndoRename(result); err != nil {
+		t.Errorf("second UndoRename() error = %v, want nil", err)
+	}
+}
+
+// TestUndoRenamePartial tests that only the moves recorded in Renamed are
+// undone, and that a move which cannot be reversed is reported while the
+// others still are.
+func TestUndoRenamePartial(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+	for _, pkg := range []string{"gst-a", "gst-b", "gst-c"} {
+		createRenameTestEbuild(t, overlayPath, "app-misc", pkg, "1.0.0")
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "app-misc", PackagePattern: "gst-*", OldVersion: "1.0.0", NewVersion: "2.0.0"}
+	result, err := Rename(cfg, spec, &RenameOptions{NoManifest: true})
+	if err != nil || len(result.Renamed) != 3 {
+		t.Fatalf("Rename() = %+v, %v; want 3 renamed", result, err)
+	}
+
+	// Pretend gst-c's move failed, so it is not recorded, and that
+	// gst-a-1.0.0.ebuild has been recreated since.
+	result.Renamed = result.Renamed[:2]
+	createRenameTestEbuild(t, overlayPath, "app-misc", "gst-a", "1.0.0")
+
+	if err := UndoRename(result); !errors.Is(err, ErrUndoRename) {
+		t.Fatalf("UndoRename() error = %v, want %v", err, ErrUndoRename)
+	}
+	want := map[string]bool{
+		"gst-a/gst-a-2.0.0.ebuild": true, // blocked by the recreated 1.0.0
+		"gst-b/gst-b-1.0.0.ebuild": true,
+		"gst-b/gst-b-2.0.0.ebuild": false,
+		"gst-c/gst-c-2.0.0.ebuild": true, // not recorded
+		"gst-c/gst-c-1.0.0.ebuild": false,
+	}
+	for f, exists := range want {
+		_, err := os.Stat(filepath.Join(overlayPath, "app-misc", f))
+		if (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", f, err == nil, exists)
+		}
+	}
+}
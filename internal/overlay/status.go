@@ -23,16 +23,16 @@ const (
 
 // FileChange represents a single file change within a package
 type FileChange struct {
-	Type   FileType // ebuild, manifest, metadata, files, other
-	Name   string   // filename
-	Status string   // Added, Modified, Deleted, Renamed, Untracked
+	Type   FileType `json:"type"`   // ebuild, manifest, metadata, files, other
+	Name   string   `json:"name"`   // filename
+	Status string   `json:"status"` // Added, Modified, Deleted, Renamed, Untracked
 }
 
 // PackageStatus represents the status of changes for a single package
 type PackageStatus struct {
-	Category string
-	Package  string
-	Changes  []FileChange
+	Category string       `json:"category"`
+	Package  string       `json:"package"`
+	Changes  []FileChange `json:"changes"`
 }
 
 // statusLabelMap maps git status codes to human-readable labels
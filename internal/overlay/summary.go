@@ -0,0 +1,131 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+// OverlaySummary is a snapshot of an overlay's state: its size, what is
+// waiting to be applied or committed, and which packages are incomplete.
+type OverlaySummary struct {
+	// Packages is the number of packages with at least one ebuild
+	Packages int `json:"packages"`
+	// Pending lists the overlay packages ("category/package") with a pending
+	// autoupdate entry
+	Pending []string `json:"pending"`
+	// Changes are the uncommitted changes, grouped as Status does
+	Changes []PackageStatus `json:"changes"`
+	// MissingMetadata lists the packages without a metadata.xml
+	MissingMetadata []string `json:"missing_metadata"`
+	// MissingManifest lists the packages fetching distfiles (an ebuild sets
+	// SRC_URI) but without a Manifest
+	MissingManifest []string `json:"missing_manifest"`
+}
+
+// Summarize builds the OverlaySummary of the configured overlay. pending are
+// the packages of the autoupdate pending list: the list lives in the
+// autoupdate package, which builds on this one, so the caller loads it.
+func Summarize(cfg *config.Config, pending []string) (*OverlaySummary, error) {
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		return nil, err
+	}
+	return SummarizeWithExecutor(overlayPath, git.NewGitRunner(overlayPath), pending)
+}
+
+// SummarizeWithExecutor builds the OverlaySummary of the overlay at
+// overlayPath, reading uncommitted changes through executor. Pending
+// packages no longer in the overlay are left out.
+func SummarizeWithExecutor(overlayPath string, executor git.GitExecutor, pending []string) (*OverlaySummary, error) {
+	scan, err := ScanOverlay(overlayPath)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := StatusWithExecutor(executor)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &OverlaySummary{
+		Packages:        len(scan.Packages),
+		Pending:         []string{},
+		Changes:         changes,
+		MissingMetadata: []string{},
+		MissingManifest: []string{},
+	}
+	inOverlay := make(map[string]bool, len(scan.Packages))
+	for _, pkg := range scan.Packages {
+		name := pkg.FullName()
+		inOverlay[name] = true
+		pkgDir := filepath.Join(overlayPath, pkg.Category, pkg.Package)
+		if !fileExists(filepath.Join(pkgDir, "metadata.xml")) {
+			summary.MissingMetadata = append(summary.MissingMetadata, name)
+		}
+		if !fileExists(filepath.Join(pkgDir, "Manifest")) && fetchesDistfiles(pkgDir) {
+			summary.MissingManifest = append(summary.MissingManifest, name)
+		}
+	}
+	for _, name := range pending {
+		if inOverlay[name] && !slices.Contains(summary.Pending, name) {
+			summary.Pending = append(summary.Pending, name)
+		}
+	}
+	slices.Sort(summary.Pending)
+	return summary, nil
+}
+
+// fileExists reports whether path exists and is a regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// fetchesDistfiles reports whether any ebuild in pkgDir sets SRC_URI, and so
+// needs a Manifest; a live or metapackage ebuild does without one.
+func fetchesDistfiles(pkgDir string) bool {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".ebuild") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(pkgDir, entry.Name()))
+		if err == nil && bytes.Contains(content, []byte("SRC_URI=")) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatOverlaySummary formats an overlay summary for display, followed by its
+// uncommitted changes as FormatStatus shows them.
+func FormatOverlaySummary(summary *OverlaySummary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Packages: %d\n", summary.Packages)
+	writeSummaryList(&sb, "Pending updates", summary.Pending)
+	writeSummaryList(&sb, "Missing metadata.xml", summary.MissingMetadata)
+	writeSummaryList(&sb, "Missing Manifest", summary.MissingManifest)
+	fmt.Fprintf(&sb, "Uncommitted changes: %d package(s)\n\n", len(summary.Changes))
+	sb.WriteString(FormatStatus(summary.Changes))
+	return sb.String()
+}
+
+// writeSummaryList writes a count line followed by one line per package.
+func writeSummaryList(sb *strings.Builder, label string, packages []string) {
+	fmt.Fprintf(sb, "%s: %d\n", label, len(packages))
+	for _, pkg := range packages {
+		category, name, _ := strings.Cut(pkg, "/")
+		fmt.Fprintf(sb, "  %s\n", output.FormatPackage(category, name))
+	}
+}
@@ -0,0 +1,66 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// TestSummarizeWithExecutor tests the package count, the pending packages
+// still in the overlay, and the metadata.xml and Manifest checks, the latter
+// only for packages fetching distfiles.
+func TestSummarizeWithExecutor(t *testing.T) {
+	overlayPath := t.TempDir()
+	writeFile := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(overlayPath, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("app-misc/complete/complete-1.0.ebuild", `SRC_URI="https://example.com/complete-1.0.tar.gz"`)
+	writeFile("app-misc/complete/Manifest", "DIST complete-1.0.tar.gz 1 BLAKE2B 0\n")
+	writeFile("app-misc/complete/metadata.xml", "<pkgmetadata/>\n")
+	writeFile("app-misc/nomanifest/nomanifest-1.0.ebuild", `SRC_URI="https://example.com/nomanifest-1.0.tar.gz"`)
+	writeFile("app-misc/nomanifest/metadata.xml", "<pkgmetadata/>\n")
+	writeFile("dev-util/live/live-9999.ebuild", "inherit git-r3\n")
+
+	executor := git.NewMockGitRunner(overlayPath)
+	executor.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{{Status: "M", FilePath: "app-misc/complete/complete-1.0.ebuild"}}, nil
+	}
+
+	summary, err := SummarizeWithExecutor(overlayPath, executor, []string{"dev-util/live", "app-misc/gone", "dev-util/live"})
+	if err != nil {
+		t.Fatalf("SummarizeWithExecutor() error = %v", err)
+	}
+	if summary.Packages != 3 {
+		t.Errorf("Packages = %d, want 3", summary.Packages)
+	}
+	if want := []string{"dev-util/live"}; !slices.Equal(summary.Pending, want) {
+		t.Errorf("Pending = %v, want %v", summary.Pending, want)
+	}
+	if want := []string{"dev-util/live"}; !slices.Equal(summary.MissingMetadata, want) {
+		t.Errorf("MissingMetadata = %v, want %v", summary.MissingMetadata, want)
+	}
+	if want := []string{"app-misc/nomanifest"}; !slices.Equal(summary.MissingManifest, want) {
+		t.Errorf("MissingManifest = %v, want %v", summary.MissingManifest, want)
+	}
+	if len(summary.Changes) != 1 || summary.Changes[0].Package != "complete" {
+		t.Errorf("Changes = %+v, want the complete package", summary.Changes)
+	}
+
+	text := FormatOverlaySummary(summary)
+	for _, want := range []string{"Packages: 3", "Pending updates: 1", "Missing Manifest: 1", "Uncommitted changes: 1 package(s)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("FormatOverlaySummary() missing %q:\n%s", want, text)
+		}
+	}
+}
@@ -18,18 +18,21 @@ type ValidationResult struct {
 // A valid overlay must have:
 // - profiles/ directory
 // - metadata/ directory
+//
+// A missing path or one that is not a directory returns an error matching
+// config.ErrOverlayPathNotFound.
 func ValidateOverlay(path string) (*ValidationResult, error) {
 	// Check if path exists
 	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("overlay path does not exist: %s", path)
+			return nil, fmt.Errorf("%w: %s", config.ErrOverlayPathNotFound, path)
 		}
 		return nil, fmt.Errorf("failed to access overlay path: %w", err)
 	}
 
 	if !info.IsDir() {
-		return nil, fmt.Errorf("overlay path is not a directory: %s", path)
+		return nil, fmt.Errorf("%w: %s is not a directory", config.ErrOverlayPathNotFound, path)
 	}
 
 	// Use the config package's validation function
@@ -42,6 +45,26 @@ func ValidateOverlay(path string) (*ValidationResult, error) {
 	}, nil
 }
 
+// RequireOverlay is ValidateOverlay as the guard every operation that writes
+// to the overlay runs first: it returns config.ErrOverlayPathNotSet for an
+// empty path, ValidateOverlay's error for a missing one, and an
+// *config.OverlayValidationError (matching config.ErrOverlayInvalidStructure)
+// when profiles/ or metadata/ is missing. A mistyped or half-configured path
+// then fails up front instead of leaving stray files in some other tree.
+func RequireOverlay(path string) error {
+	if path == "" {
+		return config.ErrOverlayPathNotSet
+	}
+	result, err := ValidateOverlay(path)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return &config.OverlayValidationError{Path: path, Errors: result.Errors}
+	}
+	return nil
+}
+
 // FormatValidationError formats a validation result into a user-friendly error message
 func FormatValidationError(result *ValidationResult, path string) string {
 	if result.Valid {
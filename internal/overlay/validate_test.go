@@ -1,6 +1,7 @@
 package overlay
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/obentoo/bentoolkit/internal/common/config"
 )
 
 // DirectoryConfig represents which directories exist in a test overlay
@@ -204,3 +206,36 @@ func TestFormatValidationError(t *testing.T) {
 		}
 	})
 }
+
+// TestRequireOverlay tests the error-returning guard used by mutating operations
+func TestRequireOverlay(t *testing.T) {
+	valid := t.TempDir()
+	for _, dir := range []string{"profiles", "metadata"} {
+		if err := os.MkdirAll(filepath.Join(valid, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s dir: %v", dir, err)
+		}
+	}
+	if err := RequireOverlay(valid); err != nil {
+		t.Errorf("RequireOverlay(valid overlay) = %v, want nil", err)
+	}
+
+	noProfiles := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(noProfiles, "metadata"), 0755); err != nil {
+		t.Fatalf("Failed to create metadata dir: %v", err)
+	}
+	err := RequireOverlay(noProfiles)
+	if !errors.Is(err, config.ErrOverlayInvalidStructure) {
+		t.Fatalf("RequireOverlay(missing profiles/) = %v, want ErrOverlayInvalidStructure", err)
+	}
+	var verr *config.OverlayValidationError
+	if !errors.As(err, &verr) || !strings.Contains(verr.Error(), "profiles/") {
+		t.Errorf("RequireOverlay(missing profiles/) = %v, want an OverlayValidationError naming profiles/", err)
+	}
+
+	if err := RequireOverlay(""); !errors.Is(err, config.ErrOverlayPathNotSet) {
+		t.Errorf("RequireOverlay(\"\") = %v, want ErrOverlayPathNotSet", err)
+	}
+	if err := RequireOverlay(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, config.ErrOverlayPathNotFound) {
+		t.Errorf("RequireOverlay(missing dir) = %v, want ErrOverlayPathNotFound", err)
+	}
+}
@@ -4,6 +4,7 @@ package overlay
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -92,3 +93,140 @@ func (d *VersionFilesDetector) scanFilesDir(filesDir, category, pkg, oldVersion
 func containsVersion(filename, version string) bool {
 	return strings.Contains(filename, version)
 }
+
+// DetectRevisionFiles fills in the Files of each revision-bump match: the
+// files in files/ whose name holds the old version with its revision
+// ("hello-1.0.0-r1-fix.patch"), renamed to the new revision. A file named
+// after the bare version serves every revision of it and is left alone, as
+// is everything when the old ebuild had no revision.
+func (d *VersionFilesDetector) DetectRevisionFiles(matches []RenameMatch) {
+	for i := range matches {
+		match := &matches[i]
+		if !match.RevisionBump || !match.HasRevision {
+			continue
+		}
+		oldVersion, _ := ebuildVersion(match.OldFilename, match.Package)
+		newVersion, _ := ebuildVersion(match.NewFilename, match.Package)
+
+		filesDir := filepath.Join(d.overlayPath, match.Category, match.Package, "files")
+		entries, err := os.ReadDir(filesDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			name := entry.Name()
+			at := revisionIndex(name, oldVersion)
+			if at < 0 {
+				continue
+			}
+			newName := name[:at] + newVersion + name[at+len(oldVersion):]
+			match.Files = append(match.Files, FileRename{
+				OldPath: filepath.Join(filesDir, name),
+				NewPath: filepath.Join(filesDir, newName),
+			})
+		}
+	}
+}
+
+// revisionIndex returns the index of version in filename, skipping an
+// occurrence followed by a digit so "1.0.0-r1" is not found in
+// "foo-1.0.0-r10.patch", or -1.
+func revisionIndex(filename, version string) int {
+	for from := 0; ; {
+		i := strings.Index(filename[from:], version)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		end := i + len(version)
+		if end == len(filename) || filename[end] < '0' || filename[end] > '9' {
+			return i
+		}
+		from = i + 1
+	}
+}
+
+// versionContinuesRegex matches what, right after an occurrence of a version
+// in a file name, makes it part of a longer version: more components
+// ("1.0.0.1"), a revision ("1.0.0-r1"), a Gentoo suffix ("1.0.0_rc1") or a
+// letter or digit ("1.0.0a", "1.0.01").
+var versionContinuesRegex = regexp.MustCompile(`^(\.[0-9]|-r[0-9]|_(alpha|beta|pre|rc|p)([0-9]|[^a-z]|$)|[0-9A-Za-z])`)
+
+// DetectVersionFileRenames fills in the Files of the rename matches with the
+// files in files/ named after oldVersion, each renamed with oldVersion
+// replaced by newVersion: "hello-1.0.0-fix.patch" becomes
+// "hello-2.0.0-fix.patch".
+//
+// Only whole-version occurrences count (see versionTokenIndexes), so a file
+// that merely contains the version inside a longer one, like
+// "hello-1.0.0.1.patch" or "hello-11.0.0.patch", is left alone, as are
+// subdirectories of files/ and their contents, which Detect does not look
+// into either. A package's files go with its first match only, since every
+// match of a package moves to the same new version.
+func (d *VersionFilesDetector) DetectVersionFileRenames(matches []RenameMatch, oldVersion, newVersion string) {
+	processed := make(map[string]bool)
+	for i := range matches {
+		match := &matches[i]
+		key := match.Category + "/" + match.Package
+		if processed[key] {
+			continue
+		}
+		processed[key] = true
+
+		filesDir := filepath.Join(d.overlayPath, match.Category, match.Package, "files")
+		entries, err := os.ReadDir(filesDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			name := entry.Name()
+			at := versionTokenIndexes(name, oldVersion)
+			if len(at) == 0 {
+				continue
+			}
+			var sb strings.Builder
+			prev := 0
+			for _, i := range at {
+				sb.WriteString(name[prev:i])
+				sb.WriteString(newVersion)
+				prev = i + len(oldVersion)
+			}
+			sb.WriteString(name[prev:])
+			match.Files = append(match.Files, FileRename{
+				OldPath: filepath.Join(filesDir, name),
+				NewPath: filepath.Join(filesDir, sb.String()),
+			})
+		}
+	}
+}
+
+// versionTokenIndexes returns the indexes at which version appears in
+// filename as a whole version: not preceded by a digit or dot, and not
+// continued into a longer version (versionContinuesRegex).
+func versionTokenIndexes(filename, version string) []int {
+	var indexes []int
+	for from := 0; from < len(filename); {
+		i := strings.Index(filename[from:], version)
+		if i < 0 {
+			break
+		}
+		i += from
+		from = i + 1
+		if i > 0 && (filename[i-1] == '.' || (filename[i-1] >= '0' && filename[i-1] <= '9')) {
+			continue
+		}
+		end := i + len(version)
+		if versionContinuesRegex.MatchString(filename[end:]) {
+			continue
+		}
+		indexes = append(indexes, i)
+		from = end
+	}
+	return indexes
+}